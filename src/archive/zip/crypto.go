@@ -0,0 +1,402 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zip
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// ErrPassword is returned when opening an encrypted file
+// without a password or with an incorrect password.
+var ErrPassword = errors.New("zip: invalid password")
+
+var errNoPassword = errors.New("zip: encryption requires a password")
+
+// An EncryptionMethod identifies how the contents of a file are encrypted.
+type EncryptionMethod uint8
+
+// Encryption methods.
+const (
+	NoEncryption EncryptionMethod = iota // not encrypted
+	ZipCrypto                            // traditional PKWARE encryption (reading only)
+	AES128                               // WinZip AES encryption with a 128-bit key
+	AES192                               // WinZip AES encryption with a 192-bit key
+	AES256                               // WinZip AES encryption with a 256-bit key
+)
+
+const (
+	// WinZip AES parameters.
+	// See https://www.winzip.com/en/support/aes-encryption/.
+	aesVersion1       = 1      // AE-1: the CRC-32 of the plaintext is stored
+	aesVersion2       = 2      // AE-2: the CRC-32 is not stored
+	aesVendorID       = 0x4541 // "AE"
+	aesIterations     = 1000
+	aesVerifierLen    = 2
+	aesAuthCodeLen    = 10
+	aesExtraFieldSize = 7
+
+	// zipCryptoHeaderLen is the length of the encryption header
+	// preceding the data of a traditional PKWARE encrypted file.
+	zipCryptoHeaderLen = 12
+)
+
+// aesKeyLen returns the key length in bytes of the AES encryption method m.
+// The salt length is half the key length.
+func aesKeyLen(m EncryptionMethod) int {
+	switch m {
+	case AES128:
+		return 16
+	case AES192:
+		return 24
+	case AES256:
+		return 32
+	}
+	return 0
+}
+
+// aesStrength returns the key strength recorded in the AES extra field.
+func aesStrength(m EncryptionMethod) uint8 {
+	return uint8(m - AES128 + 1)
+}
+
+// aesKeys derives the encryption key, authentication key and
+// password verification value from the password and salt.
+func aesKeys(password string, salt []byte, keyLen int) (key, authKey, verifier []byte, err error) {
+	k, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*keyLen+aesVerifierLen)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return k[:keyLen], k[keyLen : 2*keyLen], k[2*keyLen:], nil
+}
+
+// aesCTR implements the counter mode used by WinZip AES encryption.
+// Unlike [cipher.NewCTR], the counter is a little-endian integer
+// starting at one.
+type aesCTR struct {
+	block cipher.Block
+	ctr   [aes.BlockSize]byte
+	ks    [aes.BlockSize]byte // current key stream block
+	off   int                 // offset of the next unused byte in ks
+}
+
+func newAESCTR(key []byte) (*aesCTR, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aesCTR{block: block, off: aes.BlockSize}, nil
+}
+
+func (c *aesCTR) XORKeyStream(dst, src []byte) {
+	for len(src) > 0 {
+		if c.off == aes.BlockSize {
+			for i := range c.ctr {
+				c.ctr[i]++
+				if c.ctr[i] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.ks[:], c.ctr[:])
+			c.off = 0
+		}
+		n := subtle.XORBytes(dst, src, c.ks[c.off:])
+		c.off += n
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// decrypt returns a reader of the decrypted contents of the file data in r.
+// If the file is AES encrypted, the reader is an *aesReader.
+func (f *File) decrypt(r *io.SectionReader, password string) (io.Reader, error) {
+	switch f.Encryption {
+	case NoEncryption:
+		if f.Flags&0x1 != 0 {
+			// Encrypted using a method this package does not support,
+			// such as PKWARE strong encryption.
+			return nil, ErrAlgorithm
+		}
+		return r, nil
+	case ZipCrypto:
+		if password == "" {
+			return nil, ErrPassword
+		}
+		// The last byte of the encryption header is used to check the
+		// password. It is the high byte of the CRC-32, or of the
+		// modification time if the CRC-32 follows the file data.
+		check := byte(f.CRC32 >> 24)
+		if f.hasDataDescriptor() {
+			check = byte(f.ModifiedTime >> 8)
+		}
+		return newZipCryptoReader(r, password, check)
+	case AES128, AES192, AES256:
+		if password == "" {
+			return nil, ErrPassword
+		}
+		return newAESReader(r, password, aesKeyLen(f.Encryption))
+	}
+	return nil, ErrAlgorithm
+}
+
+// zipCryptoKeys holds the state of the traditional PKWARE stream cipher.
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decrypt(b []byte) {
+	for i, c := range b {
+		t := k[2] | 2
+		b[i] = c ^ byte(t*(t^1)>>8)
+		k.update(b[i])
+	}
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+// newZipCryptoReader reads and checks the encryption header at the start
+// of r and returns a reader of the remaining decrypted data.
+func newZipCryptoReader(r io.Reader, password string, check byte) (io.Reader, error) {
+	keys := newZipCryptoKeys(password)
+	var hdr [zipCryptoHeaderLen]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	keys.decrypt(hdr[:])
+	if hdr[zipCryptoHeaderLen-1] != check {
+		return nil, ErrPassword
+	}
+	return &zipCryptoReader{r: r, keys: keys}, nil
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.keys.decrypt(p[:n])
+	return n, err
+}
+
+// An aesReader decrypts the data of a WinZip AES encrypted file
+// and verifies its authentication code.
+type aesReader struct {
+	data     io.Reader // encrypted file data
+	authCode io.Reader // authentication code following data
+	ctr      *aesCTR
+	mac      hash.Hash
+	verified bool
+	err      error // result of verification
+}
+
+// newAESReader reads the salt and password verification value at the
+// start of r and returns a reader of the decrypted data.
+func newAESReader(r *io.SectionReader, password string, keyLen int) (io.Reader, error) {
+	saltLen := keyLen / 2
+	dataLen := r.Size() - int64(saltLen+aesVerifierLen+aesAuthCodeLen)
+	if dataLen < 0 {
+		return nil, ErrFormat
+	}
+	hdr := make([]byte, saltLen+aesVerifierLen)
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	key, authKey, verifier, err := aesKeys(password, hdr[:saltLen], keyLen)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(verifier, hdr[saltLen:]) != 1 {
+		return nil, ErrPassword
+	}
+	ctr, err := newAESCTR(key)
+	if err != nil {
+		return nil, err
+	}
+	off := int64(len(hdr))
+	return &aesReader{
+		data:     io.NewSectionReader(r, off, dataLen),
+		authCode: io.NewSectionReader(r, off+dataLen, aesAuthCodeLen),
+		ctr:      ctr,
+		mac:      hmac.New(sha1.New, authKey),
+	}, nil
+}
+
+func (r *aesReader) Read(p []byte) (int, error) {
+	if r.verified {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+	n, err := r.data.Read(p)
+	r.mac.Write(p[:n])
+	r.ctr.XORKeyStream(p[:n], p[:n])
+	if err == io.EOF {
+		if verr := r.verify(); verr != nil {
+			err = verr
+		}
+	}
+	return n, err
+}
+
+// finish consumes any data not yet read by the decompressor
+// and verifies the authentication code.
+func (r *aesReader) finish() error {
+	if r.verified {
+		return r.err
+	}
+	if _, err := io.Copy(r.mac, r.data); err != nil {
+		return err
+	}
+	return r.verify()
+}
+
+func (r *aesReader) verify() error {
+	if r.verified {
+		return r.err
+	}
+	r.verified = true
+	var code [aesAuthCodeLen]byte
+	if _, err := io.ReadFull(r.authCode, code[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = err
+	} else if !hmac.Equal(code[:], r.mac.Sum(nil)[:aesAuthCodeLen]) {
+		r.err = ErrChecksum
+	}
+	return r.err
+}
+
+// An aesWriter encrypts file data using WinZip AES encryption.
+// The salt and password verification value are written before the
+// first encrypted byte, and the authentication code is written by Close.
+type aesWriter struct {
+	w           io.Writer
+	hdr         []byte // salt and password verification value
+	wroteHeader bool
+	ctr         *aesCTR
+	mac         hash.Hash
+	buf         []byte
+}
+
+func newAESWriter(w io.Writer, password string, keyLen int) (*aesWriter, error) {
+	saltLen := keyLen / 2
+	hdr := make([]byte, saltLen, saltLen+aesVerifierLen)
+	rand.Read(hdr)
+	key, authKey, verifier, err := aesKeys(password, hdr, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	ctr, err := newAESCTR(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aesWriter{
+		w:   w,
+		hdr: append(hdr, verifier...),
+		ctr: ctr,
+		mac: hmac.New(sha1.New, authKey),
+	}, nil
+}
+
+func (w *aesWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	_, err := w.w.Write(w.hdr)
+	return err
+}
+
+func (w *aesWriter) Write(p []byte) (int, error) {
+	if err := w.writeHeader(); err != nil {
+		return 0, err
+	}
+	const chunk = 32 << 10
+	n := 0
+	for len(p) > 0 {
+		m := min(len(p), chunk)
+		w.buf = w.buf[:0]
+		w.buf = append(w.buf, p[:m]...)
+		w.ctr.XORKeyStream(w.buf, w.buf)
+		w.mac.Write(w.buf)
+		if _, err := w.w.Write(w.buf); err != nil {
+			return n, err
+		}
+		n += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+func (w *aesWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	_, err := w.w.Write(w.mac.Sum(nil)[:aesAuthCodeLen])
+	return err
+}
+
+// appendAESExtra returns a copy of extra with any existing AES extra field
+// replaced by one describing the encryption and compression method of fh.
+func appendAESExtra(extra []byte, fh *FileHeader, version uint16) []byte {
+	var out []byte
+	b := readBuf(extra)
+	for len(b) >= 4 {
+		field := b
+		tag := b.uint16()
+		size := int(b.uint16())
+		if len(b) < size {
+			b = field
+			break
+		}
+		b.sub(size)
+		if tag != aesExtraID {
+			out = append(out, field[:4+size]...)
+		}
+	}
+	out = append(out, b...)
+
+	var buf [4 + aesExtraFieldSize]byte
+	eb := writeBuf(buf[:])
+	eb.uint16(aesExtraID)
+	eb.uint16(aesExtraFieldSize)
+	eb.uint16(version)
+	eb.uint16(aesVendorID)
+	eb.uint8(aesStrength(fh.Encryption))
+	eb.uint16(fh.Method)
+	return append(out, buf[:]...)
+}
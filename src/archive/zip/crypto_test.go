@@ -0,0 +1,260 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadEncrypted(t *testing.T) {
+	tests := []struct {
+		zip        string
+		name       string
+		encryption EncryptionMethod
+		method     uint16
+		content    string
+	}{
+		{"crypto-zipcrypto.zip", "test.txt", ZipCrypto, Deflate, "This is a test text file.\n"},
+		{"crypto-zipcrypto-stream.zip", "-", ZipCrypto, Deflate, "This is a streamed test text file.\n"},
+		{"crypto-aes.zip", "aes128.txt", AES128, Store, "This is a test text file.\n"},
+		{"crypto-aes.zip", "aes256.txt", AES256, Deflate, strings.Repeat("Hello, gophers! ", 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zip+"/"+tt.name, func(t *testing.T) {
+			r, err := OpenReader("testdata/" + tt.zip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			var f *File
+			for _, ff := range r.File {
+				if ff.Name == tt.name {
+					f = ff
+				}
+			}
+			if f == nil {
+				t.Fatalf("file %q not found", tt.name)
+			}
+			if f.Encryption != tt.encryption {
+				t.Errorf("Encryption = %v, want %v", f.Encryption, tt.encryption)
+			}
+			if f.Method != tt.method {
+				t.Errorf("Method = %v, want %v", f.Method, tt.method)
+			}
+
+			if _, err := f.Open(); err != ErrPassword {
+				t.Errorf("Open without password: err = %v, want %v", err, ErrPassword)
+			}
+			r.SetPassword("not the password")
+			if _, err := f.Open(); err != ErrPassword {
+				t.Errorf("Open with wrong password: err = %v, want %v", err, ErrPassword)
+			}
+
+			r.SetPassword("gopher")
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.content {
+				t.Errorf("content = %q, want %q", b, tt.content)
+			}
+		})
+	}
+}
+
+func TestWriteEncrypted(t *testing.T) {
+	content := bytes.Repeat([]byte("Hello, gophers! "), 1000)
+	for _, enc := range []EncryptionMethod{AES128, AES192, AES256} {
+		for _, method := range []uint16{Store, Deflate} {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+			w.SetPassword("gopher")
+			fw, err := w.CreateHeader(&FileHeader{Name: "secret.txt", Method: method, Encryption: enc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fw.Write(content); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.CreateHeader(&FileHeader{Name: "dir/", Encryption: enc}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(buf.Bytes(), []byte("gophers")) {
+				t.Errorf("%v/%d: archive contains plaintext", enc, method)
+			}
+
+			r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := r.File[0]
+			if f.Encryption != enc || f.Method != method {
+				t.Errorf("%v/%d: read Encryption = %v, Method = %d", enc, method, f.Encryption, f.Method)
+			}
+			if r.File[1].Encryption != NoEncryption {
+				t.Errorf("%v/%d: directory Encryption = %v, want NoEncryption", enc, method, r.File[1].Encryption)
+			}
+			r.SetPassword("gopher")
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("%v/%d: %v", enc, method, err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("%v/%d: content mismatch", enc, method)
+			}
+
+			// Copying the raw file must preserve the encryption.
+			var buf2 bytes.Buffer
+			w2 := NewWriter(&buf2)
+			if err := w2.Copy(f); err != nil {
+				t.Fatal(err)
+			}
+			if err := w2.Close(); err != nil {
+				t.Fatal(err)
+			}
+			r2, err := NewReader(bytes.NewReader(buf2.Bytes()), int64(buf2.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r2.SetPassword("gopher")
+			rc, err = r2.File[0].Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err = io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("%v/%d: copy: %v", enc, method, err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("%v/%d: copy: content mismatch", enc, method)
+			}
+		}
+	}
+}
+
+func TestWriteEncryptedErrors(t *testing.T) {
+	w := NewWriter(io.Discard)
+	if _, err := w.CreateHeader(&FileHeader{Name: "a", Encryption: AES256}); err == nil {
+		t.Error("CreateHeader without password succeeded")
+	}
+	w.SetPassword("gopher")
+	if _, err := w.CreateHeader(&FileHeader{Name: "b", Encryption: ZipCrypto}); err != ErrAlgorithm {
+		t.Errorf("CreateHeader with ZipCrypto: err = %v, want %v", err, ErrAlgorithm)
+	}
+}
+
+func TestWriteEncryptedKeepsExtra(t *testing.T) {
+	// An unknown extra field, followed by a stale AES extra field.
+	extra := []byte{0xfe, 0xca, 1, 0, 42, 0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, 0, 0}
+	orig := bytes.Clone(extra)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetPassword("gopher")
+	// Headers made from the same template share its Extra.
+	template := FileHeader{Method: Deflate, Encryption: AES256, Extra: extra}
+	for _, name := range []string{"a", "b"} {
+		fh := template
+		fh.Name = name
+		fw, err := w.CreateHeader(&fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, name); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(fh.Extra, orig) || !bytes.Equal(extra, orig) {
+			t.Fatalf("CreateHeader changed Extra to %x, want %x", fh.Extra, orig)
+		}
+	}
+	raw := template
+	raw.Name = "c"
+	if _, err := w.CreateRaw(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw.Extra, orig) {
+		t.Errorf("CreateRaw changed Extra to %x, want %x", raw.Extra, orig)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetPassword("gopher")
+	for _, f := range r.File {
+		// The unknown field is kept, and the AES field written once.
+		if !bytes.HasPrefix(f.Extra, orig[:5]) || len(f.Extra) != 5+4+aesExtraFieldSize {
+			t.Errorf("%s: Extra = %x", f.Name, f.Extra)
+		}
+	}
+	for _, f := range r.File[:2] {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(rc)
+		if err != nil || string(got) != f.Name {
+			t.Errorf("%s: content %q, %v", f.Name, got, err)
+		}
+	}
+}
+
+func TestReadEncryptedTampered(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetPassword("gopher")
+	fw, err := w.CreateHeader(&FileHeader{Name: "secret.txt", Method: Store, Encryption: AES256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(fw, "attack at dawn")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	off, err := func() (int64, error) {
+		r, err := NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return 0, err
+		}
+		return r.File[0].DataOffset()
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a bit of the encrypted data, after the salt and password verifier.
+	b[off+16+2] ^= 1
+
+	r, err := NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetPassword("gopher")
+	rc, err := r.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(rc); !errors.Is(err, ErrChecksum) {
+		t.Errorf("reading tampered file: err = %v, want %v", err, ErrChecksum)
+	}
+}
@@ -38,6 +38,7 @@ type Reader struct {
 	File          []*File
 	Comment       string
 	decompressors map[uint16]Decompressor
+	password      string
 
 	// Some JAR files are zip files with a prefix that is a bash script.
 	// The baseOffset field is the start of the zip file proper.
@@ -62,8 +63,9 @@ type File struct {
 	FileHeader
 	zip          *Reader
 	zipr         io.ReaderAt
-	headerOffset int64 // includes overall ZIP archive baseOffset
-	zip64        bool  // zip64 extended information extra field presence
+	headerOffset int64  // includes overall ZIP archive baseOffset
+	zip64        bool   // zip64 extended information extra field presence
+	aesVersion   uint16 // WinZip AES vendor version, if AES encrypted
 }
 
 // OpenReader will open the Zip file specified by name and return a ReadCloser.
@@ -195,6 +197,13 @@ func (r *Reader) decompressor(method uint16) Decompressor {
 	return dcomp
 }
 
+// SetPassword sets the password used to decrypt encrypted files
+// opened with [File.Open] or [Reader.Open].
+// It must be called before any file is opened.
+func (r *Reader) SetPassword(password string) {
+	r.password = password
+}
+
 // Close closes the Zip file, rendering it unusable for I/O.
 func (rc *ReadCloser) Close() error {
 	return rc.f.Close()
@@ -215,6 +224,11 @@ func (f *File) DataOffset() (offset int64, err error) {
 
 // Open returns a [ReadCloser] that provides access to the [File]'s contents.
 // Multiple files may be read concurrently.
+//
+// Encrypted files are decrypted using the password set by [Reader.SetPassword].
+// If the password is missing or incorrect, Open returns [ErrPassword].
+// Files encrypted with WinZip AES are authenticated once all of their
+// contents have been read; a failed authentication is reported as [ErrChecksum].
 func (f *File) Open() (io.ReadCloser, error) {
 	bodyOffset, err := f.findBodyOffset()
 	if err != nil {
@@ -237,21 +251,27 @@ func (f *File) Open() (io.ReadCloser, error) {
 		}
 	}
 	size := int64(f.CompressedSize64)
-	r := io.NewSectionReader(f.zipr, f.headerOffset+bodyOffset, size)
 	dcomp := f.zip.decompressor(f.Method)
 	if dcomp == nil {
 		return nil, ErrAlgorithm
 	}
+	r, err := f.decrypt(io.NewSectionReader(f.zipr, f.headerOffset+bodyOffset, size), f.zip.password)
+	if err != nil {
+		return nil, err
+	}
 	var rc io.ReadCloser = dcomp(r)
 	var desr io.Reader
 	if f.hasDataDescriptor() {
 		desr = io.NewSectionReader(f.zipr, f.headerOffset+bodyOffset+size, dataDescriptorLen)
 	}
+	aes, _ := r.(*aesReader)
 	rc = &checksumReader{
-		rc:   rc,
-		hash: crc32.NewIEEE(),
-		f:    f,
-		desr: desr,
+		rc:      rc,
+		hash:    crc32.NewIEEE(),
+		f:       f,
+		desr:    desr,
+		aes:     aes,
+		skipCRC: f.aesVersion == aesVersion2,
 	}
 	return rc, nil
 }
@@ -280,12 +300,14 @@ func (r *dirReader) Close() error {
 }
 
 type checksumReader struct {
-	rc    io.ReadCloser
-	hash  hash.Hash32
-	nread uint64 // number of bytes read so far
-	f     *File
-	desr  io.Reader // if non-nil, where to read the data descriptor
-	err   error     // sticky error
+	rc      io.ReadCloser
+	hash    hash.Hash32
+	nread   uint64 // number of bytes read so far
+	f       *File
+	desr    io.Reader  // if non-nil, where to read the data descriptor
+	aes     *aesReader // if non-nil, authenticates the file data
+	skipCRC bool       // the CRC-32 is not stored (WinZip AE-2)
+	err     error      // sticky error
 }
 
 func (r *checksumReader) Stat() (fs.FileInfo, error) {
@@ -309,6 +331,12 @@ func (r *checksumReader) Read(b []byte) (n int, err error) {
 		if r.nread != r.f.UncompressedSize64 {
 			return 0, io.ErrUnexpectedEOF
 		}
+		if r.aes != nil {
+			if err1 := r.aes.finish(); err1 != nil {
+				r.err = err1
+				return n, err1
+			}
+		}
 		if r.desr != nil {
 			if err1 := readDataDescriptor(r.desr, r.f); err1 != nil {
 				if err1 == io.EOF {
@@ -316,7 +344,7 @@ func (r *checksumReader) Read(b []byte) (n int, err error) {
 				} else {
 					err = err1
 				}
-			} else if r.hash.Sum32() != r.f.CRC32 {
+			} else if !r.skipCRC && r.hash.Sum32() != r.f.CRC32 {
 				err = ErrChecksum
 			}
 		} else {
@@ -488,9 +516,29 @@ parseExtras:
 			}
 			ts := int64(fieldBuf.uint32()) // ModTime since Unix epoch
 			modified = time.Unix(ts, 0)
+		case aesExtraID:
+			if f.Method != methodAES || len(fieldBuf) < aesExtraFieldSize {
+				continue parseExtras
+			}
+			version := fieldBuf.uint16()
+			vendor := fieldBuf.uint16()
+			strength := fieldBuf.uint8()
+			method := fieldBuf.uint16()
+			if version != aesVersion1 && version != aesVersion2 || vendor != aesVendorID || strength < 1 || strength > 3 {
+				continue parseExtras
+			}
+			f.aesVersion = version
+			f.Encryption = AES128 + EncryptionMethod(strength-1)
+			f.Method = method
 		}
 	}
 
+	// The encrypted flag without a WinZip AES extra field denotes
+	// traditional PKWARE encryption, unless strong encryption is used.
+	if f.Flags&0x1 != 0 && f.Encryption == NoEncryption && f.Method != methodAES && f.Flags&0x40 == 0 {
+		f.Encryption = ZipCrypto
+	}
+
 	msdosModified := msDosTimeToTime(f.ModifiedDate, f.ModifiedTime)
 	f.Modified = msdosModified
 	if !modified.IsZero() {
@@ -9,6 +9,11 @@ See the [ZIP specification] for details.
 
 This package does not support disk spanning.
 
+Encrypted files can be read and written using WinZip AES encryption.
+Files using the traditional PKWARE encryption can only be read,
+and PKWARE strong encryption is not supported.
+See [EncryptionMethod].
+
 A note about ZIP64:
 
 To be backwards compatible the FileHeader has both 32 and 64 bit Size
@@ -58,6 +63,7 @@ const (
 	// Version numbers.
 	zipVersion20 = 20 // 2.0
 	zipVersion45 = 45 // 4.5 (reads and writes zip64 archives)
+	zipVersion51 = 51 // 5.1 (reads and writes AES encrypted files)
 
 	// Limits for non zip64 files.
 	uint16max = (1 << 16) - 1
@@ -77,6 +83,11 @@ const (
 	unixExtraID        = 0x000d // UNIX
 	extTimeExtraID     = 0x5455 // Extended timestamp
 	infoZipUnixExtraID = 0x5855 // Info-ZIP Unix extension
+	aesExtraID         = 0x9901 // WinZip AES encryption
+
+	// methodAES is the compression method recorded for WinZip AES encrypted
+	// files. The actual compression method is stored in the AES extra field.
+	methodAES = 99
 )
 
 // FileHeader describes a file within a ZIP file.
@@ -110,8 +121,22 @@ type FileHeader struct {
 	Flags          uint16
 
 	// Method is the compression method. If zero, Store is used.
+	//
+	// For AES encrypted files, Method is the compression method
+	// of the file contents rather than the WinZip AES marker method
+	// recorded in the archive.
 	Method uint16
 
+	// Encryption is the encryption method of the file contents.
+	//
+	// When reading, it is set according to the file's flags and extra fields,
+	// and the file is decrypted using the password given to [Reader.SetPassword].
+	//
+	// When writing with [Writer.CreateHeader], setting Encryption to [AES128],
+	// [AES192] or [AES256] encrypts the file using the password given to
+	// [Writer.SetPassword]. [ZipCrypto] is supported only for reading.
+	Encryption EncryptionMethod
+
 	// Modified is the modified time of the file.
 	//
 	// When reading, an extended timestamp is preferred over the legacy MS-DOS
@@ -346,6 +371,24 @@ func (h *FileHeader) hasDataDescriptor() bool {
 	return h.Flags&0x8 != 0
 }
 
+// isAES reports whether the file is encrypted using WinZip AES encryption.
+func (h *FileHeader) isAES() bool {
+	switch h.Encryption {
+	case AES128, AES192, AES256:
+		return true
+	}
+	return false
+}
+
+// storedMethod returns the compression method recorded in the archive
+// headers for the file.
+func (h *FileHeader) storedMethod() uint16 {
+	if h.isAES() {
+		return methodAES
+	}
+	return h.Method
+}
+
 func msdosModeToFileMode(m uint32) (mode fs.FileMode) {
 	if m&msdosDir != 0 {
 		mode = fs.ModeDir | 0777
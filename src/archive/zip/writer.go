@@ -29,6 +29,7 @@ type Writer struct {
 	closed      bool
 	compressors map[uint16]Compressor
 	comment     string
+	password    string
 
 	// testHookCloseSizeOffset if non-nil is called with the size
 	// of offset of the central directory at Close.
@@ -39,6 +40,16 @@ type header struct {
 	*FileHeader
 	offset uint64
 	raw    bool
+	extra  []byte // if not nil, written in place of FileHeader.Extra
+}
+
+// extraField returns the extra field written for the file: FileHeader.Extra,
+// unless the Writer built its own, so as not to modify that of the caller.
+func (h *header) extraField() *[]byte {
+	if h.extra != nil {
+		return &h.extra
+	}
+	return &h.Extra
 }
 
 // NewWriter returns a new [Writer] writing a zip file to w.
@@ -63,6 +74,12 @@ func (w *Writer) Flush() error {
 	return w.cw.w.(*bufio.Writer).Flush()
 }
 
+// SetPassword sets the password used to encrypt files whose
+// [FileHeader.Encryption] is set when passed to [Writer.CreateHeader].
+func (w *Writer) SetPassword(password string) {
+	w.password = password
+}
+
 // SetComment sets the end-of-central-directory comment field.
 // It can only be called before [Writer.Close].
 func (w *Writer) SetComment(comment string) error {
@@ -90,13 +107,14 @@ func (w *Writer) Close() error {
 	// write central directory
 	start := w.cw.count
 	for _, h := range w.dir {
+		extra := h.extraField()
 		var buf [directoryHeaderLen]byte
 		b := writeBuf(buf[:])
 		b.uint32(uint32(directoryHeaderSignature))
 		b.uint16(h.CreatorVersion)
 		b.uint16(h.ReaderVersion)
 		b.uint16(h.Flags)
-		b.uint16(h.Method)
+		b.uint16(h.storedMethod())
 		b.uint16(h.ModifiedTime)
 		b.uint16(h.ModifiedDate)
 		b.uint32(h.CRC32)
@@ -115,14 +133,14 @@ func (w *Writer) Close() error {
 			eb.uint64(h.UncompressedSize64)
 			eb.uint64(h.CompressedSize64)
 			eb.uint64(h.offset)
-			h.Extra = append(h.Extra, buf[:]...)
+			*extra = append(*extra, buf[:]...)
 		} else {
 			b.uint32(h.CompressedSize)
 			b.uint32(h.UncompressedSize)
 		}
 
 		b.uint16(uint16(len(h.Name)))
-		b.uint16(uint16(len(h.Extra)))
+		b.uint16(uint16(len(*extra)))
 		b.uint16(uint16(len(h.Comment)))
 		b = b[4:] // skip disk number start and internal file attr (2x uint16)
 		b.uint32(h.ExternalAttrs)
@@ -137,7 +155,7 @@ func (w *Writer) Close() error {
 		if _, err := io.WriteString(w.cw, h.Name); err != nil {
 			return err
 		}
-		if _, err := w.cw.Write(h.Extra); err != nil {
+		if _, err := w.cw.Write(*extra); err != nil {
 			return err
 		}
 		if _, err := io.WriteString(w.cw, h.Comment); err != nil {
@@ -270,10 +288,22 @@ func (w *Writer) prepare(fh *FileHeader) error {
 // This returns a [Writer] to which the file contents should be written.
 // The file's contents must be written to the io.Writer before the next
 // call to [Writer.Create], [Writer.CreateHeader], [Writer.CreateRaw], or [Writer.Close].
+//
+// If fh.Encryption is [AES128], [AES192] or [AES256], the file contents are
+// compressed using fh.Method and then encrypted using the password set by
+// [Writer.SetPassword], in the WinZip AE-2 format.
 func (w *Writer) CreateHeader(fh *FileHeader) (io.Writer, error) {
 	if err := w.prepare(fh); err != nil {
 		return nil, err
 	}
+	if fh.Encryption != NoEncryption && !strings.HasSuffix(fh.Name, "/") {
+		if !fh.isAES() {
+			return nil, ErrAlgorithm
+		}
+		if w.password == "" {
+			return nil, errNoPassword
+		}
+	}
 
 	// The ZIP format has a sad state of affairs regarding character encoding.
 	// Officially, the name and comment fields are supposed to be encoded
@@ -348,6 +378,10 @@ func (w *Writer) CreateHeader(fh *FileHeader) (io.Writer, error) {
 		fh.Method = Store
 		fh.Flags &^= 0x8 // we will not write a data descriptor
 
+		// Directories have no contents to encrypt.
+		fh.Encryption = NoEncryption
+		fh.Flags &^= 0x1
+
 		// Explicitly clear sizes as they have no meaning for directories.
 		fh.CompressedSize = 0
 		fh.CompressedSize64 = 0
@@ -367,8 +401,22 @@ func (w *Writer) CreateHeader(fh *FileHeader) (io.Writer, error) {
 		if comp == nil {
 			return nil, ErrAlgorithm
 		}
+		var cw io.Writer = fw.compCount
+		fh.Flags &^= 0x1
+		if fh.isAES() {
+			fh.Flags |= 0x1
+			fh.ReaderVersion = zipVersion51
+			fh.CRC32 = 0
+			h.extra = appendAESExtra(fh.Extra, fh, aesVersion2)
+			aw, err := newAESWriter(fw.compCount, w.password, aesKeyLen(fh.Encryption))
+			if err != nil {
+				return nil, err
+			}
+			fw.enc = aw
+			cw = aw
+		}
 		var err error
-		fw.comp, err = comp(fw.compCount)
+		fw.comp, err = comp(cw)
 		if err != nil {
 			return nil, err
 		}
@@ -390,7 +438,8 @@ func writeHeader(w io.Writer, h *header) error {
 	if len(h.Name) > maxUint16 {
 		return errLongName
 	}
-	if len(h.Extra) > maxUint16 {
+	extra := *h.extraField()
+	if len(extra) > maxUint16 {
 		return errLongExtra
 	}
 
@@ -399,7 +448,7 @@ func writeHeader(w io.Writer, h *header) error {
 	b.uint32(uint32(fileHeaderSignature))
 	b.uint16(h.ReaderVersion)
 	b.uint16(h.Flags)
-	b.uint16(h.Method)
+	b.uint16(h.storedMethod())
 	b.uint16(h.ModifiedTime)
 	b.uint16(h.ModifiedDate)
 	// In raw mode (caller does the compression), the values are either
@@ -417,14 +466,14 @@ func writeHeader(w io.Writer, h *header) error {
 		b.uint32(0) // uncompressed size
 	}
 	b.uint16(uint16(len(h.Name)))
-	b.uint16(uint16(len(h.Extra)))
+	b.uint16(uint16(len(extra)))
 	if _, err := w.Write(buf[:]); err != nil {
 		return err
 	}
 	if _, err := io.WriteString(w, h.Name); err != nil {
 		return err
 	}
-	_, err := w.Write(h.Extra)
+	_, err := w.Write(extra)
 	return err
 }
 
@@ -434,6 +483,7 @@ func writeHeader(w io.Writer, h *header) error {
 // [Writer.CreateHeader], [Writer.CreateRaw], or [Writer.Close].
 //
 // In contrast to [Writer.CreateHeader], the bytes passed to Writer are not compressed.
+// If fh.Encryption is set, the bytes must already be encrypted accordingly.
 //
 // CreateRaw's argument is stored in w. If the argument is a pointer to the embedded
 // [FileHeader] in a [File] obtained from a [Reader] created from in-memory data,
@@ -451,6 +501,14 @@ func (w *Writer) CreateRaw(fh *FileHeader) (io.Writer, error) {
 		offset:     uint64(w.cw.count),
 		raw:        true,
 	}
+	if fh.isAES() {
+		// The CRC-32 is only recorded in the AE-1 format.
+		version := uint16(aesVersion2)
+		if fh.CRC32 != 0 {
+			version = aesVersion1
+		}
+		h.extra = appendAESExtra(fh.Extra, fh, version)
+	}
 	w.dir = append(w.dir, h)
 	if err := writeHeader(w.cw, h); err != nil {
 		return nil, err
@@ -564,6 +622,7 @@ type fileWriter struct {
 	rawCount  *countWriter
 	comp      io.WriteCloser
 	compCount *countWriter
+	enc       io.WriteCloser // if non-nil, encrypts the compressed data
 	crc32     hash.Hash32
 	closed    bool
 }
@@ -590,17 +649,26 @@ func (w *fileWriter) close() error {
 	if err := w.comp.Close(); err != nil {
 		return err
 	}
+	if w.enc != nil {
+		if err := w.enc.Close(); err != nil {
+			return err
+		}
+	}
 
 	// update FileHeader
 	fh := w.header.FileHeader
-	fh.CRC32 = w.crc32.Sum32()
+	if w.enc == nil {
+		// The WinZip AE-2 format does not record the CRC-32,
+		// relying on the authentication code instead.
+		fh.CRC32 = w.crc32.Sum32()
+	}
 	fh.CompressedSize64 = uint64(w.compCount.count)
 	fh.UncompressedSize64 = uint64(w.rawCount.count)
 
 	if fh.isZip64() {
 		fh.CompressedSize = uint32max
 		fh.UncompressedSize = uint32max
-		fh.ReaderVersion = zipVersion45 // requires 4.5 - File uses ZIP64 format extensions
+		fh.ReaderVersion = max(fh.ReaderVersion, zipVersion45) // requires 4.5 - File uses ZIP64 format extensions
 	} else {
 		fh.CompressedSize = uint32(fh.CompressedSize64)
 		fh.UncompressedSize = uint32(fh.UncompressedSize64)
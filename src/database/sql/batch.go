@@ -0,0 +1,362 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Batch is a sequence of statements to execute together with
+// [DB.ExecBatch], [Conn.ExecBatch] or [Tx.ExecBatch]. The zero value is an
+// empty batch ready to use.
+type Batch struct {
+	stmts []batchStmt
+}
+
+type batchStmt struct {
+	query string
+	args  []any
+}
+
+// Queue appends a statement to the batch, with the arguments for its
+// placeholder parameters.
+func (b *Batch) Queue(query string, args ...any) {
+	b.stmts = append(b.stmts, batchStmt{query, args})
+}
+
+// Len returns the number of statements in the batch.
+func (b *Batch) Len() int {
+	return len(b.stmts)
+}
+
+// ExecBatch executes the statements of b, in order, on a single
+// connection, stopping at the first statement that fails. It returns the
+// results of the statements that succeeded, so that the failing statement
+// is the one at index len(results) in b. The statements do not run in a
+// transaction; use [Tx.ExecBatch] to execute them atomically.
+//
+// ExecBatch uses the driver's [driver.Batcher] implementation if it has
+// one, which typically executes the batch in a single round trip to the
+// database, and executes the statements one at a time otherwise.
+func (db *DB) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	if b.Len() == 0 {
+		return nil, nil
+	}
+	var res []Result
+	var err error
+	db.retry(func(strategy connReuseStrategy) error {
+		res, err = db.execBatch(ctx, b, strategy)
+		if len(res) > 0 {
+			// Statements ran: do not run them again on a new connection.
+			return nil
+		}
+		return err
+	})
+	return res, err
+}
+
+func (db *DB) execBatch(ctx context.Context, b *Batch, strategy connReuseStrategy) ([]Result, error) {
+	dc, err := db.conn(ctx, strategy)
+	if err != nil {
+		return nil, err
+	}
+	return db.execBatchDC(ctx, dc, dc.releaseConn, b)
+}
+
+// ExecBatch executes the statements of b on the connection, like
+// [DB.ExecBatch].
+func (c *Conn) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	if b.Len() == 0 {
+		return nil, nil
+	}
+	dc, release, err := c.grabConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.db.execBatchDC(ctx, dc, release, b)
+}
+
+// ExecBatch executes the statements of b in the transaction, like
+// [DB.ExecBatch].
+func (tx *Tx) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	if b.Len() == 0 {
+		return nil, nil
+	}
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tx.db.execBatchDC(ctx, dc, release, b)
+}
+
+func (db *DB) execBatchDC(ctx context.Context, dc *driverConn, release func(error), b *Batch) (res []Result, err error) {
+	defer func() {
+		release(err)
+	}()
+	return db.execBatchConn(ctx, dc, b.stmts)
+}
+
+// execBatchConn executes stmts on dc, which the caller holds.
+func (db *DB) execBatchConn(ctx context.Context, dc *driverConn, stmts []batchStmt) ([]Result, error) {
+	if batcher, ok := dc.ci.(driver.Batcher); ok {
+		var resi []driver.Result
+		var err error
+		withLock(dc, func() {
+			dstmts := make([]driver.BatchStatement, len(stmts))
+			for i, s := range stmts {
+				dstmts[i].Query = s.query
+				dstmts[i].Args, err = driverArgsConnLocked(dc.ci, nil, s.args)
+				if err != nil {
+					err = batchError(i, err)
+					return
+				}
+			}
+			resi, err = batcher.ExecBatch(ctx, dstmts)
+		})
+		if err != driver.ErrSkip {
+			res := make([]Result, len(resi))
+			for i, r := range resi {
+				res[i] = driverResult{dc, r}
+			}
+			if err != nil && !errors.As(err, new(*batchErr)) {
+				err = batchError(len(res), err)
+			}
+			return res, err
+		}
+	}
+
+	res := make([]Result, 0, len(stmts))
+	for i, s := range stmts {
+		r, err := db.execDC(ctx, dc, func(error) {}, s.query, s.args)
+		if err != nil {
+			return res, batchError(i, err)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// batchErr is the error of a statement of a batch.
+type batchErr struct {
+	i   int
+	err error
+}
+
+func batchError(i int, err error) error {
+	return &batchErr{i, err}
+}
+
+func (e *batchErr) Error() string {
+	return fmt.Sprintf("sql: batch statement %d: %v", e.i, e.err)
+}
+
+func (e *batchErr) Unwrap() error {
+	return e.err
+}
+
+// CopyOptions holds the options of [DB.CopyFrom] and [Tx.CopyFrom].
+type CopyOptions struct {
+	// BindStyle is the syntax of the parameters of the INSERT statements
+	// with which rows are inserted when the driver does not implement
+	// [driver.Copier].
+	BindStyle BindStyle
+
+	// BatchSize is the number of INSERT statements executed at once when
+	// the driver does not implement [driver.Copier] but implements
+	// [driver.Batcher]. If BatchSize <= 0, it is 1000.
+	BatchSize int
+}
+
+// CopyFrom inserts rows into the given columns of table, and returns the
+// number of rows inserted. The values of each row are in the order of
+// columns. CopyFrom inserts either all the rows or none of them. The table
+// and column names are not quoted. The rows must not use the database.
+//
+// CopyFrom uses the driver's [driver.Copier] implementation if it has one,
+// which typically loads the rows with a bulk protocol, and otherwise
+// inserts the rows in a transaction, with INSERT statements in batches if
+// the driver implements [driver.Batcher]. The opts may be nil.
+func (db *DB) CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq[[]any], opts *CopyOptions) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("sql: CopyFrom with no columns")
+	}
+	dc, err := db.conn(ctx, cachedOrNewConn)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copyDriver(ctx, dc, table, columns, rows)
+	if err != driver.ErrSkip {
+		dc.releaseConn(err)
+		return n, err
+	}
+	tx, err := db.beginDC(ctx, dc, dc.releaseConn, nil)
+	if err != nil {
+		return 0, err
+	}
+	n, err = tx.copyFrom(ctx, table, columns, rows, opts, false)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// CopyFrom inserts rows into the given columns of table in the
+// transaction, like [DB.CopyFrom]. If CopyFrom fails, some of the rows may
+// have been inserted, and the transaction should be rolled back.
+func (tx *Tx) CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq[[]any], opts *CopyOptions) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("sql: CopyFrom with no columns")
+	}
+	return tx.copyFrom(ctx, table, columns, rows, opts, true)
+}
+
+// copyFrom implements Tx.CopyFrom, trying the driver's driver.Copier first
+// if useCopier is set.
+func (tx *Tx) copyFrom(ctx context.Context, table string, columns []string, rows iter.Seq[[]any], opts *CopyOptions, useCopier bool) (n int64, err error) {
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		release(err)
+	}()
+	if useCopier {
+		n, err = copyDriver(ctx, dc, table, columns, rows)
+		if err != driver.ErrSkip {
+			return n, err
+		}
+	}
+	return tx.db.copyInsert(ctx, dc, table, columns, rows, opts)
+}
+
+// copyDriver loads rows with the driver.Copier of dc, or returns
+// driver.ErrSkip if the driver does not implement it.
+func copyDriver(ctx context.Context, dc *driverConn, table string, columns []string, rows iter.Seq[[]any]) (n int64, err error) {
+	copier, ok := dc.ci.(driver.Copier)
+	if !ok {
+		return 0, driver.ErrSkip
+	}
+	// The driver consumes the rows during CopyFrom, while dc is locked.
+	drows := func(yield func([]driver.Value, error) bool) {
+		i := 0
+		for row := range rows {
+			vals, err := copyRow(dc.ci, i, row, len(columns))
+			if !yield(vals, err) || err != nil {
+				return
+			}
+			i++
+		}
+	}
+	withLock(dc, func() {
+		n, err = copier.CopyFrom(ctx, table, columns, drows)
+	})
+	return n, err
+}
+
+// copyRow converts the ith row of CopyFrom to driver values.
+func copyRow(ci driver.Conn, i int, row []any, ncol int) ([]driver.Value, error) {
+	if len(row) != ncol {
+		return nil, fmt.Errorf("sql: CopyFrom row %d has %d values, want %d", i, len(row), ncol)
+	}
+	nvs, err := driverArgsConnLocked(ci, nil, row)
+	if err != nil {
+		return nil, fmt.Errorf("sql: CopyFrom row %d: %w", i, err)
+	}
+	if len(nvs) != ncol {
+		return nil, fmt.Errorf("sql: CopyFrom row %d has %d values after conversion, want %d", i, len(nvs), ncol)
+	}
+	vals := make([]driver.Value, len(nvs))
+	for j, nv := range nvs {
+		vals[j] = nv.Value
+	}
+	return vals, nil
+}
+
+// copyInsert inserts rows with INSERT statements on dc, which the caller
+// holds.
+func (db *DB) copyInsert(ctx context.Context, dc *driverConn, table string, columns []string, rows iter.Seq[[]any], opts *CopyOptions) (int64, error) {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+	var q strings.Builder
+	q.WriteString("INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (")
+	for i := range columns {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteString(opts.BindStyle.placeholder(i + 1))
+	}
+	q.WriteString(")")
+	query := q.String()
+
+	if _, ok := dc.ci.(driver.Batcher); ok {
+		size := opts.BatchSize
+		if size <= 0 {
+			size = 1000
+		}
+		var n int64
+		stmts := make([]batchStmt, 0, size)
+		flush := func() error {
+			res, err := db.execBatchConn(ctx, dc, stmts)
+			n += int64(len(res))
+			stmts = stmts[:0]
+			if be := (*batchErr)(nil); errors.As(err, &be) {
+				return fmt.Errorf("sql: CopyFrom row %d: %w", n, be.err)
+			}
+			return err
+		}
+		for row := range rows {
+			if len(row) != len(columns) {
+				return n, fmt.Errorf("sql: CopyFrom row %d has %d values, want %d", n+int64(len(stmts)), len(row), len(columns))
+			}
+			// The iterator may reuse row for the next one, so keep a copy
+			// until the batch runs.
+			stmts = append(stmts, batchStmt{query, slices.Clone(row)})
+			if len(stmts) == size {
+				if err := flush(); err != nil {
+					return n, err
+				}
+			}
+		}
+		if len(stmts) > 0 {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+		return n, nil
+	}
+
+	var si driver.Stmt
+	var err error
+	withLock(dc, func() {
+		si, err = ctxDriverPrepare(ctx, dc.ci, query)
+	})
+	if err != nil {
+		return 0, err
+	}
+	ds := &driverStmt{Locker: dc, si: si}
+	defer ds.Close()
+	var n int64
+	for row := range rows {
+		if len(row) != len(columns) {
+			return n, fmt.Errorf("sql: CopyFrom row %d has %d values, want %d", n, len(row), len(columns))
+		}
+		if _, err := resultFromStatement(ctx, dc.ci, ds, row...); err != nil {
+			return n, fmt.Errorf("sql: CopyFrom row %d: %w", n, err)
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,357 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// batchConnector is a logConnector whose connections prepare statements
+// and optionally implement driver.Batcher or driver.Copier.
+type batchConnector struct {
+	logConnector
+	batcher bool // whether connections implement driver.Batcher
+	copier  bool // whether connections implement driver.Copier
+}
+
+func (c *batchConnector) Connect(context.Context) (driver.Conn, error) {
+	bc := batchConn{&logConn{c: &c.logConnector}}
+	switch {
+	case c.batcher:
+		return &logBatchConn{bc}, nil
+	case c.copier:
+		return &logCopyConn{bc}, nil
+	}
+	return bc, nil
+}
+
+// batchConn is a logConn that prepares logStmts.
+type batchConn struct {
+	*logConn
+}
+
+func (lc batchConn) Prepare(query string) (driver.Stmt, error) {
+	return logStmt{lc.logConn, query}, nil
+}
+
+// logStmt is a prepared statement of a logConn, which logs its query and
+// arguments when executed.
+type logStmt struct {
+	lc    *logConn
+	query string
+}
+
+func (s logStmt) Close() error  { return nil }
+func (s logStmt) NumInput() int { return -1 }
+
+func (s logStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errors.New("logStmt: failed")
+	}
+	s.lc.c.add(fmt.Sprint(s.query, " ", args))
+	return driver.ResultNoRows, nil
+}
+
+func (s logStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("logStmt: Query not supported")
+}
+
+// logBatchConn is a batchConn implementing driver.Batcher.
+type logBatchConn struct {
+	batchConn
+}
+
+func (lc *logBatchConn) ExecBatch(ctx context.Context, stmts []driver.BatchStatement) ([]driver.Result, error) {
+	lc.c.add(fmt.Sprint("batch ", len(stmts)))
+	var res []driver.Result
+	for _, s := range stmts {
+		if len(s.Args) > 0 {
+			s.Query = fmt.Sprint(s.Query, " ", s.Args[0].Value)
+		}
+		r, err := lc.ExecContext(ctx, s.Query, s.Args)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// logCopyConn is a batchConn implementing driver.Copier. It returns
+// driver.ErrSkip for the table "skip".
+type logCopyConn struct {
+	batchConn
+}
+
+func (lc *logCopyConn) CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq2[[]driver.Value, error]) (int64, error) {
+	if table == "skip" {
+		return 0, driver.ErrSkip
+	}
+	var all [][]driver.Value
+	for row, err := range rows {
+		if err != nil {
+			return 0, err
+		}
+		all = append(all, row)
+	}
+	lc.c.add(fmt.Sprint("copy ", table, " ", columns, " ", all))
+	return int64(len(all)), nil
+}
+
+func TestExecBatch(t *testing.T) {
+	ctx := context.Background()
+	for _, batcher := range []bool{false, true} {
+		t.Run(fmt.Sprint("batcher=", batcher), func(t *testing.T) {
+			c := &batchConnector{batcher: batcher}
+			db := OpenDB(c)
+			defer db.Close()
+			want := func(log ...string) []string {
+				if batcher {
+					return append([]string{fmt.Sprint("batch ", len(log))}, log...)
+				}
+				return log
+			}
+
+			var b Batch
+			b.Queue("INSERT 1")
+			b.Queue("INSERT 2")
+			res, err := db.ExecBatch(ctx, &b)
+			if err != nil || len(res) != 2 {
+				t.Fatalf("ExecBatch = %d results, %v; want 2 results", len(res), err)
+			}
+			if got, want := c.take(), want("INSERT 1", "INSERT 2"); !slices.Equal(got, want) {
+				t.Errorf("ran %q, want %q", got, want)
+			}
+
+			b = Batch{}
+			b.Queue("INSERT 1")
+			b.Queue("fail")
+			b.Queue("INSERT 3")
+			res, err = db.ExecBatch(ctx, &b)
+			if err == nil || !strings.Contains(err.Error(), "batch statement 1") || len(res) != 1 {
+				t.Errorf("ExecBatch with a failing statement = %d results, %v; want 1 result and an error for statement 1", len(res), err)
+			}
+			got := c.take()
+			if batcher {
+				got = got[1:]
+			}
+			if want := []string{"INSERT 1"}; !slices.Equal(got, want) {
+				t.Errorf("ran %q, want %q", got, want)
+			}
+
+			b = Batch{}
+			b.Queue("INSERT 1")
+			b.Queue("INSERT 2", make(chan int))
+			// With a Batcher, the arguments are all converted before the
+			// batch runs.
+			wantRes, wantLog := 1, []string{"INSERT 1"}
+			if batcher {
+				wantRes, wantLog = 0, nil
+			}
+			res, err = db.ExecBatch(ctx, &b)
+			if err == nil || !strings.Contains(err.Error(), "batch statement 1") || len(res) != wantRes {
+				t.Errorf("ExecBatch with a bad argument = %d results, %v; want %d results and an error for statement 1", len(res), err, wantRes)
+			}
+			if got := c.take(); !slices.Equal(got, wantLog) {
+				t.Errorf("ran %q, want %q", got, wantLog)
+			}
+
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b = Batch{}
+			b.Queue("INSERT 1")
+			if _, err := tx.ExecBatch(ctx, &b); err != nil {
+				t.Fatal(err)
+			}
+			tx.Commit()
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if _, err := conn.ExecBatch(ctx, &b); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := c.take(), slices.Concat([]string{"BEGIN"}, want("INSERT 1"), []string{"COMMIT"}, want("INSERT 1")); !slices.Equal(got, want) {
+				t.Errorf("ran %q, want %q", got, want)
+			}
+			if res, err := db.ExecBatch(ctx, &Batch{}); res != nil || err != nil {
+				t.Errorf("ExecBatch of an empty batch = %v, %v", res, err)
+			}
+		})
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	rows := func(rows ...[]any) iter.Seq[[]any] {
+		return slices.Values(rows)
+	}
+	threeRows := rows([]any{1, "one"}, []any{2, "two"}, []any{3, "three"})
+	// reusedRows yields the same rows as threeRows in a single buffer.
+	reusedRows := func(yield func([]any) bool) {
+		row := make([]any, 2)
+		for i, s := range []string{"one", "two", "three"} {
+			row[0], row[1] = i+1, s
+			if !yield(row) {
+				return
+			}
+		}
+	}
+	insert := "INSERT INTO t (a, b) VALUES (?, ?)"
+	inserts := []string{insert + " [1 one]", insert + " [2 two]", insert + " [3 three]"}
+
+	tests := []struct {
+		name    string
+		c       *batchConnector
+		table   string
+		rows    iter.Seq[[]any]
+		opts    *CopyOptions
+		want    []string
+		wantErr string
+	}{{
+		name:  "insert",
+		c:     &batchConnector{},
+		table: "t",
+		rows:  threeRows,
+		want:  slices.Concat([]string{"BEGIN"}, inserts, []string{"COMMIT"}),
+	}, {
+		name:  "batch",
+		c:     &batchConnector{batcher: true},
+		table: "t",
+		rows:  threeRows,
+		opts:  &CopyOptions{BindStyle: BindDollar, BatchSize: 2},
+		want: []string{
+			"BEGIN",
+			"batch 2",
+			"INSERT INTO t (a, b) VALUES ($1, $2) 1",
+			"INSERT INTO t (a, b) VALUES ($1, $2) 2",
+			"batch 1",
+			"INSERT INTO t (a, b) VALUES ($1, $2) 3",
+			"COMMIT",
+		},
+	}, {
+		name:  "batch reused row",
+		c:     &batchConnector{batcher: true},
+		table: "t",
+		rows:  reusedRows,
+		opts:  &CopyOptions{BatchSize: 2},
+		want: []string{
+			"BEGIN",
+			"batch 2",
+			"INSERT INTO t (a, b) VALUES (?, ?) 1",
+			"INSERT INTO t (a, b) VALUES (?, ?) 2",
+			"batch 1",
+			"INSERT INTO t (a, b) VALUES (?, ?) 3",
+			"COMMIT",
+		},
+	}, {
+		name:  "copy",
+		c:     &batchConnector{copier: true},
+		table: "t",
+		rows:  threeRows,
+		want:  []string{"copy t [a b] [[1 one] [2 two] [3 three]]"},
+	}, {
+		name:  "copy reused row",
+		c:     &batchConnector{copier: true},
+		table: "t",
+		rows:  reusedRows,
+		want:  []string{"copy t [a b] [[1 one] [2 two] [3 three]]"},
+	}, {
+		name:  "copy skip",
+		c:     &batchConnector{copier: true},
+		table: "skip",
+		rows:  rows([]any{1, "one"}),
+		want:  []string{"BEGIN", "INSERT INTO skip (a, b) VALUES (?, ?) [1 one]", "COMMIT"},
+	}, {
+		name:    "insert short row",
+		c:       &batchConnector{},
+		table:   "t",
+		rows:    rows([]any{1, "one"}, []any{2}),
+		want:    []string{"BEGIN", inserts[0], "ROLLBACK"},
+		wantErr: "row 1 has 1 values, want 2",
+	}, {
+		name:    "insert failure",
+		c:       &batchConnector{},
+		table:   "fail",
+		rows:    threeRows,
+		want:    []string{"BEGIN", "ROLLBACK"},
+		wantErr: "row 0: logStmt: failed",
+	}, {
+		name:    "batch failure",
+		c:       &batchConnector{batcher: true},
+		table:   "fail",
+		rows:    threeRows,
+		opts:    &CopyOptions{BatchSize: 2},
+		want:    []string{"BEGIN", "batch 2", "ROLLBACK"},
+		wantErr: "row 0: logConn: failed",
+	}, {
+		name:    "copy short row",
+		c:       &batchConnector{copier: true},
+		table:   "t",
+		rows:    rows([]any{1, "one"}, []any{2}),
+		wantErr: "row 1 has 1 values, want 2",
+	}, {
+		name:    "copy bad value",
+		c:       &batchConnector{copier: true},
+		table:   "t",
+		rows:    rows([]any{make(chan int), "one"}),
+		wantErr: "row 0: sql: converting argument",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := OpenDB(tt.c)
+			defer db.Close()
+			n, err := db.CopyFrom(context.Background(), tt.table, []string{"a", "b"}, tt.rows, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CopyFrom error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || n != 3 && tt.table == "t" {
+				t.Errorf("CopyFrom = %d, %v; want 3 rows", n, err)
+			}
+			if got := tt.c.take(); !slices.Equal(got, tt.want) {
+				t.Errorf("ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTxCopyFrom(t *testing.T) {
+	ctx := context.Background()
+	c := &batchConnector{copier: true}
+	db := OpenDB(c)
+	defer db.Close()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := tx.CopyFrom(ctx, "t", []string{"a"}, slices.Values([][]any{{1}, {2}}), nil)
+	if err != nil || n != 2 {
+		t.Errorf("CopyFrom = %d, %v; want 2 rows", n, err)
+	}
+	n, err = tx.CopyFrom(ctx, "skip", []string{"a"}, slices.Values([][]any{{3}}), nil)
+	if err != nil || n != 1 {
+		t.Errorf("CopyFrom with a skipping driver = %d, %v; want 1 row", n, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"BEGIN", "copy t [a] [[1] [2]]", "INSERT INTO skip (a) VALUES (?) [3]", "COMMIT"}
+	if got := c.take(); !slices.Equal(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+	if _, err := db.CopyFrom(ctx, "t", nil, slices.Values([][]any{{1}}), nil); err == nil {
+		t.Error("CopyFrom with no columns succeeded")
+	}
+}
@@ -23,6 +23,9 @@
 // also allows queries to accept per-query options as a parameter by returning
 // [ErrRemoveArgument] from CheckNamedValue.
 //
+// To execute batches of statements or load rows in bulk efficiently,
+// implement [Batcher] and [Copier].
+//
 // If multiple result sets are supported, [Rows] should implement [RowsNextResultSet].
 // If the driver knows how to describe the types present in the returned result
 // it should implement the following interfaces: [RowsColumnTypeScanType],
@@ -41,6 +44,7 @@ package driver
 import (
 	"context"
 	"errors"
+	"iter"
 	"reflect"
 )
 
@@ -227,6 +231,47 @@ type QueryerContext interface {
 	QueryContext(ctx context.Context, query string, args []NamedValue) (Rows, error)
 }
 
+// BatchStatement is a statement of a batch executed by [Batcher].
+type BatchStatement struct {
+	Query string
+	Args  []NamedValue
+}
+
+// Batcher is an optional interface that may be implemented by a [Conn] to
+// execute several statements at once, typically in a single round trip to
+// the database.
+//
+// If a [Conn] does not implement Batcher, [database/sql.DB.ExecBatch]
+// executes the statements one at a time.
+//
+// ExecBatch executes the statements in order, stopping at the first that
+// fails. It returns the results of the statements that succeeded and the
+// error of the one that failed. ExecBatch may return [ErrSkip], without
+// executing any statement.
+//
+// ExecBatch must honor the context timeout and return when the context is canceled.
+type Batcher interface {
+	ExecBatch(ctx context.Context, stmts []BatchStatement) ([]Result, error)
+}
+
+// Copier is an optional interface that may be implemented by a [Conn] to
+// load rows in bulk, with a protocol such as the COPY statement of
+// PostgreSQL.
+//
+// If a [Conn] does not implement Copier, [database/sql.DB.CopyFrom] inserts
+// the rows with INSERT statements.
+//
+// CopyFrom inserts rows into the given columns of table, and returns the
+// number of rows inserted. The values of each row are in the order of
+// columns. If rows yields a non-nil error, CopyFrom must stop and return
+// that error. CopyFrom must insert either all the rows or none of them.
+// CopyFrom may return [ErrSkip], before it starts consuming rows.
+//
+// CopyFrom must honor the context timeout and return when the context is canceled.
+type Copier interface {
+	CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq2[[]Value, error]) (int64, error)
+}
+
 // Conn is a connection to a database. It is not used concurrently
 // by multiple goroutines.
 //
@@ -521,6 +566,28 @@ type Tx interface {
 	Rollback() error
 }
 
+// TxSavepointer may be implemented by [Tx] to support savepoints for
+// databases that name them differently from the SQL standard.
+//
+// If a Tx does not implement TxSavepointer, the sql package executes the
+// statements "SAVEPOINT name", "ROLLBACK TO SAVEPOINT name" and
+// "RELEASE SAVEPOINT name" in the transaction instead.
+type TxSavepointer interface {
+	// Savepoint creates a savepoint with the given name, replacing any
+	// savepoint with the same name.
+	Savepoint(ctx context.Context, name string) error
+
+	// RollbackToSavepoint undoes the effects of the statements executed
+	// since the savepoint with the given name was created, and destroys
+	// the savepoints created after it. The savepoint itself remains.
+	RollbackToSavepoint(ctx context.Context, name string) error
+
+	// ReleaseSavepoint destroys the savepoint with the given name, and
+	// those created after it, keeping the effects of the statements
+	// executed since.
+	ReleaseSavepoint(ctx context.Context, name string) error
+}
+
 // RowsAffected implements [Result] for an INSERT or UPDATE operation
 // which mutates a number of rows.
 type RowsAffected int64
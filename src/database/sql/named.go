@@ -0,0 +1,195 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BindStyle is the syntax of positional parameters in a query, which varies
+// between databases and drivers.
+type BindStyle int
+
+const (
+	// BindQuestion is the "?" syntax of MySQL and SQLite.
+	BindQuestion BindStyle = iota
+	// BindDollar is the "$1" syntax of PostgreSQL.
+	BindDollar
+	// BindColon is the ":1" syntax of Oracle.
+	BindColon
+	// BindAt is the "@p1" syntax of SQL Server.
+	BindAt
+)
+
+// ExpandNamed rewrites query, replacing each named parameter ":name" with a
+// positional parameter in the given style, and returns the resulting query
+// together with its arguments, in order, for drivers that do not support
+// [NamedArg].
+//
+// The values of the parameters are taken from arg, which is either a struct,
+// or a pointer to one, whose fields map to names as in [Rows.ScanStruct], or
+// a map with string keys. Names are matched ignoring case for structs and
+// exactly for maps. A parameter that occurs more than once is passed once
+// per occurrence for [BindQuestion], and once otherwise.
+//
+// Colons in string literals, quoted identifiers and comments, and double
+// colons as in the PostgreSQL cast "x::text", are left as they are.
+func ExpandNamed(style BindStyle, query string, arg any) (string, []any, error) {
+	if style < BindQuestion || style > BindAt {
+		return "", nil, errors.New("sql: ExpandNamed: invalid bind style")
+	}
+	lookup, err := namedLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		b       strings.Builder
+		args    []any
+		numbers map[string]int // the positions of the parameters, by key
+		missing []string
+	)
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := skipQuoted(query, i)
+			b.WriteString(query[i:j])
+			i = j
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				j = len(query) - i
+			}
+			b.WriteString(query[i : i+j])
+			i += j
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				j = len(query) - i
+			} else {
+				j += 4
+			}
+			b.WriteString(query[i : i+j])
+			i += j
+			continue
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			b.WriteString("::")
+			i += 2
+			continue
+		case c != ':' || i+1 >= len(query) || !isNameStart(query[i+1]):
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		// A named parameter.
+		j := i + 1
+		for j < len(query) && isNameByte(query[j]) {
+			j++
+		}
+		name := query[i+1 : j]
+		i = j
+		key, v, ok := lookup(name)
+		if !ok {
+			missing = append(missing, strconv.Quote(name))
+			continue
+		}
+		n, seen := numbers[key]
+		if !seen || style == BindQuestion {
+			args = append(args, v)
+			n = len(args)
+			if numbers == nil {
+				numbers = make(map[string]int)
+			}
+			numbers[key] = n
+		}
+		b.WriteString(style.placeholder(n))
+	}
+	if missing != nil {
+		return "", nil, fmt.Errorf("sql: ExpandNamed: no value for parameter(s) %s", strings.Join(missing, ", "))
+	}
+	return b.String(), args, nil
+}
+
+// placeholder returns the placeholder of the nth parameter, counting
+// from 1.
+func (style BindStyle) placeholder(n int) string {
+	switch style {
+	case BindDollar:
+		return "$" + strconv.Itoa(n)
+	case BindColon:
+		return ":" + strconv.Itoa(n)
+	case BindAt:
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// namedLookup returns a function looking up the values of named parameters
+// in arg. The function also returns a key, which is the same for all the
+// names that match the same value.
+func namedLookup(arg any) (func(name string) (key string, v any, ok bool), error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Struct:
+		fields := fieldsOf(v.Type())
+		return func(name string) (string, any, bool) {
+			key := strings.ToLower(name)
+			index, ok := fields[key]
+			if !ok {
+				return "", nil, false
+			}
+			fv, ok := fieldByIndex(v, index, false)
+			if !ok {
+				// A field of a nil embedded struct pointer.
+				return key, nil, true
+			}
+			return key, fv.Interface(), true
+		}, nil
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return func(name string) (string, any, bool) {
+			e := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !e.IsValid() {
+				return "", nil, false
+			}
+			return name, e.Interface(), true
+		}, nil
+	}
+	return nil, fmt.Errorf("sql: ExpandNamed: argument not a struct or a map with string keys: %T", arg)
+}
+
+// skipQuoted returns the index following the quoted string, identifier or
+// literal starting at query[i]. A doubled quote stands for itself.
+func skipQuoted(query string, i int) int {
+	q := query[i]
+	for j := i + 1; j < len(query); j++ {
+		if query[j] == q {
+			if j+1 < len(query) && query[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameByte(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandNamed(t *testing.T) {
+	type base struct {
+		ID int64
+	}
+	type params struct {
+		base
+		Name    string
+		Age     int    `db:"years"`
+		Ignored string `db:"-"`
+	}
+	p := params{base{7}, "Alice", 30, "x"}
+	m := map[string]any{"name": "Bob", "id": 8, "NAME": "other"}
+
+	tests := []struct {
+		style     BindStyle
+		query     string
+		arg       any
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			style:     BindQuestion,
+			query:     "SELECT * FROM t WHERE name = :name AND age > :years",
+			arg:       p,
+			wantQuery: "SELECT * FROM t WHERE name = ? AND age > ?",
+			wantArgs:  []any{"Alice", 30},
+		},
+		{
+			style:     BindDollar,
+			query:     "UPDATE t SET name = :Name WHERE id = :id OR parent = :ID",
+			arg:       &p,
+			wantQuery: "UPDATE t SET name = $1 WHERE id = $2 OR parent = $2",
+			wantArgs:  []any{"Alice", int64(7)},
+		},
+		{
+			style:     BindQuestion,
+			query:     "SELECT :id, :id",
+			arg:       p,
+			wantQuery: "SELECT ?, ?",
+			wantArgs:  []any{int64(7), int64(7)},
+		},
+		{
+			style:     BindColon,
+			query:     "SELECT :name, :NAME, :name",
+			arg:       m,
+			wantQuery: "SELECT :1, :2, :1",
+			wantArgs:  []any{"Bob", "other"},
+		},
+		{
+			style:     BindAt,
+			query:     "SELECT :id",
+			arg:       m,
+			wantQuery: "SELECT @p1",
+			wantArgs:  []any{8},
+		},
+		{
+			style:     BindDollar,
+			query:     `SELECT ':name', ":name", ` + "`:name`" + `, 'it''s :name', :id::text -- :name` + "\n" + `/* :name */ :name`,
+			arg:       m,
+			wantQuery: `SELECT ':name', ":name", ` + "`:name`" + `, 'it''s :name', $1::text -- :name` + "\n" + `/* :name */ $2`,
+			wantArgs:  []any{8, "Bob"},
+		},
+		{
+			style:     BindQuestion,
+			query:     "SELECT 1 WHERE a = ':' AND b = :1",
+			arg:       m,
+			wantQuery: "SELECT 1 WHERE a = ':' AND b = :1",
+		},
+	}
+	for _, tt := range tests {
+		q, args, err := ExpandNamed(tt.style, tt.query, tt.arg)
+		if err != nil {
+			t.Errorf("ExpandNamed(%v, %q): %v", tt.style, tt.query, err)
+			continue
+		}
+		if q != tt.wantQuery || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("ExpandNamed(%v, %q) = %q, %v; want %q, %v", tt.style, tt.query, q, args, tt.wantQuery, tt.wantArgs)
+		}
+	}
+}
+
+func TestExpandNamedErrors(t *testing.T) {
+	type params struct {
+		Name    string
+		Ignored string `db:"-"`
+	}
+	tests := []struct {
+		style BindStyle
+		query string
+		arg   any
+		want  string
+	}{
+		{BindAt + 1, "SELECT :name", params{}, "invalid bind style"},
+		{BindQuestion, "SELECT :name", 1, "argument not a struct or a map with string keys: int"},
+		{BindQuestion, "SELECT :name", map[int]any{}, "argument not a struct or a map with string keys"},
+		{BindQuestion, "SELECT :name", (*params)(nil), "argument not a struct or a map with string keys"},
+		{BindQuestion, "SELECT :name, :age, :ignored, :age", params{}, `no value for parameter(s) "age", "ignored", "age"`},
+		{BindQuestion, "SELECT :Name", map[string]any{"name": 1}, `no value for parameter(s) "Name"`},
+	}
+	for _, tt := range tests {
+		_, _, err := ExpandNamed(tt.style, tt.query, tt.arg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ExpandNamed(%v, %q, %T): got error %v, want %q", tt.style, tt.query, tt.arg, err, tt.want)
+		}
+	}
+}
+
+func TestExpandNamedQuery(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	q, args, err := ExpandNamed(BindQuestion, "SELECT|people|name|age=:age", map[string]any{"age": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := db.QueryRow(q, args...).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "Bob" {
+		t.Errorf("name = %q, want Bob", name)
+	}
+}
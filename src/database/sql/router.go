@@ -0,0 +1,448 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaPolicy selects the replica that a [Router] runs a read on.
+type ReplicaPolicy int
+
+const (
+	// RoundRobin selects the healthy replicas in turn.
+	RoundRobin ReplicaPolicy = iota
+
+	// LeastLag selects the healthy replica with the least replication lag,
+	// as measured by the function set with [Router.SetReplicaLagFunc], and
+	// the healthy replicas in turn among those with the same lag.
+	LeastLag
+)
+
+// defaultHealthCheckInterval is the default interval between the health
+// checks of the replicas of a Router.
+const defaultHealthCheckInterval = 5 * time.Second
+
+// A Router routes reads and writes between a primary database and its read
+// replicas, each of which is a [DB] with its own connection pool.
+//
+// Queries, statements and transactions run on the primary, except for
+// transactions begun with [TxOptions.ReadOnly] set, and queries,
+// statements and transactions whose context comes from [WithReplica], which
+// run on a replica selected with the router's [ReplicaPolicy]. Replicas are
+// checked periodically, and those whose check fails are skipped until it
+// succeeds again. When no replica is healthy, reads run on the primary.
+//
+// A Router is safe for concurrent use by multiple goroutines.
+type Router struct {
+	primary  *DB
+	replicas []*replica
+
+	next      atomic.Uint64 // the replica to start selecting from
+	fallbacks atomic.Int64  // the number of reads run on the primary
+
+	checkMu sync.Mutex // serializes health checks
+
+	mu       sync.Mutex // protects following fields
+	policy   ReplicaPolicy
+	maxLag   time.Duration
+	lagFunc  func(context.Context, *DB) (time.Duration, error)
+	interval time.Duration
+	closed   bool
+
+	reset chan struct{}   // signals the health checker to read its interval
+	ctx   context.Context // canceled by Close to stop the health checker
+	stop  context.CancelFunc
+	done  chan struct{} // closed by the health checker when it stops
+}
+
+// replica is a replica of a Router, and the result of its last health
+// check.
+type replica struct {
+	db       *DB
+	selected atomic.Int64
+
+	mu      sync.Mutex // protects following fields
+	healthy bool
+	lag     time.Duration
+	err     error
+	checked time.Time
+}
+
+// NewRouter returns a [Router] that routes writes to primary and reads to
+// replicas, and starts checking the health of the replicas.
+//
+// The router takes ownership of the databases: [Router.Close] closes them.
+// Replicas are considered healthy until their first check, which happens
+// right away.
+func NewRouter(primary *DB, replicas ...*DB) *Router {
+	r := &Router{
+		primary:  primary,
+		interval: defaultHealthCheckInterval,
+		reset:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	r.ctx, r.stop = context.WithCancel(context.Background())
+	for _, db := range replicas {
+		r.replicas = append(r.replicas, &replica{db: db, healthy: true})
+	}
+	if len(r.replicas) == 0 {
+		close(r.done)
+		return r
+	}
+	go r.healthChecker()
+	return r
+}
+
+// SetReplicaPolicy sets the policy selecting the replica that reads run on.
+// The default is [RoundRobin].
+func (r *Router) SetReplicaPolicy(policy ReplicaPolicy) {
+	r.mu.Lock()
+	r.policy = policy
+	r.mu.Unlock()
+}
+
+// SetReplicaLagFunc sets the function that the health checks of the
+// replicas call to measure their replication lag, typically by querying the
+// replica. A replica whose lag function returns an error is unhealthy.
+//
+// If f is nil, which is the default, the health checks ping the replicas
+// instead and consider their lag to be zero.
+func (r *Router) SetReplicaLagFunc(f func(ctx context.Context, replica *DB) (time.Duration, error)) {
+	r.mu.Lock()
+	r.lagFunc = f
+	r.mu.Unlock()
+}
+
+// SetMaxReplicaLag sets the maximum replication lag of a healthy replica.
+//
+// If d <= 0, which is the default, replicas are healthy whatever their
+// lag.
+func (r *Router) SetMaxReplicaLag(d time.Duration) {
+	r.mu.Lock()
+	r.maxLag = d
+	r.mu.Unlock()
+}
+
+// SetHealthCheckInterval sets the interval between the health checks of
+// the replicas, which also bounds the duration of each check. The default
+// is 5 seconds.
+//
+// If d <= 0, the replicas are only checked by [Router.CheckReplicas].
+func (r *Router) SetHealthCheckInterval(d time.Duration) {
+	r.mu.Lock()
+	r.interval = d
+	r.mu.Unlock()
+	select {
+	case r.reset <- struct{}{}:
+	default:
+	}
+}
+
+// healthChecker checks the health of the replicas every interval, until
+// the router is closed.
+func (r *Router) healthChecker() {
+	defer close(r.done)
+	r.mu.Lock()
+	d := r.interval
+	r.mu.Unlock()
+	if d > 0 {
+		r.checkReplicas(r.ctx, d)
+	}
+	t := time.NewTimer(0)
+	t.Stop()
+	for {
+		r.mu.Lock()
+		d := r.interval
+		r.mu.Unlock()
+		if d > 0 {
+			t.Reset(d)
+		}
+		select {
+		case <-r.ctx.Done():
+			t.Stop()
+			return
+		case <-r.reset:
+			t.Stop()
+		case <-t.C:
+			r.checkReplicas(r.ctx, d)
+		}
+	}
+}
+
+// CheckReplicas checks the health of the replicas now, rather than waiting
+// for their next periodic check.
+func (r *Router) CheckReplicas(ctx context.Context) {
+	r.checkReplicas(ctx, 0)
+}
+
+// checkReplicas checks the health of the replicas concurrently, limiting
+// each check to timeout if it is positive.
+func (r *Router) checkReplicas(ctx context.Context, timeout time.Duration) {
+	// Checks do not overlap, so that the result of an earlier check never
+	// replaces that of a later one.
+	r.checkMu.Lock()
+	defer r.checkMu.Unlock()
+
+	r.mu.Lock()
+	lagFunc, maxLag := r.lagFunc, r.maxLag
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Go(func() {
+			ctx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			var lag time.Duration
+			var err error
+			if lagFunc != nil {
+				lag, err = lagFunc(ctx, rep.db)
+			} else {
+				err = rep.db.PingContext(ctx)
+			}
+			rep.mu.Lock()
+			defer rep.mu.Unlock()
+			rep.healthy = err == nil && (maxLag <= 0 || lag <= maxLag)
+			rep.lag, rep.err, rep.checked = lag, err, time.Now()
+		})
+	}
+	wg.Wait()
+}
+
+// Primary returns the primary database.
+func (r *Router) Primary() *DB {
+	return r.primary
+}
+
+// Replica returns the replica that the next read would run on, or the
+// primary if no replica is healthy.
+func (r *Router) Replica() *DB {
+	r.mu.Lock()
+	policy := r.policy
+	r.mu.Unlock()
+
+	n := len(r.replicas)
+	start := int(r.next.Add(1) % uint64(max(n, 1)))
+	var best *replica
+	var bestLag time.Duration
+	for i := range n {
+		rep := r.replicas[(start+i)%n]
+		rep.mu.Lock()
+		healthy, lag := rep.healthy, rep.lag
+		rep.mu.Unlock()
+		if !healthy {
+			continue
+		}
+		if policy == RoundRobin {
+			best = rep
+			break
+		}
+		if best == nil || lag < bestLag {
+			best, bestLag = rep, lag
+		}
+	}
+	if best == nil {
+		r.fallbacks.Add(1)
+		return r.primary
+	}
+	best.selected.Add(1)
+	return best.db
+}
+
+type replicaKey struct{}
+
+// WithReplica returns a copy of ctx that marks the queries, statements and
+// transactions of a [Router] that use it as reads, to run on a replica.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey{}, true)
+}
+
+// dbFor returns the database that an operation with ctx runs on.
+func (r *Router) dbFor(ctx context.Context) *DB {
+	if ctx.Value(replicaKey{}) != nil {
+		return r.Replica()
+	}
+	return r.primary
+}
+
+// PingContext verifies that the connection to the primary is still alive,
+// establishing a connection if necessary.
+func (r *Router) PingContext(ctx context.Context) error {
+	return r.primary.PingContext(ctx)
+}
+
+// Ping verifies that the connection to the primary is still alive,
+// establishing a connection if necessary.
+//
+// Ping uses [context.Background] internally; to specify the context, use
+// [Router.PingContext].
+func (r *Router) Ping() error {
+	return r.PingContext(context.Background())
+}
+
+// ExecContext executes a query without returning any rows, on the primary.
+// The args are for any placeholder parameters in the query.
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// Exec executes a query without returning any rows, on the primary.
+// The args are for any placeholder parameters in the query.
+//
+// Exec uses [context.Background] internally; to specify the context, use
+// [Router.ExecContext].
+func (r *Router) Exec(query string, args ...any) (Result, error) {
+	return r.ExecContext(context.Background(), query, args...)
+}
+
+// ExecBatch executes the statements of b on the primary, like
+// [DB.ExecBatch].
+func (r *Router) ExecBatch(ctx context.Context, b *Batch) ([]Result, error) {
+	return r.primary.ExecBatch(ctx, b)
+}
+
+// CopyFrom inserts rows into the given columns of table on the primary,
+// like [DB.CopyFrom].
+func (r *Router) CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq[[]any], opts *CopyOptions) (int64, error) {
+	return r.primary.CopyFrom(ctx, table, columns, rows, opts)
+}
+
+// QueryContext executes a query that returns rows, typically a SELECT, on
+// a replica if ctx comes from [WithReplica] and on the primary otherwise.
+// The args are for any placeholder parameters in the query.
+func (r *Router) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	return r.dbFor(ctx).QueryContext(ctx, query, args...)
+}
+
+// Query executes a query that returns rows, typically a SELECT, on the
+// primary. The args are for any placeholder parameters in the query.
+//
+// Query uses [context.Background] internally; to specify the context, use
+// [Router.QueryContext].
+func (r *Router) Query(query string, args ...any) (*Rows, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one
+// row, on a replica if ctx comes from [WithReplica] and on the primary
+// otherwise. It behaves like [DB.QueryRowContext].
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return r.dbFor(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// QueryRow executes a query that is expected to return at most one row, on
+// the primary. It behaves like [DB.QueryRow].
+//
+// QueryRow uses [context.Background] internally; to specify the context,
+// use [Router.QueryRowContext].
+func (r *Router) QueryRow(query string, args ...any) *Row {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext creates a prepared statement, on a replica if ctx comes
+// from [WithReplica] and on the primary otherwise. The statement runs on
+// the database it was prepared on.
+func (r *Router) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	return r.dbFor(ctx).PrepareContext(ctx, query)
+}
+
+// Prepare creates a prepared statement on the primary.
+//
+// Prepare uses [context.Background] internally; to specify the context, use
+// [Router.PrepareContext].
+func (r *Router) Prepare(query string) (*Stmt, error) {
+	return r.PrepareContext(context.Background(), query)
+}
+
+// BeginTx starts a transaction, on a replica if opts.ReadOnly is set or
+// ctx comes from [WithReplica], and on the primary otherwise. It behaves
+// like [DB.BeginTx].
+func (r *Router) BeginTx(ctx context.Context, opts *TxOptions) (*Tx, error) {
+	db := r.primary
+	if opts != nil && opts.ReadOnly || ctx.Value(replicaKey{}) != nil {
+		db = r.Replica()
+	}
+	return db.BeginTx(ctx, opts)
+}
+
+// Begin starts a transaction on the primary.
+//
+// Begin uses [context.Background] internally; to specify the context and
+// options, use [Router.BeginTx].
+func (r *Router) Begin() (*Tx, error) {
+	return r.BeginTx(context.Background(), nil)
+}
+
+// RouterStats contains the statistics of a [Router] and its databases.
+type RouterStats struct {
+	Primary  DBStats
+	Replicas []ReplicaStats
+
+	// The number of reads run on the primary as no replica was healthy.
+	PrimaryFallbacks int64
+}
+
+// ReplicaStats contains the statistics of a replica of a [Router].
+type ReplicaStats struct {
+	DBStats
+
+	Selected int64 // The number of reads selecting the replica.
+
+	// Health
+	Healthy     bool          // Whether the replica is selected for reads.
+	Lag         time.Duration // The replication lag measured by the last check.
+	Err         error         // The error of the last check, if it failed.
+	LastChecked time.Time     // The time of the last check, zero before it.
+}
+
+// Stats returns the statistics of the router, its primary and its replicas,
+// in the order given to [NewRouter].
+func (r *Router) Stats() RouterStats {
+	stats := RouterStats{
+		Primary:          r.primary.Stats(),
+		Replicas:         make([]ReplicaStats, len(r.replicas)),
+		PrimaryFallbacks: r.fallbacks.Load(),
+	}
+	for i, rep := range r.replicas {
+		s := ReplicaStats{
+			DBStats:  rep.db.Stats(),
+			Selected: rep.selected.Load(),
+		}
+		rep.mu.Lock()
+		s.Healthy, s.Lag, s.Err, s.LastChecked = rep.healthy, rep.lag, rep.err, rep.checked
+		rep.mu.Unlock()
+		stats.Replicas[i] = s
+	}
+	return stats
+}
+
+// Close stops the health checks of the replicas and closes the primary and
+// the replicas.
+func (r *Router) Close() error {
+	r.mu.Lock()
+	closed := r.closed
+	r.closed = true
+	r.mu.Unlock()
+	if closed {
+		return nil
+	}
+	r.stop()
+	<-r.done
+
+	errs := []error{r.primary.Close()}
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+	return errors.Join(errs...)
+}
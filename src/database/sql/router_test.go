@@ -0,0 +1,252 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// routerConnector is a logConnector whose connections also log queries and
+// read-only transactions.
+type routerConnector struct {
+	logConnector
+}
+
+func (c *routerConnector) Connect(context.Context) (driver.Conn, error) {
+	return routerConn{&logConn{c: &c.logConnector}}, nil
+}
+
+type routerConn struct {
+	*logConn
+}
+
+func (lc routerConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	lc.c.add(query)
+	return routerRows{}, nil
+}
+
+func (lc routerConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		lc.c.add("BEGIN READ ONLY")
+		return logTx{lc.c}, nil
+	}
+	return lc.Begin()
+}
+
+// routerRows is an empty result set.
+type routerRows struct{}
+
+func (routerRows) Columns() []string              { return nil }
+func (routerRows) Close() error                   { return nil }
+func (routerRows) Next(dest []driver.Value) error { return io.EOF }
+
+// newTestRouter returns a router over a primary and n replicas whose
+// connections log their statements, with periodic health checks disabled.
+func newTestRouter(t *testing.T, n int) (*Router, *routerConnector, []*routerConnector) {
+	primary := &routerConnector{}
+	var replicas []*routerConnector
+	var dbs []*DB
+	for range n {
+		c := &routerConnector{}
+		replicas = append(replicas, c)
+		dbs = append(dbs, OpenDB(c))
+	}
+	r := NewRouter(OpenDB(primary), dbs...)
+	r.SetHealthCheckInterval(0)
+	t.Cleanup(func() {
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r, primary, replicas
+}
+
+func TestRouterRouting(t *testing.T) {
+	r, primary, replicas := newTestRouter(t, 2)
+	ctx := context.Background()
+	rctx := WithReplica(ctx)
+
+	mustExec := func(ctx context.Context, query string) {
+		t.Helper()
+		if _, err := r.ExecContext(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustQuery := func(ctx context.Context, query string) {
+		t.Helper()
+		rows, err := r.QueryContext(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	mustExec(ctx, "INSERT 1")
+	mustExec(rctx, "INSERT 2") // writes always run on the primary
+	mustQuery(ctx, "SELECT 1")
+	mustQuery(rctx, "SELECT 2")
+	mustQuery(rctx, "SELECT 3")
+	mustQuery(rctx, "SELECT 4")
+	r.QueryRowContext(rctx, "SELECT 5").Scan()
+
+	tx, err := r.BeginTx(ctx, &TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	tx, err = r.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+
+	if got, want := primary.take(), []string{"INSERT 1", "INSERT 2", "SELECT 1", "BEGIN", "COMMIT"}; !slices.Equal(got, want) {
+		t.Errorf("primary ran %q, want %q", got, want)
+	}
+	// Reads alternate between the replicas.
+	got := [][]string{replicas[0].take(), replicas[1].take()}
+	want := [][]string{{"SELECT 2", "SELECT 4", "BEGIN READ ONLY", "COMMIT"}, {"SELECT 3", "SELECT 5"}}
+	if !slices.Equal(got[0], want[0]) || !slices.Equal(got[1], want[1]) {
+		want[0], want[1] = want[1], want[0]
+		if !slices.Equal(got[0], want[0]) || !slices.Equal(got[1], want[1]) {
+			t.Errorf("replicas ran %q, want %q in some order", got, want)
+		}
+	}
+
+	stats := r.Stats()
+	if n := stats.Replicas[0].Selected + stats.Replicas[1].Selected; n != 5 {
+		t.Errorf("replicas selected %d times, want 5", n)
+	}
+	if stats.PrimaryFallbacks != 0 {
+		t.Errorf("PrimaryFallbacks = %d, want 0", stats.PrimaryFallbacks)
+	}
+	if stats.Primary.OpenConnections != 1 || stats.Replicas[0].OpenConnections != 1 {
+		t.Errorf("got %+v, want one open connection per database", stats)
+	}
+}
+
+func TestRouterHealth(t *testing.T) {
+	r, primary, replicas := newTestRouter(t, 3)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	lags := map[*DB]time.Duration{}
+	errBroken := errors.New("broken")
+	dbs := make([]*DB, 3)
+	for i := range dbs {
+		dbs[i] = r.replicas[i].db
+	}
+	r.SetReplicaLagFunc(func(ctx context.Context, db *DB) (time.Duration, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		lag, ok := lags[db]
+		if !ok {
+			return 0, errBroken
+		}
+		return lag, nil
+	})
+	r.SetMaxReplicaLag(time.Minute)
+	r.SetReplicaPolicy(LeastLag)
+
+	read := func() []string {
+		t.Helper()
+		rows, err := r.QueryContext(WithReplica(ctx), "SELECT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		var ran []string
+		for i, c := range replicas {
+			if c.take() != nil {
+				ran = append(ran, "replica"+string(rune('0'+i)))
+			}
+		}
+		if primary.take() != nil {
+			ran = append(ran, "primary")
+		}
+		return ran
+	}
+
+	mu.Lock()
+	lags[dbs[0]] = 2 * time.Second
+	lags[dbs[1]] = time.Second
+	lags[dbs[2]] = time.Hour // too far behind
+	mu.Unlock()
+	r.CheckReplicas(ctx)
+	for range 3 {
+		if got := read(); !slices.Equal(got, []string{"replica1"}) {
+			t.Fatalf("read ran on %q, want replica1, the least lagging", got)
+		}
+	}
+
+	mu.Lock()
+	delete(lags, dbs[1])
+	mu.Unlock()
+	r.CheckReplicas(ctx)
+	if got := read(); !slices.Equal(got, []string{"replica0"}) {
+		t.Errorf("read ran on %q, want replica0 with replica1 broken", got)
+	}
+	stats := r.Stats()
+	if s := stats.Replicas[1]; s.Healthy || s.Err != errBroken || s.LastChecked.IsZero() {
+		t.Errorf("replica1 stats = %+v, want unhealthy with its error", s)
+	}
+	if s := stats.Replicas[2]; s.Healthy || s.Err != nil || s.Lag != time.Hour {
+		t.Errorf("replica2 stats = %+v, want unhealthy with its lag", s)
+	}
+
+	// With no healthy replica, reads fall back to the primary.
+	mu.Lock()
+	clear(lags)
+	mu.Unlock()
+	r.CheckReplicas(ctx)
+	if got := read(); !slices.Equal(got, []string{"primary"}) {
+		t.Errorf("read ran on %q, want primary", got)
+	}
+	if n := r.Stats().PrimaryFallbacks; n != 1 {
+		t.Errorf("PrimaryFallbacks = %d, want 1", n)
+	}
+}
+
+func TestRouterPeriodicHealthCheck(t *testing.T) {
+	r, _, _ := newTestRouter(t, 1)
+	checked := make(chan bool, 1)
+	r.SetReplicaLagFunc(func(ctx context.Context, db *DB) (time.Duration, error) {
+		select {
+		case checked <- true:
+		default:
+		}
+		return 0, errors.New("broken")
+	})
+	r.SetHealthCheckInterval(time.Millisecond)
+	<-checked
+	if !waitCondition(t, func() bool { return !r.Stats().Replicas[0].Healthy }) {
+		t.Fatal("replica still healthy after failed checks")
+	}
+	if r.Replica() != r.Primary() {
+		t.Error("Replica did not fall back to the primary")
+	}
+}
+
+func TestRouterClose(t *testing.T) {
+	r := NewRouter(OpenDB(&routerConnector{}), OpenDB(&routerConnector{}))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if err := r.Ping(); err == nil {
+		t.Error("Ping after Close succeeded")
+	}
+	if _, err := r.QueryContext(WithReplica(context.Background()), "SELECT"); err == nil {
+		t.Error("read after Close succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// Savepoint creates a savepoint with the given name in the transaction,
+// replacing any savepoint with the same name. The name must be an
+// identifier: a letter or underscore followed by letters, digits and
+// underscores.
+//
+// Savepoints use the driver's [driver.TxSavepointer] implementation if it
+// has one, and the SQL statement "SAVEPOINT name" otherwise.
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	return tx.savepointOp(ctx, name, "SAVEPOINT ", driver.TxSavepointer.Savepoint)
+}
+
+// RollbackTo undoes the effects of the statements executed in the
+// transaction since the savepoint with the given name was created, and
+// destroys the savepoints created after it. The savepoint itself remains,
+// so that it can be rolled back to again.
+func (tx *Tx) RollbackTo(ctx context.Context, name string) error {
+	return tx.savepointOp(ctx, name, "ROLLBACK TO SAVEPOINT ", driver.TxSavepointer.RollbackToSavepoint)
+}
+
+// Release destroys the savepoint with the given name, and those created
+// after it, keeping the effects of the statements executed since.
+func (tx *Tx) Release(ctx context.Context, name string) error {
+	return tx.savepointOp(ctx, name, "RELEASE SAVEPOINT ", driver.TxSavepointer.ReleaseSavepoint)
+}
+
+// savepointOp executes the savepoint operation op on the savepoint name,
+// or the SQL statement formed by prefix and name if the driver does not
+// implement [driver.TxSavepointer].
+func (tx *Tx) savepointOp(ctx context.Context, name, prefix string, op func(driver.TxSavepointer, context.Context, string) error) error {
+	if !validSavepointName(name) {
+		return fmt.Errorf("sql: invalid savepoint name %q", name)
+	}
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return err
+	}
+	// The driver.Tx belongs to the outermost transaction, which cannot end
+	// while its connection is grabbed.
+	sp, ok := tx.root().txi.(driver.TxSavepointer)
+	if !ok {
+		_, err = tx.db.execDC(ctx, dc, release, prefix+name, nil)
+		return err
+	}
+	withLock(dc, func() {
+		err = op(sp, ctx, name)
+	})
+	release(err)
+	return err
+}
+
+// root returns the outermost transaction that tx is nested in, or tx.
+func (tx *Tx) root() *Tx {
+	for tx.parent != nil {
+		tx = tx.parent
+	}
+	return tx
+}
+
+func validSavepointName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Begin starts a transaction nested in tx, backed by a savepoint. Committing
+// the nested transaction releases the savepoint, keeping its changes as part
+// of tx, and rolling it back rolls tx back to the savepoint. Nested
+// transactions may themselves be nested.
+//
+// The statements of a nested transaction run on the connection of tx, so tx
+// must not be used until the nested transaction ends. The nested
+// transaction is rolled back if ctx is canceled, and ends with tx if tx
+// ends first.
+func (tx *Tx) Begin(ctx context.Context) (*Tx, error) {
+	// Savepoint names must be unique across the nesting levels, as
+	// savepoints replace those with the same name in some databases.
+	name := "sql_nested_" + strconv.FormatInt(tx.root().nested.Add(1), 10)
+	if err := tx.Savepoint(ctx, name); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(tx.ctx, cancel)
+	nested := &Tx{
+		db:          tx.db,
+		dc:          tx.dc,
+		releaseConn: func(error) {},
+		cancel: func() {
+			stop()
+			cancel()
+		},
+		ctx:       ctx,
+		parent:    tx,
+		savepoint: name,
+	}
+	go nested.awaitDone()
+	return nested, nil
+}
+
+// endNested ends the nested transaction tx, releasing its savepoint if
+// commit is set and rolling back to it otherwise. It must only be called by
+// Tx.Commit or Tx.rollback, like Tx.close.
+func (tx *Tx) endNested(commit bool) error {
+	ctx := tx.parent.ctx
+	var err error
+	if !commit {
+		err = tx.parent.RollbackTo(ctx, tx.savepoint)
+	}
+	if err == nil {
+		err = tx.parent.Release(ctx, tx.savepoint)
+	}
+	tx.closePrepared()
+	tx.close(err)
+	return err
+}
@@ -0,0 +1,288 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logConnector is a driver.Connector whose connections log the statements
+// they execute and the transactions they run.
+type logConnector struct {
+	savepointer bool // whether transactions implement driver.TxSavepointer
+
+	mu  sync.Mutex
+	log []string
+}
+
+func (c *logConnector) Connect(context.Context) (driver.Conn, error) {
+	return &logConn{c: c}, nil
+}
+
+func (c *logConnector) Driver() driver.Driver { return nil }
+
+func (c *logConnector) add(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.log = append(c.log, s)
+}
+
+func (c *logConnector) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	log := c.log
+	c.log = nil
+	return log
+}
+
+type logConn struct {
+	badConn
+	c *logConnector
+}
+
+func (lc *logConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errors.New("logConn: failed")
+	}
+	lc.c.add(query)
+	return driver.ResultNoRows, nil
+}
+
+func (lc *logConn) Begin() (driver.Tx, error) {
+	lc.c.add("BEGIN")
+	if lc.c.savepointer {
+		return logSavepointTx{logTx{lc.c}}, nil
+	}
+	return logTx{lc.c}, nil
+}
+
+type logTx struct {
+	c *logConnector
+}
+
+func (tx logTx) Commit() error {
+	tx.c.add("COMMIT")
+	return nil
+}
+
+func (tx logTx) Rollback() error {
+	tx.c.add("ROLLBACK")
+	return nil
+}
+
+type logSavepointTx struct {
+	logTx
+}
+
+func (tx logSavepointTx) Savepoint(ctx context.Context, name string) error {
+	tx.c.add("save " + name)
+	return nil
+}
+
+func (tx logSavepointTx) RollbackToSavepoint(ctx context.Context, name string) error {
+	tx.c.add("rollback to " + name)
+	return nil
+}
+
+func (tx logSavepointTx) ReleaseSavepoint(ctx context.Context, name string) error {
+	tx.c.add("release " + name)
+	return nil
+}
+
+var _ driver.TxSavepointer = logSavepointTx{}
+
+func TestTxSavepoint(t *testing.T) {
+	for _, tt := range []struct {
+		savepointer bool
+		want        []string
+	}{
+		{false, []string{
+			"BEGIN",
+			"INSERT 1",
+			"SAVEPOINT a",
+			"INSERT 2",
+			"ROLLBACK TO SAVEPOINT a",
+			"RELEASE SAVEPOINT a",
+			"COMMIT",
+		}},
+		{true, []string{
+			"BEGIN",
+			"INSERT 1",
+			"save a",
+			"INSERT 2",
+			"rollback to a",
+			"release a",
+			"COMMIT",
+		}},
+	} {
+		c := &logConnector{savepointer: tt.savepointer}
+		db := OpenDB(c)
+		ctx := context.Background()
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range []func() error{
+			func() error { _, err := tx.Exec("INSERT 1"); return err },
+			func() error { return tx.Savepoint(ctx, "a") },
+			func() error { _, err := tx.Exec("INSERT 2"); return err },
+			func() error { return tx.RollbackTo(ctx, "a") },
+			func() error { return tx.Release(ctx, "a") },
+			tx.Commit,
+		} {
+			if err := f(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := c.take(); !slices.Equal(got, tt.want) {
+			t.Errorf("savepointer=%v: got %q, want %q", tt.savepointer, got, tt.want)
+		}
+		if err := tx.Savepoint(ctx, "b"); err != ErrTxDone {
+			t.Errorf("Savepoint after Commit: got %v, want ErrTxDone", err)
+		}
+		db.Close()
+	}
+}
+
+func TestTxSavepointErrors(t *testing.T) {
+	db := OpenDB(&logConnector{})
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	ctx := context.Background()
+	for _, name := range []string{"", "1a", "a b", "a;DROP TABLE t", `"a"`} {
+		if err := tx.Savepoint(ctx, name); err == nil || !strings.Contains(err.Error(), "invalid savepoint name") {
+			t.Errorf("Savepoint(%q): got error %v, want invalid savepoint name", name, err)
+		}
+	}
+	if err := tx.Savepoint(ctx, "fail"); err == nil || !strings.Contains(err.Error(), "logConn: failed") {
+		t.Errorf("Savepoint(fail): got error %v, want the driver's error", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := tx.Release(canceled, "a"); err != context.Canceled {
+		t.Errorf("Release with canceled context: got %v, want context.Canceled", err)
+	}
+}
+
+func TestTxBeginNested(t *testing.T) {
+	c := &logConnector{}
+	db := OpenDB(c)
+	defer db.Close()
+	ctx := context.Background()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := tx.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inner.Exec("INSERT 1"); err != nil {
+		t.Fatal(err)
+	}
+	innermost, err := inner.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := innermost.Exec("INSERT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := innermost.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Commit(); err != ErrTxDone {
+		t.Errorf("second Commit: got %v, want ErrTxDone", err)
+	}
+	if _, err := inner.Exec("INSERT 3"); err != ErrTxDone {
+		t.Errorf("Exec after Commit: got %v, want ErrTxDone", err)
+	}
+
+	// A nested transaction ends with its parent.
+	dangling, err := tx.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if !waitCondition(t, dangling.isDone) {
+		t.Fatal("nested transaction not done after parent Commit")
+	}
+	if _, err := dangling.Exec("INSERT 4"); err != ErrTxDone {
+		t.Errorf("Exec after parent Commit: got %v, want ErrTxDone", err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT sql_nested_1",
+		"INSERT 1",
+		"SAVEPOINT sql_nested_2",
+		"INSERT 2",
+		"ROLLBACK TO SAVEPOINT sql_nested_2",
+		"RELEASE SAVEPOINT sql_nested_2",
+		"RELEASE SAVEPOINT sql_nested_1",
+		"SAVEPOINT sql_nested_3",
+		"COMMIT",
+	}
+	if got := c.take(); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTxBeginNestedCancel(t *testing.T) {
+	c := &logConnector{}
+	db := OpenDB(c)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	ctx, cancel := context.WithCancel(context.Background())
+	inner, err := tx.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	// The rollback is asynchronous; wait for its statements.
+	var got []string
+	if !waitCondition(t, func() bool {
+		got = append(got, c.take()...)
+		return len(got) >= 4
+	}) {
+		t.Fatalf("got %q before timing out", got)
+	}
+	if !inner.isDone() {
+		t.Error("nested transaction not done after canceling its context")
+	}
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT sql_nested_1",
+		"ROLLBACK TO SAVEPOINT sql_nested_1",
+		"RELEASE SAVEPOINT sql_nested_1",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := tx.Exec("INSERT 1"); err != nil {
+		t.Errorf("Exec in parent after nested rollback: %v", err)
+	}
+}
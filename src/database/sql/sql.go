@@ -2209,6 +2209,16 @@ type Tx struct {
 
 	// ctx lives for the life of the transaction.
 	ctx context.Context
+
+	// parent is the transaction that a nested transaction, started by
+	// Tx.Begin, runs in, and savepoint is the name of the savepoint
+	// backing it. Both are zero for other transactions.
+	parent    *Tx
+	savepoint string
+
+	// nested numbers the nested transactions started in the outermost
+	// transaction.
+	nested atomic.Int64
 }
 
 // awaitDone blocks until the context in Tx is canceled and rolls back
@@ -2263,6 +2273,17 @@ func (tx *Tx) grabConn(ctx context.Context) (*driverConn, releaseConn, error) {
 		tx.closemu.RUnlock()
 		return nil, nil, ErrTxDone
 	}
+	if tx.parent != nil {
+		dc, release, err := tx.parent.grabConn(ctx)
+		if err != nil {
+			tx.closemu.RUnlock()
+			return nil, nil, err
+		}
+		return dc, func(err error) {
+			release(err)
+			tx.closemu.RUnlock()
+		}, nil
+	}
 	if hookTxGrabConn != nil { // test hook
 		hookTxGrabConn()
 	}
@@ -2315,6 +2336,10 @@ func (tx *Tx) Commit() error {
 	tx.closemu.Lock()
 	tx.closemu.Unlock()
 
+	if tx.parent != nil {
+		return tx.endNested(true)
+	}
+
 	var err error
 	withLock(tx.dc, func() {
 		err = tx.txi.Commit()
@@ -2347,6 +2372,10 @@ func (tx *Tx) rollback(discardConn bool) error {
 	tx.closemu.Lock()
 	tx.closemu.Unlock()
 
+	if tx.parent != nil {
+		return tx.endNested(false)
+	}
+
 	var err error
 	withLock(tx.dc, func() {
 		err = tx.txi.Rollback()
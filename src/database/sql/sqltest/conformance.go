@@ -0,0 +1,850 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// RunConformance runs the conformance suite against the driver of c, with
+// the default queries of [Conformance].
+func RunConformance(t *testing.T, c driver.Connector) {
+	(&Conformance{Connector: c}).Run(t)
+}
+
+// Conformance is a conformance suite for database/sql drivers, checking
+// that a driver follows the contracts of the interfaces of
+// [database/sql/driver] that it implements, both when used directly and
+// through [database/sql].
+//
+// The suite runs a few queries, which can be adapted to the SQL of the
+// database. The zero value of a query field selects its default, which
+// suits most databases.
+type Conformance struct {
+	// Connector connects to the database under test.
+	Connector driver.Connector
+
+	// Select1 selects a single row with a single column, the integer 1.
+	// The default is "SELECT 1".
+	Select1 string
+
+	// CreateTable creates the table sqltest_conformance, with an integer
+	// column id and a text column name. The default is
+	// "CREATE TABLE sqltest_conformance (id INTEGER, name VARCHAR(64))".
+	CreateTable string
+
+	// DropTable drops the table sqltest_conformance. The default is
+	// "DROP TABLE sqltest_conformance".
+	DropTable string
+
+	// Insert inserts a row into the table sqltest_conformance, taking its
+	// id and name as arguments. The default is
+	// "INSERT INTO sqltest_conformance (id, name) VALUES (?, ?)".
+	Insert string
+
+	// SelectAll selects the id and name columns of all the rows of the
+	// table sqltest_conformance, ordered by id. The default is
+	// "SELECT id, name FROM sqltest_conformance ORDER BY id".
+	SelectAll string
+
+	// MultiResult, if set, is a query yielding two result sets, holding the
+	// integers 1 and 2, such as "SELECT 1; SELECT 2". The suite checks
+	// multiple result sets only if it is set.
+	MultiResult string
+
+	// Sleep, if set, is a query taking a number of seconds as argument,
+	// that takes that long, such as "SELECT pg_sleep($1)". The suite checks
+	// the cancellation of running queries only if it is set.
+	Sleep string
+
+	// SetSession, if set, is a statement that changes the state of the
+	// session, and ShowSession a query selecting a single row with a
+	// single column that shows that state, such as "SET TIME ZONE 'UTC'"
+	// and "SHOW TIME ZONE". The suite checks that ResetSession restores
+	// the state of a new session only if both are set.
+	SetSession  string
+	ShowSession string
+}
+
+func (c *Conformance) query(q *string, def string) string {
+	if *q == "" {
+		return def
+	}
+	return *q
+}
+
+// Run runs the conformance suite, each check as a subtest of t.
+func (c *Conformance) Run(t *testing.T) {
+	if c.Connector == nil {
+		t.Fatal("sqltest: Conformance without Connector")
+	}
+	s := &suite{
+		connector:   c.Connector,
+		select1:     c.query(&c.Select1, "SELECT 1"),
+		createTable: c.query(&c.CreateTable, "CREATE TABLE sqltest_conformance (id INTEGER, name VARCHAR(64))"),
+		dropTable:   c.query(&c.DropTable, "DROP TABLE sqltest_conformance"),
+		insert:      c.query(&c.Insert, "INSERT INTO sqltest_conformance (id, name) VALUES (?, ?)"),
+		selectAll:   c.query(&c.SelectAll, "SELECT id, name FROM sqltest_conformance ORDER BY id"),
+		multiResult: c.MultiResult,
+		sleep:       c.Sleep,
+		setSession:  c.SetSession,
+		showSession: c.ShowSession,
+	}
+	for _, tc := range []struct {
+		name string
+		f    func(*testing.T)
+	}{
+		{"Connector", s.testConnector},
+		{"Conn", s.testConn},
+		{"Pinger", s.testPinger},
+		{"SessionResetter", s.testSessionResetter},
+		{"Validator", s.testValidator},
+		{"NamedValueChecker", s.testNamedValueChecker},
+		{"ExecerContext", s.testExecerContext},
+		{"QueryerContext", s.testQueryerContext},
+		{"ConnBeginTx", s.testConnBeginTx},
+		{"RowsColumnType", s.testRowsColumnType},
+		{"RowsNextResultSet", s.testRowsNextResultSet},
+		{"Context", s.testContext},
+		{"DB", s.testDB},
+		{"Tx", s.testTx},
+		{"TxSavepointer", s.testTxSavepointer},
+		{"Batcher", s.testBatcher},
+		{"Copier", s.testCopier},
+	} {
+		t.Run(tc.name, tc.f)
+	}
+}
+
+type suite struct {
+	connector                             driver.Connector
+	select1, createTable, dropTable       string
+	insert, selectAll, multiResult, sleep string
+	setSession, showSession               string
+}
+
+// conn returns a new connection to the database, closed when t ends.
+func (s *suite) conn(t *testing.T) driver.Conn {
+	t.Helper()
+	c, err := s.connector.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("Conn.Close: %v", err)
+		}
+	})
+	return c
+}
+
+// db returns a database using the connector, closed when t ends.
+func (s *suite) db(t *testing.T) *sql.DB {
+	db := sql.OpenDB(s.connector)
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close: %v", err)
+		}
+	})
+	return db
+}
+
+// table creates the table sqltest_conformance in db, dropped when t ends.
+func (s *suite) table(t *testing.T, db *sql.DB) {
+	t.Helper()
+	db.Exec(s.dropTable) // left behind by an earlier run, perhaps
+	if _, err := db.Exec(s.createTable); err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.Exec(s.dropTable); err != nil {
+			t.Errorf("dropping table: %v", err)
+		}
+	})
+}
+
+type row struct {
+	id   int64
+	name string
+}
+
+// rows returns the rows of the table sqltest_conformance, as seen by q.
+func (s *suite) rows(t *testing.T, q interface {
+	Query(string, ...any) (*sql.Rows, error)
+}) []row {
+	t.Helper()
+	rs, err := q.Query(s.selectAll)
+	if err != nil {
+		t.Fatalf("selecting rows: %v", err)
+	}
+	defer rs.Close()
+	var rows []row
+	for rs.Next() {
+		var r row
+		if err := rs.Scan(&r.id, &r.name); err != nil {
+			t.Fatalf("scanning row: %v", err)
+		}
+		rows = append(rows, r)
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("selecting rows: %v", err)
+	}
+	return rows
+}
+
+// checkSelect1 checks that r holds a single row with the single value 1,
+// and closes it.
+func checkSelect1(t *testing.T, r driver.Rows) {
+	t.Helper()
+	defer func() {
+		if err := r.Close(); err != nil {
+			t.Errorf("Rows.Close: %v", err)
+		}
+	}()
+	cols := r.Columns()
+	if len(cols) != 1 {
+		t.Fatalf("Rows.Columns() = %q, want one column", cols)
+	}
+	dest := make([]driver.Value, 1)
+	if err := r.Next(dest); err != nil {
+		t.Fatalf("Rows.Next: %v", err)
+	}
+	var n int64
+	if err := convertAssign(&n, dest[0]); err != nil || n != 1 {
+		t.Errorf("Rows.Next returned %#v, want 1", dest[0])
+	}
+	if err := r.Next(dest); err != io.EOF {
+		t.Errorf("Rows.Next after the last row: got %v, want io.EOF", err)
+	}
+}
+
+// convertAssign converts the driver value v to an int64, like Rows.Scan.
+func convertAssign(dst *int64, v driver.Value) error {
+	var n sql.NullInt64
+	if err := n.Scan(v); err != nil {
+		return err
+	}
+	if !n.Valid {
+		return errors.New("NULL")
+	}
+	*dst = n.Int64
+	return nil
+}
+
+func (s *suite) testConnector(t *testing.T) {
+	if s.connector.Driver() == nil {
+		t.Error("Connector.Driver() = nil")
+	}
+	s.conn(t)
+	s.conn(t) // connections are independent
+}
+
+func (s *suite) testConn(t *testing.T) {
+	c := s.conn(t)
+	st, err := c.Prepare(s.select1)
+	if err != nil {
+		t.Fatalf("Conn.Prepare: %v", err)
+	}
+	if n := st.NumInput(); n != 0 && n != -1 {
+		t.Errorf("Stmt.NumInput() = %d, want 0 or -1", n)
+	}
+	for range 2 { // a statement runs any number of times
+		var r driver.Rows
+		if sq, ok := st.(driver.StmtQueryContext); ok {
+			r, err = sq.QueryContext(context.Background(), nil)
+		} else {
+			r, err = st.Query(nil)
+		}
+		if err != nil {
+			t.Fatalf("Stmt.Query: %v", err)
+		}
+		checkSelect1(t, r)
+	}
+	if err := st.Close(); err != nil {
+		t.Errorf("Stmt.Close: %v", err)
+	}
+
+	if _, err := c.Prepare("this is not a valid query"); err == nil {
+		// Some drivers only send statements to the database on execution.
+		t.Log("Conn.Prepare accepted an invalid query")
+	}
+}
+
+func (s *suite) testPinger(t *testing.T) {
+	p, ok := s.conn(t).(driver.Pinger)
+	if !ok {
+		t.Skip("Conn does not implement Pinger")
+	}
+	if err := p.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
+
+func (s *suite) testSessionResetter(t *testing.T) {
+	c := s.conn(t)
+	sr, ok := c.(driver.SessionResetter)
+	if !ok {
+		t.Skip("Conn does not implement SessionResetter")
+	}
+	if err := sr.ResetSession(context.Background()); err != nil {
+		t.Errorf("ResetSession on a new connection: %v", err)
+	}
+	st, err := c.Prepare(s.select1)
+	if err != nil {
+		t.Fatalf("Conn.Prepare: %v", err)
+	}
+	r, err := st.Query(nil)
+	if err != nil {
+		t.Fatalf("Stmt.Query: %v", err)
+	}
+	checkSelect1(t, r)
+	if err := sr.ResetSession(context.Background()); err != nil {
+		t.Errorf("ResetSession after a query: %v", err)
+	}
+	// The connection remains usable.
+	r, err = st.Query(nil)
+	if err != nil {
+		t.Fatalf("Stmt.Query after ResetSession: %v", err)
+	}
+	checkSelect1(t, r)
+	st.Close()
+
+	bad := s.closedConn(t).(driver.SessionResetter)
+	if err := bad.ResetSession(context.Background()); !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("ResetSession on a closed connection: got %v, want driver.ErrBadConn", err)
+	}
+	s.checkBadConnDiscarded(t)
+
+	if s.setSession == "" || s.showSession == "" {
+		return
+	}
+	// database/sql resets the session of a connection before reusing it.
+	ctx := context.Background()
+	db := s.db(t)
+	db.SetMaxOpenConns(1)
+	initial := s.showSessionState(t, db)
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("DB.Conn: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, s.setSession); err != nil {
+		t.Fatalf("SetSession: %v", err)
+	}
+	if got := s.showSessionState(t, conn); got == initial {
+		t.Errorf("ShowSession after SetSession = %q, the state of a new session", got)
+	}
+	conn.Close()
+	if got := s.showSessionState(t, db); got != initial {
+		t.Errorf("ShowSession on a reused connection = %q, want %q", got, initial)
+	}
+}
+
+// showSessionState returns the result of ShowSession, run by q.
+func (s *suite) showSessionState(t *testing.T, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}) string {
+	t.Helper()
+	var state sql.NullString
+	if err := q.QueryRowContext(context.Background(), s.showSession).Scan(&state); err != nil {
+		t.Fatalf("ShowSession: %v", err)
+	}
+	return state.String
+}
+
+func (s *suite) testValidator(t *testing.T) {
+	c := s.conn(t)
+	v, ok := c.(driver.Validator)
+	if !ok {
+		t.Skip("Conn does not implement Validator")
+	}
+	if !v.IsValid() {
+		t.Error("IsValid on a new connection = false")
+	}
+	if _, err := c.Prepare(s.select1); err != nil {
+		t.Fatalf("Conn.Prepare: %v", err)
+	}
+	if !v.IsValid() {
+		t.Error("IsValid after a statement = false")
+	}
+	if s.closedConn(t).(driver.Validator).IsValid() {
+		t.Error("IsValid on a closed connection = true")
+	}
+	s.checkBadConnDiscarded(t)
+}
+
+// closedConn returns a new connection to the database that is closed,
+// standing in for one whose connection to the database broke.
+func (s *suite) closedConn(t *testing.T) driver.Conn {
+	t.Helper()
+	c, err := s.connector.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Conn.Close: %v", err)
+	}
+	return c
+}
+
+// checkBadConnDiscarded checks that database/sql discards an idle
+// connection that broke, rather than failing the next query.
+func (s *suite) checkBadConnDiscarded(t *testing.T) {
+	t.Helper()
+	rc := &recordingConnector{Connector: s.connector}
+	db := sql.OpenDB(rc)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	var n int64
+	if err := db.QueryRow(s.select1).Scan(&n); err != nil {
+		t.Fatalf("DB.QueryRow: %v", err)
+	}
+	rc.conns()[0].Close()
+	if err := db.QueryRow(s.select1).Scan(&n); err != nil {
+		t.Errorf("DB.QueryRow after the connection broke: %v", err)
+	}
+	if got := len(rc.conns()); got != 2 {
+		t.Errorf("database/sql opened %d connections, want 2", got)
+	}
+}
+
+// A recordingConnector records the connections that it returns.
+type recordingConnector struct {
+	driver.Connector
+	mu sync.Mutex
+	cs []driver.Conn
+}
+
+func (c *recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err == nil {
+		c.mu.Lock()
+		c.cs = append(c.cs, conn)
+		c.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (c *recordingConnector) conns() []driver.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.cs)
+}
+
+func (s *suite) testNamedValueChecker(t *testing.T) {
+	c := s.conn(t)
+	nvc, ok := c.(driver.NamedValueChecker)
+	if !ok {
+		t.Skip("Conn does not implement NamedValueChecker")
+	}
+	// Drivers must handle the default value types, either themselves or by
+	// returning ErrSkip to leave them to the default conversion.
+	for _, v := range []driver.Value{nil, int64(1), 1.5, true, "text", []byte("bytes"), time.Unix(1, 0)} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: v}
+		if err := nvc.CheckNamedValue(nv); err != nil && err != driver.ErrSkip {
+			t.Errorf("CheckNamedValue(%T): %v", v, err)
+		}
+	}
+}
+
+func (s *suite) testExecerContext(t *testing.T) {
+	c := s.conn(t)
+	ec, ok := c.(driver.ExecerContext)
+	if !ok {
+		t.Skip("Conn does not implement ExecerContext")
+	}
+	db := s.db(t)
+	s.table(t, db)
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: "one"}}
+	res, err := ec.ExecContext(context.Background(), s.insert, args)
+	if err == driver.ErrSkip {
+		t.Skip("ExecContext returned ErrSkip")
+	}
+	if err != nil {
+		t.Fatalf("ExecContext: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		t.Errorf("RowsAffected() = %d, want 1", n)
+	}
+	if got, want := s.rows(t, db), []row{{1, "one"}}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+}
+
+func (s *suite) testQueryerContext(t *testing.T) {
+	qc, ok := s.conn(t).(driver.QueryerContext)
+	if !ok {
+		t.Skip("Conn does not implement QueryerContext")
+	}
+	r, err := qc.QueryContext(context.Background(), s.select1, nil)
+	if err == driver.ErrSkip {
+		t.Skip("QueryContext returned ErrSkip")
+	}
+	if err != nil {
+		t.Fatalf("QueryContext: %v", err)
+	}
+	checkSelect1(t, r)
+}
+
+func (s *suite) testConnBeginTx(t *testing.T) {
+	bt, ok := s.conn(t).(driver.ConnBeginTx)
+	if !ok {
+		t.Skip("Conn does not implement ConnBeginTx")
+	}
+	tx, err := bt.BeginTx(context.Background(), driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Tx.Rollback: %v", err)
+	}
+	// Drivers must reject the isolation levels they do not support.
+	tx, err = bt.BeginTx(context.Background(), driver.TxOptions{Isolation: 1 << 20})
+	if err == nil {
+		tx.Rollback()
+		t.Error("BeginTx with an unknown isolation level succeeded")
+	}
+}
+
+func (s *suite) testRowsColumnType(t *testing.T) {
+	db := s.db(t)
+	s.table(t, db)
+	if _, err := db.Exec(s.insert, 1, "one"); err != nil {
+		t.Fatalf("inserting row: %v", err)
+	}
+	rows, err := db.Query(s.selectAll)
+	if err != nil {
+		t.Fatalf("selecting rows: %v", err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatalf("ColumnTypes: %v", err)
+	}
+	if len(types) != 2 {
+		t.Fatalf("got %d column types, want 2", len(types))
+	}
+	dest := make([]any, len(types))
+	for i, ct := range types {
+		st := ct.ScanType()
+		if st == nil {
+			t.Fatalf("ScanType of column %q = nil", ct.Name())
+		}
+		// Values must scan into their scan type.
+		dest[i] = reflect.New(st).Interface()
+		ct.DatabaseTypeName()
+		ct.Length()
+		ct.Nullable()
+		ct.DecimalSize()
+	}
+	if !rows.Next() {
+		t.Fatalf("no rows: %v", rows.Err())
+	}
+	if err := rows.Scan(dest...); err != nil {
+		t.Errorf("scanning into the scan types: %v", err)
+	}
+}
+
+func (s *suite) testRowsNextResultSet(t *testing.T) {
+	qc, ok := s.conn(t).(driver.QueryerContext)
+	if !ok {
+		t.Skip("Conn does not implement QueryerContext")
+	}
+	r, err := qc.QueryContext(context.Background(), s.select1, nil)
+	if err != nil {
+		t.Fatalf("QueryContext: %v", err)
+	}
+	nrs, ok := r.(driver.RowsNextResultSet)
+	if !ok {
+		r.Close()
+		t.Skip("Rows does not implement RowsNextResultSet")
+	}
+	if nrs.HasNextResultSet() {
+		t.Error("HasNextResultSet with a single result set = true")
+	}
+	if err := nrs.NextResultSet(); err != io.EOF {
+		t.Errorf("NextResultSet with a single result set: got %v, want io.EOF", err)
+	}
+	r.Close()
+
+	if s.multiResult == "" {
+		return
+	}
+	rows, err := s.db(t).Query(s.multiResult)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	defer rows.Close()
+	for i := range int64(2) {
+		if i > 0 && !rows.NextResultSet() {
+			t.Fatalf("NextResultSet = false before result set %d: %v", i+1, rows.Err())
+		}
+		var n int64
+		if !rows.Next() {
+			t.Fatalf("no rows in result set %d: %v", i+1, rows.Err())
+		}
+		if err := rows.Scan(&n); err != nil || n != i+1 {
+			t.Errorf("result set %d holds %d, %v; want %d", i+1, n, err, i+1)
+		}
+	}
+	if rows.NextResultSet() {
+		t.Error("NextResultSet after the last result set = true")
+	}
+}
+
+func (s *suite) testContext(t *testing.T) {
+	db := s.db(t)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.QueryContext(canceled, s.select1); err == nil {
+		t.Error("QueryContext with a canceled context succeeded")
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Errorf("PingContext after a canceled query: %v", err)
+	}
+
+	if s.sleep == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, s.sleep, 10)
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+	if err == nil {
+		t.Error("sleeping query outlived its context")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("sleeping query took %v to notice its context was done", d)
+	}
+	// The connection pool remains usable.
+	var n int64
+	if err := db.QueryRow(s.select1).Scan(&n); err != nil || n != 1 {
+		t.Errorf("query after a canceled query returned %d, %v", n, err)
+	}
+}
+
+func (s *suite) testDB(t *testing.T) {
+	db := s.db(t)
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	s.table(t, db)
+	st, err := db.Prepare(s.insert)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	defer st.Close()
+	want := []row{{1, "one"}, {2, "two"}, {3, "three"}}
+	for _, r := range want {
+		if _, err := st.Exec(r.id, r.name); err != nil {
+			t.Fatalf("Stmt.Exec: %v", err)
+		}
+	}
+	if got := s.rows(t, db); !slices.Equal(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+
+	// Connections in the pool see the same data.
+	db.SetMaxIdleConns(4)
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		if conns[i], err = db.Conn(context.Background()); err != nil {
+			t.Fatalf("Conn: %v", err)
+		}
+		defer conns[i].Close()
+	}
+	for _, c := range conns {
+		var id int64
+		var name string
+		if err := c.QueryRowContext(context.Background(), s.selectAll).Scan(&id, &name); err != nil || id != 1 || name != "one" {
+			t.Errorf("QueryRow on another connection returned %d, %q, %v", id, name, err)
+		}
+	}
+}
+
+func (s *suite) testTx(t *testing.T) {
+	db := s.db(t)
+	s.table(t, db)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	if _, err := tx.Exec(s.insert, 1, "one"); err != nil {
+		t.Fatalf("Tx.Exec: %v", err)
+	}
+	if got, want := s.rows(t, tx), []row{{1, "one"}}; !slices.Equal(got, want) {
+		t.Errorf("transaction sees rows %v, want %v", got, want)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if got := s.rows(t, db); len(got) != 0 {
+		t.Errorf("got rows %v after Rollback, want none", got)
+	}
+
+	tx, err = db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	if _, err := tx.Exec(s.insert, 2, "two"); err != nil {
+		t.Fatalf("Tx.Exec: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got, want := s.rows(t, db), []row{{2, "two"}}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v after Commit, want %v", got, want)
+	}
+}
+
+func (s *suite) testTxSavepointer(t *testing.T) {
+	ctx := context.Background()
+	bt, ok := s.conn(t).(driver.ConnBeginTx)
+	if !ok {
+		t.Skip("Conn does not implement ConnBeginTx")
+	}
+	dtx, err := bt.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	_, ok = dtx.(driver.TxSavepointer)
+	dtx.Rollback()
+	if !ok {
+		t.Skip("Tx does not implement TxSavepointer")
+	}
+
+	db := s.db(t)
+	s.table(t, db)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(s.insert, 1, "one"); err != nil {
+		t.Fatalf("Tx.Exec: %v", err)
+	}
+	if err := tx.Savepoint(ctx, "sqltest"); err != nil {
+		t.Fatalf("Savepoint: %v", err)
+	}
+	if _, err := tx.Exec(s.insert, 2, "two"); err != nil {
+		t.Fatalf("Tx.Exec: %v", err)
+	}
+	if err := tx.RollbackTo(ctx, "sqltest"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	if got, want := s.rows(t, tx), []row{{1, "one"}}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v after RollbackTo, want %v", got, want)
+	}
+	if _, err := tx.Exec(s.insert, 3, "three"); err != nil {
+		t.Fatalf("Tx.Exec: %v", err)
+	}
+	if err := tx.Release(ctx, "sqltest"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got, want := s.rows(t, db), []row{{1, "one"}, {3, "three"}}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v after Commit, want %v", got, want)
+	}
+}
+
+func (s *suite) testBatcher(t *testing.T) {
+	if _, ok := s.conn(t).(driver.Batcher); !ok {
+		t.Skip("Conn does not implement Batcher")
+	}
+	db := s.db(t)
+	s.table(t, db)
+	var b sql.Batch
+	b.Queue(s.insert, 1, "one")
+	b.Queue(s.insert, 2, "two")
+	res, err := db.ExecBatch(context.Background(), &b)
+	if err != nil {
+		t.Fatalf("ExecBatch: %v", err)
+	}
+	if len(res) != 2 {
+		t.Errorf("ExecBatch returned %d results, want 2", len(res))
+	}
+	if got, want := s.rows(t, db), []row{{1, "one"}, {2, "two"}}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+
+	// The statements after a failing one do not run.
+	b = sql.Batch{}
+	b.Queue(s.insert, 3, "three")
+	b.Queue("this is not a valid query")
+	b.Queue(s.insert, 4, "four")
+	res, err = db.ExecBatch(context.Background(), &b)
+	if err == nil {
+		t.Fatal("ExecBatch with an invalid statement succeeded")
+	}
+	if len(res) != 1 {
+		t.Errorf("ExecBatch with an invalid second statement returned %d results, want 1", len(res))
+	}
+	if got, want := s.rows(t, db), []row{{1, "one"}, {2, "two"}, {3, "three"}}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+}
+
+func (s *suite) testCopier(t *testing.T) {
+	c, ok := s.conn(t).(driver.Copier)
+	if !ok {
+		t.Skip("Conn does not implement Copier")
+	}
+	db := s.db(t)
+	s.table(t, db)
+	columns := []string{"id", "name"}
+
+	// The driver stops at an error from the rows, inserting no row.
+	errRows := errors.New("sqltest: rows failed")
+	_, err := c.CopyFrom(context.Background(), "sqltest_conformance", columns, func(yield func([]driver.Value, error) bool) {
+		if yield([]driver.Value{int64(1), "one"}, nil) {
+			yield(nil, errRows)
+		}
+	})
+	if err == driver.ErrSkip {
+		t.Skip("CopyFrom returned ErrSkip")
+	}
+	if !errors.Is(err, errRows) {
+		t.Errorf("CopyFrom with failing rows: got %v, want %v", err, errRows)
+	}
+	if got := s.rows(t, db); len(got) != 0 {
+		t.Errorf("got rows %v after a failed CopyFrom, want none", got)
+	}
+
+	want := []row{{1, "one"}, {2, "two"}, {3, "three"}}
+	n, err := db.CopyFrom(context.Background(), "sqltest_conformance", columns, func(yield func([]any) bool) {
+		for _, r := range want {
+			if !yield([]any{r.id, r.name}) {
+				return
+			}
+		}
+	}, nil)
+	if err != nil {
+		t.Fatalf("CopyFrom: %v", err)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("CopyFrom inserted %d rows, want %d", n, len(want))
+	}
+	if got := s.rows(t, db); !slices.Equal(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+}
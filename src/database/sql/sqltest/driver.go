@@ -0,0 +1,581 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sqltest implements support for testing database/sql drivers and
+// the applications that use them.
+//
+// The package provides an in-memory reference driver, which implements all
+// the optional interfaces of [database/sql/driver], for use in the tests of
+// applications, and [RunConformance], which checks that a driver follows
+// the contracts of those interfaces.
+//
+// The reference driver is registered with [database/sql] as "sqltest":
+//
+//	db, err := sql.Open("sqltest", "orders")
+//
+// The data source name names an in-memory database, which all the
+// connections to the same name share for the life of the process. The
+// empty name opens a new database, private to the returned [sql.DB].
+//
+// The driver understands a small subset of SQL: CREATE TABLE, DROP TABLE,
+// INSERT, SELECT, UPDATE and DELETE statements, with simple conditions,
+// savepoints, and SET and SHOW statements for session variables, which
+// [database/sql] clears before it reuses a connection. A query may hold
+// several statements separated by semicolons, in which case each SELECT
+// and SHOW statement yields a result set.
+// Transactions see a snapshot of the database taken when they begin, and
+// their changes replace the changed tables of the database when they
+// commit.
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	sql.Register("sqltest", defaultDriver)
+}
+
+var defaultDriver = &refDriver{}
+
+// refDriver is the reference driver.
+type refDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*database
+}
+
+func (d *refDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+func (d *refDriver) OpenConnector(name string) (driver.Connector, error) {
+	if name == "" {
+		return &connector{d, &database{tables: make(map[string]*table)}}, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	db := d.dbs[name]
+	if db == nil {
+		if d.dbs == nil {
+			d.dbs = make(map[string]*database)
+		}
+		db = &database{tables: make(map[string]*table)}
+		d.dbs[name] = db
+	}
+	return &connector{d, db}, nil
+}
+
+// NewConnector returns a connector to the in-memory database of the
+// reference driver with the given name, or to a new database, private to
+// the connector, if name is empty.
+func NewConnector(name string) driver.Connector {
+	c, _ := defaultDriver.OpenConnector(name)
+	return c
+}
+
+type connector struct {
+	d  *refDriver
+	db *database
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &conn{db: c.db, vars: make(map[string]driver.Value)}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.d
+}
+
+var errClosed = errors.New("sqltest: connection is closed")
+
+type conn struct {
+	db     *database
+	tx     *tx
+	vars   map[string]driver.Value // the session variables
+	closed bool
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if c.closed {
+		return nil, driver.ErrBadConn
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stmts, numInput, err := parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{c: c, stmts: stmts, numInput: numInput}, nil
+}
+
+func (c *conn) Close() error {
+	if c.closed {
+		return errClosed
+	}
+	c.closed = true
+	c.tx = nil
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.closed {
+		return nil, driver.ErrBadConn
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.tx != nil {
+		return nil, errors.New("sqltest: already in a transaction")
+	}
+	// Transactions run with snapshot isolation, which is at least as strong
+	// as the weaker levels.
+	if level := sql.IsolationLevel(opts.Isolation); level > sql.LevelSnapshot {
+		return nil, fmt.Errorf("sqltest: unsupported isolation level %v", level)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.tx = &tx{
+		c:        c,
+		tables:   maps.Clone(c.db.tables),
+		changed:  make(map[string]bool),
+		readOnly: opts.ReadOnly,
+	}
+	return c.tx, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if c.closed {
+		return driver.ErrBadConn
+	}
+	return ctx.Err()
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if c.closed {
+		return driver.ErrBadConn
+	}
+	clear(c.vars)
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	return !c.closed
+}
+
+// CheckNamedValue accepts the values that the driver stores as they are,
+// and leaves the conversion of the others to database/sql.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case nil, int64, float64, bool, string, []byte, time.Time:
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.(*stmt).ExecContext(ctx, args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.(*stmt).QueryContext(ctx, args)
+}
+
+// ExecBatch executes the statements one at a time, as the database is in
+// memory.
+func (c *conn) ExecBatch(ctx context.Context, stmts []driver.BatchStatement) ([]driver.Result, error) {
+	var res []driver.Result
+	for _, st := range stmts {
+		r, err := c.ExecContext(ctx, st.Query, st.Args)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// CopyFrom inserts the rows with a single INSERT statement, which inserts
+// either all of them or none.
+func (c *conn) CopyFrom(ctx context.Context, table string, columns []string, rows iter.Seq2[[]driver.Value, error]) (int64, error) {
+	st := &insert{table: strings.ToLower(table)}
+	for _, col := range columns {
+		st.cols = append(st.cols, strings.ToLower(col))
+	}
+	for row, err := range rows {
+		if err != nil {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		exprs := make([]expr, len(row))
+		for i, v := range row {
+			exprs[i] = literal{v}
+		}
+		st.rows = append(st.rows, exprs)
+	}
+	_, n, err := c.run(ctx, []any{st}, nil)
+	return n, err
+}
+
+func (c *conn) run(ctx context.Context, stmts []any, args []driver.NamedValue) ([]*resultSet, int64, error) {
+	if c.closed {
+		return nil, 0, driver.ErrBadConn
+	}
+	s := &session{db: c.db, tx: c.tx, vars: c.vars, args: args}
+	return s.run(ctx, stmts)
+}
+
+type stmt struct {
+	c        *conn
+	stmts    []any
+	numInput int
+	closed   bool
+}
+
+func (s *stmt) Close() error {
+	if s.closed {
+		return errors.New("sqltest: statement is closed")
+	}
+	s.closed = true
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nv := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nv
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if s.closed {
+		return nil, errors.New("sqltest: statement is closed")
+	}
+	_, n, err := s.c.run(ctx, s.stmts, args)
+	if err != nil {
+		return nil, err
+	}
+	return result(n), nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if s.closed {
+		return nil, errors.New("sqltest: statement is closed")
+	}
+	sets, _, err := s.c.run(ctx, s.stmts, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		sets = []*resultSet{{}}
+	}
+	return &rows{sets: sets}, nil
+}
+
+type result int64
+
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("sqltest: LastInsertId is not supported")
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+type tx struct {
+	c        *conn
+	tables   map[string]*table
+	changed  map[string]bool // the tables the transaction changed
+	readOnly bool
+
+	savepoints []savepoint
+}
+
+type savepoint struct {
+	name    string
+	tables  map[string]*table
+	changed map[string]bool
+}
+
+func (tx *tx) end() error {
+	if tx.c.tx != tx {
+		return errors.New("sqltest: transaction has already ended")
+	}
+	tx.c.tx = nil
+	return nil
+}
+
+func (tx *tx) Commit() error {
+	if err := tx.end(); err != nil {
+		return err
+	}
+	db := tx.c.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for name := range tx.changed {
+		if t := tx.tables[name]; t != nil {
+			db.tables[name] = t
+		} else {
+			delete(db.tables, name)
+		}
+	}
+	return nil
+}
+
+func (tx *tx) Rollback() error {
+	return tx.end()
+}
+
+func (tx *tx) Savepoint(ctx context.Context, name string) error {
+	return tx.savepoint(opSavepoint, name)
+}
+
+func (tx *tx) RollbackToSavepoint(ctx context.Context, name string) error {
+	return tx.savepoint(opRollbackTo, name)
+}
+
+func (tx *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.savepoint(opRelease, name)
+}
+
+func (tx *tx) savepoint(op savepointOp, name string) error {
+	if tx.c.tx != tx {
+		return errors.New("sqltest: transaction has already ended")
+	}
+	if op == opSavepoint {
+		tx.savepoints = slices.DeleteFunc(tx.savepoints, func(sp savepoint) bool { return sp.name == name })
+		tx.savepoints = append(tx.savepoints, savepoint{name, maps.Clone(tx.tables), maps.Clone(tx.changed)})
+		return nil
+	}
+	i := slices.IndexFunc(tx.savepoints, func(sp savepoint) bool { return sp.name == name })
+	if i < 0 {
+		return fmt.Errorf("sqltest: no savepoint %q", name)
+	}
+	if op == opRelease {
+		tx.savepoints = tx.savepoints[:i]
+		return nil
+	}
+	sp := tx.savepoints[i]
+	tx.tables, tx.changed = maps.Clone(sp.tables), maps.Clone(sp.changed)
+	tx.savepoints = tx.savepoints[:i+1]
+	return nil
+}
+
+type rows struct {
+	sets []*resultSet
+	set  int // the current result set
+	row  int // the next row of the current result set
+}
+
+func (r *rows) cur() *resultSet {
+	return r.sets[r.set]
+}
+
+func (r *rows) Columns() []string {
+	var names []string
+	for _, c := range r.cur().cols {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+func (r *rows) Close() error {
+	r.set, r.row = len(r.sets)-1, len(r.cur().rows)
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	rs := r.cur()
+	if r.row >= len(rs.rows) {
+		return io.EOF
+	}
+	for i, v := range rs.rows[r.row] {
+		if b, ok := v.([]byte); ok {
+			v = slices.Clone(b)
+		}
+		dest[i] = v
+	}
+	r.row++
+	return nil
+}
+
+func (r *rows) HasNextResultSet() bool {
+	return r.set+1 < len(r.sets)
+}
+
+func (r *rows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.set++
+	r.row = 0
+	return nil
+}
+
+// valueType returns the type of the values of column i of the current
+// result set, for columns that are not table columns.
+func (r *rows) valueType(i int) (colType, bool) {
+	for _, row := range r.cur().rows {
+		switch row[i].(type) {
+		case int64:
+			return typeInt, true
+		case float64:
+			return typeFloat, true
+		case string:
+			return typeString, true
+		case []byte:
+			return typeBytes, true
+		case bool:
+			return typeBool, true
+		case time.Time:
+			return typeTime, true
+		}
+	}
+	return 0, false
+}
+
+var (
+	scanTypes = [...]reflect.Type{
+		typeInt:    reflect.TypeFor[int64](),
+		typeFloat:  reflect.TypeFor[float64](),
+		typeString: reflect.TypeFor[string](),
+		typeBytes:  reflect.TypeFor[[]byte](),
+		typeBool:   reflect.TypeFor[bool](),
+		typeTime:   reflect.TypeFor[time.Time](),
+	}
+	nullScanTypes = [...]reflect.Type{
+		typeInt:    reflect.TypeFor[sql.NullInt64](),
+		typeFloat:  reflect.TypeFor[sql.NullFloat64](),
+		typeString: reflect.TypeFor[sql.NullString](),
+		typeBytes:  reflect.TypeFor[[]byte](),
+		typeBool:   reflect.TypeFor[sql.NullBool](),
+		typeTime:   reflect.TypeFor[sql.NullTime](),
+	}
+	typeNames = [...]string{
+		typeInt:    "INTEGER",
+		typeFloat:  "REAL",
+		typeString: "TEXT",
+		typeBytes:  "BLOB",
+		typeBool:   "BOOLEAN",
+		typeTime:   "TIMESTAMP",
+	}
+)
+
+func (r *rows) ColumnTypeScanType(i int) reflect.Type {
+	if c := r.cur().cols[i].col; c != nil {
+		if c.notNull {
+			return scanTypes[c.typ]
+		}
+		return nullScanTypes[c.typ]
+	}
+	if typ, ok := r.valueType(i); ok {
+		return scanTypes[typ]
+	}
+	return reflect.TypeFor[any]()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(i int) string {
+	if c := r.cur().cols[i].col; c != nil {
+		return c.typeName
+	}
+	if typ, ok := r.valueType(i); ok {
+		return typeNames[typ]
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(i int) (length int64, ok bool) {
+	if c := r.cur().cols[i].col; c != nil && c.length >= 0 {
+		return c.length, true
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(i int) (nullable, ok bool) {
+	if c := r.cur().cols[i].col; c != nil {
+		return !c.notNull, true
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(i int) (precision, scale int64, ok bool) {
+	if c := r.cur().cols[i].col; c != nil && c.decimal {
+		return c.precision, c.scale, true
+	}
+	return 0, 0, false
+}
+
+var (
+	_ driver.DriverContext                  = (*refDriver)(nil)
+	_ driver.Connector                      = (*connector)(nil)
+	_ driver.Pinger                         = (*conn)(nil)
+	_ driver.ExecerContext                  = (*conn)(nil)
+	_ driver.QueryerContext                 = (*conn)(nil)
+	_ driver.ConnPrepareContext             = (*conn)(nil)
+	_ driver.ConnBeginTx                    = (*conn)(nil)
+	_ driver.SessionResetter                = (*conn)(nil)
+	_ driver.Validator                      = (*conn)(nil)
+	_ driver.NamedValueChecker              = (*conn)(nil)
+	_ driver.Batcher                        = (*conn)(nil)
+	_ driver.Copier                         = (*conn)(nil)
+	_ driver.StmtExecContext                = (*stmt)(nil)
+	_ driver.StmtQueryContext               = (*stmt)(nil)
+	_ driver.TxSavepointer                  = (*tx)(nil)
+	_ driver.RowsNextResultSet              = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeLength           = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*rows)(nil)
+)
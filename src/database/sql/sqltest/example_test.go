@@ -0,0 +1,32 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqltest_test
+
+import (
+	"database/sql"
+	_ "database/sql/sqltest"
+	"fmt"
+	"log"
+)
+
+func Example() {
+	db, err := sql.Open("sqltest", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+		INSERT INTO users VALUES (1, 'gopher'), (2, 'rustacean')`); err != nil {
+		log.Fatal(err)
+	}
+	var name string
+	if err := db.QueryRow("SELECT name FROM users WHERE id = ?", 1).Scan(&name); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(name)
+	// Output: gopher
+}
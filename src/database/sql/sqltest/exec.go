@@ -0,0 +1,738 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqltest
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type colType int
+
+const (
+	typeInt colType = iota
+	typeFloat
+	typeString
+	typeBytes
+	typeBool
+	typeTime
+)
+
+// maxLength is the length of the column types without a size limit.
+const maxLength = math.MaxInt64
+
+type column struct {
+	name     string
+	typeName string // the name of the type, in upper case
+	typ      colType
+
+	length              int64 // -1 if the type has no length
+	decimal             bool
+	precision, scale    int64
+	notNull, primaryKey bool
+}
+
+type table struct {
+	name string
+	cols []*column
+	rows [][]driver.Value
+}
+
+// colIndex returns the index of the column name in t, or an error.
+func (t *table) colIndex(name string) (int, error) {
+	for i, c := range t.cols {
+		if c.name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("sqltest: no column %q in table %q", name, t.name)
+}
+
+// A database is an in-memory database.
+//
+// Tables are never modified once they are part of a database: statements
+// replace the tables they change with changed copies, sharing the rows
+// they do not change. Transactions and savepoints can thus keep snapshots
+// of the database as copies of its map of tables.
+type database struct {
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// A session is the state of a connection that statements execute in.
+type session struct {
+	db   *database
+	tx   *tx                     // nil outside transactions
+	vars map[string]driver.Value // set by SET
+	args []driver.NamedValue
+}
+
+// A resultSet is the result of a SELECT statement.
+type resultSet struct {
+	cols []resultColumn
+	rows [][]driver.Value
+}
+
+type resultColumn struct {
+	name string
+	col  *column // nil for expressions
+}
+
+// run executes the statements stmts, returning the results of the SELECT
+// statements and the number of rows that the other statements affected.
+func (s *session) run(ctx context.Context, stmts []any) (sets []*resultSet, affected int64, err error) {
+	for _, st := range stmts {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if st, ok := st.(*selectStmt); ok && st.table == "" {
+			// Selects without FROM do not read the database, and may sleep.
+			rs, err := s.selectValues(ctx, st)
+			if err != nil {
+				return nil, 0, err
+			}
+			sets = append(sets, rs)
+			continue
+		}
+		if st, ok := st.(*savepointStmt); ok {
+			if s.tx == nil {
+				return nil, 0, fmt.Errorf("sqltest: savepoints require a transaction")
+			}
+			if err := s.tx.savepoint(st.op, st.name); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		switch st := st.(type) {
+		case *setStmt:
+			v, err := s.eval(st.e, nil, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			s.vars[st.name] = v
+			continue
+		case *showStmt:
+			sets = append(sets, &resultSet{
+				cols: []resultColumn{{name: st.name}},
+				rows: [][]driver.Value{{s.vars[st.name]}},
+			})
+			continue
+		}
+		rs, n, err := s.runLocked(st)
+		if err != nil {
+			return nil, 0, err
+		}
+		if rs != nil {
+			sets = append(sets, rs)
+		}
+		affected += n
+	}
+	return sets, affected, nil
+}
+
+func (s *session) runLocked(st any) (*resultSet, int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if _, ok := st.(*selectStmt); !ok && s.tx != nil && s.tx.readOnly {
+		return nil, 0, fmt.Errorf("sqltest: cannot write in a read-only transaction")
+	}
+	switch st := st.(type) {
+	case *createTable:
+		if s.tables()[st.name] != nil {
+			if st.ifNotExists {
+				return nil, 0, nil
+			}
+			return nil, 0, fmt.Errorf("sqltest: table %q already exists", st.name)
+		}
+		s.setTable(st.name, &table{name: st.name, cols: st.cols})
+		return nil, 0, nil
+	case *dropTable:
+		if s.tables()[st.name] == nil {
+			if st.ifExists {
+				return nil, 0, nil
+			}
+			return nil, 0, fmt.Errorf("sqltest: no table %q", st.name)
+		}
+		s.setTable(st.name, nil)
+		return nil, 0, nil
+	case *insert:
+		return s.insert(st)
+	case *selectStmt:
+		rs, err := s.selectRows(st)
+		return rs, 0, err
+	case *update:
+		return s.update(st)
+	case *deleteStmt:
+		return s.delete(st)
+	}
+	panic(fmt.Sprintf("sqltest: unexpected statement %T", st))
+}
+
+// tables returns the tables that statements see. db.mu must be held.
+func (s *session) tables() map[string]*table {
+	if s.tx != nil {
+		return s.tx.tables
+	}
+	return s.db.tables
+}
+
+// setTable replaces the table name with t, or drops it if t is nil.
+// db.mu must be held.
+func (s *session) setTable(name string, t *table) {
+	tables := s.tables()
+	if t == nil {
+		delete(tables, name)
+	} else {
+		tables[name] = t
+	}
+	if s.tx != nil {
+		s.tx.changed[name] = true
+	}
+}
+
+func (s *session) table(name string) (*table, error) {
+	t := s.tables()[name]
+	if t == nil {
+		return nil, fmt.Errorf("sqltest: no table %q", name)
+	}
+	return t, nil
+}
+
+func (s *session) insert(st *insert) (*resultSet, int64, error) {
+	t, err := s.table(st.table)
+	if err != nil {
+		return nil, 0, err
+	}
+	var idx []int
+	if st.cols == nil {
+		for i := range t.cols {
+			idx = append(idx, i)
+		}
+	} else {
+		for _, name := range st.cols {
+			i, err := t.colIndex(name)
+			if err != nil {
+				return nil, 0, err
+			}
+			if slices.Contains(idx, i) {
+				return nil, 0, fmt.Errorf("sqltest: column %q given twice", name)
+			}
+			idx = append(idx, i)
+		}
+	}
+	rows := slices.Clip(t.rows)
+	for _, exprs := range st.rows {
+		if len(exprs) != len(idx) {
+			return nil, 0, fmt.Errorf("sqltest: %d values for %d columns", len(exprs), len(idx))
+		}
+		row := make([]driver.Value, len(t.cols))
+		for j, e := range exprs {
+			v, err := s.eval(e, nil, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			c := t.cols[idx[j]]
+			if row[idx[j]], err = convert(c, v); err != nil {
+				return nil, 0, err
+			}
+		}
+		for i, c := range t.cols {
+			if c.notNull && row[i] == nil {
+				return nil, 0, fmt.Errorf("sqltest: NULL value in NOT NULL column %q", c.name)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := checkPrimaryKeys(t, rows); err != nil {
+		return nil, 0, err
+	}
+	s.setTable(t.name, &table{name: t.name, cols: t.cols, rows: rows})
+	return nil, int64(len(st.rows)), nil
+}
+
+func (s *session) update(st *update) (*resultSet, int64, error) {
+	t, err := s.table(st.table)
+	if err != nil {
+		return nil, 0, err
+	}
+	idx := make([]int, len(st.cols))
+	for i, name := range st.cols {
+		if idx[i], err = t.colIndex(name); err != nil {
+			return nil, 0, err
+		}
+	}
+	rows := make([][]driver.Value, len(t.rows))
+	var n int64
+	for i, row := range t.rows {
+		rows[i] = row
+		ok, err := s.match(st.where, t, row)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			continue
+		}
+		rows[i] = slices.Clone(row)
+		for j, e := range st.exprs {
+			v, err := s.eval(e, t, row)
+			if err != nil {
+				return nil, 0, err
+			}
+			c := t.cols[idx[j]]
+			if v, err = convert(c, v); err != nil {
+				return nil, 0, err
+			}
+			if c.notNull && v == nil {
+				return nil, 0, fmt.Errorf("sqltest: NULL value in NOT NULL column %q", c.name)
+			}
+			rows[i][idx[j]] = v
+		}
+		n++
+	}
+	if err := checkPrimaryKeys(t, rows); err != nil {
+		return nil, 0, err
+	}
+	s.setTable(t.name, &table{name: t.name, cols: t.cols, rows: rows})
+	return nil, n, nil
+}
+
+func (s *session) delete(st *deleteStmt) (*resultSet, int64, error) {
+	t, err := s.table(st.table)
+	if err != nil {
+		return nil, 0, err
+	}
+	var rows [][]driver.Value
+	for _, row := range t.rows {
+		ok, err := s.match(st.where, t, row)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			rows = append(rows, row)
+		}
+	}
+	s.setTable(t.name, &table{name: t.name, cols: t.cols, rows: rows})
+	return nil, int64(len(t.rows) - len(rows)), nil
+}
+
+// checkPrimaryKeys returns an error if the primary key columns of t have
+// the same value in two of rows.
+func checkPrimaryKeys(t *table, rows [][]driver.Value) error {
+	for i, c := range t.cols {
+		if !c.primaryKey {
+			continue
+		}
+		seen := make(map[any]bool, len(rows))
+		for _, row := range rows {
+			k := row[i]
+			switch v := k.(type) {
+			case []byte:
+				k = string(v)
+			case time.Time:
+				k = v.UnixNano()
+			}
+			if seen[k] {
+				return fmt.Errorf("sqltest: duplicate value %v in primary key column %q", row[i], c.name)
+			}
+			seen[k] = true
+		}
+	}
+	return nil
+}
+
+// selectValues executes a SELECT statement without FROM.
+func (s *session) selectValues(ctx context.Context, st *selectStmt) (*resultSet, error) {
+	rs := &resultSet{rows: [][]driver.Value{nil}}
+	for _, item := range st.items {
+		var v driver.Value
+		var err error
+		switch e := item.e.(type) {
+		case countStar:
+			v = int64(1)
+		case sleep:
+			v, err = s.sleep(ctx, e)
+		default:
+			v, err = s.eval(e, nil, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rs.cols = append(rs.cols, resultColumn{name: item.name})
+		rs.rows[0] = append(rs.rows[0], v)
+	}
+	return rs, nil
+}
+
+func (s *session) sleep(ctx context.Context, e sleep) (driver.Value, error) {
+	v, err := s.eval(e.seconds, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var d time.Duration
+	switch v := v.(type) {
+	case int64:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	default:
+		return nil, fmt.Errorf("sqltest: SLEEP of %T", v)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return int64(0), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// selectRows executes a SELECT statement with FROM.
+func (s *session) selectRows(st *selectStmt) (*resultSet, error) {
+	t, err := s.table(st.table)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]driver.Value
+	for _, row := range t.rows {
+		ok, err := s.match(st.where, t, row)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+
+	if len(st.orderBy) > 0 {
+		idx := make([]int, len(st.orderBy))
+		for i, o := range st.orderBy {
+			if idx[i], err = t.colIndex(o.col); err != nil {
+				return nil, err
+			}
+		}
+		slices.SortStableFunc(rows, func(a, b []driver.Value) int {
+			for i, o := range st.orderBy {
+				x, y := a[idx[i]], b[idx[i]]
+				var c int
+				switch {
+				case x == nil || y == nil:
+					// NULL values come first.
+					c = cmp.Compare(boolInt(x != nil), boolInt(y != nil))
+				default:
+					// The values of a column always compare.
+					c, _ = compare(x, y)
+				}
+				if o.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+	}
+
+	if st.limit != nil {
+		v, err := s.eval(st.limit, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := v.(int64)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("sqltest: invalid LIMIT %v", v)
+		}
+		if n < int64(len(rows)) {
+			rows = rows[:n]
+		}
+	}
+
+	rs := &resultSet{}
+	var exprs []expr
+	counting := false
+	for _, item := range st.items {
+		switch e := item.e.(type) {
+		case nil: // *
+			for _, c := range t.cols {
+				rs.cols = append(rs.cols, resultColumn{c.name, c})
+				exprs = append(exprs, columnRef{c.name})
+			}
+			continue
+		case columnRef:
+			i, err := t.colIndex(e.name)
+			if err != nil {
+				return nil, err
+			}
+			rs.cols = append(rs.cols, resultColumn{item.name, t.cols[i]})
+		case countStar:
+			counting = true
+			rs.cols = append(rs.cols, resultColumn{name: item.name})
+		case sleep:
+			return nil, fmt.Errorf("sqltest: SLEEP with FROM")
+		default:
+			rs.cols = append(rs.cols, resultColumn{name: item.name})
+		}
+		exprs = append(exprs, item.e)
+	}
+	if counting {
+		row := make([]driver.Value, len(exprs))
+		for i, e := range exprs {
+			if _, ok := e.(countStar); !ok {
+				return nil, fmt.Errorf("sqltest: COUNT(*) with other columns")
+			}
+			row[i] = int64(len(rows))
+		}
+		rs.rows = [][]driver.Value{row}
+		return rs, nil
+	}
+	for _, row := range rows {
+		out := make([]driver.Value, len(exprs))
+		for i, e := range exprs {
+			if out[i], err = s.eval(e, t, row); err != nil {
+				return nil, err
+			}
+		}
+		rs.rows = append(rs.rows, out)
+	}
+	return rs, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// match reports whether row of t satisfies all of preds.
+func (s *session) match(preds []predicate, t *table, row []driver.Value) (bool, error) {
+	for _, p := range preds {
+		l, err := s.eval(p.left, t, row)
+		if err != nil {
+			return false, err
+		}
+		switch p.op {
+		case "is null":
+			if l != nil {
+				return false, nil
+			}
+			continue
+		case "is not null":
+			if l == nil {
+				return false, nil
+			}
+			continue
+		}
+		r, err := s.eval(p.right, t, row)
+		if err != nil {
+			return false, err
+		}
+		if l == nil || r == nil {
+			return false, nil
+		}
+		c, err := compare(l, r)
+		if err != nil {
+			return false, err
+		}
+		var ok bool
+		switch p.op {
+		case "=":
+			ok = c == 0
+		case "<>", "!=":
+			ok = c != 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// eval returns the value of e for row of t, which are nil outside tables.
+func (s *session) eval(e expr, t *table, row []driver.Value) (driver.Value, error) {
+	switch e := e.(type) {
+	case literal:
+		return e.v, nil
+	case param:
+		for _, a := range s.args {
+			if e.name != "" && a.Name == e.name || e.name == "" && a.Name == "" && a.Ordinal == e.ordinal {
+				return a.Value, nil
+			}
+		}
+		if e.name != "" {
+			return nil, fmt.Errorf("sqltest: no argument for placeholder :%s", e.name)
+		}
+		return nil, fmt.Errorf("sqltest: no argument for placeholder %d", e.ordinal)
+	case columnRef:
+		if t == nil {
+			return nil, fmt.Errorf("sqltest: no column %q", e.name)
+		}
+		i, err := t.colIndex(e.name)
+		if err != nil {
+			return nil, err
+		}
+		return row[i], nil
+	case countStar:
+		return nil, fmt.Errorf("sqltest: COUNT(*) in expression")
+	case sleep:
+		return nil, fmt.Errorf("sqltest: SLEEP in expression")
+	}
+	panic(fmt.Sprintf("sqltest: unexpected expression %T", e))
+}
+
+// compare compares the non-nil values a and b.
+func compare(a, b driver.Value) (int, error) {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return cmp.Compare(a, b), nil
+		case float64:
+			return cmp.Compare(float64(a), b), nil
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return cmp.Compare(a, float64(b)), nil
+		case float64:
+			return cmp.Compare(a, b), nil
+		}
+	case string:
+		switch b := b.(type) {
+		case string:
+			return strings.Compare(a, b), nil
+		case []byte:
+			return strings.Compare(a, string(b)), nil
+		case time.Time:
+			if t, err := parseTime(a); err == nil {
+				return t.Compare(b), nil
+			}
+		}
+	case []byte:
+		switch b := b.(type) {
+		case string:
+			return strings.Compare(string(a), b), nil
+		case []byte:
+			return bytes.Compare(a, b), nil
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return cmp.Compare(boolInt(a), boolInt(b)), nil
+		}
+	case time.Time:
+		switch b := b.(type) {
+		case time.Time:
+			return a.Compare(b), nil
+		case string:
+			if t, err := parseTime(b); err == nil {
+				return a.Compare(t), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("sqltest: cannot compare %T and %T", a, b)
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("sqltest: invalid time %q", s)
+}
+
+// convert converts v to the type of column c.
+func convert(c *column, v driver.Value) (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch c.typ {
+	case typeInt:
+		switch v := v.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+				return int64(v), nil
+			}
+		case bool:
+			return int64(boolInt(v)), nil
+		}
+	case typeFloat:
+		switch v := v.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case typeString:
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		default:
+			return nil, conversionError(c, v)
+		}
+		if c.length != maxLength && int64(utf8.RuneCountInString(s)) > c.length {
+			return nil, fmt.Errorf("sqltest: value too long for column %q of type %s(%d)", c.name, c.typeName, c.length)
+		}
+		return s, nil
+	case typeBytes:
+		var b []byte
+		switch v := v.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = bytes.Clone(v)
+		default:
+			return nil, conversionError(c, v)
+		}
+		if c.length != maxLength && int64(len(b)) > c.length {
+			return nil, fmt.Errorf("sqltest: value too long for column %q of type %s(%d)", c.name, c.typeName, c.length)
+		}
+		return b, nil
+	case typeBool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		}
+	case typeTime:
+		switch v := v.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			return parseTime(v)
+		}
+	}
+	return nil, conversionError(c, v)
+}
+
+func conversionError(c *column, v driver.Value) error {
+	return fmt.Errorf("sqltest: cannot store %T value in column %q of type %s", v, c.name, c.typeName)
+}
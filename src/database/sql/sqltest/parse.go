@@ -0,0 +1,851 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqltest
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The reference driver understands a small subset of SQL:
+//
+//	CREATE TABLE [IF NOT EXISTS] t (c type [NOT NULL | NULL | PRIMARY KEY]..., ...)
+//	DROP TABLE [IF EXISTS] t
+//	INSERT INTO t [(c, ...)] VALUES (e, ...), ...
+//	SELECT item, ... [FROM t [WHERE cond] [ORDER BY c [ASC | DESC], ...] [LIMIT e]]
+//	UPDATE t SET c = e, ... [WHERE cond]
+//	DELETE FROM t [WHERE cond]
+//	SAVEPOINT s
+//	ROLLBACK TO [SAVEPOINT] s
+//	RELEASE [SAVEPOINT] s
+//	SET v = e
+//	SHOW v
+//
+// A select item is *, a column, an expression, COUNT(*) or SLEEP(e), which
+// waits for e seconds, each optionally followed by AS and a name. An
+// expression is a literal, NULL, TRUE, FALSE, a column or a placeholder: ?,
+// $n, :name or @name. A condition is a conjunction, with AND, of
+// comparisons e op e, where op is one of = <> != < <= > >=, and tests
+// e IS [NOT] NULL. SET sets the session variable v, which SHOW selects.
+// Column types are INTEGER, INT, BIGINT, SMALLINT, REAL,
+// FLOAT, DOUBLE [PRECISION], DECIMAL(p, s), NUMERIC(p, s), TEXT, CHAR(n),
+// VARCHAR(n), BLOB, BYTEA, VARBINARY(n), BOOLEAN, BOOL, TIMESTAMP and
+// DATETIME. Keywords and names are not case-sensitive.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokParam
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // the identifier, in lower case, or the source text
+	pos  int
+}
+
+// tokenize splits query into tokens.
+func tokenize(query string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			continue
+		case isIdentStart(c):
+			for i < len(query) && isIdentByte(query[i]) {
+				i++
+			}
+			toks = append(toks, token{tokIdent, strings.ToLower(query[start:i]), start})
+		case c == '"':
+			// A quoted identifier.
+			i++
+			for i < len(query) && query[i] != '"' {
+				i++
+			}
+			if i == len(query) {
+				return nil, fmt.Errorf("sqltest: unterminated quoted identifier at offset %d", start)
+			}
+			i++
+			toks = append(toks, token{tokIdent, strings.ToLower(query[start+1 : i-1]), start})
+		case '0' <= c && c <= '9' || c == '.' && i+1 < len(query) && '0' <= query[i+1] && query[i+1] <= '9':
+			for i < len(query) && ('0' <= query[i] && query[i] <= '9' || query[i] == '.' || query[i] == 'e' || query[i] == 'E' ||
+				(query[i] == '-' || query[i] == '+') && (query[i-1] == 'e' || query[i-1] == 'E')) {
+				i++
+			}
+			toks = append(toks, token{tokNumber, query[start:i], start})
+		case c == '\'':
+			var b strings.Builder
+			i++
+			for {
+				if i == len(query) {
+					return nil, fmt.Errorf("sqltest: unterminated string at offset %d", start)
+				}
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						b.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(query[i])
+				i++
+			}
+			toks = append(toks, token{tokString, b.String(), start})
+		case c == '?':
+			i++
+			toks = append(toks, token{tokParam, "?", start})
+		case c == '$' || c == ':' || c == '@':
+			i++
+			for i < len(query) && isIdentByte(query[i]) {
+				i++
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("sqltest: invalid placeholder at offset %d", start)
+			}
+			toks = append(toks, token{tokParam, query[start:i], start})
+		case c == '<' || c == '>' || c == '!':
+			i++
+			if i < len(query) && (query[i] == '=' || c == '<' && query[i] == '>') {
+				i++
+			}
+			if query[start:i] == "!" {
+				return nil, fmt.Errorf("sqltest: unexpected %q at offset %d", "!", start)
+			}
+			toks = append(toks, token{tokPunct, query[start:i], start})
+		case strings.IndexByte("(),;*=-+", c) >= 0:
+			i++
+			toks = append(toks, token{tokPunct, query[start:i], start})
+		default:
+			return nil, fmt.Errorf("sqltest: unexpected %q at offset %d", c, start)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(query)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9'
+}
+
+// Statements.
+type (
+	createTable struct {
+		name        string
+		ifNotExists bool
+		cols        []*column
+	}
+
+	dropTable struct {
+		name     string
+		ifExists bool
+	}
+
+	insert struct {
+		table string
+		cols  []string // nil for all the columns, in order
+		rows  [][]expr
+	}
+
+	selectStmt struct {
+		items   []selectItem
+		table   string // empty without FROM
+		where   []predicate
+		orderBy []ordering
+		limit   expr
+	}
+
+	update struct {
+		table string
+		cols  []string
+		exprs []expr
+		where []predicate
+	}
+
+	deleteStmt struct {
+		table string
+		where []predicate
+	}
+
+	savepointStmt struct {
+		op   savepointOp
+		name string
+	}
+
+	setStmt struct {
+		name string
+		e    expr
+	}
+
+	showStmt struct{ name string }
+)
+
+type savepointOp int
+
+const (
+	opSavepoint savepointOp = iota
+	opRollbackTo
+	opRelease
+)
+
+// Expressions.
+type (
+	expr any
+
+	literal struct{ v driver.Value }
+
+	// param is a placeholder, with either the ordinal position or the name
+	// of its argument.
+	param struct {
+		ordinal int
+		name    string
+	}
+
+	columnRef struct{ name string }
+
+	countStar struct{}
+
+	sleep struct{ seconds expr }
+)
+
+type selectItem struct {
+	star bool // *
+	e    expr
+	name string
+}
+
+type predicate struct {
+	left  expr
+	op    string // a comparison, "is null" or "is not null"
+	right expr   // nil for the IS tests
+}
+
+type ordering struct {
+	col  string
+	desc bool
+}
+
+// A parser parses the statements of a query.
+type parser struct {
+	query string
+	toks  []token
+	pos   int
+
+	// The style of the placeholders: '?', '$' or ':' for names, 0 before
+	// the first one.
+	style     byte
+	numParams int // the number of ? or the largest $n
+}
+
+// parse parses the statements of query, separated by semicolons, and
+// returns them with the number of arguments they take, or -1 if they take
+// named arguments.
+func parse(query string) (stmts []any, numInput int, err error) {
+	toks, err := tokenize(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &parser{query: query, toks: toks}
+	for {
+		for p.punct(";") {
+		}
+		if p.peek().kind == tokEOF {
+			break
+		}
+		st, err := p.statement()
+		if err != nil {
+			return nil, 0, err
+		}
+		stmts = append(stmts, st)
+		if t := p.peek(); t.kind != tokEOF && !p.punct(";") {
+			return nil, 0, p.errorf("unexpected %q", p.tokenText(t))
+		}
+	}
+	if len(stmts) == 0 {
+		return nil, 0, fmt.Errorf("sqltest: empty query")
+	}
+	if p.style == ':' {
+		return stmts, -1, nil
+	}
+	return stmts, p.numParams, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// tokenText returns the source text of t.
+func (p *parser) tokenText(t token) string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	end := len(p.query)
+	for _, u := range p.toks {
+		if u.pos > t.pos {
+			end = u.pos
+			break
+		}
+	}
+	return strings.TrimSpace(p.query[t.pos:end])
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("sqltest: syntax error at offset %d: %s", p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+// keyword consumes the keyword kw if it comes next.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokIdent && t.text == kw {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return p.errorf("expected %s, found %q", strings.ToUpper(kw), p.tokenText(p.peek()))
+	}
+	return nil
+}
+
+// punct consumes the punctuation s if it comes next.
+func (p *parser) punct(s string) bool {
+	if t := p.peek(); t.kind == tokPunct && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(s string) error {
+	if !p.punct(s) {
+		return p.errorf("expected %q, found %q", s, p.tokenText(p.peek()))
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return "", p.errorf("expected name, found %q", p.tokenText(t))
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) identList() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.punct(",") {
+			return names, nil
+		}
+	}
+}
+
+func (p *parser) statement() (any, error) {
+	switch {
+	case p.keyword("create"):
+		return p.createTable()
+	case p.keyword("drop"):
+		if err := p.expectKeyword("table"); err != nil {
+			return nil, err
+		}
+		st := &dropTable{}
+		if p.keyword("if") {
+			if err := p.expectKeyword("exists"); err != nil {
+				return nil, err
+			}
+			st.ifExists = true
+		}
+		var err error
+		st.name, err = p.ident()
+		return st, err
+	case p.keyword("insert"):
+		return p.insert()
+	case p.keyword("select"):
+		return p.selectStmt()
+	case p.keyword("update"):
+		return p.update()
+	case p.keyword("delete"):
+		st := &deleteStmt{}
+		if err := p.expectKeyword("from"); err != nil {
+			return nil, err
+		}
+		var err error
+		if st.table, err = p.ident(); err != nil {
+			return nil, err
+		}
+		st.where, err = p.where()
+		return st, err
+	case p.keyword("savepoint"):
+		name, err := p.ident()
+		return &savepointStmt{opSavepoint, name}, err
+	case p.keyword("rollback"):
+		if err := p.expectKeyword("to"); err != nil {
+			return nil, err
+		}
+		p.keyword("savepoint")
+		name, err := p.ident()
+		return &savepointStmt{opRollbackTo, name}, err
+	case p.keyword("release"):
+		p.keyword("savepoint")
+		name, err := p.ident()
+		return &savepointStmt{opRelease, name}, err
+	case p.keyword("set"):
+		st := &setStmt{}
+		var err error
+		if st.name, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("="); err != nil {
+			return nil, err
+		}
+		st.e, err = p.expr()
+		return st, err
+	case p.keyword("show"):
+		name, err := p.ident()
+		return &showStmt{name}, err
+	}
+	return nil, p.errorf("unsupported statement %q", p.tokenText(p.peek()))
+}
+
+func (p *parser) createTable() (any, error) {
+	if err := p.expectKeyword("table"); err != nil {
+		return nil, err
+	}
+	st := &createTable{}
+	if p.keyword("if") {
+		if err := p.expectKeyword("not"); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("exists"); err != nil {
+			return nil, err
+		}
+		st.ifNotExists = true
+	}
+	var err error
+	if st.name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	for {
+		c, err := p.columnDef()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range st.cols {
+			if d.name == c.name {
+				return nil, fmt.Errorf("sqltest: duplicate column %q", c.name)
+			}
+		}
+		st.cols = append(st.cols, c)
+		if !p.punct(",") {
+			break
+		}
+	}
+	return st, p.expectPunct(")")
+}
+
+func (p *parser) columnDef() (*column, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	typeName, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	c := &column{name: name, typeName: strings.ToUpper(typeName), length: -1}
+	var nargs int // the number of arguments the type takes
+	switch typeName {
+	case "integer", "int", "bigint", "smallint":
+		c.typ = typeInt
+	case "double":
+		p.keyword("precision")
+		fallthrough
+	case "real", "float":
+		c.typ = typeFloat
+	case "decimal", "numeric":
+		c.typ, nargs = typeFloat, 2
+	case "text":
+		c.typ, c.length = typeString, maxLength
+	case "char", "varchar":
+		c.typ, nargs = typeString, 1
+	case "blob", "bytea":
+		c.typ, c.length = typeBytes, maxLength
+	case "varbinary":
+		c.typ, nargs = typeBytes, 1
+	case "boolean", "bool":
+		c.typ = typeBool
+	case "timestamp", "datetime":
+		c.typ = typeTime
+	default:
+		return nil, fmt.Errorf("sqltest: unsupported column type %s", c.typeName)
+	}
+	if nargs > 0 && p.punct("(") {
+		var args []int64
+		for {
+			t := p.next()
+			n, err := strconv.ParseInt(t.text, 10, 64)
+			if t.kind != tokNumber || err != nil || n <= 0 {
+				return nil, fmt.Errorf("sqltest: invalid size %q of column %q", p.tokenText(t), name)
+			}
+			args = append(args, n)
+			if !p.punct(",") {
+				break
+			}
+		}
+		if len(args) > nargs {
+			return nil, fmt.Errorf("sqltest: too many sizes for column %q of type %s", name, c.typeName)
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		if nargs == 1 {
+			c.length = args[0]
+		} else {
+			c.precision, c.scale, c.decimal = args[0], 0, true
+			if len(args) > 1 {
+				c.scale = args[1]
+			}
+		}
+	} else if nargs == 1 {
+		c.length = maxLength
+	}
+	for {
+		switch {
+		case p.keyword("not"):
+			if err := p.expectKeyword("null"); err != nil {
+				return nil, err
+			}
+			c.notNull = true
+		case p.keyword("null"):
+			c.notNull = false
+		case p.keyword("primary"):
+			if err := p.expectKeyword("key"); err != nil {
+				return nil, err
+			}
+			c.primaryKey, c.notNull = true, true
+		default:
+			return c, nil
+		}
+	}
+}
+
+func (p *parser) insert() (any, error) {
+	if err := p.expectKeyword("into"); err != nil {
+		return nil, err
+	}
+	st := &insert{}
+	var err error
+	if st.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if p.punct("(") {
+		if st.cols, err = p.identList(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("values"); err != nil {
+		return nil, err
+	}
+	for {
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		var row []expr
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, e)
+			if !p.punct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		st.rows = append(st.rows, row)
+		if !p.punct(",") {
+			return st, nil
+		}
+	}
+}
+
+func (p *parser) selectStmt() (any, error) {
+	st := &selectStmt{}
+	for {
+		start := p.peek()
+		var item selectItem
+		switch {
+		case p.punct("*"):
+			item.star = true
+		case p.keyword("count"):
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct("*"); err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			item.e, item.name = countStar{}, "count"
+		case p.keyword("sleep"):
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			item.e, item.name = sleep{e}, "sleep"
+		default:
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			item.e = e
+			if c, ok := e.(columnRef); ok {
+				item.name = c.name
+			} else {
+				item.name = strings.TrimSpace(p.query[start.pos:p.peek().pos])
+			}
+		}
+		if !item.star && p.keyword("as") {
+			var err error
+			if item.name, err = p.ident(); err != nil {
+				return nil, err
+			}
+		}
+		st.items = append(st.items, item)
+		if !p.punct(",") {
+			break
+		}
+	}
+	if !p.keyword("from") {
+		for _, item := range st.items {
+			if item.star {
+				return nil, fmt.Errorf("sqltest: SELECT * without FROM")
+			}
+		}
+		return st, nil
+	}
+	var err error
+	if st.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if st.where, err = p.where(); err != nil {
+		return nil, err
+	}
+	if p.keyword("order") {
+		if err := p.expectKeyword("by"); err != nil {
+			return nil, err
+		}
+		for {
+			var o ordering
+			if o.col, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if !p.keyword("asc") {
+				o.desc = p.keyword("desc")
+			}
+			st.orderBy = append(st.orderBy, o)
+			if !p.punct(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("limit") {
+		if st.limit, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+func (p *parser) update() (any, error) {
+	st := &update{}
+	var err error
+	if st.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("set"); err != nil {
+		return nil, err
+	}
+	for {
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("="); err != nil {
+			return nil, err
+		}
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		st.cols = append(st.cols, col)
+		st.exprs = append(st.exprs, e)
+		if !p.punct(",") {
+			break
+		}
+	}
+	st.where, err = p.where()
+	return st, err
+}
+
+func (p *parser) where() ([]predicate, error) {
+	if !p.keyword("where") {
+		return nil, nil
+	}
+	var preds []predicate
+	for {
+		left, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		pred := predicate{left: left}
+		if p.keyword("is") {
+			pred.op = "is null"
+			if p.keyword("not") {
+				pred.op = "is not null"
+			}
+			if err := p.expectKeyword("null"); err != nil {
+				return nil, err
+			}
+		} else {
+			t := p.next()
+			switch t.text {
+			case "=", "<>", "!=", "<", "<=", ">", ">=":
+				if t.kind != tokPunct {
+					return nil, p.errorf("expected comparison, found %q", p.tokenText(t))
+				}
+			default:
+				return nil, p.errorf("expected comparison, found %q", p.tokenText(t))
+			}
+			pred.op = t.text
+			if pred.right, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		preds = append(preds, pred)
+		if !p.keyword("and") {
+			return preds, nil
+		}
+	}
+}
+
+func (p *parser) expr() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return parseNumber(t.text, false)
+	case tokString:
+		return literal{t.text}, nil
+	case tokParam:
+		return p.param(t)
+	case tokIdent:
+		switch t.text {
+		case "null":
+			return literal{nil}, nil
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		}
+		return columnRef{t.text}, nil
+	case tokPunct:
+		if (t.text == "-" || t.text == "+") && p.peek().kind == tokNumber {
+			return parseNumber(p.next().text, t.text == "-")
+		}
+	}
+	if t.kind != tokEOF {
+		p.pos--
+	}
+	return nil, p.errorf("expected expression, found %q", p.tokenText(t))
+}
+
+func parseNumber(s string, neg bool) (expr, error) {
+	if neg {
+		s = "-" + s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return literal{n}, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("sqltest: invalid number %q", s)
+	}
+	return literal{f}, nil
+}
+
+func (p *parser) param(t token) (expr, error) {
+	style := t.text[0]
+	if style == '@' {
+		style = ':'
+	}
+	if p.style == 0 {
+		p.style = style
+	} else if p.style != style {
+		return nil, fmt.Errorf("sqltest: mixed placeholder styles at offset %d", t.pos)
+	}
+	switch style {
+	case '?':
+		p.numParams++
+		return param{ordinal: p.numParams}, nil
+	case '$':
+		n, err := strconv.Atoi(t.text[1:])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("sqltest: invalid placeholder %q", t.text)
+		}
+		p.numParams = max(p.numParams, n)
+		return param{ordinal: n}, nil
+	}
+	return param{name: t.text[1:]}, nil
+}
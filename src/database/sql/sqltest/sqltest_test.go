@@ -0,0 +1,253 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConformance(t *testing.T) {
+	c := &Conformance{
+		Connector:   NewConnector(""),
+		MultiResult: "SELECT 1; SELECT 2",
+		Sleep:       "SELECT SLEEP(?)",
+		SetSession:  "SET sqltest_var = 'x'",
+		ShowSession: "SHOW sqltest_var",
+	}
+	c.Run(t)
+}
+
+func TestRunConformance(t *testing.T) {
+	RunConformance(t, NewConnector(""))
+}
+
+func openDB(t *testing.T) *sql.DB {
+	db := sql.OpenDB(NewConnector(""))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// queryStrings returns the values of the single column of the rows of query.
+func queryStrings(t *testing.T, db *sql.DB, query string, args ...any) []string {
+	t.Helper()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var s sql.NullString
+		if err := rows.Scan(&s); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		if !s.Valid {
+			s.String = "NULL"
+		}
+		got = append(got, s.String)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return got
+}
+
+func TestQueries(t *testing.T) {
+	db := openDB(t)
+	mustExec(t, db, "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT NOT NULL, age INT, joined TIMESTAMP)")
+	mustExec(t, db, "INSERT INTO people (id, name, age) VALUES (1, 'Alice', 30), (2, 'Bob', NULL), (3, 'Chris', 25)")
+	mustExec(t, db, "INSERT INTO people VALUES ($1, $2, $3, $4)", 4, "D'Arcy", 41, time.Unix(0, 0))
+
+	tests := []struct {
+		query string
+		args  []any
+		want  []string
+	}{
+		{"SELECT name FROM people ORDER BY id", nil, []string{"Alice", "Bob", "Chris", "D'Arcy"}},
+		{"SELECT name FROM people ORDER BY age DESC", nil, []string{"D'Arcy", "Alice", "Chris", "Bob"}},
+		{"SELECT name FROM people ORDER BY age", nil, []string{"Bob", "Chris", "Alice", "D'Arcy"}},
+		{"SELECT name FROM people WHERE age > ? ORDER BY id", []any{26}, []string{"Alice", "D'Arcy"}},
+		{"SELECT name FROM people WHERE age IS NULL", nil, []string{"Bob"}},
+		{"SELECT name FROM people WHERE age IS NOT NULL AND age <= 30 ORDER BY name DESC", nil, []string{"Chris", "Alice"}},
+		{"SELECT name FROM people WHERE name <> 'Alice' ORDER BY id LIMIT 2", nil, []string{"Bob", "Chris"}},
+		{"SELECT name FROM people WHERE id = :id", []any{sql.Named("id", 3)}, []string{"Chris"}},
+		{"SELECT name FROM people WHERE id = @id", []any{sql.Named("id", 1)}, []string{"Alice"}},
+		{"SELECT COUNT(*) FROM people", nil, []string{"4"}},
+		{"SELECT COUNT(*) FROM people WHERE age < 0", nil, []string{"0"}},
+		{"SELECT age FROM people WHERE id = 2", nil, []string{"NULL"}},
+		{"select NAME from PEOPLE where ID = 1 -- comment", nil, []string{"Alice"}},
+		{`SELECT "name" FROM people WHERE id = 1`, nil, []string{"Alice"}},
+		{"SELECT 'x' AS x", nil, []string{"x"}},
+		{"SHOW v", nil, []string{"NULL"}},
+		{"SET v = ?; SHOW v", []any{7}, []string{"7"}},
+	}
+	for _, tt := range tests {
+		if got := queryStrings(t, db, tt.query, tt.args...); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	res, err := db.Exec("UPDATE people SET age = age, name = ? WHERE age < 30", "Christine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("UPDATE affected %d rows, want 1", n)
+	}
+	res, err = db.Exec("DELETE FROM people WHERE id >= 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Errorf("DELETE affected %d rows, want 2", n)
+	}
+	if got, want := queryStrings(t, db, "SELECT name FROM people ORDER BY id"), []string{"Alice", "Bob"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	var joined time.Time
+	mustExec(t, db, "INSERT INTO people (id, name, joined) VALUES (5, 'E', '2026-01-02T03:04:05Z')")
+	if err := db.QueryRow("SELECT joined FROM people WHERE id = 5").Scan(&joined); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC); !joined.Equal(want) {
+		t.Errorf("joined = %v, want %v", joined, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	db := openDB(t)
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, name VARCHAR(3) NOT NULL, n DECIMAL(4, 2))")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 'a', 1.5)")
+
+	tests := []struct {
+		query   string
+		args    []any
+		wantErr string
+	}{
+		{"SELEKT 1", nil, "syntax error"},
+		{"SELECT 1 FROM", nil, "syntax error"},
+		{"SELECT 1 FROM t WHERE id = 1 AND", nil, "syntax error"},
+		{"SELECT 'unterminated", nil, "unterminated"},
+		{"SELECT x FROM nosuch", nil, "no table"},
+		{"SELECT nosuch FROM t", nil, "no column"},
+		{"CREATE TABLE t (id INTEGER)", nil, "already exists"},
+		{"CREATE TABLE u (id WIDGET)", nil, "unsupported column type"},
+		{"DROP TABLE nosuch", nil, "no table"},
+		{"INSERT INTO t VALUES (1, 'b', 0)", nil, "duplicate value"},
+		{"INSERT INTO t (id) VALUES (2)", nil, "NOT NULL"},
+		{"INSERT INTO t VALUES (2, 'long', 0)", nil, "too long"},
+		{"INSERT INTO t VALUES (2, 'b')", nil, "3 columns"},
+		{"INSERT INTO t VALUES ('x', 'b', 0)", nil, "cannot store"},
+		{"UPDATE t SET id = NULL", nil, "NOT NULL"},
+		{"SELECT name FROM t WHERE id = ? AND name = $2", []any{1, "a"}, "mix"},
+		{"SELECT name FROM t WHERE id = ?", nil, "no argument"},
+		{"SAVEPOINT s", nil, "require a transaction"},
+	}
+	for _, tt := range tests {
+		_, err := db.Exec(tt.query, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got error %v, want %q", tt.query, err, tt.wantErr)
+		}
+	}
+	if _, err := db.Exec("DROP TABLE IF EXISTS nosuch"); err != nil {
+		t.Errorf("DROP TABLE IF EXISTS: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS t (id INTEGER)"); err != nil {
+		t.Errorf("CREATE TABLE IF NOT EXISTS: %v", err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE t (n INTEGER)")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	// Other connections see the database as it was.
+	if got := queryStrings(t, db, "SELECT n FROM t"); len(got) != 0 {
+		t.Errorf("uncommitted rows visible outside the transaction: %q", got)
+	}
+	if err := tx.Savepoint(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO t VALUES (2); SAVEPOINT b; INSERT INTO t VALUES (3)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("ROLLBACK TO b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.RollbackTo(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Release(ctx, "b"); err == nil {
+		t.Error("releasing a savepoint rolled back past succeeded")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := queryStrings(t, db, "SELECT n FROM t"), []string{"1"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	tx, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO t VALUES (4)"); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("write in a read-only transaction: got %v, want read-only error", err)
+	}
+	tx.Rollback()
+
+	if _, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelLinearizable}); err == nil {
+		t.Error("BeginTx with LevelLinearizable succeeded")
+	}
+}
+
+func TestSharedDatabase(t *testing.T) {
+	name := t.Name()
+	db1, err := sql.Open("sqltest", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db1.Close()
+	db2, err := sql.Open("sqltest", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db2.Close()
+	mustExec(t, db1, "CREATE TABLE t (s TEXT)")
+	mustExec(t, db1, "INSERT INTO t VALUES ('shared')")
+	if got, want := queryStrings(t, db2, "SELECT s FROM t"), []string{"shared"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	private, err := sql.Open("sqltest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer private.Close()
+	if _, err := private.Exec("SELECT s FROM t"); err == nil {
+		t.Error("private database sees a named database's table")
+	}
+}
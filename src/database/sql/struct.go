@@ -0,0 +1,193 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// structFields are the index sequences of the fields of a struct type that
+// map to columns or named parameters, by lower-cased name.
+type structFields map[string][]int
+
+var fieldCache sync.Map // map[reflect.Type]structFields
+
+// fieldsOf returns the fields of the struct type t that map to columns.
+//
+// A field maps to the column named by its "db" tag or, without one, to the
+// column with its name, ignoring case. A tag of "-" ignores the field. The
+// fields of embedded structs map to columns as if they were fields of t,
+// unless they are hidden by a field of the same name at a shallower depth,
+// following the rules of Go for selectors. Fields of the same name at the
+// same depth are ignored, as ambiguous.
+func fieldsOf(t reflect.Type) structFields {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(structFields)
+	}
+
+	type candidate struct {
+		index []int
+		count int // the number of fields with this name at its depth
+	}
+	byName := make(map[string]*candidate)
+	var walk func(t reflect.Type, index []int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag, tagged := sf.Tag.Lookup("db")
+			if tag == "-" {
+				continue
+			}
+			idx := append(index[:len(index):len(index)], i)
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				if sf.Anonymous && !sf.IsExported() {
+					// The pointer cannot be allocated.
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct && !isScannerType(ft) {
+				walk(ft, idx, visited)
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			name := sf.Name
+			if tagged && tag != "" {
+				name = tag
+			}
+			key := strings.ToLower(name)
+			switch c := byName[key]; {
+			case c == nil || len(idx) < len(c.index):
+				byName[key] = &candidate{idx, 1}
+			case len(idx) == len(c.index):
+				c.count++
+			}
+		}
+	}
+	walk(t, nil, make(map[reflect.Type]bool))
+
+	fields := make(structFields, len(byName))
+	for key, c := range byName {
+		if c.count == 1 {
+			fields[key] = c.index
+		}
+	}
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.(structFields)
+}
+
+var (
+	scannerType = reflect.TypeFor[Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// isScannerType reports whether a struct type t holds a single column
+// value, rather than fields that map to columns.
+func isScannerType(t reflect.Type) bool {
+	return t == timeType || reflect.PointerTo(t).Implements(scannerType)
+}
+
+// fieldByIndex returns the field of v with the given index sequence,
+// allocating the nil pointers to embedded structs on the way if alloc is
+// set, and whether the field exists.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// ScanStruct copies the columns in the current row into the fields of the
+// struct pointed at by dest.
+//
+// A field maps to the column named by its "db" tag, as in
+//
+//	Name string `db:"full_name"`
+//
+// or, without one, to the column with its name, ignoring case. A tag of "-"
+// ignores the field. The fields of embedded structs map to columns as if
+// they were fields of dest, allocating embedded struct pointers as needed.
+// Fields are scanned like the arguments of [Rows.Scan], so that fields of
+// types such as [NullString], [Null] or types implementing [Scanner] can
+// hold NULL values.
+//
+// ScanStruct returns an error, without scanning the row, if a column has no
+// matching field. Fields without a matching column are left unchanged.
+func (rs *Rows) ScanStruct(dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sql: ScanStruct: destination not a non-nil pointer to a struct: %T", dest)
+	}
+	cols, err := rs.Columns()
+	if err != nil {
+		return err
+	}
+	v = v.Elem()
+	fields := fieldsOf(v.Type())
+	var missing []string
+	for _, c := range cols {
+		if _, ok := fields[strings.ToLower(c)]; !ok {
+			missing = append(missing, strconv.Quote(c))
+		}
+	}
+	if missing != nil {
+		return fmt.Errorf("sql: ScanStruct: no field in %v for column(s) %s", v.Type(), strings.Join(missing, ", "))
+	}
+	args := make([]any, len(cols))
+	for i, c := range cols {
+		f, _ := fieldByIndex(v, fields[strings.ToLower(c)], true)
+		args[i] = f.Addr().Interface()
+	}
+	return rs.Scan(args...)
+}
+
+// ScanStructs returns an iterator over the rows of rs, scanned into values
+// of the struct type T as if by [Rows.ScanStruct], and closes rs when done.
+//
+// An error from scanning a row or from [Rows.Err] is yielded with the zero
+// T, and ends the iteration.
+func ScanStructs[T any](rs *Rows) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer rs.Close()
+		for rs.Next() {
+			var v T
+			if err := rs.ScanStruct(&v); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err := rs.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
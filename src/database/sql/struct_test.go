@@ -0,0 +1,190 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sql
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type personName struct {
+	Name string
+}
+
+type Portrait struct {
+	Photo []byte `db:"photo"`
+}
+
+type person struct {
+	personName
+	*Portrait
+	Years    int32      `db:"age"`
+	Dead     Null[bool] `db:"dead"`
+	Birthday NullTime   `db:"bdate"`
+	Ignored  string     `db:"-"`
+}
+
+func TestScanStruct(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	rows, err := db.Query("SELECT|people|age,name,photo,dead,bdate|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []person
+	for rows.Next() {
+		p := person{Ignored: "ignored"}
+		if err := rows.ScanStruct(&p); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []person{
+		{personName{"Alice"}, &Portrait{[]byte("APHOTO")}, 1, Null[bool]{}, NullTime{}, "ignored"},
+		{personName{"Bob"}, &Portrait{[]byte("BPHOTO")}, 2, Null[bool]{}, NullTime{}, "ignored"},
+		{personName{"Chris"}, &Portrait{[]byte("CPHOTO")}, 3, Null[bool]{}, NullTime{chrisBirthday, true}, "ignored"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestScanStructErrors(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	rows, err := db.Query("SELECT|people|age,name,photo,dead|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("no rows")
+	}
+	var p struct {
+		Age int
+	}
+	for _, tt := range []struct {
+		dest any
+		want string
+	}{
+		{p, "destination not a non-nil pointer to a struct"},
+		{(*person)(nil), "destination not a non-nil pointer to a struct"},
+		{new(int), "destination not a non-nil pointer to a struct"},
+		{&p, `no field in struct { Age int } for column(s) "name", "photo", "dead"`},
+	} {
+		err := rows.ScanStruct(tt.dest)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ScanStruct(%T): got error %v, want %q", tt.dest, err, tt.want)
+		}
+	}
+	// A failed ScanStruct leaves the destination unchanged.
+	if p.Age != 0 {
+		t.Errorf("Age = %d, want 0", p.Age)
+	}
+}
+
+func rowsClosed(rs *Rows) bool {
+	rs.closemu.RLock()
+	defer rs.closemu.RUnlock()
+	return rs.closed
+}
+
+func TestScanStructs(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	type row struct {
+		Name string
+		Age  int
+	}
+	rows, err := db.Query("SELECT|people|name,age|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []row
+	for r, err := range ScanStructs[row](rows) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if want := []row{{"Alice", 1}, {"Bob", 2}, {"Chris", 3}}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !rowsClosed(rows) {
+		t.Error("rows not closed after iterating")
+	}
+
+	// Stopping early closes the rows too.
+	rows, err = db.Query("SELECT|people|name,age|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range ScanStructs[row](rows) {
+		break
+	}
+	if !rowsClosed(rows) {
+		t.Error("rows not closed after stopping early")
+	}
+
+	// Errors end the iteration.
+	rows, err = db.Query("SELECT|people|name,age,photo|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, err := range ScanStructs[row](rows) {
+		n++
+		if err == nil || !strings.Contains(err.Error(), `"photo"`) {
+			t.Errorf("got error %v, want one about the photo column", err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("got %d values, want 1", n)
+	}
+}
+
+func TestFieldsOf(t *testing.T) {
+	type inner struct {
+		A, B, C int
+	}
+	type other struct {
+		B, D int
+	}
+	type hidden struct {
+		E int
+	}
+	type outer struct {
+		inner
+		other
+		*hidden   // cannot be allocated, so ignored
+		C         string
+		Created   time.Time
+		NullTime      // embedded scanners are single columns
+		Tagged    int `db:"tagged_name"`
+		unexports int
+	}
+	got := fieldsOf(reflect.TypeFor[outer]())
+	want := structFields{
+		"a":           {0, 0},
+		"d":           {1, 1},
+		"c":           {3},
+		"created":     {4},
+		"nulltime":    {5},
+		"tagged_name": {6},
+	}
+	// B is ambiguous, as inner.B and other.B are at the same depth.
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,403 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"bytes"
+	"math/big"
+	"slices"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// A Builder builds DER-encoded ASN.1 data by appending elements to a byte
+// slice, without the reflection used by [Marshal].
+//
+// Constructed elements, such as a SEQUENCE, are built by passing a function
+// that adds their components to the same Builder. The encoding is written
+// directly into a single buffer, whose length prefixes are adjusted as each
+// constructed element is completed.
+//
+// If an element cannot be encoded, the Builder records the error and
+// ignores all further calls; the error is returned by [Builder.Bytes].
+type Builder struct {
+	buf []byte
+	err error
+
+	// implicit reports whether the next element is implicitly tagged
+	// with implicitClass and implicitTag.
+	implicit                   bool
+	implicitClass, implicitTag int
+}
+
+// NewBuilder returns a new Builder that appends to buf.
+func NewBuilder(buf []byte) *Builder {
+	return &Builder{buf: buf}
+}
+
+// Bytes returns the encoded data, or the first error that occurred while
+// building it.
+func (b *Builder) Bytes() ([]byte, error) {
+	if b.err == nil && b.implicit {
+		b.err = StructuralError{"implicit tag without element"}
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.buf, nil
+}
+
+// SetError sets the error of the Builder, unless one is already set,
+// so that the functions building a constructed element can fail.
+func (b *Builder) SetError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Implicit sets the tag of the next element added to the Builder to the
+// given class and tag number, replacing its universal tag as for an
+// IMPLICIT tag in an ASN.1 module. The element remains constructed or
+// primitive as its type requires.
+func (b *Builder) Implicit(class, tag int) {
+	if class < ClassUniversal || class > ClassPrivate || tag < 0 {
+		b.SetError(StructuralError{"invalid implicit tag"})
+		return
+	}
+	b.implicit = true
+	b.implicitClass, b.implicitTag = class, tag
+}
+
+// begin appends the identifier of an element with the given tag and a
+// placeholder length, to be completed by end.
+// It returns the offset of the contents.
+func (b *Builder) begin(class, tag int, compound bool) int {
+	if b.implicit {
+		class, tag = b.implicitClass, b.implicitTag
+		b.implicit = false
+	}
+	b.buf = appendTagAndLength(b.buf, tagAndLength{class: class, tag: tag, isCompound: compound})
+	return len(b.buf)
+}
+
+// end sets the length of the element whose contents start at off
+// to the length of the data appended since, moving the contents if
+// the length does not fit in the placeholder.
+func (b *Builder) end(off int) {
+	n := len(b.buf) - off
+	if n < 0x80 {
+		b.buf[off-1] = byte(n)
+		return
+	}
+	l := lengthLength(n)
+	b.buf = append(b.buf, make([]byte, l)...)
+	copy(b.buf[off+l:], b.buf[off:off+n])
+	b.buf[off-1] = 0x80 | byte(l)
+	appendLength(b.buf[off:off], n)
+}
+
+// add appends a primitive element with the given universal tag and contents.
+func (b *Builder) add(tag int, contents []byte) {
+	if b.err != nil {
+		return
+	}
+	off := b.begin(ClassUniversal, tag, false)
+	b.buf = append(b.buf, contents...)
+	b.end(off)
+}
+
+// addConstructed appends a constructed element whose contents are added by f.
+func (b *Builder) addConstructed(class, tag int, f func(*Builder)) int {
+	if b.err != nil {
+		return -1
+	}
+	off := b.begin(class, tag, true)
+	f(b)
+	if b.err != nil {
+		return -1
+	}
+	if b.implicit {
+		b.err = StructuralError{"implicit tag without element"}
+		return -1
+	}
+	return off
+}
+
+// AddBoolean appends a BOOLEAN.
+func (b *Builder) AddBoolean(v bool) {
+	if v {
+		b.add(TagBoolean, []byte{0xff})
+	} else {
+		b.add(TagBoolean, []byte{0})
+	}
+}
+
+// AddInt64 appends an INTEGER.
+func (b *Builder) AddInt64(v int64) {
+	b.addInt(TagInteger, v)
+}
+
+// AddEnumerated appends an ENUMERATED.
+func (b *Builder) AddEnumerated(v int64) {
+	b.addInt(TagEnum, v)
+}
+
+func (b *Builder) addInt(tag int, v int64) {
+	if b.err != nil {
+		return
+	}
+	off := b.begin(ClassUniversal, tag, false)
+	e := int64Encoder(v)
+	n := e.Len()
+	b.buf = slices.Grow(b.buf, n)[:len(b.buf)+n]
+	e.Encode(b.buf[off:])
+	b.end(off)
+}
+
+// AddUint64 appends an INTEGER.
+func (b *Builder) AddUint64(v uint64) {
+	if v <= 1<<63-1 {
+		b.AddInt64(int64(v))
+		return
+	}
+	b.add(TagInteger, []byte{0, byte(v >> 56), byte(v >> 48), byte(v >> 40),
+		byte(v >> 32), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+// AddBigInt appends an INTEGER.
+func (b *Builder) AddBigInt(v *big.Int) {
+	if b.err != nil {
+		return
+	}
+	e, err := makeBigInt(v)
+	if err != nil {
+		b.err = err
+		return
+	}
+	off := b.begin(ClassUniversal, TagInteger, false)
+	n := e.Len()
+	b.buf = slices.Grow(b.buf, n)[:len(b.buf)+n]
+	e.Encode(b.buf[off:])
+	b.end(off)
+}
+
+// AddNull appends a NULL.
+func (b *Builder) AddNull() {
+	b.add(TagNull, nil)
+}
+
+// AddOctetString appends an OCTET STRING.
+func (b *Builder) AddOctetString(v []byte) {
+	b.add(TagOctetString, v)
+}
+
+// AddBitString appends a BIT STRING. The length of v.Bytes must be the
+// number of bytes needed to hold v.BitLength bits. The padding bits are
+// encoded as zero, as DER requires.
+func (b *Builder) AddBitString(v BitString) {
+	if b.err != nil {
+		return
+	}
+	if v.BitLength < 0 || len(v.Bytes) != (v.BitLength+7)/8 {
+		b.err = StructuralError{"invalid BIT STRING length"}
+		return
+	}
+	off := b.begin(ClassUniversal, TagBitString, false)
+	padding := (8 - v.BitLength%8) % 8
+	b.buf = append(b.buf, byte(padding))
+	b.buf = append(b.buf, v.Bytes...)
+	if padding > 0 {
+		b.buf[len(b.buf)-1] &^= 1<<padding - 1
+	}
+	b.end(off)
+}
+
+// AddObjectIdentifier appends an OBJECT IDENTIFIER.
+func (b *Builder) AddObjectIdentifier(v ObjectIdentifier) {
+	if b.err != nil {
+		return
+	}
+	if _, err := makeObjectIdentifier(v); err != nil || slices.ContainsFunc(v, func(c int) bool { return c < 0 }) {
+		b.err = StructuralError{"invalid object identifier"}
+		return
+	}
+	off := b.begin(ClassUniversal, TagOID, false)
+	b.buf = appendBase128Int(b.buf, int64(v[0]*40+v[1]))
+	for _, c := range v[2:] {
+		b.buf = appendBase128Int(b.buf, int64(c))
+	}
+	b.end(off)
+}
+
+// AddString appends a character string of the type given by tag, which
+// must be one of [TagUTF8String], [TagPrintableString], [TagIA5String],
+// [TagNumericString], or [TagBMPString]. The string s is UTF-8 encoded and
+// is converted to UCS-2 for a BMPString. It is an error if s contains
+// characters that cannot be represented in the string type.
+func (b *Builder) AddString(tag int, s string) {
+	if b.err != nil {
+		return
+	}
+	var err error
+	switch tag {
+	case TagUTF8String:
+		if !utf8.ValidString(s) {
+			err = StructuralError{"invalid UTF-8 string"}
+		}
+	case TagPrintableString:
+		_, err = makePrintableString(s)
+	case TagIA5String:
+		_, err = makeIA5String(s)
+	case TagNumericString:
+		_, err = makeNumericString(s)
+	case TagBMPString:
+		off := b.begin(ClassUniversal, tag, false)
+		for _, r := range s {
+			if r == utf8.RuneError || r > 0xffff || utf16.IsSurrogate(r) {
+				b.err = StructuralError{"BMPString contains invalid character"}
+				return
+			}
+			b.buf = append(b.buf, byte(r>>8), byte(r))
+		}
+		b.end(off)
+		return
+	default:
+		err = StructuralError{"unsupported string type"}
+	}
+	if err != nil {
+		b.err = err
+		return
+	}
+	off := b.begin(ClassUniversal, tag, false)
+	b.buf = append(b.buf, s...)
+	b.end(off)
+}
+
+// AddUTCTime appends a UTCTime, which represents years from 1950 to 2049.
+// The time is converted to UTC and truncated to whole seconds.
+func (b *Builder) AddUTCTime(t time.Time) {
+	if b.err != nil {
+		return
+	}
+	off := b.begin(ClassUniversal, TagUTCTime, false)
+	var err error
+	if b.buf, err = appendUTCTime(b.buf, t.UTC()); err != nil {
+		b.err = err
+		return
+	}
+	b.end(off)
+}
+
+// AddGeneralizedTime appends a GeneralizedTime. The time is converted to
+// UTC and its fractional seconds, if any, are encoded without trailing
+// zeros, as DER requires.
+func (b *Builder) AddGeneralizedTime(t time.Time) {
+	if b.err != nil {
+		return
+	}
+	off := b.begin(ClassUniversal, TagGeneralizedTime, false)
+	t = t.UTC()
+	var err error
+	if b.buf, err = appendGeneralizedTime(b.buf, t); err != nil {
+		b.err = err
+		return
+	}
+	if ns := t.Nanosecond(); ns > 0 {
+		b.buf = b.buf[:len(b.buf)-1] // remove the 'Z'
+		b.buf = append(b.buf, '.')
+		for ns > 0 {
+			b.buf = append(b.buf, byte('0'+ns/1e8))
+			ns = ns % 1e8 * 10
+		}
+		b.buf = append(b.buf, 'Z')
+	}
+	b.end(off)
+}
+
+// AddSequence appends a SEQUENCE or SEQUENCE OF whose components are
+// added by f.
+func (b *Builder) AddSequence(f func(*Builder)) {
+	if off := b.addConstructed(ClassUniversal, TagSequence, f); off >= 0 {
+		b.end(off)
+	}
+}
+
+// AddSet appends a SET whose components are added by f.
+// DER requires the components to be added in ascending order of their tags.
+func (b *Builder) AddSet(f func(*Builder)) {
+	if off := b.addConstructed(ClassUniversal, TagSet, f); off >= 0 {
+		b.end(off)
+	}
+}
+
+// AddSetOf appends a SET OF whose elements are added by f.
+// The encoded elements are sorted, as DER requires.
+func (b *Builder) AddSetOf(f func(*Builder)) {
+	off := b.addConstructed(ClassUniversal, TagSet, f)
+	if off < 0 {
+		return
+	}
+	var elems [][]byte
+	for rest := b.buf[off:]; len(rest) > 0; {
+		t, n, err := parseTagAndLength(rest, 0)
+		if err != nil || n+t.length > len(rest) {
+			b.err = StructuralError{"invalid SET OF element"}
+			return
+		}
+		elems = append(elems, rest[:n+t.length])
+		rest = rest[n+t.length:]
+	}
+	// See setEncoder.Encode for why bytes.Compare gives the DER order.
+	if !slices.IsSortedFunc(elems, bytes.Compare) {
+		slices.SortFunc(elems, bytes.Compare)
+		sorted := make([]byte, 0, len(b.buf)-off)
+		for _, e := range elems {
+			sorted = append(sorted, e...)
+		}
+		copy(b.buf[off:], sorted)
+	}
+	b.end(off)
+}
+
+// AddExplicit appends a constructed element with the given class and tag
+// number, as for an EXPLICIT tag in an ASN.1 module, whose contents are
+// added by f.
+func (b *Builder) AddExplicit(class, tag int, f func(*Builder)) {
+	if b.err != nil {
+		return
+	}
+	if class < ClassUniversal || class > ClassPrivate || tag < 0 {
+		b.err = StructuralError{"invalid explicit tag"}
+		return
+	}
+	if off := b.addConstructed(class, tag, f); off >= 0 {
+		b.end(off)
+	}
+}
+
+// AddRawValue appends v. If v.FullBytes is set, it is appended unchanged
+// and must not be preceded by a call to [Builder.Implicit]. Otherwise, an
+// element is appended with the class, tag, and contents of v.
+func (b *Builder) AddRawValue(v RawValue) {
+	if b.err != nil {
+		return
+	}
+	if len(v.FullBytes) > 0 {
+		if b.implicit {
+			b.err = StructuralError{"implicit tag for encoded RawValue"}
+			return
+		}
+		b.buf = append(b.buf, v.FullBytes...)
+		return
+	}
+	if v.Class < ClassUniversal || v.Class > ClassPrivate || v.Tag < 0 {
+		b.err = StructuralError{"invalid RawValue tag"}
+		return
+	}
+	off := b.begin(v.Class, v.Tag, v.IsCompound)
+	b.buf = append(b.buf, v.Bytes...)
+	b.end(off)
+}
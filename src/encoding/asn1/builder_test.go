@@ -0,0 +1,210 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilderMatchesMarshal(t *testing.T) {
+	type inner struct {
+		N int64 `asn1:"explicit,tag:0"`
+		S string
+	}
+	type outer struct {
+		B    bool
+		I    int64
+		Big  *big.Int
+		E    Enumerated
+		Oct  []byte
+		Bits BitString
+		OID  ObjectIdentifier
+		P    string    `asn1:"printable"`
+		IA5  string    `asn1:"ia5"`
+		U    time.Time `asn1:"utc"`
+		G    time.Time `asn1:"generalized"`
+		In   inner
+		Imp  []byte `asn1:"tag:5,application"`
+		Set  []int  `asn1:"set"`
+		Long []byte
+	}
+	v := outer{
+		B:    true,
+		I:    -129,
+		Big:  new(big.Int).Lsh(big.NewInt(-1), 70),
+		E:    3,
+		Oct:  []byte("octets"),
+		Bits: BitString{Bytes: []byte{0xa0}, BitLength: 3},
+		OID:  ObjectIdentifier{1, 2, 840, 113549},
+		P:    "Printable",
+		IA5:  "ia5@example.com",
+		U:    time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC),
+		G:    time.Date(2050, 1, 2, 3, 4, 5, 0, time.UTC),
+		In:   inner{N: 1 << 40, S: "héllo"},
+		Imp:  []byte{1, 2},
+		Set:  []int{300, 2, 1},
+		Long: bytes.Repeat([]byte{'x'}, 300),
+	}
+	want, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuilder(nil)
+	b.AddSequence(func(b *Builder) {
+		b.AddBoolean(v.B)
+		b.AddInt64(v.I)
+		b.AddBigInt(v.Big)
+		b.AddEnumerated(int64(v.E))
+		b.AddOctetString(v.Oct)
+		b.AddBitString(v.Bits)
+		b.AddObjectIdentifier(v.OID)
+		b.AddString(TagPrintableString, v.P)
+		b.AddString(TagIA5String, v.IA5)
+		b.AddUTCTime(v.U)
+		b.AddGeneralizedTime(v.G)
+		b.AddSequence(func(b *Builder) {
+			b.AddExplicit(ClassContextSpecific, 0, func(b *Builder) {
+				b.AddInt64(v.In.N)
+			})
+			b.AddString(TagUTF8String, v.In.S)
+		})
+		b.Implicit(ClassApplication, 5)
+		b.AddOctetString(v.Imp)
+		b.AddSetOf(func(b *Builder) {
+			for _, n := range v.Set {
+				b.AddInt64(int64(n))
+			}
+		})
+		b.AddOctetString(v.Long)
+	})
+	got, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Builder:\ngot  %x\nwant %x", got, want)
+	}
+}
+
+func TestBuilderValues(t *testing.T) {
+	tests := []struct {
+		add  func(*Builder)
+		want string
+	}{
+		{func(b *Builder) { b.AddUint64(1<<64 - 1) }, "020900ffffffffffffffff"},
+		{func(b *Builder) { b.AddUint64(128) }, "02020080"},
+		{func(b *Builder) { b.AddNull() }, "0500"},
+		{func(b *Builder) { b.AddBitString(BitString{Bytes: []byte{0xff}, BitLength: 4}) }, "030204f0"},
+		{func(b *Builder) { b.AddString(TagBMPString, "é€") }, "1e0400e920ac"},
+		{func(b *Builder) {
+			b.AddGeneralizedTime(time.Date(2024, 1, 2, 4, 4, 5, 120000000, time.FixedZone("", 3600)))
+		}, "181232303234303130323033303430352e31325a"},
+		{func(b *Builder) { b.AddUTCTime(time.Date(2049, 1, 2, 3, 4, 5, 0, time.UTC)) }, "170d3439303130323033303430355a"},
+		{func(b *Builder) {
+			b.Implicit(ClassContextSpecific, 40)
+			b.AddSequence(func(b *Builder) { b.AddNull() })
+		}, "bf28020500"},
+		{func(b *Builder) { b.AddRawValue(RawValue{Class: ClassPrivate, Tag: 1, Bytes: []byte{7}}) }, "c10107"},
+		{func(b *Builder) { b.AddRawValue(RawValue{FullBytes: NullBytes}) }, "0500"},
+		{func(b *Builder) {
+			b.AddSet(func(b *Builder) {
+				b.AddBoolean(false)
+				b.AddInt64(0)
+			})
+		}, "3106010100020100"},
+	}
+	for _, tt := range tests {
+		b := NewBuilder(nil)
+		tt.add(b)
+		got, err := b.Bytes()
+		if err != nil {
+			t.Errorf("%s: %v", tt.want, err)
+			continue
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("got %x, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestBuilderNestedLongForm(t *testing.T) {
+	// Each nested element grows past the short form of the length
+	// only once its contents are complete.
+	b := NewBuilder([]byte{0xaa})
+	b.AddSequence(func(b *Builder) {
+		b.AddSequence(func(b *Builder) {
+			b.AddOctetString(make([]byte, 200))
+			b.AddSequence(func(b *Builder) {
+				b.AddOctetString(make([]byte, 70000))
+			})
+		})
+		b.AddNull()
+	})
+	got, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != 0xaa {
+		t.Fatalf("prefix of buffer overwritten")
+	}
+	p := NewParser(got[1:])
+	seq, err := p.ReadSequence()
+	if err != nil || !p.Empty() {
+		t.Fatalf("ReadSequence: %v", err)
+	}
+	inner, err := seq.ReadSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oct, err := inner.ReadOctetString(); err != nil || len(oct) != 200 {
+		t.Fatalf("first OCTET STRING: %d bytes, %v", len(oct), err)
+	}
+	innermost, err := inner.ReadSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oct, err := innermost.ReadOctetString(); err != nil || len(oct) != 70000 {
+		t.Fatalf("second OCTET STRING: %d bytes, %v", len(oct), err)
+	}
+	if err := seq.ReadNull(); err != nil || !seq.Empty() {
+		t.Fatalf("ReadNull: %v", err)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		add  func(*Builder)
+		want string
+	}{
+		{func(b *Builder) { b.AddObjectIdentifier(ObjectIdentifier{3, 1}) }, "invalid object identifier"},
+		{func(b *Builder) { b.AddString(TagPrintableString, "a@b") }, "PrintableString"},
+		{func(b *Builder) { b.AddString(TagBMPString, "😀") }, "BMPString"},
+		{func(b *Builder) { b.AddString(TagT61String, "x") }, "unsupported string type"},
+		{func(b *Builder) { b.AddUTCTime(time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC)) }, "UTCTime"},
+		{func(b *Builder) { b.AddBitString(BitString{Bytes: []byte{1, 2}, BitLength: 3}) }, "BIT STRING"},
+		{func(b *Builder) { b.AddBigInt(nil) }, "empty integer"},
+		{func(b *Builder) { b.Implicit(ClassContextSpecific, 0) }, "implicit tag without element"},
+		{func(b *Builder) {
+			b.AddSequence(func(b *Builder) {
+				b.SetError(StructuralError{"custom"})
+				b.AddNull()
+			})
+			b.AddNull()
+		}, "custom"},
+	}
+	for _, tt := range tests {
+		b := NewBuilder(nil)
+		tt.add(b)
+		if _, err := b.Bytes(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Bytes() error = %v, want %q", err, tt.want)
+		}
+	}
+}
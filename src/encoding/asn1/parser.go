@@ -0,0 +1,434 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// A Parser reads ASN.1 elements one at a time from a byte slice,
+// without the reflection used by [Unmarshal].
+//
+// A Parser created by [NewParser] accepts only DER. One created by
+// [NewBERParser] also accepts the other encodings allowed by BER:
+// indefinite and non-minimal lengths, constructed strings, and booleans
+// encoded as any non-zero value.
+//
+// The methods that read an element of a given type return an error,
+// without consuming the element, if the next element has a different tag
+// or is invalid. Thus, a CHOICE or an OPTIONAL element can be read by
+// trying each alternative or by checking [Parser.PeekTag] first.
+// The values returned share memory with the input where possible.
+type Parser struct {
+	data []byte
+	ber  bool
+
+	// implicit reports whether the next element is expected to be
+	// implicitly tagged with implicitClass and implicitTag.
+	implicit                   bool
+	implicitClass, implicitTag int
+}
+
+// NewParser returns a Parser reading the DER-encoded elements in data.
+func NewParser(data []byte) *Parser {
+	return &Parser{data: data}
+}
+
+// NewBERParser returns a Parser reading the BER-encoded elements in data.
+func NewBERParser(data []byte) *Parser {
+	return &Parser{data: data, ber: true}
+}
+
+// Empty reports whether all of the elements have been read.
+func (p *Parser) Empty() bool {
+	return len(p.data) == 0
+}
+
+// Rest returns the unread data.
+func (p *Parser) Rest() []byte {
+	return p.data
+}
+
+// Implicit sets the tag expected for the next element read to the given
+// class and tag number, in place of its universal tag, as for an IMPLICIT
+// tag in an ASN.1 module.
+func (p *Parser) Implicit(class, tag int) {
+	p.implicit = true
+	p.implicitClass, p.implicitTag = class, tag
+}
+
+// PeekTag returns the tag of the next element without reading it.
+// It returns ok == false if there are no more elements or the identifier
+// of the next one is invalid.
+func (p *Parser) PeekTag() (class, tag int, compound, ok bool) {
+	if len(p.data) == 0 {
+		return 0, 0, false, false
+	}
+	t, _, err := parseHeader(p.data, p.ber)
+	if err != nil {
+		return 0, 0, false, false
+	}
+	return t.class, t.tag, t.isCompound, true
+}
+
+// maxBERDepth limits the nesting of indefinite-length elements.
+const maxBERDepth = 100
+
+// parseHeader parses the identifier and length octets at the start of data.
+// For an indefinite length in BER, the returned length is -1.
+func parseHeader(data []byte, ber bool) (t tagAndLength, n int, err error) {
+	if len(data) == 0 {
+		return t, 0, SyntaxError{"data truncated"}
+	}
+	if !ber {
+		return parseTagAndLength(data, 0)
+	}
+	b := data[0]
+	n = 1
+	t.class = int(b >> 6)
+	t.isCompound = b&0x20 == 0x20
+	t.tag = int(b & 0x1f)
+	if t.tag == 0x1f {
+		if t.tag, n, err = parseBase128Int(data, n); err != nil {
+			return
+		}
+	}
+	if n >= len(data) {
+		return t, 0, SyntaxError{"truncated tag or length"}
+	}
+	b = data[n]
+	n++
+	switch {
+	case b&0x80 == 0:
+		t.length = int(b)
+	case b == 0x80:
+		if !t.isCompound {
+			return t, 0, SyntaxError{"indefinite length of primitive element"}
+		}
+		t.length = -1
+	default:
+		numBytes := int(b & 0x7f)
+		if numBytes == 0x7f {
+			return t, 0, SyntaxError{"reserved length"}
+		}
+		for range numBytes {
+			if n >= len(data) {
+				return t, 0, SyntaxError{"truncated tag or length"}
+			}
+			if t.length >= 1<<23 {
+				return t, 0, StructuralError{"length too large"}
+			}
+			t.length = t.length<<8 | int(data[n])
+			n++
+		}
+	}
+	return t, n, nil
+}
+
+// parseElement parses the element at the start of data, returning its
+// header, its contents, and its total length.
+func parseElement(data []byte, ber bool, depth int) (t tagAndLength, contents []byte, n int, err error) {
+	t, n, err = parseHeader(data, ber)
+	if err != nil {
+		return
+	}
+	if t.length >= 0 {
+		if t.length > len(data)-n {
+			return t, nil, 0, SyntaxError{"data truncated"}
+		}
+		return t, data[n : n+t.length], n + t.length, nil
+	}
+	if depth >= maxBERDepth {
+		return t, nil, 0, StructuralError{"indefinite-length elements nested too deeply"}
+	}
+	start := n
+	for {
+		if len(data)-n >= 2 && data[n] == 0 && data[n+1] == 0 {
+			return t, data[start:n], n + 2, nil
+		}
+		if n >= len(data) {
+			return t, nil, 0, SyntaxError{"data truncated"}
+		}
+		_, _, m, err := parseElement(data[n:], ber, depth+1)
+		if err != nil {
+			return t, nil, 0, err
+		}
+		n += m
+	}
+}
+
+// next reads the next element, which must have the expected tag,
+// and returns its header and contents.
+func (p *Parser) next(class, tag int, compound bool) (t tagAndLength, contents []byte, err error) {
+	universal := tag
+	if p.implicit {
+		class, tag = p.implicitClass, p.implicitTag
+	}
+	t, contents, n, err := parseElement(p.data, p.ber, 0)
+	if err != nil {
+		return t, nil, err
+	}
+	if t.class != class || t.tag != tag {
+		return t, nil, StructuralError{fmt.Sprintf("tags don't match: expected class %d tag %d, found class %d tag %d", class, tag, t.class, t.tag)}
+	}
+	if t.isCompound != compound && !(p.ber && t.isCompound && canBeConstructed(universal)) {
+		if compound {
+			return t, nil, StructuralError{"expected constructed element"}
+		}
+		return t, nil, StructuralError{"expected primitive element"}
+	}
+	p.data = p.data[n:]
+	p.implicit = false
+	return t, contents, nil
+}
+
+// canBeConstructed reports whether BER allows the string type with
+// the given universal tag to be encoded as a constructed element.
+func canBeConstructed(tag int) bool {
+	switch tag {
+	case TagBitString, TagOctetString, TagUTF8String, TagNumericString,
+		TagPrintableString, TagT61String, TagIA5String, TagUTCTime,
+		TagGeneralizedTime, TagGeneralString, TagBMPString:
+		return true
+	}
+	return false
+}
+
+// readPrimitive reads an element of the primitive type with the given
+// universal tag and returns its contents. In BER, the segments of a
+// constructed string are joined.
+func (p *Parser) readPrimitive(tag int) ([]byte, error) {
+	saved := *p
+	t, contents, err := p.next(ClassUniversal, tag, false)
+	if err != nil || !t.isCompound {
+		return contents, err
+	}
+	if contents, err = joinSegments(contents, tag, 0); err != nil {
+		*p = saved
+		return nil, err
+	}
+	return contents, nil
+}
+
+// joinSegments returns the contents of the BER constructed string whose
+// segments are in data, as for the equivalent primitive encoding.
+func joinSegments(data []byte, tag, depth int) ([]byte, error) {
+	if depth >= maxBERDepth {
+		return nil, StructuralError{"constructed string nested too deeply"}
+	}
+	var out []byte
+	if tag == TagBitString {
+		out = []byte{0}
+	}
+	for len(data) > 0 {
+		t, contents, n, err := parseElement(data, true, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if t.class != ClassUniversal || t.tag != tag {
+			return nil, StructuralError{"invalid segment of constructed string"}
+		}
+		if t.isCompound {
+			if contents, err = joinSegments(contents, tag, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		if tag == TagBitString {
+			if len(contents) == 0 || out[0] != 0 {
+				return nil, SyntaxError{"invalid padding bits in BIT STRING"}
+			}
+			out[0] = contents[0]
+			contents = contents[1:]
+		}
+		out = append(out, contents...)
+		data = data[n:]
+	}
+	return out, nil
+}
+
+// readConstructed reads a constructed element with the given tag
+// and returns a Parser for its contents.
+func (p *Parser) readConstructed(class, tag int) (Parser, error) {
+	_, contents, err := p.next(class, tag, true)
+	if err != nil {
+		return Parser{}, err
+	}
+	return Parser{data: contents, ber: p.ber}, nil
+}
+
+// ReadRawValue reads the next element, whatever its tag. Its Bytes field
+// holds the contents of the element, excluding the end-of-contents octets
+// of a BER indefinite length, and FullBytes holds the whole encoding.
+// A tag set by [Parser.Implicit] is ignored.
+func (p *Parser) ReadRawValue() (RawValue, error) {
+	t, contents, n, err := parseElement(p.data, p.ber, 0)
+	if err != nil {
+		return RawValue{}, err
+	}
+	v := RawValue{Class: t.class, Tag: t.tag, IsCompound: t.isCompound, Bytes: contents, FullBytes: p.data[:n]}
+	p.data = p.data[n:]
+	p.implicit = false
+	return v, nil
+}
+
+// Skip reads the next element and discards it.
+func (p *Parser) Skip() error {
+	_, err := p.ReadRawValue()
+	return err
+}
+
+// ReadBoolean reads a BOOLEAN.
+func (p *Parser) ReadBoolean() (bool, error) {
+	saved := *p
+	contents, err := p.readPrimitive(TagBoolean)
+	if err != nil {
+		return false, err
+	}
+	if p.ber && len(contents) == 1 {
+		return contents[0] != 0, nil
+	}
+	v, err := parseBool(contents)
+	if err != nil {
+		*p = saved
+	}
+	return v, err
+}
+
+// ReadInt64 reads an INTEGER that fits in an int64.
+func (p *Parser) ReadInt64() (int64, error) {
+	return readValue(p, TagInteger, parseInt64)
+}
+
+// ReadEnumerated reads an ENUMERATED.
+func (p *Parser) ReadEnumerated() (int64, error) {
+	return readValue(p, TagEnum, parseInt64)
+}
+
+// ReadUint64 reads a non-negative INTEGER that fits in a uint64.
+func (p *Parser) ReadUint64() (uint64, error) {
+	return readValue(p, TagInteger, func(b []byte) (uint64, error) {
+		if err := checkInteger(b); err != nil {
+			return 0, err
+		}
+		if b[0]&0x80 != 0 {
+			return 0, StructuralError{"negative integer"}
+		}
+		if b[0] == 0 {
+			b = b[1:]
+		}
+		if len(b) > 8 {
+			return 0, StructuralError{"integer too large"}
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, nil
+	})
+}
+
+// ReadBigInt reads an INTEGER.
+func (p *Parser) ReadBigInt() (*big.Int, error) {
+	return readValue(p, TagInteger, parseBigInt)
+}
+
+// ReadNull reads a NULL.
+func (p *Parser) ReadNull() error {
+	_, err := readValue(p, TagNull, func(b []byte) (struct{}, error) {
+		if len(b) != 0 {
+			return struct{}{}, SyntaxError{"invalid NULL"}
+		}
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// ReadOctetString reads an OCTET STRING.
+func (p *Parser) ReadOctetString() ([]byte, error) {
+	return p.readPrimitive(TagOctetString)
+}
+
+// ReadBitString reads a BIT STRING.
+func (p *Parser) ReadBitString() (BitString, error) {
+	return readValue(p, TagBitString, parseBitString)
+}
+
+// ReadObjectIdentifier reads an OBJECT IDENTIFIER.
+func (p *Parser) ReadObjectIdentifier() (ObjectIdentifier, error) {
+	return readValue(p, TagOID, parseObjectIdentifier)
+}
+
+// ReadString reads a character string of the type given by tag, which
+// must be one of [TagUTF8String], [TagPrintableString], [TagIA5String],
+// [TagNumericString], [TagT61String], [TagGeneralString], or
+// [TagBMPString], and returns it converted to UTF-8.
+func (p *Parser) ReadString(tag int) (string, error) {
+	var parse func([]byte) (string, error)
+	switch tag {
+	case TagUTF8String:
+		parse = parseUTF8String
+	case TagPrintableString:
+		parse = parsePrintableString
+	case TagIA5String:
+		parse = parseIA5String
+	case TagNumericString:
+		parse = parseNumericString
+	case TagT61String, TagGeneralString:
+		parse = parseT61String
+	case TagBMPString:
+		parse = parseBMPString
+	default:
+		return "", StructuralError{"unsupported string type"}
+	}
+	return readValue(p, tag, parse)
+}
+
+// ReadUTCTime reads a UTCTime.
+func (p *Parser) ReadUTCTime() (time.Time, error) {
+	return readValue(p, TagUTCTime, parseUTCTime)
+}
+
+// ReadGeneralizedTime reads a GeneralizedTime.
+func (p *Parser) ReadGeneralizedTime() (time.Time, error) {
+	return readValue(p, TagGeneralizedTime, parseGeneralizedTime)
+}
+
+// readValue reads an element of the primitive type with the given
+// universal tag and parses its contents.
+// The element is not consumed if it cannot be parsed.
+func readValue[T any](p *Parser, tag int, parse func([]byte) (T, error)) (T, error) {
+	saved := *p
+	contents, err := p.readPrimitive(tag)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	v, err := parse(contents)
+	if err != nil {
+		*p = saved
+	}
+	return v, err
+}
+
+// ReadSequence reads a SEQUENCE or SEQUENCE OF and returns a Parser
+// for its components.
+func (p *Parser) ReadSequence() (Parser, error) {
+	return p.readConstructed(ClassUniversal, TagSequence)
+}
+
+// ReadSet reads a SET or SET OF and returns a Parser for its components.
+func (p *Parser) ReadSet() (Parser, error) {
+	return p.readConstructed(ClassUniversal, TagSet)
+}
+
+// ReadExplicit reads a constructed element with the given class and tag
+// number, as for an EXPLICIT tag in an ASN.1 module, and returns a Parser
+// for the element it contains.
+func (p *Parser) ReadExplicit(class, tag int) (Parser, error) {
+	p.implicit = false
+	return p.readConstructed(class, tag)
+}
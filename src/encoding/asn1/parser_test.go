@@ -0,0 +1,233 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asn1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParserRoundTrip(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 250000000, time.UTC)
+	b := NewBuilder(nil)
+	b.AddSequence(func(b *Builder) {
+		b.AddBoolean(true)
+		b.AddInt64(-1000)
+		b.AddUint64(1<<64 - 1)
+		b.AddBigInt(new(big.Int).Lsh(big.NewInt(1), 100))
+		b.AddEnumerated(7)
+		b.AddNull()
+		b.AddOctetString([]byte("data"))
+		b.AddBitString(BitString{Bytes: []byte{0x80}, BitLength: 1})
+		b.AddObjectIdentifier(ObjectIdentifier{2, 5, 4, 3})
+		b.AddString(TagBMPString, "héllo")
+		b.AddUTCTime(now)
+		b.AddGeneralizedTime(now)
+		b.AddExplicit(ClassContextSpecific, 3, func(b *Builder) {
+			b.AddString(TagUTF8String, "inner")
+		})
+		b.Implicit(ClassContextSpecific, 1)
+		b.AddInt64(42)
+		b.AddSetOf(func(b *Builder) {
+			b.AddInt64(2)
+			b.AddInt64(1)
+		})
+	})
+	data, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser(data)
+	seq, err := p.ReadSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := seq.ReadBoolean(); err != nil || !v {
+		t.Errorf("ReadBoolean = %v, %v", v, err)
+	}
+	if v, err := seq.ReadInt64(); err != nil || v != -1000 {
+		t.Errorf("ReadInt64 = %d, %v", v, err)
+	}
+	uv, err := seq.ReadUint64()
+	if err != nil || uv != 1<<64-1 {
+		t.Errorf("ReadUint64 = %d, %v", uv, err)
+	}
+	n, err := seq.ReadBigInt()
+	if err != nil || n.BitLen() != 101 {
+		t.Errorf("ReadBigInt = %v, %v", n, err)
+	}
+	if v, err := seq.ReadEnumerated(); err != nil || v != 7 {
+		t.Errorf("ReadEnumerated = %d, %v", v, err)
+	}
+	if err := seq.ReadNull(); err != nil {
+		t.Errorf("ReadNull: %v", err)
+	}
+	if v, err := seq.ReadOctetString(); err != nil || string(v) != "data" {
+		t.Errorf("ReadOctetString = %q, %v", v, err)
+	}
+	bs, err := seq.ReadBitString()
+	if err != nil || bs.BitLength != 1 || bs.At(0) != 1 {
+		t.Errorf("ReadBitString = %v, %v", bs, err)
+	}
+	oid, err := seq.ReadObjectIdentifier()
+	if err != nil || !oid.Equal(ObjectIdentifier{2, 5, 4, 3}) {
+		t.Errorf("ReadObjectIdentifier = %v, %v", oid, err)
+	}
+	sv, err := seq.ReadString(TagBMPString)
+	if err != nil || sv != "héllo" {
+		t.Errorf("ReadString = %q, %v", sv, err)
+	}
+	ut, err := seq.ReadUTCTime()
+	if err != nil || !ut.Equal(now.Truncate(time.Second)) {
+		t.Errorf("ReadUTCTime = %v, %v", ut, err)
+	}
+	gt, err := seq.ReadGeneralizedTime()
+	if err != nil || !gt.Equal(now) {
+		t.Errorf("ReadGeneralizedTime = %v, %v", gt, err)
+	}
+	if class, tag, compound, ok := seq.PeekTag(); !ok || class != ClassContextSpecific || tag != 3 || !compound {
+		t.Errorf("PeekTag = %d, %d, %v, %v", class, tag, compound, ok)
+	}
+	exp, err := seq.ReadExplicit(ClassContextSpecific, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := exp.ReadString(TagUTF8String); err != nil || s != "inner" || !exp.Empty() {
+		t.Errorf("explicit ReadString = %q, %v", s, err)
+	}
+	if _, err := seq.ReadInt64(); err == nil {
+		t.Errorf("ReadInt64 of implicitly tagged element succeeded")
+	}
+	seq.Implicit(ClassContextSpecific, 1)
+	if v, err := seq.ReadInt64(); err != nil || v != 42 {
+		t.Errorf("implicit ReadInt64 = %d, %v", v, err)
+	}
+	set, err := seq.ReadSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for want := int64(1); !set.Empty(); want++ {
+		if v, err := set.ReadInt64(); err != nil || v != want {
+			t.Errorf("SET OF element = %d, %v; want %d", v, err, want)
+		}
+	}
+	if !seq.Empty() || !p.Empty() {
+		t.Errorf("unread data: %x", seq.Rest())
+	}
+}
+
+func TestParserBER(t *testing.T) {
+	// SEQUENCE (indefinite) {
+	//   BOOLEAN 0x01
+	//   OCTET STRING (constructed, indefinite) { "ab", OCTET STRING (constructed) { "c" } }
+	//   BIT STRING (constructed) { 00 ff, 04 f0 }
+	//   [0] (indefinite) { INTEGER 5 } with a non-minimal length
+	//   INTEGER 1 with a long-form length
+	// }
+	data := mustDecodeHex(t, "30 80"+
+		"01 01 01"+
+		"24 80 04 02 6162 24 03 04 01 63 00 00"+
+		"23 08 03 02 00ff 03 02 04f0"+
+		"a0 80 02 81 01 05 00 00"+
+		"02 81 01 01"+
+		"00 00 ff")
+	if _, err := NewParser(data).ReadSequence(); err == nil {
+		t.Fatal("DER Parser accepted indefinite length")
+	}
+	p := NewBERParser(data)
+	seq, err := p.ReadSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := seq.ReadBoolean(); err != nil || !v {
+		t.Errorf("ReadBoolean = %v, %v", v, err)
+	}
+	if v, err := seq.ReadOctetString(); err != nil || string(v) != "abc" {
+		t.Errorf("ReadOctetString = %q, %v", v, err)
+	}
+	if v, err := seq.ReadBitString(); err != nil || v.BitLength != 12 || !bytes.Equal(v.Bytes, []byte{0xff, 0xf0}) {
+		t.Errorf("ReadBitString = %v, %v", v, err)
+	}
+	exp, err := seq.ReadExplicit(ClassContextSpecific, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := exp.ReadInt64(); err != nil || v != 5 {
+		t.Errorf("ReadInt64 = %d, %v", v, err)
+	}
+	if v, err := seq.ReadInt64(); err != nil || v != 1 {
+		t.Errorf("ReadInt64 = %d, %v", v, err)
+	}
+	if !seq.Empty() {
+		t.Errorf("unread data in SEQUENCE: %x", seq.Rest())
+	}
+	if !bytes.Equal(p.Rest(), []byte{0xff}) {
+		t.Errorf("Rest() = %x, want ff", p.Rest())
+	}
+}
+
+func TestParserRawValue(t *testing.T) {
+	data := mustDecodeHex(t, "30 80 04 01 61 00 00 05 00")
+	p := NewBERParser(data)
+	v, err := p.ReadRawValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Tag != TagSequence || !v.IsCompound || !bytes.Equal(v.Bytes, data[2:5]) || !bytes.Equal(v.FullBytes, data[:7]) {
+		t.Errorf("ReadRawValue = %+v", v)
+	}
+	if err := p.Skip(); err != nil || !p.Empty() {
+		t.Errorf("Skip: %v", err)
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		ber  bool
+		read func(*Parser) error
+		want string
+	}{
+		{"0101ff", false, func(p *Parser) error { _, err := p.ReadInt64(); return err }, "tags don't match"},
+		{"010101", false, func(p *Parser) error { _, err := p.ReadBoolean(); return err }, "invalid boolean"},
+		{"02020001", false, func(p *Parser) error { _, err := p.ReadInt64(); return err }, "not minimally-encoded"},
+		{"0201ff", false, func(p *Parser) error { _, err := p.ReadUint64(); return err }, "negative"},
+		{"0404616263", false, func(p *Parser) error { _, err := p.ReadOctetString(); return err }, "truncated"},
+		{"24800401610000", false, func(p *Parser) error { _, err := p.ReadOctetString(); return err }, "indefinite length"},
+		{"2403020161", true, func(p *Parser) error { _, err := p.ReadOctetString(); return err }, "invalid segment"},
+		{"3080020101", true, func(p *Parser) error { _, err := p.ReadSequence(); return err }, "truncated"},
+		{"0480", true, func(p *Parser) error { _, err := p.ReadOctetString(); return err }, "indefinite length of primitive"},
+		{"3003020101", false, func(p *Parser) error { _, err := p.ReadString(21); return err }, "unsupported"},
+		{strings.Repeat("3080", 200), true, func(p *Parser) error { return p.Skip() }, "nested too deeply"},
+	}
+	for _, tt := range tests {
+		data := mustDecodeHex(t, tt.data)
+		p := NewParser(data)
+		if tt.ber {
+			p = NewBERParser(data)
+		}
+		err := tt.read(p)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.data, err, tt.want)
+		}
+		if !bytes.Equal(p.Rest(), data) {
+			t.Errorf("%s: failed read consumed input", tt.data)
+		}
+	}
+}
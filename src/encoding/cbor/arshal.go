@@ -0,0 +1,183 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Marshal serializes a Go value as a single CBOR data item
+// according to the provided marshal options.
+//
+// See the package documentation for how Go values are mapped to CBOR.
+func Marshal(in any, opts ...Options) (out []byte, err error) {
+	var e Encoder
+	e.opts.join(opts...)
+	if err := marshalEncode(&e, in); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// MarshalWrite serializes a Go value into an [io.Writer] according to the
+// provided marshal options. See [Marshal] for details.
+func MarshalWrite(out io.Writer, in any, opts ...Options) (err error) {
+	e := NewEncoder(out, opts...)
+	if err := marshalEncode(e, in); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+// MarshalEncode serializes a Go value into an [Encoder] according to the
+// provided marshal options, which are joined with the options of the Encoder
+// for the duration of the call. See [Marshal] for details.
+func MarshalEncode(out *Encoder, in any, opts ...Options) (err error) {
+	if len(opts) > 0 {
+		saved := out.opts
+		defer func() { out.opts = saved }()
+		out.opts.join(opts...)
+	}
+	return marshalEncode(out, in)
+}
+
+func marshalEncode(e *Encoder, in any) error {
+	v := reflect.ValueOf(in)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return e.WriteToken(Null)
+	}
+	// Shallow copy non-pointer values to obtain an addressable value.
+	// It is beneficial to performance to always pass pointers to avoid this.
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	} else {
+		v2 := reflect.New(v.Type()).Elem()
+		v2.Set(v)
+		v = v2
+	}
+	return lookupArshaler(v.Type()).marshal(e, v)
+}
+
+// Unmarshal decodes the input as a single CBOR data item and stores
+// the result in the value pointed to by out, which must be a non-nil pointer.
+// It reports an error if the input contains more than one data item.
+//
+// See the package documentation for how CBOR is mapped to Go values.
+func Unmarshal(in []byte, out any, opts ...Options) (err error) {
+	var d Decoder
+	var o options
+	o.join(opts...)
+	d.resetBytes(in, &o)
+	return unmarshalFull(&d, out)
+}
+
+// UnmarshalRead deserializes a Go value from an [io.Reader] according to the
+// provided unmarshal options. The input must contain exactly one data item
+// and be terminated by [io.EOF]. See [Unmarshal] for details.
+func UnmarshalRead(in io.Reader, out any, opts ...Options) (err error) {
+	return unmarshalFull(NewDecoder(in, opts...), out)
+}
+
+func unmarshalFull(d *Decoder, out any) error {
+	if err := unmarshalDecode(d, out); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	switch err := d.need(d.pos + 1); err {
+	case nil:
+		return &SyntacticError{ByteOffset: d.InputOffset(), Err: errTrailingData}
+	case io.EOF:
+		return nil
+	default:
+		return err
+	}
+}
+
+// UnmarshalDecode deserializes a Go value from a [Decoder] according to the
+// provided unmarshal options, which are joined with the options of the Decoder
+// for the duration of the call. Unlike [Unmarshal] and [UnmarshalRead],
+// it reads only the next data item and returns [io.EOF]
+// if there are no more items in the stream.
+// See [Unmarshal] for details.
+func UnmarshalDecode(in *Decoder, out any, opts ...Options) (err error) {
+	if len(opts) > 0 {
+		saved := in.opts
+		defer func() { in.opts = saved }()
+		in.opts.join(opts...)
+	}
+	return unmarshalDecode(in, out)
+}
+
+func unmarshalDecode(d *Decoder, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return &SemanticError{action: "unmarshal", GoType: reflect.TypeOf(out), Err: errNonNilPointer}
+	}
+	return lookupArshaler(v.Type().Elem()).unmarshal(d, v.Elem())
+}
+
+var errNonNilPointer = errors.New("value must be passed as a non-nil pointer")
+
+// arshaler marshals and unmarshals values of a particular Go type.
+// The value passed to marshal and unmarshal is always addressable.
+type arshaler struct {
+	marshal   func(*Encoder, reflect.Value) error
+	unmarshal func(*Decoder, reflect.Value) error
+}
+
+var arshalerCache sync.Map // map[reflect.Type]*arshaler
+
+func lookupArshaler(t reflect.Type) *arshaler {
+	if v, ok := arshalerCache.Load(t); ok {
+		return v.(*arshaler)
+	}
+	fncs := makeDefaultArshaler(t)
+	fncs = makeMethodArshaler(fncs, t)
+	v, _ := arshalerCache.LoadOrStore(t, fncs)
+	return v.(*arshaler)
+}
+
+// newMarshalError wraps err as a [SemanticError] for the Go type t,
+// populating any unset fields of an existing SemanticError.
+// Syntactic and I/O errors are returned unchanged.
+func newMarshalError(e *Encoder, t reflect.Type, err error) error {
+	return newSemanticError("marshal", e.OutputOffset(), KindInvalid, t, err)
+}
+
+// newUnmarshalError is like newMarshalError, but for unmarshaling
+// a CBOR item of kind k.
+func newUnmarshalError(d *Decoder, k Kind, t reflect.Type, err error) error {
+	return newSemanticError("unmarshal", d.InputOffset(), k, t, err)
+}
+
+func newSemanticError(action string, offset int64, k Kind, t reflect.Type, err error) error {
+	switch err := err.(type) {
+	case *SyntacticError, *ioError:
+		return err
+	case *SemanticError:
+		if err.action == "" {
+			err.action = action
+		}
+		if err.ByteOffset == 0 {
+			err.ByteOffset = offset
+		}
+		if err.CBORKind == KindInvalid {
+			err.CBORKind = k
+		}
+		if err.GoType == nil {
+			err.GoType = t
+		}
+		return err
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return err
+	}
+	return &SemanticError{action: action, ByteOffset: offset, CBORKind: k, GoType: t, Err: err}
+}
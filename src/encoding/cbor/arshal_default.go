@@ -0,0 +1,656 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"time"
+)
+
+var (
+	errOverflow        = errors.New("value out of range")
+	errArrayLength     = errors.New("array length does not match Go array length")
+	errUnhashableKey   = errors.New("map key is not hashable")
+	errUnsupportedType = errors.New("unsupported type")
+)
+
+var anyType = reflect.TypeFor[any]()
+
+func makeDefaultArshaler(t reflect.Type) *arshaler {
+	switch t {
+	case timeTimeType:
+		return makeTimeArshaler(false)
+	case valueType:
+		return makeValueArshaler()
+	case tagType:
+		return makeTagArshaler()
+	case rawTagType:
+		return makeRawTagArshaler()
+	case simpleValueType:
+		return makeSimpleValueArshaler()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return makeBoolArshaler(t)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return makeIntArshaler(t)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return makeUintArshaler(t)
+	case reflect.Float32, reflect.Float64:
+		return makeFloatArshaler(t)
+	case reflect.String:
+		return makeStringArshaler(t)
+	case reflect.Slice:
+		if isByteType(t.Elem()) {
+			return makeBytesArshaler(t)
+		}
+		return makeSliceArshaler(t)
+	case reflect.Array:
+		if isByteType(t.Elem()) {
+			return makeBytesArshaler(t)
+		}
+		return makeArrayArshaler(t)
+	case reflect.Map:
+		return makeMapArshaler(t)
+	case reflect.Struct:
+		return makeStructArshaler(t)
+	case reflect.Pointer:
+		return makePointerArshaler(t)
+	case reflect.Interface:
+		return makeInterfaceArshaler(t)
+	}
+	return makeInvalidArshaler(t)
+}
+
+// isByteType reports whether t is a byte type without custom serialization,
+// such that a slice or array of t is encoded as a byte string.
+func isByteType(t reflect.Type) bool {
+	return t.Kind() == reflect.Uint8 &&
+		!implements(t, marshalerToType) && !implements(t, marshalerType) &&
+		!implements(t, unmarshalerFromType) && !implements(t, unmarshalerType) &&
+		!implements(t, textMarshalerType) && !implements(t, textUnmarshalerType)
+}
+
+// skipTags skips over any tag heads preceding the next data item.
+// Tags are ignored when unmarshaling into Go types that have
+// no representation for them.
+func (d *Decoder) skipTags() error {
+	for d.PeekKind() == KindTag {
+		if _, err := d.ReadToken(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readScalar reads the next token after any tags.
+// If it is null or undefined, it reports true and sets va to its zero value.
+func (d *Decoder) readScalar(va reflect.Value) (Token, bool, error) {
+	if err := d.skipTags(); err != nil {
+		return Token{}, false, err
+	}
+	tok, err := d.ReadToken()
+	if err != nil {
+		return tok, false, err
+	}
+	if k := tok.Kind(); k == KindNull || k == KindUndefined {
+		va.SetZero()
+		return tok, true, nil
+	}
+	return tok, false, nil
+}
+
+// readNull reads a null or undefined item after any tags, if present,
+// setting va to its zero value.
+func (d *Decoder) readNull(va reflect.Value) (bool, error) {
+	if err := d.skipTags(); err != nil {
+		return false, err
+	}
+	if k := d.PeekKind(); k == KindNull || k == KindUndefined {
+		_, err := d.ReadToken()
+		va.SetZero()
+		return true, err
+	}
+	return false, nil
+}
+
+func makeBoolArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			return e.WriteToken(Bool(va.Bool()))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			tok, null, err := d.readScalar(va)
+			if err != nil || null {
+				return err
+			}
+			if tok.Kind() != KindBool {
+				return newUnmarshalError(d, tok.Kind(), t, nil)
+			}
+			va.SetBool(tok.Bool())
+			return nil
+		},
+	}
+}
+
+func makeIntArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			return e.WriteToken(Int(va.Int()))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			tok, null, err := d.readScalar(va)
+			if err != nil || null {
+				return err
+			}
+			k := tok.Kind()
+			if k != KindUint && k != KindNegInt {
+				return newUnmarshalError(d, k, t, nil)
+			}
+			n := tok.Int()
+			if tok.Uint() > math.MaxInt64 || va.OverflowInt(n) {
+				return newUnmarshalError(d, k, t, errOverflow)
+			}
+			va.SetInt(n)
+			return nil
+		},
+	}
+}
+
+func makeUintArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			return e.WriteToken(Uint(va.Uint()))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			tok, null, err := d.readScalar(va)
+			if err != nil || null {
+				return err
+			}
+			switch k := tok.Kind(); k {
+			case KindUint:
+				if va.OverflowUint(tok.Uint()) {
+					return newUnmarshalError(d, k, t, errOverflow)
+				}
+				va.SetUint(tok.Uint())
+				return nil
+			case KindNegInt:
+				return newUnmarshalError(d, k, t, errOverflow)
+			default:
+				return newUnmarshalError(d, k, t, nil)
+			}
+		},
+	}
+}
+
+func makeFloatArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			return e.WriteToken(Float(va.Float()))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			tok, null, err := d.readScalar(va)
+			if err != nil || null {
+				return err
+			}
+			switch k := tok.Kind(); k {
+			case KindFloat, KindUint, KindNegInt:
+				f := tok.Float()
+				if !math.IsInf(f, 0) && va.OverflowFloat(f) {
+					return newUnmarshalError(d, k, t, errOverflow)
+				}
+				va.SetFloat(f)
+				return nil
+			default:
+				return newUnmarshalError(d, k, t, nil)
+			}
+		},
+	}
+}
+
+func makeStringArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(String(va.String())); err != nil {
+				return newMarshalError(e, t, err)
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if null, err := d.readNull(va); err != nil || null {
+				return err
+			}
+			b, err := d.readString(KindString)
+			if err != nil {
+				return newUnmarshalError(d, KindInvalid, t, err)
+			}
+			va.SetString(string(b))
+			return nil
+		},
+	}
+}
+
+func makeBytesArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			return e.WriteToken(Bytes(va.Bytes()))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if null, err := d.readNull(va); err != nil || null {
+				return err
+			}
+			b, err := d.readString(KindBytes)
+			if err != nil {
+				return newUnmarshalError(d, KindInvalid, t, err)
+			}
+			if t.Kind() == reflect.Array {
+				if len(b) != t.Len() {
+					return newUnmarshalError(d, KindBytes, t, errArrayLength)
+				}
+				copy(va.Bytes(), b)
+				return nil
+			}
+			va.SetBytes(append(va.Bytes()[:0:0], b...))
+			return nil
+		},
+	}
+}
+
+// readArrayHead reads the head of an array, reporting its length
+// or -1 if it has an indefinite length.
+func (d *Decoder) readArrayHead(t reflect.Type) (int, error) {
+	tok, err := d.ReadToken()
+	if err != nil {
+		return 0, err
+	}
+	if tok.Kind() != KindArray {
+		return 0, newUnmarshalError(d, tok.Kind(), t, nil)
+	}
+	return tok.Len(), nil
+}
+
+// more reports whether there are more items to read from an array or map
+// with the given length, where i items have been read so far.
+// It consumes the break code of an indefinite-length item.
+func (d *Decoder) more(n, i int) (bool, error) {
+	if n >= 0 {
+		return i < n, nil
+	}
+	if d.PeekKind() == KindBreak {
+		_, err := d.ReadToken()
+		return false, err
+	}
+	return true, nil
+}
+
+func makeSliceArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(ArrayHead(va.Len())); err != nil {
+				return err
+			}
+			elem := lookupArshaler(t.Elem())
+			for i := range va.Len() {
+				if err := elem.marshal(e, va.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if null, err := d.readNull(va); err != nil || null {
+				return err
+			}
+			n, err := d.readArrayHead(t)
+			if err != nil {
+				return err
+			}
+			elem := lookupArshaler(t.Elem())
+			// The length in the head is not trusted to preallocate the slice,
+			// since the actual elements may never arrive.
+			va.SetLen(0)
+			if va.IsNil() {
+				va.Set(reflect.MakeSlice(t, 0, 0))
+			}
+			for i := 0; ; i++ {
+				if more, err := d.more(n, i); err != nil || !more {
+					return err
+				}
+				if i == va.Cap() {
+					va.Grow(1)
+				}
+				va.SetLen(i + 1)
+				v := va.Index(i)
+				v.SetZero()
+				if err := elem.unmarshal(d, v); err != nil {
+					return err
+				}
+			}
+		},
+	}
+}
+
+func makeArrayArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(ArrayHead(va.Len())); err != nil {
+				return err
+			}
+			elem := lookupArshaler(t.Elem())
+			for i := range va.Len() {
+				if err := elem.marshal(e, va.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if null, err := d.readNull(va); err != nil || null {
+				return err
+			}
+			n, err := d.readArrayHead(t)
+			if err != nil {
+				return err
+			}
+			elem := lookupArshaler(t.Elem())
+			for i := 0; ; i++ {
+				more, err := d.more(n, i)
+				if err != nil {
+					return err
+				}
+				if !more {
+					if i < va.Len() {
+						return newUnmarshalError(d, KindArray, t, errArrayLength)
+					}
+					return nil
+				}
+				if i >= va.Len() {
+					return newUnmarshalError(d, KindArray, t, errArrayLength)
+				}
+				v := va.Index(i)
+				v.SetZero()
+				if err := elem.unmarshal(d, v); err != nil {
+					return err
+				}
+			}
+		},
+	}
+}
+
+// mapEntry is the position of an encoded map entry within the Encoder buffer.
+type mapEntry struct {
+	start, keyEnd, end int
+}
+
+// sortEntries sorts the encoded map entries in e.buf[start:] by the bytewise
+// lexicographic order of their keys, as required by the deterministic encoding.
+func (e *Encoder) sortEntries(start int, entries []mapEntry) error {
+	b := e.buf
+	slices.SortFunc(entries, func(x, y mapEntry) int {
+		return bytes.Compare(b[x.start:x.keyEnd], b[y.start:y.keyEnd])
+	})
+	sorted := make([]byte, 0, len(b)-start)
+	for i, ent := range entries {
+		if i > 0 {
+			prev := entries[i-1]
+			if bytes.Equal(b[prev.start:prev.keyEnd], b[ent.start:ent.keyEnd]) {
+				return &SemanticError{action: "marshal", ByteOffset: e.offset + int64(ent.start), Err: ErrDuplicateKey}
+			}
+		}
+		sorted = append(sorted, b[ent.start:ent.end]...)
+	}
+	copy(b[start:], sorted)
+	return nil
+}
+
+func makeMapArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(MapHead(va.Len())); err != nil {
+				return err
+			}
+			if va.Len() == 0 {
+				return nil
+			}
+			keyArshaler, valArshaler := lookupArshaler(t.Key()), lookupArshaler(t.Elem())
+			sorted := e.opts.get(deterministic) && va.Len() > 1
+			var entries []mapEntry
+			start := len(e.buf)
+			if sorted {
+				e.noFlush++
+				defer func() { e.noFlush-- }()
+				entries = make([]mapEntry, 0, va.Len())
+			}
+			k := reflect.New(t.Key()).Elem()
+			v := reflect.New(t.Elem()).Elem()
+			for iter := va.MapRange(); iter.Next(); {
+				ent := mapEntry{start: len(e.buf)}
+				k.SetIterKey(iter)
+				if err := keyArshaler.marshal(e, k); err != nil {
+					return err
+				}
+				ent.keyEnd = len(e.buf)
+				v.SetIterValue(iter)
+				if err := valArshaler.marshal(e, v); err != nil {
+					return err
+				}
+				ent.end = len(e.buf)
+				entries = append(entries, ent)
+			}
+			if sorted {
+				return e.sortEntries(start, entries)
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if null, err := d.readNull(va); err != nil || null {
+				return err
+			}
+			tok, err := d.ReadToken()
+			if err != nil {
+				return err
+			}
+			if tok.Kind() != KindMap {
+				return newUnmarshalError(d, tok.Kind(), t, nil)
+			}
+			if va.IsNil() {
+				va.Set(reflect.MakeMap(t))
+			}
+			keyArshaler, valArshaler := lookupArshaler(t.Key()), lookupArshaler(t.Elem())
+			var seen map[string]struct{}
+			if !d.opts.get(allowDuplicateKeys) {
+				seen = make(map[string]struct{})
+			}
+			n := tok.Len()
+			var kd Decoder
+			for i := 0; ; i++ {
+				if more, err := d.more(n, i); err != nil || !more {
+					return err
+				}
+				kv, err := d.ReadValue()
+				if err != nil {
+					return err
+				}
+				if seen != nil {
+					if _, ok := seen[string(kv)]; ok {
+						return newUnmarshalError(d, kv.Kind(), t, ErrDuplicateKey)
+					}
+					seen[string(kv)] = struct{}{}
+				}
+				k := reflect.New(t.Key()).Elem()
+				kd.resetBytes(kv, &d.opts)
+				kd.offset = d.InputOffset() - int64(len(kv))
+				if err := keyArshaler.unmarshal(&kd, k); err != nil {
+					return err
+				}
+				if !isHashable(k) {
+					return newUnmarshalError(d, kv.Kind(), t, errUnhashableKey)
+				}
+				v := reflect.New(t.Elem()).Elem()
+				if err := valArshaler.unmarshal(d, v); err != nil {
+					return err
+				}
+				va.SetMapIndex(k, v)
+			}
+		},
+	}
+}
+
+// isHashable reports whether v may be used as a map key
+// without causing a run-time panic.
+func isHashable(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	return v.Comparable()
+}
+
+func makePointerArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if va.IsNil() {
+				return e.WriteToken(Null)
+			}
+			return lookupArshaler(t.Elem()).marshal(e, va.Elem())
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			// Tags are left in place, as the element type may handle them.
+			if k := d.PeekKind(); k == KindNull || k == KindUndefined {
+				_, err := d.ReadToken()
+				va.SetZero()
+				return err
+			}
+			if va.IsNil() {
+				va.Set(reflect.New(t.Elem()))
+			}
+			return lookupArshaler(t.Elem()).unmarshal(d, va.Elem())
+		},
+	}
+}
+
+func makeInterfaceArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if va.IsNil() {
+				return e.WriteToken(Null)
+			}
+			v := va.Elem()
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					return e.WriteToken(Null)
+				}
+				v = v.Elem()
+			} else {
+				v2 := reflect.New(v.Type()).Elem()
+				v2.Set(v)
+				v = v2
+			}
+			return lookupArshaler(v.Type()).marshal(e, v)
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if k := d.PeekKind(); k == KindNull || k == KindUndefined {
+				_, err := d.ReadToken()
+				va.SetZero()
+				return err
+			}
+			// Unmarshal into the existing value if it is a non-nil pointer,
+			// which allows the caller to choose the concrete type.
+			if !va.IsNil() && va.Elem().Kind() == reflect.Pointer && !va.Elem().IsNil() {
+				v := va.Elem()
+				return lookupArshaler(v.Type().Elem()).unmarshal(d, v.Elem())
+			}
+			if t.NumMethod() > 0 {
+				return newUnmarshalError(d, d.PeekKind(), t, errUnsupportedType)
+			}
+			v, err := d.readAny()
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				va.SetZero()
+			} else {
+				va.Set(reflect.ValueOf(v))
+			}
+			return nil
+		},
+	}
+}
+
+// readAny reads the next data item as a value of the default Go type
+// for its kind, as documented in [Unmarshal].
+func (d *Decoder) readAny() (any, error) {
+	switch k := d.PeekKind(); k {
+	case KindBytes:
+		b, err := d.readString(KindBytes)
+		return bytes.Clone(b), err
+	case KindString:
+		b, err := d.readString(KindString)
+		return string(b), err
+	case KindArray:
+		var s []any
+		va := reflect.ValueOf(&s).Elem()
+		return s, lookupArshaler(va.Type()).unmarshal(d, va)
+	case KindMap:
+		var m map[any]any
+		va := reflect.ValueOf(&m).Elem()
+		return m, lookupArshaler(va.Type()).unmarshal(d, va)
+	case KindTag:
+		tok, err := d.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+		switch num := tok.TagNumber(); num {
+		case tagDateTime, tagEpochTime:
+			var t time.Time
+			err := unmarshalTime(d, num, reflect.ValueOf(&t).Elem())
+			return t, err
+		case tagSelfDescribe:
+			return d.readAny()
+		default:
+			content, err := d.readAny()
+			return Tag{Number: num, Content: content}, err
+		}
+	}
+	tok, err := d.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	switch k := tok.Kind(); k {
+	case KindUint:
+		return tok.Uint(), nil
+	case KindNegInt:
+		if tok.Uint() > math.MaxInt64 {
+			return nil, newUnmarshalError(d, k, anyType, errOverflow)
+		}
+		return tok.Int(), nil
+	case KindFloat:
+		return tok.Float(), nil
+	case KindBool:
+		return tok.Bool(), nil
+	case KindNull, KindUndefined:
+		return nil, nil
+	case KindSimple:
+		return SimpleValue(tok.Simple()), nil
+	}
+	return nil, newUnmarshalError(d, tok.Kind(), anyType, nil)
+}
+
+func makeInvalidArshaler(t reflect.Type) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			return newMarshalError(e, t, errUnsupportedType)
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			return newUnmarshalError(d, d.PeekKind(), t, errUnsupportedType)
+		},
+	}
+}
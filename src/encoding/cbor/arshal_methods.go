@@ -0,0 +1,192 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+)
+
+// Interfaces for custom serialization.
+var (
+	marshalerType       = reflect.TypeFor[Marshaler]()
+	marshalerToType     = reflect.TypeFor[MarshalerTo]()
+	unmarshalerType     = reflect.TypeFor[Unmarshaler]()
+	unmarshalerFromType = reflect.TypeFor[UnmarshalerFrom]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Marshaler is implemented by types that can marshal themselves.
+// It is recommended that types implement [MarshalerTo] instead,
+// since it avoids copying the encoded data.
+//
+// The returned data must be exactly one well-formed CBOR data item.
+//
+// If the returned error is a [SemanticError], then unpopulated fields
+// of the error may be populated by [cbor] with additional context.
+// Errors of other types are wrapped within a [SemanticError].
+type Marshaler interface {
+	MarshalCBOR() ([]byte, error)
+}
+
+// MarshalerTo is implemented by types that can marshal themselves.
+// If a type implements both [Marshaler] and MarshalerTo,
+// then MarshalerTo takes precedence.
+//
+// The implementation must write exactly one CBOR data item to the Encoder
+// and must not retain the pointer to the [Encoder].
+//
+// If the returned error is a [SemanticError], then unpopulated fields
+// of the error may be populated by [cbor] with additional context.
+// Errors of other types are wrapped within a [SemanticError],
+// unless it is a [SyntacticError] or an I/O error.
+type MarshalerTo interface {
+	MarshalCBORTo(*Encoder) error
+}
+
+// Unmarshaler is implemented by types that can unmarshal themselves.
+// It is recommended that types implement [UnmarshalerFrom] instead,
+// since it avoids copying the encoded data.
+//
+// The input is a single well-formed CBOR data item, which may be null.
+// UnmarshalCBOR must copy the data if it is retained after returning.
+//
+// If the returned error is a [SemanticError], then unpopulated fields
+// of the error may be populated by [cbor] with additional context.
+// Errors of other types are wrapped within a [SemanticError].
+type Unmarshaler interface {
+	UnmarshalCBOR([]byte) error
+}
+
+// UnmarshalerFrom is implemented by types that can unmarshal themselves.
+// If a type implements both [Unmarshaler] and UnmarshalerFrom,
+// then UnmarshalerFrom takes precedence.
+//
+// The implementation must read exactly one CBOR data item from the Decoder
+// and must not retain the pointer to the [Decoder].
+//
+// If the returned error is a [SemanticError], then unpopulated fields
+// of the error may be populated by [cbor] with additional context.
+// Errors of other types are wrapped within a [SemanticError],
+// unless it is a [SyntacticError] or an I/O error.
+type UnmarshalerFrom interface {
+	UnmarshalCBORFrom(*Decoder) error
+}
+
+var (
+	errMarshalMethod   = errors.New("marshal method must write exactly one CBOR data item")
+	errUnmarshalMethod = errors.New("unmarshal method must read exactly one CBOR data item")
+)
+
+// implements reports whether t or *t implements ifaceType.
+func implements(t, ifaceType reflect.Type) bool {
+	return t.Implements(ifaceType) || reflect.PointerTo(t).Implements(ifaceType)
+}
+
+// method returns the value to call the method of ifaceType on,
+// which is either va or its address.
+func method(va reflect.Value, ifaceType reflect.Type) reflect.Value {
+	if va.Type().Implements(ifaceType) {
+		return va
+	}
+	return va.Addr()
+}
+
+func makeMethodArshaler(fncs *arshaler, t reflect.Type) *arshaler {
+	// Avoid injecting method arshaler on the pointer or interface version
+	// to avoid ever calling the method on a nil pointer or interface receiver.
+	// Let it be injected on the value receiver (which is always addressable).
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return fncs
+	}
+
+	switch {
+	case implements(t, marshalerToType):
+		fncs.marshal = func(e *Encoder, va reflect.Value) error {
+			depth, offset := e.StackDepth(), e.OutputOffset()
+			err := method(va, marshalerToType).Interface().(MarshalerTo).MarshalCBORTo(e)
+			if err == nil && (e.StackDepth() > depth || e.OutputOffset() == offset) {
+				err = errMarshalMethod
+			}
+			if err != nil {
+				return newMarshalError(e, t, err)
+			}
+			return nil
+		}
+	case implements(t, marshalerType):
+		fncs.marshal = func(e *Encoder, va reflect.Value) error {
+			b, err := method(va, marshalerType).Interface().(Marshaler).MarshalCBOR()
+			if err != nil {
+				return newMarshalError(e, t, err)
+			}
+			if err := e.WriteValue(b); err != nil {
+				return newMarshalError(e, t, errors.Join(errMarshalMethod, err))
+			}
+			return nil
+		}
+	case isSpecialType(t):
+		// Special types of this package have precedence over text methods.
+	case implements(t, textMarshalerType):
+		fncs.marshal = func(e *Encoder, va reflect.Value) error {
+			b, err := method(va, textMarshalerType).Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return newMarshalError(e, t, err)
+			}
+			if err := e.WriteToken(String(string(b))); err != nil {
+				return newMarshalError(e, t, err)
+			}
+			return nil
+		}
+	}
+
+	switch {
+	case implements(t, unmarshalerFromType):
+		fncs.unmarshal = func(d *Decoder, va reflect.Value) error {
+			depth, offset := d.StackDepth(), d.InputOffset()
+			err := va.Addr().Interface().(UnmarshalerFrom).UnmarshalCBORFrom(d)
+			if err == nil && (d.StackDepth() > depth || d.InputOffset() == offset) {
+				err = errUnmarshalMethod
+			}
+			if err != nil {
+				return newUnmarshalError(d, KindInvalid, t, err)
+			}
+			return nil
+		}
+	case implements(t, unmarshalerType):
+		fncs.unmarshal = func(d *Decoder, va reflect.Value) error {
+			v, err := d.ReadValue()
+			if err != nil {
+				return err
+			}
+			if err := va.Addr().Interface().(Unmarshaler).UnmarshalCBOR(v); err != nil {
+				return newUnmarshalError(d, v.Kind(), t, err)
+			}
+			return nil
+		}
+	case isSpecialType(t):
+	case implements(t, textUnmarshalerType):
+		fncs.unmarshal = func(d *Decoder, va reflect.Value) error {
+			if err := d.skipTags(); err != nil {
+				return err
+			}
+			if k := d.PeekKind(); k == KindNull || k == KindUndefined {
+				_, err := d.ReadToken()
+				va.SetZero()
+				return err
+			}
+			b, err := d.readString(KindString)
+			if err != nil {
+				return newUnmarshalError(d, KindInvalid, t, err)
+			}
+			if err := va.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(b); err != nil {
+				return newUnmarshalError(d, KindString, t, err)
+			}
+			return nil
+		}
+	}
+	return fncs
+}
@@ -0,0 +1,389 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type (
+	structBasic struct {
+		Bool   bool
+		Int    int
+		Uint   uint8
+		Float  float32
+		String string
+		Bytes  []byte
+		Slice  []int
+		Map    map[string]int
+	}
+	structTags struct {
+		Name    string `cbor:"name"`
+		Ignored string `cbor:"-"`
+		Empty   string `cbor:",omitempty"`
+		Zero    Point  `cbor:",omitzero"`
+		Time    time.Time
+		Unix    time.Time `cbor:",format:unix"`
+	}
+	structKeyAsInt struct {
+		Alg int    `cbor:"1,keyasint"`
+		Kid []byte `cbor:"4,keyasint,omitempty"`
+		Crv int    `cbor:"-1,keyasint"`
+	}
+	Point struct {
+		_    struct{} `cbor:",toarray"`
+		X, Y int
+	}
+	structEmbedded struct {
+		A int
+		*Inner
+		structOther
+	}
+	Inner struct {
+		A, B int // A is shadowed by structEmbedded.A
+		C    int
+	}
+	structOther struct {
+		C int // conflicts with Inner.C and is dropped
+		D int
+	}
+	structMethods struct {
+		Text netip.Addr
+		Mar  marshalerInt
+		To   marshalerToInt
+	}
+)
+
+// marshalerInt is marshaled as a string using Marshaler and Unmarshaler.
+type marshalerInt int
+
+func (n marshalerInt) MarshalCBOR() ([]byte, error) {
+	return Marshal(strconv.Itoa(int(n)))
+}
+
+func (n *marshalerInt) UnmarshalCBOR(b []byte) error {
+	var s string
+	if err := Unmarshal(b, &s); err != nil {
+		return err
+	}
+	i, err := strconv.Atoi(s)
+	*n = marshalerInt(i)
+	return err
+}
+
+// marshalerToInt is marshaled as a tagged integer using MarshalerTo and UnmarshalerFrom.
+type marshalerToInt int
+
+func (n marshalerToInt) MarshalCBORTo(e *Encoder) error {
+	if err := e.WriteToken(TagHead(1000)); err != nil {
+		return err
+	}
+	return e.WriteToken(Int(int64(n)))
+}
+
+func (n *marshalerToInt) UnmarshalCBORFrom(d *Decoder) error {
+	tok, err := d.ReadToken()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != KindTag || tok.TagNumber() != 1000 {
+		return errors.New("missing tag 1000")
+	}
+	if tok, err = d.ReadToken(); err != nil {
+		return err
+	}
+	*n = marshalerToInt(tok.Int())
+	return nil
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Options
+		in   any
+		want string
+	}{
+		{"Nil", nil, nil, "f6"},
+		{"Bool", nil, true, "f5"},
+		{"Int", nil, -500, "3901f3"},
+		{"Uint", nil, uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{"Float32", nil, float32(0.5), "f93800"},
+		{"Float64", nil, 0.1, "fb3fb999999999999a"},
+		{"String", nil, "IETF", "6449455446"},
+		{"Bytes", nil, []byte{1, 2}, "420102"},
+		{"ByteArray", nil, [2]byte{1, 2}, "420102"},
+		{"NilSlice", nil, []int(nil), "80"},
+		{"Array", nil, [2]int{1, 2}, "820102"},
+		{"NilMap", nil, map[int]int(nil), "a0"},
+		{"Pointer", nil, new(int), "00"},
+		{"NilPointer", nil, (*int)(nil), "f6"},
+		{"Struct", nil, structBasic{Bool: true, Slice: []int{1}, Map: map[string]int{"a": 1}},
+			"a8" + "64426f6f6cf5" + "63496e7400" + "6455696e7400" + "65466c6f6174f90000" +
+				"66537472696e6760" + "65427974657340" + "65536c6963658101" + "634d6170a1616101"},
+		{"StructTags", nil, structTags{Name: "x", Ignored: "y", Time: time.Unix(1363896240, 0).UTC(), Unix: time.Unix(1363896240, 500e6)},
+			"a3" + "646e616d656178" +
+				"6454696d65" + "c074323031332d30332d32315432303a30343a30305a" +
+				"64556e6978" + "c1fb41d452d9ec200000"},
+		{"StructKeyAsInt", nil, structKeyAsInt{Alg: -7, Crv: 1}, "a2" + "0126" + "2001"},
+		{"StructKeyAsIntDeterministic", []Options{Deterministic(true)}, structKeyAsInt{Alg: -7, Kid: []byte{1}, Crv: 1}, "a3" + "0126" + "044101" + "2001"},
+		{"StructToArray", nil, Point{X: 1, Y: -1}, "820120"},
+		{"StructEmbedded", nil, structEmbedded{A: 1, Inner: &Inner{B: 2}, structOther: structOther{D: 3}},
+			"a3" + "614101" + "614202" + "614403"},
+		{"StructEmbeddedNil", nil, structEmbedded{A: 1}, "a2" + "614101" + "614400"},
+		{"StructDeterministic", []Options{Deterministic(true)}, struct{ BB, A, C int }{},
+			"a3" + "614100" + "614300" + "62424200"},
+		{"MapDeterministic", []Options{Deterministic(true)}, map[any]int{"b": 1, 10: 2, -1: 3, "a": 4, false: 5},
+			"a5" + "0a02" + "2003" + "616104" + "616201" + "f405"},
+		{"Methods", nil, structMethods{Text: netip.MustParseAddr("::1"), Mar: 12, To: 3},
+			"a3" + "6454657874633a3a31" + "634d6172623132" + "62546fd903e803"},
+		{"Tag", nil, Tag{Number: 32, Content: "http://example.com"}, "d8207268747470" + hex.EncodeToString([]byte("://example.com"))},
+		{"RawTag", nil, RawTag{Number: 24, Content: Value{0x01}}, "d81801"},
+		{"SimpleValue", nil, SimpleValue(16), "f0"},
+		{"Value", nil, Value{0x9f, 0xff}, "9fff"},
+		{"ZeroValue", nil, Value(nil), "f6"},
+		{"Any", nil, []any{1, "a", nil, []any{}}, "8401" + "6161" + "f6" + "80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.in, tt.opts...)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("Marshal = %x\nwant       %s", got, tt.want)
+			}
+			var buf bytes.Buffer
+			if err := MarshalWrite(&buf, tt.in, tt.opts...); err != nil {
+				t.Fatalf("MarshalWrite error: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), got) {
+				t.Errorf("MarshalWrite = %x, want %x", buf.Bytes(), got)
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		err  error
+	}{
+		{"Chan", make(chan int), errUnsupportedType},
+		{"InvalidUTF8", "\xff", errInvalidUTF8},
+		{"BadTag", struct {
+			A int `cbor:",bogus"`
+		}{}, nil},
+		{"BadMarshaler", badMarshaler{}, errMarshalMethod},
+		{"DuplicateKey", map[any]int{uint8(1): 1, 1: 2}, ErrDuplicateKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.in, Deterministic(true))
+			if err == nil {
+				t.Fatal("Marshal succeeded, want error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Marshal error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+type badMarshaler struct{}
+
+func (badMarshaler) MarshalCBOR() ([]byte, error) { return []byte{0x01, 0x02}, nil }
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Options
+		in   string
+		out  any // pointer to zero value of the output type
+		want any
+	}{
+		{"Bool", nil, "f5", new(bool), true},
+		{"Int", nil, "3901f3", new(int16), int16(-500)},
+		{"IntFromTag", nil, "c11a514b67b0", new(int64), int64(1363896240)},
+		{"Uint", nil, "1bffffffffffffffff", new(uint64), uint64(math.MaxUint64)},
+		{"FloatHalf", nil, "f93e00", new(float32), float32(1.5)},
+		{"FloatFromInt", nil, "20", new(float64), -1.0},
+		{"String", nil, "7f657374726561646d696e67ff", new(string), "streaming"},
+		{"Bytes", nil, "5f42010243030405ff", new([]byte), []byte{1, 2, 3, 4, 5}},
+		{"ByteArray", nil, "420102", new([2]byte), [2]byte{1, 2}},
+		{"Slice", nil, "9f0102ff", new([]int), []int{1, 2}},
+		{"Array", nil, "820102", new([2]uint), [2]uint{1, 2}},
+		{"Map", nil, "a201020304", new(map[int]uint8), map[int]uint8{1: 2, 3: 4}},
+		{"MapIndefinite", nil, "bf616101ff", new(map[string]int), map[string]int{"a": 1}},
+		{"NullPointer", nil, "f6", new(*int), (*int)(nil)},
+		{"Pointer", nil, "01", new(*int), ptr(1)},
+		{"Undefined", nil, "f7", new(string), ""},
+		{"Struct", nil, "a3" + "63496e7401" + "6455696e7402" + "65666c6f6174f93e00", new(structBasic),
+			structBasic{Int: 1, Uint: 2}},
+		{"StructUnknown", nil, "a2" + "63496e7401" + "63466f6f820102", new(structBasic), structBasic{Int: 1}},
+		{"StructTags", nil, "a3" + "646e616d656178" +
+			"6454696d65" + "c074323031332d30332d32315432303a30343a30305a" +
+			"64556e6978" + "c1fb41d452d9ec200000", new(structTags),
+			structTags{Name: "x", Time: time.Unix(1363896240, 0).UTC(), Unix: time.Unix(1363896240, 500e6).UTC()}},
+		{"StructKeyAsInt", nil, "a3" + "0126" + "2001" + "044101", new(structKeyAsInt), structKeyAsInt{Alg: -7, Crv: 1, Kid: []byte{1}}},
+		{"StructToArray", nil, "820120", new(Point), Point{X: 1, Y: -1}},
+		{"StructEmbedded", nil, "a3" + "614101" + "614202" + "614403", new(structEmbedded),
+			structEmbedded{A: 1, Inner: &Inner{B: 2}, structOther: structOther{D: 3}}},
+		{"Methods", nil, "a3" + "6454657874633a3a31" + "634d6172623132" + "62546fd903e803", new(structMethods),
+			structMethods{Text: netip.MustParseAddr("::1"), Mar: 12, To: 3}},
+		{"Tag", nil, "d8206161", new(Tag), Tag{Number: 32, Content: "a"}},
+		{"RawTag", nil, "d8188101", new(RawTag), RawTag{Number: 24, Content: Value{0x81, 0x01}}},
+		{"SimpleValue", nil, "f0", new(SimpleValue), SimpleValue(16)},
+		{"Value", nil, "9f01ff", new(Value), Value{0x9f, 0x01, 0xff}},
+		{"TimeString", nil, "74323031332d30332d32315432303a30343a30305a", new(time.Time), time.Unix(1363896240, 0).UTC()},
+		{"AnyUint", nil, "01", new(any), uint64(1)},
+		{"AnyNegInt", nil, "20", new(any), int64(-1)},
+		{"AnyFloat", nil, "f93e00", new(any), 1.5},
+		{"AnyArray", nil, "83f5f640", new(any), []any{true, nil, []byte{}}},
+		{"AnyMap", nil, "a2016161f4f5", new(any), map[any]any{uint64(1): "a", false: true}},
+		{"AnyTime", nil, "c11a514b67b0", new(any), time.Unix(1363896240, 0).UTC()},
+		{"TimeMaxEpoch", nil, "c11b7ffffff1886e08ff", new(time.Time), time.Unix(maxEpoch, 0).UTC()},
+		{"TimeMinEpoch", nil, "c13b7ffffffe1c6d33ff", new(time.Time), time.Unix(minEpoch, 0).UTC()},
+		{"AnyTag", nil, "d8206161", new(any), Tag{Number: 32, Content: "a"}},
+		{"AnySelfDescribed", nil, "d9d9f701", new(any), uint64(1)},
+		{"AnySimple", nil, "f0", new(any), SimpleValue(16)},
+		{"DuplicateAllowed", []Options{AllowDuplicateKeys(true)}, "a2616101616102", new(map[string]int), map[string]int{"a": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := mustHex(t, tt.in)
+			if err := Unmarshal(in, tt.out, tt.opts...); err != nil {
+				t.Fatalf("Unmarshal error: %v", err)
+			}
+			got := reflect.ValueOf(tt.out).Elem().Interface()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal = %#v\nwant        %#v", got, tt.want)
+			}
+			if err := UnmarshalRead(bytes.NewReader(in), tt.out, tt.opts...); err != nil {
+				t.Fatalf("UnmarshalRead error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Options
+		in   string
+		out  any
+		err  error
+	}{
+		{"Truncated", nil, "8201", new([]int), io.ErrUnexpectedEOF},
+		{"Trailing", nil, "0101", new(int), errTrailingData},
+		{"KindMismatch", nil, "6161", new(int), nil},
+		{"Overflow", nil, "190100", new(uint8), errOverflow},
+		{"NegativeUint", nil, "20", new(uint), errOverflow},
+		{"ArrayLength", nil, "83010203", new([2]int), errArrayLength},
+		{"DuplicateMapKey", nil, "a2616101616102", new(map[string]int), ErrDuplicateKey},
+		{"DuplicateField", nil, "a263496e740163496e7402", new(structBasic), ErrDuplicateKey},
+		{"UnknownField", []Options{RejectUnknownFields(true)}, "a163466f6f01", new(structBasic), ErrUnknownField},
+		{"UnhashableKey", nil, "a1800a", new(any), errUnhashableKey},
+		{"TimeTag", nil, "c26161", new(time.Time), errTimeTag},
+		{"TimeAfterMaxEpoch", nil, "c11b7ffffff1886e0900", new(time.Time), errOverflow},
+		{"TimeBeforeMinEpoch", nil, "c13b7ffffffe1c6d3400", new(time.Time), errOverflow},
+		{"TimeMaxInt64Epoch", nil, "c11b7fffffffffffffff", new(time.Time), errOverflow},
+		{"TimeMinInt64Epoch", nil, "c13b7fffffffffffffff", new(time.Time), errOverflow},
+		{"TimeFloatEpoch", nil, "c1fb43dfffffffffffff", new(time.Time), errOverflow},
+		{"AnyTimeEpoch", nil, "c11b7fffffffffffffff", new(any), errOverflow},
+		{"NotTag", nil, "01", new(Tag), errNotTag},
+		{"NilPointer", nil, "01", (*int)(nil), errNonNilPointer},
+		{"NotDeterministic", []Options{Deterministic(true)}, "1801", new(int), errNotDeterministic},
+		{"UnmarshalerFrom", nil, "01", new(marshalerToInt), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal(mustHex(t, tt.in), tt.out, tt.opts...)
+			if err == nil {
+				t.Fatal("Unmarshal succeeded, want error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Unmarshal error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalReuse(t *testing.T) {
+	s := make([]int, 3, 10)
+	if err := Unmarshal(mustHex(t, "820102"), &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 2 || cap(s) != 10 {
+		t.Errorf("len, cap = %d, %d, want 2, 10", len(s), cap(s))
+	}
+	m := map[string]int{"a": 1}
+	if err := Unmarshal(mustHex(t, "a1616202"), &m); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"a": 1, "b": 2}; !reflect.DeepEqual(m, want) {
+		t.Errorf("map = %v, want %v", m, want)
+	}
+	var p Point
+	var v any = &p
+	if err := Unmarshal(mustHex(t, "820102"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if p.X != 1 || p.Y != 2 {
+		t.Errorf("Point = %+v, want {X:1 Y:2}", p)
+	}
+}
+
+func TestUnmarshalDecode(t *testing.T) {
+	d := NewDecoder(bytes.NewReader(mustHex(t, "01a1616102")))
+	var n int
+	if err := UnmarshalDecode(d, &n); err != nil || n != 1 {
+		t.Fatalf("UnmarshalDecode = %d, %v", n, err)
+	}
+	var m map[string]int
+	if err := UnmarshalDecode(d, &m); err != nil || m["a"] != 2 {
+		t.Fatalf("UnmarshalDecode = %v, %v", m, err)
+	}
+	if err := UnmarshalDecode(d, &n); err != io.EOF {
+		t.Errorf("UnmarshalDecode error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestRoundTripDeterministic(t *testing.T) {
+	in := map[string]any{
+		"z": []any{uint64(1), int64(-2), 1.5, "x", []byte{1}},
+		"a": map[any]any{uint64(10): true, "k": nil},
+		"t": Tag{Number: 100, Content: "v"},
+	}
+	b, err := Marshal(in, Deterministic(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Value(b).IsValid(Deterministic(true)) {
+		t.Errorf("Marshal output is not deterministic: %x", b)
+	}
+	c := Value(b).Clone()
+	if err := c.Canonicalize(); err != nil || !bytes.Equal(c, b) {
+		t.Errorf("Canonicalize = %x, %v, want unchanged", []byte(c), err)
+	}
+	var out map[string]any
+	if err := Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %#v\nwant %#v", out, in)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,126 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"time"
+)
+
+var timeTimeType = reflect.TypeFor[time.Time]()
+
+var errTimeTag = errors.New("invalid tag for time.Time")
+
+// minEpoch and maxEpoch are the earliest and latest numbers of seconds
+// since the Unix epoch that a time.Time can hold, March 1 of year
+// -292277022400 and December 6 of year 292277024627. Beyond them, the
+// seconds since year 1 overflow the internal representation of times.
+const (
+	minEpoch = -9223372028741760000
+	maxEpoch = math.MaxInt64 - 62135596800
+)
+
+// makeTimeArshaler returns an arshaler for time.Time, which is marshaled
+// as an RFC 3339 string with tag 0 or, if unix is set, as the number of
+// seconds since the Unix epoch with tag 1.
+func makeTimeArshaler(unix bool) *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			t := va.Interface().(time.Time)
+			if unix {
+				if err := e.WriteToken(TagHead(tagEpochTime)); err != nil {
+					return err
+				}
+				if t.Nanosecond() == 0 {
+					return e.WriteToken(Int(t.Unix()))
+				}
+				return e.WriteToken(Float(float64(t.Unix()) + float64(t.Nanosecond())/1e9))
+			}
+			b, err := t.MarshalText()
+			if err != nil {
+				return newMarshalError(e, timeTimeType, err)
+			}
+			if err := e.WriteToken(TagHead(tagDateTime)); err != nil {
+				return err
+			}
+			return e.WriteToken(String(string(b)))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			for d.PeekKind() == KindTag {
+				tok, err := d.ReadToken()
+				if err != nil {
+					return err
+				}
+				switch num := tok.TagNumber(); num {
+				case tagDateTime, tagEpochTime:
+					return unmarshalTime(d, num, va)
+				case tagSelfDescribe:
+				default:
+					return newUnmarshalError(d, KindTag, timeTimeType, errTimeTag)
+				}
+			}
+			switch d.PeekKind() {
+			case KindString:
+				return unmarshalTime(d, tagDateTime, va)
+			case KindUint, KindNegInt, KindFloat:
+				return unmarshalTime(d, tagEpochTime, va)
+			case KindNull, KindUndefined:
+				_, err := d.ReadToken()
+				va.SetZero()
+				return err
+			}
+			tok, err := d.ReadToken()
+			if err != nil {
+				return err
+			}
+			return newUnmarshalError(d, tok.Kind(), timeTimeType, nil)
+		},
+	}
+}
+
+// unmarshalTime unmarshals the content of a date/time tag with number num
+// into va. Epoch-based times are returned in UTC.
+func unmarshalTime(d *Decoder, num uint64, va reflect.Value) error {
+	if num == tagDateTime {
+		b, err := d.readString(KindString)
+		if err != nil {
+			return newUnmarshalError(d, KindInvalid, timeTimeType, err)
+		}
+		var t time.Time
+		if err := t.UnmarshalText(b); err != nil {
+			return newUnmarshalError(d, KindString, timeTimeType, err)
+		}
+		va.Set(reflect.ValueOf(t))
+		return nil
+	}
+	tok, err := d.ReadToken()
+	if err != nil {
+		return err
+	}
+	var t time.Time
+	switch k := tok.Kind(); k {
+	case KindUint, KindNegInt:
+		if k == KindUint && tok.Uint() > maxEpoch || k == KindNegInt && tok.Int() < minEpoch {
+			return newUnmarshalError(d, k, timeTimeType, errOverflow)
+		}
+		t = time.Unix(tok.Int(), 0)
+	case KindFloat:
+		f := tok.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) >= 1<<63 {
+			return newUnmarshalError(d, k, timeTimeType, errOverflow)
+		}
+		sec, frac := math.Modf(f)
+		if s := int64(sec); s < minEpoch || s > maxEpoch {
+			return newUnmarshalError(d, k, timeTimeType, errOverflow)
+		}
+		t = time.Unix(int64(sec), int64(math.Round(frac*1e9)))
+	default:
+		return newUnmarshalError(d, k, timeTimeType, nil)
+	}
+	va.Set(reflect.ValueOf(t.UTC()))
+	return nil
+}
@@ -0,0 +1,367 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"io"
+	"math"
+	"slices"
+	"unicode/utf8"
+)
+
+// minRead is the minimum number of bytes the Decoder requests
+// from the underlying reader.
+const minRead = 512
+
+// Decoder is a streaming decoder for raw CBOR tokens and values.
+// It is used to read a stream of top-level CBOR data items,
+// with no delimiters between them.
+//
+// [Decoder.ReadToken] reads the next token and
+// [Decoder.ReadValue] reads the next entire data item.
+// The Decoder validates that the input is well-formed CBOR.
+//
+// For example, the following data items:
+//
+//	1
+//	[2, "three"]
+//
+// can be decoded with the following calls:
+//
+//	d.ReadToken() // 1
+//	d.ReadToken() // array(2)
+//	d.ReadValue() // 2
+//	d.ReadToken() // "three"
+//
+// Lengths of strings are only trusted as far as the input provides the
+// data, so the Decoder never allocates buffers much larger than its input.
+type Decoder struct {
+	r      io.Reader // nil when decoding from a fixed buffer
+	buf    []byte    // buf[pos:] is unread input
+	pos    int
+	offset int64 // stream offset of buf[0]
+	rerr   error // sticky read error, including io.EOF
+	state  state
+	opts   options
+}
+
+// NewDecoder constructs a new streaming decoder reading from r
+// configured with the provided options.
+//
+// If r is a [bytes.Buffer], then the decoder reads from its unread portion
+// like any other [io.Reader].
+func NewDecoder(r io.Reader, opts ...Options) *Decoder {
+	d := new(Decoder)
+	d.Reset(r, opts...)
+	return d
+}
+
+// Reset resets a decoder such that it is reading afresh from r and
+// configured with the provided options. Reset must not be called on
+// a Decoder passed to the [UnmarshalerFrom.UnmarshalCBORFrom] method.
+func (d *Decoder) Reset(r io.Reader, opts ...Options) {
+	*d = Decoder{r: r, buf: d.buf[:0], state: state{stack: d.state.stack[:0]}}
+	d.opts.join(opts...)
+}
+
+// resetBytes resets the decoder to read from the fixed input b.
+func (d *Decoder) resetBytes(b []byte, opts *options) {
+	*d = Decoder{buf: b, rerr: io.EOF, opts: *opts}
+}
+
+// Options returns the options used to construct the decoder and
+// may additionally contain semantic options passed to [UnmarshalDecode].
+func (d *Decoder) Options() Options {
+	o := d.opts
+	return &o
+}
+
+// InputOffset returns the current input byte offset. It gives the location
+// of the next byte immediately after the most recently returned token or value.
+func (d *Decoder) InputOffset() int64 {
+	return d.offset + int64(d.pos)
+}
+
+// StackDepth returns the number of arrays, maps, tags, and
+// indefinite-length strings that have been started but not completed.
+func (d *Decoder) StackDepth() int {
+	return d.state.depth()
+}
+
+// compact discards input that has already been consumed.
+// Values previously returned by ReadValue become invalid.
+func (d *Decoder) compact() {
+	if d.r == nil || d.pos == 0 || d.pos < len(d.buf)/2 {
+		return
+	}
+	n := copy(d.buf, d.buf[d.pos:])
+	d.buf = d.buf[:n]
+	d.offset += int64(d.pos)
+	d.pos = 0
+}
+
+// need ensures that buf[:end] is populated, reading from the underlying
+// reader as necessary. It returns io.EOF if no more input is available.
+func (d *Decoder) need(end int) error {
+	for len(d.buf) < end {
+		if d.rerr != nil {
+			return d.rerr
+		}
+		if d.r == nil {
+			return io.EOF
+		}
+		if cap(d.buf)-len(d.buf) < minRead {
+			// Grow at most geometrically, so that a large length in
+			// the input does not cause an allocation until the
+			// corresponding data has actually been read.
+			d.buf = slices.Grow(d.buf, max(minRead, min(end-len(d.buf), cap(d.buf))))
+		}
+		n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+		d.buf = d.buf[:len(d.buf)+n]
+		if err != nil {
+			if err == io.EOF {
+				d.rerr = io.EOF
+			} else {
+				d.rerr = &ioError{action: "read", err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// head is a parsed head of a data item.
+type head struct {
+	major, ai byte
+	arg       uint64
+	n         int // length of the head, including the contents of a definite-length string
+}
+
+// scanHead parses and validates the head of the data item at buf[off:].
+// For a definite-length string, it also ensures the contents are available.
+func (d *Decoder) scanHead(off int) (h head, err error) {
+	if err := d.need(off + 1); err != nil {
+		return h, err
+	}
+	hl := headLen(d.buf[off])
+	if hl < 0 {
+		return h, errReservedAI
+	}
+	if err := d.need(off + hl); err != nil {
+		return h, unexpectedEOF(err)
+	}
+	h.major, h.ai, h.arg = parseHead(d.buf[off : off+hl])
+	h.n = hl
+	switch h.major {
+	case majorUint, majorNegInt, majorTag:
+		if h.ai == aiIndefinite {
+			return h, errInvalidIndefinite
+		}
+	case majorBytes, majorText:
+		if h.ai == aiIndefinite {
+			break
+		}
+		if h.arg > uint64(math.MaxInt-off-hl) {
+			return h, errNegativeLength
+		}
+		h.n += int(h.arg)
+		if err := d.need(off + h.n); err != nil {
+			return h, unexpectedEOF(err)
+		}
+		if h.major == majorText && !d.opts.get(allowInvalidUTF8) && !utf8.Valid(d.buf[off+hl:off+h.n]) {
+			return h, errInvalidUTF8
+		}
+	case majorSimple:
+		if h.ai == aiUint8 && h.arg < 32 {
+			return h, errReservedSimple
+		}
+	}
+	if d.opts.get(deterministic) {
+		switch {
+		case h.ai == aiIndefinite && h.major != majorSimple:
+			return h, errNotDeterministic
+		case h.major != majorSimple && !isShortestHead(h.ai, h.arg):
+			return h, errNotDeterministic
+		case h.major == majorSimple && h.ai >= aiFloat16 && h.ai <= aiFloat64 && !isShortestFloat(h.ai, decodeFloat(h.ai, h.arg)):
+			return h, errNotDeterministic
+		}
+	}
+	return h, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// wrapError wraps an error that occurred at buf[off].
+// An io.EOF is only reported as such if it occurred before
+// the start of a top-level data item.
+func (d *Decoder) wrapError(err error, off int) error {
+	if err == io.EOF && (off != d.pos || d.state.depth() > 0) {
+		err = io.ErrUnexpectedEOF
+	}
+	return wrapSyntacticError(err, d.offset+int64(off))
+}
+
+// PeekKind returns the kind of the data item that would be read next
+// without advancing the read offset.
+//
+// It returns [KindInvalid] if an error occurs, and the error is
+// returned by the next read call.
+func (d *Decoder) PeekKind() Kind {
+	d.compact()
+	if d.need(d.pos+1) != nil {
+		return KindInvalid
+	}
+	return kindOf(d.buf[d.pos])
+}
+
+// ReadToken reads the next [Token], advancing the read offset.
+// It returns [io.EOF] if there are no more tokens.
+//
+// If the next item is a definite-length string, the returned token
+// contains its entire contents.
+func (d *Decoder) ReadToken() (Token, error) {
+	d.compact()
+	off := d.pos
+	h, err := d.scanHead(off)
+	if err == nil {
+		err = d.state.check(d.buf[off])
+	}
+	if err == nil {
+		err = d.state.advance(h.major, h.ai, h.arg)
+	}
+	if err != nil {
+		return Token{}, d.wrapError(err, off)
+	}
+	d.pos += h.n
+
+	var t Token
+	switch h.major {
+	case majorUint:
+		t = Uint(h.arg)
+	case majorNegInt:
+		t = NegInt(h.arg)
+	case majorBytes, majorText:
+		t.kind = KindBytes
+		if h.major == majorText {
+			t.kind = KindString
+		}
+		if h.ai == aiIndefinite {
+			t.indef = true
+		} else {
+			t.arg = h.arg
+			t.str = string(d.buf[off+h.n-int(h.arg) : off+h.n])
+		}
+	case majorArray, majorMap:
+		t.kind = KindArray
+		if h.major == majorMap {
+			t.kind = KindMap
+		}
+		t.arg = h.arg
+		t.indef = h.ai == aiIndefinite
+	case majorTag:
+		t = TagHead(h.arg)
+	case majorSimple:
+		switch h.ai {
+		case aiFloat16, aiFloat32, aiFloat64:
+			t = Float(decodeFloat(h.ai, h.arg))
+		case aiBreak:
+			t = Break
+		default:
+			t = Simple(uint8(h.arg))
+		}
+	}
+	return t, nil
+}
+
+// ReadValue returns the next raw CBOR data item, advancing the read offset.
+// The returned value is only valid until the next Peek, Read, or Skip call
+// and may not be mutated while the Decoder remains in use.
+// It returns [io.EOF] if there are no more values.
+func (d *Decoder) ReadValue() (Value, error) {
+	d.compact()
+	start := d.pos
+	end, err := d.scanValue(start)
+	if err != nil {
+		return nil, err
+	}
+	d.state.complete()
+	d.pos = end
+	return Value(d.buf[start:end:end]), nil
+}
+
+// scanValue validates the entire data item at buf[start:]
+// and returns the offset of its end.
+func (d *Decoder) scanValue(start int) (end int, err error) {
+	if err := d.need(start + 1); err != nil {
+		return 0, d.wrapError(err, start)
+	}
+	if err := d.state.check(d.buf[start]); err != nil {
+		return 0, d.wrapError(err, start)
+	}
+	var s state
+	off := start
+	for {
+		h, err := d.scanHead(off)
+		if err == nil {
+			err = s.check(d.buf[off])
+		}
+		if err == nil && d.state.depth()+s.depth() >= maxDepth && h.ai == aiIndefinite {
+			err = errTooDeep
+		}
+		if err == nil {
+			err = s.advance(h.major, h.ai, h.arg)
+		}
+		if err != nil {
+			return 0, d.wrapError(err, off)
+		}
+		off += h.n
+		if s.depth() == 0 {
+			return off, nil
+		}
+		if d.state.depth()+s.depth() > maxDepth {
+			return 0, d.wrapError(errTooDeep, off)
+		}
+	}
+}
+
+// SkipValue is semantically equivalent to calling [Decoder.ReadValue]
+// and discarding the result except that memory is not wasted trying
+// to hold the entire value.
+func (d *Decoder) SkipValue() error {
+	_, err := d.ReadValue()
+	return err
+}
+
+// readString reads a text or byte string of the given kind,
+// concatenating the chunks of an indefinite-length string.
+// The result is only valid until the next read call.
+func (d *Decoder) readString(k Kind) ([]byte, error) {
+	v, err := d.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind() != k {
+		return nil, &SemanticError{action: "unmarshal", CBORKind: v.Kind()}
+	}
+	hl := headLen(v[0])
+	if v[0]&0x1f != aiIndefinite {
+		return v[hl:], nil
+	}
+	var b []byte
+	for v = v[hl:]; v[0] != breakByte; {
+		hl := headLen(v[0])
+		_, _, n := parseHead(v[:hl])
+		b = append(b, v[hl:hl+int(n)]...)
+		v = v[hl+int(n):]
+	}
+	if b == nil {
+		b = []byte{}
+	}
+	return b, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cbor implements the Concise Binary Object Representation (CBOR)
+// as specified in RFC 8949.
+// CBOR is a binary data format whose data model is a superset of JSON,
+// additionally supporting byte strings, tagged items, and
+// maps with keys of any type.
+//
+// The API is modeled after encoding/json/v2.
+// [Marshal] and [Unmarshal] encode and decode Go values
+// to and from CBOR data contained within a []byte.
+// [MarshalWrite] and [UnmarshalRead] operate on CBOR data
+// by writing to or reading from an [io.Writer] or [io.Reader].
+// [MarshalEncode] and [UnmarshalDecode] operate on CBOR data
+// by encoding to or decoding from an [Encoder] or [Decoder].
+// [Options] may be passed to each of these functions to configure
+// the syntactic and semantic behavior.
+//
+// The [Encoder] and [Decoder] types provide low-level, streaming access
+// to the [Token] and [Value] items of a CBOR data stream,
+// including indefinite-length arrays, maps, and strings.
+// Both validate that the data is well-formed.
+//
+// # Go and CBOR types
+//
+// Go values are marshaled as follows:
+//
+//   - A Go bool is a CBOR boolean.
+//   - A Go signed or unsigned integer is a CBOR unsigned or negative integer.
+//   - A Go float is a CBOR float in the shortest of the half, single,
+//     and double precision formats that preserves its value.
+//   - A Go string is a CBOR text string, which must be valid UTF-8
+//     unless [AllowInvalidUTF8] is specified.
+//   - A Go []byte or [N]byte is a CBOR byte string.
+//   - Any other Go slice or array is a CBOR array.
+//   - A Go map is a CBOR map. Its keys may be of any type.
+//   - A Go struct is a CBOR map keyed by field name,
+//     or a CBOR array if it has the toarray option (see below).
+//   - A nil Go pointer or interface is CBOR null. Otherwise, the value
+//     it points to or contains is marshaled.
+//   - A [time.Time] is a text string with tag 0 (RFC 3339 date/time).
+//   - A [Value] is copied verbatim, after validation.
+//   - A [Tag] or [RawTag] is a CBOR tagged item.
+//   - A [SimpleValue] is a CBOR simple value.
+//
+// Unlike encoding/json, a nil slice or map is marshaled as
+// an empty array or map rather than as null.
+// Channels, functions, and complex numbers cannot be marshaled.
+//
+// When unmarshaling, CBOR null and undefined set the Go value to its zero value.
+// Integers may be unmarshaled into any Go integer or float type that can
+// represent their value, and floats only into Go float types.
+// Slices are resized and reuse their existing backing array,
+// while arrays must exactly match the CBOR array length.
+// New entries are added to existing Go maps.
+// Tags are ignored, except when unmarshaling into
+// a time.Time, [Tag], [RawTag], or Go interface.
+//
+// When unmarshaling into a Go interface that does not contain
+// a non-nil pointer, a value of the following type is stored:
+//
+//   - bool, for CBOR booleans
+//   - uint64, for CBOR unsigned integers
+//   - int64, for CBOR negative integers
+//   - float64, for CBOR floats
+//   - []byte, for CBOR byte strings
+//   - string, for CBOR text strings
+//   - []any, for CBOR arrays
+//   - map[any]any, for CBOR maps
+//   - [time.Time], for tags 0 and 1
+//   - [Tag], for all other tags except the self-described CBOR tag 55799,
+//     which is ignored
+//   - [SimpleValue], for other simple values
+//   - nil, for CBOR null and undefined
+//
+// # Struct fields
+//
+// Exported struct fields are encoded as map entries keyed by the field name,
+// which may be customized with the "cbor" struct tag.
+// The tag is a comma-separated list, where the first item is the name
+// and the remaining items are options:
+//
+//   - omitempty: When marshaling, the field is omitted if it is a nil
+//     pointer or interface, or an empty string, slice, map, or array.
+//
+//   - omitzero: When marshaling, the field is omitted if it is the zero
+//     Go value or if it has an IsZero() bool method that reports true.
+//
+//   - keyasint: The name is a decimal integer that is used as an integer
+//     map key instead of a text string, as is common in COSE and
+//     other compact protocols.
+//
+//   - format:unix: For a [time.Time] field, the time is encoded as
+//     the number of seconds since the Unix epoch with tag 1.
+//
+// A field with a tag of "-" is ignored.
+// Fields of embedded structs are inlined unless a name is given in
+// the struct tag, following the same precedence rules as encoding/json.
+//
+// A struct containing a blank field with the toarray option
+// is encoded as a CBOR array of its fields in declaration order:
+//
+//	type Point struct {
+//		_    struct{} `cbor:",toarray"`
+//		X, Y int
+//	}
+//
+// # Custom serialization
+//
+// Go types can customize their CBOR representation by implementing
+// [Marshaler], [MarshalerTo], [Unmarshaler], or [UnmarshalerFrom].
+// Otherwise, types implementing [encoding.TextMarshaler] and
+// [encoding.TextUnmarshaler] are represented as CBOR text strings.
+//
+// # Deterministic encoding
+//
+// With the [Deterministic] option, marshaling produces the core
+// deterministic encoding of RFC 8949, section 4.2.1, where the entries of
+// maps and structs are sorted by their encoded keys.
+// [Value.Canonicalize] converts any CBOR data to that encoding.
+//
+// # Security considerations
+//
+// The Decoder limits the nesting depth to 10000 and only allocates memory
+// in proportion to the input actually read, regardless of the lengths
+// declared in the data. Map keys are checked for duplicates unless
+// [AllowDuplicateKeys] is specified.
+package cbor
@@ -0,0 +1,188 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// flushThreshold is the buffer size above which the Encoder
+// writes buffered output before a top-level value is complete.
+const flushThreshold = 64 << 10
+
+// Encoder is a streaming encoder from raw CBOR tokens and values.
+// It is used to write a stream of top-level CBOR data items,
+// with no delimiters between them.
+//
+// [Encoder.WriteToken] writes the next token and
+// [Encoder.WriteValue] writes the next entire data item.
+// The Encoder validates that the sequence of tokens and values
+// forms well-formed CBOR.
+//
+// For example, the following data items:
+//
+//	1
+//	[2, "three"]
+//	{_ "a": h'04'}
+//
+// can be encoded with the following calls:
+//
+//	e.WriteToken(Uint(1))
+//	e.WriteToken(ArrayHead(2))
+//	e.WriteToken(Uint(2))
+//	e.WriteToken(String("three"))
+//	e.WriteValue(Value{0xbf, 0x61, 0x61, 0x41, 0x04, 0xff})
+type Encoder struct {
+	w      io.Writer
+	buf    []byte
+	offset int64 // number of bytes written to w
+	err    error // sticky I/O error
+	state  state
+	opts   options
+
+	// noFlush is non-zero while buffered output may still be rearranged,
+	// such as when sorting the entries of a map.
+	noFlush int
+}
+
+// NewEncoder constructs a new streaming encoder writing to w
+// configured with the provided options.
+// It flushes the internal buffer when the buffer is sufficiently full
+// or when a top-level value has been written.
+func NewEncoder(w io.Writer, opts ...Options) *Encoder {
+	e := new(Encoder)
+	e.Reset(w, opts...)
+	return e
+}
+
+// Reset resets an encoder such that it is writing afresh to w and
+// configured with the provided options. Reset must not be called on
+// an Encoder passed to the [MarshalerTo.MarshalCBORTo] method.
+func (e *Encoder) Reset(w io.Writer, opts ...Options) {
+	if e.noFlush > 0 {
+		panic("cbor: Encoder.Reset called while marshaling")
+	}
+	*e = Encoder{w: w, buf: e.buf[:0], state: state{stack: e.state.stack[:0]}}
+	e.opts.join(opts...)
+}
+
+// Options returns the options used to construct the encoder and
+// may additionally contain semantic options passed to [MarshalEncode].
+func (e *Encoder) Options() Options {
+	o := e.opts
+	return &o
+}
+
+// OutputOffset returns the current output byte offset, which is the
+// number of bytes written so far, including buffered data.
+func (e *Encoder) OutputOffset() int64 {
+	return e.offset + int64(len(e.buf))
+}
+
+// StackDepth returns the number of arrays, maps, tags, and
+// indefinite-length strings that have been started but not completed.
+func (e *Encoder) StackDepth() int {
+	return e.state.depth()
+}
+
+// WriteToken writes the next token and advances the internal write offset.
+//
+// The provided token must be valid within the current position:
+// a [Break] may only end an indefinite-length item,
+// and the chunks of an indefinite-length string must be
+// definite-length strings of the same kind.
+// If the token is invalid, WriteToken returns a [*SyntacticError]
+// and the encoder state is unchanged.
+func (e *Encoder) WriteToken(t Token) error {
+	if e.err != nil {
+		return e.err
+	}
+	pos := len(e.buf)
+	b, err := t.appendTo(e.buf)
+	if err == nil {
+		err = e.checkToken(t, b[pos])
+	}
+	if err == nil {
+		hb := b[pos : pos+headLen(b[pos])]
+		major, ai, arg := parseHead(hb)
+		err = e.state.advance(major, ai, arg)
+	}
+	if err != nil {
+		e.buf = b[:pos]
+		return wrapSyntacticError(err, e.OutputOffset())
+	}
+	e.buf = b
+	return e.maybeFlush()
+}
+
+func (e *Encoder) checkToken(t Token, b0 byte) error {
+	if err := e.state.check(b0); err != nil {
+		return err
+	}
+	if t.indef && e.opts.get(deterministic) {
+		return errNotDeterministic
+	}
+	if t.kind == KindString && !t.indef && !e.opts.get(allowInvalidUTF8) && !utf8.ValidString(t.str) {
+		return errInvalidUTF8
+	}
+	return nil
+}
+
+// WriteValue writes the next raw data item and advances the internal
+// write offset. The Encoder does not simply copy the provided value verbatim,
+// but validates that it is a single well-formed CBOR data item that
+// may appear at the current position.
+// If the value is invalid, WriteValue returns a [*SyntacticError]
+// and the encoder state is unchanged.
+func (e *Encoder) WriteValue(v Value) error {
+	if e.err != nil {
+		return e.err
+	}
+	if len(v) == 0 {
+		return wrapSyntacticError(errIncomplete, e.OutputOffset())
+	}
+	if err := e.state.check(v[0]); err != nil {
+		return wrapSyntacticError(err, e.OutputOffset())
+	}
+	if err := v.validate(&e.opts); err != nil {
+		if serr, ok := err.(*SyntacticError); ok {
+			serr.ByteOffset += e.OutputOffset()
+		}
+		return err
+	}
+	e.buf = append(e.buf, v...)
+	e.state.complete()
+	return e.maybeFlush()
+}
+
+// maybeFlush writes the buffered output to the underlying writer
+// if a top-level value is complete or the buffer is large.
+func (e *Encoder) maybeFlush() error {
+	if e.w == nil || e.noFlush > 0 {
+		return nil
+	}
+	if e.state.depth() > 0 && len(e.buf) < flushThreshold {
+		return nil
+	}
+	return e.flush()
+}
+
+func (e *Encoder) flush() error {
+	if e.w == nil || len(e.buf) == 0 {
+		return nil
+	}
+	n, err := e.w.Write(e.buf)
+	e.offset += int64(n)
+	if err == nil && n < len(e.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		e.err = &ioError{action: "write", err: err}
+		return e.err
+	}
+	e.buf = e.buf[:0]
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+const errorPrefix = "cbor: "
+
+// ErrDuplicateKey indicates that a CBOR map contains a duplicate key.
+// It is returned when unmarshaling unless [AllowDuplicateKeys] is specified.
+var ErrDuplicateKey = errors.New("duplicate map key")
+
+// ErrUnknownField indicates that a CBOR map key does not match any
+// field of the Go struct being unmarshaled into.
+// It is returned when [RejectUnknownFields] is specified.
+var ErrUnknownField = errors.New("unknown map key")
+
+var (
+	errInvalidToken      = errors.New("invalid cbor.Token")
+	errReservedSimple    = errors.New("reserved simple value")
+	errReservedAI        = errors.New("reserved additional information")
+	errInvalidIndefinite = errors.New("invalid indefinite-length item")
+	errInvalidChunk      = errors.New("invalid chunk of indefinite-length string")
+	errUnexpectedBreak   = errors.New("unexpected break")
+	errMissingValue      = errors.New("missing map value before break")
+	errTooDeep           = errors.New("exceeded max depth")
+	errInvalidUTF8       = errors.New("invalid UTF-8 within text string")
+	errNotDeterministic  = errors.New("not in deterministic encoding")
+	errIncomplete        = errors.New("incomplete value")
+	errTrailingData      = errors.New("unexpected data after top-level value")
+	errNegativeLength    = errors.New("length too large")
+)
+
+type ioError struct {
+	action string // either "read" or "write"
+	err    error
+}
+
+func (e *ioError) Error() string {
+	return errorPrefix + e.action + " error: " + e.err.Error()
+}
+
+func (e *ioError) Unwrap() error {
+	return e.err
+}
+
+// SyntacticError is a description of a syntactic error that occurred when
+// encoding or decoding CBOR according to its grammar (RFC 8949, section 3).
+//
+// The contents of this error as produced by this package may change over time.
+type SyntacticError struct {
+	// ByteOffset indicates that an error occurred after this byte offset.
+	ByteOffset int64
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *SyntacticError) Error() string {
+	b := []byte(errorPrefix)
+	if e.Err != nil {
+		b = append(b, e.Err.Error()...)
+	} else {
+		b = append(b, "syntactic error"...)
+	}
+	if e.ByteOffset > 0 {
+		b = strconv.AppendInt(append(b, " after offset "...), e.ByteOffset, 10)
+	}
+	return string(b)
+}
+
+func (e *SyntacticError) Unwrap() error {
+	return e.Err
+}
+
+// wrapSyntacticError annotates err with the byte offset at which it occurred.
+// I/O errors and [io.EOF] are returned unwrapped.
+func wrapSyntacticError(err error, offset int64) error {
+	if _, ok := err.(*ioError); ok || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return err
+	}
+	return &SyntacticError{ByteOffset: offset, Err: err}
+}
+
+// SemanticError describes an error determining the meaning
+// of CBOR data as Go data or vice-versa.
+//
+// If a [Marshaler], [MarshalerTo], [Unmarshaler], or [UnmarshalerFrom] method
+// returns a SemanticError when called by this package,
+// then the ByteOffset and GoType fields are automatically
+// populated by the calling context if they are the zero value.
+//
+// The contents of this error as produced by this package may change over time.
+type SemanticError struct {
+	action string // either "marshal" or "unmarshal"
+
+	// ByteOffset indicates that an error occurred after this byte offset.
+	ByteOffset int64
+
+	// CBORKind is the CBOR kind that could not be handled.
+	CBORKind Kind // may be zero if unknown
+
+	// GoType is the Go type that could not be handled.
+	GoType reflect.Type // may be nil if unknown
+
+	// Err is the underlying error.
+	Err error // may be nil
+}
+
+func (e *SemanticError) Error() string {
+	b := []byte(errorPrefix)
+	switch e.action {
+	case "marshal":
+		b = append(b, "cannot marshal"...)
+	case "unmarshal":
+		b = append(b, "cannot unmarshal"...)
+	default:
+		b = append(b, "cannot handle"...)
+	}
+	if e.CBORKind != KindInvalid {
+		b = append(b, " CBOR "...)
+		b = append(b, e.CBORKind.String()...)
+	}
+	if e.GoType != nil {
+		if e.action == "marshal" {
+			b = append(b, " from"...)
+		} else {
+			b = append(b, " into"...)
+		}
+		b = append(b, " Go "...)
+		b = append(b, e.GoType.String()...)
+	}
+	if e.ByteOffset > 0 {
+		b = strconv.AppendInt(append(b, " after offset "...), e.ByteOffset, 10)
+	}
+	if e.Err != nil {
+		b = append(b, ": "...)
+		b = append(b, e.Err.Error()...)
+	}
+	return string(b)
+}
+
+func (e *SemanticError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor_test
+
+import (
+	"bytes"
+	"encoding/cbor"
+	"fmt"
+	"io"
+	"log"
+)
+
+// Integer map keys, as used by COSE (RFC 9052), are specified
+// with the keyasint struct tag option.
+func Example_integerKeys() {
+	type COSEKey struct {
+		Kty int    `cbor:"1,keyasint"`
+		Kid []byte `cbor:"2,keyasint,omitempty"`
+		Alg int    `cbor:"3,keyasint"`
+		Crv int    `cbor:"-1,keyasint"`
+		X   []byte `cbor:"-2,keyasint"`
+		Y   []byte `cbor:"-3,keyasint"`
+	}
+	key := COSEKey{Kty: 2, Alg: -7, Crv: 1, X: []byte{0x01}, Y: []byte{0x02}}
+
+	b, err := cbor.Marshal(key, cbor.Deterministic(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%x\n", b)
+	fmt.Println(cbor.Value(b))
+
+	var key2 COSEKey
+	if err := cbor.Unmarshal(b, &key2); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", key2)
+
+	// Output:
+	// a5010203262001214101224102
+	// {1: 2, 3: -7, -1: 1, -2: h'01', -3: h'02'}
+	// {Kty:2 Kid:[] Alg:-7 Crv:1 X:[1] Y:[2]}
+}
+
+// The Decoder can read an indefinite-length array token by token
+// as it is being streamed.
+func ExampleDecoder() {
+	in := []byte{0x9f, 0x01, 0x63, 'a', 'b', 'c', 0xf9, 0x3e, 0x00, 0xff}
+	d := cbor.NewDecoder(bytes.NewReader(in))
+	for {
+		tok, err := d.ReadToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%v: %v\n", tok.Kind(), tok)
+	}
+
+	// Output:
+	// array: array(_)
+	// unsigned integer: 1
+	// text string: abc
+	// float: 1.5
+	// break: break
+}
@@ -0,0 +1,383 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeFor[isZeroer]()
+
+// structField is a Go struct field as represented in CBOR.
+type structField struct {
+	index     []int // index path through embedded structs
+	typ       reflect.Type
+	name      string
+	key       Token  // either a text string or an integer
+	keyBytes  []byte // encoding of key
+	tagged    bool   // whether the name was specified in the struct tag
+	omitempty bool
+	omitzero  bool
+	fncs      *arshaler // nil to use the default arshaler of typ
+}
+
+// structFields are the CBOR representation of a Go struct type.
+type structFields struct {
+	flattened []structField // in declaration order
+	declared  []int         // indexes into flattened, in order
+	sorted    []int         // indexes into flattened, sorted by keyBytes
+	byName    map[string]int
+	byInt     map[int64]int
+	toArray   bool
+	err       error // invalid struct tag
+}
+
+// makeStructFields computes the fields of the struct type root.
+// Embedded structs without a name in their struct tag are inlined,
+// using the same rules as encoding/json to resolve conflicting names:
+// the least nested field wins, then the field with a name in its
+// struct tag, and otherwise all conflicting fields are ignored.
+func makeStructFields(root reflect.Type) (sf structFields) {
+	type queueEntry struct {
+		typ   reflect.Type
+		index []int
+	}
+	var all []structField
+	queue := []queueEntry{{root, nil}}
+	visited := map[reflect.Type]bool{root: true}
+	depth := map[string]int{} // number of index elements of each field, by key
+	for len(queue) > 0 {
+		var next []queueEntry
+		for _, qe := range queue {
+			for i := range qe.typ.NumField() {
+				f := qe.typ.Field(i)
+				tag := f.Tag.Get("cbor")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				if f.Name == "_" {
+					if qe.index == nil && hasOption(opts, "toarray") {
+						sf.toArray = true
+					}
+					continue
+				}
+				index := append(slices.Clip(qe.index), i)
+				ft := f.Type
+				if f.Anonymous && name == "" {
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						if !f.IsExported() && f.Type.Kind() == reflect.Pointer {
+							continue // cannot allocate an unexported embedded pointer
+						}
+						if !visited[ft] {
+							visited[ft] = true
+							next = append(next, queueEntry{ft, index})
+						}
+						continue
+					}
+				}
+				if !f.IsExported() {
+					continue
+				}
+				field := structField{index: index, typ: f.Type, name: f.Name, tagged: name != ""}
+				if name != "" {
+					field.name = name
+				}
+				field.key = String(field.name)
+				for opt := range strings.SplitSeq(opts, ",") {
+					switch {
+					case opt == "":
+					case opt == "omitempty":
+						field.omitempty = true
+					case opt == "omitzero":
+						field.omitzero = true
+					case opt == "keyasint":
+						n, err := strconv.ParseInt(field.name, 10, 64)
+						if err != nil {
+							sf.err = errors.New("invalid keyasint name " + strconv.Quote(field.name) + " on field " + f.Name)
+							return sf
+						}
+						field.key = Int(n)
+					case opt == "format:unix" && f.Type == timeTimeType:
+						field.fncs = makeTimeArshaler(true)
+					case opt == "format:rfc3339" && f.Type == timeTimeType:
+					default:
+						sf.err = errors.New("invalid struct tag option " + strconv.Quote(opt) + " on field " + f.Name)
+						return sf
+					}
+				}
+				field.keyBytes, _ = field.key.appendTo(nil)
+				k := string(field.keyBytes)
+				if d, ok := depth[k]; ok && d < len(index) {
+					continue // a less nested field dominates
+				}
+				depth[k] = len(index)
+				all = append(all, field)
+			}
+		}
+		queue = next
+	}
+
+	// Resolve conflicts between fields at the same depth.
+	byKey := map[string][]int{}
+	for i, f := range all {
+		byKey[string(f.keyBytes)] = append(byKey[string(f.keyBytes)], i)
+	}
+	for i, f := range all {
+		candidates := byKey[string(f.keyBytes)]
+		if len(candidates) > 1 {
+			var tagged []int
+			for _, j := range candidates {
+				if all[j].tagged {
+					tagged = append(tagged, j)
+				}
+			}
+			if len(tagged) != 1 || tagged[0] != i {
+				continue
+			}
+		}
+		sf.flattened = append(sf.flattened, f)
+	}
+	// Order fields by declaration order rather than depth.
+	slices.SortStableFunc(sf.flattened, func(x, y structField) int {
+		return slices.Compare(x.index, y.index)
+	})
+
+	sf.byName = make(map[string]int)
+	sf.byInt = make(map[int64]int)
+	for i, f := range sf.flattened {
+		if f.key.Kind() == KindString {
+			sf.byName[f.name] = i
+		} else {
+			sf.byInt[f.key.Int()] = i
+		}
+		sf.declared = append(sf.declared, i)
+	}
+	sf.sorted = slices.Clone(sf.declared)
+	slices.SortFunc(sf.sorted, func(i, j int) int {
+		return bytes.Compare(sf.flattened[i].keyBytes, sf.flattened[j].keyBytes)
+	})
+	return sf
+}
+
+func hasOption(opts, want string) bool {
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == want {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldByIndex returns the field of va with the given index path.
+// If alloc is set, nil embedded pointers are allocated;
+// otherwise, it reports false if one is encountered.
+func fieldByIndex(va reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && va.Kind() == reflect.Pointer {
+			if va.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				va.Set(reflect.New(va.Type().Elem()))
+			}
+			va = va.Elem()
+		}
+		va = va.Field(x)
+	}
+	return va, true
+}
+
+// isOmitted reports whether the value of field f should be omitted.
+func (f *structField) isOmitted(v reflect.Value) bool {
+	if f.omitzero {
+		if f.typ.Implements(isZeroerType) {
+			if (f.typ.Kind() != reflect.Pointer && f.typ.Kind() != reflect.Interface) || !v.IsNil() {
+				if v.Interface().(isZeroer).IsZero() {
+					return true
+				}
+			}
+		}
+		if v.IsZero() {
+			return true
+		}
+	}
+	if f.omitempty {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			return v.IsNil()
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			return v.Len() == 0
+		}
+	}
+	return false
+}
+
+func makeStructArshaler(t reflect.Type) *arshaler {
+	var once sync.Once
+	var sf structFields
+	init := func() {
+		once.Do(func() { sf = makeStructFields(t) })
+	}
+	fieldArshaler := func(f *structField) *arshaler {
+		if f.fncs != nil {
+			return f.fncs
+		}
+		return lookupArshaler(f.typ)
+	}
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			init()
+			if sf.err != nil {
+				return newMarshalError(e, t, sf.err)
+			}
+			if sf.toArray {
+				if err := e.WriteToken(ArrayHead(len(sf.flattened))); err != nil {
+					return err
+				}
+				for i := range sf.flattened {
+					f := &sf.flattened[i]
+					v, ok := fieldByIndex(va, f.index, false)
+					if !ok {
+						if err := e.WriteToken(Null); err != nil {
+							return err
+						}
+						continue
+					}
+					if err := fieldArshaler(f).marshal(e, v); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			order := sf.declared
+			if e.opts.get(deterministic) {
+				order = sf.sorted
+			}
+			fields := make([]reflect.Value, len(sf.flattened))
+			n := 0
+			for i := range sf.flattened {
+				f := &sf.flattened[i]
+				v, ok := fieldByIndex(va, f.index, false)
+				if ok && !f.isOmitted(v) {
+					fields[i] = v
+					n++
+				}
+			}
+			if err := e.WriteToken(MapHead(n)); err != nil {
+				return err
+			}
+			for _, i := range order {
+				if !fields[i].IsValid() {
+					continue
+				}
+				f := &sf.flattened[i]
+				if err := e.WriteToken(f.key); err != nil {
+					return err
+				}
+				if err := fieldArshaler(f).marshal(e, fields[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			init()
+			if sf.err != nil {
+				return newUnmarshalError(d, KindInvalid, t, sf.err)
+			}
+			if null, err := d.readNull(va); err != nil || null {
+				return err
+			}
+			tok, err := d.ReadToken()
+			if err != nil {
+				return err
+			}
+			n := tok.Len()
+			if sf.toArray {
+				if tok.Kind() != KindArray {
+					return newUnmarshalError(d, tok.Kind(), t, nil)
+				}
+				for i := 0; ; i++ {
+					if more, err := d.more(n, i); err != nil || !more {
+						return err
+					}
+					if i >= len(sf.flattened) {
+						return newUnmarshalError(d, KindArray, t, errArrayLength)
+					}
+					f := &sf.flattened[i]
+					v, _ := fieldByIndex(va, f.index, true)
+					if err := fieldArshaler(f).unmarshal(d, v); err != nil {
+						return err
+					}
+				}
+			}
+
+			if tok.Kind() != KindMap {
+				return newUnmarshalError(d, tok.Kind(), t, nil)
+			}
+			var seen map[string]struct{}
+			if !d.opts.get(allowDuplicateKeys) {
+				seen = make(map[string]struct{})
+			}
+			var kd Decoder
+			for i := 0; ; i++ {
+				if more, err := d.more(n, i); err != nil || !more {
+					return err
+				}
+				kv, err := d.ReadValue()
+				if err != nil {
+					return err
+				}
+				if seen != nil {
+					if _, ok := seen[string(kv)]; ok {
+						return newUnmarshalError(d, kv.Kind(), t, ErrDuplicateKey)
+					}
+					seen[string(kv)] = struct{}{}
+				}
+				idx, ok := -1, false
+				kd.resetBytes(kv, &d.opts)
+				switch kv.Kind() {
+				case KindString:
+					if b, err := kd.readString(KindString); err == nil {
+						idx, ok = sf.byName[string(b)]
+					}
+				case KindUint, KindNegInt:
+					if tok, err := kd.ReadToken(); err == nil && tok.Uint() <= 1<<63-1 {
+						idx, ok = sf.byInt[tok.Int()]
+					}
+				}
+				if !ok {
+					if d.opts.get(rejectUnknownFields) {
+						return newUnmarshalError(d, kv.Kind(), t, ErrUnknownField)
+					}
+					if err := d.SkipValue(); err != nil {
+						return err
+					}
+					continue
+				}
+				f := &sf.flattened[idx]
+				v, _ := fieldByIndex(va, f.index, true)
+				if err := fieldArshaler(f).unmarshal(d, v); err != nil {
+					return err
+				}
+			}
+		},
+	}
+}
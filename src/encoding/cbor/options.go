@@ -0,0 +1,142 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+// Options configure [Marshal], [MarshalWrite], [MarshalEncode],
+// [Unmarshal], [UnmarshalRead], [UnmarshalDecode],
+// [NewEncoder], and [NewDecoder] with specific features.
+// Each function takes in a variadic list of options, where properties
+// set in later options override the value of previously set properties.
+//
+// Options may be passed to an [Encoder] or [Decoder], in which case
+// they also apply to the values marshaled to or unmarshaled from them.
+//
+// The Options type is identical to all options declared in this package
+// and cannot be implemented outside of it.
+type Options interface {
+	cborOptions()
+}
+
+// flags is a set of boolean options.
+// The lowest bit is the value the other bits are set to.
+type flags uint64
+
+const (
+	deterministic flags = 1 << (iota + 1)
+	allowDuplicateKeys
+	allowInvalidUTF8
+	rejectUnknownFields
+)
+
+func (flags) cborOptions() {}
+
+// options is the combination of all options.
+type options struct {
+	present flags
+	values  flags
+}
+
+func (*options) cborOptions() {}
+
+func (o *options) join(srcs ...Options) {
+	for _, src := range srcs {
+		switch src := src.(type) {
+		case nil:
+		case flags:
+			f := src &^ 1
+			o.present |= f
+			if src&1 != 0 {
+				o.values |= f
+			} else {
+				o.values &^= f
+			}
+		case *options:
+			o.present |= src.present
+			o.values = o.values&^src.present | src.values&src.present
+		}
+	}
+}
+
+func (o *options) get(f flags) bool {
+	return o.values&f != 0
+}
+
+// JoinOptions coalesces the provided list of options into a single Options.
+// Properties set in later options override the value of previously set properties.
+func JoinOptions(srcs ...Options) Options {
+	o := new(options)
+	o.join(srcs...)
+	return o
+}
+
+// GetOption returns the value stored in opts with the provided setter,
+// reporting whether the value is present.
+//
+// Example usage:
+//
+//	v, ok := cbor.GetOption(opts, cbor.Deterministic)
+func GetOption[T any](opts Options, setter func(T) Options) (T, bool) {
+	var o options
+	o.join(opts)
+	var zero T
+	f, ok := setter(zero).(flags)
+	if !ok {
+		return zero, false
+	}
+	f &^= 1
+	v, _ := any(o.values&f != 0).(T)
+	return v, o.present&f != 0
+}
+
+func boolOption(f flags, v bool) Options {
+	if v {
+		return f | 1
+	}
+	return f
+}
+
+// Deterministic specifies that data is encoded using the core deterministic
+// encoding requirements of RFC 8949, section 4.2.1:
+//
+//   - integers, lengths, and tag numbers use the shortest possible head,
+//   - floating-point numbers use the shortest form that preserves their value,
+//   - indefinite-length items are not used, and
+//   - the keys of each map are sorted in the bytewise lexicographic
+//     order of their encodings.
+//
+// The [Encoder] always uses the shortest heads and float encodings, and
+// with this option it rejects indefinite-length items. Map keys are sorted
+// when marshaling Go maps and structs.
+//
+// When decoding, this option rejects data that does not use the shortest
+// heads and float encodings or that contains indefinite-length items.
+// Map key order is not checked.
+func Deterministic(v bool) Options {
+	return boolOption(deterministic, v)
+}
+
+// AllowDuplicateKeys specifies that unmarshaling a CBOR map with duplicate
+// keys does not report an error. The value for a duplicate key
+// replaces the previous one.
+//
+// This only affects unmarshaling and is ignored when marshaling.
+func AllowDuplicateKeys(v bool) Options {
+	return boolOption(allowDuplicateKeys, v)
+}
+
+// AllowInvalidUTF8 specifies that text strings may contain invalid UTF-8,
+// which is otherwise rejected when encoding or decoding.
+func AllowInvalidUTF8(v bool) Options {
+	return boolOption(allowInvalidUTF8, v)
+}
+
+// RejectUnknownFields specifies that unmarshaling a CBOR map into a Go struct
+// reports an error if a key does not match any field of the struct.
+// By default, unknown keys are skipped.
+//
+// This only affects unmarshaling and is ignored when marshaling.
+func RejectUnknownFields(v bool) Options {
+	return boolOption(rejectUnknownFields, v)
+}
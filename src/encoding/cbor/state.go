@@ -0,0 +1,116 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import "math"
+
+// maxDepth is the maximum nesting depth of arrays, maps, tags,
+// and indefinite-length strings.
+const maxDepth = 10000
+
+// A frame is an array, map, tag, or indefinite-length string
+// whose items are still being encoded or decoded.
+type frame struct {
+	major byte
+	indef bool
+	// n is the number of remaining items of a definite-length frame
+	// or the number of items seen so far in an indefinite-length frame.
+	// Each key and value of a map is counted as a separate item.
+	n uint64
+}
+
+// state is a state machine validating that a sequence of heads
+// forms a well-formed sequence of CBOR data items.
+type state struct {
+	stack []frame
+}
+
+func (s *state) depth() int {
+	return len(s.stack)
+}
+
+func (s *state) reset() {
+	s.stack = s.stack[:0]
+}
+
+// check reports whether an item starting with the initial byte b
+// may appear next.
+func (s *state) check(b byte) error {
+	if len(s.stack) == 0 {
+		if b == breakByte {
+			return errUnexpectedBreak
+		}
+		return nil
+	}
+	top := &s.stack[len(s.stack)-1]
+	if b == breakByte {
+		switch {
+		case !top.indef:
+			return errUnexpectedBreak
+		case top.major == majorMap && top.n%2 != 0:
+			return errMissingValue
+		}
+		return nil
+	}
+	if top.major == majorBytes || top.major == majorText {
+		if b>>5 != top.major || b&0x1f == aiIndefinite {
+			return errInvalidChunk
+		}
+	}
+	return nil
+}
+
+// advance updates the state with a head that has already been
+// validated with check.
+func (s *state) advance(major, ai byte, arg uint64) error {
+	switch {
+	case major == majorSimple && ai == aiBreak:
+		s.stack = s.stack[:len(s.stack)-1]
+		s.complete()
+		return nil
+	case ai == aiIndefinite:
+		return s.push(frame{major: major, indef: true})
+	case major == majorArray || major == majorMap:
+		if major == majorMap {
+			if arg > math.MaxUint64/2 {
+				return errNegativeLength
+			}
+			arg *= 2
+		}
+		if arg == 0 {
+			s.complete()
+			return nil
+		}
+		return s.push(frame{major: major, n: arg})
+	case major == majorTag:
+		return s.push(frame{major: major, n: 1})
+	}
+	s.complete()
+	return nil
+}
+
+func (s *state) push(f frame) error {
+	if len(s.stack) >= maxDepth {
+		return errTooDeep
+	}
+	s.stack = append(s.stack, f)
+	return nil
+}
+
+// complete records that an entire item has been encoded or decoded,
+// which may in turn complete the enclosing items.
+func (s *state) complete() {
+	for len(s.stack) > 0 {
+		top := &s.stack[len(s.stack)-1]
+		if top.indef {
+			top.n++
+			return
+		}
+		if top.n--; top.n > 0 {
+			return
+		}
+		s.stack = s.stack[:len(s.stack)-1]
+	}
+}
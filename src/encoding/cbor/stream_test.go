@@ -0,0 +1,181 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncoderTokens(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	tokens := []Token{
+		MapHead(-1),
+		String("a"), ArrayHead(2), Int(-1), Float(1.5),
+		String("b"), IndefiniteBytes, Bytes([]byte{1}), Bytes(nil), Break,
+		Break,
+		TagHead(1), Uint(0),
+		Null,
+	}
+	for _, tok := range tokens {
+		if err := e.WriteToken(tok); err != nil {
+			t.Fatalf("WriteToken(%v) error: %v", tok, err)
+		}
+	}
+	if e.StackDepth() != 0 {
+		t.Errorf("StackDepth = %d, want 0", e.StackDepth())
+	}
+	const want = "bf616182" + "20f93e00" + "61625f410140ff" + "ff" + "c100" + "f6"
+	if got := hex.EncodeToString(buf.Bytes()); got != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+
+	d := NewDecoder(bytes.NewReader(buf.Bytes()))
+	for i, want := range tokens {
+		got, err := d.ReadToken()
+		if err != nil {
+			t.Fatalf("ReadToken %d error: %v", i, err)
+		}
+		if got.Kind() != want.Kind() || got.String() != want.String() {
+			t.Errorf("ReadToken %d = %v, want %v", i, got, want)
+		}
+	}
+	if _, err := d.ReadToken(); err != io.EOF {
+		t.Errorf("final ReadToken error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestEncoderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Options
+		tokens []Token
+		err    error
+	}{
+		{"TopLevelBreak", nil, []Token{Break}, errUnexpectedBreak},
+		{"DefiniteBreak", nil, []Token{ArrayHead(1), Break}, errUnexpectedBreak},
+		{"MissingValue", nil, []Token{MapHead(-1), Int(1), Break}, errMissingValue},
+		{"WrongChunk", nil, []Token{IndefiniteString, Bytes(nil)}, errInvalidChunk},
+		{"NestedIndefiniteChunk", nil, []Token{IndefiniteString, IndefiniteString}, errInvalidChunk},
+		{"InvalidUTF8", nil, []Token{String("\xff")}, errInvalidUTF8},
+		{"ReservedSimple", nil, []Token{Simple(24)}, errReservedSimple},
+		{"ZeroToken", nil, []Token{{}}, errInvalidToken},
+		{"Deterministic", []Options{Deterministic(true)}, []Token{ArrayHead(-1)}, errNotDeterministic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf, tt.opts...)
+			var err error
+			for _, tok := range tt.tokens {
+				if err = e.WriteToken(tok); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			var serr *SyntacticError
+			if !errors.As(err, &serr) {
+				t.Errorf("error is %T, want *SyntacticError", err)
+			}
+		})
+	}
+}
+
+func TestEncoderWriteValue(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	if err := e.WriteToken(ArrayHead(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteValue(Value{0x82, 0x01}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("WriteValue(incomplete) error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if err := e.WriteValue(Value{0x01, 0x02}); !errors.Is(err, errTrailingData) {
+		t.Errorf("WriteValue(two items) error = %v, want %v", err, errTrailingData)
+	}
+	for _, v := range []Value{{0x9f, 0xff}, {0x01}} {
+		if err := e.WriteValue(v); err != nil {
+			t.Fatalf("WriteValue(%v) error: %v", v, err)
+		}
+	}
+	if got, want := hex.EncodeToString(buf.Bytes()), "829fff01"; got != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+}
+
+func TestDecoderReadValue(t *testing.T) {
+	in := mustHex(t, "83010203"+"a1616101"+"f6")
+	d := NewDecoder(&onlyReader{in})
+	if k := d.PeekKind(); k != KindArray {
+		t.Errorf("PeekKind = %v, want %v", k, KindArray)
+	}
+	tok, err := d.ReadToken()
+	if err != nil || tok.Len() != 3 {
+		t.Fatalf("ReadToken = %v, %v", tok, err)
+	}
+	if err := d.SkipValue(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := d.ReadValue()
+	if err != nil || v.String() != "2" {
+		t.Fatalf("ReadValue = %v, %v", v, err)
+	}
+	if d.StackDepth() != 1 {
+		t.Errorf("StackDepth = %d, want 1", d.StackDepth())
+	}
+	if _, err := d.ReadValue(); err != nil {
+		t.Fatal(err)
+	}
+	if d.StackDepth() != 0 {
+		t.Errorf("StackDepth = %d, want 0", d.StackDepth())
+	}
+	if v, err := d.ReadValue(); err != nil || v.String() != `{"a": 1}` {
+		t.Fatalf("ReadValue = %v, %v", v, err)
+	}
+	if off := d.InputOffset(); off != 8 {
+		t.Errorf("InputOffset = %d, want 8", off)
+	}
+	if v, err := d.ReadValue(); err != nil || v.Kind() != KindNull {
+		t.Fatalf("ReadValue = %v, %v", v, err)
+	}
+	if _, err := d.ReadValue(); err != io.EOF {
+		t.Errorf("ReadValue error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestDecoderErrorOffset(t *testing.T) {
+	d := NewDecoder(bytes.NewReader(mustHex(t, "8201f818")))
+	_, err := d.ReadValue()
+	var serr *SyntacticError
+	if !errors.As(err, &serr) || serr.ByteOffset != 2 || serr.Err != errReservedSimple {
+		t.Errorf("ReadValue error = %v, want reserved simple value after offset 2", err)
+	}
+}
+
+func TestDecoderLargeStream(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	for i := range 10000 {
+		if err := e.WriteToken(String(string(rune('a' + i%26)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewDecoder(&buf)
+	for i := range 10000 {
+		tok, err := d.ReadToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := string(rune('a' + i%26)); tok.String() != want {
+			t.Fatalf("token %d = %q, want %q", i, tok.String(), want)
+		}
+	}
+}
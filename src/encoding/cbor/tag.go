@@ -0,0 +1,163 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Tag numbers with built-in support (RFC 8949, section 3.4).
+const (
+	tagDateTime     = 0     // RFC 3339 date/time string
+	tagEpochTime    = 1     // seconds relative to 1970-01-01T00:00Z
+	tagSelfDescribe = 55799 // self-described CBOR
+)
+
+var (
+	valueType       = reflect.TypeFor[Value]()
+	tagType         = reflect.TypeFor[Tag]()
+	rawTagType      = reflect.TypeFor[RawTag]()
+	simpleValueType = reflect.TypeFor[SimpleValue]()
+)
+
+// isSpecialType reports whether t is a type with a built-in
+// representation that takes precedence over text methods.
+func isSpecialType(t reflect.Type) bool {
+	switch t {
+	case timeTimeType, valueType, tagType, rawTagType, simpleValueType:
+		return true
+	}
+	return false
+}
+
+var errNotTag = errors.New("expected tagged data item")
+
+// Tag is a CBOR tagged data item with a tag number and its content.
+// The content is marshaled and unmarshaled like any other Go value.
+//
+// When unmarshaling into a Tag, if Content is a non-nil pointer,
+// the tag content is unmarshaled into the value it points to.
+// Otherwise, it is unmarshaled as if into an any value.
+type Tag struct {
+	Number  uint64
+	Content any
+}
+
+// RawTag is a CBOR tagged data item with a tag number
+// and its content as a raw CBOR data item.
+type RawTag struct {
+	Number  uint64
+	Content Value
+}
+
+// SimpleValue is a CBOR simple value (major type 7) other than a float.
+// The values 20 to 23 represent false, true, null and undefined.
+// When unmarshaled into an any value, only simple values without
+// a corresponding Go value are represented as a SimpleValue.
+type SimpleValue uint8
+
+func makeValueArshaler() *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			v := va.Bytes()
+			if len(v) == 0 {
+				// The zero Value is treated as a null.
+				return e.WriteToken(Null)
+			}
+			if err := e.WriteValue(v); err != nil {
+				return newMarshalError(e, valueType, err)
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			v, err := d.ReadValue()
+			if err != nil {
+				return err
+			}
+			va.SetBytes(append(va.Bytes()[:0], v...))
+			return nil
+		},
+	}
+}
+
+func makeTagArshaler() *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(TagHead(va.Field(0).Uint())); err != nil {
+				return err
+			}
+			return lookupArshaler(anyType).marshal(e, va.Field(1))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			num, err := d.readTagHead(tagType)
+			if err != nil {
+				return err
+			}
+			va.Field(0).SetUint(num)
+			return lookupArshaler(anyType).unmarshal(d, va.Field(1))
+		},
+	}
+}
+
+func makeRawTagArshaler() *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(TagHead(va.Field(0).Uint())); err != nil {
+				return err
+			}
+			return lookupArshaler(valueType).marshal(e, va.Field(1))
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			num, err := d.readTagHead(rawTagType)
+			if err != nil {
+				return err
+			}
+			va.Field(0).SetUint(num)
+			return lookupArshaler(valueType).unmarshal(d, va.Field(1))
+		},
+	}
+}
+
+func (d *Decoder) readTagHead(t reflect.Type) (uint64, error) {
+	if k := d.PeekKind(); k != KindTag {
+		if k == KindInvalid {
+			_, err := d.ReadToken()
+			return 0, err
+		}
+		return 0, newUnmarshalError(d, k, t, errNotTag)
+	}
+	tok, err := d.ReadToken()
+	if err != nil {
+		return 0, err
+	}
+	return tok.TagNumber(), nil
+}
+
+func makeSimpleValueArshaler() *arshaler {
+	return &arshaler{
+		marshal: func(e *Encoder, va reflect.Value) error {
+			if err := e.WriteToken(Simple(uint8(va.Uint()))); err != nil {
+				return newMarshalError(e, simpleValueType, err)
+			}
+			return nil
+		},
+		unmarshal: func(d *Decoder, va reflect.Value) error {
+			if err := d.skipTags(); err != nil {
+				return err
+			}
+			tok, err := d.ReadToken()
+			if err != nil {
+				return err
+			}
+			switch tok.Kind() {
+			case KindSimple, KindBool, KindNull, KindUndefined:
+				va.SetUint(uint64(tok.Simple()))
+				return nil
+			}
+			return newUnmarshalError(d, tok.Kind(), simpleValueType, nil)
+		},
+	}
+}
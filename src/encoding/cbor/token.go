@@ -0,0 +1,402 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"math"
+	"strconv"
+)
+
+// A Kind represents the kind of a CBOR token or value.
+type Kind byte
+
+const (
+	KindInvalid   Kind = iota // invalid kind
+	KindUint                  // unsigned integer (major type 0)
+	KindNegInt                // negative integer (major type 1)
+	KindBytes                 // byte string (major type 2)
+	KindString                // text string (major type 3)
+	KindArray                 // array (major type 4)
+	KindMap                   // map (major type 5)
+	KindTag                   // tag (major type 6)
+	KindSimple                // simple value other than the ones below (major type 7)
+	KindBool                  // false or true
+	KindNull                  // null
+	KindUndefined             // undefined
+	KindFloat                 // floating-point number
+	KindBreak                 // "break" stop code of an indefinite-length item
+)
+
+var kindNames = [...]string{
+	KindInvalid:   "invalid",
+	KindUint:      "unsigned integer",
+	KindNegInt:    "negative integer",
+	KindBytes:     "byte string",
+	KindString:    "text string",
+	KindArray:     "array",
+	KindMap:       "map",
+	KindTag:       "tag",
+	KindSimple:    "simple value",
+	KindBool:      "bool",
+	KindNull:      "null",
+	KindUndefined: "undefined",
+	KindFloat:     "float",
+	KindBreak:     "break",
+}
+
+// String returns a human-readable name of the kind.
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// kindOf returns the kind of the data item starting with the initial byte b.
+func kindOf(b byte) Kind {
+	switch major, ai := b>>5, b&0x1f; major {
+	case majorUint:
+		return KindUint
+	case majorNegInt:
+		return KindNegInt
+	case majorBytes:
+		return KindBytes
+	case majorText:
+		return KindString
+	case majorArray:
+		return KindArray
+	case majorMap:
+		return KindMap
+	case majorTag:
+		return KindTag
+	default:
+		switch ai {
+		case aiFalse, aiTrue:
+			return KindBool
+		case aiNull:
+			return KindNull
+		case aiUndefined:
+			return KindUndefined
+		case aiFloat16, aiFloat32, aiFloat64:
+			return KindFloat
+		case aiBreak:
+			return KindBreak
+		}
+		return KindSimple
+	}
+}
+
+// A Token represents a lexical CBOR token, which is one of:
+//
+//   - an integer, float, bool, null, undefined, or other simple value
+//   - a definite-length byte or text string (or a chunk of an
+//     indefinite-length string)
+//   - the head of an array, map, tag, or indefinite-length string,
+//     which is followed by the items it contains
+//   - the break code ending an indefinite-length item
+//
+// Unlike JSON, a definite-length array or map has no end token:
+// its end is implied by the number of items in its head.
+//
+// A Token cannot represent an entire array or map,
+// while a [Value] can represent all possible CBOR values.
+//
+// The zero Token has an invalid kind.
+type Token struct {
+	kind  Kind
+	arg   uint64  // integer argument, length, tag number, or simple value
+	float float64 // for KindFloat
+	str   string  // contents of a definite-length string
+	indef bool    // whether this is the head of an indefinite-length item
+}
+
+var (
+	Null      = Token{kind: KindNull, arg: aiNull}
+	Undefined = Token{kind: KindUndefined, arg: aiUndefined}
+	False     = Token{kind: KindBool, arg: aiFalse}
+	True      = Token{kind: KindBool, arg: aiTrue}
+	Break     = Token{kind: KindBreak}
+
+	// IndefiniteBytes and IndefiniteString begin an indefinite-length
+	// byte or text string, which consists of definite-length chunks
+	// of the same kind followed by [Break].
+	IndefiniteBytes  = Token{kind: KindBytes, indef: true}
+	IndefiniteString = Token{kind: KindString, indef: true}
+)
+
+// Bool constructs a Token representing a CBOR boolean.
+func Bool(b bool) Token {
+	if b {
+		return True
+	}
+	return False
+}
+
+// Int constructs a Token representing a CBOR integer.
+// Non-negative values are encoded as an unsigned integer.
+func Int(n int64) Token {
+	if n >= 0 {
+		return Token{kind: KindUint, arg: uint64(n)}
+	}
+	return Token{kind: KindNegInt, arg: uint64(-1 - n)}
+}
+
+// Uint constructs a Token representing a CBOR unsigned integer.
+func Uint(n uint64) Token {
+	return Token{kind: KindUint, arg: n}
+}
+
+// NegInt constructs a Token representing the CBOR negative integer -1-n,
+// which allows representing values below [math.MinInt64].
+func NegInt(n uint64) Token {
+	return Token{kind: KindNegInt, arg: n}
+}
+
+// Float constructs a Token representing a CBOR floating-point number.
+// It is encoded using the shortest of the half, single, and double
+// precision formats that preserves its value.
+func Float(f float64) Token {
+	return Token{kind: KindFloat, float: f}
+}
+
+// String constructs a Token representing a definite-length CBOR text string.
+func String(s string) Token {
+	return Token{kind: KindString, str: s, arg: uint64(len(s))}
+}
+
+// Bytes constructs a Token representing a definite-length CBOR byte string.
+func Bytes(b []byte) Token {
+	return Token{kind: KindBytes, str: string(b), arg: uint64(len(b))}
+}
+
+// Simple constructs a Token representing the CBOR simple value v.
+// The values 20 to 23 represent false, true, null and undefined.
+// The values 24 to 31 are reserved and cannot be encoded.
+func Simple(v uint8) Token {
+	if aiFalse <= v && v <= aiUndefined {
+		return Token{kind: kindOf(majorSimple<<5 | v), arg: uint64(v)}
+	}
+	return Token{kind: KindSimple, arg: uint64(v)}
+}
+
+// ArrayHead constructs a Token representing the head of an array of n elements.
+// If n is negative, the array has an indefinite length and
+// must be terminated by [Break].
+func ArrayHead(n int) Token {
+	if n < 0 {
+		return Token{kind: KindArray, indef: true}
+	}
+	return Token{kind: KindArray, arg: uint64(n)}
+}
+
+// MapHead constructs a Token representing the head of a map of n key-value pairs.
+// If n is negative, the map has an indefinite length and
+// must be terminated by [Break].
+func MapHead(n int) Token {
+	if n < 0 {
+		return Token{kind: KindMap, indef: true}
+	}
+	return Token{kind: KindMap, arg: uint64(n)}
+}
+
+// TagHead constructs a Token representing the head of a tag with the given number.
+// It must be followed by exactly one tag content item.
+func TagHead(num uint64) Token {
+	return Token{kind: KindTag, arg: num}
+}
+
+// Kind returns the token kind.
+func (t Token) Kind() Kind {
+	return t.kind
+}
+
+// Bool returns the value for a CBOR boolean.
+// It panics if the token kind is not a CBOR boolean.
+func (t Token) Bool() bool {
+	if t.kind != KindBool {
+		panic("invalid CBOR token kind: " + t.kind.String())
+	}
+	return t.arg == aiTrue
+}
+
+// Int returns the value of a CBOR integer or float, truncating any
+// fractional part and clamping values outside the int64 range.
+// It panics if the token kind is not an integer or float.
+func (t Token) Int() int64 {
+	switch t.kind {
+	case KindUint:
+		return int64(min(t.arg, math.MaxInt64))
+	case KindNegInt:
+		return -1 - int64(min(t.arg, math.MaxInt64))
+	case KindFloat:
+		switch f := t.float; {
+		case math.IsNaN(f):
+			return 0
+		case f >= math.MaxInt64:
+			return math.MaxInt64
+		case f <= math.MinInt64:
+			return math.MinInt64
+		default:
+			return int64(f)
+		}
+	}
+	panic("invalid CBOR token kind: " + t.kind.String())
+}
+
+// Uint returns the value of a CBOR unsigned integer or float, truncating any
+// fractional part and clamping values outside the uint64 range.
+// For a negative integer, it returns the argument n encoding the value -1-n.
+// It panics if the token kind is not an integer or float.
+func (t Token) Uint() uint64 {
+	switch t.kind {
+	case KindUint, KindNegInt:
+		return t.arg
+	case KindFloat:
+		switch f := t.float; {
+		case math.IsNaN(f), f <= 0:
+			return 0
+		case f >= math.MaxUint64:
+			return math.MaxUint64
+		default:
+			return uint64(f)
+		}
+	}
+	panic("invalid CBOR token kind: " + t.kind.String())
+}
+
+// Float returns the value of a CBOR float or integer.
+// It panics if the token kind is not a float or integer.
+func (t Token) Float() float64 {
+	switch t.kind {
+	case KindFloat:
+		return t.float
+	case KindUint:
+		return float64(t.arg)
+	case KindNegInt:
+		return -1 - float64(t.arg)
+	}
+	panic("invalid CBOR token kind: " + t.kind.String())
+}
+
+// String returns the contents of a definite-length text or byte string.
+// For other kinds, it returns a human-readable representation of the token
+// for debugging purposes, and does not panic.
+func (t Token) String() string {
+	switch {
+	case (t.kind == KindString || t.kind == KindBytes) && !t.indef:
+		return t.str
+	case t.kind == KindBool:
+		return strconv.FormatBool(t.Bool())
+	case t.kind == KindNull:
+		return "null"
+	case t.kind == KindUndefined:
+		return "undefined"
+	case t.kind == KindUint:
+		return strconv.FormatUint(t.arg, 10)
+	case t.kind == KindNegInt:
+		if t.arg < math.MaxInt64 {
+			return strconv.FormatInt(-1-int64(t.arg), 10)
+		}
+		return "-" + strconv.FormatUint(t.arg, 10) + "-1"
+	case t.kind == KindFloat:
+		return strconv.FormatFloat(t.float, 'g', -1, 64)
+	case t.kind == KindSimple:
+		return "simple(" + strconv.FormatUint(t.arg, 10) + ")"
+	case t.kind == KindTag:
+		return "tag(" + strconv.FormatUint(t.arg, 10) + ")"
+	case t.kind == KindArray, t.kind == KindMap, t.kind == KindBytes, t.kind == KindString:
+		if t.indef {
+			return t.kind.String() + "(_)"
+		}
+		return t.kind.String() + "(" + strconv.FormatUint(t.arg, 10) + ")"
+	case t.kind == KindBreak:
+		return "break"
+	}
+	return "<invalid cbor.Token>"
+}
+
+// Bytes returns the contents of a definite-length byte or text string.
+// It panics if the token kind is not a definite-length string.
+func (t Token) Bytes() []byte {
+	if (t.kind != KindBytes && t.kind != KindString) || t.indef {
+		panic("invalid CBOR token kind: " + t.kind.String())
+	}
+	return []byte(t.str)
+}
+
+// Len returns the number of elements of an array head, the number of
+// key-value pairs of a map head, or the length of a string.
+// It returns -1 for the head of an indefinite-length item.
+// It panics if the token kind is not an array, map, or string.
+func (t Token) Len() int {
+	switch t.kind {
+	case KindArray, KindMap, KindBytes, KindString:
+		if t.indef {
+			return -1
+		}
+		return int(min(t.arg, math.MaxInt))
+	}
+	panic("invalid CBOR token kind: " + t.kind.String())
+}
+
+// TagNumber returns the number of a tag head.
+// It panics if the token kind is not a tag.
+func (t Token) TagNumber() uint64 {
+	if t.kind != KindTag {
+		panic("invalid CBOR token kind: " + t.kind.String())
+	}
+	return t.arg
+}
+
+// Simple returns the simple value of a bool, null, undefined, or other
+// simple value token.
+// It panics if the token is not a simple value.
+func (t Token) Simple() uint8 {
+	switch t.kind {
+	case KindSimple, KindBool, KindNull, KindUndefined:
+		return uint8(t.arg)
+	}
+	panic("invalid CBOR token kind: " + t.kind.String())
+}
+
+// appendTo appends the encoding of t to b.
+func (t Token) appendTo(b []byte) ([]byte, error) {
+	switch t.kind {
+	case KindUint:
+		return appendHead(b, majorUint, t.arg), nil
+	case KindNegInt:
+		return appendHead(b, majorNegInt, t.arg), nil
+	case KindBytes, KindString:
+		major := byte(majorBytes)
+		if t.kind == KindString {
+			major = majorText
+		}
+		if t.indef {
+			return append(b, major<<5|aiIndefinite), nil
+		}
+		return append(appendHead(b, major, uint64(len(t.str))), t.str...), nil
+	case KindArray, KindMap:
+		major := byte(majorArray)
+		if t.kind == KindMap {
+			major = majorMap
+		}
+		if t.indef {
+			return append(b, major<<5|aiIndefinite), nil
+		}
+		return appendHead(b, major, t.arg), nil
+	case KindTag:
+		return appendHead(b, majorTag, t.arg), nil
+	case KindSimple, KindBool, KindNull, KindUndefined:
+		if aiUint8 <= t.arg && t.arg < 32 {
+			return b, errReservedSimple
+		}
+		return appendHead(b, majorSimple, t.arg), nil
+	case KindFloat:
+		return appendFloat(b, t.float), nil
+	case KindBreak:
+		return append(b, breakByte), nil
+	}
+	return b, errInvalidToken
+}
@@ -0,0 +1,257 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Value represents a single raw CBOR data item, including
+// any nested arrays, maps, and tags.
+//
+// When a Value is unmarshaled into or marshaled from, its contents
+// are copied verbatim, after being validated.
+type Value []byte
+
+// Clone returns a copy of v.
+func (v Value) Clone() Value {
+	return bytes.Clone(v)
+}
+
+// Kind returns the kind of the data item v.
+// It returns [KindInvalid] if v is empty.
+// It does not validate the rest of v.
+func (v Value) Kind() Kind {
+	if len(v) == 0 {
+		return KindInvalid
+	}
+	return kindOf(v[0])
+}
+
+// IsValid reports whether v is exactly one well-formed CBOR data item.
+// The options control whether invalid UTF-8 is permitted and
+// whether the encoding must be deterministic.
+func (v Value) IsValid(opts ...Options) bool {
+	var o options
+	o.join(opts...)
+	return v.validate(&o) == nil
+}
+
+// validate reports an error if v is not exactly one well-formed data item.
+func (v Value) validate(opts *options) error {
+	var d Decoder
+	d.resetBytes(v, opts)
+	if _, err := d.ReadValue(); err != nil {
+		return unexpectedEOF(err)
+	}
+	if d.pos < len(v) {
+		return &SyntacticError{ByteOffset: int64(d.pos), Err: errTrailingData}
+	}
+	return nil
+}
+
+// Canonicalize rewrites v in the core deterministic encoding of
+// RFC 8949, section 4.2.1: all heads and floats use their shortest form,
+// indefinite-length items are converted to definite-length ones,
+// and the entries of every map are sorted by the bytewise lexicographic
+// order of the encoded keys. It reports [ErrDuplicateKey] if a map
+// contains the same key more than once.
+func (v *Value) Canonicalize() error {
+	var d Decoder
+	d.resetBytes(*v, &options{present: allowInvalidUTF8, values: allowInvalidUTF8})
+	if _, err := d.ReadValue(); err != nil {
+		return unexpectedEOF(err)
+	}
+	if d.pos < len(*v) {
+		return &SyntacticError{ByteOffset: int64(d.pos), Err: errTrailingData}
+	}
+	b, _, err := appendCanonical(nil, *v)
+	if err != nil {
+		return err
+	}
+	*v = b
+	return nil
+}
+
+// appendCanonical appends the canonical form of the well-formed data item
+// at the start of src to b and returns the unconsumed remainder of src.
+func appendCanonical(b, src []byte) ([]byte, []byte, error) {
+	hl := headLen(src[0])
+	major, ai, arg := parseHead(src[:hl])
+	src = src[hl:]
+	switch major {
+	case majorUint, majorNegInt:
+		return appendHead(b, major, arg), src, nil
+	case majorBytes, majorText:
+		if ai != aiIndefinite {
+			return append(appendHead(b, major, arg), src[:arg]...), src[arg:], nil
+		}
+		var s []byte
+		for src[0] != breakByte {
+			hl := headLen(src[0])
+			_, _, n := parseHead(src[:hl])
+			s = append(s, src[hl:hl+int(n)]...)
+			src = src[hl+int(n):]
+		}
+		return append(appendHead(b, major, uint64(len(s))), s...), src[1:], nil
+	case majorArray:
+		var items []byte
+		n := uint64(0)
+		var err error
+		for ; ai == aiIndefinite && src[0] != breakByte || ai != aiIndefinite && n < arg; n++ {
+			if items, src, err = appendCanonical(items, src); err != nil {
+				return b, src, err
+			}
+		}
+		if ai == aiIndefinite {
+			src = src[1:]
+		}
+		return append(appendHead(b, majorArray, n), items...), src, nil
+	case majorMap:
+		type entry struct{ key, val []byte }
+		var entries []entry
+		var err error
+		for ai == aiIndefinite && src[0] != breakByte || ai != aiIndefinite && uint64(len(entries)) < arg {
+			var e entry
+			if e.key, src, err = appendCanonical(nil, src); err != nil {
+				return b, src, err
+			}
+			if e.val, src, err = appendCanonical(nil, src); err != nil {
+				return b, src, err
+			}
+			entries = append(entries, e)
+		}
+		if ai == aiIndefinite {
+			src = src[1:]
+		}
+		slices.SortFunc(entries, func(x, y entry) int { return bytes.Compare(x.key, y.key) })
+		b = appendHead(b, majorMap, uint64(len(entries)))
+		for i, e := range entries {
+			if i > 0 && bytes.Equal(entries[i-1].key, e.key) {
+				return b, src, ErrDuplicateKey
+			}
+			b = append(append(b, e.key...), e.val...)
+		}
+		return b, src, nil
+	case majorTag:
+		return appendCanonical(appendHead(b, majorTag, arg), src)
+	default:
+		switch ai {
+		case aiFloat16, aiFloat32, aiFloat64:
+			return appendFloat(b, decodeFloat(ai, arg)), src, nil
+		}
+		return appendHead(b, majorSimple, arg), src, nil
+	}
+}
+
+// String returns the diagnostic notation of v as described in
+// RFC 8949, section 8, for debugging purposes.
+// If v is not a well-formed data item, it returns a description of the error.
+func (v Value) String() string {
+	var d Decoder
+	d.resetBytes(v, &options{present: allowInvalidUTF8, values: allowInvalidUTF8})
+	if _, err := d.ReadValue(); err != nil {
+		return "<invalid cbor.Value: " + err.Error() + ">"
+	}
+	b, _ := appendDiag(nil, v)
+	return string(b)
+}
+
+// appendDiag appends the diagnostic notation of the well-formed data item
+// at the start of src to b and returns the unconsumed remainder of src.
+func appendDiag(b, src []byte) ([]byte, []byte) {
+	hl := headLen(src[0])
+	major, ai, arg := parseHead(src[:hl])
+	src = src[hl:]
+	switch major {
+	case majorUint:
+		return strconv.AppendUint(b, arg, 10), src
+	case majorNegInt:
+		if arg < math.MaxInt64 {
+			return strconv.AppendInt(b, -1-int64(arg), 10), src
+		}
+		return append(strconv.AppendUint(append(b, '-'), arg, 10), "-1"...), src
+	case majorBytes, majorText:
+		if ai == aiIndefinite {
+			b = append(b, "(_ "...)
+			for i := 0; src[0] != breakByte; i++ {
+				if i > 0 {
+					b = append(b, ", "...)
+				}
+				b, src = appendDiag(b, src)
+			}
+			return append(b, ')'), src[1:]
+		}
+		s := src[:arg]
+		if major == majorText {
+			return strconv.AppendQuote(b, string(s)), src[arg:]
+		}
+		return append(hex.AppendEncode(append(b, "h'"...), s), '\''), src[arg:]
+	case majorArray, majorMap:
+		open, close := byte('['), byte(']')
+		n := arg
+		if major == majorMap {
+			open, close = '{', '}'
+			n *= 2
+		}
+		b = append(b, open)
+		if ai == aiIndefinite {
+			b = append(b, "_ "...)
+		}
+		for i := uint64(0); ai == aiIndefinite && src[0] != breakByte || ai != aiIndefinite && i < n; i++ {
+			switch {
+			case i == 0:
+			case major == majorMap && i%2 == 1:
+				b = append(b, ": "...)
+			default:
+				b = append(b, ", "...)
+			}
+			b, src = appendDiag(b, src)
+		}
+		if ai == aiIndefinite {
+			src = src[1:]
+		}
+		return append(b, close), src
+	case majorTag:
+		b = append(strconv.AppendUint(b, arg, 10), '(')
+		b, src = appendDiag(b, src)
+		return append(b, ')'), src
+	default:
+		switch ai {
+		case aiFalse:
+			return append(b, "false"...), src
+		case aiTrue:
+			return append(b, "true"...), src
+		case aiNull:
+			return append(b, "null"...), src
+		case aiUndefined:
+			return append(b, "undefined"...), src
+		case aiFloat16, aiFloat32, aiFloat64:
+			f := decodeFloat(ai, arg)
+			switch {
+			case math.IsNaN(f):
+				return append(b, "NaN"...), src
+			case math.IsInf(f, 1):
+				return append(b, "Infinity"...), src
+			case math.IsInf(f, -1):
+				return append(b, "-Infinity"...), src
+			}
+			format := byte('f')
+			if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+				format = 'g'
+			}
+			s := strconv.AppendFloat(nil, f, format, -1, 64)
+			if bytes.IndexAny(s, ".e") < 0 {
+				s = append(s, ".0"...)
+			}
+			return append(b, s...), src
+		}
+		return append(strconv.AppendUint(append(b, "simple("...), arg, 10), ')'), src
+	}
+}
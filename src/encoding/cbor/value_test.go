@@ -0,0 +1,259 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+func mustHex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// appendixA contains the examples of RFC 8949, Appendix A,
+// with their diagnostic notation as produced by Value.String.
+var appendixA = []struct {
+	hex       string
+	diag      string
+	preferred bool // whether the encoding is the preferred serialization
+}{
+	{"00", "0", true},
+	{"01", "1", true},
+	{"0a", "10", true},
+	{"17", "23", true},
+	{"1818", "24", true},
+	{"1819", "25", true},
+	{"1864", "100", true},
+	{"1903e8", "1000", true},
+	{"1a000f4240", "1000000", true},
+	{"1b000000e8d4a51000", "1000000000000", true},
+	{"1bffffffffffffffff", "18446744073709551615", true},
+	{"3bffffffffffffffff", "-18446744073709551615-1", true},
+	{"20", "-1", true},
+	{"29", "-10", true},
+	{"3863", "-100", true},
+	{"3903e7", "-1000", true},
+	{"f90000", "0.0", true},
+	{"f98000", "-0.0", true},
+	{"f93c00", "1.0", true},
+	{"fb3ff199999999999a", "1.1", true},
+	{"f93e00", "1.5", true},
+	{"f97bff", "65504.0", true},
+	{"fa47c35000", "100000.0", true},
+	{"fa7f7fffff", "3.4028234663852886e+38", true},
+	{"fb7e37e43c8800759c", "1e+300", true},
+	{"f90001", "5.960464477539063e-08", true},
+	{"f90400", "0.00006103515625", true},
+	{"f9c400", "-4.0", true},
+	{"fbc010666666666666", "-4.1", true},
+	{"f97c00", "Infinity", true},
+	{"f97e00", "NaN", true},
+	{"f9fc00", "-Infinity", true},
+	{"fa7f800000", "Infinity", false},
+	{"fa7fc00000", "NaN", false},
+	{"faff800000", "-Infinity", false},
+	{"fb7ff0000000000000", "Infinity", false},
+	{"fb7ff8000000000000", "NaN", false},
+	{"fbfff0000000000000", "-Infinity", false},
+	{"f4", "false", true},
+	{"f5", "true", true},
+	{"f6", "null", true},
+	{"f7", "undefined", true},
+	{"f0", "simple(16)", true},
+	{"f8ff", "simple(255)", true},
+	{"c074323031332d30332d32315432303a30343a30305a", `0("2013-03-21T20:04:00Z")`, true},
+	{"c11a514b67b0", "1(1363896240)", true},
+	{"c1fb41d452d9ec200000", "1(1363896240.5)", true},
+	{"d74401020304", "23(h'01020304')", true},
+	{"d818456449455446", "24(h'6449455446')", true},
+	{"d82076687474703a2f2f7777772e6578616d706c652e636f6d", `32("http://www.example.com")`, true},
+	{"40", "h''", true},
+	{"4401020304", "h'01020304'", true},
+	{"60", `""`, true},
+	{"6161", `"a"`, true},
+	{"6449455446", `"IETF"`, true},
+	{"62225c", `"\"\\"`, true},
+	{"62c3bc", `"ü"`, true},
+	{"63e6b0b4", `"水"`, true},
+	{"64f0908591", `"𐅑"`, true},
+	{"80", "[]", true},
+	{"83010203", "[1, 2, 3]", true},
+	{"8301820203820405", "[1, [2, 3], [4, 5]]", true},
+	{"98190102030405060708090a0b0c0d0e0f101112131415161718181819", "[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25]", true},
+	{"a0", "{}", true},
+	{"a201020304", "{1: 2, 3: 4}", true},
+	{"a26161016162820203", `{"a": 1, "b": [2, 3]}`, true},
+	{"826161a161626163", `["a", {"b": "c"}]`, true},
+	{"a56161614161626142616361436164614461656145", `{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}`, true},
+	{"5f42010243030405ff", "(_ h'0102', h'030405')", false},
+	{"7f657374726561646d696e67ff", `(_ "strea", "ming")`, false},
+	{"9fff", "[_ ]", false},
+	{"9f018202039f0405ffff", "[_ 1, [2, 3], [_ 4, 5]]", false},
+	{"9f01820203820405ff", "[_ 1, [2, 3], [4, 5]]", false},
+	{"83018202039f0405ff", "[1, [2, 3], [_ 4, 5]]", false},
+	{"83019f0203ff820405", "[1, [_ 2, 3], [4, 5]]", false},
+	{"9f0102030405060708090a0b0c0d0e0f101112131415161718181819ff", "[_ 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25]", false},
+	{"bf61610161629f0203ffff", `{_ "a": 1, "b": [_ 2, 3]}`, false},
+	{"826161bf61626163ff", `["a", {_ "b": "c"}]`, false},
+	{"bf6346756ef563416d7421ff", `{_ "Fun": true, "Amt": -2}`, false},
+}
+
+func TestAppendixA(t *testing.T) {
+	for _, tt := range appendixA {
+		v := Value(mustHex(t, tt.hex))
+		if !v.IsValid() {
+			t.Errorf("%s: IsValid = false, want true", tt.hex)
+			continue
+		}
+		if got := v.String(); got != tt.diag {
+			t.Errorf("%s: String = %s, want %s", tt.hex, got, tt.diag)
+		}
+		if got := v.IsValid(Deterministic(true)); got != tt.preferred {
+			t.Errorf("%s: IsValid(Deterministic(true)) = %v, want %v", tt.hex, got, tt.preferred)
+		}
+
+		// Reading token by token and writing them back must reproduce the input,
+		// except for floats which are always written in preferred form.
+		d := NewDecoder(&onlyReader{v})
+		var e Encoder
+		for {
+			tok, err := d.ReadToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: ReadToken error: %v", tt.hex, err)
+			}
+			if err := e.WriteToken(tok); err != nil {
+				t.Fatalf("%s: WriteToken(%v) error: %v", tt.hex, tok, err)
+			}
+		}
+		if got := Value(e.buf); tt.preferred && string(got) != string(v) {
+			t.Errorf("%s: token round trip = %x", tt.hex, []byte(got))
+		}
+
+		// Canonicalize must produce the preferred serialization.
+		c := v.Clone()
+		if err := c.Canonicalize(); err != nil {
+			t.Errorf("%s: Canonicalize error: %v", tt.hex, err)
+		} else if !c.IsValid(Deterministic(true)) {
+			t.Errorf("%s: Canonicalize = %x, which is not deterministic", tt.hex, []byte(c))
+		} else if tt.preferred && string(c) != string(v) {
+			t.Errorf("%s: Canonicalize = %x, want unchanged", tt.hex, []byte(c))
+		}
+	}
+}
+
+// onlyReader hides all methods other than Read,
+// and returns at most one byte per call to exercise buffering.
+type onlyReader struct{ b []byte }
+
+func (r *onlyReader) Read(p []byte) (int, error) {
+	if len(r.b) == 0 {
+		return 0, io.EOF
+	}
+	p[0] = r.b[0]
+	r.b = r.b[1:]
+	return 1, nil
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      error
+	}{
+		// Map keys are sorted bytewise: 0, 10, 100, "z", "aa", [100], [-1], false.
+		{"a8f40818640a0020617a0162616102811864038120040a05",
+			"a800200a0518640a617a016261610281186403812004f408", nil},
+		{"bf6161016161f5ff", "", ErrDuplicateKey},
+		{"1900ff", "18ff", nil},
+		{"fb3ff8000000000000", "f93e00", nil},
+		{"7f6161ff", "6161", nil},
+	}
+	for _, tt := range tests {
+		v := Value(mustHex(t, tt.in))
+		err := v.Canonicalize()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Canonicalize(%s) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && hex.EncodeToString(v) != tt.want {
+			t.Errorf("Canonicalize(%s) = %x, want %s", tt.in, []byte(v), tt.want)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	tests := []struct {
+		hex  string
+		opts []Options
+		err  error
+	}{
+		{"", nil, io.ErrUnexpectedEOF},
+		{"18", nil, io.ErrUnexpectedEOF},
+		{"62", nil, io.ErrUnexpectedEOF},
+		{"8201", nil, io.ErrUnexpectedEOF},
+		{"1c", nil, errReservedAI},
+		{"1f", nil, errInvalidIndefinite},
+		{"df", nil, errInvalidIndefinite},
+		{"f818", nil, errReservedSimple},
+		{"ff", nil, errUnexpectedBreak},
+		{"81ff", nil, errUnexpectedBreak},
+		{"bf00ff", nil, errMissingValue},
+		{"5f01ff", nil, errInvalidChunk},
+		{"5f5fffff", nil, errInvalidChunk},
+		{"7f4100ff", nil, errInvalidChunk},
+		{"61ff", nil, errInvalidUTF8},
+		{"0000", nil, errTrailingData},
+		{"1801", []Options{Deterministic(true)}, errNotDeterministic},
+		{"9fff", []Options{Deterministic(true)}, errNotDeterministic},
+		{"fa3f800000", []Options{Deterministic(true)}, errNotDeterministic},
+	}
+	for _, tt := range tests {
+		var o options
+		o.join(tt.opts...)
+		err := Value(mustHex(t, tt.hex)).validate(&o)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("validate(%s) = %v, want %v", tt.hex, err, tt.err)
+		}
+	}
+	// Invalid UTF-8 is allowed with AllowInvalidUTF8.
+	if !Value(mustHex(t, "61ff")).IsValid(AllowInvalidUTF8(true)) {
+		t.Errorf("IsValid(AllowInvalidUTF8(true)) = false, want true")
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	b := make([]byte, maxDepth+1)
+	for i := range b {
+		b[i] = 0x81
+	}
+	b = append(b, 0x00)
+	if err := Value(b).validate(new(options)); !errors.Is(err, errTooDeep) {
+		t.Errorf("validate error = %v, want %v", err, errTooDeep)
+	}
+	if err := Value(b[1:]).validate(new(options)); err != nil {
+		t.Errorf("validate error = %v, want nil", err)
+	}
+}
+
+func TestLargeLength(t *testing.T) {
+	// A huge declared length must not result in a huge allocation.
+	d := NewDecoder(&onlyReader{mustHex(t, "5b00000000ffffffff00")})
+	if _, err := d.ReadToken(); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadToken error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if cap(d.buf) > 4096 {
+		t.Errorf("buffer capacity = %d, want small", cap(d.buf))
+	}
+}
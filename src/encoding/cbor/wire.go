@@ -0,0 +1,213 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Major types of a data item, stored in the high 3 bits of the initial byte.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Additional information, stored in the low 5 bits of the initial byte.
+const (
+	aiUint8      = 24
+	aiUint16     = 25
+	aiUint32     = 26
+	aiUint64     = 27
+	aiIndefinite = 31
+
+	// For major type 7.
+	aiFalse     = 20
+	aiTrue      = 21
+	aiNull      = 22
+	aiUndefined = 23
+	aiFloat16   = 25
+	aiFloat32   = 26
+	aiFloat64   = 27
+	aiBreak     = 31
+)
+
+const breakByte = majorSimple<<5 | aiBreak
+
+// headLen returns the length of the head starting with the initial byte b,
+// or -1 if the additional information is reserved.
+func headLen(b byte) int {
+	switch ai := b & 0x1f; {
+	case ai < aiUint8:
+		return 1
+	case ai == aiUint8:
+		return 2
+	case ai == aiUint16:
+		return 3
+	case ai == aiUint32:
+		return 5
+	case ai == aiUint64:
+		return 9
+	case ai == aiIndefinite:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// parseHead parses the head in b, which must be exactly headLen(b[0]) bytes.
+func parseHead(b []byte) (major, ai byte, arg uint64) {
+	major, ai = b[0]>>5, b[0]&0x1f
+	switch ai {
+	case aiUint8:
+		arg = uint64(b[1])
+	case aiUint16:
+		arg = uint64(binary.BigEndian.Uint16(b[1:]))
+	case aiUint32:
+		arg = uint64(binary.BigEndian.Uint32(b[1:]))
+	case aiUint64:
+		arg = binary.BigEndian.Uint64(b[1:])
+	default:
+		if ai < aiUint8 {
+			arg = uint64(ai)
+		}
+	}
+	return major, ai, arg
+}
+
+// appendHead appends the shortest head encoding arg with the given major type.
+func appendHead(b []byte, major byte, arg uint64) []byte {
+	m := major << 5
+	switch {
+	case arg < aiUint8:
+		return append(b, m|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, m|aiUint8, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, m|aiUint16), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, m|aiUint32), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, m|aiUint64), arg)
+	}
+}
+
+// isShortestHead reports whether a head with the given additional information
+// is the shortest encoding of arg.
+func isShortestHead(ai byte, arg uint64) bool {
+	switch ai {
+	case aiUint8:
+		return arg >= aiUint8
+	case aiUint16:
+		return arg > math.MaxUint8
+	case aiUint32:
+		return arg > math.MaxUint16
+	case aiUint64:
+		return arg > math.MaxUint32
+	}
+	return true
+}
+
+// appendFloat appends the shortest encoding of f that preserves its value,
+// which is the preferred serialization of RFC 8949, section 4.1.
+// All NaN values are encoded as the half-precision quiet NaN.
+func appendFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) {
+		return append(b, majorSimple<<5|aiFloat16, 0x7e, 0x00)
+	}
+	if f32 := float32(f); float64(f32) == f {
+		if h, ok := float32ToFloat16(f32); ok {
+			return binary.BigEndian.AppendUint16(append(b, majorSimple<<5|aiFloat16), h)
+		}
+		return binary.BigEndian.AppendUint32(append(b, majorSimple<<5|aiFloat32), math.Float32bits(f32))
+	}
+	return binary.BigEndian.AppendUint64(append(b, majorSimple<<5|aiFloat64), math.Float64bits(f))
+}
+
+// isShortestFloat reports whether a float encoded with the given
+// additional information is in its preferred serialization.
+func isShortestFloat(ai byte, f float64) bool {
+	if math.IsNaN(f) {
+		return ai == aiFloat16
+	}
+	switch ai {
+	case aiFloat32:
+		_, ok := float32ToFloat16(float32(f))
+		return !ok
+	case aiFloat64:
+		return float64(float32(f)) != f
+	}
+	return true
+}
+
+// decodeFloat returns the value of the float with the given
+// additional information and argument.
+func decodeFloat(ai byte, arg uint64) float64 {
+	switch ai {
+	case aiFloat16:
+		return float16ToFloat64(uint16(arg))
+	case aiFloat32:
+		return float64(math.Float32frombits(uint32(arg)))
+	default:
+		return math.Float64frombits(arg)
+	}
+}
+
+// float32ToFloat16 converts f to IEEE 754 half precision.
+// It reports false if f cannot be represented exactly.
+// The caller must handle NaN.
+func float32ToFloat16(f float32) (uint16, bool) {
+	u := math.Float32bits(f)
+	sign := uint16(u>>16) & 0x8000
+	exp := int(u>>23&0xff) - 127
+	mant := u & 0x7fffff
+	switch {
+	case u&0x7fffffff == 0: // zero
+		return sign, true
+	case exp == 128: // infinity
+		return sign | 0x7c00, mant == 0
+	case -14 <= exp && exp <= 15: // normal
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case -24 <= exp && exp < -14: // subnormal
+		full := mant | 0x800000
+		shift := -exp - 1
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	}
+	return 0, false
+}
+
+// float16ToFloat64 converts the IEEE 754 half precision value h to a float64.
+func float16ToFloat64(h uint16) float64 {
+	exp := int(h >> 10 & 0x1f)
+	mant := uint64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(float64(mant), -24)
+	case 0x1f:
+		if mant != 0 {
+			return math.NaN()
+		}
+		f = math.Inf(1)
+	default:
+		f = math.Ldexp(float64(mant|0x400), exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -11,6 +11,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 func ExampleReader() {
@@ -129,3 +130,63 @@ func ExampleWriter_WriteAll() {
 	// Ken,Thompson,ken
 	// Robert,Griesemer,gri
 }
+
+func ExampleUnmarshal() {
+	in := `name,born,languages
+Rob,1956-01-01,3
+Ken,1943-02-04,
+`
+	type Person struct {
+		Name      string    `csv:"name"`
+		Born      time.Time `csv:"born,format:DateOnly"`
+		Languages int       `csv:"languages"`
+	}
+	var people []Person
+	if err := csv.Unmarshal(csv.NewReader(strings.NewReader(in)), &people); err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range people {
+		fmt.Println(p.Name, p.Born.Year(), p.Languages)
+	}
+	// Output:
+	// Rob 1956 3
+	// Ken 1943 0
+}
+
+func ExampleRecords() {
+	in := `item,qty
+apple,3
+pear,many
+plum,2
+`
+	type Line struct {
+		Item string `csv:"item"`
+		Qty  int    `csv:"qty"`
+	}
+	for line, err := range csv.Records[Line](csv.NewReader(strings.NewReader(in))) {
+		if err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		fmt.Println(line.Item, line.Qty)
+	}
+	// Output:
+	// apple 3
+	// error: record on line 3, column 6: cannot unmarshal "many" into column "qty" of type int: strconv.ParseInt: parsing "many": invalid syntax
+	// plum 2
+}
+
+func ExampleMarshal() {
+	type Point struct {
+		X, Y  float64
+		Label string `csv:"label,omitempty"`
+	}
+	points := []Point{{1, 2, "origin-ish"}, {3.5, -1, ""}}
+	if err := csv.Marshal(csv.NewWriter(os.Stdout), points); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// X,Y,label
+	// 1,2,origin-ish
+	// 3.5,-1,
+}
@@ -0,0 +1,537 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A FieldError describes a CSV field that could not be converted
+// to or from the struct field it is mapped to.
+type FieldError struct {
+	Line   int          // Line where the field starts; 0 when writing
+	Column int          // Column (1-based byte index) where the field starts; 0 when writing
+	Header string       // Name of the column in the header record
+	Value  string       // Text of the field; empty when writing
+	Type   reflect.Type // Type of the struct field
+	Err    error        // The actual error
+}
+
+func (e *FieldError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("cannot marshal column %q of type %v: %v", e.Header, e.Type, e.Err)
+	}
+	return fmt.Sprintf("record on line %d, column %d: cannot unmarshal %q into column %q of type %v: %v",
+		e.Line, e.Column, e.Value, e.Header, e.Type, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// timeLayouts maps the names accepted by the format option
+// to the corresponding layouts of package time.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// A field describes a struct field mapped to a CSV column.
+type field struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	layout    string // time layout given by the format option
+	tagged    bool   // whether the name comes from the tag
+}
+
+type structFields struct {
+	fields []field
+	err    error
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+// cachedFields returns the fields of the struct type t
+// that are mapped to CSV columns, in struct order.
+func cachedFields(t reflect.Type) ([]field, error) {
+	if f, ok := fieldCache.Load(t); ok {
+		sf := f.(*structFields)
+		return sf.fields, sf.err
+	}
+	sf := new(structFields)
+	sf.fields, sf.err = typeFields(t)
+	f, _ := fieldCache.LoadOrStore(t, sf)
+	sf = f.(*structFields)
+	return sf.fields, sf.err
+}
+
+func typeFields(t reflect.Type) ([]field, error) {
+	var fields []field
+	var walk func(t reflect.Type, index []int) error
+	walk = func(t reflect.Type, index []int) error {
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("csv")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			idx := append(index[:len(index):len(index)], i)
+			if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct && !isScalar(sf.Type) {
+				if err := walk(sf.Type, idx); err != nil {
+					return err
+				}
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			f := field{name: name, index: idx, typ: sf.Type, tagged: name != ""}
+			if name == "" {
+				f.name = sf.Name
+			}
+			for opts != "" {
+				var opt string
+				if strings.HasPrefix(opts, "format:") {
+					// The layout extends to the end of the tag,
+					// since it may itself contain commas.
+					opt, opts = opts, ""
+				} else {
+					opt, opts, _ = strings.Cut(opts, ",")
+				}
+				switch {
+				case opt == "omitempty":
+					f.omitEmpty = true
+				case strings.HasPrefix(opt, "format:"):
+					layout := strings.TrimPrefix(opt, "format:")
+					if l, ok := timeLayouts[layout]; ok {
+						layout = l
+					}
+					f.layout = layout
+				default:
+					return fmt.Errorf("csv: unknown option %q in tag of field %s.%s", opt, t, sf.Name)
+				}
+			}
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.layout != "" && ft != timeType {
+				return fmt.Errorf("csv: format option used on field %s.%s of non-time type %v", t, sf.Name, sf.Type)
+			}
+			if !isScalar(ft) {
+				return fmt.Errorf("csv: unsupported type %v for field %s.%s", sf.Type, t, sf.Name)
+			}
+			fields = append(fields, f)
+		}
+		return nil
+	}
+	if err := walk(t, nil); err != nil {
+		return nil, err
+	}
+
+	// Resolve the fields with the same name as encoding/json does: the
+	// least nested one wins, then a tagged one at the same depth, and if
+	// that still leaves more than one, the name is dropped.
+	byName := make(map[string][]int)
+	for i, f := range fields {
+		byName[f.name] = append(byName[f.name], i)
+	}
+	out := fields[:0]
+	for i, f := range fields {
+		if dominantField(fields, byName[f.name]) == i {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// dominantField returns the index of the field among fields[i] for i in
+// candidates, all of the same name, that the name refers to, or -1 if none.
+func dominantField(fields []field, candidates []int) int {
+	depth := len(fields[candidates[0]].index)
+	for _, i := range candidates {
+		depth = min(depth, len(fields[i].index))
+	}
+	dominant, n := -1, 0
+	for _, tagged := range []bool{true, false} {
+		for _, i := range candidates {
+			if f := &fields[i]; len(f.index) == depth && f.tagged == tagged {
+				dominant = i
+				n++
+			}
+		}
+		if n > 0 {
+			break
+		}
+	}
+	if n > 1 {
+		return -1
+	}
+	return dominant
+}
+
+// isScalar reports whether values of type t are stored in a single field.
+func isScalar(t reflect.Type) bool {
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// structType returns the struct type of t or *t.
+func structType(t reflect.Type) (st reflect.Type, ptr bool) {
+	if t.Kind() == reflect.Pointer {
+		t, ptr = t.Elem(), true
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	return t, ptr
+}
+
+// A decoder maps the records of a Reader to structs.
+type decoder struct {
+	r       *Reader
+	header  []string
+	columns []*field // indexed by column; nil for columns without a field
+}
+
+// newDecoder reads the header record from r and
+// maps its columns to the fields of the struct type t.
+func newDecoder(r *Reader, t reflect.Type) (*decoder, error) {
+	fields, err := cachedFields(t)
+	if err != nil {
+		return nil, err
+	}
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{r: r, header: append([]string(nil), header...)}
+	d.columns = make([]*field, len(header))
+	for i, name := range header {
+		for j := range fields {
+			if fields[j].name == name {
+				d.columns[i] = &fields[j]
+				break
+			}
+		}
+	}
+	return d, nil
+}
+
+// decode reads the next record into the struct v.
+func (d *decoder) decode(v reflect.Value) error {
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	for i, s := range record {
+		if i >= len(d.columns) || d.columns[i] == nil {
+			continue
+		}
+		f := d.columns[i]
+		if err := unmarshalField(v.FieldByIndex(f.index), f, s); err != nil {
+			line, col := d.r.FieldPos(i)
+			return &FieldError{Line: line, Column: col, Header: d.header[i], Value: s, Type: f.typ, Err: err}
+		}
+	}
+	return nil
+}
+
+func unmarshalField(v reflect.Value, f *field, s string) error {
+	if s == "" {
+		v.SetZero()
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if f.layout != "" {
+		t, err := time.Parse(f.layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
+
+func marshalField(v reflect.Value, f *field) (string, error) {
+	if f.omitEmpty && v.IsZero() {
+		return "", nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if f.layout != "" {
+		return v.Interface().(time.Time).Format(f.layout), nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		v = v.Addr()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", errors.New("unsupported type")
+}
+
+// Unmarshal reads a header record followed by all remaining records from r
+// and appends them to the slice pointed to by v, which must be a non-nil
+// pointer to a slice of structs or of pointers to structs.
+//
+// Each column of the header record is mapped to the exported struct field
+// whose name, or the name given in its "csv" struct tag, equals the header
+// exactly. Columns without a matching field are ignored, and fields without
+// a matching column are left at their zero value. The fields of an embedded
+// struct are treated as fields of the outer struct, and a field with the tag
+// "-" is ignored. When several fields have the same name, the one chosen
+// follows the rules of [encoding/json.Marshal]: a less nested field hides
+// more nested ones, a tagged field hides untagged ones at the same depth,
+// and if that still leaves more than one field, the name is ignored.
+//
+// Fields of type string, bool, integer, or floating-point, of types
+// implementing [encoding.TextUnmarshaler], and pointers to those are
+// supported. The tag option "format:layout" parses a [time.Time] field with
+// the given [time.Time.Format] layout or with the layout named by one of the
+// constants of package time, such as "DateOnly" or "RFC1123". Because a
+// layout may contain commas, the format option must come last in the tag.
+// An empty field sets the struct field to its zero value.
+//
+// If a field cannot be converted, Unmarshal returns a [*FieldError] giving
+// the position of the field as reported by [Reader.FieldPos]. Records
+// decoded before the error remain appended to the slice.
+// If r contains no records, Unmarshal returns nil and leaves the slice
+// unchanged.
+func Unmarshal(r *Reader, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("csv: Unmarshal requires a non-nil pointer to a slice")
+	}
+	sv := rv.Elem()
+	st, ptr := structType(sv.Type().Elem())
+	if st == nil {
+		return fmt.Errorf("csv: cannot unmarshal into slice of %v", sv.Type().Elem())
+	}
+	d, err := newDecoder(r, st)
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	for {
+		p := reflect.New(st)
+		if err := d.decode(p.Elem()); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if ptr {
+			sv.Set(reflect.Append(sv, p))
+		} else {
+			sv.Set(reflect.Append(sv, p.Elem()))
+		}
+	}
+}
+
+// Records returns an iterator that reads a header record from r followed
+// by the remaining records, decoding each into a value of type T as
+// described for [Unmarshal]. T must be a struct type or a pointer to one.
+//
+// If a record cannot be read or decoded, the iterator yields the partially
+// decoded value along with the error. Iteration continues after a
+// [*ParseError] or [*FieldError] and stops after any other error.
+func Records[T any](r *Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		st, ptr := structType(reflect.TypeFor[T]())
+		if st == nil {
+			yield(zero, fmt.Errorf("csv: cannot unmarshal into %v", reflect.TypeFor[T]()))
+			return
+		}
+		d, err := newDecoder(r, st)
+		if err != nil {
+			if err != io.EOF {
+				yield(zero, err)
+			}
+			return
+		}
+		for {
+			var v T
+			var sv reflect.Value
+			if ptr {
+				p := reflect.New(st)
+				v, sv = p.Interface().(T), p.Elem()
+			} else {
+				sv = reflect.ValueOf(&v).Elem()
+			}
+			err := d.decode(sv)
+			if err == io.EOF {
+				return
+			}
+			if !yield(v, err) {
+				return
+			}
+			if err != nil {
+				var perr *ParseError
+				var ferr *FieldError
+				if !errors.As(err, &perr) && !errors.As(err, &ferr) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Marshal writes a header record followed by one record for each element
+// of v to w, and then calls [Writer.Flush], returning any error from the
+// Flush. The value v must be a slice or array of structs or of pointers to
+// structs. The header names and the supported field types are those
+// described for [Unmarshal].
+//
+// Numbers are formatted in decimal, [time.Time] fields with a format option
+// using its layout, and other types implementing [encoding.TextMarshaler]
+// using their MarshalText method. A field with the tag option "omitempty"
+// is written as an empty field if it has its zero value, as is a nil
+// pointer. A nil pointer element is written as a record of empty fields.
+func Marshal(w *Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	if k := rv.Kind(); k != reflect.Slice && k != reflect.Array {
+		return fmt.Errorf("csv: cannot marshal %T, want slice or array", v)
+	}
+	st, ptr := structType(rv.Type().Elem())
+	if st == nil {
+		return fmt.Errorf("csv: cannot marshal slice of %v", rv.Type().Elem())
+	}
+	fields, err := cachedFields(st)
+	if err != nil {
+		return err
+	}
+	record := make([]string, len(fields))
+	for i := range fields {
+		record[i] = fields[i].name
+	}
+	if err := w.Write(record); err != nil {
+		return err
+	}
+	for i := range rv.Len() {
+		ev := rv.Index(i)
+		if ptr {
+			if ev.IsNil() {
+				clear(record)
+				if err := w.Write(record); err != nil {
+					return err
+				}
+				continue
+			}
+			ev = ev.Elem()
+		}
+		for j := range fields {
+			f := &fields[j]
+			s, err := marshalField(ev.FieldByIndex(f.index), f)
+			if err != nil {
+				return &FieldError{Header: f.name, Type: f.typ, Err: err}
+			}
+			record[j] = s
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,225 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Base struct {
+	ID int `csv:"id"`
+}
+
+type record struct {
+	Base
+	Name    string     `csv:"name"`
+	Score   float64    `csv:"score,omitempty"`
+	Active  bool       `csv:"active"`
+	Joined  time.Time  `csv:"joined,format:DateOnly"`
+	Seen    *time.Time `csv:"seen,format:Jan 2, 2006"`
+	Addr    netip.Addr `csv:"addr"`
+	Count   *uint8
+	Ignored string `csv:"-"`
+	private string
+}
+
+const recordCSV = `id,name,score,active,joined,seen,addr,Count
+1,Ada,9.5,true,2024-01-02,"Mar 4, 2025",10.0.0.1,7
+2,Bob,,false,2024-05-06,,::1,
+`
+
+func TestUnmarshal(t *testing.T) {
+	var got []record
+	if err := Unmarshal(NewReader(strings.NewReader(recordCSV)), &got); err != nil {
+		t.Fatal(err)
+	}
+	seen := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	seven := uint8(7)
+	want := []record{{
+		Base: Base{ID: 1}, Name: "Ada", Score: 9.5, Active: true,
+		Joined: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Seen: &seen,
+		Addr: netip.MustParseAddr("10.0.0.1"), Count: &seven,
+	}, {
+		Base: Base{ID: 2}, Name: "Bob",
+		Joined: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Addr:   netip.MustParseAddr("::1"),
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	var got []*record
+	if err := Unmarshal(NewReader(strings.NewReader(recordCSV)), &got); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := Marshal(NewWriter(&b), got); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != recordCSV {
+		t.Errorf("Marshal:\ngot  %q\nwant %q", b.String(), recordCSV)
+	}
+}
+
+func TestUnmarshalHeaderMapping(t *testing.T) {
+	type row struct {
+		A string
+		B int `csv:"b"`
+		C string
+	}
+	in := "extra,b,A\nx,2,a\n"
+	var got []row
+	if err := Unmarshal(NewReader(strings.NewReader(in)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []row{{A: "a", B: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = nil
+	if err := Unmarshal(NewReader(strings.NewReader("")), &got); err != nil || got != nil {
+		t.Errorf("Unmarshal of empty input = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestUnmarshalFieldError(t *testing.T) {
+	type row struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+	in := "name,age\nAda,36\n\"Bob\nBobson\",old\n"
+	var got []row
+	err := Unmarshal(NewReader(strings.NewReader(in)), &got)
+	var ferr *FieldError
+	if !errors.As(err, &ferr) {
+		t.Fatalf("Unmarshal error = %v, want *FieldError", err)
+	}
+	if ferr.Line != 4 || ferr.Column != 9 || ferr.Header != "age" || ferr.Value != "old" {
+		t.Errorf("FieldError = %+v", ferr)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("error %v does not wrap strconv.ErrSyntax", err)
+	}
+	const msg = `record on line 4, column 9: cannot unmarshal "old" into column "age" of type int: strconv.ParseInt: parsing "old": invalid syntax`
+	if err.Error() != msg {
+		t.Errorf("Error() = %q, want %q", err.Error(), msg)
+	}
+	if len(got) != 1 {
+		t.Errorf("decoded %d records before the error, want 1", len(got))
+	}
+}
+
+func TestRecords(t *testing.T) {
+	type row struct {
+		N int `csv:"n"`
+	}
+	in := "n\n1\nx\n3\n4,5\n6\n"
+	var ns []int
+	var errs []error
+	for r, err := range Records[*row](NewReader(strings.NewReader(in))) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ns = append(ns, r.N)
+	}
+	if want := []int{1, 3, 6}; !reflect.DeepEqual(ns, want) {
+		t.Errorf("values = %v, want %v", ns, want)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want 2", errs)
+	}
+	var ferr *FieldError
+	var perr *ParseError
+	if !errors.As(errs[0], &ferr) || ferr.Line != 3 {
+		t.Errorf("first error = %v, want FieldError on line 3", errs[0])
+	}
+	if !errors.As(errs[1], &perr) || perr.Err != ErrFieldCount {
+		t.Errorf("second error = %v, want %v", errs[1], ErrFieldCount)
+	}
+
+	for range Records[row](NewReader(strings.NewReader(in))) {
+		break // stopping early must not panic
+	}
+}
+
+type precedenceA struct {
+	X string
+	Y string
+	Z string `csv:"Z"`
+}
+
+type precedenceB struct {
+	Y string
+	Z string
+}
+
+type precedence struct {
+	precedenceA
+	precedenceB
+	X string
+	D string `csv:"d"`
+	E string `csv:"d"`
+}
+
+func TestFieldPrecedence(t *testing.T) {
+	// X is hidden in precedenceA by the outer X, Z in precedenceB by the
+	// tagged Z of precedenceA, and Y and d are ambiguous and ignored.
+	var got []precedence
+	in := "X,Y,Z,d\n1,2,3,4\n"
+	if err := Unmarshal(NewReader(strings.NewReader(in)), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []precedence{{X: "1", precedenceA: precedenceA{Z: "3"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal:\ngot  %+v\nwant %+v", got, want)
+	}
+	var b strings.Builder
+	if err := Marshal(NewWriter(&b), want); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "Z,X\n3,1\n"; got != want {
+		t.Errorf("Marshal = %q, want %q", got, want)
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	type badOption struct {
+		A string `csv:"a,bogus"`
+	}
+	type badFormat struct {
+		A int `csv:"a,format:DateOnly"`
+	}
+	type badType struct {
+		A []string
+	}
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{&[]badOption{}, `unknown option "bogus"`},
+		{&[]badFormat{}, "non-time type"},
+		{&[]badType{}, "unsupported type"},
+		{&[]int{}, "cannot unmarshal into slice of int"},
+		{[]record{}, "non-nil pointer"},
+	}
+	for _, tt := range tests {
+		err := Unmarshal(NewReader(strings.NewReader("a\n1\n")), tt.v)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Unmarshal(%T) error = %v, want %q", tt.v, err, tt.want)
+		}
+	}
+	if err := Marshal(NewWriter(new(strings.Builder)), 1); err == nil {
+		t.Error("Marshal(int) succeeded, want error")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gob
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// A ChangeKind identifies how a type as transmitted differs from the
+// local type it is decoded into. See [SchemaChange].
+type ChangeKind int
+
+const (
+	// FieldAdded reports a field of the local struct type that is absent
+	// from the transmitted type, as when the sender runs an older version
+	// of the program. Decoding leaves such fields unchanged.
+	FieldAdded ChangeKind = iota + 1
+
+	// FieldRemoved reports a transmitted field that has no counterpart in
+	// the local struct type, as when the sender runs a newer version of
+	// the program. Decoding discards the field's data unless the Decoder
+	// disallows unknown fields.
+	FieldRemoved
+
+	// FieldTypeChanged reports a field present in both types whose types
+	// are not compatible. Decoding the value fails.
+	FieldTypeChanged
+
+	// TypeNotRegistered reports an interface value whose concrete type
+	// name was not registered with [Register] or [RegisterName] by the
+	// receiver. Decoding the value fails.
+	TypeNotRegistered
+)
+
+var changeKindNames = [...]string{
+	FieldAdded:        "FieldAdded",
+	FieldRemoved:      "FieldRemoved",
+	FieldTypeChanged:  "FieldTypeChanged",
+	TypeNotRegistered: "TypeNotRegistered",
+}
+
+func (k ChangeKind) String() string {
+	if 0 < k && int(k) < len(changeKindNames) {
+		return changeKindNames[k]
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A SchemaChange describes a difference between a type as described by
+// the encoder and the local type into which a [Decoder] stores it.
+// See [Decoder.ReportSchemaChanges].
+type SchemaChange struct {
+	Kind ChangeKind
+
+	// Type is the local type being decoded into. For TypeNotRegistered
+	// it is the interface type.
+	Type reflect.Type
+
+	// WireType is the name of the type as transmitted. For
+	// TypeNotRegistered it is the name of the concrete type.
+	WireType string
+
+	// Field is the name of the field that changed. It is empty for
+	// TypeNotRegistered.
+	Field string
+
+	// WireFieldType describes the transmitted type of the field for
+	// FieldRemoved and FieldTypeChanged.
+	WireFieldType string
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("field %s of %s is not in transmitted type %s", c.Field, c.Type, c.WireType)
+	case FieldRemoved:
+		return fmt.Sprintf("transmitted field %s.%s (%s) is not in %s", c.WireType, c.Field, c.WireFieldType, c.Type)
+	case FieldTypeChanged:
+		return fmt.Sprintf("transmitted field %s.%s (%s) is not compatible with field %s of %s", c.WireType, c.Field, c.WireFieldType, c.Field, c.Type)
+	case TypeNotRegistered:
+		return fmt.Sprintf("name %q is not registered for interface %s", c.WireType, c.Type)
+	}
+	return c.Kind.String()
+}
+
+// ReportSchemaChanges arranges for the Decoder to call f with each
+// difference it finds between a transmitted struct type and the local
+// struct type it is decoded into, and with each interface value whose
+// concrete type is not registered. Struct differences are reported once
+// per pair of types, when the Decoder first prepares to decode one into
+// the other; the report precedes any error that results from the
+// difference, and includes the differences after the first one that makes
+// decoding fail. f is called while decoding is in progress and must not
+// call methods of the Decoder. Calling ReportSchemaChanges with a nil f
+// stops reporting.
+func (dec *Decoder) ReportSchemaChanges(f func(SchemaChange)) {
+	dec.mutex.Lock()
+	defer dec.mutex.Unlock()
+	dec.reportChange = f
+	dec.resetEngines()
+}
+
+// DisallowUnknownFields causes the Decoder to return an error when a
+// transmitted struct contains a field that has no counterpart in the
+// local type, rather than silently discarding the field's data.
+// Values decoded with a nil destination are unaffected.
+func (dec *Decoder) DisallowUnknownFields() {
+	dec.mutex.Lock()
+	defer dec.mutex.Unlock()
+	dec.disallowUnknownFields = true
+	dec.resetEngines()
+}
+
+// resetEngines discards compiled decoders so that they are rebuilt
+// under the Decoder's current settings.
+func (dec *Decoder) resetEngines() {
+	clear(dec.decoderCache)
+}
+
+// reportFieldChanges reports the fields of the local struct type rt that
+// are missing from wireStruct, followed by the other changes found while
+// compiling the decoder.
+func (dec *Decoder) reportFieldChanges(rt reflect.Type, wireStruct *structType, changes []SchemaChange) {
+	sent := make(map[string]bool, len(wireStruct.Field))
+	for _, f := range wireStruct.Field {
+		sent[f.Name] = true
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if isSent(&f) && !sent[f.Name] {
+			dec.reportChange(SchemaChange{Kind: FieldAdded, Type: rt, WireType: wireStruct.Name, Field: f.Name})
+		}
+	}
+	for _, c := range changes {
+		dec.reportChange(c)
+	}
+}
+
+// wireTypeName returns the name of the transmitted type with the given id.
+func (dec *Decoder) wireTypeName(id typeId) string {
+	if t := builtinIdToType(id); t != nil {
+		return t.name()
+	}
+	return dec.wireType[id].string()
+}
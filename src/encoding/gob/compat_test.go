@@ -0,0 +1,172 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gob
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type compatV1 struct {
+	A     int
+	B     string
+	C     []int
+	Inner compatInnerV1
+}
+
+type compatInnerV1 struct {
+	X, Y int
+}
+
+type compatV2 struct {
+	A     int
+	B     int // changed type
+	D     float64
+	Inner compatInnerV2
+}
+
+type compatInnerV2 struct {
+	X int
+	Z string
+	f int
+	G chan int
+}
+
+func encodeCompat(t *testing.T, vals ...any) *bytes.Buffer {
+	t.Helper()
+	b := new(bytes.Buffer)
+	enc := NewEncoder(b)
+	for _, v := range vals {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func TestReportSchemaChanges(t *testing.T) {
+	b := encodeCompat(t, compatV1{A: 1, B: "x", C: []int{2}, Inner: compatInnerV1{3, 4}})
+	dec := NewDecoder(b)
+	var got []string
+	dec.ReportSchemaChanges(func(c SchemaChange) {
+		got = append(got, c.Kind.String()+" "+c.Field+" "+c.WireFieldType)
+	})
+	var v compatV2
+	err := dec.Decode(&v)
+	if err == nil || !strings.Contains(err.Error(), "wrong type (int) for received field compatV1.B") {
+		t.Fatalf("Decode error = %v, want wrong type error", err)
+	}
+	// The changes after the incompatible field B are reported too.
+	want := []string{
+		"FieldAdded D ",
+		"FieldTypeChanged B string",
+		"FieldRemoved C []int",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestReportSchemaChangesNested(t *testing.T) {
+	type outer1 struct{ Inner compatInnerV1 }
+	type outer2 struct{ Inner compatInnerV2 }
+	b := encodeCompat(t, outer1{compatInnerV1{1, 2}}, outer1{compatInnerV1{3, 4}})
+	dec := NewDecoder(b)
+	var got []SchemaChange
+	dec.ReportSchemaChanges(func(c SchemaChange) { got = append(got, c) })
+	for range 2 {
+		var v outer2
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []SchemaChange{
+		{Kind: FieldAdded, Type: reflect.TypeFor[compatInnerV2](), WireType: "compatInnerV1", Field: "Z"},
+		{Kind: FieldRemoved, Type: reflect.TypeFor[compatInnerV2](), WireType: "compatInnerV1", Field: "Y", WireFieldType: "int"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	type T1 struct{ A, B int }
+	type T2 struct{ A int }
+	b := encodeCompat(t, T1{1, 2}, T1{3, 4}, T2{5})
+
+	dec := NewDecoder(bytes.NewReader(b.Bytes()))
+	var v T2
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("lenient Decode: %v", err)
+	}
+
+	dec = NewDecoder(bytes.NewReader(b.Bytes()))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&v)
+	if err == nil || err.Error() != "gob: unknown field T1.B for type gob.T2" {
+		t.Fatalf("strict Decode error = %v", err)
+	}
+	// Values with no destination may still be skipped.
+	if err := dec.Decode(nil); err != nil {
+		t.Fatalf("Decode(nil): %v", err)
+	}
+	v = T2{}
+	if err := dec.Decode(&v); err != nil || v.A != 5 {
+		t.Fatalf("Decode = %v, %v; want {5}", v, err)
+	}
+}
+
+func TestReportSchemaChangesAfterError(t *testing.T) {
+	type T1 struct{ A, B, C, D int }
+	type T2 struct {
+		B string
+		D string
+	}
+	b := encodeCompat(t, T1{1, 2, 3, 4})
+	dec := NewDecoder(b)
+	dec.DisallowUnknownFields()
+	var got []string
+	dec.ReportSchemaChanges(func(c SchemaChange) {
+		got = append(got, c.Kind.String()+" "+c.Field)
+	})
+	var v T2
+	// The error is the first one, but all the changes are reported.
+	err := dec.Decode(&v)
+	if err == nil || err.Error() != "gob: unknown field T1.A for type gob.T2" {
+		t.Fatalf("Decode error = %v, want unknown field A", err)
+	}
+	want := []string{"FieldRemoved A", "FieldTypeChanged B", "FieldRemoved C", "FieldTypeChanged D"}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestReportUnregisteredType(t *testing.T) {
+	type unregistered struct{ N int }
+	var w bytes.Buffer
+	enc := NewEncoder(&w)
+	RegisterName("gob.compatUnregistered", unregistered{})
+	if err := enc.Encode(&struct{ I any }{unregistered{1}}); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a receiver that lacks the registration by renaming the
+	// type in the stream.
+	data := bytes.Replace(w.Bytes(), []byte("gob.compatUnregistered"), []byte("gob.compatUnknownType_"), 1)
+	dec := NewDecoder(bytes.NewReader(data))
+	var got []SchemaChange
+	dec.ReportSchemaChanges(func(c SchemaChange) { got = append(got, c) })
+	var v struct{ I any }
+	err := dec.Decode(&v)
+	if err == nil || err.Error() != `gob: name not registered for interface: "gob.compatUnknownType_"` {
+		t.Fatalf("Decode error = %v", err)
+	}
+	want := []SchemaChange{{Kind: TypeNotRegistered, Type: reflect.TypeFor[any](), WireType: "gob.compatUnknownType_"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
@@ -9,6 +9,7 @@ package gob
 import (
 	"encoding"
 	"errors"
+	"fmt"
 	"internal/saferio"
 	"io"
 	"math"
@@ -699,6 +700,9 @@ func (dec *Decoder) decodeInterface(ityp reflect.Type, state *decoderState, valu
 	// The concrete type must be registered.
 	typi, ok := nameToConcreteType.Load(string(name))
 	if !ok {
+		if dec.reportChange != nil {
+			dec.reportChange(SchemaChange{Kind: TypeNotRegistered, Type: ityp, WireType: string(name)})
+		}
 		errorf("name not registered for interface: %q", name)
 	}
 	typ := typi.(reflect.Type)
@@ -1155,6 +1159,11 @@ func (dec *Decoder) compileDec(remoteId typeId, ut *userTypeInfo) (engine *decEn
 	engine = new(decEngine)
 	engine.instr = make([]decInstr, len(wireStruct.Field))
 	seen := make(map[reflect.Type]*decOp)
+	// Differences from the wire type matter only when decoding into a
+	// real destination, not when ignoring the value.
+	local := srt != emptyStructType
+	var changes []SchemaChange
+	var fail error // first error found; remaining fields are only checked
 	// Loop over the fields of the wire type.
 	for fieldnum := 0; fieldnum < len(wireStruct.Field); fieldnum++ {
 		wireField := wireStruct.Field[fieldnum]
@@ -1166,17 +1175,40 @@ func (dec *Decoder) compileDec(remoteId typeId, ut *userTypeInfo) (engine *decEn
 		localField, present := srt.FieldByName(wireField.Name)
 		// TODO(r): anonymous names
 		if !present || !isExported(wireField.Name) {
+			if local {
+				changes = append(changes, SchemaChange{Kind: FieldRemoved, Type: rt, WireType: wireStruct.Name,
+					Field: wireField.Name, WireFieldType: dec.wireTypeName(wireField.Id)})
+				if dec.disallowUnknownFields && fail == nil {
+					fail = fmt.Errorf("gob: unknown field %s.%s for type %s", wireStruct.Name, wireField.Name, rt)
+				}
+			}
+			if fail != nil {
+				continue
+			}
 			op := dec.decIgnoreOpFor(wireField.Id, make(map[typeId]*decOp))
 			engine.instr[fieldnum] = decInstr{*op, fieldnum, nil, ovfl}
 			continue
 		}
 		if !dec.compatibleType(localField.Type, wireField.Id, make(map[reflect.Type]typeId)) {
-			errorf("wrong type (%s) for received field %s.%s", localField.Type, wireStruct.Name, wireField.Name)
+			changes = append(changes, SchemaChange{Kind: FieldTypeChanged, Type: rt, WireType: wireStruct.Name,
+				Field: wireField.Name, WireFieldType: dec.wireTypeName(wireField.Id)})
+			if fail == nil {
+				fail = fmt.Errorf("gob: wrong type (%s) for received field %s.%s", localField.Type, wireStruct.Name, wireField.Name)
+			}
+		}
+		if fail != nil {
+			continue
 		}
 		op := dec.decOpFor(wireField.Id, localField.Type, localField.Name, seen)
 		engine.instr[fieldnum] = decInstr{*op, fieldnum, localField.Index, ovfl}
 		engine.numInstr++
 	}
+	if local && dec.reportChange != nil {
+		dec.reportFieldChanges(srt, wireStruct, changes)
+	}
+	if fail != nil {
+		error_(fail)
+	}
 	return
 }
 
@@ -37,6 +37,10 @@ type Decoder struct {
 	err          error
 	// ignoreDepth tracks the depth of recursively parsed ignored fields
 	ignoreDepth int
+
+	disallowUnknownFields bool               // reject transmitted fields with no local counterpart
+	reportChange          func(SchemaChange) // if non-nil, called with differences between wire and local types
+	typeLog               *[]typeId          // if non-nil, records the ids of received types
 }
 
 // NewDecoder returns a new decoder that reads from the [io.Reader].
@@ -73,6 +77,9 @@ func (dec *Decoder) recvType(id typeId) {
 	}
 	// Remember we've seen this type.
 	dec.wireType[id] = wire
+	if dec.typeLog != nil {
+		*dec.typeLog = append(*dec.typeLog, id)
+	}
 }
 
 var errBadCount = errors.New("invalid message length")
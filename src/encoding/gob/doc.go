@@ -62,6 +62,12 @@ Attempting to receive into these types will draw a decode error:
 	struct { }			// no field names in common
 	struct { C, D int }		// no field names in common
 
+A [Decoder] can report these differences as it finds them, which helps when
+the two sides of a connection run different versions of a program; see
+[Decoder.ReportSchemaChanges]. [Decoder.DisallowUnknownFields] makes dropped
+data an error, and [Decoder.Inspect] describes a stream as transmitted, without
+reference to local types.
+
 Integers are transmitted two ways: arbitrary precision signed integers or
 arbitrary precision unsigned integers. There is no int8, int16 etc.
 discrimination in the gob format; there are only signed and unsigned integers. As
@@ -0,0 +1,325 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gob
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// A Message describes one top-level value read from a gob stream by
+// [Decoder.Inspect], together with the type definitions that arrived
+// with it.
+type Message struct {
+	// Types lists the type definitions received while reading the
+	// message, in the order they arrived. It includes definitions sent
+	// for the concrete types of interface values.
+	Types []*WireType
+
+	// Value is the transmitted value.
+	Value *Value
+}
+
+// A WireKind identifies the form of a [WireType].
+type WireKind int
+
+const (
+	WireArray           WireKind = iota + 1 // a fixed-length array
+	WireSlice                               // a slice
+	WireStruct                              // a struct
+	WireMap                                 // a map
+	WireGobEncoder                          // a type implementing GobEncoder
+	WireBinaryMarshaler                     // a type implementing encoding.BinaryMarshaler
+	WireTextMarshaler                       // a type implementing encoding.TextMarshaler
+)
+
+var wireKindNames = [...]string{
+	WireArray:           "array",
+	WireSlice:           "slice",
+	WireStruct:          "struct",
+	WireMap:             "map",
+	WireGobEncoder:      "GobEncoder",
+	WireBinaryMarshaler: "BinaryMarshaler",
+	WireTextMarshaler:   "TextMarshaler",
+}
+
+func (k WireKind) String() string {
+	if 0 < k && int(k) < len(wireKindNames) {
+		return wireKindNames[k]
+	}
+	return "WireKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A WireType describes a type definition as transmitted in a gob stream.
+// Types are referred to by name; the predefined types are named
+// "bool", "int", "uint", "float", "bytes", "string", "complex" and
+// "interface".
+type WireType struct {
+	Id     int    // the id by which the stream refers to the type
+	Name   string // the name of the type
+	Kind   WireKind
+	Len    int         // the length of an array
+	Key    string      // the key type of a map
+	Elem   string      // the element type of an array, slice or map
+	Fields []WireField // the fields of a struct
+}
+
+// A WireField describes a field of a transmitted struct type.
+type WireField struct {
+	Name string
+	Type string
+}
+
+// A Value describes a value as transmitted in a gob stream. Which fields
+// are set depends on the kind of its type.
+type Value struct {
+	// Type is the name of the value's type.
+	Type string
+
+	// Basic holds the value of a predefined type as a bool, int64,
+	// uint64, float64, complex128, []byte or string, and the encoded
+	// form of a GobEncoder, BinaryMarshaler or TextMarshaler as a []byte.
+	Basic any
+
+	// Elems holds the elements of an array or slice, or the values of a map.
+	Elems []*Value
+
+	// Keys holds the keys of a map, in the order they were transmitted.
+	// Keys[i] is the key of Elems[i].
+	Keys []*Value
+
+	// Fields holds the transmitted fields of a struct. Fields with zero
+	// values are not transmitted.
+	Fields []FieldValue
+
+	// Name is the registered name of the concrete type of an interface
+	// value, and Concrete is its value. Both are zero for a nil interface.
+	Name     string
+	Concrete *Value
+}
+
+// A FieldValue is a field of a transmitted struct.
+type FieldValue struct {
+	Name  string
+	Value *Value
+}
+
+// maxInspectDepth limits the nesting of values described by Inspect.
+const maxInspectDepth = 10000
+
+// Inspect reads the next value from the input stream and returns a
+// description of it as transmitted, without storing it in a Go value.
+// Unlike [Decoder.Decode], it requires neither the local types nor the
+// registration of the concrete types of interface values, so it can
+// examine any well-formed stream. Types received by Inspect are
+// remembered, and calls to Inspect and Decode may be interleaved.
+// If the input is at EOF, Inspect returns [io.EOF].
+func (dec *Decoder) Inspect() (*Message, error) {
+	dec.mutex.Lock()
+	defer dec.mutex.Unlock()
+
+	dec.buf.Reset() // In case data lingers from previous invocation.
+	dec.err = nil
+	var ids []typeId
+	dec.typeLog = &ids
+	defer func() { dec.typeLog = nil }()
+	id := dec.decodeTypeSequence(false)
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	m := new(Message)
+	func() {
+		defer catchError(&dec.err)
+		m.Value = dec.inspectValue(id, 0)
+	}()
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	for _, id := range ids {
+		m.Types = append(m.Types, dec.describeType(id))
+	}
+	return m, nil
+}
+
+// describeType returns the description of the received type id.
+func (dec *Decoder) describeType(id typeId) *WireType {
+	wire := dec.wireType[id]
+	t := &WireType{Id: int(id), Name: wire.string()}
+	switch {
+	case wire.ArrayT != nil:
+		t.Kind, t.Len, t.Elem = WireArray, wire.ArrayT.Len, dec.wireTypeName(wire.ArrayT.Elem)
+	case wire.SliceT != nil:
+		t.Kind, t.Elem = WireSlice, dec.wireTypeName(wire.SliceT.Elem)
+	case wire.StructT != nil:
+		t.Kind = WireStruct
+		for _, f := range wire.StructT.Field {
+			t.Fields = append(t.Fields, WireField{f.Name, dec.wireTypeName(f.Id)})
+		}
+	case wire.MapT != nil:
+		t.Kind, t.Key, t.Elem = WireMap, dec.wireTypeName(wire.MapT.Key), dec.wireTypeName(wire.MapT.Elem)
+	case wire.GobEncoderT != nil:
+		t.Kind = WireGobEncoder
+	case wire.BinaryMarshalerT != nil:
+		t.Kind = WireBinaryMarshaler
+	case wire.TextMarshalerT != nil:
+		t.Kind = WireTextMarshaler
+	}
+	return t
+}
+
+// lookupWireType returns the definition of a type that is not predefined
+// as a basic type.
+func (dec *Decoder) lookupWireType(id typeId) *wireType {
+	if wire := dec.wireType[id]; wire != nil {
+		return wire
+	}
+	if st, ok := builtinIdToType(id).(*structType); ok {
+		return &wireType{StructT: st}
+	}
+	errorf("bad data: undefined type %s", id.string())
+	return nil
+}
+
+// inspectValue describes a top-level value, which is either a struct or a
+// singleton preceded by a zero delta, as for Decoder.decodeValue.
+func (dec *Decoder) inspectValue(id typeId, depth int) *Value {
+	state := dec.newDecoderState(&dec.buf)
+	defer dec.freeDecoderState(state)
+	if _, ok := decIgnoreOpMap[id]; !ok && id != tInterface {
+		if wire := dec.lookupWireType(id); wire.StructT != nil {
+			return dec.inspectStruct(state, id, wire.StructT, depth)
+		}
+	}
+	if state.decodeUint() != 0 {
+		errorf("decode: corrupted data: non-zero delta for singleton")
+	}
+	return dec.inspectItem(state, id, depth)
+}
+
+// inspectStruct describes the fields of a struct, which are terminated by
+// a zero delta. Unlike Decoder.decodeStruct, it requires the terminator,
+// so that every struct takes at least one byte of input.
+func (dec *Decoder) inspectStruct(state *decoderState, id typeId, st *structType, depth int) *Value {
+	v := &Value{Type: dec.wireTypeName(id)}
+	fieldnum := -1
+	for {
+		if state.b.Len() == 0 {
+			error_(io.ErrUnexpectedEOF)
+		}
+		delta := int(state.decodeUint())
+		if delta < 0 {
+			errorf("decode: corrupted data: negative delta")
+		}
+		if delta == 0 { // struct terminator is zero delta fieldnum
+			break
+		}
+		if delta >= len(st.Field)-fieldnum {
+			error_(errRange)
+		}
+		fieldnum += delta
+		f := st.Field[fieldnum]
+		v.Fields = append(v.Fields, FieldValue{f.Name, dec.inspectItem(state, f.Id, depth+1)})
+	}
+	return v
+}
+
+// inspectItem describes a value that is not at top level.
+func (dec *Decoder) inspectItem(state *decoderState, id typeId, depth int) *Value {
+	if depth > maxInspectDepth {
+		error_(errors.New("gob: invalid nesting depth"))
+	}
+	v := &Value{Type: dec.wireTypeName(id)}
+	switch id {
+	case tBool:
+		v.Basic = state.decodeUint() != 0
+	case tInt:
+		v.Basic = state.decodeInt()
+	case tUint:
+		v.Basic = state.decodeUint()
+	case tFloat:
+		v.Basic = float64FromBits(state.decodeUint())
+	case tComplex:
+		real := float64FromBits(state.decodeUint())
+		imag := float64FromBits(state.decodeUint())
+		v.Basic = complex(real, imag)
+	case tBytes:
+		v.Basic = inspectBytes(state)
+	case tString:
+		v.Basic = string(inspectBytes(state))
+	case tInterface:
+		dec.inspectInterface(state, v, depth)
+	default:
+		wire := dec.lookupWireType(id)
+		switch {
+		case wire.ArrayT != nil:
+			n := inspectCount(state)
+			if n != wire.ArrayT.Len {
+				errorf("length mismatch in decodeArray")
+			}
+			v.Elems = dec.inspectElems(state, wire.ArrayT.Elem, n, depth)
+		case wire.SliceT != nil:
+			v.Elems = dec.inspectElems(state, wire.SliceT.Elem, inspectCount(state), depth)
+		case wire.MapT != nil:
+			n := inspectCount(state)
+			for range n {
+				v.Keys = append(v.Keys, dec.inspectItem(state, wire.MapT.Key, depth+1))
+				v.Elems = append(v.Elems, dec.inspectItem(state, wire.MapT.Elem, depth+1))
+			}
+		case wire.StructT != nil:
+			return dec.inspectStruct(state, id, wire.StructT, depth)
+		default:
+			v.Basic = inspectBytes(state)
+		}
+	}
+	return v
+}
+
+// inspectCount reads the length of an array, slice or map. Every element
+// takes at least one byte, which bounds the length by the remaining input.
+func inspectCount(state *decoderState) int {
+	n := state.decodeUint()
+	if n > uint64(state.b.Len()) {
+		errorf("decoding array or slice: length exceeds input size (%d elements)", n)
+	}
+	return int(n)
+}
+
+func (dec *Decoder) inspectElems(state *decoderState, elemId typeId, n, depth int) []*Value {
+	elems := make([]*Value, 0, n)
+	for range n {
+		elems = append(elems, dec.inspectItem(state, elemId, depth+1))
+	}
+	return elems
+}
+
+// inspectBytes reads a count-delimited byte sequence.
+func inspectBytes(state *decoderState) []byte {
+	n, ok := state.getLength()
+	if !ok {
+		errorf("invalid length %d: exceeds input size %d", n, state.b.Len())
+	}
+	b := make([]byte, n)
+	copy(b, state.b.Bytes())
+	state.b.Drop(n)
+	return b
+}
+
+// inspectInterface describes an interface value: the name of its concrete
+// type, any type definitions, and the delimited concrete value.
+func (dec *Decoder) inspectInterface(state *decoderState, v *Value, depth int) {
+	v.Name = string(inspectBytes(state))
+	if v.Name == "" {
+		return
+	}
+	id := dec.decodeTypeSequence(true)
+	if id < 0 {
+		error_(dec.err)
+	}
+	// Byte count of value is next; the value itself is encoded as at
+	// top level.
+	state.decodeUint()
+	v.Concrete = dec.inspectValue(id, depth+1)
+}
@@ -0,0 +1,234 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gob
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type inspectPoint struct {
+	X, Y int
+}
+
+type inspectShape struct {
+	Name   string
+	Points []inspectPoint
+	Attrs  map[string]float64
+	Fill   [2]bool
+	Data   []byte
+	Next   *inspectShape
+	Extra  any
+	When   time.Time
+	Weight complex64
+	Count  uint16
+}
+
+func TestInspect(t *testing.T) {
+	Register(inspectPoint{})
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	whenBytes, _ := when.GobEncode()
+	b := encodeCompat(t,
+		inspectShape{
+			Name:   "tri",
+			Points: []inspectPoint{{1, 2}, {0, -3}},
+			Attrs:  map[string]float64{"a": 1.5},
+			Fill:   [2]bool{false, true},
+			Data:   []byte("xy"),
+			Next:   &inspectShape{Name: "child"},
+			Extra:  inspectPoint{X: 7},
+			When:   when,
+			Weight: 1 + 2i,
+			Count:  9,
+		},
+		"hello",
+	)
+	dec := NewDecoder(b)
+	m, err := dec.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := make(map[string]*WireType)
+	for _, typ := range m.Types {
+		types[typ.Name] = typ
+	}
+	if typ := types["inspectShape"]; typ == nil || typ.Kind != WireStruct || len(typ.Fields) != 10 ||
+		typ.Fields[1] != (WireField{"Points", "[]gob.inspectPoint"}) || typ.Fields[5] != (WireField{"Next", "inspectShape"}) {
+		t.Errorf("inspectShape = %+v", typ)
+	}
+	if typ := types["map[string]float64"]; typ == nil || typ.Kind != WireMap || typ.Key != "string" || typ.Elem != "float" {
+		t.Errorf("map type = %+v", typ)
+	}
+	if typ := types["[2]bool"]; typ == nil || typ.Kind != WireArray || typ.Len != 2 || typ.Elem != "bool" {
+		t.Errorf("array type = %+v", typ)
+	}
+	if typ := types["Time"]; typ == nil || typ.Kind != WireGobEncoder {
+		t.Errorf("time type = %+v", typ)
+	}
+
+	point := func(x, y int64) *Value {
+		v := &Value{Type: "inspectPoint"}
+		if x != 0 {
+			v.Fields = append(v.Fields, FieldValue{"X", &Value{Type: "int", Basic: x}})
+		}
+		if y != 0 {
+			v.Fields = append(v.Fields, FieldValue{"Y", &Value{Type: "int", Basic: y}})
+		}
+		return v
+	}
+	want := &Value{Type: "inspectShape", Fields: []FieldValue{
+		{"Name", &Value{Type: "string", Basic: "tri"}},
+		{"Points", &Value{Type: "[]gob.inspectPoint", Elems: []*Value{point(1, 2), point(0, -3)}}},
+		{"Attrs", &Value{Type: "map[string]float64",
+			Keys:  []*Value{{Type: "string", Basic: "a"}},
+			Elems: []*Value{{Type: "float", Basic: 1.5}}}},
+		{"Fill", &Value{Type: "[2]bool", Elems: []*Value{{Type: "bool", Basic: false}, {Type: "bool", Basic: true}}}},
+		{"Data", &Value{Type: "bytes", Basic: []byte("xy")}},
+		{"Next", &Value{Type: "inspectShape", Fields: []FieldValue{
+			{"Name", &Value{Type: "string", Basic: "child"}},
+			// Arrays are transmitted even when zero.
+			{"Fill", &Value{Type: "[2]bool", Elems: []*Value{{Type: "bool", Basic: false}, {Type: "bool", Basic: false}}}},
+		}}},
+		{"Extra", &Value{Type: "interface", Name: "encoding/gob.inspectPoint", Concrete: point(7, 0)}},
+		{"When", &Value{Type: "Time", Basic: whenBytes}},
+		{"Weight", &Value{Type: "complex", Basic: complex128(1 + 2i)}},
+		{"Count", &Value{Type: "uint", Basic: uint64(9)}},
+	}}
+	if !reflect.DeepEqual(m.Value, want) {
+		t.Errorf("Inspect value mismatch:\ngot  %s\nwant %s", dumpValue(m.Value), dumpValue(want))
+	}
+
+	// Types already received are not reported again, and Inspect
+	// and Decode may be interleaved.
+	m, err = dec.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Types) != 0 || !reflect.DeepEqual(m.Value, &Value{Type: "string", Basic: "hello"}) {
+		t.Errorf("second Inspect = %+v", m)
+	}
+	if _, err := dec.Inspect(); err != io.EOF {
+		t.Errorf("Inspect at EOF: %v", err)
+	}
+}
+
+func TestInspectThenDecode(t *testing.T) {
+	b := encodeCompat(t, inspectPoint{1, 2}, inspectPoint{3, 4})
+	dec := NewDecoder(b)
+	if _, err := dec.Inspect(); err != nil {
+		t.Fatal(err)
+	}
+	var p inspectPoint
+	if err := dec.Decode(&p); err != nil || p != (inspectPoint{3, 4}) {
+		t.Fatalf("Decode = %v, %v", p, err)
+	}
+}
+
+func TestInspectBadData(t *testing.T) {
+	for i, test := range badDataTests {
+		data, err := hex.DecodeString(test.input)
+		if err != nil {
+			t.Fatalf("#%d: hex error: %s", i, err)
+		}
+		// Inspect must fail cleanly or succeed; it must not panic.
+		NewDecoder(bytes.NewReader(data)).Inspect()
+	}
+	b := encodeCompat(t, inspectShape{Name: "x", Points: []inspectPoint{{1, 2}}})
+	data := b.Bytes()
+	for n := range len(data) {
+		dec := NewDecoder(bytes.NewReader(data[:n]))
+		if _, err := dec.Inspect(); err == nil {
+			t.Errorf("Inspect of %d-byte prefix succeeded", n)
+		} else if n > 0 && errors.Is(err, io.EOF) {
+			t.Errorf("Inspect of %d-byte prefix: %v, want unexpected EOF", n, err)
+		}
+	}
+}
+
+// craftArrayStream returns a stream that defines type 66 as [n]S, where
+// S is struct{ X int }, and then sends value, which follows the type id of
+// a value of type 66.
+func craftArrayStream(n int, value []byte) []byte {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	state := enc.newEncoderState(new(encBuffer))
+	defer enc.freeEncoderState(state)
+	state.b.Write(spaceForLength)
+	send := func(id typeId, wire *wireType) {
+		state.encodeInt(-int64(id))
+		enc.encode(state.b, reflect.ValueOf(*wire), wireTypeUserInfo)
+		enc.writeMessage(&buf, state.b)
+	}
+	send(65, &wireType{StructT: &structType{CommonType{"S", 65}, []fieldType{{"X", tInt}}}})
+	send(66, &wireType{ArrayT: &arrayType{CommonType{"A", 66}, 65, n}})
+	state.encodeInt(66)
+	state.b.Write(value)
+	enc.writeMessage(&buf, state.b)
+	return buf.Bytes()
+}
+
+func TestInspectArrayLength(t *testing.T) {
+	// A singleton delta, the length 2, {X: 3} and {}.
+	data := craftArrayStream(2, []byte{0, 2, 1, 6, 0, 0})
+	m, err := NewDecoder(bytes.NewReader(data)).Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dumpValue(m.Value), "A(S(X=int(3) ) S() )"; got != want {
+		t.Errorf("Inspect = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name  string
+		n     int
+		value []byte
+		want  error
+	}{
+		// Elements that take no input must not be conjured up from
+		// the declared length.
+		{"huge", 1 << 28, []byte{0, 0xfc, 0x10, 0, 0, 0}, nil},
+		{"missing elements", 1024, []byte{0, 0xfe, 0x04, 0x00}, nil},
+		{"missing element", 2, []byte{0, 2, 1, 6, 0}, io.ErrUnexpectedEOF},
+		{"missing field terminator", 1, []byte{0, 1, 1, 6}, io.ErrUnexpectedEOF},
+		{"length mismatch", 2, []byte{0, 1, 0}, nil},
+	}
+	for _, tt := range tests {
+		data := craftArrayStream(tt.n, tt.value)
+		_, err := NewDecoder(bytes.NewReader(data)).Inspect()
+		if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: Inspect error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func dumpValue(v *Value) string {
+	if v == nil {
+		return "nil"
+	}
+	s := v.Type + "("
+	if v.Basic != nil {
+		s += fmt.Sprint(v.Basic)
+	}
+	for i, e := range v.Elems {
+		if v.Keys != nil {
+			s += dumpValue(v.Keys[i]) + ":"
+		}
+		s += dumpValue(e) + " "
+	}
+	for _, f := range v.Fields {
+		s += f.Name + "=" + dumpValue(f.Value) + " "
+	}
+	if v.Name != "" {
+		s += v.Name + " " + dumpValue(v.Concrete)
+	}
+	return s + ")"
+}
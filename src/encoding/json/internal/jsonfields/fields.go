@@ -0,0 +1,26 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+// Package jsonfields shares the JSON object members of Go struct types,
+// as determined by "json", with "jsonschema".
+package jsonfields
+
+import "reflect"
+
+// Field is a JSON object member of a Go struct type.
+type Field struct {
+	Name      string
+	Type      reflect.Type
+	OmitZero  bool
+	OmitEmpty bool
+	String    bool
+	Format    string
+	Unknown   bool // whether the field holds unknown members
+}
+
+// StructFields returns the JSON object members of a Go struct type,
+// in the order they are marshaled. It is set by "json".
+var StructFields func(reflect.Type) ([]Field, error)
@@ -0,0 +1,588 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"encoding/json/internal/jsonfields"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+)
+
+// For returns the schema of the JSON representation of values of type T.
+// It is shorthand for ForType(reflect.TypeFor[T](), opts...).
+func For[T any](opts ...json.Options) (*Schema, error) {
+	return ForType(reflect.TypeFor[T](), opts...)
+}
+
+// ForType returns the schema of the JSON representation of values of
+// type t, as marshaled by [json.Marshal] with the given options.
+//
+// Named struct types are described in the "$defs" of the schema and
+// referred to by "$ref", so that recursive types are supported.
+// A struct field is required unless it has the omitzero or omitempty
+// option or [json.OmitZeroStructFields] is set, and members without a
+// corresponding field are only disallowed if [json.RejectUnknownMembers]
+// is set. Types with MarshalJSONTo or MarshalJSON methods, interface
+// types, and [jsontext.Value] allow any value, while types with
+// MarshalText or AppendText methods allow any string.
+// Marshalers provided by [json.WithMarshalers] are not considered.
+//
+// It reports an error for types that cannot be marshaled, such as
+// channels, functions, and complex numbers.
+func ForType(t reflect.Type, opts ...json.Options) (*Schema, error) {
+	g := &generator{opts: json.JoinOptions(opts...), defs: make(map[reflect.Type]*definition), root: t}
+	var s *genSchema
+	var err error
+	if t.Kind() == reflect.Struct && t.Name() != "" && !implementsAnyMarshaler(t) {
+		// Describe the root type directly, so that it can refer to itself as "#".
+		s, err = g.structSchema(t)
+	} else {
+		s, err = g.schemaFor(t, fieldOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.schema = "https://json-schema.org/draft/2020-12/schema"
+	s.defs = g.order
+
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	if err := s.encode(enc); err != nil {
+		return nil, err
+	}
+	return Compile(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+type generator struct {
+	opts  json.Options
+	root  reflect.Type
+	defs  map[reflect.Type]*definition
+	order []*definition // in the order they were referred to
+}
+
+type definition struct {
+	name   string
+	schema *genSchema
+}
+
+// fieldOptions are the options of a struct field that apply to its value.
+type fieldOptions struct {
+	string bool
+	format string
+}
+
+// A genSchema is a generated schema, encoded in a fixed order of keywords.
+type genSchema struct {
+	schema          string
+	ref             string
+	types           []string
+	format          string
+	contentEncoding string
+	pattern         string
+	enum            []string
+	minimum         string
+	maximum         string
+	items           *genSchema
+	minItems        int // -1 if absent
+	maxItems        int // -1 if absent
+	properties      []genProperty
+	required        []string
+	additional      *genSchema
+	noAdditional    bool
+	propertyNames   *genSchema
+	anyOf           []*genSchema
+	defs            []*definition
+}
+
+type genProperty struct {
+	name   string
+	schema *genSchema
+}
+
+func newSchema(types ...string) *genSchema {
+	return &genSchema{types: types, minItems: -1, maxItems: -1}
+}
+
+var (
+	jsontextValueType  = reflect.TypeFor[jsontext.Value]()
+	timeTimeType       = reflect.TypeFor[time.Time]()
+	timeDurationType   = reflect.TypeFor[time.Duration]()
+	marshalerToType    = reflect.TypeFor[json.MarshalerTo]()
+	marshalerType      = reflect.TypeFor[json.Marshaler]()
+	textAppenderType   = reflect.TypeFor[encoding.TextAppender]()
+	textMarshalerType  = reflect.TypeFor[encoding.TextMarshaler]()
+	errUnsupportedType = errors.New("unsupported type")
+)
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func (g *generator) schemaFor(t reflect.Type, fo fieldOptions) (*genSchema, error) {
+	stringify := fo.string
+	if v, ok := json.GetOption(g.opts, json.StringifyNumbers); ok && v {
+		stringify = true
+	}
+	invalidFormat := func() (*genSchema, error) {
+		return nil, fmt.Errorf("jsonschema: invalid format flag %q for type %v", fo.format, t)
+	}
+
+	switch t {
+	case jsontextValueType:
+		return newSchema(), nil
+	case timeTimeType:
+		switch fo.format {
+		case "", "RFC3339", "RFC3339Nano":
+			s := newSchema("string")
+			s.format = "date-time"
+			return s, nil
+		case "unix", "unixmilli", "unixmicro", "unixnano":
+			return newSchema("number"), nil
+		case "DateOnly":
+			s := newSchema("string")
+			s.format = "date"
+			return s, nil
+		}
+		return newSchema("string"), nil
+	case timeDurationType:
+		switch fo.format {
+		case "sec", "milli", "micro", "nano":
+			return newSchema("number"), nil
+		case "units":
+			return newSchema("string"), nil
+		case "iso8601":
+			s := newSchema("string")
+			s.format = "duration"
+			return s, nil
+		case "":
+			return nil, fmt.Errorf("jsonschema: no default representation for %v; specify an explicit format", t)
+		}
+		return invalidFormat()
+	}
+	switch {
+	case implements(t, marshalerToType), implements(t, marshalerType):
+		return newSchema(), nil
+	case implements(t, textAppenderType), implements(t, textMarshalerType):
+		return newSchema("string"), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return newSchema("boolean"), nil
+	case reflect.String:
+		return newSchema("string"), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		if stringify {
+			s := newSchema("string")
+			s.pattern = intPattern(bits)
+			return s, nil
+		}
+		s := newSchema("integer")
+		s.minimum = strconv.FormatInt(-1<<(bits-1), 10)
+		s.maximum = strconv.FormatInt(1<<(bits-1)-1, 10)
+		return s, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if stringify {
+			s := newSchema("string")
+			s.pattern = uintPattern(t.Bits())
+			return s, nil
+		}
+		s := newSchema("integer")
+		s.minimum = "0"
+		s.maximum = strconv.FormatUint(math.MaxUint64>>(64-t.Bits()), 10)
+		return s, nil
+	case reflect.Float32, reflect.Float64:
+		if stringify {
+			return newSchema("string"), nil
+		}
+		switch fo.format {
+		case "":
+			return newSchema("number"), nil
+		case "nonfinite":
+			nonfinite := newSchema("string")
+			nonfinite.enum = []string{"NaN", "Infinity", "-Infinity"}
+			s := newSchema()
+			s.anyOf = []*genSchema{newSchema("number"), nonfinite}
+			return s, nil
+		}
+		return invalidFormat()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && !implementsAnyMarshaler(t.Elem()) && fo.format != "array" {
+			s := newSchema("string")
+			switch fo.format {
+			case "", "base64":
+				s.contentEncoding = "base64"
+			case "base64url", "base32", "base32hex", "base16":
+				s.contentEncoding = fo.format
+			case "hex":
+				s.contentEncoding = "base16"
+			case "emitnull", "emitempty":
+				if t.Kind() == reflect.Array {
+					return invalidFormat()
+				}
+				s.contentEncoding = "base64"
+			default:
+				return invalidFormat()
+			}
+			return g.nullableSlice(t, fo, s), nil
+		}
+		if fo.format != "" && fo.format != "array" && !(t.Kind() == reflect.Slice && (fo.format == "emitnull" || fo.format == "emitempty")) {
+			return invalidFormat()
+		}
+		items, err := g.schemaFor(t.Elem(), fieldOptions{string: fo.string})
+		if err != nil {
+			return nil, err
+		}
+		s := newSchema("array")
+		s.items = items
+		if t.Kind() == reflect.Array {
+			s.minItems, s.maxItems = t.Len(), t.Len()
+		}
+		return g.nullableSlice(t, fo, s), nil
+	case reflect.Map:
+		if fo.format != "" && fo.format != "emitnull" && fo.format != "emitempty" {
+			return invalidFormat()
+		}
+		s := newSchema("object")
+		var err error
+		if s.additional, err = g.schemaFor(t.Elem(), fieldOptions{string: fo.string}); err != nil {
+			return nil, err
+		}
+		switch k := t.Key(); {
+		case implementsAnyMarshaler(k) || k.Kind() == reflect.String:
+		case k.Kind() >= reflect.Int && k.Kind() <= reflect.Int64:
+			s.propertyNames = newSchema()
+			s.propertyNames.pattern = intPattern(k.Bits())
+		case k.Kind() >= reflect.Uint && k.Kind() <= reflect.Uintptr:
+			s.propertyNames = newSchema()
+			s.propertyNames.pattern = uintPattern(k.Bits())
+		case k.Kind() == reflect.Float32 || k.Kind() == reflect.Float64:
+		case k.Kind() == reflect.Bool:
+			s.propertyNames = newSchema()
+			s.propertyNames.enum = []string{"true", "false"}
+		default:
+			return nil, fmt.Errorf("jsonschema: %w %v", errUnsupportedType, t)
+		}
+		nullable := fo.format == "emitnull"
+		if v, ok := json.GetOption(g.opts, json.FormatNilMapAsNull); ok && v && fo.format != "emitempty" {
+			nullable = true
+		}
+		if nullable {
+			return makeNullable(s), nil
+		}
+		return s, nil
+	case reflect.Pointer:
+		s, err := g.schemaFor(t.Elem(), fo)
+		if err != nil {
+			return nil, err
+		}
+		return makeNullable(s), nil
+	case reflect.Interface:
+		return newSchema(), nil
+	case reflect.Struct:
+		if fo.format != "" {
+			return invalidFormat()
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.definitionFor(t)
+	}
+	return nil, fmt.Errorf("jsonschema: %w %v", errUnsupportedType, t)
+}
+
+func implementsAnyMarshaler(t reflect.Type) bool {
+	return implements(t, marshalerToType) || implements(t, marshalerType) ||
+		implements(t, textAppenderType) || implements(t, textMarshalerType)
+}
+
+// nullableSlice makes the schema s of a slice type nullable
+// if nil slices are marshaled as null.
+func (g *generator) nullableSlice(t reflect.Type, fo fieldOptions, s *genSchema) *genSchema {
+	if t.Kind() != reflect.Slice {
+		return s
+	}
+	nullable := fo.format == "emitnull"
+	if v, ok := json.GetOption(g.opts, json.FormatNilSliceAsNull); ok && v && fo.format != "emitempty" {
+		nullable = true
+	}
+	if nullable {
+		return makeNullable(s)
+	}
+	return s
+}
+
+// makeNullable returns a schema allowing null in addition to s.
+func makeNullable(s *genSchema) *genSchema {
+	switch {
+	case s.ref == "" && s.anyOf == nil && s.enum == nil && len(s.types) > 0:
+		if s.types[len(s.types)-1] != "null" {
+			s.types = append(s.types, "null")
+		}
+		return s
+	case s.ref == "" && s.anyOf != nil:
+		s.anyOf = append(s.anyOf, newSchema("null"))
+		return s
+	case s.types == nil && s.ref == "":
+		return s // already allows any value
+	}
+	n := newSchema()
+	n.anyOf = []*genSchema{s, newSchema("null")}
+	return n
+}
+
+// definitionFor returns a reference to the definition of the named struct
+// type t, adding the definition if needed.
+func (g *generator) definitionFor(t reflect.Type) (*genSchema, error) {
+	ref := newSchema()
+	if t == g.root {
+		ref.ref = "#"
+		return ref, nil
+	}
+	d, ok := g.defs[t]
+	if !ok {
+		d = &definition{name: g.definitionName(t)}
+		g.defs[t] = d
+		g.order = append(g.order, d)
+		var err error
+		if d.schema, err = g.structSchema(t); err != nil {
+			return nil, err
+		}
+	}
+	ref.ref = "#/$defs/" + d.name
+	return ref, nil
+}
+
+// definitionName returns a unique name for the definition of t,
+// restricted to characters that need no escaping in a reference.
+func (g *generator) definitionName(t reflect.Type) string {
+	base := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, t.Name())
+	name := base
+	for i := 2; ; i++ {
+		taken := false
+		for _, d := range g.order {
+			taken = taken || d.name == name
+		}
+		if !taken {
+			return name
+		}
+		name = base + strconv.Itoa(i)
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) (*genSchema, error) {
+	fields, err := jsonfields.StructFields(t)
+	if err != nil {
+		return nil, err
+	}
+	omitAll, _ := json.GetOption(g.opts, json.OmitZeroStructFields)
+	s := newSchema("object")
+	unknown := false
+	for _, f := range fields {
+		if f.Unknown {
+			unknown = true
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Map {
+				if s.additional, err = g.schemaFor(ft.Elem(), fieldOptions{}); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		fs, err := g.schemaFor(f.Type, fieldOptions{string: f.String, format: f.Format})
+		if err != nil {
+			return nil, err
+		}
+		s.properties = append(s.properties, genProperty{f.Name, fs})
+		if !f.OmitZero && !f.OmitEmpty && !omitAll {
+			s.required = append(s.required, f.Name)
+		}
+	}
+	if reject, _ := json.GetOption(g.opts, json.RejectUnknownMembers); reject && !unknown {
+		s.noAdditional = true
+	}
+	return s, nil
+}
+
+// encode writes the schema to enc.
+func (s *genSchema) encode(enc *jsontext.Encoder) error {
+	var err error
+	write := func(tok jsontext.Token) {
+		if err == nil {
+			err = enc.WriteToken(tok)
+		}
+	}
+	writeString := func(name, v string) {
+		if v != "" {
+			write(jsontext.String(name))
+			write(jsontext.String(v))
+		}
+	}
+	writeNumber := func(name, v string) {
+		if v != "" && err == nil {
+			write(jsontext.String(name))
+			if err == nil {
+				err = enc.WriteValue(jsontext.Value(v))
+			}
+		}
+	}
+	writeSchema := func(name string, v *genSchema) {
+		if v != nil && err == nil {
+			write(jsontext.String(name))
+			if err == nil {
+				err = v.encode(enc)
+			}
+		}
+	}
+	writeStrings := func(name string, v []string) {
+		if v == nil {
+			return
+		}
+		write(jsontext.String(name))
+		write(jsontext.BeginArray)
+		for _, v := range v {
+			write(jsontext.String(v))
+		}
+		write(jsontext.EndArray)
+	}
+
+	write(jsontext.BeginObject)
+	writeString("$schema", s.schema)
+	writeString("$ref", s.ref)
+	switch len(s.types) {
+	case 0:
+	case 1:
+		writeString("type", s.types[0])
+	default:
+		writeStrings("type", s.types)
+	}
+	writeString("format", s.format)
+	writeString("contentEncoding", s.contentEncoding)
+	writeString("pattern", s.pattern)
+	writeStrings("enum", s.enum)
+	writeNumber("minimum", s.minimum)
+	writeNumber("maximum", s.maximum)
+	writeSchema("items", s.items)
+	if s.minItems >= 0 {
+		writeNumber("minItems", strconv.Itoa(s.minItems))
+	}
+	if s.maxItems >= 0 {
+		writeNumber("maxItems", strconv.Itoa(s.maxItems))
+	}
+	if s.properties != nil {
+		write(jsontext.String("properties"))
+		write(jsontext.BeginObject)
+		for _, p := range s.properties {
+			writeSchema(p.name, p.schema)
+		}
+		write(jsontext.EndObject)
+	}
+	writeStrings("required", s.required)
+	if s.noAdditional {
+		write(jsontext.String("additionalProperties"))
+		write(jsontext.False)
+	}
+	writeSchema("additionalProperties", s.additional)
+	writeSchema("propertyNames", s.propertyNames)
+	if s.anyOf != nil {
+		write(jsontext.String("anyOf"))
+		write(jsontext.BeginArray)
+		for _, v := range s.anyOf {
+			if err == nil {
+				err = v.encode(enc)
+			}
+		}
+		write(jsontext.EndArray)
+	}
+	if s.defs != nil {
+		write(jsontext.String("$defs"))
+		write(jsontext.BeginObject)
+		for _, d := range s.defs {
+			writeSchema(d.name, d.schema)
+		}
+		write(jsontext.EndObject)
+	}
+	write(jsontext.EndObject)
+	return err
+}
+
+// intPattern returns a regular expression matching the decimal
+// representations of the signed integers of the given size,
+// including "-0", which they are decoded from.
+func intPattern(bits int) string {
+	max := strconv.FormatInt(1<<(bits-1)-1, 10)
+	minAbs := strconv.FormatUint(1<<(bits-1), 10)
+	return "^(" + strings.Join(decimalRange(max), "|") + "|-(" + strings.Join(decimalRange(minAbs), "|") + "))$"
+}
+
+// uintPattern returns a regular expression matching the decimal
+// representations of the unsigned integers of the given size.
+func uintPattern(bits int) string {
+	max := strconv.FormatUint(math.MaxUint64>>(64-bits), 10)
+	return "^(" + strings.Join(decimalRange(max), "|") + ")$"
+}
+
+// decimalRange returns the alternatives of a regular expression matching
+// the decimal representations, without leading zeros, of the integers
+// from 0 to max.
+func decimalRange(max string) []string {
+	alts := []string{"0"}
+	// Numbers with fewer digits than max.
+	if len(max) > 1 {
+		alts = append(alts, "[1-9]"+anyDigits(0, len(max)-2))
+	}
+	// Numbers with as many digits as max, which are less than it from
+	// their (i+1)th digit on.
+	for i := range len(max) {
+		low := byte('0')
+		if i == 0 {
+			low = '1'
+		}
+		if max[i] > low {
+			class := string(low)
+			if high := max[i] - 1; high > low {
+				class = "[" + string(low) + "-" + string(high) + "]"
+			}
+			alts = append(alts, max[:i]+class+anyDigits(len(max)-i-1, len(max)-i-1))
+		}
+	}
+	return append(alts, max)
+}
+
+// anyDigits returns a regular expression matching from min to max digits.
+func anyDigits(min, max int) string {
+	switch {
+	case max == 0:
+		return ""
+	case min == max && max == 1:
+		return "[0-9]"
+	case min == 0 && max == 1:
+		return "[0-9]?"
+	case min == max:
+		return "[0-9]{" + strconv.Itoa(max) + "}"
+	}
+	return "[0-9]{" + strconv.Itoa(min) + "," + strconv.Itoa(max) + "}"
+}
@@ -0,0 +1,222 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsonschema
+
+import (
+	"math"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+)
+
+type Address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitzero"`
+}
+
+type Meta struct {
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires,format:unix"`
+}
+
+type Person struct {
+	Meta
+	Name     string            `json:"name"`
+	Age      uint8             `json:"age,omitempty"`
+	ID       int64             `json:"id,string"`
+	Score    float64           `json:"score,format:nonfinite"`
+	Home     *Address          `json:"home"`
+	Work     Address           `json:"work"`
+	Tags     []string          `json:"tags,omitzero"`
+	Data     []byte            `json:"data,format:hex"`
+	Coords   [2]float32        `json:"coords"`
+	Counts   map[int]uint16    `json:"counts"`
+	Addr     netip.Addr        `json:"addr"`
+	Any      any               `json:"any"`
+	Friends  []*Person         `json:"friends"`
+	Ignored  string            `json:"-"`
+	Unknown  map[string]string `json:",unknown"`
+	internal int
+}
+
+// Patterns of the stringified integers of kind int64 and uint8.
+const (
+	int64Pattern = `^(0|[1-9][0-9]{0,17}|[1-8][0-9]{18}|9[0-1][0-9]{17}|92[0-1][0-9]{16}|922[0-2][0-9]{15}|9223[0-2][0-9]{14}|92233[0-6][0-9]{13}|922337[0-1][0-9]{12}|92233720[0-2][0-9]{10}|922337203[0-5][0-9]{9}|9223372036[0-7][0-9]{8}|92233720368[0-4][0-9]{7}|922337203685[0-3][0-9]{6}|9223372036854[0-6][0-9]{5}|92233720368547[0-6][0-9]{4}|922337203685477[0-4][0-9]{3}|9223372036854775[0-7][0-9]{2}|922337203685477580[0-6]|9223372036854775807` +
+		`|-(0|[1-9][0-9]{0,17}|[1-8][0-9]{18}|9[0-1][0-9]{17}|92[0-1][0-9]{16}|922[0-2][0-9]{15}|9223[0-2][0-9]{14}|92233[0-6][0-9]{13}|922337[0-1][0-9]{12}|92233720[0-2][0-9]{10}|922337203[0-5][0-9]{9}|9223372036[0-7][0-9]{8}|92233720368[0-4][0-9]{7}|922337203685[0-3][0-9]{6}|9223372036854[0-6][0-9]{5}|92233720368547[0-6][0-9]{4}|922337203685477[0-4][0-9]{3}|9223372036854775[0-7][0-9]{2}|922337203685477580[0-7]|9223372036854775808))$`
+	uint8Pattern = `^(0|[1-9][0-9]?|1[0-9]{2}|2[0-4][0-9]|25[0-4]|255)$`
+)
+
+func TestForType(t *testing.T) {
+	s, err := For[Person]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"created":{"type":"string","format":"date-time"},` +
+		`"expires":{"type":"number"},` +
+		`"name":{"type":"string"},` +
+		`"age":{"type":"integer","minimum":0,"maximum":255},` +
+		`"id":{"type":"string","pattern":"` + int64Pattern + `"},` +
+		`"score":{"anyOf":[{"type":"number"},{"type":"string","enum":["NaN","Infinity","-Infinity"]}]},` +
+		`"home":{"anyOf":[{"$ref":"#/$defs/Address"},{"type":"null"}]},` +
+		`"work":{"$ref":"#/$defs/Address"},` +
+		`"tags":{"type":"array","items":{"type":"string"}},` +
+		`"data":{"type":"string","contentEncoding":"base16"},` +
+		`"coords":{"type":"array","items":{"type":"number"},"minItems":2,"maxItems":2},` +
+		`"counts":{"type":"object","additionalProperties":{"type":"integer","minimum":0,"maximum":65535},"propertyNames":{"pattern":"` + int64Pattern + `"}},` +
+		`"addr":{"type":"string"},` +
+		`"any":{},` +
+		`"friends":{"type":"array","items":{"anyOf":[{"$ref":"#"},{"type":"null"}]}}},` +
+		`"required":["created","expires","name","id","score","home","work","data","coords","counts","addr","any","friends"],` +
+		`"additionalProperties":{"type":"string"},` +
+		`"$defs":{"Address":{"type":"object","properties":{"street":{"type":"string"},"zip":{"type":"string"}},"required":["street"]}}}`
+	if string(got) != want {
+		t.Errorf("For[Person]:\ngot  %s\nwant %s", got, want)
+	}
+
+	p := Person{
+		Name:    "Ada",
+		ID:      -7,
+		Score:   math.Inf(1),
+		Work:    Address{Street: "Main"},
+		Counts:  map[int]uint16{1: 2},
+		Friends: []*Person{{Name: "Bob", Score: 1}, nil},
+		Unknown: map[string]string{"extra": "x"},
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(b); err != nil {
+		t.Errorf("Validate(%s): %v", b, err)
+	}
+	for _, bad := range []string{
+		`{"name":1}`,
+		strings.Replace(string(b), `"id":"-7"`, `"id":-7`, 1),
+		strings.Replace(string(b), `"extra":"x"`, `"extra":1`, 1),
+		strings.Replace(string(b), `"street":"Main"`, `"street":null`, 1),
+	} {
+		if err := s.Validate(jsontext.Value(bad)); err == nil {
+			t.Errorf("Validate(%s) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestForTypeOptions(t *testing.T) {
+	type T struct {
+		N     int   `json:"n"`
+		S     []int `json:"s"`
+		M     map[string]bool
+		Bytes [4]byte  `json:"b,format:array"`
+		Null  []string `json:"null,format:emitnull"`
+	}
+	tests := []struct {
+		opts []json.Options
+		want string
+	}{{
+		want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+			`"n":{"type":"integer","minimum":-9223372036854775808,"maximum":9223372036854775807},` +
+			`"s":{"type":"array","items":{"type":"integer","minimum":-9223372036854775808,"maximum":9223372036854775807}},` +
+			`"M":{"type":"object","additionalProperties":{"type":"boolean"}},` +
+			`"b":{"type":"array","items":{"type":"integer","minimum":0,"maximum":255},"minItems":4,"maxItems":4},` +
+			`"null":{"type":["array","null"],"items":{"type":"string"}}},` +
+			`"required":["n","s","M","b","null"]}`,
+	}, {
+		opts: []json.Options{
+			json.StringifyNumbers(true),
+			json.FormatNilSliceAsNull(true),
+			json.FormatNilMapAsNull(true),
+			json.OmitZeroStructFields(true),
+			json.RejectUnknownMembers(true),
+		},
+		want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+			`"n":{"type":"string","pattern":"` + int64Pattern + `"},` +
+			`"s":{"type":["array","null"],"items":{"type":"string","pattern":"` + int64Pattern + `"}},` +
+			`"M":{"type":["object","null"],"additionalProperties":{"type":"boolean"}},` +
+			`"b":{"type":"array","items":{"type":"string","pattern":"` + uint8Pattern + `"},"minItems":4,"maxItems":4},` +
+			`"null":{"type":["array","null"],"items":{"type":"string"}}},` +
+			`"additionalProperties":false}`,
+	}}
+	for _, tt := range tests {
+		s, err := ForType(reflect.TypeFor[T](), tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := json.Marshal(s)
+		if string(got) != tt.want {
+			t.Errorf("ForType(%v):\ngot  %s\nwant %s", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestForTypeStringifiedRange(t *testing.T) {
+	type T struct {
+		I8  int8   `json:",string"`
+		I64 int64  `json:",string"`
+		U64 uint64 `json:",string"`
+	}
+	s, err := For[T]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{`{"I8":"127","I64":"0","U64":"0"}`, true},
+		{`{"I8":"-128","I64":"0","U64":"0"}`, true},
+		{`{"I8":"128","I64":"0","U64":"0"}`, false},
+		{`{"I8":"300","I64":"0","U64":"0"}`, false},
+		{`{"I8":"-129","I64":"0","U64":"0"}`, false},
+		{`{"I8":"-0","I64":"0","U64":"0"}`, true},
+		{`{"I8":"0","I64":"0","U64":"-0"}`, false},
+		{`{"I8":"0","I64":"9223372036854775807","U64":"18446744073709551615"}`, true},
+		{`{"I8":"0","I64":"-9223372036854775808","U64":"0"}`, true},
+		{`{"I8":"0","I64":"9223372036854775808","U64":"0"}`, false},
+		{`{"I8":"0","I64":"-9223372036854775809","U64":"0"}`, false},
+		{`{"I8":"0","I64":"0","U64":"18446744073709551616"}`, false},
+		{`{"I8":"0","I64":"0","U64":"99999999999999999999"}`, false},
+	}
+	for _, tt := range tests {
+		if err := s.Validate(jsontext.Value(tt.value)); (err == nil) != tt.ok {
+			t.Errorf("Validate(%s) = %v, want ok=%v", tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestForTypeErrors(t *testing.T) {
+	type badFormat struct {
+		F float64 `json:"f,format:bogus"`
+	}
+	type duration struct {
+		D time.Duration
+	}
+	for _, typ := range []reflect.Type{
+		reflect.TypeFor[chan int](),
+		reflect.TypeFor[map[complex64]int](),
+		reflect.TypeFor[badFormat](),
+		reflect.TypeFor[duration](),
+	} {
+		if _, err := ForType(typ); err == nil {
+			t.Errorf("ForType(%v) succeeded, want error", typ)
+		}
+	}
+	if s, err := For[[]int](); err != nil {
+		t.Errorf("For[[]int]: %v", err)
+	} else if err := s.Validate(jsontext.Value(`[1,2]`)); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
@@ -0,0 +1,775 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+// Package jsonschema derives JSON Schema documents from Go types and
+// validates JSON values against them.
+//
+// The schemas follow the JSON Schema 2020-12 specification
+// (https://json-schema.org/draft/2020-12). [For] and [ForType] derive a
+// schema from a Go type using the same rules as [json.Marshal]: the JSON
+// object member names, the omitzero, omitempty, inline, unknown, string,
+// and format options of struct fields, and the options that change the
+// representation of values, such as [json.StringifyNumbers] and
+// [json.FormatNilSliceAsNull]. [Compile] accepts any schema document.
+//
+// [Schema.Validate] reports every violation as a [ValidationError] that
+// locates both the offending value and the failed keyword with a
+// [jsontext.Pointer].
+//
+// Schemas may only refer to subschemas of the same document, with a
+// "$ref" of "#" followed by a JSON Pointer, such as "#/$defs/Name".
+// The "format" keyword is treated as an annotation and not validated,
+// as the specification recommends by default, and regular expressions
+// use the syntax of the [regexp] package rather than ECMA 262.
+package jsonschema
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+)
+
+// A Schema is a compiled JSON Schema document.
+// It is safe for concurrent use by multiple goroutines.
+type Schema struct {
+	source jsontext.Value
+	root   *node
+}
+
+// Compile compiles the JSON Schema document in schema.
+func Compile(schema jsontext.Value) (*Schema, error) {
+	c := &compiler{root: schema, nodes: make(map[jsontext.Pointer]*node)}
+	root, err := c.compile("")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{source: schema.Clone(), root: root}, nil
+}
+
+// MarshalJSONTo writes the schema document to enc.
+func (s *Schema) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return enc.WriteValue(s.source)
+}
+
+// UnmarshalJSONFrom reads a schema document from dec and compiles it.
+func (s *Schema) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	v, err := dec.ReadValue()
+	if err != nil {
+		return err
+	}
+	s2, err := Compile(v)
+	if err != nil {
+		return err
+	}
+	*s = *s2
+	return nil
+}
+
+// A ValidationError reports that a JSON value does not satisfy a keyword
+// of a schema.
+type ValidationError struct {
+	// InstancePointer locates the offending value within the validated value.
+	InstancePointer jsontext.Pointer
+	// KeywordPointer locates the failed keyword within the schema document.
+	KeywordPointer jsontext.Pointer
+	// Message describes the violation.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("jsonschema: value at %q does not satisfy %q: %s", e.InstancePointer, e.KeywordPointer, e.Message)
+}
+
+// Validate reports whether v satisfies the schema. If v is not valid JSON,
+// it returns the syntactic error. Otherwise, it returns nil or the
+// [ValidationError] for each violated keyword, joined with [errors.Join].
+func (s *Schema) Validate(v jsontext.Value) error {
+	in, err := parseInstance(v)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	s.root.validate(in, "", &errs, 0)
+	return errors.Join(errs...)
+}
+
+// A node is a compiled schema or subschema.
+type node struct {
+	ptr jsontext.Pointer // location in the schema document
+
+	// always is set for the boolean schemas true and false,
+	// in which case no other fields are used.
+	always *bool
+
+	ref                    *node
+	types                  []string
+	enum                   []*instance
+	constant               *instance
+	minimum, maximum       *instance
+	exclusiveMinimum       *instance
+	exclusiveMaximum       *instance
+	multipleOf             *float64
+	minLength, maxLength   int // -1 if absent
+	pattern                *regexp.Regexp
+	minItems, maxItems     int // -1 if absent
+	uniqueItems            bool
+	prefixItems            []*node
+	items                  *node
+	contains               *node
+	minContains            int
+	maxContains            int // -1 if absent
+	minProperties          int // -1 if absent
+	maxProperties          int // -1 if absent
+	required               []string
+	dependentRequired      map[string][]string
+	properties             map[string]*node
+	patternProperties      []patternProperty
+	additionalProperties   *node
+	propertyNames          *node
+	allOf, anyOf, oneOf    []*node
+	not, if_, then_, else_ *node
+}
+
+type patternProperty struct {
+	re     *regexp.Regexp
+	schema *node
+}
+
+type compiler struct {
+	root  jsontext.Value
+	nodes map[jsontext.Pointer]*node // compiled subschemas by location
+}
+
+func schemaError(ptr jsontext.Pointer, format string, args ...any) error {
+	return fmt.Errorf("jsonschema: invalid schema at %q: %s", ptr, fmt.Sprintf(format, args...))
+}
+
+// compile compiles the subschema at ptr, reusing any previous compilation
+// so that recursive references terminate.
+func (c *compiler) compile(ptr jsontext.Pointer) (*node, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	v, err := c.root.Lookup(ptr)
+	if err != nil {
+		return nil, schemaError(ptr, "%v", err)
+	}
+	n := &node{ptr: ptr, minLength: -1, maxLength: -1, minItems: -1, maxItems: -1,
+		minContains: 1, maxContains: -1, minProperties: -1, maxProperties: -1}
+	c.nodes[ptr] = n
+
+	switch v.Kind() {
+	case 't', 'f':
+		b := v.Kind() == 't'
+		n.always = &b
+		return n, nil
+	case '{':
+	default:
+		return nil, schemaError(ptr, "schema must be an object or a boolean")
+	}
+	var kw map[string]jsontext.Value
+	if err := json.Unmarshal(v, &kw); err != nil {
+		return nil, schemaError(ptr, "%v", err)
+	}
+	sub := func(key string) (*node, error) {
+		if _, ok := kw[key]; !ok {
+			return nil, nil
+		}
+		return c.compile(ptr.AppendToken(key))
+	}
+	subs := func(key string) ([]*node, error) {
+		raw, ok := kw[key]
+		if !ok {
+			return nil, nil
+		}
+		var elems []jsontext.Value
+		if err := json.Unmarshal(raw, &elems); err != nil || len(elems) == 0 {
+			return nil, schemaError(ptr.AppendToken(key), "must be a non-empty array of schemas")
+		}
+		ns := make([]*node, len(elems))
+		for i := range elems {
+			if ns[i], err = c.compile(ptr.AppendToken(key).AppendToken(strconv.Itoa(i))); err != nil {
+				return nil, err
+			}
+		}
+		return ns, nil
+	}
+	subMap := func(key string) (map[string]*node, error) {
+		raw, ok := kw[key]
+		if !ok {
+			return nil, nil
+		}
+		var m map[string]jsontext.Value
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, schemaError(ptr.AppendToken(key), "must be an object of schemas")
+		}
+		ns := make(map[string]*node, len(m))
+		for name := range m {
+			if ns[name], err = c.compile(ptr.AppendToken(key).AppendToken(name)); err != nil {
+				return nil, err
+			}
+		}
+		return ns, nil
+	}
+	number := func(key string) (*float64, error) {
+		raw, ok := kw[key]
+		if !ok {
+			return nil, nil
+		}
+		var f float64
+		if raw.Kind() != '0' || json.Unmarshal(raw, &f) != nil {
+			return nil, schemaError(ptr.AppendToken(key), "must be a number")
+		}
+		return &f, nil
+	}
+	count := func(key string, dst *int) error {
+		raw, ok := kw[key]
+		if !ok {
+			return nil
+		}
+		var f float64
+		if raw.Kind() != '0' || json.Unmarshal(raw, &f) != nil || f < 0 || f != math.Trunc(f) || f > math.MaxInt32 {
+			return schemaError(ptr.AppendToken(key), "must be a non-negative integer")
+		}
+		*dst = int(f)
+		return nil
+	}
+	regexpOf := func(key, s string) (*regexp.Regexp, error) {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, schemaError(ptr.AppendToken(key), "%v", err)
+		}
+		return re, nil
+	}
+
+	if raw, ok := kw["$ref"]; ok {
+		var ref string
+		if err := json.Unmarshal(raw, &ref); err != nil || !strings.HasPrefix(ref, "#") {
+			return nil, schemaError(ptr.AppendToken("$ref"), "only references to the same document are supported")
+		}
+		target := jsontext.Pointer(ref[1:])
+		if !target.IsValid() {
+			return nil, schemaError(ptr.AppendToken("$ref"), "invalid JSON Pointer %q", ref[1:])
+		}
+		if n.ref, err = c.compile(target); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := subMap("$defs"); err != nil {
+		return nil, err
+	}
+	if raw, ok := kw["type"]; ok {
+		switch raw.Kind() {
+		case '"':
+			var t string
+			json.Unmarshal(raw, &t)
+			n.types = []string{t}
+		case '[':
+			if err := json.Unmarshal(raw, &n.types); err != nil {
+				return nil, schemaError(ptr.AppendToken("type"), "must be a string or an array of strings")
+			}
+		default:
+			return nil, schemaError(ptr.AppendToken("type"), "must be a string or an array of strings")
+		}
+		for _, t := range n.types {
+			if !slices.Contains(typeNames, t) {
+				return nil, schemaError(ptr.AppendToken("type"), "unknown type %q", t)
+			}
+		}
+	}
+	if raw, ok := kw["enum"]; ok {
+		var elems []jsontext.Value
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, schemaError(ptr.AppendToken("enum"), "must be an array")
+		}
+		for _, e := range elems {
+			in, err := parseInstance(e)
+			if err != nil {
+				return nil, schemaError(ptr.AppendToken("enum"), "%v", err)
+			}
+			n.enum = append(n.enum, in)
+		}
+	}
+	if raw, ok := kw["const"]; ok {
+		if n.constant, err = parseInstance(raw); err != nil {
+			return nil, schemaError(ptr.AppendToken("const"), "%v", err)
+		}
+	}
+	for key, dst := range map[string]**instance{
+		"minimum":          &n.minimum,
+		"maximum":          &n.maximum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+	} {
+		if _, err := number(key); err != nil {
+			return nil, err
+		}
+		if raw, ok := kw[key]; ok {
+			if *dst, err = parseInstance(raw); err != nil {
+				return nil, schemaError(ptr.AppendToken(key), "%v", err)
+			}
+		}
+	}
+	if n.multipleOf, err = number("multipleOf"); err != nil {
+		return nil, err
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return nil, schemaError(ptr.AppendToken("multipleOf"), "must be greater than 0")
+	}
+	for key, dst := range map[string]*int{
+		"minLength":     &n.minLength,
+		"maxLength":     &n.maxLength,
+		"minItems":      &n.minItems,
+		"maxItems":      &n.maxItems,
+		"minContains":   &n.minContains,
+		"maxContains":   &n.maxContains,
+		"minProperties": &n.minProperties,
+		"maxProperties": &n.maxProperties,
+	} {
+		if err := count(key, dst); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := kw["pattern"]; ok {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, schemaError(ptr.AppendToken("pattern"), "must be a string")
+		}
+		if n.pattern, err = regexpOf("pattern", s); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := kw["uniqueItems"]; ok {
+		if err := json.Unmarshal(raw, &n.uniqueItems); err != nil {
+			return nil, schemaError(ptr.AppendToken("uniqueItems"), "must be a boolean")
+		}
+	}
+	if raw, ok := kw["required"]; ok {
+		if err := json.Unmarshal(raw, &n.required); err != nil {
+			return nil, schemaError(ptr.AppendToken("required"), "must be an array of strings")
+		}
+	}
+	if raw, ok := kw["dependentRequired"]; ok {
+		if err := json.Unmarshal(raw, &n.dependentRequired); err != nil {
+			return nil, schemaError(ptr.AppendToken("dependentRequired"), "must be an object of arrays of strings")
+		}
+	}
+	if n.prefixItems, err = subs("prefixItems"); err != nil {
+		return nil, err
+	}
+	if n.allOf, err = subs("allOf"); err != nil {
+		return nil, err
+	}
+	if n.anyOf, err = subs("anyOf"); err != nil {
+		return nil, err
+	}
+	if n.oneOf, err = subs("oneOf"); err != nil {
+		return nil, err
+	}
+	if n.properties, err = subMap("properties"); err != nil {
+		return nil, err
+	}
+	patterns, err := subMap("patternProperties")
+	if err != nil {
+		return nil, err
+	}
+	for src, schema := range patterns {
+		re, err := regexpOf("patternProperties", src)
+		if err != nil {
+			return nil, err
+		}
+		n.patternProperties = append(n.patternProperties, patternProperty{re, schema})
+	}
+	for key, dst := range map[string]**node{
+		"items":                &n.items,
+		"contains":             &n.contains,
+		"additionalProperties": &n.additionalProperties,
+		"propertyNames":        &n.propertyNames,
+		"not":                  &n.not,
+		"if":                   &n.if_,
+		"then":                 &n.then_,
+		"else":                 &n.else_,
+	} {
+		if *dst, err = sub(key); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+var typeNames = []string{"null", "boolean", "object", "array", "number", "string", "integer"}
+
+// maxDepth limits the nesting of subschemas applied during validation,
+// which is otherwise unbounded for a reference to an enclosing schema
+// that does not descend into the value.
+const maxDepth = 1000
+
+// validate appends to errs the errors for the keywords of n that
+// the value in at the given location does not satisfy.
+func (n *node) validate(in *instance, at jsontext.Pointer, errs *[]error, depth int) {
+	fail := func(keyword, format string, args ...any) {
+		*errs = append(*errs, &ValidationError{
+			InstancePointer: at,
+			KeywordPointer:  n.ptr.AppendToken(keyword),
+			Message:         fmt.Sprintf(format, args...),
+		})
+	}
+	if n.always != nil {
+		if !*n.always {
+			*errs = append(*errs, &ValidationError{at, n.ptr, "false schema allows no value"})
+		}
+		return
+	}
+	if depth >= maxDepth {
+		fail("$ref", "schema nested too deeply")
+		return
+	}
+	// valid reports whether in satisfies the subschema s.
+	valid := func(s *node, in *instance, at jsontext.Pointer) bool {
+		var errs []error
+		s.validate(in, at, &errs, depth+1)
+		return len(errs) == 0
+	}
+
+	if n.ref != nil {
+		n.ref.validate(in, at, errs, depth+1)
+	}
+	if n.types != nil && !slices.ContainsFunc(n.types, in.hasType) {
+		fail("type", "%s is not of type %s", in.typeName(), strings.Join(n.types, " or "))
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, in.equal) {
+		fail("enum", "value is not one of the enumerated values")
+	}
+	if n.constant != nil && !in.equal(n.constant) {
+		fail("const", "value is not the constant value")
+	}
+
+	switch in.kind {
+	case '0':
+		if n.minimum != nil && in.compare(n.minimum) < 0 {
+			fail("minimum", "%s is less than %s", in.str, n.minimum.str)
+		}
+		if n.maximum != nil && in.compare(n.maximum) > 0 {
+			fail("maximum", "%s is greater than %s", in.str, n.maximum.str)
+		}
+		if n.exclusiveMinimum != nil && in.compare(n.exclusiveMinimum) <= 0 {
+			fail("exclusiveMinimum", "%s is not greater than %s", in.str, n.exclusiveMinimum.str)
+		}
+		if n.exclusiveMaximum != nil && in.compare(n.exclusiveMaximum) >= 0 {
+			fail("exclusiveMaximum", "%s is not less than %s", in.str, n.exclusiveMaximum.str)
+		}
+		if n.multipleOf != nil {
+			q := in.num / *n.multipleOf
+			if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("multipleOf", "%v is not a multiple of %v", in.num, *n.multipleOf)
+			}
+		}
+	case '"':
+		if n.minLength >= 0 || n.maxLength >= 0 {
+			l := utf8.RuneCountInString(in.str)
+			if n.minLength >= 0 && l < n.minLength {
+				fail("minLength", "length %d is less than %d", l, n.minLength)
+			}
+			if n.maxLength >= 0 && l > n.maxLength {
+				fail("maxLength", "length %d is greater than %d", l, n.maxLength)
+			}
+		}
+		if n.pattern != nil && !n.pattern.MatchString(in.str) {
+			fail("pattern", "%q does not match pattern %q", in.str, n.pattern)
+		}
+	case '[':
+		if n.minItems >= 0 && len(in.elems) < n.minItems {
+			fail("minItems", "%d items is fewer than %d", len(in.elems), n.minItems)
+		}
+		if n.maxItems >= 0 && len(in.elems) > n.maxItems {
+			fail("maxItems", "%d items is more than %d", len(in.elems), n.maxItems)
+		}
+		if n.uniqueItems {
+		unique:
+			for i, a := range in.elems {
+				for j, b := range in.elems[:i] {
+					if a.equal(b) {
+						fail("uniqueItems", "items %d and %d are equal", j, i)
+						break unique
+					}
+				}
+			}
+		}
+		for i, e := range in.elems {
+			at := at.AppendToken(strconv.Itoa(i))
+			if i < len(n.prefixItems) {
+				n.prefixItems[i].validate(e, at, errs, depth+1)
+			} else if n.items != nil {
+				n.items.validate(e, at, errs, depth+1)
+			}
+		}
+		if n.contains != nil {
+			matches := 0
+			for i, e := range in.elems {
+				if valid(n.contains, e, at.AppendToken(strconv.Itoa(i))) {
+					matches++
+				}
+			}
+			if matches < n.minContains {
+				fail("contains", "%d items match, fewer than %d", matches, n.minContains)
+			}
+			if n.maxContains >= 0 && matches > n.maxContains {
+				fail("maxContains", "%d items match, more than %d", matches, n.maxContains)
+			}
+		}
+	case '{':
+		if n.minProperties >= 0 && len(in.members) < n.minProperties {
+			fail("minProperties", "%d members is fewer than %d", len(in.members), n.minProperties)
+		}
+		if n.maxProperties >= 0 && len(in.members) > n.maxProperties {
+			fail("maxProperties", "%d members is more than %d", len(in.members), n.maxProperties)
+		}
+		for _, name := range n.required {
+			if in.member(name) == nil {
+				fail("required", "missing required member %q", name)
+			}
+		}
+		for name, deps := range n.dependentRequired {
+			if in.member(name) == nil {
+				continue
+			}
+			for _, dep := range deps {
+				if in.member(dep) == nil {
+					fail("dependentRequired", "member %q requires member %q", name, dep)
+				}
+			}
+		}
+		for _, m := range in.members {
+			at := at.AppendToken(m.name)
+			if n.propertyNames != nil {
+				n.propertyNames.validate(&instance{kind: '"', str: m.name}, at, errs, depth+1)
+			}
+			matched := false
+			if s, ok := n.properties[m.name]; ok {
+				s.validate(m.value, at, errs, depth+1)
+				matched = true
+			}
+			for _, pp := range n.patternProperties {
+				if pp.re.MatchString(m.name) {
+					pp.schema.validate(m.value, at, errs, depth+1)
+					matched = true
+				}
+			}
+			if !matched && n.additionalProperties != nil {
+				n.additionalProperties.validate(m.value, at, errs, depth+1)
+			}
+		}
+	}
+
+	for _, s := range n.allOf {
+		s.validate(in, at, errs, depth+1)
+	}
+	if n.anyOf != nil && !slices.ContainsFunc(n.anyOf, func(s *node) bool { return valid(s, in, at) }) {
+		fail("anyOf", "value does not match any schema")
+	}
+	if n.oneOf != nil {
+		var matches []int
+		for i, s := range n.oneOf {
+			if valid(s, in, at) {
+				matches = append(matches, i)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			fail("oneOf", "value does not match any schema")
+		case 1:
+		default:
+			fail("oneOf", "value matches schemas %d and %d", matches[0], matches[1])
+		}
+	}
+	if n.not != nil && valid(n.not, in, at) {
+		fail("not", "value matches the schema")
+	}
+	if n.if_ != nil {
+		if valid(n.if_, in, at) {
+			if n.then_ != nil {
+				n.then_.validate(in, at, errs, depth+1)
+			}
+		} else if n.else_ != nil {
+			n.else_.validate(in, at, errs, depth+1)
+		}
+	}
+}
+
+// An instance is a parsed JSON value being validated.
+type instance struct {
+	kind    jsontext.Kind // 'n', 't', 'f', '"', '0', '{', or '['
+	str     string        // a string, or the literal of a number
+	num     float64
+	elems   []*instance
+	members []member
+}
+
+type member struct {
+	name  string
+	value *instance
+}
+
+func parseInstance(v jsontext.Value) (*instance, error) {
+	dec := jsontext.NewDecoder(strings.NewReader(string(v)))
+	in, err := readInstance(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.ReadToken(); err == nil {
+		return nil, errors.New("jsonschema: unexpected data after top-level value")
+	}
+	return in, nil
+}
+
+func readInstance(dec *jsontext.Decoder) (*instance, error) {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	in := &instance{kind: tok.Kind()}
+	switch in.kind {
+	case '"':
+		in.str = tok.String()
+	case '0':
+		in.str = tok.String()
+		in.num = tok.Float()
+	case '{':
+		for dec.PeekKind() != '}' {
+			tok, err := dec.ReadToken()
+			if err != nil {
+				return nil, err
+			}
+			name := tok.String()
+			value, err := readInstance(dec)
+			if err != nil {
+				return nil, err
+			}
+			in.members = append(in.members, member{name, value})
+		}
+		if _, err := dec.ReadToken(); err != nil {
+			return nil, err
+		}
+	case '[':
+		for dec.PeekKind() != ']' {
+			e, err := readInstance(dec)
+			if err != nil {
+				return nil, err
+			}
+			in.elems = append(in.elems, e)
+		}
+		if _, err := dec.ReadToken(); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+func (in *instance) member(name string) *instance {
+	for _, m := range in.members {
+		if m.name == name {
+			return m.value
+		}
+	}
+	return nil
+}
+
+func (in *instance) typeName() string {
+	switch in.kind {
+	case 'n':
+		return "null"
+	case 't', 'f':
+		return "boolean"
+	case '"':
+		return "string"
+	case '[':
+		return "array"
+	case '{':
+		return "object"
+	}
+	if in.num == math.Trunc(in.num) {
+		return "integer"
+	}
+	return "number"
+}
+
+func (in *instance) hasType(t string) bool {
+	name := in.typeName()
+	return name == t || t == "number" && name == "integer"
+}
+
+// equal reports whether in and other are equal JSON values,
+// comparing numbers by value and objects regardless of member order.
+func (in *instance) equal(other *instance) bool {
+	if in.kind != other.kind {
+		return false
+	}
+	switch in.kind {
+	case '"':
+		return in.str == other.str
+	case '0':
+		return in.compare(other) == 0
+	case '[':
+		return slices.EqualFunc(in.elems, other.elems, (*instance).equal)
+	case '{':
+		if len(in.members) != len(other.members) {
+			return false
+		}
+		for _, m := range in.members {
+			if v := other.member(m.name); v == nil || !m.value.equal(v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compare compares the numbers in and other. Integer literals are
+// compared exactly, since float64 cannot represent all of the integers
+// used as bounds, such as math.MaxInt64 and math.MaxUint64.
+func (in *instance) compare(other *instance) int {
+	x, y := in.str, other.str
+	if !isIntLiteral(x) || !isIntLiteral(y) {
+		return cmp.Compare(in.num, other.num)
+	}
+	// Compare the signs first, treating -0 as 0.
+	xneg, yneg := x[0] == '-' && x != "-0", y[0] == '-' && y != "-0"
+	if xneg != yneg {
+		if xneg {
+			return -1
+		}
+		return +1
+	}
+	c := compareDigits(strings.TrimPrefix(x, "-"), strings.TrimPrefix(y, "-"))
+	if xneg {
+		c = -c
+	}
+	return c
+}
+
+// isIntLiteral reports whether s is a JSON number with no fraction or
+// exponent.
+func isIntLiteral(s string) bool {
+	return s != "" && strings.TrimLeft(strings.TrimPrefix(s, "-"), "0123456789") == ""
+}
+
+// compareDigits compares the non-negative decimal integers x and y,
+// which have no leading zeros.
+func compareDigits(x, y string) int {
+	if len(x) != len(y) {
+		return cmp.Compare(len(x), len(y))
+	}
+	return strings.Compare(x, y)
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsonschema
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  string
+		// want lists the instance and keyword pointers of the expected
+		// violations, in order, as "instance keyword".
+		want []string
+	}{
+		{`true`, `{"a":1}`, nil},
+		{`false`, `1`, []string{" "}},
+		{`{"type":"integer"}`, `1.0`, nil},
+		{`{"type":"integer"}`, `1.5`, []string{" /type"}},
+		{`{"type":["string","null"]}`, `null`, nil},
+		{`{"type":"number","minimum":1,"exclusiveMaximum":3,"multipleOf":0.5}`, `2.5`, nil},
+		{`{"type":"number","minimum":1,"exclusiveMaximum":3,"multipleOf":0.5}`, `3`, []string{" /exclusiveMaximum"}},
+		{`{"multipleOf":0.1}`, `0.3`, nil},
+		{`{"minimum":-9223372036854775808,"maximum":9223372036854775807}`, `9223372036854775807`, nil},
+		{`{"minimum":-9223372036854775808,"maximum":9223372036854775807}`, `9223372036854775808`, []string{" /maximum"}},
+		{`{"minimum":-9223372036854775808,"maximum":9223372036854775807}`, `-9223372036854775808`, nil},
+		{`{"minimum":-9223372036854775808,"maximum":9223372036854775807}`, `-9223372036854775809`, []string{" /minimum"}},
+		{`{"minimum":0,"maximum":18446744073709551615}`, `18446744073709551615`, nil},
+		{`{"minimum":0,"maximum":18446744073709551615}`, `18446744073709551616`, []string{" /maximum"}},
+		{`{"exclusiveMaximum":18446744073709551615}`, `18446744073709551614`, nil},
+		{`{"exclusiveMaximum":18446744073709551615}`, `18446744073709551615`, []string{" /exclusiveMaximum"}},
+		{`{"exclusiveMinimum":0}`, `-0`, []string{" /exclusiveMinimum"}},
+		{`{"maximum":1.5}`, `1`, nil},
+		{`{"const":9007199254740993}`, `9007199254740992`, []string{" /const"}},
+		{`{"minLength":2,"maxLength":3,"pattern":"^a"}`, `"aé"`, nil},
+		{`{"minLength":2,"maxLength":3,"pattern":"^a"}`, `"bcde"`, []string{" /maxLength", " /pattern"}},
+		{`{"enum":[1,"x",{"a":[1,2]}]}`, `{"a":[1.0,2]}`, nil},
+		{`{"enum":[1,"x",{"a":[1,2]}]}`, `{"a":[2,1]}`, []string{" /enum"}},
+		{`{"const":{"a":1,"b":2}}`, `{"b":2,"a":1}`, nil},
+		{`{"uniqueItems":true}`, `[1,{"a":1},1.0]`, []string{" /uniqueItems"}},
+		{`{"prefixItems":[{"type":"string"}],"items":{"type":"integer"},"minItems":2}`, `["a",1,"b"]`, []string{"/2 /items/type"}},
+		{`{"contains":{"const":2},"maxContains":1}`, `[1,2,2]`, []string{" /maxContains"}},
+		{`{"contains":{"const":2}}`, `[1]`, []string{" /contains"}},
+		{
+			`{"type":"object","properties":{"a":{"type":"string"}},"patternProperties":{"^x-":true},"additionalProperties":false,"required":["a","b"]}`,
+			`{"a":1,"x-y":0,"c~/":0}`,
+			[]string{" /required", "/a /properties/a/type", "/c~0~1 /additionalProperties"},
+		},
+		{`{"propertyNames":{"maxLength":1},"maxProperties":1}`, `{"ab":1}`, []string{"/ab /propertyNames/maxLength"}},
+		{`{"dependentRequired":{"a":["b"]}}`, `{"a":1}`, []string{" /dependentRequired"}},
+		{`{"allOf":[{"type":"integer"},{"minimum":5}]}`, `4`, []string{" /allOf/1/minimum"}},
+		{`{"anyOf":[{"type":"integer"},{"type":"string"}]}`, `true`, []string{" /anyOf"}},
+		{`{"oneOf":[{"type":"integer"},{"minimum":0}]}`, `1`, []string{" /oneOf"}},
+		{`{"not":{"type":"null"}}`, `null`, []string{" /not"}},
+		{`{"if":{"type":"string"},"then":{"minLength":1},"else":{"type":"integer"}}`, `""`, []string{" /then/minLength"}},
+		{`{"if":{"type":"string"},"then":{"minLength":1},"else":{"type":"integer"}}`, `1.5`, []string{" /else/type"}},
+		{
+			`{"$defs":{"node":{"type":"object","properties":{"next":{"$ref":"#/$defs/node"},"v":{"type":"integer"}}}},"$ref":"#/$defs/node"}`,
+			`{"v":1,"next":{"v":2,"next":{"v":"3"}}}`,
+			[]string{"/next/next/v /$defs/node/properties/v/type"},
+		},
+		{`{"items":{"$ref":"#"},"type":"array"}`, `[[],[[1]]]`, []string{"/1/0/0 /type"}},
+	}
+	for _, tt := range tests {
+		s, err := Compile(jsontext.Value(tt.schema))
+		if err != nil {
+			t.Errorf("Compile(%s): %v", tt.schema, err)
+			continue
+		}
+		var got []string
+		for _, err := range unjoin(s.Validate(jsontext.Value(tt.value))) {
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Errorf("Validate(%s, %s) returned non-ValidationError %v", tt.schema, tt.value, err)
+				continue
+			}
+			got = append(got, string(verr.InstancePointer)+" "+string(verr.KeywordPointer))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Validate(%s, %s):\ngot  %q\nwant %q", tt.schema, tt.value, got, tt.want)
+		}
+	}
+}
+
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		return j.Unwrap()
+	}
+	return []error{err}
+}
+
+func TestValidateErrors(t *testing.T) {
+	s, err := Compile(jsontext.Value(`{"$ref":"#"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(jsontext.Value(`1`)); err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Errorf("Validate of unbounded reference = %v", err)
+	}
+	if err := s.Validate(jsontext.Value(`[1,`)); err == nil {
+		t.Errorf("Validate of invalid JSON succeeded")
+	}
+
+	s, _ = Compile(jsontext.Value(`{"properties":{"age":{"minimum":18}}}`))
+	const msg = `jsonschema: value at "/age" does not satisfy "/properties/age/minimum": 3 is less than 18`
+	if err := s.Validate(jsontext.Value(`{"age":3}`)); err == nil || err.Error() != msg {
+		t.Errorf("Validate error = %v, want %s", err, msg)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, schema := range []string{
+		`1`,
+		`{"type":"int"}`,
+		`{"minLength":-1}`,
+		`{"pattern":"("}`,
+		`{"$ref":"other.json#/a"}`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"anyOf":[]}`,
+		`{"properties":{"a":3}}`,
+		`{"multipleOf":0}`,
+	} {
+		if _, err := Compile(jsontext.Value(schema)); err == nil {
+			t.Errorf("Compile(%s) succeeded, want error", schema)
+		}
+	}
+}
+
+func TestSchemaJSON(t *testing.T) {
+	const doc = `{"type":"object","required":["a"]}`
+	var s Schema
+	if err := json.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(jsontext.Value(`{}`)); err == nil {
+		t.Error("Validate of unmarshaled schema succeeded, want error")
+	}
+	b, err := json.Marshal(&s)
+	if err != nil || string(b) != doc {
+		t.Errorf("Marshal = %s, %v; want %s", b, err, doc)
+	}
+}
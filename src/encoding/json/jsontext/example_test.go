@@ -128,3 +128,28 @@ func ExampleEscapeForHTML() {
 	// 	"Body": "\u003cscript\u003e console.log(\"Hello, world!\"); \u003c/script\u003e"
 	// }
 }
+
+// This example demonstrates how to modify a JSON document with a
+// JSON Patch (RFC 6902) and inspect the result with a JSON Pointer.
+func ExampleValue_ApplyPatch() {
+	doc := jsontext.Value(`{"name":"gopher","tags":["go"],"age":15}`)
+	patch := jsontext.Value(`[
+		{"op": "test", "path": "/name", "value": "gopher"},
+		{"op": "add", "path": "/tags/-", "value": "mascot"},
+		{"op": "remove", "path": "/age"}
+	]`)
+	if err := doc.ApplyPatch(patch); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(doc)
+
+	tag, err := doc.Lookup("/tags/1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(tag)
+
+	// Output:
+	// {"name":"gopher","tags":["go","mascot"]}
+	// "mascot"
+}
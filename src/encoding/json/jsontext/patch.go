@@ -0,0 +1,804 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsontext
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strconv"
+
+	"encoding/json/internal/jsonflags"
+)
+
+// ErrPointerNotFound indicates that a JSON Pointer does not reference
+// a value within a JSON value.
+var ErrPointerNotFound = errors.New("JSON pointer does not reference a value")
+
+// ErrTestFailed indicates that a "test" operation of a JSON Patch failed
+// because the referenced value is not equal to the expected value.
+// This error is directly wrapped within a [PatchError] when produced.
+var ErrTestFailed = errors.New("test operation failed")
+
+var (
+	errInvalidPointer   = errors.New("invalid JSON pointer")
+	errPatchNotArray    = errors.New(errorPrefix + "JSON patch must be an array")
+	errOpNotObject      = errors.New("operation must be an object")
+	errOpNotString      = errors.New(`"op", "path", and "from" members must be strings`)
+	errUnknownOp        = errors.New("unknown operation")
+	errMissingPath      = errors.New(`missing "path" member`)
+	errMissingFrom      = errors.New(`missing "from" member`)
+	errMissingOpValue   = errors.New(`missing "value" member`)
+	errMoveIntoChild    = errors.New("cannot move a value into one of its children")
+	errRemoveRoot       = errors.New("cannot remove the top-level value")
+	errNotObjectOrArray = errors.New("parent is neither an object nor an array")
+)
+
+// PatchError describes an operation of a JSON Patch (RFC 6902)
+// that could not be applied.
+//
+// The contents of this error as produced by this package may change over time.
+type PatchError struct {
+	requireKeyedLiterals
+	nonComparable
+
+	// Index is the index of the operation within the patch.
+	Index int
+	// Op is the name of the operation (e.g., "add").
+	Op string
+	// Path is the target location of the operation.
+	Path Pointer
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *PatchError) Error() string {
+	b := []byte(errorPrefix + "cannot apply patch operation ")
+	b = strconv.AppendInt(b, int64(e.Index), 10)
+	if e.Op != "" {
+		b = append(b, " ("...)
+		b = append(b, e.Op...)
+		b = strconv.AppendQuote(append(b, ' '), string(e.Path))
+		b = append(b, ')')
+	}
+	if e.Err != nil {
+		b = append(append(b, ": "...), e.Err.Error()...)
+	}
+	return string(b)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// Lookup returns the JSON value within v that p references
+// according to RFC 6901, section 4. The result aliases v.
+//
+// An array index token must be a base-10 integer without leading zeros.
+// If no such value exists, it reports [ErrPointerNotFound].
+// Only the portion of v up to the end of the referenced value
+// is checked for syntactic validity.
+func (v Value) Lookup(p Pointer) (Value, error) {
+	if !p.IsValid() {
+		return nil, errInvalidPointer
+	}
+	d := getBufferedDecoder(v)
+	defer putBufferedDecoder(d)
+	for tok := range p.Tokens() {
+		switch d.PeekKind() {
+		case KindBeginObject:
+			d.ReadToken()
+			for {
+				if d.PeekKind() == KindEndObject {
+					return nil, ErrPointerNotFound
+				}
+				name, err := d.ReadToken()
+				if err != nil {
+					return nil, err
+				}
+				if name.String() == tok {
+					break
+				}
+				if err := d.SkipValue(); err != nil {
+					return nil, err
+				}
+			}
+		case KindBeginArray:
+			i, ok := parseArrayIndex(tok)
+			if !ok {
+				return nil, ErrPointerNotFound
+			}
+			d.ReadToken()
+			for ; i >= 0; i-- {
+				if d.PeekKind() == KindEndArray {
+					return nil, ErrPointerNotFound
+				}
+				if i > 0 {
+					if err := d.SkipValue(); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case invalidKind:
+			_, err := d.ReadToken()
+			return nil, err
+		default:
+			return nil, ErrPointerNotFound
+		}
+	}
+	val, err := d.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	return val[:len(val):len(val)], nil
+}
+
+// parseArrayIndex parses an array index token
+// according to RFC 6901, section 4.
+func parseArrayIndex(tok string) (int, bool) {
+	if tok == "" || len(tok) > 1 && tok[0] == '0' {
+		return 0, false
+	}
+	for _, c := range []byte(tok) {
+		if c < '0' || '9' < c {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(tok)
+	return i, err == nil
+}
+
+// patchNode is a JSON value that is split into its object members or
+// array elements only once an operation needs to descend into it,
+// so that values unaffected by a patch are never parsed.
+type patchNode struct {
+	raw    Value // the entire value if not split; otherwise nil
+	kind   Kind
+	names  []string     // unquoted names of object members
+	values []*patchNode // object member values or array elements
+}
+
+func newPatchNode(v Value) *patchNode {
+	return &patchNode{raw: v, kind: v.Kind()}
+}
+
+// split splits an unsplit object or array into its members or elements.
+func (n *patchNode) split() error {
+	if n.raw == nil || (n.kind != KindBeginObject && n.kind != KindBeginArray) {
+		return nil
+	}
+	d := getBufferedDecoder(n.raw)
+	defer putBufferedDecoder(d)
+	if _, err := d.ReadToken(); err != nil {
+		return err
+	}
+	for {
+		switch d.PeekKind() {
+		case KindEndObject, KindEndArray:
+			if _, err := d.ReadToken(); err != nil {
+				return err
+			}
+			n.raw = nil
+			return nil
+		}
+		if n.kind == KindBeginObject {
+			name, err := d.ReadToken()
+			if err != nil {
+				return err
+			}
+			n.names = append(n.names, name.String())
+		}
+		val, err := d.ReadValue()
+		if err != nil {
+			return err
+		}
+		n.values = append(n.values, newPatchNode(val[:len(val):len(val)]))
+	}
+}
+
+// index returns the index of the object member with the given name or -1.
+func (n *patchNode) index(name string) int {
+	return slices.Index(n.names, name)
+}
+
+// find returns the index of the member or element that tok references.
+func (n *patchNode) find(tok string) (int, error) {
+	if err := n.split(); err != nil {
+		return -1, err
+	}
+	i := -1
+	switch n.kind {
+	case KindBeginObject:
+		i = n.index(tok)
+	case KindBeginArray:
+		if j, ok := parseArrayIndex(tok); ok && j < len(n.values) {
+			i = j
+		}
+	}
+	if i < 0 {
+		return -1, ErrPointerNotFound
+	}
+	return i, nil
+}
+
+// resolve returns the node that p references.
+func (n *patchNode) resolve(p Pointer) (*patchNode, error) {
+	for tok := range p.Tokens() {
+		i, err := n.find(tok)
+		if err != nil {
+			return nil, err
+		}
+		n = n.values[i]
+	}
+	return n, nil
+}
+
+// resolveParent returns the object or array containing
+// the value that the non-empty p references.
+func (n *patchNode) resolveParent(p Pointer) (*patchNode, error) {
+	parent, err := n.resolve(p.Parent())
+	if err != nil {
+		return nil, err
+	}
+	if err := parent.split(); err != nil {
+		return nil, err
+	}
+	if parent.kind != KindBeginObject && parent.kind != KindBeginArray {
+		return nil, errNotObjectOrArray
+	}
+	return parent, nil
+}
+
+// encode writes the value of n to e.
+func (n *patchNode) encode(e *Encoder) error {
+	if n.raw != nil {
+		return e.WriteValue(n.raw)
+	}
+	begin, end := BeginObject, EndObject
+	if n.kind == KindBeginArray {
+		begin, end = BeginArray, EndArray
+	}
+	if err := e.WriteToken(begin); err != nil {
+		return err
+	}
+	for i, v := range n.values {
+		if n.kind == KindBeginObject {
+			if err := e.WriteToken(String(n.names[i])); err != nil {
+				return err
+			}
+		}
+		if err := v.encode(e); err != nil {
+			return err
+		}
+	}
+	return e.WriteToken(end)
+}
+
+// value returns the value of n, which aliases n if it is unsplit.
+func (n *patchNode) value() (Value, error) {
+	if n.raw != nil {
+		return n.raw, nil
+	}
+	var v Value
+	err := v.setNode(n)
+	return v, err
+}
+
+// setNode sets v to the encoded value of n.
+func (v *Value) setNode(n *patchNode) error {
+	e := getBufferedEncoder()
+	defer putBufferedEncoder(e)
+	e.s.Flags.Set(jsonflags.OmitTopLevelNewline | 1)
+	if err := n.encode(e); err != nil {
+		return err
+	}
+	*v = append((*v)[:0], e.s.Buf...)
+	return nil
+}
+
+// checkValue reports an error if v is not a single valid JSON value.
+func checkValue(v Value) error {
+	d := getBufferedDecoder(v)
+	defer putBufferedDecoder(d)
+	if _, err := d.ReadValue(); err != nil {
+		return err
+	}
+	return d.s.CheckEOF()
+}
+
+// equalValues reports whether a and b represent the same JSON value,
+// where object members may appear in any order and
+// numbers are compared as IEEE 754 double-precision values.
+func equalValues(a, b Value) bool {
+	a2, b2 := a.Clone(), b.Clone()
+	if a2.Canonicalize() != nil || b2.Canonicalize() != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(a2, b2)
+}
+
+// patchOp is a single operation of a JSON Patch.
+type patchOp struct {
+	op       string
+	path     Pointer
+	from     Pointer
+	value    Value
+	hasPath  bool
+	hasFrom  bool
+	hasValue bool
+}
+
+// parsePatch parses a JSON Patch document.
+func parsePatch(patch Value) ([]patchOp, error) {
+	d := getBufferedDecoder(patch)
+	defer putBufferedDecoder(d)
+	if d.PeekKind() != KindBeginArray {
+		if _, err := d.ReadToken(); err != nil {
+			return nil, err
+		}
+		return nil, errPatchNotArray
+	}
+	d.ReadToken()
+	var ops []patchOp
+	for d.PeekKind() != KindEndArray {
+		if d.PeekKind() != KindBeginObject {
+			if _, err := d.ReadToken(); err != nil {
+				return nil, err
+			}
+			return nil, &PatchError{Index: len(ops), Err: errOpNotObject}
+		}
+		d.ReadToken()
+		var op patchOp
+		for d.PeekKind() != KindEndObject {
+			name, err := d.ReadToken()
+			if err != nil {
+				return nil, err
+			}
+			switch name := name.String(); name {
+			case "op", "path", "from":
+				if d.PeekKind() != KindString {
+					if err := d.SkipValue(); err != nil {
+						return nil, err
+					}
+					return nil, &PatchError{Index: len(ops), Err: errOpNotString}
+				}
+				tok, err := d.ReadToken()
+				if err != nil {
+					return nil, err
+				}
+				switch name {
+				case "op":
+					op.op = tok.String()
+				case "path":
+					op.path, op.hasPath = Pointer(tok.String()), true
+				case "from":
+					op.from, op.hasFrom = Pointer(tok.String()), true
+				}
+			case "value":
+				val, err := d.ReadValue()
+				if err != nil {
+					return nil, err
+				}
+				op.value, op.hasValue = val[:len(val):len(val)], true
+			default:
+				// Per RFC 6902, section 4, ignore unrecognized members.
+				if err := d.SkipValue(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if _, err := d.ReadToken(); err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if _, err := d.ReadToken(); err != nil {
+		return nil, err
+	}
+	if err := d.s.CheckEOF(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// patchDoc is a document that a JSON Patch is applied to.
+type patchDoc struct {
+	root *patchNode
+}
+
+func (d *patchDoc) add(p Pointer, v *patchNode) error {
+	if p == "" {
+		d.root = v
+		return nil
+	}
+	parent, err := d.root.resolveParent(p)
+	if err != nil {
+		return err
+	}
+	tok := p.LastToken()
+	if parent.kind == KindBeginObject {
+		if i := parent.index(tok); i >= 0 {
+			parent.values[i] = v
+		} else {
+			parent.names = append(parent.names, tok)
+			parent.values = append(parent.values, v)
+		}
+		return nil
+	}
+	i := len(parent.values)
+	if tok != "-" {
+		var ok bool
+		if i, ok = parseArrayIndex(tok); !ok || i > len(parent.values) {
+			return ErrPointerNotFound
+		}
+	}
+	parent.values = slices.Insert(parent.values, i, v)
+	return nil
+}
+
+func (d *patchDoc) remove(p Pointer) (*patchNode, error) {
+	if p == "" {
+		return nil, errRemoveRoot
+	}
+	parent, err := d.root.resolveParent(p)
+	if err != nil {
+		return nil, err
+	}
+	i, err := parent.find(p.LastToken())
+	if err != nil {
+		return nil, err
+	}
+	v := parent.values[i]
+	if parent.kind == KindBeginObject {
+		parent.names = slices.Delete(parent.names, i, i+1)
+	}
+	parent.values = slices.Delete(parent.values, i, i+1)
+	return v, nil
+}
+
+func (d *patchDoc) replace(p Pointer, v *patchNode) error {
+	if p == "" {
+		d.root = v
+		return nil
+	}
+	parent, err := d.root.resolveParent(p)
+	if err != nil {
+		return err
+	}
+	i, err := parent.find(p.LastToken())
+	if err != nil {
+		return err
+	}
+	parent.values[i] = v
+	return nil
+}
+
+func (d *patchDoc) apply(op patchOp) error {
+	switch {
+	case !op.hasPath:
+		return errMissingPath
+	case !op.path.IsValid() || !op.from.IsValid():
+		return errInvalidPointer
+	}
+	switch op.op {
+	case "add", "replace", "test":
+		if !op.hasValue {
+			return errMissingOpValue
+		}
+	case "move", "copy":
+		if !op.hasFrom {
+			return errMissingFrom
+		}
+	}
+	switch op.op {
+	case "add":
+		return d.add(op.path, newPatchNode(op.value))
+	case "remove":
+		_, err := d.remove(op.path)
+		return err
+	case "replace":
+		return d.replace(op.path, newPatchNode(op.value))
+	case "move":
+		if op.from == op.path {
+			_, err := d.root.resolve(op.from)
+			return err
+		}
+		if op.from.Contains(op.path) {
+			return errMoveIntoChild
+		}
+		v, err := d.remove(op.from)
+		if err != nil {
+			return err
+		}
+		return d.add(op.path, v)
+	case "copy":
+		n, err := d.root.resolve(op.from)
+		if err != nil {
+			return err
+		}
+		v, err := n.value()
+		if err != nil {
+			return err
+		}
+		return d.add(op.path, newPatchNode(v))
+	case "test":
+		n, err := d.root.resolve(op.path)
+		if err != nil {
+			return err
+		}
+		v, err := n.value()
+		if err != nil {
+			return err
+		}
+		if !equalValues(v, op.value) {
+			return ErrTestFailed
+		}
+		return nil
+	default:
+		return errUnknownOp
+	}
+}
+
+// ApplyPatch applies a JSON Patch (RFC 6902) to the raw JSON value in place.
+//
+// The patch is a JSON array of operations, which are applied in order.
+// If any operation fails, ApplyPatch reports a [PatchError]
+// and v is left unchanged. Only the objects and arrays along the paths
+// of the operations are parsed, and the result is formatted
+// as by [Value.Format] without options.
+// The "test" operation compares numbers as IEEE 754 double-precision values.
+func (v *Value) ApplyPatch(patch Value) error {
+	if err := checkValue(*v); err != nil {
+		return err
+	}
+	ops, err := parsePatch(patch)
+	if err != nil {
+		return err
+	}
+	doc := &patchDoc{root: newPatchNode(*v)}
+	for i, op := range ops {
+		if err := doc.apply(op); err != nil {
+			return &PatchError{Index: i, Op: op.op, Path: op.path, Err: err}
+		}
+	}
+	return v.setNode(doc.root)
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to the raw JSON value
+// in place. A patch object replaces or merges into the members of v
+// with the same name, and a null member removes the member of v.
+// Any other patch value replaces v entirely.
+// The result is formatted as by [Value.Format] without options.
+func (v *Value) ApplyMergePatch(patch Value) error {
+	if err := checkValue(*v); err != nil {
+		return err
+	}
+	if err := checkValue(patch); err != nil {
+		return err
+	}
+	n, err := mergePatch(newPatchNode(*v), newPatchNode(patch))
+	if err != nil {
+		return err
+	}
+	return v.setNode(n)
+}
+
+func mergePatch(target, patch *patchNode) (*patchNode, error) {
+	if patch.kind != KindBeginObject {
+		return patch, nil
+	}
+	if target == nil || target.kind != KindBeginObject {
+		target = &patchNode{kind: KindBeginObject}
+	}
+	if err := target.split(); err != nil {
+		return nil, err
+	}
+	if err := patch.split(); err != nil {
+		return nil, err
+	}
+	for i, name := range patch.names {
+		pv := patch.values[i]
+		j := target.index(name)
+		if pv.kind == KindNull {
+			if j >= 0 {
+				target.names = slices.Delete(target.names, j, j+1)
+				target.values = slices.Delete(target.values, j, j+1)
+			}
+			continue
+		}
+		var tv *patchNode
+		if j >= 0 {
+			tv = target.values[j]
+		}
+		nv, err := mergePatch(tv, pv)
+		if err != nil {
+			return nil, err
+		}
+		if j >= 0 {
+			target.values[j] = nv
+		} else {
+			target.names = append(target.names, name)
+			target.values = append(target.values, nv)
+		}
+	}
+	return target, nil
+}
+
+// DiffPatch returns a JSON Patch (RFC 6902) that transforms from into to.
+//
+// Object members are compared by name and array elements by index.
+// Members and elements present in both values are diffed recursively,
+// while values of differing kinds are replaced entirely.
+// The patch does not contain "move", "copy", or "test" operations.
+func DiffPatch(from, to Value) (Value, error) {
+	if err := checkValue(from); err != nil {
+		return nil, err
+	}
+	if err := checkValue(to); err != nil {
+		return nil, err
+	}
+	e := getBufferedEncoder()
+	defer putBufferedEncoder(e)
+	e.s.Flags.Set(jsonflags.OmitTopLevelNewline | 1)
+	if err := e.WriteToken(BeginArray); err != nil {
+		return nil, err
+	}
+	if err := diffPatch(e, "", newPatchNode(from), newPatchNode(to)); err != nil {
+		return nil, err
+	}
+	if err := e.WriteToken(EndArray); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.s.Buf), nil
+}
+
+func diffPatch(e *Encoder, p Pointer, from, to *patchNode) error {
+	if equalValues(from.raw, to.raw) {
+		return nil
+	}
+	switch {
+	case from.kind == KindBeginObject && to.kind == KindBeginObject:
+		if err := from.split(); err != nil {
+			return err
+		}
+		if err := to.split(); err != nil {
+			return err
+		}
+		for _, name := range from.names {
+			if to.index(name) < 0 {
+				if err := writePatchOp(e, "remove", p.AppendToken(name), nil); err != nil {
+					return err
+				}
+			}
+		}
+		for i, name := range to.names {
+			if j := from.index(name); j >= 0 {
+				if err := diffPatch(e, p.AppendToken(name), from.values[j], to.values[i]); err != nil {
+					return err
+				}
+			} else {
+				if err := writePatchOp(e, "add", p.AppendToken(name), to.values[i].raw); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case from.kind == KindBeginArray && to.kind == KindBeginArray:
+		if err := from.split(); err != nil {
+			return err
+		}
+		if err := to.split(); err != nil {
+			return err
+		}
+		n := min(len(from.values), len(to.values))
+		for i := range n {
+			if err := diffPatch(e, p.AppendToken(strconv.Itoa(i)), from.values[i], to.values[i]); err != nil {
+				return err
+			}
+		}
+		for i := len(from.values) - 1; i >= n; i-- {
+			if err := writePatchOp(e, "remove", p.AppendToken(strconv.Itoa(i)), nil); err != nil {
+				return err
+			}
+		}
+		for i := n; i < len(to.values); i++ {
+			if err := writePatchOp(e, "add", p.AppendToken(strconv.Itoa(i)), to.values[i].raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return writePatchOp(e, "replace", p, to.raw)
+	}
+}
+
+// writePatchOp writes a single JSON Patch operation.
+// The value is omitted if nil.
+func writePatchOp(e *Encoder, op string, path Pointer, value Value) error {
+	for _, tok := range []Token{BeginObject, String("op"), String(op), String("path"), String(string(path))} {
+		if err := e.WriteToken(tok); err != nil {
+			return err
+		}
+	}
+	if value != nil {
+		if err := e.WriteToken(String("value")); err != nil {
+			return err
+		}
+		if err := e.WriteValue(value); err != nil {
+			return err
+		}
+	}
+	return e.WriteToken(EndObject)
+}
+
+// DiffMergePatch returns a JSON Merge Patch (RFC 7396) that transforms
+// from into to.
+//
+// Since a null member in a merge patch removes the member,
+// a merge patch cannot set an object member to null.
+// If to contains such a member, applying the result to from
+// does not reproduce to exactly.
+func DiffMergePatch(from, to Value) (Value, error) {
+	if err := checkValue(from); err != nil {
+		return nil, err
+	}
+	if err := checkValue(to); err != nil {
+		return nil, err
+	}
+	e := getBufferedEncoder()
+	defer putBufferedEncoder(e)
+	e.s.Flags.Set(jsonflags.OmitTopLevelNewline | 1)
+	if err := diffMergePatch(e, newPatchNode(from), newPatchNode(to)); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.s.Buf), nil
+}
+
+func diffMergePatch(e *Encoder, from, to *patchNode) error {
+	if from.kind != KindBeginObject || to.kind != KindBeginObject {
+		return e.WriteValue(to.raw)
+	}
+	if err := from.split(); err != nil {
+		return err
+	}
+	if err := to.split(); err != nil {
+		return err
+	}
+	if err := e.WriteToken(BeginObject); err != nil {
+		return err
+	}
+	for _, name := range from.names {
+		if to.index(name) < 0 {
+			if err := e.WriteToken(String(name)); err != nil {
+				return err
+			}
+			if err := e.WriteToken(Null); err != nil {
+				return err
+			}
+		}
+	}
+	for i, name := range to.names {
+		j := from.index(name)
+		if j >= 0 && equalValues(from.values[j].raw, to.values[i].raw) {
+			continue
+		}
+		if err := e.WriteToken(String(name)); err != nil {
+			return err
+		}
+		if j >= 0 {
+			if err := diffMergePatch(e, from.values[j], to.values[i]); err != nil {
+				return err
+			}
+		} else {
+			if err := e.WriteValue(to.values[i].raw); err != nil {
+				return err
+			}
+		}
+	}
+	return e.WriteToken(EndObject)
+}
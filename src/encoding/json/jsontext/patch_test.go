@@ -0,0 +1,280 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsontext
+
+import (
+	"errors"
+	"testing"
+
+	"encoding/json/internal/jsontest"
+)
+
+func TestValueLookup(t *testing.T) {
+	// Examples from RFC 6901, section 5.
+	const doc = `{
+		"foo": ["bar", "baz"],
+		"": 0,
+		"a/b": 1,
+		"c%d": 2,
+		"e^f": 3,
+		"g|h": 4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ": 7,
+		"m~n": 8
+	}`
+	tests := []struct {
+		ptr     Pointer
+		want    string
+		wantErr error
+	}{
+		{ptr: "", want: doc},
+		{ptr: "/foo", want: `["bar", "baz"]`},
+		{ptr: "/foo/0", want: `"bar"`},
+		{ptr: "/foo/1", want: `"baz"`},
+		{ptr: "/", want: "0"},
+		{ptr: "/a~1b", want: "1"},
+		{ptr: "/c%d", want: "2"},
+		{ptr: "/e^f", want: "3"},
+		{ptr: "/g|h", want: "4"},
+		{ptr: "/i\\j", want: "5"},
+		{ptr: "/k\"l", want: "6"},
+		{ptr: "/ ", want: "7"},
+		{ptr: "/m~0n", want: "8"},
+		{ptr: "/foo/2", wantErr: ErrPointerNotFound},
+		{ptr: "/foo/01", wantErr: ErrPointerNotFound},
+		{ptr: "/foo/-", wantErr: ErrPointerNotFound},
+		{ptr: "/foo/0/x", wantErr: ErrPointerNotFound},
+		{ptr: "/missing", wantErr: ErrPointerNotFound},
+		{ptr: "foo", wantErr: errInvalidPointer},
+	}
+	for _, tt := range tests {
+		got, err := Value(doc).Lookup(tt.ptr)
+		if err != tt.wantErr {
+			t.Errorf("Lookup(%q) error = %v, want %v", tt.ptr, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr == nil && string(got) != tt.want {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.ptr, got, tt.want)
+		}
+	}
+
+	if _, err := Value(`{"a":[1,}`).Lookup("/a/1"); err == nil {
+		t.Error("Lookup on invalid value succeeded, want error")
+	}
+}
+
+var patchTestdata = []struct {
+	name    jsontest.CaseName
+	doc     string
+	patch   string
+	want    string
+	wantErr error
+}{
+	// Examples from RFC 6902, appendix A.
+	{name: jsontest.Name("AddObjectMember"), doc: `{"foo":"bar"}`,
+		patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+		want:  `{"foo":"bar","baz":"qux"}`},
+	{name: jsontest.Name("AddArrayElement"), doc: `{"foo":["bar","baz"]}`,
+		patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+		want:  `{"foo":["bar","qux","baz"]}`},
+	{name: jsontest.Name("RemoveObjectMember"), doc: `{"baz":"qux","foo":"bar"}`,
+		patch: `[{"op":"remove","path":"/baz"}]`,
+		want:  `{"foo":"bar"}`},
+	{name: jsontest.Name("RemoveArrayElement"), doc: `{"foo":["bar","qux","baz"]}`,
+		patch: `[{"op":"remove","path":"/foo/1"}]`,
+		want:  `{"foo":["bar","baz"]}`},
+	{name: jsontest.Name("Replace"), doc: `{"baz":"qux","foo":"bar"}`,
+		patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+		want:  `{"baz":"boo","foo":"bar"}`},
+	{name: jsontest.Name("MoveValue"), doc: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+		patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+		want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+	{name: jsontest.Name("MoveArrayElement"), doc: `{"foo":["all","grass","cows","eat"]}`,
+		patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+		want:  `{"foo":["all","cows","eat","grass"]}`},
+	{name: jsontest.Name("TestSuccess"), doc: `{"baz":"qux","foo":["a",2,"c"]}`,
+		patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+		want:  `{"baz":"qux","foo":["a",2,"c"]}`},
+	{name: jsontest.Name("TestFailure"), doc: `{"baz":"qux"}`,
+		patch:   `[{"op":"test","path":"/baz","value":"bar"}]`,
+		wantErr: ErrTestFailed},
+	{name: jsontest.Name("AddNestedMember"), doc: `{"foo":"bar"}`,
+		patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+		want:  `{"foo":"bar","child":{"grandchild":{}}}`},
+	{name: jsontest.Name("IgnoreUnrecognized"), doc: `{"foo":"bar"}`,
+		patch: `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+		want:  `{"foo":"bar","baz":"qux"}`},
+	{name: jsontest.Name("AddToNonexistentTarget"), doc: `{"foo":"bar"}`,
+		patch:   `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+		wantErr: ErrPointerNotFound},
+	{name: jsontest.Name("EscapeOrdering"), doc: `{"/":9,"~1":10}`,
+		patch: `[{"op":"test","path":"/~01","value":10}]`,
+		want:  `{"/":9,"~1":10}`},
+	{name: jsontest.Name("CompareStringsAndNumbers"), doc: `{"/":9,"~1":10}`,
+		patch:   `[{"op":"test","path":"/~01","value":"10"}]`,
+		wantErr: ErrTestFailed},
+	{name: jsontest.Name("AddArrayValue"), doc: `{"foo":["bar"]}`,
+		patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+		want:  `{"foo":["bar",["abc","def"]]}`},
+
+	// Additional cases.
+	{name: jsontest.Name("PreserveUnaffected"), doc: `{"a": {"b": [1, 2]}, "c": 3}`,
+		patch: `[{"op":"replace","path":"/c","value":4}]`,
+		want:  `{"a":{"b":[1,2]},"c":4}`},
+	{name: jsontest.Name("Copy"), doc: `{"a":{"b":1}}`,
+		patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/d","value":2}]`,
+		want:  `{"a":{"b":1},"c":{"b":1,"d":2}}`},
+	{name: jsontest.Name("ReplaceRoot"), doc: `{"a":1}`,
+		patch: `[{"op":"replace","path":"","value":[true]}]`,
+		want:  `[true]`},
+	{name: jsontest.Name("MoveIntoChild"), doc: `{"a":{"b":1}}`,
+		patch:   `[{"op":"move","from":"/a","path":"/a/c"}]`,
+		wantErr: errMoveIntoChild},
+	{name: jsontest.Name("RemoveRoot"), doc: `{}`,
+		patch:   `[{"op":"remove","path":""}]`,
+		wantErr: errRemoveRoot},
+	{name: jsontest.Name("ArrayIndexOutOfRange"), doc: `[1]`,
+		patch:   `[{"op":"add","path":"/2","value":0}]`,
+		wantErr: ErrPointerNotFound},
+	{name: jsontest.Name("UnknownOp"), doc: `{}`,
+		patch:   `[{"op":"frobnicate","path":""}]`,
+		wantErr: errUnknownOp},
+	{name: jsontest.Name("MissingValue"), doc: `{}`,
+		patch:   `[{"op":"add","path":"/a"}]`,
+		wantErr: errMissingOpValue},
+	{name: jsontest.Name("AtomicFailure"), doc: `{"a":1}`,
+		patch:   `[{"op":"remove","path":"/a"},{"op":"test","path":"/a","value":1}]`,
+		wantErr: ErrPointerNotFound},
+	{name: jsontest.Name("NotArray"), doc: `{}`,
+		patch:   `{"op":"add"}`,
+		wantErr: errPatchNotArray},
+}
+
+func TestValueApplyPatch(t *testing.T) {
+	for _, tt := range patchTestdata {
+		t.Run(tt.name.Name, func(t *testing.T) {
+			v := Value(tt.doc)
+			err := v.ApplyPatch(Value(tt.patch))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s: ApplyPatch error = %v, want %v", tt.name.Where, err, tt.wantErr)
+			}
+			if err != nil {
+				if string(v) != tt.doc {
+					t.Errorf("%s: value modified on error: %s", tt.name.Where, v)
+				}
+				return
+			}
+			if string(v) != tt.want {
+				t.Errorf("%s: ApplyPatch:\ngot  %s\nwant %s", tt.name.Where, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchError(t *testing.T) {
+	v := Value(`{"a":1}`)
+	err := v.ApplyPatch(Value(`[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/b"}]`))
+	perr, ok := errors.AsType[*PatchError](err)
+	if !ok || perr.Index != 1 || perr.Op != "remove" || perr.Path != "/b" {
+		t.Fatalf("ApplyPatch error = %#v, want PatchError for operation 1", err)
+	}
+	const want = `jsontext: cannot apply patch operation 1 (remove "/b"): JSON pointer does not reference a value`
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %s, want %s", got, want)
+	}
+}
+
+func TestValueApplyMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A.
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		v := Value(tt.doc)
+		if err := v.ApplyMergePatch(Value(tt.patch)); err != nil {
+			t.Errorf("ApplyMergePatch(%s, %s) error: %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if string(v) != tt.want {
+			t.Errorf("ApplyMergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, v, tt.want)
+		}
+	}
+}
+
+var diffTestdata = []struct {
+	from, to string
+	patch    string
+	merge    string
+}{
+	{`{"a":1}`, `{"a":1}`, `[]`, `{}`},
+	{`{"a":1,"b":2}`, `{"b":2,"a":1.0}`, `[]`, `{}`},
+	{`{"a":1,"b":{"c":[1,2,3]}}`, `{"b":{"c":[1,5]},"d":true}`,
+		`[{"op":"remove","path":"/a"},{"op":"replace","path":"/b/c/1","value":5},{"op":"remove","path":"/b/c/2"},{"op":"add","path":"/d","value":true}]`,
+		`{"a":null,"b":{"c":[1,5]},"d":true}`},
+	{`[1]`, `[1,{"x":"y"},3]`,
+		`[{"op":"add","path":"/1","value":{"x":"y"}},{"op":"add","path":"/2","value":3}]`,
+		`[1,{"x":"y"},3]`},
+	{`{"a/b":{"~":1}}`, `{"a/b":{"~":2}}`,
+		`[{"op":"replace","path":"/a~1b/~0","value":2}]`,
+		`{"a/b":{"~":2}}`},
+	{`"x"`, `{"a":1}`, `[{"op":"replace","path":"","value":{"a":1}}]`, `{"a":1}`},
+}
+
+func TestDiffPatch(t *testing.T) {
+	for _, tt := range diffTestdata {
+		patch, err := DiffPatch(Value(tt.from), Value(tt.to))
+		if err != nil {
+			t.Errorf("DiffPatch(%s, %s) error: %v", tt.from, tt.to, err)
+			continue
+		}
+		if string(patch) != tt.patch {
+			t.Errorf("DiffPatch(%s, %s):\ngot  %s\nwant %s", tt.from, tt.to, patch, tt.patch)
+		}
+		v := Value(tt.from)
+		if err := v.ApplyPatch(patch); err != nil {
+			t.Errorf("ApplyPatch(%s, %s) error: %v", tt.from, patch, err)
+		} else if !equalValues(v, Value(tt.to)) {
+			t.Errorf("ApplyPatch(%s, %s) = %s, want %s", tt.from, patch, v, tt.to)
+		}
+	}
+}
+
+func TestDiffMergePatch(t *testing.T) {
+	for _, tt := range diffTestdata {
+		patch, err := DiffMergePatch(Value(tt.from), Value(tt.to))
+		if err != nil {
+			t.Errorf("DiffMergePatch(%s, %s) error: %v", tt.from, tt.to, err)
+			continue
+		}
+		if string(patch) != tt.merge {
+			t.Errorf("DiffMergePatch(%s, %s):\ngot  %s\nwant %s", tt.from, tt.to, patch, tt.merge)
+		}
+		v := Value(tt.from)
+		if err := v.ApplyMergePatch(patch); err != nil {
+			t.Errorf("ApplyMergePatch(%s, %s) error: %v", tt.from, patch, err)
+		} else if !equalValues(v, Value(tt.to)) {
+			t.Errorf("ApplyMergePatch(%s, %s) = %s, want %s", tt.from, patch, v, tt.to)
+		}
+	}
+}
@@ -18,10 +18,36 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"encoding/json/internal/jsonfields"
 	"encoding/json/internal/jsonflags"
 	"encoding/json/internal/jsonwire"
 )
 
+// Provide the struct fields to jsonschema.
+func init() {
+	jsonfields.StructFields = func(t reflect.Type) ([]jsonfields.Field, error) {
+		fs, serr := makeStructFields(t)
+		if serr != nil {
+			return nil, serr
+		}
+		var out []jsonfields.Field
+		for _, f := range fs.flattened {
+			out = append(out, jsonfields.Field{
+				Name:      f.name,
+				Type:      f.typ,
+				OmitZero:  f.omitzero,
+				OmitEmpty: f.omitempty,
+				String:    f.string,
+				Format:    f.format,
+			})
+		}
+		if f := fs.inlinedFallback; f != nil {
+			out = append(out, jsonfields.Field{Type: f.typ, Unknown: true})
+		}
+		return out, nil
+	}
+}
+
 type isZeroer interface {
 	IsZero() bool
 }
@@ -0,0 +1,394 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protowire
+
+import (
+	"bufio"
+	"errors"
+	"internal/saferio"
+	"io"
+)
+
+var (
+	errWireType    = errors.New("protowire: mismatching wire type")
+	errNoField     = errors.New("protowire: no field value to read")
+	errOutOfBounds = errors.New("protowire: length exceeds enclosing message")
+)
+
+// source is the input shared by a Reader and its nested Readers.
+type source struct {
+	br  *bufio.Reader
+	off int64 // number of bytes consumed from br
+}
+
+// A Reader reads the fields of a message from an [io.Reader].
+//
+// Fields are iterated over with [Reader.Next], after which the value of
+// the field may be read with the method matching its wire type.
+// A value that is not read is skipped by the next call to Next.
+// Embedded messages, packed repeated fields, and groups are read
+// with a nested Reader obtained from [Reader.Message], [Reader.Packed],
+// and [Reader.Group].
+//
+// A nested Reader shares the input of its parent. It must not be used
+// after the next call to a method of its parent, which discards the
+// remainder of the nested data.
+type Reader struct {
+	src *source
+	end int64 // input offset where the message ends, or -1 if unknown
+
+	group  Number // for a group, the field number that ends it
+	packed Type   // for packed repeated values, their wire type; otherwise -1
+
+	num   Number
+	typ   Type
+	ready bool    // whether the value of the current field is unread
+	done  bool    // whether the end of the message has been reached
+	child *Reader // most recently created nested Reader
+	depth int
+}
+
+// NewReader returns a new Reader reading a message from r.
+// The message extends until r returns [io.EOF].
+//
+// If r does not implement [io.ByteReader], the Reader may read more
+// data from r than necessary.
+func NewReader(r io.Reader) *Reader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Reader{src: &source{br: br}, end: -1, packed: -1}
+}
+
+// InputOffset returns the number of bytes consumed from the underlying reader.
+func (r *Reader) InputOffset() int64 {
+	return r.src.off
+}
+
+// sync discards any unread data of the most recently created nested Reader.
+func (r *Reader) sync() error {
+	if r.child == nil {
+		return nil
+	}
+	c := r.child
+	r.child = nil
+	return c.drain()
+}
+
+// drain discards the remainder of the message.
+func (r *Reader) drain() error {
+	if r.done {
+		return nil
+	}
+	if r.end >= 0 && r.group == 0 {
+		if err := r.sync(); err != nil {
+			return err
+		}
+		if err := r.discard(r.end - r.src.off); err != nil {
+			return err
+		}
+		r.done = true
+		return nil
+	}
+	for {
+		if _, _, err := r.Next(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// atEnd reports whether the end of the message has been reached
+// at a boundary between fields or values.
+func (r *Reader) atEnd() (bool, error) {
+	if r.end >= 0 {
+		return r.src.off >= r.end, nil
+	}
+	if _, err := r.src.br.Peek(1); err != nil {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+func (r *Reader) readByte() (byte, error) {
+	if r.end >= 0 && r.src.off >= r.end {
+		return 0, io.ErrUnexpectedEOF
+	}
+	c, err := r.src.br.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	r.src.off++
+	return c, nil
+}
+
+// readFull reads exactly n bytes into a new slice.
+func (r *Reader) readFull(n uint64) ([]byte, error) {
+	if r.end >= 0 && n > uint64(r.end-r.src.off) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b, err := saferio.ReadData(r.src.br, n)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	r.src.off += int64(n)
+	return b, nil
+}
+
+func (r *Reader) discard(n int64) error {
+	if r.end >= 0 && n > r.end-r.src.off {
+		return io.ErrUnexpectedEOF
+	}
+	m, err := r.src.br.Discard(int(min(n, 1<<30)))
+	r.src.off += int64(m)
+	for err == nil && int64(m) < n {
+		n -= int64(m)
+		m, err = r.src.br.Discard(int(min(n, 1<<30)))
+		r.src.off += int64(m)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (r *Reader) readVarint() (uint64, error) {
+	var v uint64
+	for i := 0; ; i++ {
+		c, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if i == 9 {
+			if c > 1 {
+				return 0, errOverflow
+			}
+			return v | uint64(c)<<63, nil
+		}
+		v |= uint64(c&0x7f) << (7 * i)
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+}
+
+// Next advances to the next field, skipping the value of the current
+// field if it has not been read, and returns its number and wire type.
+// At the end of the message, it returns [io.EOF].
+//
+// Next returns an error for a packed Reader.
+func (r *Reader) Next() (Number, Type, error) {
+	if r.packed >= 0 {
+		return 0, 0, errNoField
+	}
+	if err := r.sync(); err != nil {
+		return 0, 0, err
+	}
+	if r.ready {
+		if err := r.Skip(); err != nil {
+			return 0, 0, err
+		}
+	}
+	if r.done {
+		return 0, 0, io.EOF
+	}
+	if end, err := r.atEnd(); err != nil || end {
+		if err == nil {
+			if r.group != 0 {
+				// A group must be terminated by its end group marker.
+				return 0, 0, io.ErrUnexpectedEOF
+			}
+			r.done = true
+			err = io.EOF
+		}
+		return 0, 0, err
+	}
+	v, err := r.readVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	num, typ := DecodeTag(v)
+	if num < MinValidNumber {
+		return 0, 0, errFieldNumber
+	}
+	if typ == EndGroupType {
+		if num != r.group {
+			return 0, 0, errEndGroup
+		}
+		r.done = true
+		return 0, 0, io.EOF
+	}
+	r.num, r.typ, r.ready = num, typ, true
+	return num, typ, nil
+}
+
+// value prepares to read a value of wire type typ.
+// For a packed Reader, it reports io.EOF at the end of the values.
+func (r *Reader) value(typ Type) error {
+	if r.packed >= 0 {
+		if r.packed != typ {
+			return errWireType
+		}
+		if end, _ := r.atEnd(); end {
+			return io.EOF
+		}
+		return nil
+	}
+	if !r.ready {
+		return errNoField
+	}
+	if r.typ != typ {
+		return errWireType
+	}
+	r.ready = false
+	return nil
+}
+
+// Varint reads the value of a field of [VarintType].
+func (r *Reader) Varint() (uint64, error) {
+	if err := r.value(VarintType); err != nil {
+		return 0, err
+	}
+	return r.readVarint()
+}
+
+// Fixed32 reads the value of a field of [Fixed32Type].
+func (r *Reader) Fixed32() (uint32, error) {
+	if err := r.value(Fixed32Type); err != nil {
+		return 0, err
+	}
+	b, err := r.readFull(4)
+	if err != nil {
+		return 0, err
+	}
+	v, _ := ConsumeFixed32(b)
+	return v, nil
+}
+
+// Fixed64 reads the value of a field of [Fixed64Type].
+func (r *Reader) Fixed64() (uint64, error) {
+	if err := r.value(Fixed64Type); err != nil {
+		return 0, err
+	}
+	b, err := r.readFull(8)
+	if err != nil {
+		return 0, err
+	}
+	v, _ := ConsumeFixed64(b)
+	return v, nil
+}
+
+// Bytes reads the value of a field of [BytesType] into a new slice.
+func (r *Reader) Bytes() ([]byte, error) {
+	if err := r.value(BytesType); err != nil {
+		return nil, err
+	}
+	n, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	return r.readFull(n)
+}
+
+// Message returns a Reader for the embedded message that is
+// the value of a field of [BytesType].
+func (r *Reader) Message() (*Reader, error) {
+	return r.nested(-1)
+}
+
+// Packed returns a Reader for the packed repeated values of wire type typ
+// that are the value of a field of [BytesType]. The values are read by
+// calling the method for typ until it returns [io.EOF].
+func (r *Reader) Packed(typ Type) (*Reader, error) {
+	switch typ {
+	case VarintType, Fixed32Type, Fixed64Type:
+		return r.nested(typ)
+	}
+	return nil, errWireType
+}
+
+func (r *Reader) nested(packed Type) (*Reader, error) {
+	if err := r.value(BytesType); err != nil {
+		return nil, err
+	}
+	n, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > 1<<62 || r.end >= 0 && int64(n) > r.end-r.src.off {
+		return nil, errOutOfBounds
+	}
+	c := &Reader{src: r.src, end: r.src.off + int64(n), packed: packed, depth: r.depth + 1}
+	if c.depth > DefaultRecursionLimit {
+		return nil, errRecursion
+	}
+	r.child = c
+	return c, nil
+}
+
+// Group returns a Reader for the fields of a group, which is the value of
+// a field of [StartGroupType]. The nested Reader returns [io.EOF] after
+// consuming the matching end group marker.
+func (r *Reader) Group() (*Reader, error) {
+	num := r.num
+	if err := r.value(StartGroupType); err != nil {
+		return nil, err
+	}
+	c := &Reader{src: r.src, end: r.end, group: num, packed: -1, depth: r.depth + 1}
+	if c.depth > DefaultRecursionLimit {
+		return nil, errRecursion
+	}
+	r.child = c
+	return c, nil
+}
+
+// Skip skips the value of the current field.
+func (r *Reader) Skip() error {
+	if !r.ready {
+		return errNoField
+	}
+	switch r.typ {
+	case VarintType:
+		_, err := r.Varint()
+		return err
+	case Fixed32Type:
+		r.ready = false
+		return r.discard(4)
+	case Fixed64Type:
+		r.ready = false
+		return r.discard(8)
+	case BytesType:
+		r.ready = false
+		n, err := r.readVarint()
+		if err != nil {
+			return err
+		}
+		if n > 1<<62 {
+			return io.ErrUnexpectedEOF
+		}
+		return r.discard(int64(n))
+	case StartGroupType:
+		g, err := r.Group()
+		if err != nil {
+			return err
+		}
+		r.child = nil
+		return g.drain()
+	default:
+		return errReserved
+	}
+}
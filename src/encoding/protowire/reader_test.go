@@ -0,0 +1,176 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protowire
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReader(t *testing.T) {
+	var b []byte
+	b = AppendTag(b, 1, VarintType)
+	b = AppendVarint(b, 150)
+	b = AppendTag(b, 2, BytesType)
+	b = AppendString(b, "skipped")
+	b = AppendMessage(b, 3, func(b []byte) []byte {
+		b = AppendTag(b, 1, Fixed32Type)
+		b = AppendFixed32(b, 7)
+		b = AppendTag(b, 2, BytesType)
+		return AppendBytes(b, []byte("unread"))
+	})
+	b = AppendTag(b, 4, BytesType)
+	packed := AppendVarint(AppendVarint(AppendVarint(nil, 1), 300), 1<<40)
+	b = AppendBytes(b, packed)
+	b = AppendTag(b, 5, StartGroupType)
+	b = AppendGroup(b, 5, AppendFixed64(AppendTag(nil, 1, Fixed64Type), 9))
+	b = AppendTag(b, 6, Fixed64Type)
+	b = AppendFixed64(b, 42)
+
+	r := NewReader(iotest.OneByteReader(bytes.NewReader(b)))
+	next := func(wantNum Number, wantTyp Type) {
+		t.Helper()
+		num, typ, err := r.Next()
+		if err != nil || num != wantNum || typ != wantTyp {
+			t.Fatalf("Next = %d, %d, %v, want %d, %d", num, typ, err, wantNum, wantTyp)
+		}
+	}
+
+	next(1, VarintType)
+	if v, err := r.Varint(); err != nil || v != 150 {
+		t.Fatalf("Varint = %d, %v", v, err)
+	}
+	next(2, BytesType)
+	next(3, BytesType)
+	m, err := r.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num, _, err := m.Next(); err != nil || num != 1 {
+		t.Fatalf("Message.Next = %d, %v", num, err)
+	}
+	if v, err := m.Fixed32(); err != nil || v != 7 {
+		t.Fatalf("Message.Fixed32 = %d, %v", v, err)
+	}
+	next(4, BytesType)
+	p, err := r.Packed(VarintType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vals []uint64
+	for {
+		v, err := p.Varint()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals = append(vals, v)
+	}
+	if len(vals) != 3 || vals[0] != 1 || vals[1] != 300 || vals[2] != 1<<40 {
+		t.Errorf("packed values = %v", vals)
+	}
+	next(5, StartGroupType)
+	g, err := r.Group()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num, typ, err := g.Next(); err != nil || num != 1 || typ != Fixed64Type {
+		t.Fatalf("Group.Next = %d, %d, %v", num, typ, err)
+	}
+	if v, err := g.Fixed64(); err != nil || v != 9 {
+		t.Fatalf("Group.Fixed64 = %d, %v", v, err)
+	}
+	if _, _, err := g.Next(); err != io.EOF {
+		t.Fatalf("Group.Next error = %v, want io.EOF", err)
+	}
+	next(6, Fixed64Type)
+	if _, err := r.Varint(); err != errWireType {
+		t.Errorf("Varint on Fixed64 field error = %v, want %v", err, errWireType)
+	}
+	if v, err := r.Fixed64(); err != nil || v != 42 {
+		t.Fatalf("Fixed64 = %d, %v", v, err)
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next error = %v, want io.EOF", err)
+	}
+	if off := r.InputOffset(); off != int64(len(b)) {
+		t.Errorf("InputOffset = %d, want %d", off, len(b))
+	}
+}
+
+func TestReaderSkipGroup(t *testing.T) {
+	var b []byte
+	inner := AppendGroup(AppendTag(nil, 2, StartGroupType), 2, AppendVarint(AppendTag(nil, 1, VarintType), 5))
+	b = AppendTag(b, 1, StartGroupType)
+	b = AppendGroup(b, 1, inner)
+	b = AppendTag(b, 3, VarintType)
+	b = AppendVarint(b, 8)
+
+	r := NewReader(bytes.NewReader(b))
+	if num, _, err := r.Next(); err != nil || num != 1 {
+		t.Fatalf("Next = %d, %v", num, err)
+	}
+	if num, _, err := r.Next(); err != nil || num != 3 {
+		t.Fatalf("Next = %d, %v", num, err)
+	}
+	if v, err := r.Varint(); err != nil || v != 8 {
+		t.Fatalf("Varint = %d, %v", v, err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		err  error
+	}{
+		{"TruncatedTag", []byte{0x80}, io.ErrUnexpectedEOF},
+		{"TruncatedValue", []byte{0x08, 0x80}, io.ErrUnexpectedEOF},
+		{"TruncatedBytes", []byte{0x12, 0x05, 'a'}, io.ErrUnexpectedEOF},
+		{"HugeBytes", AppendVarint([]byte{0x12}, 1<<50), io.ErrUnexpectedEOF},
+		{"ZeroNumber", []byte{0x00}, errFieldNumber},
+		{"Reserved", []byte{0x0e}, errReserved},
+		{"StrayEndGroup", []byte{0x0c}, errEndGroup},
+		{"UnterminatedGroup", []byte{0x0b, 0x08, 0x01}, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(bytes.NewReader(tt.in))
+			var err error
+			for err == nil {
+				_, _, err = r.Next()
+			}
+			if err != tt.err {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestReaderMessageBounds(t *testing.T) {
+	// The embedded message claims a field extending past its end.
+	b := AppendTag(nil, 1, BytesType)
+	b = AppendBytes(b, []byte{0x12, 0x05, 'a', 'b'})
+	b = AppendTag(b, 2, VarintType)
+	b = AppendVarint(b, 1)
+
+	r := NewReader(bytes.NewReader(b))
+	r.Next()
+	m, err := r.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Next()
+	if _, err := m.Bytes(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Bytes error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if num, _, err := r.Next(); err != nil || num != 2 {
+		t.Errorf("Next = %d, %v, want 2", num, err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protowire
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestVarint(t *testing.T) {
+	tests := []struct {
+		v   uint64
+		hex string
+	}{
+		{0, "00"},
+		{1, "01"},
+		{127, "7f"},
+		{128, "8001"},
+		{300, "ac02"},
+		{1<<14 - 1, "ff7f"},
+		{1 << 14, "808001"},
+		{math.MaxUint32, "ffffffff0f"},
+		{math.MaxUint64, "ffffffffffffffffff01"},
+	}
+	for _, tt := range tests {
+		b := AppendVarint(nil, tt.v)
+		if got := hex.EncodeToString(b); got != tt.hex {
+			t.Errorf("AppendVarint(%d) = %s, want %s", tt.v, got, tt.hex)
+		}
+		if n := SizeVarint(tt.v); n != len(b) {
+			t.Errorf("SizeVarint(%d) = %d, want %d", tt.v, n, len(b))
+		}
+		v, n := ConsumeVarint(b)
+		if v != tt.v || n != len(b) {
+			t.Errorf("ConsumeVarint(%s) = %d, %d, want %d, %d", tt.hex, v, n, tt.v, len(b))
+		}
+	}
+}
+
+func TestConsumeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		err  error
+	}{
+		{"Varint/Empty", func() int { _, n := ConsumeVarint(nil); return n }(), io.ErrUnexpectedEOF},
+		{"Varint/Truncated", func() int { _, n := ConsumeVarint([]byte{0x80}); return n }(), io.ErrUnexpectedEOF},
+		{"Varint/Overflow", func() int {
+			_, n := ConsumeVarint([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+			return n
+		}(), errOverflow},
+		{"Fixed32/Truncated", func() int { _, n := ConsumeFixed32([]byte{1, 2, 3}); return n }(), io.ErrUnexpectedEOF},
+		{"Bytes/Truncated", func() int { _, n := ConsumeBytes([]byte{3, 'a'}); return n }(), io.ErrUnexpectedEOF},
+		{"Tag/ZeroNumber", func() int { _, _, n := ConsumeTag([]byte{0x00}); return n }(), errFieldNumber},
+		{"Field/Reserved", func() int { _, _, n := ConsumeField([]byte{0x0e}); return n }(), errReserved},
+		{"Field/EndGroup", func() int { _, _, n := ConsumeField([]byte{0x0c}); return n }(), errEndGroup},
+		{"Group/Mismatch", func() int { _, n := ConsumeGroup(1, []byte{0x14}); return n }(), errEndGroup},
+		{"Group/Unterminated", func() int { _, n := ConsumeGroup(1, []byte{0x08, 0x01}); return n }(), io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		if tt.n >= 0 {
+			t.Errorf("%s: n = %d, want negative", tt.name, tt.n)
+			continue
+		}
+		if err := ParseError(tt.n); err != tt.err {
+			t.Errorf("%s: ParseError = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestField(t *testing.T) {
+	var b []byte
+	b = AppendTag(b, 1, VarintType)
+	b = AppendVarint(b, 150)
+	b = AppendTag(b, 2, Fixed32Type)
+	b = AppendFixed32(b, 0xdeadbeef)
+	b = AppendTag(b, 3, Fixed64Type)
+	b = AppendFixed64(b, math.Float64bits(1.5))
+	b = AppendTag(b, 4, BytesType)
+	b = AppendString(b, "testing")
+	b = AppendTag(b, 5, StartGroupType)
+	b = AppendGroup(b, 5, AppendVarint(AppendTag(nil, 1, VarintType), 1))
+	b = AppendTag(b, 536870911, VarintType)
+	b = AppendVarint(b, EncodeZigZag(-2))
+
+	want := []struct {
+		num Number
+		typ Type
+	}{
+		{1, VarintType}, {2, Fixed32Type}, {3, Fixed64Type},
+		{4, BytesType}, {5, StartGroupType}, {MaxValidNumber, VarintType},
+	}
+	for i := 0; len(b) > 0; i++ {
+		num, typ, n := ConsumeField(b)
+		if n < 0 {
+			t.Fatalf("ConsumeField error: %v", ParseError(n))
+		}
+		if i >= len(want) || num != want[i].num || typ != want[i].typ {
+			t.Fatalf("field %d = %d/%d", i, num, typ)
+		}
+		if num == 4 {
+			_, _, m := ConsumeTag(b)
+			if v, _ := ConsumeString(b[m:]); v != "testing" {
+				t.Errorf("ConsumeString = %q, want %q", v, "testing")
+			}
+		}
+		if num == MaxValidNumber {
+			_, _, m := ConsumeTag(b)
+			if v, _ := ConsumeVarint(b[m:]); DecodeZigZag(v) != -2 {
+				t.Errorf("DecodeZigZag = %d, want -2", DecodeZigZag(v))
+			}
+		}
+		b = b[n:]
+	}
+}
+
+func TestZigZag(t *testing.T) {
+	for _, v := range []int64{0, -1, 1, -2, 2, math.MaxInt64, math.MinInt64} {
+		if got := DecodeZigZag(EncodeZigZag(v)); got != v {
+			t.Errorf("DecodeZigZag(EncodeZigZag(%d)) = %d", v, got)
+		}
+	}
+	if got := EncodeZigZag(-1); got != 1 {
+		t.Errorf("EncodeZigZag(-1) = %d, want 1", got)
+	}
+}
+
+func TestAppendMessage(t *testing.T) {
+	for _, size := range []int{0, 1, 127, 128, 300, 1 << 14, 1<<21 + 5} {
+		payload := bytes.Repeat([]byte{'x'}, size)
+		b := AppendMessage([]byte{0xaa}, 7, func(b []byte) []byte {
+			return append(b, payload...)
+		})
+		want := AppendBytes(AppendTag([]byte{0xaa}, 7, BytesType), payload)
+		if !bytes.Equal(b, want) {
+			t.Errorf("AppendMessage with %d bytes: mismatch", size)
+		}
+	}
+}
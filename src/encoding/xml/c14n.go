@@ -0,0 +1,383 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xml
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// A Canonicalizer writes the Exclusive XML Canonicalization
+// (https://www.w3.org/TR/xml-exc-c14n/) or, if Inclusive is set, the
+// Canonical XML 1.0 (https://www.w3.org/TR/xml-c14n) form of a stream of
+// XML tokens, as used to compute and verify XML signatures.
+//
+// The tokens must be those returned by [Decoder.RawToken], in which the
+// Space field of a [Name] holds the name space prefix rather than the
+// name space url. The Canonicalizer resolves the prefixes itself from the
+// xmlns attributes of the elements.
+//
+// The canonical form is written in UTF-8 without an XML declaration or
+// document type declaration. Empty elements are written as a start-end tag
+// pair, and the name space declarations and attributes of an element are
+// sorted. In exclusive canonicalization, a name space declaration is only
+// written on the outermost element that uses its prefix, either in its own
+// name or in one of its attributes. In inclusive canonicalization, all the
+// name space declarations in scope are written on the top-level element,
+// and on the elements that change them. Character data and attribute
+// values are escaped as the specification requires.
+//
+// Entity references are expanded by the [Decoder] and attribute values are
+// not normalized, so a document relying on a document type definition for
+// either is not canonicalized correctly.
+type Canonicalizer struct {
+	// WithComments specifies whether comments are written,
+	// as for the "#WithComments" canonicalization method.
+	WithComments bool
+
+	// Inclusive selects Canonical XML 1.0 rather than Exclusive XML
+	// Canonicalization.
+	Inclusive bool
+
+	// InclusivePrefixes lists the prefixes of name spaces that are
+	// written as by inclusive canonicalization, whether or not they are
+	// used, as given by the InclusiveNamespaces PrefixList parameter of
+	// exclusive canonicalization. The prefix "#default" denotes the
+	// default name space. It is ignored if Inclusive is set.
+	InclusivePrefixes []string
+
+	w        *bufio.Writer
+	scope    []nsBinding // in-scope name space declarations, innermost last
+	xmlAttrs []Attr      // xml: attributes of the ancestors of the tokens
+	rendered []nsBinding // name space declarations written by open elements
+	open     []c14nElement
+	seenRoot bool
+	closed   bool
+}
+
+type c14nElement struct {
+	name     Name // raw name, with the prefix in Space
+	scope    int  // length of Canonicalizer.scope before the element
+	rendered int  // length of Canonicalizer.rendered before the element
+}
+
+// NewCanonicalizer returns a new Canonicalizer writing to w.
+func NewCanonicalizer(w io.Writer) *Canonicalizer {
+	return &Canonicalizer{w: bufio.NewWriter(w)}
+}
+
+// DeclareNamespace declares that the name space url is bound to prefix
+// in the context of the tokens, such as by an ancestor of the
+// canonicalized element in a larger document.
+// An empty prefix denotes the default name space.
+// It must be called before the first call to [Canonicalizer.EncodeToken].
+func (c *Canonicalizer) DeclareNamespace(prefix, url string) {
+	c.scope = append(c.scope, nsBinding{prefix, url})
+}
+
+// DeclareXMLAttr declares that the attribute xml:local, such as xml:lang
+// or xml:space, has the given value in the context of the tokens, such as
+// by an ancestor of the canonicalized element in a larger document.
+// If Inclusive is set, the attribute is written on the top-level elements
+// that do not have it, as inclusive canonicalization requires; it is
+// ignored otherwise.
+// It must be called before the first call to [Canonicalizer.EncodeToken].
+func (c *Canonicalizer) DeclareXMLAttr(local, value string) {
+	c.xmlAttrs = slices.DeleteFunc(c.xmlAttrs, func(a Attr) bool { return a.Name.Local == local })
+	c.xmlAttrs = append(c.xmlAttrs, Attr{Name{xmlPrefix, local}, value})
+}
+
+var errCanonicalizerClosed = errors.New("xml: Canonicalizer is closed")
+
+// EncodeToken writes the canonical form of the given token, which must be
+// a token as returned by [Decoder.RawToken]. Character data outside of the
+// top-level element, [Directive] tokens, the XML declaration, and
+// (unless WithComments is set) comments are omitted.
+func (c *Canonicalizer) EncodeToken(t Token) error {
+	if c.closed {
+		return errCanonicalizerClosed
+	}
+	switch t := t.(type) {
+	case StartElement:
+		if err := c.writeStart(&t); err != nil {
+			return err
+		}
+	case EndElement:
+		if len(c.open) == 0 {
+			return fmt.Errorf("xml: end tag </%s> without start tag", qualifiedName(t.Name))
+		}
+		e := c.open[len(c.open)-1]
+		if e.name != t.Name {
+			return fmt.Errorf("xml: end tag </%s> does not match start tag <%s>", qualifiedName(t.Name), qualifiedName(e.name))
+		}
+		c.open = c.open[:len(c.open)-1]
+		c.scope = c.scope[:e.scope]
+		c.rendered = c.rendered[:e.rendered]
+		c.w.WriteString("</")
+		c.w.WriteString(qualifiedName(t.Name))
+		c.w.WriteByte('>')
+	case CharData:
+		if len(c.open) > 0 {
+			escapeC14N(c.w, string(t), false)
+		}
+	case Comment:
+		if c.WithComments {
+			c.writeTopLevel(func() {
+				c.w.WriteString("<!--")
+				c.w.Write(t)
+				c.w.WriteString("-->")
+			})
+		}
+	case ProcInst:
+		if t.Target != "xml" {
+			c.writeTopLevel(func() {
+				c.w.WriteString("<?")
+				c.w.WriteString(t.Target)
+				if len(t.Inst) > 0 {
+					c.w.WriteByte(' ')
+					c.w.Write(t.Inst)
+				}
+				c.w.WriteString("?>")
+			})
+		}
+	case Directive:
+	default:
+		return fmt.Errorf("xml: EncodeToken of invalid token type")
+	}
+	return c.cachedWriteError()
+}
+
+// writeTopLevel calls write, separating a comment or processing instruction
+// outside of the top-level element from it by a newline.
+func (c *Canonicalizer) writeTopLevel(write func()) {
+	switch {
+	case len(c.open) > 0:
+		write()
+	case c.seenRoot:
+		c.w.WriteByte('\n')
+		write()
+	default:
+		write()
+		c.w.WriteByte('\n')
+	}
+}
+
+func (c *Canonicalizer) lookup(bindings []nsBinding, prefix string) (string, bool) {
+	for i := len(bindings) - 1; i >= 0; i-- {
+		if b := bindings[i]; b.prefix == prefix {
+			return b.url, true
+		}
+	}
+	return "", false
+}
+
+func (c *Canonicalizer) writeStart(start *StartElement) error {
+	if start.Name.Local == "" {
+		return fmt.Errorf("xml: start tag with no name")
+	}
+	if len(c.open) == 0 {
+		c.seenRoot = true
+	}
+	c.open = append(c.open, c14nElement{start.Name, len(c.scope), len(c.rendered)})
+
+	var attrs []Attr
+	for _, a := range start.Attr {
+		switch {
+		case a.Name.Space == xmlnsPrefix:
+			c.scope = append(c.scope, nsBinding{a.Name.Local, a.Value})
+		case a.Name.Space == "" && a.Name.Local == xmlnsPrefix:
+			c.scope = append(c.scope, nsBinding{"", a.Value})
+		default:
+			attrs = append(attrs, a)
+		}
+	}
+	if c.Inclusive && len(c.open) == 1 {
+		// Inherit the xml: attributes of the ancestors.
+		for _, a := range c.xmlAttrs {
+			if !slices.ContainsFunc(attrs, func(b Attr) bool { return b.Name == a.Name }) {
+				attrs = append(attrs, a)
+			}
+		}
+	}
+
+	// Determine the name space declarations that are visibly utilized,
+	// or listed in InclusivePrefixes, or, if Inclusive is set, in scope,
+	// and not yet written with the same url.
+	var decls []nsBinding
+	render := func(prefix string, inclusive bool) error {
+		if prefix == xmlPrefix || slices.ContainsFunc(decls, func(b nsBinding) bool { return b.prefix == prefix }) {
+			return nil
+		}
+		url, ok := c.lookup(c.scope, prefix)
+		if !ok && prefix != "" {
+			if inclusive {
+				return nil
+			}
+			return fmt.Errorf("xml: undeclared name space prefix %q", prefix)
+		}
+		if prefix != "" && url == "" {
+			return nil
+		}
+		// The default name space is initially rendered as empty.
+		if r, done := c.lookup(c.rendered, prefix); r == url && (done || prefix == "") {
+			return nil
+		}
+		decls = append(decls, nsBinding{prefix, url})
+		return nil
+	}
+	if err := render(start.Name.Space, false); err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		if a.Name.Space != "" {
+			if err := render(a.Name.Space, false); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Inclusive {
+		for _, b := range c.scope {
+			if err := render(b.prefix, true); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, prefix := range c.InclusivePrefixes {
+			if prefix == "#default" {
+				prefix = ""
+			}
+			if err := render(prefix, true); err != nil {
+				return err
+			}
+		}
+	}
+	c.rendered = append(c.rendered, decls...)
+	slices.SortFunc(decls, func(a, b nsBinding) int { return strings.Compare(a.prefix, b.prefix) })
+
+	// Sort the attributes by name space url and then local name.
+	type qattr struct {
+		url  string
+		attr Attr
+	}
+	qattrs := make([]qattr, len(attrs))
+	for i, a := range attrs {
+		qattrs[i].attr = a
+		switch a.Name.Space {
+		case "":
+		case xmlPrefix:
+			qattrs[i].url = xmlURL
+		default:
+			qattrs[i].url, _ = c.lookup(c.scope, a.Name.Space)
+		}
+	}
+	slices.SortFunc(qattrs, func(a, b qattr) int {
+		return cmp.Or(strings.Compare(a.url, b.url), strings.Compare(a.attr.Name.Local, b.attr.Name.Local))
+	})
+
+	c.w.WriteByte('<')
+	c.w.WriteString(qualifiedName(start.Name))
+	for _, b := range decls {
+		c.w.WriteString(" xmlns")
+		if b.prefix != "" {
+			c.w.WriteByte(':')
+			c.w.WriteString(b.prefix)
+		}
+		c.w.WriteString(`="`)
+		escapeC14N(c.w, b.url, true)
+		c.w.WriteByte('"')
+	}
+	for _, a := range qattrs {
+		c.w.WriteByte(' ')
+		c.w.WriteString(qualifiedName(a.attr.Name))
+		c.w.WriteString(`="`)
+		escapeC14N(c.w, a.attr.Value, true)
+		c.w.WriteByte('"')
+	}
+	c.w.WriteByte('>')
+	return nil
+}
+
+// Flush flushes any buffered output to the underlying writer.
+func (c *Canonicalizer) Flush() error {
+	return c.w.Flush()
+}
+
+// Close flushes any buffered output to the underlying writer and returns
+// an error if an element is left unclosed. No more tokens may be written.
+func (c *Canonicalizer) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	if len(c.open) > 0 {
+		return fmt.Errorf("xml: unclosed tag <%s>", qualifiedName(c.open[len(c.open)-1].name))
+	}
+	return nil
+}
+
+func (c *Canonicalizer) cachedWriteError() error {
+	_, err := c.w.Write(nil)
+	return err
+}
+
+// qualifiedName returns the name as written in the document,
+// for a name whose Space field holds a prefix.
+func qualifiedName(n Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// escapeC14N writes s to w with the escaping required for character data
+// or, if attr is set, attribute values in canonical XML.
+func escapeC14N(w *bufio.Writer, s string, attr bool) {
+	last := 0
+	for i := 0; i < len(s); i++ {
+		var esc string
+		switch s[i] {
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			if attr {
+				continue
+			}
+			esc = "&gt;"
+		case '"':
+			if !attr {
+				continue
+			}
+			esc = "&quot;"
+		case '\t':
+			if !attr {
+				continue
+			}
+			esc = "&#x9;"
+		case '\n':
+			if !attr {
+				continue
+			}
+			esc = "&#xA;"
+		case '\r':
+			esc = "&#xD;"
+		default:
+			continue
+		}
+		w.WriteString(s[last:i])
+		w.WriteString(esc)
+		last = i + 1
+	}
+	w.WriteString(s[last:])
+}
@@ -0,0 +1,234 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xml
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func canonicalize(t *testing.T, c *Canonicalizer, b *strings.Builder, in string) string {
+	t.Helper()
+	d := NewDecoder(strings.NewReader(in))
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.EncodeToken(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+var c14nTests = []struct {
+	name      string
+	in        string
+	comments  bool
+	c14n10    bool // Inclusive
+	inclusive []string
+	want      string
+}{{
+	// Canonical XML 1.0, section 3.1.
+	name: "PIs and comments",
+	in: `<?xml version="1.0"?>
+
+<?xml-stylesheet   href="doc.xsl"
+   type="text/xsl"   ?>
+
+<!DOCTYPE doc SYSTEM "doc.dtd">
+
+<doc>Hello, world!<!-- Comment 1 --></doc>
+
+<?pi-without-data     ?>
+
+<!-- Comment 2 -->
+
+<!-- Comment 3 -->
+`,
+	comments: true,
+	want: `<?xml-stylesheet href="doc.xsl"
+   type="text/xsl"   ?>
+<doc>Hello, world!<!-- Comment 1 --></doc>
+<?pi-without-data?>
+<!-- Comment 2 -->
+<!-- Comment 3 -->`,
+}, {
+	name: "without comments",
+	in:   "<!-- c --><doc><!-- c -->x</doc><!-- c -->",
+	want: "<doc>x</doc>",
+}, {
+	// Canonical XML 1.0, section 3.3, without the document type declaration,
+	// and with the name space declarations rendered exclusively.
+	name: "start and end tags",
+	in: `<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+	want: `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6>
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+}, {
+	// Canonical XML 1.0, section 3.3, without the document type declaration.
+	name: "start and end tags inclusive",
+	in: `<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+	c14n10: true,
+	want: `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6 xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9 xmlns:a="http://www.ietf.org"></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+}, {
+	name: "escaping",
+	in:   "<doc attr='&lt;&amp;\"&#9;&#10;&#13;>'>&lt;&amp;&gt;\"'&#13;\n</doc>",
+	want: "<doc attr=\"&lt;&amp;&quot;&#x9;&#xA;&#xD;>\">&lt;&amp;&gt;\"'&#xD;\n</doc>",
+}, {
+	// Exclusive XML Canonicalization, section 2.2.
+	name: "visibly utilized",
+	in: `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org">` +
+		`<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/></n1:elem2>` +
+		`</n0:local>`,
+	want: `<n0:local xmlns:n0="foo:bar">` +
+		`<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff></n1:elem2>` +
+		`</n0:local>`,
+}, {
+	name:      "inclusive prefixes",
+	in:        `<a xmlns="urn:d" xmlns:p="urn:p" xmlns:q="urn:q"><b xmlns:p="urn:p2"/></a>`,
+	inclusive: []string{"#default", "p", "r"},
+	want:      `<a xmlns="urn:d" xmlns:p="urn:p"><b xmlns:p="urn:p2"></b></a>`,
+}, {
+	name:      "inclusive ignores prefixes",
+	in:        `<a xmlns="urn:d" xmlns:p="urn:p" xmlns:q="urn:q"><b xmlns:p="urn:p2" xmlns:q="urn:q"/></a>`,
+	c14n10:    true,
+	inclusive: []string{"p"},
+	want:      `<a xmlns="urn:d" xmlns:p="urn:p" xmlns:q="urn:q"><b xmlns:p="urn:p2"></b></a>`,
+}}
+
+func TestCanonicalizer(t *testing.T) {
+	for _, tt := range c14nTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			c := NewCanonicalizer(&b)
+			c.WithComments = tt.comments
+			c.Inclusive = tt.c14n10
+			c.InclusivePrefixes = tt.inclusive
+			if got := canonicalize(t, c, &b, tt.in); got != tt.want {
+				t.Errorf("canonical form:\ngot  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizerSubtree(t *testing.T) {
+	// Exclusive XML Canonicalization, section 2.2: the canonical form of
+	// the n1:elem2 element does not depend on its ancestors.
+	var b strings.Builder
+	c := NewCanonicalizer(&b)
+	c.DeclareNamespace("n0", "foo:bar")
+	c.DeclareNamespace("n3", "ftp://example.org")
+	c.DeclareXMLAttr("space", "preserve") // not inherited
+	const in = `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+     <n3:stuff/>
+  </n1:elem2>`
+	const want = `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+     <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+  </n1:elem2>`
+	if got := canonicalize(t, c, &b, in); got != want {
+		t.Errorf("canonical form:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestCanonicalizerInclusiveSubtree(t *testing.T) {
+	// Exclusive XML Canonicalization, section 2.2: the inclusive canonical
+	// form of the n1:elem2 element includes the name space declarations of
+	// its ancestors, and their xml: attributes that it does not override.
+	var b strings.Builder
+	c := NewCanonicalizer(&b)
+	c.Inclusive = true
+	c.DeclareNamespace("n0", "foo:bar")
+	c.DeclareNamespace("n3", "ftp://example.org")
+	c.DeclareXMLAttr("lang", "fr")
+	c.DeclareXMLAttr("space", "preserve")
+	const in = `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+     <n3:stuff/>
+  </n1:elem2>`
+	const want = `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:lang="en" xml:space="preserve">
+     <n3:stuff></n3:stuff>
+  </n1:elem2>`
+	if got := canonicalize(t, c, &b, in); got != want {
+		t.Errorf("canonical form:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestCanonicalizerErrors(t *testing.T) {
+	c := NewCanonicalizer(io.Discard)
+	if err := c.EncodeToken(StartElement{Name: Name{"p", "a"}}); err == nil || !strings.Contains(err.Error(), "undeclared") {
+		t.Errorf("undeclared prefix: error = %v", err)
+	}
+	c = NewCanonicalizer(io.Discard)
+	c.EncodeToken(StartElement{Name: Name{Local: "a"}})
+	if err := c.EncodeToken(EndElement{Name{Local: "b"}}); err == nil {
+		t.Error("mismatched end tag succeeded")
+	}
+	if err := c.Close(); err == nil {
+		t.Error("Close with unclosed element succeeded")
+	}
+	if err := c.EncodeToken(CharData("x")); err == nil {
+		t.Error("EncodeToken after Close succeeded")
+	}
+}
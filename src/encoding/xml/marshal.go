@@ -162,6 +162,37 @@ func (enc *Encoder) Indent(prefix, indent string) {
 	enc.p.indent = indent
 }
 
+// PreservePrefixes sets the encoder to write name spaces using prefixes
+// declared once per scope, rather than repeating an xmlns attribute
+// on every element.
+//
+// In this mode, an xmlns or xmlns:prefix attribute of a [StartElement] is
+// written as a name space declaration, and the element and its attributes
+// refer to an in-scope name space using its declared prefix. Thus, the
+// tokens returned by [Decoder.Token] are re-encoded with their original
+// prefixes. An element in a name space that has no prefix in scope
+// declares it as the default name space, and an attribute in such
+// a name space declares a new prefix.
+func (enc *Encoder) PreservePrefixes() {
+	enc.p.nsMode = true
+}
+
+// DeclareNamespace sets the encoder to declare the name space url with the
+// given prefix on each top-level element, so that the elements and
+// attributes nested within it can refer to it without further declarations.
+// An empty prefix declares the default name space.
+// DeclareNamespace implies [Encoder.PreservePrefixes].
+func (enc *Encoder) DeclareNamespace(prefix, url string) {
+	enc.p.nsMode = true
+	for i, b := range enc.p.rootNS {
+		if b.prefix == prefix {
+			enc.p.rootNS[i].url = url
+			return
+		}
+	}
+	enc.p.rootNS = append(enc.p.rootNS, nsBinding{prefix, url})
+}
+
 // Encode writes the XML encoding of v to the stream.
 //
 // See the documentation for [Marshal] for details about the conversion
@@ -332,6 +363,18 @@ type printer struct {
 	tags       []Name
 	closed     bool
 	err        error
+
+	// The following fields are only used by PreservePrefixes.
+	nsMode bool
+	rootNS []nsBinding // name spaces declared on top-level elements
+	scope  []nsBinding // in-scope name space declarations, innermost last
+	marks  []int       // length of scope when each open element started
+	qnames []string    // qualified names of open elements
+}
+
+// A nsBinding binds a name space prefix to a name space url.
+type nsBinding struct {
+	prefix, url string
 }
 
 // createAttrPrefix finds the name space prefix attribute to use for the given name space,
@@ -734,6 +777,10 @@ func (p *printer) writeStart(start *StartElement) error {
 		return fmt.Errorf("xml: start tag with no name")
 	}
 
+	if p.nsMode {
+		return p.writeStartPrefixed(start)
+	}
+
 	p.tags = append(p.tags, start.Name)
 	p.markPrefix()
 
@@ -785,12 +832,174 @@ func (p *printer) writeEnd(name Name) error {
 	p.writeIndent(-1)
 	p.WriteByte('<')
 	p.WriteByte('/')
-	p.WriteString(name.Local)
+	if p.nsMode {
+		p.WriteString(p.qnames[len(p.qnames)-1])
+		p.qnames = p.qnames[:len(p.qnames)-1]
+		p.scope = p.scope[:p.marks[len(p.marks)-1]]
+		p.marks = p.marks[:len(p.marks)-1]
+	} else {
+		p.WriteString(name.Local)
+	}
 	p.WriteByte('>')
 	p.popPrefix()
 	return nil
 }
 
+// lookupNS returns the name space url bound to prefix in the current scope.
+func (p *printer) lookupNS(prefix string) (string, bool) {
+	for i := len(p.scope) - 1; i >= 0; i-- {
+		if b := p.scope[i]; b.prefix == prefix {
+			return b.url, true
+		}
+	}
+	return "", false
+}
+
+// lookupPrefix returns a non-empty prefix bound to url in the current scope.
+func (p *printer) lookupPrefix(url string) (string, bool) {
+	for i := len(p.scope) - 1; i >= 0; i-- {
+		b := p.scope[i]
+		if b.prefix == "" || b.url != url {
+			continue
+		}
+		if u, _ := p.lookupNS(b.prefix); u == url {
+			return b.prefix, true
+		}
+	}
+	return "", false
+}
+
+// newPrefix picks a prefix for url that is not bound in the current scope.
+func (p *printer) newPrefix(url string) string {
+	prefix := strings.TrimRight(url, "/")
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		prefix = prefix[i+1:]
+	}
+	if prefix == "" || !isName([]byte(prefix)) || strings.Contains(prefix, ":") {
+		prefix = "_"
+	}
+	if len(prefix) >= 3 && strings.EqualFold(prefix[:3], "xml") {
+		prefix = "_" + prefix
+	}
+	if _, ok := p.lookupNS(prefix); ok {
+		for p.seq++; ; p.seq++ {
+			if id := prefix + "_" + strconv.Itoa(p.seq); !p.isBound(id) {
+				prefix = id
+				break
+			}
+		}
+	}
+	return prefix
+}
+
+func (p *printer) isBound(prefix string) bool {
+	_, ok := p.lookupNS(prefix)
+	return ok
+}
+
+// writeStartPrefixed writes the given start element for PreservePrefixes.
+func (p *printer) writeStartPrefixed(start *StartElement) error {
+	root := len(p.tags) == 0
+	mark := len(p.scope)
+	p.marks = append(p.marks, mark)
+
+	// Collect the name space declarations of the element,
+	// which take precedence over those made by DeclareNamespace.
+	var decls []nsBinding
+	declare := func(prefix, url string) {
+		for i, b := range decls {
+			if b.prefix == prefix {
+				decls[i].url = url
+				p.scope[mark+i].url = url
+				return
+			}
+		}
+		decls = append(decls, nsBinding{prefix, url})
+		p.scope = append(p.scope, nsBinding{prefix, url})
+	}
+	if root {
+		for _, b := range p.rootNS {
+			declare(b.prefix, b.url)
+		}
+	}
+	explicitDefault := false
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == xmlnsPrefix && attr.Name.Local != "":
+			declare(attr.Name.Local, attr.Value)
+		case attr.Name.Space == "" && attr.Name.Local == xmlnsPrefix:
+			declare("", attr.Value)
+			explicitDefault = true
+		}
+	}
+
+	qname := start.Name.Local
+	if def, _ := p.lookupNS(""); start.Name.Space != def {
+		if prefix, ok := p.lookupPrefix(start.Name.Space); ok && start.Name.Space != "" {
+			qname = prefix + ":" + qname
+		} else if !explicitDefault {
+			declare("", start.Name.Space)
+		} else if start.Name.Space != "" {
+			prefix := p.newPrefix(start.Name.Space)
+			declare(prefix, start.Name.Space)
+			qname = prefix + ":" + qname
+		} else {
+			// Leave the printer as it was before the element.
+			p.scope = p.scope[:mark]
+			p.marks = p.marks[:len(p.marks)-1]
+			return fmt.Errorf("xml: start tag <%s> with no name space declares default name space %s", start.Name.Local, def)
+		}
+	}
+	p.tags = append(p.tags, start.Name)
+	p.markPrefix()
+	p.qnames = append(p.qnames, qname)
+
+	// Qualify the attribute names, declaring prefixes where needed.
+	var attrs []Attr
+	for _, attr := range start.Attr {
+		name := attr.Name
+		switch {
+		case name.Local == "",
+			name.Space == xmlnsPrefix,
+			name.Space == "" && name.Local == xmlnsPrefix:
+			continue
+		case name.Space == xmlURL:
+			name.Local = xmlPrefix + ":" + name.Local
+		case name.Space != "":
+			prefix, ok := p.lookupPrefix(name.Space)
+			if !ok {
+				prefix = p.newPrefix(name.Space)
+				declare(prefix, name.Space)
+			}
+			name.Local = prefix + ":" + name.Local
+		}
+		attrs = append(attrs, Attr{Name{Local: name.Local}, attr.Value})
+	}
+
+	p.writeIndent(1)
+	p.WriteByte('<')
+	p.WriteString(qname)
+	for _, b := range decls {
+		p.WriteString(" xmlns")
+		if b.prefix != "" {
+			p.WriteByte(':')
+			p.WriteString(b.prefix)
+		}
+		p.WriteString(`="`)
+		p.EscapeString(b.url)
+		p.WriteByte('"')
+	}
+	for _, attr := range attrs {
+		p.WriteByte(' ')
+		p.WriteString(attr.Name.Local)
+		p.WriteString(`="`)
+		p.EscapeString(attr.Value)
+		p.WriteByte('"')
+	}
+	p.WriteByte('>')
+	return nil
+}
+
 func (p *printer) marshalSimple(typ reflect.Type, val reflect.Value) (string, []byte, error) {
 	switch val.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
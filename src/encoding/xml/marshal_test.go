@@ -2588,3 +2588,99 @@ func TestClose(t *testing.T) {
 		})
 	}
 }
+
+func TestPreservePrefixes(t *testing.T) {
+	const in = `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:m="urn:example:m">` +
+		`<soap:Body><m:GetPrice m:currency="EUR" xml:lang="en"><m:Item>Apples</m:Item><Note xmlns="urn:example:n">fresh</Note></m:GetPrice></soap:Body>` +
+		`</soap:Envelope>`
+	var b strings.Builder
+	d := NewDecoder(strings.NewReader(in))
+	enc := NewEncoder(&b)
+	enc.PreservePrefixes()
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != in {
+		t.Errorf("round trip:\ngot  %s\nwant %s", b.String(), in)
+	}
+}
+
+func TestPreservePrefixesError(t *testing.T) {
+	var b strings.Builder
+	enc := NewEncoder(&b)
+	enc.PreservePrefixes()
+	a := StartElement{Name{"urn:example:a", "a"}, []Attr{{Name{"", "xmlns"}, "urn:example:a"}}}
+	if err := enc.EncodeToken(a); err != nil {
+		t.Fatal(err)
+	}
+	// An element with no name space cannot declare a default name space.
+	bad := StartElement{Name{"", "b"}, []Attr{{Name{"", "xmlns"}, "urn:example:b"}}}
+	if err := enc.EncodeToken(bad); err == nil {
+		t.Fatal("EncodeToken succeeded, want error")
+	}
+	// The failed start tag is not open.
+	if err := enc.EncodeToken(bad.End()); err == nil {
+		t.Fatal("EncodeToken of the end of the failed element succeeded, want error")
+	}
+	if err := enc.EncodeToken(a.End()); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<a xmlns="urn:example:a"></a>`; b.String() != want {
+		t.Errorf("got %s, want %s", b.String(), want)
+	}
+}
+
+func TestEncoderDeclareNamespace(t *testing.T) {
+	type Item struct {
+		XMLName Name   `xml:"urn:example:m Item"`
+		Code    string `xml:"urn:example:a code,attr"`
+		Name    string `xml:"urn:example:m name"`
+		Other   string `xml:"urn:example:o other"`
+	}
+	var b strings.Builder
+	enc := NewEncoder(&b)
+	enc.DeclareNamespace("m", "urn:example:m")
+	enc.DeclareNamespace("a", "urn:example:a")
+	if err := enc.Encode([]Item{{Code: "x", Name: "one", Other: "o"}, {Name: "two"}}); err != nil {
+		t.Fatal(err)
+	}
+	const want = `<m:Item xmlns:m="urn:example:m" xmlns:a="urn:example:a" a:code="x"><m:name>one</m:name><other xmlns="urn:example:o">o</other></m:Item>` +
+		`<m:Item xmlns:m="urn:example:m" xmlns:a="urn:example:a" a:code=""><m:name>two</m:name><other xmlns="urn:example:o"></other></m:Item>`
+	if b.String() != want {
+		t.Errorf("Encode:\ngot  %s\nwant %s", b.String(), want)
+	}
+
+	// An attribute in a name space without a prefix in scope declares one.
+	b.Reset()
+	enc = NewEncoder(&b)
+	enc.PreservePrefixes()
+	start := StartElement{Name{"urn:example:m", "e"}, []Attr{{Name{"http://example.com/ns/b/", "x"}, "1"}}}
+	if err := enc.EncodeToken(start); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncodeToken(start.End()); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	const want2 = `<e xmlns="urn:example:m" xmlns:b="http://example.com/ns/b/" b:x="1"></e>`
+	if b.String() != want2 {
+		t.Errorf("EncodeToken:\ngot  %s\nwant %s", b.String(), want2)
+	}
+}
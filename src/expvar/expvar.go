@@ -9,6 +9,14 @@
 //
 // Operations to set or modify these public variables are atomic.
 //
+// The [Counter], [Gauge] and [Histogram] variables are typed metrics,
+// optionally partitioned by labels. [MetricsHandler] serves them, with the
+// other numeric variables and the metrics of package [runtime/metrics], in
+// the OpenMetrics text format understood by Prometheus. In the JSON form of
+// a metric with labels, each series is keyed by its label pairs, such as
+// "method=GET,code=200", with backslashes before the backslashes, commas
+// and equal signs in the label values.
+//
 // In addition to adding the HTTP handler, this package registers the
 // following variables:
 //
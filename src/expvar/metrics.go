@@ -0,0 +1,372 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expvar
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing float64 metric, optionally
+// partitioned by labels, that satisfies the [Var] interface.
+//
+// A Counter created with label names holds one series for each
+// combination of label values, which [Counter.With] returns. It must not
+// be changed itself.
+type Counter struct {
+	f    Float
+	help string
+	l    *labeled[Counter] // nil for counters without labels and series
+}
+
+// NewCounter creates and publishes a [Counter] with the given name, help
+// text and label names. It panics if the name or a label name is not a
+// valid metric or label name.
+func NewCounter(name, help string, labels ...string) *Counter {
+	checkMetricName(name, labels)
+	v := &Counter{help: help}
+	if len(labels) > 0 {
+		v.l = newLabeled[Counter](labels)
+	}
+	Publish(name, v)
+	return v
+}
+
+// With returns the series of v with the given label values, one for each
+// label name of v, creating it if needed.
+func (v *Counter) With(values ...string) *Counter {
+	return v.l.with(values, func() *Counter { return new(Counter) })
+}
+
+// Value returns the value of v.
+func (v *Counter) Value() float64 {
+	return v.f.Value()
+}
+
+// Add adds delta, which must not be negative, to v.
+func (v *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("expvar: negative Counter delta")
+	}
+	v.l.checkUnlabeled()
+	v.f.Add(delta)
+}
+
+// Inc adds 1 to v.
+func (v *Counter) Inc() {
+	v.Add(1)
+}
+
+func (v *Counter) String() string {
+	return string(v.appendJSON(nil))
+}
+
+func (v *Counter) appendJSON(b []byte) []byte {
+	if v.l == nil {
+		return v.f.appendJSON(b)
+	}
+	return v.l.appendJSON(b, (*Counter).appendJSON)
+}
+
+// Gauge is a float64 metric that can go up and down, optionally
+// partitioned by labels, that satisfies the [Var] interface.
+//
+// A Gauge created with label names holds one series for each combination
+// of label values, which [Gauge.With] returns. It must not be changed
+// itself.
+type Gauge struct {
+	f    Float
+	help string
+	l    *labeled[Gauge]
+}
+
+// NewGauge creates and publishes a [Gauge] with the given name, help text
+// and label names. It panics if the name or a label name is not a valid
+// metric or label name.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	checkMetricName(name, labels)
+	v := &Gauge{help: help}
+	if len(labels) > 0 {
+		v.l = newLabeled[Gauge](labels)
+	}
+	Publish(name, v)
+	return v
+}
+
+// With returns the series of v with the given label values, one for each
+// label name of v, creating it if needed.
+func (v *Gauge) With(values ...string) *Gauge {
+	return v.l.with(values, func() *Gauge { return new(Gauge) })
+}
+
+// Value returns the value of v.
+func (v *Gauge) Value() float64 {
+	return v.f.Value()
+}
+
+// Set sets v to value.
+func (v *Gauge) Set(value float64) {
+	v.l.checkUnlabeled()
+	v.f.Set(value)
+}
+
+// Add adds delta to v.
+func (v *Gauge) Add(delta float64) {
+	v.l.checkUnlabeled()
+	v.f.Add(delta)
+}
+
+func (v *Gauge) String() string {
+	return string(v.appendJSON(nil))
+}
+
+func (v *Gauge) appendJSON(b []byte) []byte {
+	if v.l == nil {
+		return v.f.appendJSON(b)
+	}
+	return v.l.appendJSON(b, (*Gauge).appendJSON)
+}
+
+// Histogram is a metric that counts observed float64 values in buckets,
+// optionally partitioned by labels, that satisfies the [Var] interface.
+//
+// A Histogram created with label names holds one series for each
+// combination of label values, which [Histogram.With] returns. It must not
+// be observed itself.
+type Histogram struct {
+	help   string
+	bounds []float64       // the upper bounds of the buckets, except +Inf
+	counts []atomic.Uint64 // the counts of the buckets, +Inf last
+	sum    Float
+	l      *labeled[Histogram]
+}
+
+// NewHistogram creates and publishes a [Histogram] with the given name,
+// help text, bucket upper bounds and label names. A bucket for +Inf is
+// added if buckets does not end with one. It panics if the buckets are not
+// in increasing order, or if the name or a label name is not a valid
+// metric or label name.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	checkMetricName(name, labels)
+	if slices.Contains(labels, "le") {
+		panic("expvar: invalid Histogram label name le")
+	}
+	if len(buckets) > 0 && math.IsInf(buckets[len(buckets)-1], +1) {
+		buckets = buckets[:len(buckets)-1]
+	}
+	for i, b := range buckets {
+		if math.IsNaN(b) || i > 0 && b <= buckets[i-1] {
+			panic("expvar: Histogram buckets not in increasing order")
+		}
+	}
+	v := newHistogram(help, slices.Clone(buckets))
+	if len(labels) > 0 {
+		v.l = newLabeled[Histogram](labels)
+	}
+	Publish(name, v)
+	return v
+}
+
+func newHistogram(help string, bounds []float64) *Histogram {
+	return &Histogram{
+		help:   help,
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// With returns the series of v with the given label values, one for each
+// label name of v, creating it if needed.
+func (v *Histogram) With(values ...string) *Histogram {
+	return v.l.with(values, func() *Histogram { return newHistogram("", v.bounds) })
+}
+
+// Observe adds value to v.
+func (v *Histogram) Observe(value float64) {
+	v.l.checkUnlabeled()
+	v.counts[sort.SearchFloat64s(v.bounds, value)].Add(1)
+	v.sum.Add(value)
+}
+
+// buckets calls f with the upper bound and cumulative count of each bucket
+// of v, and returns the total count.
+func (v *Histogram) buckets(f func(le float64, count uint64)) uint64 {
+	var n uint64
+	for i := range v.counts {
+		n += v.counts[i].Load()
+		le := math.Inf(+1)
+		if i < len(v.bounds) {
+			le = v.bounds[i]
+		}
+		f(le, n)
+	}
+	return n
+}
+
+func (v *Histogram) String() string {
+	return string(v.appendJSON(nil))
+}
+
+func (v *Histogram) appendJSON(b []byte) []byte {
+	if v.l != nil {
+		return v.l.appendJSON(b, (*Histogram).appendJSON)
+	}
+	b = append(b, `{"buckets": {`...)
+	n := v.buckets(func(le float64, count uint64) {
+		b = appendJSONQuote(b, formatBound(le))
+		b = append(b, ": "...)
+		b = strconv.AppendUint(b, count, 10)
+		b = append(b, ", "...)
+	})
+	b = b[:len(b)-2]
+	b = append(b, `}, "count": `...)
+	b = strconv.AppendUint(b, n, 10)
+	b = append(b, `, "sum": `...)
+	b = v.sum.appendJSON(b)
+	return append(b, '}')
+}
+
+// labeled holds the series of a metric with labels.
+type labeled[T any] struct {
+	names []string
+
+	mu     sync.RWMutex
+	series map[string]*series[T] // keyed by seriesKey of the label values
+	keys   []string              // sorted
+}
+
+type series[T any] struct {
+	values []string
+	v      *T
+}
+
+func newLabeled[T any](names []string) *labeled[T] {
+	return &labeled[T]{names: slices.Clone(names), series: make(map[string]*series[T])}
+}
+
+func (l *labeled[T]) with(values []string, newT func() *T) *T {
+	if l == nil {
+		panic("expvar: With on a metric without labels")
+	}
+	if len(values) != len(l.names) {
+		panic(fmt.Sprintf("expvar: With got %d label values, want %d", len(values), len(l.names)))
+	}
+	key := seriesKey(values)
+	l.mu.RLock()
+	s := l.series[key]
+	l.mu.RUnlock()
+	if s != nil {
+		return s.v
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s := l.series[key]; s != nil {
+		return s.v
+	}
+	s = &series[T]{slices.Clone(values), newT()}
+	l.series[key] = s
+	i, _ := slices.BinarySearch(l.keys, key)
+	l.keys = slices.Insert(l.keys, i, key)
+	return s.v
+}
+
+// seriesKey returns the key of the series with the given label values: the
+// values separated by two NUL bytes, with each NUL byte in them followed by
+// a 1 byte. Unlike a plain join, it tells all lists of values apart, and
+// the keys sort as the lists of values do.
+func seriesKey(values []string) string {
+	var b strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			b.WriteString("\x00\x00")
+		}
+		b.WriteString(strings.ReplaceAll(v, "\x00", "\x00\x01"))
+	}
+	return b.String()
+}
+
+// checkUnlabeled panics if l holds the series of the metric being changed.
+func (l *labeled[T]) checkUnlabeled() {
+	if l != nil {
+		panic("expvar: metric with labels changed without With")
+	}
+}
+
+// do calls f for each series, in the order of their label values.
+func (l *labeled[T]) do(f func(values []string, v *T)) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, k := range l.keys {
+		s := l.series[k]
+		f(s.values, s.v)
+	}
+}
+
+// appendJSON appends the series as a JSON object whose keys are the label
+// pairs of the series, such as "method=GET,code=200". The backslashes,
+// commas and equal signs in the label values are escaped with backslashes,
+// so that distinct series have distinct keys.
+func (l *labeled[T]) appendJSON(b []byte, appendValue func(*T, []byte) []byte) []byte {
+	b = append(b, '{')
+	first := true
+	l.do(func(values []string, v *T) {
+		if !first {
+			b = append(b, ", "...)
+		}
+		first = false
+		var key strings.Builder
+		for i, name := range l.names {
+			if i > 0 {
+				key.WriteByte(',')
+			}
+			key.WriteString(name)
+			key.WriteByte('=')
+			for _, c := range []byte(values[i]) {
+				if c == '\\' || c == ',' || c == '=' {
+					key.WriteByte('\\')
+				}
+				key.WriteByte(c)
+			}
+		}
+		b = appendJSONQuote(b, key.String())
+		b = append(b, ": "...)
+		b = appendValue(v, b)
+	})
+	return append(b, '}')
+}
+
+// checkMetricName panics if name is not a valid metric name or a label
+// name is not a valid label name.
+func checkMetricName(name string, labels []string) {
+	if !validName(name, true) {
+		panic("expvar: invalid metric name " + strconv.Quote(name))
+	}
+	for _, l := range labels {
+		if !validName(l, false) || strings.HasPrefix(l, "__") {
+			panic("expvar: invalid label name " + strconv.Quote(l))
+		}
+	}
+}
+
+// validName reports whether s matches [a-zA-Z_][a-zA-Z0-9_]*, with colons
+// allowed too if colon is set.
+func validName(s string, colon bool) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || colon && c == ':' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
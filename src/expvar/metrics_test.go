@@ -0,0 +1,252 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expvar
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	RemoveAll()
+	c := NewCounter("requests", "Requests served.")
+	c.Inc()
+	c.Add(1.5)
+	if got := c.Value(); got != 2.5 {
+		t.Errorf("Value() = %v, want 2.5", got)
+	}
+	if got := Get("requests").String(); got != "2.5" {
+		t.Errorf("String() = %s, want 2.5", got)
+	}
+
+	lc := NewCounter("labeled", "", "method", "code")
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			lc.With("GET", "200").Inc()
+		})
+	}
+	wg.Wait()
+	lc.With("POST", "500").Add(2)
+	if got := lc.With("GET", "200").Value(); got != 10 {
+		t.Errorf("With(GET, 200).Value() = %v, want 10", got)
+	}
+	const want = `{"method=GET,code=200": 10, "method=POST,code=500": 2}`
+	if got := lc.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	var m map[string]float64
+	if err := json.Unmarshal([]byte(lc.String()), &m); err != nil {
+		t.Errorf("String() is not valid JSON: %v", err)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	RemoveAll()
+	g := NewGauge("temperature", "", "room")
+	g.With("kitchen").Set(21)
+	g.With("kitchen").Add(-1.5)
+	if got := g.With("kitchen").Value(); got != 19.5 {
+		t.Errorf("Value() = %v, want 19.5", got)
+	}
+	if got, want := g.String(), `{"room=kitchen": 19.5}`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	RemoveAll()
+	h := NewHistogram("latency", "", []float64{0.1, 1, math.Inf(+1)})
+	for _, v := range []float64{0.05, 0.1, 0.5, 2} {
+		h.Observe(v)
+	}
+	const want = `{"buckets": {"0.1": 2, "1.0": 3, "+Inf": 4}, "count": 4, "sum": 2.65}`
+	if got := h.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(h.String()), &m); err != nil {
+		t.Errorf("String() is not valid JSON: %v", err)
+	}
+}
+
+func TestLabelCollisions(t *testing.T) {
+	RemoveAll()
+	j := NewCounter("joined", "", "x", "y")
+	if j.With("a\xffb", "c") == j.With("a", "b\xffc") {
+		t.Error("With returned the same series for distinct label values")
+	}
+	c := NewCounter("escaped", "", "x", "y")
+	for i, values := range [][]string{
+		{"a,y=b", "c"},
+		{"a", "b,y=c"},
+		{"a\x00", ""},
+		{"a", "\x00"},
+		{`a\`, ""},
+	} {
+		c.With(values...).Add(float64(i + 1))
+	}
+	const want = `{"x=a,y=\u0000": 4, "x=a,y=b\\,y\\=c": 2, "x=a\u0000,y=": 3, "x=a\\,y\\=b,y=c": 1, "x=a\\\\,y=": 5}`
+	if got := c.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestMetricPanics(t *testing.T) {
+	RemoveAll()
+	for _, tt := range []struct {
+		name string
+		f    func()
+		want string
+	}{
+		{"metric name", func() { NewCounter("a-b", "") }, "invalid metric name"},
+		{"label name", func() { NewGauge("g", "", "1x") }, "invalid label name"},
+		{"reserved label name", func() { NewGauge("g", "", "__x") }, "invalid label name"},
+		{"le", func() { NewHistogram("h", "", nil, "le") }, "invalid Histogram label name"},
+		{"buckets", func() { NewHistogram("h", "", []float64{1, 1}) }, "not in increasing order"},
+		{"negative", func() { new(Counter).Add(-1) }, "negative"},
+		{"label count", func() { NewCounter("c1", "", "a").With("x", "y") }, "got 2 label values, want 1"},
+		{"no labels", func() { NewCounter("c2", "").With("x") }, "without labels"},
+		{"unlabeled", func() { NewGauge("g2", "", "a").Set(1) }, "without With"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), tt.want) {
+					t.Errorf("got panic %v, want %q", r, tt.want)
+				}
+			}()
+			tt.f()
+		})
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	RemoveAll()
+	c := NewCounter("http_requests", "HTTP requests\nserved.", "method")
+	c.With(`GE"T`).Inc()
+	NewCounter("bytes_total", "").Add(2)
+	NewGauge("up", "").Set(1)
+	h := NewHistogram("latency_seconds", "", []float64{0.5}, "op")
+	h.With("read").Observe(0.25)
+	h.With("read").Observe(1)
+	NewInt("hits").Add(3)
+	m := NewMap("map")
+	m.Add("a", 1)
+	m.AddFloat("b", 0.5)
+	m.Set("s", new(String))
+	NewString("name").Set("x")
+
+	rr := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/metrics", nil))
+	if got, want := rr.Header().Get("Content-Type"), "application/openmetrics-text; version=1.0.0; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	out := rr.Body.String()
+	const want = `# TYPE bytes counter
+bytes_total 2
+# TYPE hits unknown
+hits 3
+# TYPE http_requests counter
+# HELP http_requests HTTP requests\nserved.
+http_requests_total{method="GE\"T"} 1
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="read",le="0.5"} 1
+latency_seconds_bucket{op="read",le="+Inf"} 2
+latency_seconds_count{op="read"} 2
+latency_seconds_sum{op="read"} 1.25
+# TYPE map unknown
+map{key="a"} 1
+map{key="b"} 0.5
+# TYPE up gauge
+up 1
+`
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("got\n%s\nwant prefix\n%s", out, want)
+	}
+	if !strings.HasSuffix(out, "\n# EOF\n") {
+		t.Error("output does not end with # EOF")
+	}
+
+	// Check the runtime metrics.
+	families := map[string]string{}
+	var lastBucket float64
+	for line := range strings.Lines(strings.TrimPrefix(out, want)) {
+		line = strings.TrimSuffix(line, "\n")
+		if name, typ, ok := strings.Cut(strings.TrimPrefix(line, "# TYPE "), " "); ok && strings.HasPrefix(line, "# TYPE ") {
+			if _, dup := families[name]; dup {
+				t.Errorf("duplicate metric family %s", name)
+			}
+			families[name] = typ
+			lastBucket = 0
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Errorf("bad sample %q", line)
+		}
+		if strings.Contains(line, "_bucket{") {
+			if v < lastBucket {
+				t.Errorf("bucket counts not cumulative: %q", line)
+			}
+			lastBucket = v
+		}
+	}
+	for _, d := range metrics.All() {
+		name := runtimeMetricName(d.Name)
+		want := "gauge"
+		switch {
+		case d.Kind == metrics.KindFloat64Histogram:
+			want = "histogram"
+		case d.Kind == metrics.KindBad:
+			continue
+		case d.Cumulative:
+			name = counterName(name)
+			want = "counter"
+		}
+		if got := families[name]; got != want {
+			t.Errorf("%s: got type %q, want %q", name, got, want)
+		}
+	}
+	if !strings.Contains(out, `go_gc_heap_allocs_by_size_bytes_bucket{le="+Inf"} `) {
+		t.Error("no +Inf bucket for go_gc_heap_allocs_by_size_bytes")
+	}
+}
+
+func TestFormatBound(t *testing.T) {
+	for _, tt := range []struct {
+		f    float64
+		want string
+	}{
+		{1, "1.0"},
+		{0.25, "0.25"},
+		{1e21, "1e+21"},
+		{math.Inf(+1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+	} {
+		if got := formatBound(tt.f); got != tt.want {
+			t.Errorf("formatBound(%v) = %q, want %q", tt.f, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkCounterWith(b *testing.B) {
+	RemoveAll()
+	c := NewCounter("c", "", "method")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.With("GET").Inc()
+		}
+	})
+}
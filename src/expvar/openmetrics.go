@@ -0,0 +1,261 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expvar
+
+import (
+	"math"
+	"net/http"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+)
+
+// MetricsHandler returns an HTTP handler that serves the exported
+// variables and the metrics of package [runtime/metrics] in the OpenMetrics
+// text format, for scraping by Prometheus and compatible systems.
+//
+// [Counter], [Gauge] and [Histogram] variables are served as metrics of
+// the same types. [Int] and [Float] variables, and the Int and Float
+// entries of [Map] variables, labeled with their keys, are served as
+// metrics of unknown type. Other variables are not served. Characters that
+// metric names cannot hold are replaced with underscores in the names of
+// variables. Counter samples are named with a _total suffix, which is not
+// repeated if the name of the variable already ends with it.
+//
+// The runtime metrics are named after their names in package
+// runtime/metrics, prefixed with "go" and with the characters that metric
+// names cannot hold replaced with underscores: for example,
+// /gc/heap/allocs:bytes becomes go_gc_heap_allocs_bytes. Cumulative
+// metrics are served as counters, others as gauges, and
+// [metrics.KindFloat64Histogram] metrics as histograms. The runtime does
+// not record the sums of its histograms, so they are served without
+// _sum samples.
+//
+// Unlike the JSON handler, the handler is not installed by the package.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(metricsHandler)
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	w.Write(appendOpenMetrics(nil))
+}
+
+// appendOpenMetrics appends the exposition of all the metrics.
+func appendOpenMetrics(b []byte) []byte {
+	Do(func(kv KeyValue) {
+		b = appendVar(b, metricName(kv.Key), kv.Value)
+	})
+	b = appendRuntimeMetrics(b)
+	return append(b, "# EOF\n"...)
+}
+
+// appendVar appends the exposition of an exported variable.
+func appendVar(b []byte, name string, v Var) []byte {
+	switch v := v.(type) {
+	case *Counter:
+		name = counterName(name)
+		b = appendFamily(b, name, "counter", v.help)
+		if v.l == nil {
+			return appendSample(b, name+"_total", nil, nil, v.Value())
+		}
+		v.l.do(func(values []string, s *Counter) {
+			b = appendSample(b, name+"_total", v.l.names, values, s.Value())
+		})
+	case *Gauge:
+		b = appendFamily(b, name, "gauge", v.help)
+		if v.l == nil {
+			return appendSample(b, name, nil, nil, v.Value())
+		}
+		v.l.do(func(values []string, s *Gauge) {
+			b = appendSample(b, name, v.l.names, values, s.Value())
+		})
+	case *Histogram:
+		b = appendFamily(b, name, "histogram", v.help)
+		if v.l == nil {
+			return appendHistogram(b, name, nil, nil, v)
+		}
+		v.l.do(func(values []string, s *Histogram) {
+			b = appendHistogram(b, name, v.l.names, values, s)
+		})
+	case *Int:
+		b = appendFamily(b, name, "unknown", "")
+		b = appendSample(b, name, nil, nil, float64(v.Value()))
+	case *Float:
+		b = appendFamily(b, name, "unknown", "")
+		b = appendSample(b, name, nil, nil, v.Value())
+	case *Map:
+		first := true
+		v.Do(func(kv KeyValue) {
+			var f float64
+			switch v := kv.Value.(type) {
+			case *Int:
+				f = float64(v.Value())
+			case *Float:
+				f = v.Value()
+			default:
+				return
+			}
+			if first {
+				b = appendFamily(b, name, "unknown", "")
+				first = false
+			}
+			b = appendSample(b, name, []string{"key"}, []string{kv.Key}, f)
+		})
+	}
+	return b
+}
+
+func appendHistogram(b []byte, name string, labels, values []string, v *Histogram) []byte {
+	labels = append(labels[:len(labels):len(labels)], "le")
+	values = append(values[:len(values):len(values)], "")
+	n := v.buckets(func(le float64, count uint64) {
+		values[len(values)-1] = formatBound(le)
+		b = appendSample(b, name+"_bucket", labels, values, float64(count))
+	})
+	labels, values = labels[:len(labels)-1], values[:len(values)-1]
+	b = appendSample(b, name+"_count", labels, values, float64(n))
+	return appendSample(b, name+"_sum", labels, values, v.sum.Value())
+}
+
+// appendRuntimeMetrics appends the exposition of the runtime metrics.
+func appendRuntimeMetrics(b []byte) []byte {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i := range samples {
+		samples[i].Name = descs[i].Name
+	}
+	metrics.Read(samples)
+	for i, s := range samples {
+		d := descs[i]
+		name := runtimeMetricName(d.Name)
+		switch s.Value.Kind() {
+		case metrics.KindUint64, metrics.KindFloat64:
+			var f float64
+			if s.Value.Kind() == metrics.KindUint64 {
+				f = float64(s.Value.Uint64())
+			} else {
+				f = s.Value.Float64()
+			}
+			if d.Cumulative {
+				name = counterName(name)
+				b = appendFamily(b, name, "counter", d.Description)
+				b = appendSample(b, name+"_total", nil, nil, f)
+			} else {
+				b = appendFamily(b, name, "gauge", d.Description)
+				b = appendSample(b, name, nil, nil, f)
+			}
+		case metrics.KindFloat64Histogram:
+			h := s.Value.Float64Histogram()
+			b = appendFamily(b, name, "histogram", d.Description)
+			var n uint64
+			labels, values := []string{"le"}, []string{""}
+			for i, c := range h.Counts {
+				n += c
+				// Buckets[i+1] is the upper bound of Counts[i].
+				values[0] = formatBound(h.Buckets[i+1])
+				b = appendSample(b, name+"_bucket", labels, values, float64(n))
+			}
+			if len(h.Buckets) == 0 || !math.IsInf(h.Buckets[len(h.Buckets)-1], +1) {
+				values[0] = "+Inf"
+				b = appendSample(b, name+"_bucket", labels, values, float64(n))
+			}
+			// The runtime does not record the sum of the observations, and
+			// the buckets at either end may be unbounded, so no sum can be
+			// derived from the counts. OpenMetrics allows it to be left out.
+			b = appendSample(b, name+"_count", nil, nil, float64(n))
+		}
+	}
+	return b
+}
+
+// appendFamily appends the metadata of a metric family.
+func appendFamily(b []byte, name, typ, help string) []byte {
+	b = append(b, "# TYPE "...)
+	b = append(b, name...)
+	b = append(b, ' ')
+	b = append(b, typ...)
+	b = append(b, '\n')
+	if help != "" {
+		b = append(b, "# HELP "...)
+		b = append(b, name...)
+		b = append(b, ' ')
+		b = appendEscaped(b, help, false)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// appendSample appends a sample with the given labels.
+func appendSample(b []byte, name string, labels, values []string, v float64) []byte {
+	b = append(b, name...)
+	if len(labels) > 0 {
+		b = append(b, '{')
+		for i, l := range labels {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, l...)
+			b = append(b, '=', '"')
+			b = appendEscaped(b, values[i], true)
+			b = append(b, '"')
+		}
+		b = append(b, '}')
+	}
+	b = append(b, ' ')
+	b = strconv.AppendFloat(b, v, 'g', -1, 64)
+	return append(b, '\n')
+}
+
+// appendEscaped appends s, escaping backslashes and newlines, and double
+// quotes too if quote is set.
+func appendEscaped(b []byte, s string, quote bool) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			b = append(b, `\\`...)
+		case c == '\n':
+			b = append(b, `\n`...)
+		case c == '"' && quote:
+			b = append(b, `\"`...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// formatBound formats the upper bound of a histogram bucket as a canonical
+// OpenMetrics number, such as 1.0, 0.25 or +Inf.
+func formatBound(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// metricName returns name with the characters that metric names cannot
+// hold replaced with underscores.
+func metricName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == ':' || i > 0 && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// counterName returns the name of a counter family, which must not have the
+// _total suffix of its samples.
+func counterName(name string) string {
+	return strings.TrimSuffix(name, "_total")
+}
+
+// runtimeMetricName returns the metric name of a runtime metric.
+func runtimeMetricName(name string) string {
+	return metricName("go" + strings.ReplaceAll(name, ":", "_"))
+}
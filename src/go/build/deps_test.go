@@ -268,6 +268,12 @@ var depsRules = `
 	< encoding/ascii85, encoding/csv, encoding/gob, encoding/hex,
 	  encoding/pem, encoding/xml, mime;
 
+	FMT, encoding, encoding/binary, encoding/hex
+	< encoding/cbor;
+
+	STR, internal/saferio
+	< encoding/protowire;
+
 	STR, errors
 	< encoding/json/internal
 	< encoding/json/internal/jsonflags
@@ -275,6 +281,9 @@ var depsRules = `
 	< encoding/json/internal/jsonwire
 	< encoding/json/jsontext;
 
+	reflect
+	< encoding/json/internal/jsonfields;
+
 	FMT,
 	encoding/hex,
 	encoding/base32,
@@ -284,7 +293,8 @@ var depsRules = `
 	encoding/json/internal,
 	encoding/json/internal/jsonflags,
 	encoding/json/internal/jsonopts,
-	encoding/json/internal/jsonwire
+	encoding/json/internal/jsonwire,
+	encoding/json/internal/jsonfields
 	< encoding/json/v2
 	< encoding/json;
 
@@ -300,7 +310,7 @@ var depsRules = `
 	# compression
 	FMT, encoding/binary, hash/adler32, hash/crc32, sort
 	< compress/bzip2, compress/flate, compress/lzw, internal/zstd
-	< archive/zip, compress/gzip, compress/zlib;
+	< compress/gzip, compress/zlib;
 
 	# templates
 	FMT
@@ -322,6 +332,9 @@ var depsRules = `
 	< regexp
 	< internal/lazyregexp;
 
+	encoding/json/internal/jsonfields, encoding/json/v2, regexp
+	< encoding/json/jsonschema;
+
 	encoding/json, html, text/template, regexp
 	< html/template;
 
@@ -387,12 +400,12 @@ var depsRules = `
 	database/sql/driver, math/rand/v2 < database/sql;
 
 	# images
-	FMT, compress/lzw, compress/zlib
+	FMT, compress/lzw, compress/zlib, internal/saferio, simd/archsimd
 	< image/color
-	< image, image/color/palette
+	< image, image/color/icc, image/color/palette
 	< image/internal/imageutil
 	< image/draw
-	< image/gif, image/jpeg, image/png;
+	< image/bmp, image/gif, image/jpeg, image/png, image/tiff, image/webp;
 
 	# cgo, delayed as long as possible.
 	# If you add a dependency on CGO, you must add the package
@@ -479,6 +492,13 @@ var depsRules = `
 	NET, log
 	< net/mail;
 
+	NET, log/slog
+	< log/slog/internal/severity
+	< log/slog/journal, log/slog/syslog;
+
+	OS, compress/gzip
+	< log/rotate;
+
 	# FIPS is the FIPS 140 module.
 	# It must not depend on external crypto packages.
 	# Package hash is ok as it's only the interface.
@@ -592,6 +612,11 @@ var depsRules = `
 
 	CGO, net !< CRYPTO-MATH;
 
+	# archive/zip uses CRYPTO and crypto/rand for WinZip AES encryption.
+	# crypto/rand brings in math/big, but nothing else from CRYPTO-MATH.
+	compress/flate, CRYPTO, crypto/rand
+	< archive/zip;
+
 	# TLS, Prince of Dependencies.
 
 	crypto/fips140, sync/atomic < crypto/tls/internal/fips140tls;
@@ -663,7 +688,7 @@ var depsRules = `
 
 	# HTTP-aware packages
 
-	encoding/json, net/http
+	encoding/json, net/http, runtime/metrics
 	< expvar;
 
 	net/http, net/http/internal/ascii
@@ -678,10 +703,11 @@ var depsRules = `
 
 	# Profiling
 	internal/runtime/pprof/label, runtime, context < internal/runtime/pprof;
-	FMT, compress/gzip, encoding/binary, sort, text/tabwriter, internal/runtime/pprof, internal/runtime/pprof/label
+	FMT, compress/gzip, encoding/binary, encoding/protowire, sort, text/tabwriter,
+	internal/runtime/pprof, internal/runtime/pprof/label
 	< runtime/pprof;
 
-	OS, compress/gzip, internal/lazyregexp
+	OS, compress/gzip, encoding/protowire, internal/lazyregexp
 	< internal/profile;
 
 	html, internal/profile, net/http, runtime/pprof, runtime/trace
@@ -713,6 +739,9 @@ var depsRules = `
 	log/slog, testing
 	< testing/slogtest;
 
+	database/sql, testing
+	< database/sql/sqltest;
+
 	testing, crypto/rand
 	< testing/cryptotest;
 
@@ -0,0 +1,443 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bmp implements a BMP image decoder and encoder.
+//
+// The decoder supports the Windows and OS/2 variants of the format with
+// 1, 2, 4, 8, 16, 24 and 32 bits per pixel, run-length encoded 4- and
+// 8-bit images, and bit field masks including an alpha channel. The
+// BMP specification is at
+// https://learn.microsoft.com/en-us/windows/win32/gdi/bitmap-storage.
+package bmp
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"strconv"
+)
+
+// A FormatError reports that the input is not a valid BMP.
+type FormatError string
+
+func (e FormatError) Error() string { return "bmp: invalid format: " + string(e) }
+
+// An UnsupportedError reports that the input uses a valid but unimplemented BMP feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string { return "bmp: unsupported feature: " + string(e) }
+
+// Compression methods.
+const (
+	biRGB            = 0
+	biRLE8           = 1
+	biRLE4           = 2
+	biBitFields      = 3
+	biAlphaBitFields = 6
+)
+
+const (
+	fileHeaderLen = 14
+	coreHeaderLen = 12 // OS/2 BITMAPCOREHEADER
+	infoHeaderLen = 40 // BITMAPINFOHEADER
+	v4HeaderLen   = 108
+	v5HeaderLen   = 124
+)
+
+// maxPixels bounds the size of an image, guarding against allocating
+// unreasonable amounts of memory for a corrupt header.
+const maxPixels = 1 << 28
+
+// A channel extracts one color component from a pixel using a bit mask.
+type channel struct {
+	mask  uint32
+	shift uint
+	max   uint32 // the largest value after shifting
+}
+
+func newChannel(mask uint32) channel {
+	if mask == 0 {
+		return channel{}
+	}
+	shift := uint(bits.TrailingZeros32(mask))
+	return channel{mask, shift, mask >> shift}
+}
+
+// get returns the component of p scaled to 8 bits.
+func (c channel) get(p uint32) uint8 {
+	if c.mask == 0 {
+		return 0
+	}
+	v := (p & c.mask) >> c.shift
+	if c.max == 0xff {
+		return uint8(v)
+	}
+	return uint8((uint64(v)*0xff + uint64(c.max)/2) / uint64(c.max))
+}
+
+type decoder struct {
+	r           io.Reader
+	width       int
+	height      int
+	topDown     bool
+	bpp         int
+	compression uint32
+	palette     color.Palette
+	masks       [4]channel // red, green, blue, alpha
+	alpha       bool
+}
+
+// decodeHeader reads everything preceding the pixel data.
+func (d *decoder) decodeHeader() error {
+	var fh [fileHeaderLen]byte
+	if _, err := io.ReadFull(d.r, fh[:]); err != nil {
+		return err
+	}
+	if string(fh[:2]) != "BM" {
+		return FormatError("not a BMP file")
+	}
+	offset := binary.LittleEndian.Uint32(fh[10:])
+
+	// The info header starts with its own length.
+	var b [v5HeaderLen]byte
+	if _, err := io.ReadFull(d.r, b[:4]); err != nil {
+		return err
+	}
+	infoLen := binary.LittleEndian.Uint32(b[:])
+	switch infoLen {
+	case coreHeaderLen, infoHeaderLen, 52, 56, 64, v4HeaderLen, v5HeaderLen:
+	default:
+		return UnsupportedError("DIB header size " + strconv.FormatUint(uint64(infoLen), 10))
+	}
+	if _, err := io.ReadFull(d.r, b[4:infoLen]); err != nil {
+		return err
+	}
+	read := fileHeaderLen + infoLen
+	h := b[:infoLen]
+
+	var planes int
+	var colorsUsed uint32
+	if infoLen == coreHeaderLen {
+		d.width = int(binary.LittleEndian.Uint16(h[4:]))
+		d.height = int(binary.LittleEndian.Uint16(h[6:]))
+		planes = int(binary.LittleEndian.Uint16(h[8:]))
+		d.bpp = int(binary.LittleEndian.Uint16(h[10:]))
+	} else {
+		d.width = int(int32(binary.LittleEndian.Uint32(h[4:])))
+		d.height = int(int32(binary.LittleEndian.Uint32(h[8:])))
+		planes = int(binary.LittleEndian.Uint16(h[12:]))
+		d.bpp = int(binary.LittleEndian.Uint16(h[14:]))
+		d.compression = binary.LittleEndian.Uint32(h[16:])
+		colorsUsed = binary.LittleEndian.Uint32(h[32:])
+	}
+	if d.height < 0 {
+		d.height, d.topDown = -d.height, true
+	}
+	if planes != 1 {
+		return FormatError("number of planes")
+	}
+	if d.width <= 0 || d.height <= 0 {
+		return FormatError("non-positive dimension")
+	}
+	if d.width > maxPixels/d.height {
+		return UnsupportedError("dimension overflow")
+	}
+
+	switch d.compression {
+	case biRGB:
+		switch d.bpp {
+		case 1, 2, 4, 8, 24:
+		case 16:
+			d.masks = [4]channel{newChannel(0x7c00), newChannel(0x03e0), newChannel(0x001f)}
+		case 32:
+			d.masks = [4]channel{newChannel(0xff0000), newChannel(0xff00), newChannel(0xff)}
+		default:
+			return UnsupportedError("bits per pixel " + strconv.Itoa(d.bpp))
+		}
+	case biRLE8, biRLE4:
+		if d.compression == biRLE8 && d.bpp != 8 || d.compression == biRLE4 && d.bpp != 4 {
+			return FormatError("bits per pixel for run-length encoding")
+		}
+		if d.topDown {
+			return FormatError("top-down run-length encoded image")
+		}
+	case biBitFields, biAlphaBitFields:
+		if d.bpp != 16 && d.bpp != 32 {
+			return FormatError("bits per pixel for bit fields")
+		}
+		var m [16]byte
+		n := 3
+		if d.compression == biAlphaBitFields {
+			n = 4
+		}
+		if infoLen == infoHeaderLen {
+			// The masks follow the header.
+			if _, err := io.ReadFull(d.r, m[:4*n]); err != nil {
+				return err
+			}
+			read += uint32(4 * n)
+		} else {
+			copy(m[:], h[40:])
+			if infoLen > 52 {
+				n = 4
+			}
+		}
+		for i := range n {
+			d.masks[i] = newChannel(binary.LittleEndian.Uint32(m[4*i:]))
+		}
+		for i := range n {
+			for j := range i {
+				if d.masks[i].mask&d.masks[j].mask != 0 {
+					return FormatError("overlapping bit field masks")
+				}
+			}
+		}
+		if d.bpp == 16 {
+			for _, c := range d.masks {
+				if c.mask > 0xffff {
+					return FormatError("bit field mask exceeds pixel size")
+				}
+			}
+		}
+		d.alpha = d.masks[3].mask != 0
+	default:
+		return UnsupportedError("compression method " + strconv.FormatUint(uint64(d.compression), 10))
+	}
+
+	if d.bpp <= 8 {
+		n := 1 << d.bpp
+		if colorsUsed != 0 {
+			if colorsUsed > uint32(n) {
+				return FormatError("palette too large")
+			}
+			n = int(colorsUsed)
+		}
+		entry := 4
+		if infoLen == coreHeaderLen {
+			entry = 3
+		}
+		p := make([]byte, n*entry)
+		if _, err := io.ReadFull(d.r, p); err != nil {
+			return err
+		}
+		read += uint32(len(p))
+		d.palette = make(color.Palette, n)
+		for i := range d.palette {
+			q := p[i*entry:]
+			d.palette[i] = color.RGBA{q[2], q[1], q[0], 0xff}
+		}
+	}
+
+	if offset < read {
+		return FormatError("pixel data offset")
+	}
+	if _, err := io.CopyN(io.Discard, d.r, int64(offset-read)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *decoder) colorModel() color.Model {
+	switch {
+	case d.palette != nil:
+		return d.palette
+	case d.alpha:
+		return color.NRGBAModel
+	}
+	return color.RGBAModel
+}
+
+// row returns the y coordinate of the i'th row stored in the file.
+func (d *decoder) row(i int) int {
+	if d.topDown {
+		return i
+	}
+	return d.height - 1 - i
+}
+
+func (d *decoder) decode() (image.Image, error) {
+	switch d.compression {
+	case biRLE8, biRLE4:
+		return d.decodeRLE()
+	}
+	stride := (d.width*d.bpp + 31) / 32 * 4
+	buf := make([]byte, stride)
+	rect := image.Rect(0, 0, d.width, d.height)
+
+	if d.palette != nil {
+		m := image.NewPaletted(rect, d.palette)
+		ppb := 8 / d.bpp // pixels per byte
+		mask := byte(1<<d.bpp - 1)
+		for i := range d.height {
+			if _, err := io.ReadFull(d.r, buf); err != nil {
+				return nil, err
+			}
+			pix := m.Pix[d.row(i)*m.Stride:][:d.width]
+			for x := range pix {
+				shift := uint(8 - d.bpp*(x%ppb+1))
+				c := buf[x/ppb] >> shift & mask
+				if int(c) >= len(d.palette) {
+					return nil, FormatError("palette index out of range")
+				}
+				pix[x] = c
+			}
+		}
+		return m, nil
+	}
+
+	var pixels []byte
+	var mStride int
+	var m image.Image
+	if d.alpha {
+		mm := image.NewNRGBA(rect)
+		m, pixels, mStride = mm, mm.Pix, mm.Stride
+	} else {
+		mm := image.NewRGBA(rect)
+		m, pixels, mStride = mm, mm.Pix, mm.Stride
+	}
+	for i := range d.height {
+		if _, err := io.ReadFull(d.r, buf); err != nil {
+			return nil, err
+		}
+		pix := pixels[d.row(i)*mStride:][:4*d.width]
+		switch d.bpp {
+		case 24:
+			for x, j := 0, 0; x < len(pix); x, j = x+4, j+3 {
+				pix[x+0] = buf[j+2]
+				pix[x+1] = buf[j+1]
+				pix[x+2] = buf[j+0]
+				pix[x+3] = 0xff
+			}
+		case 16:
+			for x, j := 0, 0; x < len(pix); x, j = x+4, j+2 {
+				d.setPixel(pix[x:x+4], uint32(binary.LittleEndian.Uint16(buf[j:])))
+			}
+		case 32:
+			for x := 0; x < len(pix); x += 4 {
+				d.setPixel(pix[x:x+4], binary.LittleEndian.Uint32(buf[x:]))
+			}
+		}
+	}
+	return m, nil
+}
+
+func (d *decoder) setPixel(pix []byte, p uint32) {
+	pix[0] = d.masks[0].get(p)
+	pix[1] = d.masks[1].get(p)
+	pix[2] = d.masks[2].get(p)
+	pix[3] = 0xff
+	if d.alpha {
+		pix[3] = d.masks[3].get(p)
+	}
+}
+
+// decodeRLE decodes a run-length encoded image. Pixels that the
+// encoding skips over keep the palette's first color, and pixels that
+// fall outside the image are dropped.
+func (d *decoder) decodeRLE() (image.Image, error) {
+	m := image.NewPaletted(image.Rect(0, 0, d.width, d.height), d.palette)
+	var buf [2]byte
+	var abs [256]byte
+	x, y := 0, d.height-1
+	set := func(c byte) error {
+		if int(c) >= len(d.palette) {
+			return FormatError("palette index out of range")
+		}
+		if x < d.width && y >= 0 {
+			m.Pix[y*m.Stride+x] = c
+		}
+		x++
+		return nil
+	}
+	for {
+		if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+			return nil, err
+		}
+		n, c := int(buf[0]), buf[1]
+		if n > 0 {
+			// An encoded run.
+			for i := range n {
+				v := c
+				if d.bpp == 4 {
+					v = c >> (4 * uint(1-i%2)) & 0x0f
+				}
+				if err := set(v); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		switch c {
+		case 0: // End of line.
+			x, y = 0, y-1
+		case 1: // End of bitmap.
+			return m, nil
+		case 2: // Delta.
+			if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+				return nil, err
+			}
+			x, y = x+int(buf[0]), y-int(buf[1])
+		default: // Absolute mode.
+			n := int(c)
+			size := n
+			if d.bpp == 4 {
+				size = (n + 1) / 2
+			}
+			size += size & 1 // Runs are padded to 16 bits.
+			if _, err := io.ReadFull(d.r, abs[:size]); err != nil {
+				return nil, err
+			}
+			for i := range n {
+				v := abs[i]
+				if d.bpp == 4 {
+					v = abs[i/2] >> (4 * uint(1-i%2)) & 0x0f
+				}
+				if err := set(v); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+}
+
+// Decode reads a BMP image from r and returns it as an [image.Image].
+// Images with a palette are returned as [*image.Paletted], images with
+// an alpha channel as [*image.NRGBA] and other images as [*image.RGBA].
+func Decode(r io.Reader) (image.Image, error) {
+	d := &decoder{r: r}
+	if err := d.decodeHeader(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	m, err := d.decode()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return m, err
+}
+
+// DecodeConfig returns the color model and dimensions of a BMP image
+// without decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	d := &decoder{r: r}
+	if err := d.decodeHeader(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: d.colorModel(),
+		Width:      d.width,
+		Height:     d.height,
+	}, nil
+}
+
+func init() {
+	image.RegisterFormat("bmp", "BM????\x00\x00\x00\x00", Decode, DecodeConfig)
+}
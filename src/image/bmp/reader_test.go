@@ -0,0 +1,188 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+)
+
+// bmpFile assembles a BMP file from an info header (without its length
+// field), a color table and pixel data.
+func bmpFile(info, colors, pixels []byte) []byte {
+	offset := 14 + 4 + len(info) + len(colors)
+	b := []byte("BM")
+	b = binary.LittleEndian.AppendUint32(b, uint32(offset+len(pixels)))
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = binary.LittleEndian.AppendUint32(b, uint32(offset))
+	b = binary.LittleEndian.AppendUint32(b, uint32(4+len(info)))
+	b = append(b, info...)
+	b = append(b, colors...)
+	return append(b, pixels...)
+}
+
+// infoHeader returns the fields of a BITMAPINFOHEADER after its length.
+func infoHeader(w, h int32, bpp uint16, compression uint32, colors uint32, extra ...uint32) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(w))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h))
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint16(b, bpp)
+	b = binary.LittleEndian.AppendUint32(b, compression)
+	b = append(b, make([]byte, 12)...)
+	b = binary.LittleEndian.AppendUint32(b, colors)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	for _, x := range extra {
+		b = binary.LittleEndian.AppendUint32(b, x)
+	}
+	return b
+}
+
+var (
+	red   = color.RGBA{0xff, 0, 0, 0xff}
+	green = color.RGBA{0, 0xff, 0, 0xff}
+	blue  = color.RGBA{0, 0, 0xff, 0xff}
+	black = color.RGBA{0, 0, 0, 0xff}
+)
+
+// Color table with red, green and blue in BGRX order.
+var rgbTable = []byte{0, 0, 0xff, 0, 0, 0xff, 0, 0, 0xff, 0, 0, 0}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want [][]color.Color // rows, top to bottom
+	}{{
+		name: "8-bit bottom-up",
+		data: bmpFile(infoHeader(3, 2, 8, biRGB, 3), rgbTable, []byte{
+			2, 1, 0, 0, // bottom row, padded to 4 bytes
+			0, 1, 2, 0,
+		}),
+		want: [][]color.Color{{red, green, blue}, {blue, green, red}},
+	}, {
+		name: "4-bit top-down",
+		data: bmpFile(infoHeader(3, -2, 4, biRGB, 3), rgbTable, []byte{
+			0x01, 0x20, 0, 0,
+			0x22, 0x10, 0, 0,
+		}),
+		want: [][]color.Color{{red, green, blue}, {blue, blue, green}},
+	}, {
+		name: "1-bit",
+		data: bmpFile(infoHeader(9, 1, 1, biRGB, 2), rgbTable[:8], []byte{0xa5, 0x80, 0, 0}),
+		want: [][]color.Color{{green, red, green, red, red, green, red, green, green}},
+	}, {
+		name: "OS/2 core header",
+		data: bmpFile([]byte{2, 0, 1, 0, 1, 0, 1, 0}, []byte{0, 0, 0xff, 0xff, 0, 0}, []byte{0x80, 0, 0, 0}),
+		want: [][]color.Color{{blue, red}},
+	}, {
+		name: "24-bit",
+		data: bmpFile(infoHeader(2, 1, 24, biRGB, 0), nil, []byte{0xff, 0, 0, 0, 0xff, 0, 0, 0}),
+		want: [][]color.Color{{blue, green}},
+	}, {
+		name: "16-bit 5-5-5",
+		data: bmpFile(infoHeader(2, 1, 16, biRGB, 0), nil, []byte{0x00, 0x7c, 0x1f, 0x00}),
+		want: [][]color.Color{{red, blue}},
+	}, {
+		name: "16-bit 5-6-5 bit fields",
+		data: bmpFile(append(infoHeader(2, 1, 16, biBitFields, 0), 0xf800&0xff, 0xf8, 0, 0, 0xe0, 0x07, 0, 0, 0x1f, 0, 0, 0), nil,
+			[]byte{0xe0, 0x07, 0x10, 0x84}),
+		want: [][]color.Color{{green, color.RGBA{0x84, 0x82, 0x84, 0xff}}},
+	}, {
+		name: "32-bit alpha bit fields",
+		data: bmpFile(infoHeader(2, 1, 32, biAlphaBitFields, 0, 0xff, 0xff00, 0xff0000, 0xff000000), nil,
+			[]byte{0xff, 0, 0, 0x80, 0, 0, 0xff, 0xff}),
+		want: [][]color.Color{{color.NRGBA{0xff, 0, 0, 0x80}, color.NRGBA{0, 0, 0xff, 0xff}}},
+	}, {
+		name: "32-bit without alpha",
+		data: bmpFile(infoHeader(1, 1, 32, biRGB, 0), nil, []byte{0x10, 0x20, 0x30, 0x00}),
+		want: [][]color.Color{{color.RGBA{0x30, 0x20, 0x10, 0xff}}},
+	}, {
+		name: "RLE8",
+		data: bmpFile(infoHeader(4, 3, 8, biRLE8, 3), rgbTable, []byte{
+			3, 1, // three green pixels
+			0, 0, // end of line
+			0, 3, 2, 1, 0, 0, // absolute run, padded
+			0, 2, 2, 1, // delta: skip the rest of the line and the next
+			0, 1, // end of bitmap
+		}),
+		want: [][]color.Color{{red, red, red, red}, {blue, green, red, red}, {green, green, green, red}},
+	}, {
+		name: "RLE4",
+		data: bmpFile(infoHeader(6, 1, 4, biRLE4, 3), rgbTable, []byte{
+			3, 0x12, // green, blue, green
+			0, 3, 0x21, 0x20, // absolute run of three
+			0, 1,
+		}),
+		want: [][]color.Color{{green, blue, green, blue, green, blue}},
+	}}
+	for _, tt := range tests {
+		m, err := Decode(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		b := m.Bounds()
+		if b != image.Rect(0, 0, len(tt.want[0]), len(tt.want)) {
+			t.Errorf("%s: bounds %v", tt.name, b)
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				r0, g0, b0, a0 := m.At(x, y).RGBA()
+				r1, g1, b1, a1 := want.RGBA()
+				if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+					t.Errorf("%s: At(%d, %d) = %v, want %v", tt.name, x, y, m.At(x, y), want)
+				}
+			}
+		}
+		cfg, err := DecodeConfig(bytes.NewReader(tt.data))
+		if err != nil || cfg.Width != b.Dx() || cfg.Height != b.Dy() || fmt.Sprint(cfg.ColorModel) != fmt.Sprint(m.ColorModel()) {
+			t.Errorf("%s: DecodeConfig = %v, %v", tt.name, cfg, err)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	valid := bmpFile(infoHeader(3, 2, 8, biRGB, 3), rgbTable, []byte{2, 1, 0, 0, 0, 1, 2, 0})
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "unexpected EOF"},
+		{"truncated", valid[:len(valid)-1], "unexpected EOF"},
+		{"signature", append([]byte("XX"), valid[2:]...), "not a BMP file"},
+		{"palette index", bmpFile(infoHeader(1, 1, 8, biRGB, 3), rgbTable, []byte{3, 0, 0, 0}), "palette index"},
+		{"zero width", bmpFile(infoHeader(0, 1, 24, biRGB, 0), nil, nil), "non-positive dimension"},
+		{"huge", bmpFile(infoHeader(1<<30, 1<<30, 24, biRGB, 0), nil, nil), "dimension overflow"},
+		{"bpp", bmpFile(infoHeader(1, 1, 7, biRGB, 0), nil, nil), "bits per pixel"},
+		{"compression", bmpFile(infoHeader(1, 1, 24, 4, 0), nil, nil), "compression method"},
+		{"overlapping masks", bmpFile(infoHeader(1, 1, 32, biBitFields, 0, 0xff, 0xff, 0xff00), nil, nil), "overlapping"},
+		{"top-down RLE", bmpFile(infoHeader(1, -1, 8, biRLE8, 1), rgbTable[:4], []byte{0, 1}), "top-down"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Decode error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	var b bytes.Buffer
+	if err := Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	_, name, err := image.Decode(io.MultiReader(&b))
+	if err != nil || name != "bmp" {
+		t.Errorf("image.Decode = %q, %v", name, err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// opaque reports whether m is fully opaque, as for the png encoder.
+func opaque(m image.Image) bool {
+	if o, ok := m.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			_, _, _, a := m.At(x, y).RGBA()
+			if a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Encode writes the image m to w in BMP format.
+//
+// Paletted images with at most 256 colors and grayscale images are
+// written with a palette, using the fewest bits per pixel that hold
+// every index. Other opaque images are written with 24 bits per pixel,
+// and images with transparency with 32 bits per pixel and an alpha
+// channel.
+func Encode(w io.Writer, m image.Image) error {
+	b := m.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 || width > maxPixels/height {
+		return FormatError("invalid image size: " + strconv.Itoa(width) + "x" + strconv.Itoa(height))
+	}
+
+	var palette color.Palette
+	bpp, infoLen, compression := 24, uint32(infoHeaderLen), uint32(biRGB)
+	switch m := m.(type) {
+	case *image.Paletted:
+		if len(m.Palette) > 256 || len(m.Palette) == 0 {
+			break
+		}
+		palette = m.Palette
+	case *image.Gray:
+		palette = make(color.Palette, 256)
+		for i := range palette {
+			palette[i] = color.Gray{uint8(i)}
+		}
+	}
+	switch {
+	case palette != nil:
+		switch n := len(palette); {
+		case n <= 2:
+			bpp = 1
+		case n <= 16:
+			bpp = 4
+		default:
+			bpp = 8
+		}
+	case !opaque(m):
+		bpp, infoLen, compression = 32, v4HeaderLen, biBitFields
+	}
+
+	stride := (width*bpp + 31) / 32 * 4
+	offset := fileHeaderLen + infoLen + uint32(4*len(palette))
+	size := uint64(offset) + uint64(stride)*uint64(height)
+	if size > 1<<32-1 {
+		return UnsupportedError("image too large")
+	}
+
+	h := make([]byte, offset)
+	h[0], h[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(h[2:], uint32(size))
+	binary.LittleEndian.PutUint32(h[10:], offset)
+	info := h[fileHeaderLen:]
+	binary.LittleEndian.PutUint32(info[0:], infoLen)
+	binary.LittleEndian.PutUint32(info[4:], uint32(width))
+	binary.LittleEndian.PutUint32(info[8:], uint32(height))
+	binary.LittleEndian.PutUint16(info[12:], 1)
+	binary.LittleEndian.PutUint16(info[14:], uint16(bpp))
+	binary.LittleEndian.PutUint32(info[16:], compression)
+	binary.LittleEndian.PutUint32(info[20:], uint32(stride*height))
+	binary.LittleEndian.PutUint32(info[32:], uint32(len(palette)))
+	if infoLen == v4HeaderLen {
+		binary.LittleEndian.PutUint32(info[40:], 0x00ff0000) // red
+		binary.LittleEndian.PutUint32(info[44:], 0x0000ff00) // green
+		binary.LittleEndian.PutUint32(info[48:], 0x000000ff) // blue
+		binary.LittleEndian.PutUint32(info[52:], 0xff000000) // alpha
+		copy(info[56:], "BGRs")                              // LCS_sRGB
+	}
+	p := h[fileHeaderLen+infoLen:]
+	for i, c := range palette {
+		r, g, b, _ := c.RGBA()
+		p[4*i+0] = uint8(b >> 8)
+		p[4*i+1] = uint8(g >> 8)
+		p[4*i+2] = uint8(r >> 8)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(h)
+	row := make([]byte, stride)
+	for y := b.Max.Y - 1; y >= b.Min.Y; y-- {
+		switch bpp {
+		case 1, 4, 8:
+			pm, _ := m.(*image.Paletted)
+			ppb := 8 / bpp
+			clear(row)
+			for x := range width {
+				var c uint8
+				if pm != nil {
+					c = pm.ColorIndexAt(b.Min.X+x, y)
+				} else {
+					c = color.GrayModel.Convert(m.At(b.Min.X+x, y)).(color.Gray).Y
+				}
+				row[x/ppb] |= c << uint(8-bpp*(x%ppb+1))
+			}
+		case 24:
+			for x := range width {
+				r, g, b, _ := m.At(b.Min.X+x, y).RGBA()
+				row[3*x+0] = uint8(b >> 8)
+				row[3*x+1] = uint8(g >> 8)
+				row[3*x+2] = uint8(r >> 8)
+			}
+		case 32:
+			for x := range width {
+				c := color.NRGBAModel.Convert(m.At(b.Min.X+x, y)).(color.NRGBA)
+				row[4*x+0] = c.B
+				row[4*x+1] = c.G
+				row[4*x+2] = c.R
+				row[4*x+3] = c.A
+			}
+		}
+		bw.Write(row)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bmp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"testing"
+)
+
+func diff(m0, m1 image.Image) error {
+	b0, b1 := m0.Bounds(), m1.Bounds()
+	if !b0.Size().Eq(b1.Size()) {
+		return fmt.Errorf("dimensions differ: %v vs %v", b0, b1)
+	}
+	dx := b1.Min.X - b0.Min.X
+	dy := b1.Min.Y - b0.Min.Y
+	for y := b0.Min.Y; y < b0.Max.Y; y++ {
+		for x := b0.Min.X; x < b0.Max.X; x++ {
+			c0 := m0.At(x, y)
+			c1 := m1.At(x+dx, y+dy)
+			r0, g0, b0, a0 := c0.RGBA()
+			r1, g1, b1, a1 := c1.RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				return fmt.Errorf("colors differ at (%d, %d): %T%v vs %T%v", x, y, c0, c0, c1, c1)
+			}
+		}
+	}
+	return nil
+}
+
+func TestEncodeDecode(t *testing.T) {
+	r := image.Rect(3, 5, 22, 14)
+	rgba := image.NewRGBA(r)
+	nrgba := image.NewNRGBA(r)
+	gray := image.NewGray(r)
+	pal2 := image.NewPaletted(r, color.Palette{color.Black, color.White})
+	pal16 := image.NewPaletted(r, palette.Plan9[:16])
+	pal256 := image.NewPaletted(r, palette.WebSafe)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			rgba.Set(x, y, color.RGBA{uint8(x * 10), uint8(y * 20), uint8(x * y), 0xff})
+			nrgba.Set(x, y, color.NRGBA{uint8(x * 10), uint8(y * 20), uint8(x * y), uint8(x*y*3 + 1)})
+			gray.Set(x, y, color.Gray{uint8(x*y + x)})
+			pal2.SetColorIndex(x, y, uint8((x+y)%2))
+			pal16.SetColorIndex(x, y, uint8((x*y)%16))
+			pal256.SetColorIndex(x, y, uint8((x*y+y)%216))
+		}
+	}
+	tests := []struct {
+		m    image.Image
+		bpp  int
+		want string // the type of the decoded image
+	}{
+		{rgba, 24, "*image.RGBA"},
+		{nrgba, 32, "*image.NRGBA"},
+		{gray, 8, "*image.Paletted"},
+		{pal2, 1, "*image.Paletted"},
+		{pal16, 4, "*image.Paletted"},
+		{pal256, 8, "*image.Paletted"},
+		{image.NewUniform(color.Gray16{0x1234}), 0, ""},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		err := Encode(&b, tt.m)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Encode(%T) succeeded for unbounded image", tt.m)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Encode(%T): %v", tt.m, err)
+			continue
+		}
+		if got := int(b.Bytes()[28]); got != tt.bpp {
+			t.Errorf("Encode(%T): %d bits per pixel, want %d", tt.m, got, tt.bpp)
+		}
+		m, err := Decode(&b)
+		if err != nil {
+			t.Errorf("Decode(Encode(%T)): %v", tt.m, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", m); got != tt.want {
+			t.Errorf("Decode(Encode(%T)) is %s, want %s", tt.m, got, tt.want)
+		}
+		if err := diff(tt.m, m); err != nil {
+			t.Errorf("Decode(Encode(%T)): %v", tt.m, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package icc_test
+
+import (
+	"fmt"
+	"image/color"
+	"image/color/icc"
+	"log"
+)
+
+func ExampleTransform() {
+	// Convert pure sRGB red to Display P3, where it is less saturated.
+	t, err := icc.NewTransform(icc.SRGB, icc.DisplayP3, icc.RelativeColorimetric)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := color.NRGBAModel.Convert(t.Convert(color.RGBA{0xff, 0, 0, 0xff}))
+	fmt.Println(c)
+	// Output: {234 51 35 255}
+}
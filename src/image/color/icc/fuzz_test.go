@@ -0,0 +1,40 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package icc
+
+import (
+	"image/color"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	if testing.Short() {
+		f.Skip("Skipping in short mode")
+	}
+	// Small curves and grids keep the seeds small, since the time taken
+	// to minimize an input grows with the square of its size.
+	f.Add(matrixProfile(16))
+	f.Add(lutProfile(3))
+	f.Add(lutABProfile())
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		p, err := Parse(b)
+		if err != nil {
+			return
+		}
+		for _, intent := range []RenderingIntent{Perceptual, RelativeColorimetric, Saturation, AbsoluteColorimetric} {
+			for _, x := range [...][2]*Profile{{p, SRGB}, {SRGB, p}} {
+				t, err := NewTransform(x[0], x[1], intent)
+				if err != nil {
+					continue
+				}
+				for _, c := range testColors {
+					t.Convert(c)
+				}
+				t.Convert(color.CMYK{0x10, 0x20, 0x30, 0x40})
+			}
+		}
+	})
+}
@@ -0,0 +1,522 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package icc
+
+import (
+	"encoding/binary"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildProfile returns a profile with the given header fields and tags.
+func buildProfile(version uint32, class, space, pcs Signature, tags [][2]string) []byte {
+	b := make([]byte, headerSize+4+12*len(tags))
+	binary.BigEndian.PutUint32(b[8:], version)
+	binary.BigEndian.PutUint32(b[12:], uint32(class))
+	binary.BigEndian.PutUint32(b[16:], uint32(space))
+	binary.BigEndian.PutUint32(b[20:], uint32(pcs))
+	copy(b[36:], "acsp")
+	binary.BigEndian.PutUint32(b[headerSize:], uint32(len(tags)))
+	for i, t := range tags {
+		for len(b)%4 != 0 {
+			b = append(b, 0)
+		}
+		e := b[headerSize+4+12*i:]
+		copy(e, t[0])
+		binary.BigEndian.PutUint32(e[4:], uint32(len(b)))
+		binary.BigEndian.PutUint32(e[8:], uint32(len(t[1])))
+		b = append(b, t[1]...)
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	return b
+}
+
+func appendS15Fixed16(b []byte, x float64) []byte {
+	return binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(x*0x10000))))
+}
+
+func xyzTag(v [3]float64) string {
+	b := []byte("XYZ \x00\x00\x00\x00")
+	for _, x := range v {
+		b = appendS15Fixed16(b, x)
+	}
+	return string(b)
+}
+
+// curvTag returns a curveType sampling f at n points.
+func curvTag(n int, f func(float64) float64) string {
+	b := []byte("curv\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint32(b, uint32(n))
+	for i := range n {
+		b = binary.BigEndian.AppendUint16(b, uint16(math.Round(f(float64(i)/float64(n-1))*0xffff)))
+	}
+	return string(b)
+}
+
+// paraTag returns a parametricCurveType.
+func paraTag(typ int, params ...float64) string {
+	b := []byte("para\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint16(b, uint16(typ))
+	b = append(b, 0, 0)
+	for _, x := range params {
+		b = appendS15Fixed16(b, x)
+	}
+	return string(b)
+}
+
+func descTag(s string) string {
+	b := []byte("desc\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)+1))
+	b = append(b, s...)
+	return string(append(b, 0))
+}
+
+func mlucTag(s string) string {
+	u := utf16.Encode([]rune(s))
+	b := []byte("mluc\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint32(b, 2)
+	b = binary.BigEndian.AppendUint32(b, 12)
+	b = append(b, "deDE"...)
+	b = binary.BigEndian.AppendUint32(b, 2)
+	b = binary.BigEndian.AppendUint32(b, 40)
+	b = append(b, "enUS"...)
+	b = binary.BigEndian.AppendUint32(b, uint32(2*len(u)))
+	b = binary.BigEndian.AppendUint32(b, 42)
+	b = append(b, 0, 'x')
+	for _, c := range u {
+		b = binary.BigEndian.AppendUint16(b, c)
+	}
+	return string(b)
+}
+
+// srgbCurve is the sRGB transfer function.
+func srgbCurve(x float64) float64 {
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func srgbInverse(y float64) float64 {
+	if y <= 0.0031308 {
+		return y * 12.92
+	}
+	return 1.055*math.Pow(y, 1/2.4) - 0.055
+}
+
+// srgbMatrix returns the columns of the matrix of SRGB.
+func srgbMatrix() [3][3]float64 {
+	var m [3][3]float64
+	for i := range 3 {
+		var v [maxChannels]float64
+		v[i] = 1
+		SRGB.matrix.eval(&v)
+		m[i] = [3]float64{v[0], v[1], v[2]}
+	}
+	return m
+}
+
+// srgbToXYZ and xyzToSRGB are reference implementations of the SRGB
+// transforms.
+func srgbToXYZ(rgb []float64) [3]float64 {
+	m := srgbMatrix()
+	var xyz [3]float64
+	for i := range 3 {
+		for k := range 3 {
+			xyz[k] += m[i][k] * srgbCurve(rgb[i])
+		}
+	}
+	return xyz
+}
+
+func xyzToSRGB(xyz [3]float64) [3]float64 {
+	var v [maxChannels]float64
+	copy(v[:], xyz[:])
+	SRGB.invMatrix.eval(&v)
+	return [3]float64{v[0], v[1], v[2]}
+}
+
+// matrixProfile returns a version 2 sRGB profile with matrix/TRC tags,
+// whose curves have the given number of entries.
+func matrixProfile(entries int) []byte {
+	m := srgbMatrix()
+	trc := curvTag(entries, srgbCurve)
+	return buildProfile(0x02100000, DisplayClass, RGBSpace, XYZSpace, [][2]string{
+		{"desc", descTag("test sRGB")},
+		{"wtpt", xyzTag(d50)},
+		{"rXYZ", xyzTag(m[0])},
+		{"gXYZ", xyzTag(m[1])},
+		{"bXYZ", xyzTag(m[2])},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	})
+}
+
+// lut16Tag returns a lut16Type with a grid of the given size, mapping
+// in channels to out channels with f, and identity curves.
+func lut16Tag(in, out, grid int, f func(in []float64) []float64) string {
+	b := []byte("mft2\x00\x00\x00\x00")
+	b = append(b, byte(in), byte(out), byte(grid), 0)
+	for i := range 9 {
+		b = appendS15Fixed16(b, float64(1-min(i%4, 1)))
+	}
+	b = binary.BigEndian.AppendUint16(b, 2)
+	b = binary.BigEndian.AppendUint16(b, 2)
+	for range in {
+		b = append(b, 0, 0, 0xff, 0xff)
+	}
+	n := 1
+	for range in {
+		n *= grid
+	}
+	x := make([]float64, in)
+	for i := range n {
+		for d, j := in-1, i; d >= 0; d, j = d-1, j/grid {
+			x[d] = float64(j%grid) / float64(grid-1)
+		}
+		for _, y := range f(x) {
+			b = binary.BigEndian.AppendUint16(b, uint16(math.Round(min(max(y, 0), 1)*0xffff)))
+		}
+	}
+	for range out {
+		b = append(b, 0, 0, 0xff, 0xff)
+	}
+	return string(b)
+}
+
+// lutProfile returns an sRGB profile with lut16Type tags, whose CLUTs have
+// grid points along each dimension, and a Lab profile connection space.
+func lutProfile(grid int) []byte {
+	a2b := lut16Tag(3, 3, grid, func(in []float64) []float64 {
+		var v [maxChannels]float64
+		encodePCS(&v, srgbToXYZ(in), pcsLab16)
+		return v[:3]
+	})
+	b2a := lut16Tag(3, 3, grid, func(in []float64) []float64 {
+		var v [maxChannels]float64
+		copy(v[:], in)
+		rgb := xyzToSRGB(decodePCS(&v, pcsLab16))
+		return rgb[:]
+	})
+	return buildProfile(0x04200000, DisplayClass, RGBSpace, LabSpace, [][2]string{
+		{"desc", mlucTag("LUT sRGB")},
+		{"A2B0", a2b},
+		{"B2A0", b2a},
+	})
+}
+
+// lutABProfile returns an sRGB profile with lutAToBType and lutBToAType
+// tags, which use their matrix and curves rather than CLUTs.
+func lutABProfile() []byte {
+	m := srgbMatrix()
+	inv, _ := invert3([9]float64{
+		m[0][0], m[1][0], m[2][0],
+		m[0][1], m[1][1], m[2][1],
+		m[0][2], m[1][2], m[2][2],
+	})
+	// The XYZ encoding of the profile connection space scales 1 to 0x8000,
+	// and the B curves undo that.
+	const scale = 0x8000 / float64(0xffff)
+	lut := func(typ string, mat [9]float64, b, m string) string {
+		t := []byte(typ + "\x00\x00\x00\x00\x03\x03\x00\x00")
+		t = binary.BigEndian.AppendUint32(t, 32)                  // B curves
+		t = binary.BigEndian.AppendUint32(t, uint32(32+3*len(b))) // matrix
+		t = binary.BigEndian.AppendUint32(t, uint32(32+3*len(b)+48))
+		t = append(t, make([]byte, 8)...)
+		for range 3 {
+			t = append(t, b...)
+		}
+		for _, x := range mat {
+			t = appendS15Fixed16(t, x)
+		}
+		t = append(t, make([]byte, 12)...)
+		for range 3 {
+			t = append(t, m...)
+		}
+		return string(t)
+	}
+	var fwd [9]float64
+	for r := range 3 {
+		for c := range 3 {
+			fwd[3*r+c] = m[c][r]
+		}
+	}
+	// The A2B0 tag linearizes sRGB values with the M curves, then applies
+	// the matrix and encodes the result with the B curves. The B2A0 tag
+	// decodes with the B curves and applies the inverse matrix, but its M
+	// curves are the identity, so it returns linear values.
+	a2b := lut("mAB ", fwd, paraTag(1, 1, scale, 0), paraTag(3, 2.4, 1/1.055, 0.055/1.055, 1/12.92, 0.04045))
+	b2a := lut("mBA ", inv, paraTag(1, 1, 1/scale, 0), paraTag(0, 1))
+	return buildProfile(0x04300000, DisplayClass, RGBSpace, XYZSpace, [][2]string{
+		{"desc", descTag("LUT AB linear sRGB")},
+		{"A2B0", a2b},
+		{"B2A0", b2a},
+	})
+}
+
+func mustParse(t *testing.T, b []byte) *Profile {
+	t.Helper()
+	p, err := Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func mustTransform(t *testing.T, src, dst *Profile, intent RenderingIntent) *Transform {
+	t.Helper()
+	x, err := NewTransform(src, dst, intent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+// near reports whether the colors c0 and c1 are within tolerance.
+func near(c0, c1 color.Color, tolerance uint32) bool {
+	r0, g0, b0, a0 := c0.RGBA()
+	r1, g1, b1, a1 := c1.RGBA()
+	for _, d := range [...][2]uint32{{r0, r1}, {g0, g1}, {b0, b1}, {a0, a1}} {
+		if d[0] > d[1]+tolerance || d[1] > d[0]+tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+var testColors = []color.Color{
+	color.RGBA{0, 0, 0, 0xff},
+	color.RGBA{0xff, 0xff, 0xff, 0xff},
+	color.RGBA{0xff, 0, 0, 0xff},
+	color.RGBA{0x20, 0x80, 0xe0, 0xff},
+	color.NRGBA{0x40, 0xc0, 0x10, 0x80},
+	color.Gray{0x7f},
+	color.RGBA64{0x1234, 0x5678, 0x9abc, 0xffff},
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		b       []byte
+		version uint32
+		pcs     Signature
+		desc    string
+	}{
+		{"matrix", matrixProfile(1024), 0x02100000, XYZSpace, "test sRGB"},
+		{"lut16", lutProfile(33), 0x04200000, LabSpace, "LUT sRGB"},
+		{"lutAB", lutABProfile(), 0x04300000, XYZSpace, "LUT AB linear sRGB"},
+	} {
+		p := mustParse(t, tt.b)
+		if p.Version != tt.version || p.Class != DisplayClass || p.ColorSpace != RGBSpace || p.PCS != tt.pcs || p.Description != tt.desc {
+			t.Errorf("%s: got %#x %v %v %v %q", tt.name, p.Version, p.Class, p.ColorSpace, p.PCS, p.Description)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := matrixProfile(1024)
+	for _, tt := range []struct {
+		name string
+		edit func([]byte) []byte
+		err  string
+	}{
+		{"short", func(b []byte) []byte { return b[:100] }, "short profile"},
+		{"size", func(b []byte) []byte { return b[:len(b)-1] }, "bad profile size"},
+		{"signature", func(b []byte) []byte { b[36] = 'x'; return b }, "missing acsp signature"},
+		{"version", func(b []byte) []byte { b[8] = 5; return b }, "unsupported feature: version 5"},
+		{"tag count", func(b []byte) []byte { binary.BigEndian.PutUint32(b[headerSize:], 1<<30); return b }, "bad tag count"},
+		{"tag bounds", func(b []byte) []byte { binary.BigEndian.PutUint32(b[headerSize+8:], 1<<31); return b }, "bad tag bounds"},
+		{"curve", func(b []byte) []byte {
+			i := strings.Index(string(b), "curv")
+			binary.BigEndian.PutUint32(b[i+8:], 1<<20)
+			return b
+		}, "short curve"},
+		{"white point", func(b []byte) []byte {
+			i := headerSize + strings.Index(string(b[headerSize:]), "XYZ ")
+			binary.BigEndian.PutUint32(b[i+12:], 0)
+			return b
+		}, "bad white point"},
+		{"negative white point", func(b []byte) []byte {
+			i := headerSize + strings.Index(string(b[headerSize:]), "XYZ ")
+			binary.BigEndian.PutUint32(b[i+8:], 0xffff0000)
+			return b
+		}, "bad white point"},
+	} {
+		b := tt.edit(append([]byte(nil), valid...))
+		_, err := Parse(b)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestIdentityTransforms(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		p         *Profile
+		tolerance uint32
+	}{
+		{"SRGB", SRGB, 2},
+		{"DisplayP3", DisplayP3, 2},
+		{"matrix", mustParse(t, matrixProfile(1024)), 0x20},
+		// Interpolating the CLUTs is less precise near the edges of the
+		// gamut.
+		{"lut16", mustParse(t, lutProfile(33)), 0x800},
+	} {
+		for _, intent := range []RenderingIntent{Perceptual, RelativeColorimetric, AbsoluteColorimetric} {
+			x := mustTransform(t, tt.p, tt.p, intent)
+			for _, c := range testColors {
+				if got := x.Convert(c); !near(got, c, tt.tolerance) {
+					t.Errorf("%s, intent %d: %v converts to %v", tt.name, intent, c, got)
+				}
+			}
+		}
+		// Converting from SRGB and back is also lossless, since the color
+		// spaces hold the sRGB gamut.
+		to, from := mustTransform(t, SRGB, tt.p, Perceptual), mustTransform(t, tt.p, SRGB, Perceptual)
+		for _, c := range testColors {
+			if got := from.Convert(to.Convert(c)); !near(got, c, 2*tt.tolerance) {
+				t.Errorf("%s: %v converts to %v through SRGB", tt.name, c, got)
+			}
+		}
+	}
+}
+
+func TestSRGBToDisplayP3(t *testing.T) {
+	x := mustTransform(t, SRGB, DisplayP3, RelativeColorimetric)
+	for _, tt := range []struct {
+		in   color.Color
+		want [3]float64
+	}{
+		{color.RGBA{0xff, 0, 0, 0xff}, [3]float64{0.9175, 0.2003, 0.1386}},
+		{color.RGBA{0, 0xff, 0, 0xff}, [3]float64{0.4584, 0.9853, 0.2983}},
+		{color.RGBA{0, 0, 0xff, 0xff}, [3]float64{0, 0, 0.9596}},
+		{color.RGBA{0xff, 0xff, 0xff, 0xff}, [3]float64{1, 1, 1}},
+	} {
+		r, g, b, _ := x.Convert(tt.in).RGBA()
+		got := [3]float64{float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 0.002 {
+				t.Errorf("%v: got %.4f, want %.4f", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestLUTAB(t *testing.T) {
+	p := mustParse(t, lutABProfile())
+	x := mustTransform(t, p, SRGB, Perceptual)
+	y := mustTransform(t, SRGB, p, Perceptual)
+	for _, c := range testColors {
+		r, g, b, a := c.RGBA()
+		if a != 0xffff {
+			continue
+		}
+		// The B2A0 tag returns linear values.
+		lin := y.Convert(c)
+		lr, lg, lb, _ := lin.RGBA()
+		for _, v := range [...][2]uint32{{r, lr}, {g, lg}, {b, lb}} {
+			want := srgbCurve(float64(v[0]) / 0xffff)
+			if math.Abs(float64(v[1])/0xffff-want) > 0.002 {
+				t.Errorf("%v: got linear %v, want %.4f", c, lin, want)
+			}
+		}
+		// The A2B0 tag is the sRGB transform.
+		if got := x.Convert(c); !near(got, c, 0x20) {
+			t.Errorf("%v converts to %v", c, got)
+		}
+	}
+}
+
+func TestGrayAndCMYK(t *testing.T) {
+	// A linear gray profile.
+	gray := mustParse(t, buildProfile(0x04300000, DisplayClass, GraySpace, XYZSpace, [][2]string{
+		{"kTRC", paraTag(0, 1)},
+	}))
+	x := mustTransform(t, gray, SRGB, Perceptual)
+	got := x.Convert(color.Gray{0x80})
+	want := uint32(math.Round(srgbInverse(float64(0x80)/0xff) * 0xffff))
+	if r, g, b, _ := got.RGBA(); max(r, g, b)-min(r, g, b) > 0x10 || r+0x40 < want || r > want+0x40 {
+		t.Errorf("gray 0x80 converts to %v, want gray %#x", got, want)
+	}
+	y := mustTransform(t, SRGB, gray, Perceptual)
+	if got := y.Convert(color.Gray{0xbc}); got.(color.Gray16).Y>>8 != 0x7f && got.(color.Gray16).Y>>8 != 0x80 {
+		t.Errorf("sRGB gray 0xbc converts to %v", got)
+	}
+
+	// A CMYK profile whose Lab output is only darkened by the K channel.
+	cmyk := mustParse(t, buildProfile(0x02100000, OutputClass, CMYKSpace, LabSpace, [][2]string{
+		{"A2B0", lut16Tag(4, 3, 3, func(in []float64) []float64 {
+			return []float64{1 - in[3], 0.5, 0.5}
+		})},
+		{"B2A0", lut16Tag(3, 4, 3, func(in []float64) []float64 {
+			return []float64{0, 0, 0, 1 - in[0]}
+		})},
+	}))
+	x = mustTransform(t, cmyk, SRGB, Perceptual)
+	for _, tt := range []struct {
+		in   color.CMYK
+		want color.RGBA
+	}{
+		{color.CMYK{0, 0, 0, 0}, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{color.CMYK{0xff, 0, 0, 0}, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{color.CMYK{0, 0, 0, 0xff}, color.RGBA{0, 0, 0, 0xff}},
+	} {
+		if got := x.Convert(tt.in); !near(got, tt.want, 0x300) {
+			t.Errorf("%v converts to %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	y = mustTransform(t, SRGB, cmyk, Perceptual)
+	if got := y.Convert(color.Black).(color.CMYK); got.K < 0xf0 {
+		t.Errorf("black converts to %v", got)
+	}
+}
+
+func TestNewTransformErrors(t *testing.T) {
+	lab := mustParse(t, buildProfile(0x04300000, ColorSpaceClass, LabSpace, LabSpace, nil))
+	noTags := mustParse(t, buildProfile(0x04300000, DisplayClass, RGBSpace, XYZSpace, nil))
+	for _, tt := range []struct {
+		src, dst *Profile
+		intent   RenderingIntent
+		err      string
+	}{
+		{SRGB, SRGB, 4, "rendering intent"},
+		{lab, SRGB, Perceptual, "color space Lab"},
+		{SRGB, lab, Perceptual, "color space Lab"},
+		{noTags, SRGB, Perceptual, "source profile has no transform"},
+		{SRGB, noTags, Perceptual, "destination profile has no transform"},
+	} {
+		_, err := NewTransform(tt.src, tt.dst, tt.intent)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("got error %v, want %q", err, tt.err)
+		}
+	}
+}
+
+func TestConvertAlpha(t *testing.T) {
+	x := mustTransform(t, SRGB, SRGB, Perceptual)
+	for _, c := range []color.NRGBA{{0xff, 0x80, 0, 0x80}, {0x10, 0x20, 0x30, 0}} {
+		if got := x.Convert(c); !near(got, c, 0x101) {
+			t.Errorf("%v converts to %v", c, got)
+		}
+	}
+}
+
+func TestNaN(t *testing.T) {
+	nan := math.NaN()
+	if got := (tableCurve{0.25, 0.5, 1}).eval(nan); got != 0.25 {
+		t.Errorf("tableCurve.eval(NaN) = %v, want 0.25", got)
+	}
+	c := &clut{in: 2, out: 1, grid: []int{2, 2}, data: []float64{0.5, 0, 0, 0}}
+	v := [maxChannels]float64{nan, nan}
+	if c.apply(&v, 2); v[0] != 0.5 {
+		t.Errorf("clut.apply(NaN) = %v, want 0.5", v[0])
+	}
+}
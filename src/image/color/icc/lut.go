@@ -0,0 +1,579 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package icc
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// maxChannels is the largest number of channels of a color space.
+const maxChannels = 15
+
+// pcsEncoding is the encoding of profile connection space values at the
+// boundary of a pipeline.
+type pcsEncoding int
+
+const (
+	// pcsXYZ is unencoded XYZ, where the D50 white point has a Y of 1.
+	pcsXYZ pcsEncoding = iota
+	// pcsXYZ16 is the 16-bit XYZ encoding of LUTs, where 1 is encoded as
+	// 0x8000 and normalized to [0, 1].
+	pcsXYZ16
+	// pcsLab is the Lab encoding of version 4 profiles and of 8-bit LUTs,
+	// where L in [0, 100] and a and b in [-128, 127] are normalized to
+	// [0, 1].
+	pcsLab
+	// pcsLab16 is the legacy Lab encoding of 16-bit LUTs in version 2 and
+	// version 4 profiles, where L in [0, 100] is encoded as [0, 0xff00]
+	// and a and b in [-128, 127+255/256] as [0, 0xffff].
+	pcsLab16
+)
+
+// A pipeline is a transform between device values and the profile
+// connection space. Device values are normalized to [0, 1].
+type pipeline struct {
+	in, out int
+	// pcs is the encoding of the output of a transform to the profile
+	// connection space, or of the input of a transform from it.
+	pcs    pcsEncoding
+	stages []stage
+}
+
+// eval transforms the values in v in place.
+func (p *pipeline) eval(v *[maxChannels]float64) {
+	n := p.in
+	for _, s := range p.stages {
+		n = s.apply(v, n)
+	}
+}
+
+// A stage is a step of a pipeline. It transforms the n values in v in place
+// and returns the resulting number of values.
+type stage interface {
+	apply(v *[maxChannels]float64, n int) int
+}
+
+// curves is a stage applying a curve to each channel.
+type curves []curve
+
+func (c curves) apply(v *[maxChannels]float64, n int) int {
+	for i, c := range c {
+		v[i] = c.eval(v[i])
+	}
+	return n
+}
+
+// inverseCurves is a stage applying the inverse of a curve to each channel.
+type inverseCurves []curve
+
+func (c inverseCurves) apply(v *[maxChannels]float64, n int) int {
+	for i, c := range c {
+		v[i] = c.inverse(v[i])
+	}
+	return n
+}
+
+// matrix is a stage multiplying the values by a matrix, with rows output
+// values and cols input values, and then adding an optional offset.
+type matrix struct {
+	rows, cols int
+	m          []float64 // in row major order
+	offset     []float64
+}
+
+func (m *matrix) apply(v *[maxChannels]float64, n int) int {
+	var out [maxChannels]float64
+	for r := range m.rows {
+		var sum float64
+		for c, x := range m.m[r*m.cols : (r+1)*m.cols] {
+			sum += x * v[c]
+		}
+		if m.offset != nil {
+			sum += m.offset[r]
+		}
+		out[r] = sum
+	}
+	copy(v[:m.rows], out[:m.rows])
+	return m.rows
+}
+
+// clut is a stage interpolating a multidimensional color lookup table.
+type clut struct {
+	in, out int
+	grid    []int // the number of grid points of each input dimension
+	data    []float64
+}
+
+func (c *clut) apply(v *[maxChannels]float64, n int) int {
+	var (
+		frac   [maxChannels]float64
+		stride [maxChannels]int
+	)
+	base, s := 0, c.out
+	for d := c.in - 1; d >= 0; d-- {
+		g := c.grid[d]
+		x := unit(v[d]) * float64(g-1)
+		i := min(int(x), max(g-2, 0))
+		frac[d] = x - float64(i)
+		stride[d] = s
+		base += i * s
+		s *= g
+	}
+	// Interpolate multilinearly between the corners of the enclosing cell.
+	var out [maxChannels]float64
+	for corner := range 1 << c.in {
+		w, off := 1.0, base
+		for d := range c.in {
+			if corner>>d&1 != 0 {
+				w *= frac[d]
+				off += stride[d]
+			} else {
+				w *= 1 - frac[d]
+			}
+		}
+		if w == 0 {
+			continue
+		}
+		for k, x := range c.data[off : off+c.out] {
+			out[k] += w * x
+		}
+	}
+	copy(v[:c.out], out[:c.out])
+	return c.out
+}
+
+// unit clamps x to [0, 1], mapping NaN to 0 so that x can be used to
+// compute an index.
+func unit(x float64) float64 {
+	if !(x > 0) {
+		return 0
+	}
+	return min(x, 1)
+}
+
+// A curve maps [0, 1] onto [0, 1].
+type curve interface {
+	eval(x float64) float64
+	inverse(y float64) float64
+}
+
+// tableCurve is a curve interpolating linearly between evenly spaced values.
+type tableCurve []float64
+
+func (t tableCurve) eval(x float64) float64 {
+	x = unit(x) * float64(len(t)-1)
+	i := min(int(x), len(t)-2)
+	return t[i] + (x-float64(i))*(t[i+1]-t[i])
+}
+
+// inverse returns the input value for y, assuming that t is monotonic.
+func (t tableCurve) inverse(y float64) float64 {
+	n := len(t)
+	up := t[n-1] >= t[0]
+	if up && y <= t[0] || !up && y >= t[0] {
+		return 0
+	}
+	if up && y >= t[n-1] || !up && y <= t[n-1] {
+		return 1
+	}
+	// Find the segment from t[lo] to t[hi] that y is on.
+	lo, hi := 0, n-1
+	for hi-lo > 1 {
+		mid := int(uint(lo+hi) >> 1)
+		if (t[mid] < y) == up {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (float64(lo) + (y-t[lo])/(t[hi]-t[lo])) / float64(n-1)
+}
+
+// paraCurve is a parametric curve, of one of the five function types of the
+// parametricCurveType.
+type paraCurve struct {
+	typ                 int
+	g, a, b, c, d, e, f float64
+}
+
+func (p *paraCurve) eval(x float64) float64 {
+	x = min(max(x, 0), 1)
+	var y float64
+	switch p.typ {
+	case 0:
+		y = math.Pow(x, p.g)
+	case 1:
+		if x >= -p.b/p.a {
+			y = math.Pow(p.a*x+p.b, p.g)
+		}
+	case 2:
+		y = p.c
+		if x >= -p.b/p.a {
+			y += math.Pow(p.a*x+p.b, p.g)
+		}
+	case 3:
+		if x >= p.d {
+			y = math.Pow(p.a*x+p.b, p.g)
+		} else {
+			y = p.c * x
+		}
+	case 4:
+		if x >= p.d {
+			y = math.Pow(p.a*x+p.b, p.g) + p.e
+		} else {
+			y = p.c*x + p.f
+		}
+	}
+	return min(max(y, 0), 1)
+}
+
+func (p *paraCurve) inverse(y float64) float64 {
+	y = min(max(y, 0), 1)
+	var x float64
+	switch p.typ {
+	case 0:
+		x = math.Pow(y, 1/p.g)
+	case 1:
+		x = (math.Pow(y, 1/p.g) - p.b) / p.a
+	case 2:
+		x = (math.Pow(max(y-p.c, 0), 1/p.g) - p.b) / p.a
+	case 3:
+		if p.c != 0 && y < p.c*p.d {
+			x = y / p.c
+		} else {
+			x = (math.Pow(y, 1/p.g) - p.b) / p.a
+		}
+	case 4:
+		if p.c != 0 && y < p.c*p.d+p.f {
+			x = (y - p.f) / p.c
+		} else {
+			x = (math.Pow(max(y-p.e, 0), 1/p.g) - p.b) / p.a
+		}
+	}
+	if math.IsNaN(x) {
+		return 0
+	}
+	return min(max(x, 0), 1)
+}
+
+// paraParams is the number of parameters of each parametric function type.
+var paraParams = [...]int{1, 3, 4, 5, 7}
+
+// parseCurve parses a curveType or parametricCurveType at the start of t,
+// and returns the curve and its size in bytes.
+func parseCurve(t []byte) (curve, int, error) {
+	if len(t) < 12 {
+		return nil, 0, FormatError("short curve")
+	}
+	switch string(t[:4]) {
+	case "curv":
+		n := binary.BigEndian.Uint32(t[8:])
+		if uint64(n) > uint64(len(t)-12)/2 {
+			return nil, 0, FormatError("short curve")
+		}
+		size := 12 + 2*int(n)
+		switch n {
+		case 0:
+			return &paraCurve{g: 1}, size, nil
+		case 1:
+			g := float64(binary.BigEndian.Uint16(t[12:])) / 0x100
+			return &paraCurve{g: g}, size, nil
+		}
+		c := make(tableCurve, n)
+		for i := range c {
+			c[i] = float64(binary.BigEndian.Uint16(t[12+2*i:])) / 0xffff
+		}
+		return c, size, nil
+	case "para":
+		typ := int(binary.BigEndian.Uint16(t[8:]))
+		if typ >= len(paraParams) {
+			return nil, 0, UnsupportedError("parametric curve type")
+		}
+		size := 12 + 4*paraParams[typ]
+		if len(t) < size {
+			return nil, 0, FormatError("short curve")
+		}
+		var params [7]float64
+		for i := range paraParams[typ] {
+			params[i] = s15Fixed16(t[12+4*i:])
+		}
+		p := &paraCurve{typ, params[0], params[1], params[2], params[3], params[4], params[5], params[6]}
+		if typ == 0 && p.g <= 0 || typ != 0 && (p.g <= 0 || p.a == 0) {
+			return nil, 0, FormatError("bad parametric curve")
+		}
+		return p, size, nil
+	}
+	return nil, 0, FormatError("bad curve type")
+}
+
+// parseCurves parses n curves at the start of t, each of which is padded
+// to a multiple of 4 bytes.
+func parseCurves(t []byte, n int) (curves, error) {
+	c := make(curves, n)
+	for i := range c {
+		var size int
+		var err error
+		if c[i], size, err = parseCurve(t); err != nil {
+			return nil, err
+		}
+		size = (size + 3) &^ 3
+		t = t[min(size, len(t)):]
+	}
+	return c, nil
+}
+
+// parseLUT parses an AToB or BToA tag, depending on toPCS, of a profile with
+// the profile connection space pcs.
+func parseLUT(t []byte, pcs Signature, toPCS bool) (*pipeline, error) {
+	if len(t) < 32 {
+		return nil, FormatError("short LUT")
+	}
+	in, out := int(t[8]), int(t[9])
+	if in < 1 || in > maxChannels || out < 1 || out > maxChannels {
+		return nil, FormatError("bad LUT channel count")
+	}
+	p := &pipeline{in: in, out: out}
+	var err error
+	switch typ := string(t[:4]); typ {
+	case "mft1", "mft2":
+		p.stages, err = parseLUT8or16(t, in, out, pcs == XYZSpace && !toPCS, typ == "mft1")
+		switch {
+		case pcs == XYZSpace:
+			p.pcs = pcsXYZ16
+		case typ == "mft1":
+			p.pcs = pcsLab
+		default:
+			p.pcs = pcsLab16
+		}
+	case "mAB ", "mBA ":
+		p.stages, err = parseLUTAB(t, in, out, typ == "mAB ")
+		p.pcs = pcsLab
+		if pcs == XYZSpace {
+			p.pcs = pcsXYZ16
+		}
+	default:
+		return nil, UnsupportedError("LUT type " + typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseLUT8or16 parses the stages of a lut8Type or lut16Type. The matrix is
+// only used if its input is XYZ, according to useMatrix.
+func parseLUT8or16(t []byte, in, out int, useMatrix, is8 bool) ([]stage, error) {
+	if len(t) < 48 {
+		return nil, FormatError("short LUT")
+	}
+	grid := int(t[10])
+	if grid < 2 {
+		return nil, FormatError("bad LUT grid size")
+	}
+	var stages []stage
+	if useMatrix {
+		if in != 3 {
+			return nil, FormatError("bad LUT channel count")
+		}
+		m := &matrix{rows: 3, cols: 3, m: make([]float64, 9)}
+		for i := range m.m {
+			m.m[i] = s15Fixed16(t[12+4*i:])
+		}
+		stages = append(stages, m)
+	}
+
+	inEntries, outEntries, data, size := 256, 256, t[48:], 1
+	if !is8 {
+		if len(t) < 52 {
+			return nil, FormatError("short LUT")
+		}
+		inEntries = int(binary.BigEndian.Uint16(t[48:]))
+		outEntries = int(binary.BigEndian.Uint16(t[50:]))
+		data, size = t[52:], 2
+		if inEntries < 2 || outEntries < 2 {
+			return nil, FormatError("bad LUT table size")
+		}
+	}
+	// next returns the next n values of the tag.
+	var err error
+	next := func(n int) []float64 {
+		if err != nil || n > len(data)/size {
+			err = FormatError("short LUT")
+			return nil
+		}
+		v := make([]float64, n)
+		for i := range v {
+			if is8 {
+				v[i] = float64(data[i]) / 0xff
+			} else {
+				v[i] = float64(binary.BigEndian.Uint16(data[2*i:])) / 0xffff
+			}
+		}
+		data = data[n*size:]
+		return v
+	}
+
+	inCurves := make(curves, in)
+	for i := range inCurves {
+		inCurves[i] = tableCurve(next(inEntries))
+	}
+	n := out
+	c := &clut{in: in, out: out, grid: make([]int, in)}
+	for i := range c.grid {
+		c.grid[i] = grid
+		if n > len(data) {
+			// Bound n before it can overflow.
+			n = len(data) + 1
+		}
+		n *= grid
+	}
+	c.data = next(n)
+	outCurves := make(curves, out)
+	for i := range outCurves {
+		outCurves[i] = tableCurve(next(outEntries))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(stages, inCurves, c, outCurves), nil
+}
+
+// parseLUTAB parses the stages of a lutAToBType or lutBToAType, depending
+// on aToB.
+func parseLUTAB(t []byte, in, out int, aToB bool) ([]stage, error) {
+	// element returns the data at the offset at t[i:], or nil if the
+	// offset is zero.
+	var err error
+	element := func(i int) []byte {
+		off := binary.BigEndian.Uint32(t[i:])
+		if off == 0 {
+			return nil
+		}
+		if off >= uint32(len(t)) {
+			err = FormatError("bad LUT offset")
+			return nil
+		}
+		return t[off:]
+	}
+	bData, mData, mCurveData, clutData, aData := element(12), element(16), element(20), element(24), element(28)
+	if err != nil {
+		return nil, err
+	}
+	if bData == nil {
+		return nil, FormatError("missing LUT B curves")
+	}
+
+	// In the AToB direction, the A curves and the CLUT are on the side of
+	// the in channels, and the matrix and the M and B curves on the side
+	// of the 3 out channels. The BToA direction is the other way around.
+	aChannels, bChannels := in, out
+	if !aToB {
+		aChannels, bChannels = out, in
+	}
+	if bChannels != 3 {
+		return nil, FormatError("bad LUT channel count")
+	}
+	if clutData != nil && aData == nil || clutData == nil && aChannels != bChannels {
+		return nil, FormatError("bad LUT elements")
+	}
+	var a, m, b curves
+	var mat, c stage
+	if b, err = parseCurves(bData, 3); err != nil {
+		return nil, err
+	}
+	if mData != nil {
+		if mCurveData == nil {
+			return nil, FormatError("missing LUT M curves")
+		}
+		if len(mData) < 48 {
+			return nil, FormatError("short LUT matrix")
+		}
+		mm := &matrix{rows: 3, cols: 3, m: make([]float64, 9), offset: make([]float64, 3)}
+		for i := range mm.m {
+			mm.m[i] = s15Fixed16(mData[4*i:])
+		}
+		for i := range mm.offset {
+			mm.offset[i] = s15Fixed16(mData[36+4*i:])
+		}
+		mat = mm
+	}
+	if mCurveData != nil {
+		if m, err = parseCurves(mCurveData, 3); err != nil {
+			return nil, err
+		}
+	}
+	if aData != nil {
+		if a, err = parseCurves(aData, aChannels); err != nil {
+			return nil, err
+		}
+	}
+	if clutData != nil {
+		cin, cout := aChannels, bChannels
+		if !aToB {
+			cin, cout = bChannels, aChannels
+		}
+		if c, err = parseCLUT(clutData, cin, cout); err != nil {
+			return nil, err
+		}
+	}
+
+	var stages []stage
+	add := func(s ...stage) {
+		for _, s := range s {
+			switch s := s.(type) {
+			case curves:
+				if s == nil {
+					continue
+				}
+			case nil:
+				continue
+			}
+			stages = append(stages, s)
+		}
+	}
+	if aToB {
+		add(a, c, m, mat, b)
+	} else {
+		add(b, mat, m, c, a)
+	}
+	return stages, nil
+}
+
+// parseCLUT parses the CLUT of a lutAToBType or lutBToAType.
+func parseCLUT(t []byte, in, out int) (*clut, error) {
+	if in > 15 || len(t) < 20 {
+		return nil, FormatError("short LUT")
+	}
+	c := &clut{in: in, out: out, grid: make([]int, in)}
+	n := out
+	for i := range c.grid {
+		c.grid[i] = int(t[i])
+		if c.grid[i] < 1 {
+			return nil, FormatError("bad LUT grid size")
+		}
+		if n > len(t) {
+			n = len(t) + 1
+		}
+		n *= c.grid[i]
+	}
+	size := int(t[16])
+	if size != 1 && size != 2 {
+		return nil, FormatError("bad LUT precision")
+	}
+	data := t[20:]
+	if n > len(data)/size {
+		return nil, FormatError("short LUT")
+	}
+	c.data = make([]float64, n)
+	for i := range c.data {
+		if size == 1 {
+			c.data[i] = float64(data[i]) / 0xff
+		} else {
+			c.data[i] = float64(binary.BigEndian.Uint16(data[2*i:])) / 0xffff
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,371 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package icc implements ICC color profiles, as specified by versions 2 and 4
+// of the International Color Consortium's ICC.1 specification, and color
+// conversions between them.
+//
+// Images decoded by the image packages hold colors in the color space of
+// their embedded profile, if any, which the image/color models ignore: they
+// assume sRGB. A [Transform] is a [color.Model] that converts colors from one
+// profile's color space to another's, such as from a camera's wide-gamut
+// profile to [SRGB]. The decoders of the image packages, such as
+// [image/png.DecodeICCProfile], return the embedded profiles.
+//
+// Profiles with matrix and tone reproduction curve (TRC) transforms and
+// profiles with lookup table (LUT) transforms are supported, for the Gray,
+// RGB and CMYK color spaces.
+package icc
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"unicode/utf16"
+)
+
+// A FormatError reports that the input is not a valid ICC profile.
+type FormatError string
+
+func (e FormatError) Error() string { return "icc: invalid format: " + string(e) }
+
+// An UnsupportedError reports that the input uses a valid but unimplemented
+// ICC feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string { return "icc: unsupported feature: " + string(e) }
+
+// A Signature is a four-character code identifying a tag, type or other
+// element of a profile.
+type Signature uint32
+
+func (s Signature) String() string {
+	b := binary.BigEndian.AppendUint32(nil, uint32(s))
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return "0x" + strconv.FormatUint(uint64(s), 16)
+		}
+	}
+	return string(b)
+}
+
+func sig(s string) Signature {
+	return Signature(binary.BigEndian.Uint32([]byte(s)))
+}
+
+// Profile classes.
+const (
+	InputClass      Signature = 's'<<24 | 'c'<<16 | 'n'<<8 | 'r'
+	DisplayClass    Signature = 'm'<<24 | 'n'<<16 | 't'<<8 | 'r'
+	OutputClass     Signature = 'p'<<24 | 'r'<<16 | 't'<<8 | 'r'
+	LinkClass       Signature = 'l'<<24 | 'i'<<16 | 'n'<<8 | 'k'
+	ColorSpaceClass Signature = 's'<<24 | 'p'<<16 | 'a'<<8 | 'c'
+	AbstractClass   Signature = 'a'<<24 | 'b'<<16 | 's'<<8 | 't'
+	NamedColorClass Signature = 'n'<<24 | 'm'<<16 | 'c'<<8 | 'l'
+)
+
+// Color spaces.
+const (
+	XYZSpace  Signature = 'X'<<24 | 'Y'<<16 | 'Z'<<8 | ' '
+	LabSpace  Signature = 'L'<<24 | 'a'<<16 | 'b'<<8 | ' '
+	GraySpace Signature = 'G'<<24 | 'R'<<16 | 'A'<<8 | 'Y'
+	RGBSpace  Signature = 'R'<<24 | 'G'<<16 | 'B'<<8 | ' '
+	CMYKSpace Signature = 'C'<<24 | 'M'<<16 | 'Y'<<8 | 'K'
+)
+
+// RenderingIntent is the way in which out of gamut colors are mapped when
+// converting between color spaces.
+type RenderingIntent int
+
+const (
+	Perceptual           RenderingIntent = 0
+	RelativeColorimetric RenderingIntent = 1
+	Saturation           RenderingIntent = 2
+	AbsoluteColorimetric RenderingIntent = 3
+)
+
+// d50 is the XYZ value of the D50 illuminant of the profile connection space.
+var d50 = [3]float64{0.9642, 1, 0.8249}
+
+// headerSize is the size of a profile header.
+const headerSize = 128
+
+// Profile is an ICC color profile.
+type Profile struct {
+	// Version is the version of the specification that the profile
+	// follows, with the major version in the top byte and the minor and
+	// bug fix versions in the next byte, such as 0x04300000 for 4.3.
+	Version uint32
+	// Class is the class of the profile, such as DisplayClass.
+	Class Signature
+	// ColorSpace is the color space of the device, or data, side of the
+	// profile, such as RGBSpace.
+	ColorSpace Signature
+	// PCS is the profile connection space, XYZSpace or LabSpace.
+	PCS Signature
+	// Intent is the rendering intent that the profile was created for.
+	Intent RenderingIntent
+	// Description is the profile description, meant for display.
+	Description string
+
+	// white is the media white point, used by the absolute colorimetric
+	// intent.
+	white [3]float64
+	// toPCS and fromPCS hold the transforms to and from the profile
+	// connection space for the perceptual, relative colorimetric and
+	// saturation intents, which are nil if absent.
+	toPCS, fromPCS [3]*pipeline
+	// matrix and invMatrix are the matrix/TRC transforms to and from the
+	// profile connection space, which are nil if absent.
+	matrix, invMatrix *pipeline
+}
+
+// Parse parses an ICC profile, such as one embedded in an image.
+func Parse(b []byte) (*Profile, error) {
+	if len(b) < headerSize+4 {
+		return nil, FormatError("short profile")
+	}
+	size := binary.BigEndian.Uint32(b)
+	if size < headerSize+4 || uint64(size) > uint64(len(b)) {
+		return nil, FormatError("bad profile size")
+	}
+	b = b[:size]
+	if string(b[36:40]) != "acsp" {
+		return nil, FormatError("missing acsp signature")
+	}
+	p := &Profile{
+		Version:    binary.BigEndian.Uint32(b[8:]),
+		Class:      Signature(binary.BigEndian.Uint32(b[12:])),
+		ColorSpace: Signature(binary.BigEndian.Uint32(b[16:])),
+		PCS:        Signature(binary.BigEndian.Uint32(b[20:])),
+		Intent:     RenderingIntent(binary.BigEndian.Uint16(b[66:])),
+		white:      d50,
+	}
+	if major := p.Version >> 24; major < 2 || major > 4 {
+		return nil, UnsupportedError("version " + strconv.Itoa(int(major)))
+	}
+	if p.PCS != XYZSpace && p.PCS != LabSpace && p.Class != LinkClass {
+		return nil, FormatError("bad profile connection space " + p.PCS.String())
+	}
+
+	// Read the tag table.
+	n := binary.BigEndian.Uint32(b[headerSize:])
+	if uint64(n) > uint64(len(b)-headerSize-4)/12 {
+		return nil, FormatError("bad tag count")
+	}
+	tags := make(map[Signature][]byte, n)
+	for i := range int(n) {
+		e := b[headerSize+4+12*i:]
+		off, size := binary.BigEndian.Uint32(e[4:]), binary.BigEndian.Uint32(e[8:])
+		if uint64(off)+uint64(size) > uint64(len(b)) || size < 8 {
+			return nil, FormatError("bad tag bounds")
+		}
+		s := Signature(binary.BigEndian.Uint32(e))
+		if _, ok := tags[s]; !ok {
+			tags[s] = b[off : off+size]
+		}
+	}
+
+	if t, ok := tags[sig("desc")]; ok {
+		desc, err := parseText(t)
+		if err != nil {
+			return nil, err
+		}
+		p.Description = desc
+	}
+	if t, ok := tags[sig("wtpt")]; ok {
+		white, err := parseXYZ(t)
+		if err != nil {
+			return nil, err
+		}
+		// The white point divides in absolute colorimetric transforms.
+		for _, v := range white {
+			if !(v > 0) {
+				return nil, FormatError("bad white point")
+			}
+		}
+		p.white = white
+	}
+	if err := p.parseTransforms(tags); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseTransforms parses the transforms to and from the profile connection
+// space.
+func (p *Profile) parseTransforms(tags map[Signature][]byte) error {
+	for i, names := range [3][2]string{{"A2B0", "B2A0"}, {"A2B1", "B2A1"}, {"A2B2", "B2A2"}} {
+		var err error
+		if t, ok := tags[sig(names[0])]; ok {
+			if p.toPCS[i], err = parseLUT(t, p.PCS, true); err != nil {
+				return err
+			}
+		}
+		if t, ok := tags[sig(names[1])]; ok {
+			if p.fromPCS[i], err = parseLUT(t, p.PCS, false); err != nil {
+				return err
+			}
+		}
+	}
+	if p.PCS != XYZSpace {
+		return nil
+	}
+	switch p.ColorSpace {
+	case GraySpace:
+		t, ok := tags[sig("kTRC")]
+		if !ok {
+			return nil
+		}
+		c, _, err := parseCurve(t)
+		if err != nil {
+			return err
+		}
+		// The gray value is the luminance of the D50 white point.
+		p.matrix = &pipeline{in: 1, out: 3, pcs: pcsXYZ, stages: []stage{
+			curves{c},
+			&matrix{rows: 3, cols: 1, m: d50[:]},
+		}}
+		p.invMatrix = &pipeline{in: 3, out: 1, pcs: pcsXYZ, stages: []stage{
+			&matrix{rows: 1, cols: 3, m: []float64{0, 1, 0}},
+			inverseCurves{c},
+		}}
+	case RGBSpace:
+		var m [9]float64
+		var trc curves
+		for i, c := range [3]string{"r", "g", "b"} {
+			xyz, ok1 := tags[sig(c+"XYZ")]
+			t, ok2 := tags[sig(c+"TRC")]
+			if !ok1 || !ok2 {
+				return nil
+			}
+			v, err := parseXYZ(xyz)
+			if err != nil {
+				return err
+			}
+			m[i], m[3+i], m[6+i] = v[0], v[1], v[2]
+			c, _, err := parseCurve(t)
+			if err != nil {
+				return err
+			}
+			trc = append(trc, c)
+		}
+		p.setMatrix(m, trc)
+	}
+	return nil
+}
+
+// setMatrix sets the matrix/TRC transforms of an RGB profile, given the
+// matrix whose columns are the XYZ values of the red, green and blue
+// primaries and the curves that linearize each channel.
+func (p *Profile) setMatrix(m [9]float64, trc curves) {
+	p.matrix = &pipeline{in: 3, out: 3, pcs: pcsXYZ, stages: []stage{
+		trc,
+		&matrix{rows: 3, cols: 3, m: m[:]},
+	}}
+	if inv, ok := invert3(m); ok {
+		p.invMatrix = &pipeline{in: 3, out: 3, pcs: pcsXYZ, stages: []stage{
+			&matrix{rows: 3, cols: 3, m: inv[:]},
+			inverseCurves(trc),
+		}}
+	}
+}
+
+// channels returns the number of channels of the color space s, or 0 if it
+// is not supported.
+func channels(s Signature) int {
+	switch s {
+	case GraySpace:
+		return 1
+	case RGBSpace:
+		return 3
+	case CMYKSpace:
+		return 4
+	}
+	return 0
+}
+
+// s15Fixed16 returns the signed 15.16 fixed-point number in b.
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 0x10000
+}
+
+func parseXYZ(t []byte) ([3]float64, error) {
+	if string(t[:4]) != "XYZ " || len(t) < 20 {
+		return [3]float64{}, FormatError("bad XYZ tag")
+	}
+	return [3]float64{s15Fixed16(t[8:]), s15Fixed16(t[12:]), s15Fixed16(t[16:])}, nil
+}
+
+// parseText parses a description, which is either a textDescriptionType
+// in version 2 profiles or a multiLocalizedUnicodeType in version 4.
+func parseText(t []byte) (string, error) {
+	switch string(t[:4]) {
+	case "desc":
+		if len(t) < 12 {
+			break
+		}
+		n := binary.BigEndian.Uint32(t[8:])
+		if uint64(n) > uint64(len(t)-12) {
+			break
+		}
+		s := t[12 : 12+n]
+		for len(s) > 0 && s[len(s)-1] == 0 {
+			s = s[:len(s)-1]
+		}
+		return string(s), nil
+	case "mluc":
+		if len(t) < 16 {
+			break
+		}
+		n, size := binary.BigEndian.Uint32(t[8:]), binary.BigEndian.Uint32(t[12:])
+		if size < 12 || uint64(n)*uint64(size) > uint64(len(t)-16) {
+			break
+		}
+		// Prefer English, and otherwise use the first record.
+		var rec []byte
+		for i := range int(n) {
+			r := t[16+i*int(size):]
+			if i == 0 || string(r[:2]) == "en" && string(rec[:2]) != "en" {
+				rec = r
+			}
+		}
+		if rec == nil {
+			return "", nil
+		}
+		length, off := binary.BigEndian.Uint32(rec[4:]), binary.BigEndian.Uint32(rec[8:])
+		if uint64(off)+uint64(length) > uint64(len(t)) || length%2 != 0 {
+			break
+		}
+		u := make([]uint16, length/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(t[off+2*uint32(i):])
+		}
+		return string(utf16.Decode(u)), nil
+	case "text":
+		s := t[8:]
+		for len(s) > 0 && s[len(s)-1] == 0 {
+			s = s[:len(s)-1]
+		}
+		return string(s), nil
+	}
+	return "", FormatError("bad description tag")
+}
+
+// invert3 returns the inverse of the 3x3 matrix m, and whether m is
+// invertible.
+func invert3(m [9]float64) ([9]float64, bool) {
+	c0 := m[4]*m[8] - m[5]*m[7]
+	c1 := m[5]*m[6] - m[3]*m[8]
+	c2 := m[3]*m[7] - m[4]*m[6]
+	det := m[0]*c0 + m[1]*c1 + m[2]*c2
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return [9]float64{}, false
+	}
+	return [9]float64{
+		c0 / det, (m[2]*m[7] - m[1]*m[8]) / det, (m[1]*m[5] - m[2]*m[4]) / det,
+		c1 / det, (m[0]*m[8] - m[2]*m[6]) / det, (m[2]*m[3] - m[0]*m[5]) / det,
+		c2 / det, (m[1]*m[6] - m[0]*m[7]) / det, (m[0]*m[4] - m[1]*m[3]) / det,
+	}, true
+}
@@ -0,0 +1,301 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package icc
+
+import (
+	"image/color"
+	"math"
+)
+
+// SRGB is the sRGB color space of IEC 61966-2-1, which the image/color
+// models assume.
+var SRGB = newRGBProfile("sRGB IEC61966-2.1", [3][2]float64{
+	{0.64, 0.33},
+	{0.30, 0.60},
+	{0.15, 0.06},
+})
+
+// DisplayP3 is the Display P3 color space, which has the primaries of DCI-P3
+// and the white point and transfer function of sRGB.
+var DisplayP3 = newRGBProfile("Display P3", [3][2]float64{
+	{0.680, 0.320},
+	{0.265, 0.690},
+	{0.150, 0.060},
+})
+
+// newRGBProfile returns a version 4 display profile with the given
+// primaries, given as xy chromaticities, and the D65 white point and sRGB
+// transfer function.
+func newRGBProfile(desc string, primaries [3][2]float64) *Profile {
+	const wx, wy = 0.3127, 0.3290 // D65
+	white := [3]float64{wx / wy, 1, (1 - wx - wy) / wy}
+
+	// The columns of m are the XYZ values of the primaries, scaled so that
+	// they add up to the white point.
+	var m [9]float64
+	for i, p := range primaries {
+		m[i], m[3+i], m[6+i] = p[0]/p[1], 1, (1-p[0]-p[1])/p[1]
+	}
+	inv, _ := invert3(m)
+	for i := range 3 {
+		s := inv[3*i]*white[0] + inv[3*i+1]*white[1] + inv[3*i+2]*white[2]
+		m[i], m[3+i], m[6+i] = s*m[i], s*m[3+i], s*m[6+i]
+	}
+	m = mul3(chromaticAdaptation(white, d50), m)
+
+	trc := &paraCurve{typ: 3, g: 2.4, a: 1 / 1.055, b: 0.055 / 1.055, c: 1 / 12.92, d: 0.04045}
+	p := &Profile{
+		Version:     0x04300000,
+		Class:       DisplayClass,
+		ColorSpace:  RGBSpace,
+		PCS:         XYZSpace,
+		Intent:      Perceptual,
+		Description: desc,
+		white:       d50,
+	}
+	p.setMatrix(m, curves{trc, trc, trc})
+	return p
+}
+
+// chromaticAdaptation returns the linear Bradford transform that maps the
+// XYZ values of colors seen under the white point src to those seen under
+// the white point dst.
+func chromaticAdaptation(src, dst [3]float64) [9]float64 {
+	bradford := [9]float64{
+		0.8951, 0.2664, -0.1614,
+		-0.7502, 1.7135, 0.0367,
+		0.0389, -0.0685, 1.0296,
+	}
+	inv, _ := invert3(bradford)
+	var s, d [3]float64
+	for i := range 3 {
+		for k := range 3 {
+			s[i] += bradford[3*i+k] * src[k]
+			d[i] += bradford[3*i+k] * dst[k]
+		}
+	}
+	scale := [9]float64{d[0] / s[0], 0, 0, 0, d[1] / s[1], 0, 0, 0, d[2] / s[2]}
+	return mul3(inv, mul3(scale, bradford))
+}
+
+// mul3 returns the product of the 3x3 matrices a and b.
+func mul3(a, b [9]float64) [9]float64 {
+	var m [9]float64
+	for r := range 3 {
+		for c := range 3 {
+			for k := range 3 {
+				m[3*r+c] += a[3*r+k] * b[3*k+c]
+			}
+		}
+	}
+	return m
+}
+
+// A Transform converts colors from the color space of one profile to that
+// of another. It implements the [color.Model] interface.
+type Transform struct {
+	src, dst       *Profile
+	toPCS, fromPCS *pipeline
+	absolute       bool
+	absoluteScale  [3]float64
+}
+
+// NewTransform returns a transform from the color space of the src profile to
+// that of the dst profile, with the given rendering intent.
+//
+// When a profile lacks a transform for the intent, its perceptual transform
+// is used and, failing that, its matrix/TRC transform. The color spaces of
+// both profiles must be Gray, RGB or CMYK.
+func NewTransform(src, dst *Profile, intent RenderingIntent) (*Transform, error) {
+	if intent < Perceptual || intent > AbsoluteColorimetric {
+		return nil, UnsupportedError("rendering intent")
+	}
+	t := &Transform{
+		src:      src,
+		dst:      dst,
+		absolute: intent == AbsoluteColorimetric,
+	}
+	srcChan, dstChan := channels(src.ColorSpace), channels(dst.ColorSpace)
+	if srcChan == 0 {
+		return nil, UnsupportedError("color space " + src.ColorSpace.String())
+	}
+	if dstChan == 0 {
+		return nil, UnsupportedError("color space " + dst.ColorSpace.String())
+	}
+	if src.Class == LinkClass || src.Class == NamedColorClass {
+		return nil, UnsupportedError("profile class " + src.Class.String())
+	}
+	if dst.Class == LinkClass || dst.Class == NamedColorClass {
+		return nil, UnsupportedError("profile class " + dst.Class.String())
+	}
+	t.toPCS = src.transform(src.toPCS, src.matrix, intent)
+	t.fromPCS = dst.transform(dst.fromPCS, dst.invMatrix, intent)
+	if t.toPCS == nil || t.toPCS.in != srcChan || t.toPCS.out != 3 {
+		return nil, UnsupportedError("source profile has no transform for its color space")
+	}
+	if t.fromPCS == nil || t.fromPCS.in != 3 || t.fromPCS.out != dstChan {
+		return nil, UnsupportedError("destination profile has no transform for its color space")
+	}
+	if t.absolute {
+		for i := range t.absoluteScale {
+			t.absoluteScale[i] = src.white[i] / dst.white[i]
+		}
+	}
+	return t, nil
+}
+
+// transform returns the transform for the intent, among the LUT transforms
+// luts and the matrix/TRC transform m.
+func (p *Profile) transform(luts [3]*pipeline, m *pipeline, intent RenderingIntent) *pipeline {
+	if intent == AbsoluteColorimetric {
+		// The absolute colorimetric intent scales the result of the
+		// relative colorimetric one by the media white points.
+		intent = RelativeColorimetric
+	}
+	if luts[intent] != nil {
+		return luts[intent]
+	}
+	if luts[Perceptual] != nil {
+		return luts[Perceptual]
+	}
+	return m
+}
+
+// Convert converts c, in the color space of the source profile, to the color
+// space of the destination profile.
+//
+// Colors in the RGB color space are converted without their alpha, which is
+// then reapplied, and returned as [color.RGBA64]. Colors in the Gray and CMYK
+// color spaces are returned as [color.Gray16] and [color.CMYK], and are
+// opaque.
+func (t *Transform) Convert(c color.Color) color.Color {
+	var v [maxChannels]float64
+	alpha := uint32(0xffff)
+	switch t.src.ColorSpace {
+	case GraySpace:
+		switch c := c.(type) {
+		case color.Gray:
+			v[0] = float64(c.Y) / 0xff
+		case color.Gray16:
+			v[0] = float64(c.Y) / 0xffff
+		default:
+			n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+			// These coefficients are those of color.Gray16Model.
+			y := (19595*uint32(n.R) + 38470*uint32(n.G) + 7471*uint32(n.B) + 1<<15) >> 16
+			v[0], alpha = float64(y)/0xffff, uint32(n.A)
+		}
+	case RGBSpace:
+		n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+		v[0], v[1], v[2] = float64(n.R)/0xffff, float64(n.G)/0xffff, float64(n.B)/0xffff
+		alpha = uint32(n.A)
+	case CMYKSpace:
+		k := color.CMYKModel.Convert(c).(color.CMYK)
+		v[0], v[1], v[2], v[3] = float64(k.C)/0xff, float64(k.M)/0xff, float64(k.Y)/0xff, float64(k.K)/0xff
+	}
+
+	t.apply(&v)
+
+	switch t.dst.ColorSpace {
+	case GraySpace:
+		return color.Gray16{unorm16(v[0])}
+	case RGBSpace:
+		r, g, b := uint32(unorm16(v[0])), uint32(unorm16(v[1])), uint32(unorm16(v[2]))
+		if alpha != 0xffff {
+			r, g, b = r*alpha/0xffff, g*alpha/0xffff, b*alpha/0xffff
+		}
+		return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(alpha)}
+	default:
+		return color.CMYK{unorm8(v[0]), unorm8(v[1]), unorm8(v[2]), unorm8(v[3])}
+	}
+}
+
+// apply converts the device values in v from the source color space to the
+// destination color space.
+func (t *Transform) apply(v *[maxChannels]float64) {
+	t.toPCS.eval(v)
+	xyz := decodePCS(v, t.toPCS.pcs)
+	if t.absolute {
+		for i := range xyz {
+			xyz[i] *= t.absoluteScale[i]
+		}
+	}
+	encodePCS(v, xyz, t.fromPCS.pcs)
+	t.fromPCS.eval(v)
+}
+
+// decodePCS returns the XYZ value of the first three values of v, encoded
+// with enc.
+func decodePCS(v *[maxChannels]float64, enc pcsEncoding) [3]float64 {
+	switch enc {
+	case pcsXYZ16:
+		const scale = 0xffff / float64(0x8000)
+		return [3]float64{v[0] * scale, v[1] * scale, v[2] * scale}
+	case pcsLab:
+		return labToXYZ(v[0]*100, v[1]*0xff-0x80, v[2]*0xff-0x80)
+	case pcsLab16:
+		return labToXYZ(v[0]*0xffff/0xff00*100, v[1]*0xffff/0x100-0x80, v[2]*0xffff/0x100-0x80)
+	}
+	return [3]float64{v[0], v[1], v[2]}
+}
+
+// encodePCS sets the first three values of v to the XYZ value xyz, encoded
+// with enc.
+func encodePCS(v *[maxChannels]float64, xyz [3]float64, enc pcsEncoding) {
+	switch enc {
+	case pcsXYZ:
+		v[0], v[1], v[2] = xyz[0], xyz[1], xyz[2]
+		return
+	case pcsXYZ16:
+		const scale = 0x8000 / float64(0xffff)
+		v[0], v[1], v[2] = xyz[0]*scale, xyz[1]*scale, xyz[2]*scale
+	case pcsLab:
+		l, a, b := xyzToLab(xyz)
+		v[0], v[1], v[2] = l/100, (a+0x80)/0xff, (b+0x80)/0xff
+	case pcsLab16:
+		l, a, b := xyzToLab(xyz)
+		v[0], v[1], v[2] = l/100*0xff00/0xffff, (a+0x80)*0x100/0xffff, (b+0x80)*0x100/0xffff
+	}
+	for i := range 3 {
+		v[i] = min(max(v[i], 0), 1)
+	}
+}
+
+// labToXYZ converts CIELAB to XYZ, relative to the D50 white point.
+func labToXYZ(l, a, b float64) [3]float64 {
+	f := func(t float64) float64 {
+		if t > 6.0/29 {
+			return t * t * t
+		}
+		return 3 * (6.0 / 29) * (6.0 / 29) * (t - 4.0/29)
+	}
+	fy := (l + 16) / 116
+	return [3]float64{
+		d50[0] * f(fy+a/500),
+		d50[1] * f(fy),
+		d50[2] * f(fy-b/200),
+	}
+}
+
+// xyzToLab converts XYZ to CIELAB, relative to the D50 white point.
+func xyzToLab(xyz [3]float64) (l, a, b float64) {
+	f := func(t float64) float64 {
+		if t > (6.0/29)*(6.0/29)*(6.0/29) {
+			return math.Cbrt(t)
+		}
+		return t/(3*(6.0/29)*(6.0/29)) + 4.0/29
+	}
+	fx, fy, fz := f(xyz[0]/d50[0]), f(xyz[1]/d50[1]), f(xyz[2]/d50[2])
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// unorm16 converts x in [0, 1] to a 16-bit value.
+func unorm16(x float64) uint16 {
+	return uint16(min(max(x, 0), 1)*0xffff + 0.5)
+}
+
+// unorm8 converts x in [0, 1] to an 8-bit value.
+func unorm8(x float64) uint8 {
+	return uint8(min(max(x, 0), 1)*0xff + 0.5)
+}
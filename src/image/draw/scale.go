@@ -0,0 +1,753 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package draw
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Scaler scales the part of the source image defined by src and sr and
+// writes the result of a Porter-Duff composition to the part of the
+// destination image defined by dst and dr.
+//
+// A Scaler is safe to use concurrently.
+type Scaler interface {
+	Scale(dst Image, dr image.Rectangle, src image.Image, sr image.Rectangle, op Op, opts *Options)
+}
+
+// Transformer transforms the part of the source image defined by src and sr
+// and writes the result of a Porter-Duff composition to the part of the
+// destination image defined by dst and the affine transform m applied to sr.
+//
+// For example, if m is the matrix
+//
+//	m00 m01 m02
+//	m10 m11 m12
+//
+// then the src-space point (sx, sy) maps to the dst-space point
+// (m00*sx + m01*sy + m02, m10*sx + m11*sy + m12).
+//
+// A Transformer is safe to use concurrently.
+type Transformer interface {
+	Transform(dst Image, m Aff3, src image.Image, sr image.Rectangle, op Op, opts *Options)
+}
+
+// Interpolator is an interpolation algorithm, used when dst and src pixels
+// don't have a 1:1 correspondence.
+//
+// Of the interpolators provided by this package:
+//   - [NearestNeighbor] is fast but usually looks worst.
+//   - [CatmullRom] is slow but usually looks best.
+//   - [BiLinear] has reasonable speed and quality.
+//   - [Lanczos3] is the slowest and keeps the most detail, at the cost of
+//     some ringing near sharp edges.
+type Interpolator interface {
+	Scaler
+	Transformer
+}
+
+// Aff3 is a 3x3 affine transformation matrix in row major order, where the
+// bottom row is implicitly [0 0 1].
+//
+// m[3*r + c] is the element in the r'th row and c'th column.
+type Aff3 [6]float64
+
+// Options are optional parameters to Scale and Transform.
+//
+// A nil *Options means to use the default (zero) values of each field.
+type Options struct {
+	// Masks limit what parts of the dst image are drawn to and what parts of
+	// the src image are drawn from.
+	//
+	// A dst or src mask image having a zero alpha (transparent) pixel value
+	// in the respective coordinate space means that that dst pixel is
+	// entirely unaffected or that that src pixel is considered transparent
+	// black. A full alpha (opaque) value means that the dst pixel is
+	// maximally affected or the src pixel contributes maximally. The default
+	// values, nil, are equivalent to fully opaque, infinitely large mask
+	// images.
+	//
+	// The DstMask is otherwise known as a clip mask, and its pixels map 1:1
+	// to the dst image's pixels. DstMaskP in DstMask space corresponds to
+	// image.Point{X:0, Y:0} in dst space.
+	//
+	// The SrcMask's pixels map 1:1 to the src image's pixels. SrcMaskP in
+	// SrcMask space corresponds to image.Point{X:0, Y:0} in src space.
+	DstMask  image.Image
+	DstMaskP image.Point
+	SrcMask  image.Image
+	SrcMaskP image.Point
+}
+
+var (
+	// NearestNeighbor is the nearest neighbor interpolator. It is very fast,
+	// but usually gives very low quality results. When scaling up, the
+	// result will look 'blocky'.
+	NearestNeighbor = Interpolator(nnInterpolator{})
+
+	// BiLinear is the tent kernel. It is slow, but usually gives high
+	// quality results.
+	BiLinear = &Kernel{1, func(t float64) float64 {
+		return 1 - t
+	}}
+
+	// CatmullRom is the Catmull-Rom kernel. It is very slow, but usually
+	// gives very high quality results.
+	//
+	// It is an instance of the more general cubic BC-spline kernel with
+	// parameters B=0 and C=0.5. See Mitchell and Netravali, "Reconstruction
+	// Filters in Computer Graphics", Computer Graphics, Vol. 22, No. 4, pp.
+	// 221-228.
+	CatmullRom = &Kernel{2, func(t float64) float64 {
+		if t < 1 {
+			return (1.5*t-2.5)*t*t + 1
+		}
+		return ((-0.5*t+2.5)*t-4)*t + 2
+	}}
+
+	// Lanczos3 is the Lanczos kernel with 3 lobes, a windowed sinc function.
+	// It is the slowest of the provided kernels and keeps the most detail,
+	// but it can overshoot near sharp edges.
+	Lanczos3 = &Kernel{3, func(t float64) float64 {
+		if t == 0 {
+			return 1
+		}
+		x := math.Pi * t
+		return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+	}}
+)
+
+// Kernel is an interpolator that blends source pixels weighted by a
+// symmetric kernel function.
+type Kernel struct {
+	// Support is the kernel support and must be >= 0. At(t) is assumed to be
+	// zero when t >= Support.
+	Support float64
+	// At is the kernel function. It will only be called with t in the
+	// range [0, Support).
+	At func(t float64) float64
+}
+
+// Scale implements the [Scaler] interface.
+func (q *Kernel) Scale(dst Image, dr image.Rectangle, src image.Image, sr image.Rectangle, op Op, opts *Options) {
+	q.newScaler(dr.Dx(), dr.Dy(), sr.Dx(), sr.Dy()).Scale(dst, dr, src, sr, op, opts)
+}
+
+// NewScaler returns a Scaler that is optimized for scaling multiple times with
+// the same fixed destination and source width and height.
+func (q *Kernel) NewScaler(dw, dh, sw, sh int) Scaler {
+	return q.newScaler(dw, dh, sw, sh)
+}
+
+func (q *Kernel) newScaler(dw, dh, sw, sh int) *kernelScaler {
+	z := &kernelScaler{q: q, dw: dw, dh: dh, sw: sw, sh: sh}
+	if dw > 0 && dh > 0 && sw > 0 && sh > 0 {
+		z.horizontal = newDistrib(q, dw, sw)
+		z.vertical = newDistrib(q, dh, sh)
+	}
+	return z
+}
+
+// A tap is the weight of the i'th source pixel, relative to the source
+// rectangle, in a destination pixel.
+type tap struct {
+	i int
+	w float64
+}
+
+// distrib is how source pixels contribute to destination pixels along one
+// axis: taps[off[j]:off[j+1]] are the taps of the j'th destination pixel.
+type distrib struct {
+	off  []int
+	taps []tap
+}
+
+// newDistrib returns the distribution of sw source pixels over dw
+// destination pixels. When shrinking, the kernel is widened so that every
+// source pixel contributes.
+func newDistrib(q *Kernel, dw, sw int) distrib {
+	scale := float64(sw) / float64(dw)
+	halfWidth, argScale := q.Support, 1.0
+	if scale > 1 {
+		halfWidth *= scale
+		argScale = 1 / scale
+	}
+	d := distrib{off: make([]int, dw+1)}
+	for j := 0; j < dw; j++ {
+		// center is the position of the destination pixel's center, in a
+		// space where the i'th source pixel's center is at i.
+		center := (float64(j)+0.5)*scale - 0.5
+		lo := max(int(math.Ceil(center-halfWidth)), 0)
+		hi := min(int(math.Floor(center+halfWidth)), sw-1)
+		start, sum := len(d.taps), 0.0
+		for i := lo; i <= hi; i++ {
+			t := math.Abs(center-float64(i)) * argScale
+			if t >= q.Support {
+				continue
+			}
+			if w := q.At(t); w != 0 {
+				d.taps = append(d.taps, tap{i, w})
+				sum += w
+			}
+		}
+		if sum == 0 {
+			// Fall back to the nearest source pixel.
+			d.taps = append(d.taps[:start], tap{min(max(int(math.Round(center)), 0), sw-1), 1})
+			sum = 1
+		}
+		for k := start; k < len(d.taps); k++ {
+			d.taps[k].w /= sum
+		}
+		d.off[j+1] = len(d.taps)
+	}
+	return d
+}
+
+type kernelScaler struct {
+	q                    *Kernel
+	dw, dh, sw, sh       int
+	horizontal, vertical distrib
+}
+
+func (z *kernelScaler) Scale(dst Image, dr image.Rectangle, src image.Image, sr image.Rectangle, op Op, opts *Options) {
+	if z.dw != dr.Dx() || z.dh != dr.Dy() || z.sw != sr.Dx() || z.sh != sr.Dy() {
+		z = z.q.newScaler(dr.Dx(), dr.Dy(), sr.Dx(), sr.Dy())
+	}
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	// adr is the affected destination pixels.
+	adr := dst.Bounds().Intersect(dr)
+	if adr.Empty() || sr.Empty() {
+		return
+	}
+	// Make adr relative to dr.Min.
+	adr = adr.Sub(dr.Min)
+
+	// The vertical taps of the affected rows reach the source rows syLo to
+	// syHi, and the horizontal taps of the affected columns reach the source
+	// columns sxLo to sxHi, relative to sr.Min.
+	h, v := &z.horizontal, &z.vertical
+	syLo, syHi := v.span(adr.Min.Y, adr.Max.Y)
+	sxLo, sxHi := h.span(adr.Min.X, adr.Max.X)
+
+	// The horizontal pass scales each of those source rows into tmp, which
+	// holds adr.Dx() premultiplied RGBA values per row.
+	w := adr.Dx()
+	tmp := make([]float64, 4*w*(syHi-syLo))
+	row := make([]float64, 4*(sxHi-sxLo))
+	for sy := syLo; sy < syHi; sy++ {
+		loadRow(row, src, sr.Min.X+sxLo, sr.Min.Y+sy, &o)
+		out := tmp[4*w*(sy-syLo):]
+		for x := 0; x < w; x++ {
+			taps := h.taps[h.off[adr.Min.X+x]:h.off[adr.Min.X+x+1]]
+			sumTaps((*[4]float64)(out[4*x:]), row, taps, sxLo)
+		}
+	}
+
+	// The vertical pass blends the rows of tmp into each destination row.
+	out := make([]float64, 4*w)
+	for y := adr.Min.Y; y < adr.Max.Y; y++ {
+		clear(out)
+		for _, t := range v.taps[v.off[y]:v.off[y+1]] {
+			axpy(out, tmp[4*w*(t.i-syLo):4*w*(t.i-syLo+1)], t.w)
+		}
+		storeRow(dst, dr.Min.X+adr.Min.X, dr.Min.Y+y, out, op, &o)
+	}
+}
+
+// span returns the range of source pixels that the destination pixels j0 to
+// j1 draw from.
+func (d *distrib) span(j0, j1 int) (lo, hi int) {
+	lo, hi = math.MaxInt, 0
+	for _, t := range d.taps[d.off[j0]:d.off[j1]] {
+		lo = min(lo, t.i)
+		hi = max(hi, t.i+1)
+	}
+	return lo, hi
+}
+
+// sumTapsGeneric sets dst to the sum of the RGBA values of the pixels of row,
+// weighted by taps. The first pixel of row is at index offset.
+func sumTapsGeneric(dst *[4]float64, row []float64, taps []tap, offset int) {
+	var r, g, b, a float64
+	for _, t := range taps {
+		p := row[4*(t.i-offset):][:4]
+		r += t.w * p[0]
+		g += t.w * p[1]
+		b += t.w * p[2]
+		a += t.w * p[3]
+	}
+	dst[0], dst[1], dst[2], dst[3] = r, g, b, a
+}
+
+// axpyGeneric adds w*x to y.
+func axpyGeneric(y, x []float64, w float64) {
+	x = x[:len(y)]
+	for i := range y {
+		y[i] += w * x[i]
+	}
+}
+
+// Scale implements the [Scaler] interface.
+func (nnInterpolator) Scale(dst Image, dr image.Rectangle, src image.Image, sr image.Rectangle, op Op, opts *Options) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	// adr is the affected destination pixels.
+	adr := dst.Bounds().Intersect(dr)
+	if adr.Empty() || sr.Empty() {
+		return
+	}
+	dw, dh := int64(dr.Dx()), int64(dr.Dy())
+	sw, sh := int64(sr.Dx()), int64(sr.Dy())
+	row := make([]float64, 4*sr.Dx())
+	out := make([]float64, 4*adr.Dx())
+	prevSy := -1
+	for y := adr.Min.Y; y < adr.Max.Y; y++ {
+		// The destination pixel's center maps to the source pixel sy.
+		sy := int((2*int64(y-dr.Min.Y) + 1) * sh / (2 * dh))
+		if sy != prevSy {
+			loadRow(row, src, sr.Min.X, sr.Min.Y+sy, &o)
+			prevSy = sy
+		}
+		for x := adr.Min.X; x < adr.Max.X; x++ {
+			sx := int((2*int64(x-dr.Min.X) + 1) * sw / (2 * dw))
+			copy(out[4*(x-adr.Min.X):][:4], row[4*sx:])
+		}
+		storeRow(dst, adr.Min.X, y, out, op, &o)
+	}
+}
+
+type nnInterpolator struct{}
+
+// Transform implements the [Transformer] interface.
+func (z nnInterpolator) Transform(dst Image, s2d Aff3, src image.Image, sr image.Rectangle, op Op, opts *Options) {
+	if dr, ok := scaleRect(&s2d, sr); ok {
+		z.Scale(dst, dr, src, sr, op, opts)
+		return
+	}
+	transform(dst, s2d, src, sr, op, opts, func(out *[4]float64, sx, sy float64, px pixelFunc) {
+		px(out, int(math.Floor(sx)), int(math.Floor(sy)))
+	})
+}
+
+// Transform implements the [Transformer] interface.
+func (q *Kernel) Transform(dst Image, s2d Aff3, src image.Image, sr image.Rectangle, op Op, opts *Options) {
+	if dr, ok := scaleRect(&s2d, sr); ok {
+		q.Scale(dst, dr, src, sr, op, opts)
+		return
+	}
+	d2s, ok := invert(&s2d)
+	if !ok {
+		return
+	}
+	// When shrinking, broaden the effective kernel support so that we still
+	// visit every source pixel.
+	xHalfWidth, xArgScale := q.Support, 1.0
+	if xscale := math.Max(math.Abs(d2s[0]), math.Abs(d2s[1])); xscale > 1 {
+		xHalfWidth *= xscale
+		xArgScale = 1 / xscale
+	}
+	yHalfWidth, yArgScale := q.Support, 1.0
+	if yscale := math.Max(math.Abs(d2s[3]), math.Abs(d2s[4])); yscale > 1 {
+		yHalfWidth *= yscale
+		yArgScale = 1 / yscale
+	}
+	var (
+		xWeights, yWeights []float64
+		p                  [4]float64
+	)
+	transform(dst, s2d, src, sr, op, opts, func(out *[4]float64, sx, sy float64, px pixelFunc) {
+		// Move to a space where the i'th source pixel's center is at i.
+		sx -= 0.5
+		sy -= 0.5
+		x0 := max(int(math.Ceil(sx-xHalfWidth)), sr.Min.X)
+		x1 := min(int(math.Floor(sx+xHalfWidth))+1, sr.Max.X)
+		y0 := max(int(math.Ceil(sy-yHalfWidth)), sr.Min.Y)
+		y1 := min(int(math.Floor(sy+yHalfWidth))+1, sr.Max.Y)
+		xWeights = kernelWeights(xWeights[:0], q, sx, x0, x1, xArgScale)
+		yWeights = kernelWeights(yWeights[:0], q, sy, y0, y1, yArgScale)
+		var sum [4]float64
+		total := 0.0
+		for j, yw := range yWeights {
+			if yw == 0 {
+				continue
+			}
+			for i, xw := range xWeights {
+				w := xw * yw
+				if w == 0 {
+					continue
+				}
+				px(&p, x0+i, y0+j)
+				sum[0] += w * p[0]
+				sum[1] += w * p[1]
+				sum[2] += w * p[2]
+				sum[3] += w * p[3]
+				total += w
+			}
+		}
+		if total == 0 {
+			px(out, min(max(int(math.Round(sx)), sr.Min.X), sr.Max.X-1), min(max(int(math.Round(sy)), sr.Min.Y), sr.Max.Y-1))
+			return
+		}
+		for k := range out {
+			out[k] = sum[k] / total
+		}
+	})
+}
+
+// kernelWeights appends the kernel weights of the source pixels i0 to i1 at
+// the position c to w.
+func kernelWeights(w []float64, q *Kernel, c float64, i0, i1 int, argScale float64) []float64 {
+	for i := i0; i < i1; i++ {
+		t := math.Abs(c-float64(i)) * argScale
+		if t < q.Support {
+			w = append(w, q.At(t))
+		} else {
+			w = append(w, 0)
+		}
+	}
+	return w
+}
+
+// transform calls sample for each destination pixel in the transformed
+// source rectangle, with the position of its center in source space, and
+// composes the sampled color onto dst.
+func transform(dst Image, s2d Aff3, src image.Image, sr image.Rectangle, op Op, opts *Options, sample func(out *[4]float64, sx, sy float64, px pixelFunc)) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	dr := transformRect(&s2d, sr)
+	// adr is the affected destination pixels.
+	adr := dst.Bounds().Intersect(dr)
+	if adr.Empty() || sr.Empty() {
+		return
+	}
+	d2s, ok := invert(&s2d)
+	if !ok {
+		return
+	}
+	px := newPixelFunc(src, &o)
+	out := make([]float64, 4*adr.Dx())
+	for y := adr.Min.Y; y < adr.Max.Y; y++ {
+		dyf := float64(y) + 0.5
+		for x := adr.Min.X; x < adr.Max.X; x++ {
+			dxf := float64(x) + 0.5
+			sx := d2s[0]*dxf + d2s[1]*dyf + d2s[2]
+			sy := d2s[3]*dxf + d2s[4]*dyf + d2s[5]
+			p := (*[4]float64)(out[4*(x-adr.Min.X):])
+			if !(image.Point{int(math.Floor(sx)), int(math.Floor(sy))}).In(sr) {
+				// A NaN alpha leaves the destination pixel unaffected.
+				p[3] = math.NaN()
+				continue
+			}
+			sample(p, sx, sy, px)
+		}
+		storeRow(dst, adr.Min.X, y, out, op, &o)
+	}
+}
+
+// scaleRect returns the destination rectangle of sr if s2d only scales it
+// by positive factors and translates it onto whole pixels, in which case
+// transforming is scaling.
+func scaleRect(s2d *Aff3, sr image.Rectangle) (image.Rectangle, bool) {
+	if s2d[1] != 0 || s2d[3] != 0 || s2d[0] <= 0 || s2d[4] <= 0 {
+		return image.Rectangle{}, false
+	}
+	x0 := s2d[0]*float64(sr.Min.X) + s2d[2]
+	x1 := s2d[0]*float64(sr.Max.X) + s2d[2]
+	y0 := s2d[4]*float64(sr.Min.Y) + s2d[5]
+	y1 := s2d[4]*float64(sr.Max.Y) + s2d[5]
+	for _, f := range [...]float64{x0, x1, y0, y1} {
+		if f != math.Trunc(f) || math.Abs(f) > 1<<30 {
+			return image.Rectangle{}, false
+		}
+	}
+	return image.Rect(int(x0), int(y0), int(x1), int(y1)), true
+}
+
+// transformRect returns the smallest rectangle of whole pixels that holds
+// the transform of r by s2d.
+func transformRect(s2d *Aff3, r image.Rectangle) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range [...]image.Point{r.Min, {r.Max.X, r.Min.Y}, {r.Min.X, r.Max.Y}, r.Max} {
+		x := s2d[0]*float64(p.X) + s2d[1]*float64(p.Y) + s2d[2]
+		y := s2d[3]*float64(p.X) + s2d[4]*float64(p.Y) + s2d[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	// Keep the rectangle within the range of int, for any s2d.
+	clamp := func(f float64) int {
+		return int(math.Max(math.Min(f, 1<<30), -1<<30))
+	}
+	return image.Rect(clamp(math.Floor(minX)), clamp(math.Floor(minY)), clamp(math.Ceil(maxX)), clamp(math.Ceil(maxY)))
+}
+
+// invert returns the inverse of m, and whether m is invertible.
+func invert(m *Aff3) (Aff3, bool) {
+	m00 := +m[3*1+1]
+	m01 := -m[3*0+1]
+	m02 := +m[3*1+2]*m[3*0+1] - m[3*1+1]*m[3*0+2]
+	m10 := -m[3*1+0]
+	m11 := +m[3*0+0]
+	m12 := +m[3*1+0]*m[3*0+2] - m[3*1+2]*m[3*0+0]
+
+	det := m00*m11 - m10*m01
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Aff3{}, false
+	}
+	return Aff3{
+		m00 / det,
+		m01 / det,
+		m02 / det,
+		m10 / det,
+		m11 / det,
+		m12 / det,
+	}, true
+}
+
+// A pixelFunc stores the premultiplied RGBA value of the src pixel at (x, y),
+// as float64s in the range [0, 0xffff], in out.
+type pixelFunc func(out *[4]float64, x, y int)
+
+// newPixelFunc returns the pixelFunc of src, with the src mask of o applied.
+func newPixelFunc(src image.Image, o *Options) pixelFunc {
+	var px pixelFunc
+	b := src.Bounds()
+	switch src := src.(type) {
+	case *image.RGBA:
+		px = func(out *[4]float64, x, y int) {
+			if !(image.Point{x, y}).In(b) {
+				*out = [4]float64{}
+				return
+			}
+			p := src.Pix[src.PixOffset(x, y):][:4]
+			*out = [4]float64{float64(p[0]) * 0x101, float64(p[1]) * 0x101, float64(p[2]) * 0x101, float64(p[3]) * 0x101}
+		}
+	case *image.NRGBA:
+		px = func(out *[4]float64, x, y int) {
+			if !(image.Point{x, y}).In(b) {
+				*out = [4]float64{}
+				return
+			}
+			p := src.Pix[src.PixOffset(x, y):][:4]
+			a := float64(p[3]) * (0x101 / 255.0)
+			*out = [4]float64{float64(p[0]) * a, float64(p[1]) * a, float64(p[2]) * a, float64(p[3]) * 0x101}
+		}
+	case image.RGBA64Image:
+		px = func(out *[4]float64, x, y int) {
+			c := src.RGBA64At(x, y)
+			*out = [4]float64{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+		}
+	default:
+		px = func(out *[4]float64, x, y int) {
+			r, g, b, a := src.At(x, y).RGBA()
+			*out = [4]float64{float64(r), float64(g), float64(b), float64(a)}
+		}
+	}
+	if o.SrcMask == nil {
+		return px
+	}
+	mask, mp := o.SrcMask, o.SrcMaskP
+	return func(out *[4]float64, x, y int) {
+		px(out, x, y)
+		_, _, _, ma := mask.At(x+mp.X, y+mp.Y).RGBA()
+		for i := range out {
+			out[i] *= float64(ma) / 0xffff
+		}
+	}
+}
+
+// loadRow stores the premultiplied RGBA values of the len(row)/4 src pixels
+// starting at (x, y) in row, as float64s in the range [0, 0xffff]. Pixels
+// outside of the bounds of src are transparent.
+func loadRow(row []float64, src image.Image, x, y int, o *Options) {
+	n := len(row) / 4
+	// The fast paths cover the pixels from x0 to x1, within the bounds of
+	// src.
+	b := src.Bounds()
+	x0, x1 := max(x, b.Min.X), min(x+n, b.Max.X)
+	fast := b.Min.Y <= y && y < b.Max.Y && x0 < x1
+	if fast {
+		switch src := src.(type) {
+		case *image.RGBA:
+			pix := src.Pix[src.PixOffset(x0, y):][:4*(x1-x0)]
+			out := row[4*(x0-x):][:len(pix)]
+			for i, p := range pix {
+				out[i] = float64(p) * 0x101
+			}
+		case *image.NRGBA:
+			pix := src.Pix[src.PixOffset(x0, y):][:4*(x1-x0)]
+			out := row[4*(x0-x):][:len(pix)]
+			for i := 0; i < len(pix); i += 4 {
+				a := float64(pix[i+3]) * (0x101 / 255.0)
+				out[i+0] = float64(pix[i+0]) * a
+				out[i+1] = float64(pix[i+1]) * a
+				out[i+2] = float64(pix[i+2]) * a
+				out[i+3] = float64(pix[i+3]) * 0x101
+			}
+		case *image.YCbCr:
+			for sx := x0; sx < x1; sx++ {
+				yi, ci := src.YOffset(sx, y), src.COffset(sx, y)
+				r, g, b, _ := color.YCbCr{src.Y[yi], src.Cb[ci], src.Cr[ci]}.RGBA()
+				out := row[4*(sx-x):][:4]
+				out[0], out[1], out[2], out[3] = float64(r), float64(g), float64(b), 0xffff
+			}
+		default:
+			fast = false
+		}
+	}
+	if !fast {
+		x0, x1 = x, x
+	}
+	if x0 > x || x1 < x+n {
+		px := newPixelFunc(src, &Options{})
+		for sx := x; sx < x0; sx++ {
+			px((*[4]float64)(row[4*(sx-x):]), sx, y)
+		}
+		for sx := x1; sx < x+n; sx++ {
+			px((*[4]float64)(row[4*(sx-x):]), sx, y)
+		}
+	}
+	if o.SrcMask != nil {
+		for sx := x; sx < x+n; sx++ {
+			_, _, _, ma := o.SrcMask.At(sx+o.SrcMaskP.X, y+o.SrcMaskP.Y).RGBA()
+			out := row[4*(sx-x):][:4]
+			for i := range out {
+				out[i] *= float64(ma) / 0xffff
+			}
+		}
+	}
+}
+
+// storeRow composes the len(row)/4 premultiplied RGBA values of row onto the
+// dst pixels starting at (x, y). The values are clamped to valid colors,
+// since kernels with negative lobes can overshoot. A NaN alpha leaves its
+// pixel unaffected.
+func storeRow(dst Image, x, y int, row []float64, op Op, o *Options) {
+	n := len(row) / 4
+	for i := 0; i < n; i++ {
+		p := row[4*i:][:4]
+		if math.IsNaN(p[3]) {
+			continue
+		}
+		a := math.Min(math.Max(p[3], 0), 0xffff)
+		p[0] = math.Min(math.Max(p[0], 0), a)
+		p[1] = math.Min(math.Max(p[1], 0), a)
+		p[2] = math.Min(math.Max(p[2], 0), a)
+		p[3] = a
+	}
+	if o.DstMask == nil {
+		switch dst := dst.(type) {
+		case *image.RGBA:
+			pix := dst.Pix[dst.PixOffset(x, y):][:4*n]
+			for i := 0; i < len(pix); i += 4 {
+				if math.IsNaN(row[i+3]) {
+					continue
+				}
+				sr, sg, sb, sa := round16(row[i+0]), round16(row[i+1]), round16(row[i+2]), round16(row[i+3])
+				d := pix[i : i+4 : i+4]
+				if op == Over {
+					a := (m - sa) * 0x101
+					d[0] = uint8((uint32(d[0])*a/m + sr) >> 8)
+					d[1] = uint8((uint32(d[1])*a/m + sg) >> 8)
+					d[2] = uint8((uint32(d[2])*a/m + sb) >> 8)
+					d[3] = uint8((uint32(d[3])*a/m + sa) >> 8)
+				} else {
+					d[0] = uint8(sr >> 8)
+					d[1] = uint8(sg >> 8)
+					d[2] = uint8(sb >> 8)
+					d[3] = uint8(sa >> 8)
+				}
+			}
+			return
+		case *image.NRGBA:
+			pix := dst.Pix[dst.PixOffset(x, y):][:4*n]
+			for i := 0; i < len(pix); i += 4 {
+				if math.IsNaN(row[i+3]) {
+					continue
+				}
+				sr, sg, sb, sa := round16(row[i+0]), round16(row[i+1]), round16(row[i+2]), round16(row[i+3])
+				d := pix[i : i+4 : i+4]
+				if op == Over {
+					// Convert the destination to premultiplied color and
+					// back.
+					da := uint32(d[3]) * 0x101
+					dr := uint32(d[0]) * da / 0xff
+					dg := uint32(d[1]) * da / 0xff
+					db := uint32(d[2]) * da / 0xff
+					a := m - sa
+					sr = dr*a/m + sr
+					sg = dg*a/m + sg
+					sb = db*a/m + sb
+					sa = da*a/m + sa
+				}
+				if sa == 0 {
+					d[0], d[1], d[2], d[3] = 0, 0, 0, 0
+					continue
+				}
+				d[0] = uint8(sr * 0xffff / sa >> 8)
+				d[1] = uint8(sg * 0xffff / sa >> 8)
+				d[2] = uint8(sb * 0xffff / sa >> 8)
+				d[3] = uint8(sa >> 8)
+			}
+			return
+		}
+	}
+	dst64, _ := dst.(RGBA64Image)
+	for i := 0; i < n; i++ {
+		p := row[4*i:][:4]
+		if math.IsNaN(p[3]) {
+			continue
+		}
+		sr, sg, sb, sa := round16(p[0]), round16(p[1]), round16(p[2]), round16(p[3])
+		ma := uint32(m)
+		if o.DstMask != nil {
+			_, _, _, ma = o.DstMask.At(x+i+o.DstMaskP.X, y+o.DstMaskP.Y).RGBA()
+			if ma == 0 {
+				continue
+			}
+		}
+		var c color.RGBA64
+		if op == Over || ma != m {
+			dr, dg, db, da := dst.At(x+i, y).RGBA()
+			// With Over, the mask scales the source, and the destination
+			// shows through the rest. With Src, the destination only shows
+			// through where the mask is not opaque.
+			var a uint32
+			if op == Over {
+				a = m - sa*ma/m
+			} else {
+				a = m - ma
+			}
+			c = color.RGBA64{
+				uint16((dr*a + sr*ma) / m),
+				uint16((dg*a + sg*ma) / m),
+				uint16((db*a + sb*ma) / m),
+				uint16((da*a + sa*ma) / m),
+			}
+		} else {
+			c = color.RGBA64{uint16(sr), uint16(sg), uint16(sb), uint16(sa)}
+		}
+		if dst64 != nil {
+			dst64.SetRGBA64(x+i, y, c)
+		} else {
+			dst.Set(x+i, y, c)
+		}
+	}
+}
+
+// round16 rounds f, which is in the range [0, 0xffff], to the nearest
+// integer.
+func round16(f float64) uint32 {
+	return uint32(f + 0.5)
+}
@@ -0,0 +1,15 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !(goexperiment.simd && amd64)
+
+package draw
+
+func sumTaps(dst *[4]float64, row []float64, taps []tap, offset int) {
+	sumTapsGeneric(dst, row, taps, offset)
+}
+
+func axpy(y, x []float64, w float64) {
+	axpyGeneric(y, x, w)
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.simd && amd64
+
+package draw
+
+import "simd/archsimd"
+
+var useAVX = archsimd.X86.AVX()
+
+// sumTaps is like sumTapsGeneric, but it processes the four channels of a
+// pixel as one vector.
+func sumTaps(dst *[4]float64, row []float64, taps []tap, offset int) {
+	if !useAVX {
+		sumTapsGeneric(dst, row, taps, offset)
+		return
+	}
+	var sum archsimd.Float64x4
+	for _, t := range taps {
+		p := archsimd.LoadFloat64x4Slice(row[4*(t.i-offset):])
+		sum = sum.Add(p.Mul(archsimd.BroadcastFloat64x4(t.w)))
+	}
+	sum.Store(dst)
+}
+
+// axpy is like axpyGeneric, but it processes four values at a time. len(y)
+// must be a multiple of 4.
+func axpy(y, x []float64, w float64) {
+	if !useAVX {
+		axpyGeneric(y, x, w)
+		return
+	}
+	x = x[:len(y)]
+	vw := archsimd.BroadcastFloat64x4(w)
+	for i := 0; i < len(y); i += 4 {
+		vx := archsimd.LoadFloat64x4Slice(x[i:])
+		vy := archsimd.LoadFloat64x4Slice(y[i:])
+		vy.Add(vx.Mul(vw)).StoreSlice(y[i:])
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package draw
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+var interpolators = []struct {
+	name string
+	q    Interpolator
+}{
+	{"NearestNeighbor", NearestNeighbor},
+	{"BiLinear", BiLinear},
+	{"CatmullRom", CatmullRom},
+	{"Lanczos3", Lanczos3},
+}
+
+// testImage returns an image with a gradient, a translucent band and a hard
+// edge.
+func testImage(r image.Rectangle) *image.RGBA {
+	m := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			a := uint8(0xff)
+			if (x+y)%7 == 0 {
+				a = 0x80
+			}
+			c := color.NRGBA{uint8(x * 13), uint8(y * 29), uint8(x * y), a}
+			if x > r.Min.X+r.Dx()/2 {
+				c.B = 0xff
+			}
+			m.Set(x, y, c)
+		}
+	}
+	return m
+}
+
+// opaqueImage hides the concrete type of an image, to test the generic code
+// paths.
+type opaqueImage struct {
+	image.Image
+}
+
+type opaqueDst struct {
+	Image
+}
+
+func sameImage(m0, m1 image.Image, tolerance int) error {
+	b := m0.Bounds()
+	if b != m1.Bounds() {
+		return fmt.Errorf("bounds differ: %v and %v", b, m1.Bounds())
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r0, g0, b0, a0 := m0.At(x, y).RGBA()
+			r1, g1, b1, a1 := m1.At(x, y).RGBA()
+			for _, d := range [...]int{int(r0) - int(r1), int(g0) - int(g1), int(b0) - int(b1), int(a0) - int(a1)} {
+				if d > tolerance || d < -tolerance {
+					return fmt.Errorf("pixels at (%d, %d) differ: %v and %v", x, y, m0.At(x, y), m1.At(x, y))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestScaleIdentity(t *testing.T) {
+	src := testImage(image.Rect(3, 4, 23, 19))
+	for _, tt := range interpolators {
+		dst := image.NewRGBA(image.Rect(0, 0, 20, 15))
+		tt.q.Scale(dst, dst.Bounds(), src, src.Bounds(), Src, nil)
+		want := image.NewRGBA(dst.Bounds())
+		Draw(want, want.Bounds(), src, src.Bounds().Min, Src)
+		if err := sameImage(dst, want, 0); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestScaleUniform(t *testing.T) {
+	c := color.NRGBA{0x40, 0x80, 0xc0, 0xa0}
+	r := image.Rect(0, 0, 13, 9)
+	rgba := image.NewRGBA(r)
+	nrgba := image.NewNRGBA(r)
+	ycbcr := image.NewYCbCr(r, image.YCbCrSubsampleRatio420)
+	for y := 0; y < r.Max.Y; y++ {
+		for x := 0; x < r.Max.X; x++ {
+			rgba.Set(x, y, c)
+			nrgba.Set(x, y, c)
+		}
+	}
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = 0x70
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = 0x60, 0x90
+	}
+	for _, tt := range interpolators {
+		for _, src := range []image.Image{rgba, nrgba, ycbcr, opaqueImage{nrgba}} {
+			want := src.At(0, 0)
+			for _, size := range []image.Point{{5, 4}, {13, 9}, {40, 31}} {
+				for _, dst := range []Image{
+					image.NewRGBA(image.Rectangle{Max: size}),
+					image.NewNRGBA(image.Rectangle{Max: size}),
+					image.NewRGBA64(image.Rectangle{Max: size}),
+				} {
+					tt.q.Scale(dst, dst.Bounds(), src, src.Bounds(), Src, nil)
+					u := image.NewUniform(want)
+					m := image.NewRGBA64(dst.Bounds())
+					Draw(m, m.Bounds(), u, image.Point{}, Src)
+					// Converting to 8-bit destination colors can round
+					// differently from converting the source color.
+					if err := sameImage(dst, m, 0x101); err != nil {
+						t.Errorf("%s: %T to %T %v: %v", tt.name, src, dst, size, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestScaleFastPaths tests that the fast paths for particular image types
+// give the same results as the generic code.
+func TestScaleFastPaths(t *testing.T) {
+	rgba := testImage(image.Rect(1, 2, 31, 22))
+	nrgba := image.NewNRGBA(rgba.Bounds())
+	Draw(nrgba, nrgba.Bounds(), rgba, rgba.Bounds().Min, Src)
+	ycbcr := image.NewYCbCr(rgba.Bounds(), image.YCbCrSubsampleRatio422)
+	for y := rgba.Rect.Min.Y; y < rgba.Rect.Max.Y; y++ {
+		for x := rgba.Rect.Min.X; x < rgba.Rect.Max.X; x++ {
+			ycbcr.Y[ycbcr.YOffset(x, y)] = uint8(x * y)
+			ycbcr.Cb[ycbcr.COffset(x, y)] = uint8(x * 9)
+			ycbcr.Cr[ycbcr.COffset(x, y)] = uint8(y * 11)
+		}
+	}
+	dr := image.Rect(-3, 5, 40, 33)
+	sr := image.Rect(0, 3, 25, 24) // Partly outside of the sources.
+	for _, tt := range interpolators {
+		for _, src := range []image.Image{rgba, nrgba, ycbcr} {
+			for _, op := range []Op{Over, Src} {
+				for _, newDst := range []func(image.Rectangle) Image{
+					func(r image.Rectangle) Image { return image.NewRGBA(r) },
+					func(r image.Rectangle) Image { return image.NewNRGBA(r) },
+				} {
+					// Start from a non-trivial destination, so that Over
+					// blends with something.
+					got, want := newDst(image.Rect(0, 0, 36, 30)), newDst(image.Rect(0, 0, 36, 30))
+					Draw(got, got.Bounds(), testImage(got.Bounds()), image.Point{}, Src)
+					Draw(want, want.Bounds(), got, image.Point{}, Src)
+
+					tt.q.Scale(got, dr, src, sr, op, nil)
+					tt.q.Scale(opaqueDst{want}, dr, opaqueImage{src}, sr, op, nil)
+					if err := sameImage(got, want, 0x101); err != nil {
+						t.Errorf("%s: %T to %T, op %d: %v", tt.name, src, got, op, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNewScaler(t *testing.T) {
+	src := testImage(image.Rect(0, 0, 30, 20))
+	z := CatmullRom.NewScaler(12, 10, 30, 20)
+	got := image.NewRGBA(image.Rect(0, 0, 12, 10))
+	want := image.NewRGBA(image.Rect(0, 0, 12, 10))
+	z.Scale(got, got.Bounds(), src, src.Bounds(), Src, nil)
+	CatmullRom.Scale(want, want.Bounds(), src, src.Bounds(), Src, nil)
+	if err := sameImage(got, want, 0); err != nil {
+		t.Error(err)
+	}
+	// Other sizes still work.
+	got = image.NewRGBA(image.Rect(0, 0, 7, 7))
+	want = image.NewRGBA(image.Rect(0, 0, 7, 7))
+	z.Scale(got, got.Bounds(), src, src.Bounds(), Src, nil)
+	CatmullRom.Scale(want, want.Bounds(), src, src.Bounds(), Src, nil)
+	if err := sameImage(got, want, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScaleDown(t *testing.T) {
+	// Shrinking a checkerboard by a large factor averages it to gray,
+	// since the kernels are widened to cover every source pixel.
+	src := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{0xff})
+			}
+		}
+	}
+	for _, tt := range interpolators[1:] {
+		dst := image.NewGray(image.Rect(0, 0, 4, 4))
+		tt.q.Scale(dst, dst.Bounds(), src, src.Bounds(), Src, nil)
+		for _, p := range dst.Pix {
+			if p < 0x78 || p > 0x88 {
+				t.Errorf("%s: got pixels %v, want gray", tt.name, dst.Pix)
+				break
+			}
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	src := testImage(image.Rect(2, 3, 12, 9))
+	// Rotating by 90 degrees maps pixel centers to pixel centers, so every
+	// interpolator copies the pixels.
+	rot := Aff3{
+		0, -1, 20,
+		1, 0, -1,
+	}
+	want := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			// The pixel whose top-left corner is (x, y) has its top-right
+			// corner at (x, y) after the rotation.
+			want.Set(20-y-1, x-1, src.At(x, y))
+		}
+	}
+	for _, tt := range interpolators {
+		dst := image.NewRGBA(image.Rect(0, 0, 20, 20))
+		tt.q.Transform(dst, rot, src, src.Bounds(), Src, nil)
+		if err := sameImage(dst, want, 0); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+
+	// Scaling and translating by whole pixels is the same as Scale.
+	scale := Aff3{
+		2, 0, 5,
+		0, 3, -4,
+	}
+	for _, tt := range interpolators {
+		got := image.NewRGBA(image.Rect(0, 0, 30, 30))
+		want := image.NewRGBA(image.Rect(0, 0, 30, 30))
+		tt.q.Transform(got, scale, src, src.Bounds(), Src, nil)
+		tt.q.Scale(want, image.Rect(9, 5, 29, 23), src, src.Bounds(), Src, nil)
+		if err := sameImage(got, want, 0); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestTransformLeavesOtherPixels(t *testing.T) {
+	src := image.NewUniform(color.RGBA{0, 0, 0xff, 0xff})
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	// Rotate a square by 45 degrees around its center.
+	const c, s = 0.7071067811865476, 0.7071067811865476
+	m := Aff3{
+		c, -s, 20 - 10*c + 10*s,
+		s, c, 20 - 10*s - 10*c,
+	}
+	for _, tt := range interpolators {
+		dst := image.NewRGBA(image.Rect(0, 0, 40, 40))
+		Draw(dst, dst.Bounds(), image.NewUniform(red), image.Point{}, Src)
+		tt.q.Transform(dst, m, src, image.Rect(0, 0, 20, 20), Src, nil)
+		// The center is blue, while the corners, outside of the rotated
+		// square, are still red.
+		if got := dst.RGBAAt(20, 20); got != (color.RGBA{0, 0, 0xff, 0xff}) {
+			t.Errorf("%s: center is %v", tt.name, got)
+		}
+		for _, p := range []image.Point{{0, 0}, {39, 0}, {0, 39}, {39, 39}, {6, 6}} {
+			if got := dst.RGBAAt(p.X, p.Y); got != red {
+				t.Errorf("%s: pixel at %v is %v, want %v", tt.name, p, got, red)
+			}
+		}
+	}
+	// A singular matrix draws nothing.
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	BiLinear.Transform(dst, Aff3{1, 1, 0, 1, 1, 0}, src, image.Rect(0, 0, 4, 4), Src, nil)
+	if got := dst.RGBAAt(1, 1); got != (color.RGBA{}) {
+		t.Errorf("singular transform drew %v", got)
+	}
+}
+
+func TestScaleOptions(t *testing.T) {
+	blue := image.NewUniform(color.RGBA{0, 0, 0xff, 0xff})
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	// The dst mask is opaque on its left half and transparent on its right
+	// half. The src mask is half transparent everywhere.
+	dstMask := image.NewAlpha(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			dstMask.SetAlpha(x, y, color.Alpha{0xff})
+		}
+	}
+	srcMask := image.NewUniform(color.Alpha{0x80})
+	for _, tt := range interpolators {
+		for _, op := range []Op{Over, Src} {
+			dst := image.NewRGBA(image.Rect(0, 0, 8, 4))
+			Draw(dst, dst.Bounds(), image.NewUniform(red), image.Point{}, Src)
+			tt.q.Scale(dst, dst.Bounds(), blue, image.Rect(0, 0, 2, 2), op, &Options{
+				DstMask:  dstMask,
+				SrcMask:  srcMask,
+				SrcMaskP: image.Point{5, 5},
+			})
+			if got := dst.RGBAAt(7, 0); got != red {
+				t.Errorf("%s, op %d: masked pixel is %v, want %v", tt.name, op, got, red)
+			}
+			var want color.RGBA
+			if op == Over {
+				want = color.RGBA{0x7f, 0, 0x80, 0xff}
+			} else {
+				want = color.RGBA{0, 0, 0x80, 0x80}
+			}
+			if got := dst.RGBAAt(0, 0); got != want {
+				t.Errorf("%s, op %d: pixel is %v, want %v", tt.name, op, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkScaleRGBA(b *testing.B) {
+	src := testImage(image.Rect(0, 0, 400, 300))
+	for _, tt := range interpolators {
+		for _, size := range []image.Point{{200, 150}, {800, 600}} {
+			b.Run(fmt.Sprintf("%s/%dx%d", tt.name, size.X, size.Y), func(b *testing.B) {
+				dst := image.NewRGBA(image.Rectangle{Max: size})
+				b.ReportAllocs()
+				for b.Loop() {
+					tt.q.Scale(dst, dst.Bounds(), src, src.Bounds(), Src, nil)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkTransformRGBA(b *testing.B) {
+	src := testImage(image.Rect(0, 0, 200, 200))
+	const c, s = 0.8, 0.6
+	m := Aff3{
+		c, -s, 150,
+		s, c, 0,
+	}
+	for _, tt := range interpolators {
+		b.Run(tt.name, func(b *testing.B) {
+			dst := image.NewRGBA(image.Rect(0, 0, 300, 300))
+			b.ReportAllocs()
+			for b.Loop() {
+				tt.q.Transform(dst, m, src, src.Bounds(), Over, nil)
+			}
+		})
+	}
+}
@@ -61,6 +61,8 @@ const (
 	// but in practice, their use is described at
 	// https://www.sno.phy.queensu.ca/~phil/exiftool/TagNames/JPEG.html
 	app0Marker  = 0xe0
+	app1Marker  = 0xe1
+	app2Marker  = 0xe2
 	app14Marker = 0xee
 	app15Marker = 0xef
 )
@@ -86,6 +88,16 @@ var unzig = [blockSize]int{
 	53, 60, 61, 54, 47, 55, 62, 63,
 }
 
+// A Segment is an application-specific marker segment, such as the APP1
+// segment that holds Exif metadata or the APP2 segments that hold an ICC
+// profile.
+type Segment struct {
+	// Marker is the segment's marker, from 0xe0 (APP0) to 0xef (APP15).
+	Marker byte
+	// Data is the segment's payload, after its length.
+	Data []byte
+}
+
 // Deprecated: Reader is not used by the [image/jpeg] package and should
 // not be used by others. It is kept for compatibility.
 type Reader interface {
@@ -135,6 +147,9 @@ type decoder struct {
 	baseline    bool
 	progressive bool
 
+	// segments, if non-nil, collects the application segments.
+	segments []Segment
+
 	jfif                bool
 	adobeTransformValid bool
 	adobeTransform      uint8
@@ -488,7 +503,7 @@ func (d *decoder) processApp0Marker(n int) error {
 	}
 	n -= 5
 
-	d.jfif = d.tmp[0] == 'J' && d.tmp[1] == 'F' && d.tmp[2] == 'I' && d.tmp[3] == 'F' && d.tmp[4] == '\x00'
+	d.jfif = isJFIF(d.tmp[:5])
 
 	if n > 0 {
 		return d.ignore(n)
@@ -496,6 +511,11 @@ func (d *decoder) processApp0Marker(n int) error {
 	return nil
 }
 
+// isJFIF reports whether an APP0 segment starting with p is a JFIF segment.
+func isJFIF(p []byte) bool {
+	return p[0] == 'J' && p[1] == 'F' && p[2] == 'I' && p[3] == 'F' && p[4] == '\x00'
+}
+
 func (d *decoder) processApp14Marker(n int) error {
 	if n < 12 {
 		return d.ignore(n)
@@ -505,10 +525,7 @@ func (d *decoder) processApp14Marker(n int) error {
 	}
 	n -= 12
 
-	if d.tmp[0] == 'A' && d.tmp[1] == 'd' && d.tmp[2] == 'o' && d.tmp[3] == 'b' && d.tmp[4] == 'e' {
-		d.adobeTransformValid = true
-		d.adobeTransform = d.tmp[11]
-	}
+	d.processAdobe(d.tmp[:12])
 
 	if n > 0 {
 		return d.ignore(n)
@@ -516,6 +533,32 @@ func (d *decoder) processApp14Marker(n int) error {
 	return nil
 }
 
+// processAdobe notes the color transform of an APP14 segment starting with
+// the 12 bytes of p, if it is an Adobe segment.
+func (d *decoder) processAdobe(p []byte) {
+	if p[0] == 'A' && p[1] == 'd' && p[2] == 'o' && p[3] == 'b' && p[4] == 'e' {
+		d.adobeTransformValid = true
+		d.adobeTransform = p[11]
+	}
+}
+
+// processSegment reads an application segment into d.segments, noting its
+// JFIF or Adobe metadata.
+func (d *decoder) processSegment(marker byte, n int) error {
+	data := make([]byte, n)
+	if err := d.readFull(data); err != nil {
+		return err
+	}
+	d.segments = append(d.segments, Segment{Marker: marker, Data: data})
+	switch {
+	case marker == app0Marker && n >= 5:
+		d.jfif = isJFIF(data)
+	case marker == app14Marker && n >= 12:
+		d.processAdobe(data)
+	}
+	return nil
+}
+
 // decode reads a JPEG image from r and returns it as an image.Image.
 func (d *decoder) decode(r io.Reader, configOnly bool) (image.Image, error) {
 	d.r = r
@@ -597,6 +640,13 @@ func (d *decoder) decode(r io.Reader, configOnly bool) (image.Image, error) {
 			return nil, FormatError("short segment length")
 		}
 
+		if d.segments != nil && app0Marker <= marker && marker <= app15Marker {
+			if err := d.processSegment(marker, n); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		switch marker {
 		case sof0Marker, sof1Marker, sof2Marker:
 			d.baseline = marker == sof0Marker
@@ -773,6 +823,33 @@ func Decode(r io.Reader) (image.Image, error) {
 	return d.decode(r, false)
 }
 
+// DecodeWithSegments reads a JPEG image from r like [Decode] and also returns
+// its application segments, in the order in which they appear. Passing them
+// as [Options.Segments] to [Encode] preserves metadata such as Exif and ICC
+// profiles.
+func DecodeWithSegments(r io.Reader) (image.Image, []Segment, error) {
+	var d decoder
+	d.segments = []Segment{}
+	m, err := d.decode(r, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, d.segments, nil
+}
+
+// DecodeICCProfile returns the ICC profile embedded in the APP2 segments of
+// a JPEG image, or nil if there is none, without decoding the entire image.
+// Like [DecodeConfig], it stops reading at the frame header, which the
+// profile precedes in conforming images.
+func DecodeICCProfile(r io.Reader) ([]byte, error) {
+	var d decoder
+	d.segments = []Segment{}
+	if _, err := d.decode(r, true); err != nil {
+		return nil, err
+	}
+	return ICCProfile(d.segments), nil
+}
+
 // DecodeConfig returns the color model and dimensions of a JPEG image without
 // decoding the entire image.
 func DecodeConfig(r io.Reader) (image.Config, error) {
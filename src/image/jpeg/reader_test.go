@@ -7,12 +7,14 @@ package jpeg
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
 	"math/rand"
 	"os"
+	"reflect"
 	"runtime/debug"
 	"strings"
 	"testing"
@@ -28,6 +30,7 @@ func TestDecodeProgressive(t *testing.T) {
 		"../testdata/video-001.q50.410",
 		"../testdata/video-001.q50.411",
 		"../testdata/video-001.q50.420",
+		"../testdata/video-001.q50.420.restart3",
 		"../testdata/video-001.q50.422",
 		"../testdata/video-001.q50.440",
 		"../testdata/video-001.q50.444",
@@ -546,6 +549,130 @@ func TestBadRestartMarker(t *testing.T) {
 	}
 }
 
+// exif returns an APP1 segment with Exif metadata that records the given
+// orientation.
+func exif(order binary.AppendByteOrder, orientation uint16) Segment {
+	b := []byte("Exif\x00\x00")
+	if order == binary.LittleEndian {
+		b = append(b, "II*\x00"...)
+	} else {
+		b = append(b, "MM\x00*"...)
+	}
+	b = order.AppendUint32(b, 8)
+	b = order.AppendUint16(b, 2)
+	// An XResolution entry, then the orientation entry.
+	b = order.AppendUint16(b, 0x011a)
+	b = order.AppendUint16(b, 5)
+	b = order.AppendUint32(b, 1)
+	b = order.AppendUint32(b, 38)
+	b = order.AppendUint16(b, 0x0112)
+	b = order.AppendUint16(b, 3)
+	b = order.AppendUint32(b, 1)
+	b = order.AppendUint16(b, orientation)
+	b = order.AppendUint16(b, 0)
+	b = order.AppendUint32(b, 0)
+	b = order.AppendUint32(b, 72)
+	b = order.AppendUint32(b, 1)
+	return Segment{Marker: app1Marker, Data: b}
+}
+
+func TestDecodeWithSegments(t *testing.T) {
+	profile := make([]byte, 150000)
+	for i := range profile {
+		profile[i] = uint8(i * 13)
+	}
+	icc, err := ICCProfileSegments(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(icc) != 3 {
+		t.Fatalf("ICCProfileSegments returned %d segments, want 3", len(icc))
+	}
+	segments := append([]Segment{exif(binary.BigEndian, 6)}, icc...)
+	segments = append(segments, Segment{Marker: app15Marker, Data: []byte("custom")})
+
+	m0 := image.NewRGBA(image.Rect(0, 0, 17, 9))
+	for _, progressive := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, m0, &Options{Segments: segments, Progressive: progressive}); err != nil {
+			t.Fatal(err)
+		}
+		m1, got, err := DecodeWithSegments(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m1.Bounds() != m0.Bounds() {
+			t.Errorf("bounds differ: %v and %v", m0.Bounds(), m1.Bounds())
+		}
+		if !reflect.DeepEqual(got, segments) {
+			t.Errorf("DecodeWithSegments returned %d segments that differ from the %d encoded ones", len(got), len(segments))
+		}
+		if p := ICCProfile(got); !bytes.Equal(p, profile) {
+			t.Errorf("ICCProfile returned %d bytes that differ from the %d encoded ones", len(p), len(profile))
+		}
+		if o := Orientation(got); o != 6 {
+			t.Errorf("Orientation = %d, want 6", o)
+		}
+		if p, err := DecodeICCProfile(bytes.NewReader(buf.Bytes())); err != nil || !bytes.Equal(p, profile) {
+			t.Errorf("DecodeICCProfile returned %d bytes, %v", len(p), err)
+		}
+	}
+	if p, err := DecodeICCProfile(bytes.NewReader(mustReadFile(t, "../testdata/video-001.jpeg"))); err != nil || p != nil {
+		t.Errorf("DecodeICCProfile returned %d bytes, %v, want none", len(p), err)
+	}
+
+	// Segments from an existing file include its JFIF header, which
+	// still determines its color model.
+	m, got, err := DecodeWithSegments(bytes.NewReader(mustReadFile(t, "../testdata/video-001.jpeg")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[0].Marker != app0Marker || !isJFIF(got[0].Data) {
+		t.Errorf("got %d segments, want a leading JFIF segment", len(got))
+	}
+	if _, ok := m.(*image.YCbCr); !ok {
+		t.Errorf("got %T, want *image.YCbCr", m)
+	}
+}
+
+func mustReadFile(t *testing.T, filename string) []byte {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSegmentHelpers(t *testing.T) {
+	icc, _ := ICCProfileSegments([]byte("profile"))
+	tests := []struct {
+		name        string
+		segments    []Segment
+		profile     string
+		orientation int
+	}{
+		{"none", nil, "", 0},
+		{"little-endian Exif", []Segment{exif(binary.LittleEndian, 3)}, "", 3},
+		{"invalid orientation", []Segment{exif(binary.LittleEndian, 9)}, "", 0},
+		{"truncated Exif", []Segment{{Marker: app1Marker, Data: exif(binary.BigEndian, 3).Data[:20]}}, "", 0},
+		{"Exif in APP2", []Segment{{Marker: app2Marker, Data: exif(binary.BigEndian, 3).Data}}, "", 0},
+		{"ICC profile", icc, "profile", 0},
+		{"incomplete ICC profile", []Segment{{Marker: app2Marker, Data: []byte("ICC_PROFILE\x00\x01\x02abc")}}, "", 0},
+		{"repeated ICC chunk", append(icc, icc...), "", 0},
+	}
+	for _, tt := range tests {
+		if got := string(ICCProfile(tt.segments)); got != tt.profile {
+			t.Errorf("%s: ICCProfile = %q, want %q", tt.name, got, tt.profile)
+		}
+		if got := Orientation(tt.segments); got != tt.orientation {
+			t.Errorf("%s: Orientation = %d, want %d", tt.name, got, tt.orientation)
+		}
+	}
+	if _, err := ICCProfileSegments(nil); err == nil {
+		t.Error("ICCProfileSegments(nil) succeeded")
+	}
+}
+
 func benchmarkDecode(b *testing.B, filename string) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -169,8 +169,42 @@ func (d *decoder) processSOS(n int) error {
 		bx, by     int
 		blockCount int
 	)
+	// nextMCU starts the next MCU, first processing the restart marker that
+	// ends the previous restart interval, if any. In a non-interleaved scan,
+	// each block is an MCU, as per section A.2.2.
+	nextMCU := func() error {
+		if d.ri > 0 && mcu > 0 && mcu%d.ri == 0 {
+			// For well-formed input, the RST[0-7] restart marker follows
+			// immediately. For corrupt input, call findRST to try to
+			// resynchronize.
+			if err := d.readFull(d.tmp[:2]); err != nil {
+				return err
+			} else if d.tmp[0] != 0xff || d.tmp[1] != expectedRST {
+				if err := d.findRST(expectedRST); err != nil {
+					return err
+				}
+			}
+			expectedRST++
+			if expectedRST == rst7Marker+1 {
+				expectedRST = rst0Marker
+			}
+			// Reset the Huffman decoder.
+			d.bits = bits{}
+			// Reset the DC components, as per section F.2.1.3.1.
+			dc = [maxComponents]int32{}
+			// Reset the progressive decoder state, as per section G.1.2.2.
+			d.eobRun = 0
+		}
+		mcu++
+		return nil
+	}
 	for my := 0; my < myy; my++ {
 		for mx := 0; mx < mxx; mx++ {
+			if nComp != 1 {
+				if err := nextMCU(); err != nil {
+					return err
+				}
+			}
 			for i := 0; i < nComp; i++ {
 				compIndex := scan[i].compIndex
 				hi := d.comp[compIndex].h
@@ -212,6 +246,9 @@ func (d *decoder) processSOS(n int) error {
 						if bx*8 >= d.width || by*8 >= d.height {
 							continue
 						}
+						if err := nextMCU(); err != nil {
+							return err
+						}
 					}
 
 					// Load the previous partially decoded coefficients, if applicable.
@@ -303,29 +340,6 @@ func (d *decoder) processSOS(n int) error {
 					}
 				} // for j
 			} // for i
-			mcu++
-			if d.ri > 0 && mcu%d.ri == 0 && mcu < mxx*myy {
-				// For well-formed input, the RST[0-7] restart marker follows
-				// immediately. For corrupt input, call findRST to try to
-				// resynchronize.
-				if err := d.readFull(d.tmp[:2]); err != nil {
-					return err
-				} else if d.tmp[0] != 0xff || d.tmp[1] != expectedRST {
-					if err := d.findRST(expectedRST); err != nil {
-						return err
-					}
-				}
-				expectedRST++
-				if expectedRST == rst7Marker+1 {
-					expectedRST = rst0Marker
-				}
-				// Reset the Huffman decoder.
-				d.bits = bits{}
-				// Reset the DC components, as per section F.2.1.3.1.
-				dc = [maxComponents]int32{}
-				// Reset the progressive decoder state, as per section G.1.2.2.
-				d.eobRun = 0
-			}
 		} // for mx
 	} // for my
 
@@ -0,0 +1,106 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jpeg
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// iccHeader starts each APP2 segment holding a chunk of an ICC profile, as
+// per section B.4 of the ICC specification. It is followed by the 1-based
+// sequence number of the chunk and the number of chunks.
+const iccHeader = "ICC_PROFILE\x00"
+
+// maxICCChunk is the largest chunk of an ICC profile that fits in a segment.
+const maxICCChunk = 0xffff - 2 - len(iccHeader) - 2
+
+// ICCProfile returns the ICC profile held by the APP2 segments among
+// segments, or nil if there is no complete profile.
+func ICCProfile(segments []Segment) []byte {
+	var chunks [][]byte
+	for _, s := range segments {
+		if s.Marker != app2Marker || len(s.Data) < len(iccHeader)+2 || string(s.Data[:len(iccHeader)]) != iccHeader {
+			continue
+		}
+		seq, n := int(s.Data[len(iccHeader)]), int(s.Data[len(iccHeader)+1])
+		if chunks == nil {
+			chunks = make([][]byte, n)
+		}
+		if seq < 1 || seq > len(chunks) || n != len(chunks) || chunks[seq-1] != nil {
+			return nil
+		}
+		chunks[seq-1] = s.Data[len(iccHeader)+2:]
+	}
+	var profile []byte
+	for _, c := range chunks {
+		if c == nil {
+			return nil
+		}
+		profile = append(profile, c...)
+	}
+	return profile
+}
+
+// ICCProfileSegments returns the APP2 segments that hold the given ICC
+// profile, for use as [Options.Segments].
+func ICCProfileSegments(profile []byte) ([]Segment, error) {
+	n := (len(profile) + maxICCChunk - 1) / maxICCChunk
+	if n == 0 || n > 255 {
+		return nil, errors.New("jpeg: invalid ICC profile size")
+	}
+	segments := make([]Segment, n)
+	for i := range segments {
+		chunk := profile[i*maxICCChunk : min((i+1)*maxICCChunk, len(profile))]
+		data := append([]byte(iccHeader), byte(i+1), byte(n))
+		segments[i] = Segment{Marker: app2Marker, Data: append(data, chunk...)}
+	}
+	return segments, nil
+}
+
+// Orientation returns the orientation recorded in the Exif metadata of the
+// APP1 segment among segments, from 1 to 8 as per the Exif specification,
+// or 0 if there is none. 1 means that the image is upright, the other values
+// that it must be mirrored and/or rotated for display.
+func Orientation(segments []Segment) int {
+	for _, s := range segments {
+		if s.Marker != app1Marker || len(s.Data) < 6 || string(s.Data[:6]) != "Exif\x00\x00" {
+			continue
+		}
+		// The Exif metadata is a TIFF structure. The orientation tag is
+		// in the first IFD.
+		tiff := s.Data[6:]
+		if len(tiff) < 8 {
+			return 0
+		}
+		var order binary.ByteOrder
+		switch string(tiff[:4]) {
+		case "II*\x00":
+			order = binary.LittleEndian
+		case "MM\x00*":
+			order = binary.BigEndian
+		default:
+			return 0
+		}
+		ifd := order.Uint32(tiff[4:])
+		if ifd > uint32(len(tiff)-2) {
+			return 0
+		}
+		n := int(order.Uint16(tiff[ifd:]))
+		entries := tiff[ifd+2:]
+		for i := 0; i < n && 12*i+12 <= len(entries); i++ {
+			e := entries[12*i : 12*i+12]
+			// The orientation tag is a SHORT.
+			if order.Uint16(e) == 0x0112 && order.Uint16(e[2:]) == 3 {
+				if o := int(order.Uint16(e[8:])); 1 <= o && o <= 8 {
+					return o
+				}
+				return 0
+			}
+		}
+		return 0
+	}
+	return 0
+}
@@ -207,6 +207,92 @@ func init() {
 	}
 }
 
+// optimalHuffmanSpec returns a Huffman encoding for symbols with the given
+// frequencies, generated as per section K.2 of the spec: the code lengths
+// are limited to 16 bits and no codeword consists of only 1 bits.
+func optimalHuffmanSpec(freq *[256]int) huffmanSpec {
+	// Symbol 256 is reserved, so that no codeword is all 1 bits.
+	var (
+		f        [257]int
+		codesize [257]int
+		others   [257]int
+	)
+	copy(f[:], freq[:])
+	f[256] = 1
+	for i := range others {
+		others[i] = -1
+	}
+	for {
+		// Find the two least frequent symbols, v1 and v2, preferring larger
+		// symbol values on ties.
+		v1, v2 := -1, -1
+		for i, x := range f {
+			if x == 0 {
+				continue
+			}
+			if v1 < 0 || x <= f[v1] {
+				v1, v2 = i, v1
+			} else if v2 < 0 || x <= f[v2] {
+				v2 = i
+			}
+		}
+		if v2 < 0 {
+			break
+		}
+		// Merge the tree containing v2 into the one containing v1.
+		f[v1] += f[v2]
+		f[v2] = 0
+		for codesize[v1]++; others[v1] >= 0; codesize[v1]++ {
+			v1 = others[v1]
+		}
+		others[v1] = v2
+		for codesize[v2]++; others[v2] >= 0; codesize[v2]++ {
+			v2 = others[v2]
+		}
+	}
+	// Code lengths can reach 256 bits for skewed frequencies.
+	var bits [257]int
+	for _, n := range codesize {
+		if n > 0 {
+			bits[n]++
+		}
+	}
+	// Limit the code lengths to 16 bits, as per figure K.3.
+	for i := len(bits) - 1; i > 16; i-- {
+		for bits[i] > 0 {
+			j := i - 2
+			for bits[j] == 0 {
+				j--
+			}
+			bits[i] -= 2
+			bits[i-1]++
+			bits[j+1] += 2
+			bits[j]--
+		}
+	}
+	// Remove the reserved symbol, which has one of the longest codes.
+	i := 16
+	for bits[i] == 0 {
+		i--
+	}
+	bits[i]--
+
+	// The values are listed in order of increasing code length, as per
+	// figure K.4. Shortening the longest codes above preserved that order.
+	var s huffmanSpec
+	for n := 1; n <= 16; n++ {
+		s.count[n-1] = byte(bits[n])
+	}
+	for n := 1; n < len(bits); n++ {
+		for v := 0; v < 256; v++ {
+			if codesize[v] == n {
+				s.value = append(s.value, byte(v))
+			}
+		}
+	}
+	return s
+}
+
 // writer is a buffered writer.
 type writer interface {
 	Flush() error
@@ -226,6 +312,30 @@ type encoder struct {
 	bits, nBits uint32
 	// quant is the scaled quantization tables, in zig-zag order.
 	quant [nQuantIndex][blockSize]byte
+	// comp are the components of the frame: Y, or Y, Cb and Cr. Their
+	// quantization table selectors double as Huffman table selectors.
+	comp []component
+	// size is the image size.
+	size image.Point
+	// ri is the restart interval, in MCUs. Zero means no restart markers.
+	ri int
+	// huff and spec are the Huffman encodings in use and their
+	// specifications. They start out as theHuffmanLUT and theHuffmanSpec.
+	huff [nHuffIndex]huffmanLUT
+	spec [nHuffIndex]huffmanSpec
+	// freq, if non-nil, makes the encoder count the Huffman-coded symbols
+	// instead of writing anything, so that their encodings can be optimized.
+	freq *[nHuffIndex][256]int
+	// mcu holds the quantized blocks of the current MCU, in zig-zag order:
+	// the h*v blocks of each component in turn.
+	mcu [6]block
+	// coeffs holds the quantized blocks of each component, in zig-zag order,
+	// for progressive encoding.
+	coeffs [3][]block
+	// eobRun is the pending End-of-Band run of a progressive AC scan, and
+	// corr holds the correction bits of the blocks in that run.
+	eobRun int32
+	corr   []byte
 }
 
 func (e *encoder) flush() {
@@ -252,6 +362,9 @@ func (e *encoder) writeByte(b byte) {
 // emit emits the least significant nBits bits of bits to the bit-stream.
 // The precondition is bits < 1<<nBits && nBits <= 16.
 func (e *encoder) emit(bits, nBits uint32) {
+	if e.freq != nil {
+		return
+	}
 	nBits += e.nBits
 	bits <<= 32 - nBits
 	bits |= e.bits
@@ -267,12 +380,32 @@ func (e *encoder) emit(bits, nBits uint32) {
 	e.bits, e.nBits = bits, nBits
 }
 
+// pad pads the bit-stream to a byte boundary with 1 bits, as per section
+// F.1.2.3.
+func (e *encoder) pad() {
+	e.emit(0x7f, 7)
+	e.bits, e.nBits = 0, 0
+}
+
 // emitHuff emits the given value with the given Huffman encoder.
 func (e *encoder) emitHuff(h huffIndex, value int32) {
-	x := theHuffmanLUT[h][value]
+	if e.freq != nil {
+		e.freq[h][value]++
+		return
+	}
+	x := e.huff[h][value]
 	e.emit(x&(1<<24-1), x>>24)
 }
 
+// bitLength returns the number of bits needed to hold a, which must be less
+// than 1<<16.
+func bitLength(a int32) uint32 {
+	if a < 0x100 {
+		return uint32(bitCount[a])
+	}
+	return 8 + uint32(bitCount[a>>8])
+}
+
 // emitHuffRLE emits a run of runLength copies of value encoded with the given
 // Huffman encoder.
 func (e *encoder) emitHuffRLE(h huffIndex, runLength, value int32) {
@@ -280,12 +413,7 @@ func (e *encoder) emitHuffRLE(h huffIndex, runLength, value int32) {
 	if a < 0 {
 		a, b = -value, value-1
 	}
-	var nBits uint32
-	if a < 0x100 {
-		nBits = uint32(bitCount[a])
-	} else {
-		nBits = 8 + uint32(bitCount[a>>8])
-	}
+	nBits := bitLength(a)
 	e.emitHuff(h, runLength<<4|int32(nBits))
 	if nBits > 0 {
 		e.emit(uint32(b)&(1<<nBits-1), nBits)
@@ -301,6 +429,14 @@ func (e *encoder) writeMarkerHeader(marker uint8, markerlen int) {
 	e.write(e.buf[:4])
 }
 
+// writeSegments writes the application segments.
+func (e *encoder) writeSegments(segments []Segment) {
+	for _, s := range segments {
+		e.writeMarkerHeader(s.Marker, 2+len(s.Data))
+		e.write(s.Data)
+	}
+}
+
 // writeDQT writes the Define Quantization Table marker.
 func (e *encoder) writeDQT() {
 	const markerlen = 2 + int(nQuantIndex)*(1+blockSize)
@@ -311,63 +447,116 @@ func (e *encoder) writeDQT() {
 	}
 }
 
-// writeSOF0 writes the Start Of Frame (Baseline Sequential) marker.
-func (e *encoder) writeSOF0(size image.Point, nComponent int) {
+// writeSOF writes the Start Of Frame marker, which is either sof0Marker
+// (Baseline Sequential) or sof2Marker (Progressive).
+func (e *encoder) writeSOF(marker uint8) {
+	nComponent := len(e.comp)
 	markerlen := 8 + 3*nComponent
-	e.writeMarkerHeader(sof0Marker, markerlen)
+	e.writeMarkerHeader(marker, markerlen)
 	e.buf[0] = 8 // 8-bit color.
-	e.buf[1] = uint8(size.Y >> 8)
-	e.buf[2] = uint8(size.Y & 0xff)
-	e.buf[3] = uint8(size.X >> 8)
-	e.buf[4] = uint8(size.X & 0xff)
+	e.buf[1] = uint8(e.size.Y >> 8)
+	e.buf[2] = uint8(e.size.Y & 0xff)
+	e.buf[3] = uint8(e.size.X >> 8)
+	e.buf[4] = uint8(e.size.X & 0xff)
 	e.buf[5] = uint8(nComponent)
-	if nComponent == 1 {
-		e.buf[6] = 1
-		// No subsampling for grayscale image.
-		e.buf[7] = 0x11
-		e.buf[8] = 0x00
-	} else {
-		for i := 0; i < nComponent; i++ {
-			e.buf[3*i+6] = uint8(i + 1)
-			// We use 4:2:0 chroma subsampling.
-			e.buf[3*i+7] = "\x22\x11\x11"[i]
-			e.buf[3*i+8] = "\x00\x01\x01"[i]
-		}
+	for i, c := range e.comp {
+		e.buf[3*i+6] = c.c
+		e.buf[3*i+7] = uint8(c.h<<4 | c.v)
+		e.buf[3*i+8] = c.tq
 	}
 	e.write(e.buf[:3*(nComponent-1)+9])
 }
 
-// writeDHT writes the Define Huffman Table marker.
-func (e *encoder) writeDHT(nComponent int) {
+// writeDRI writes the Define Restart Interval marker.
+func (e *encoder) writeDRI() {
+	e.writeMarkerHeader(driMarker, 4)
+	e.buf[0] = uint8(e.ri >> 8)
+	e.buf[1] = uint8(e.ri & 0xff)
+	e.write(e.buf[:2])
+}
+
+// writeDHT writes the Define Huffman Table marker for the given tables.
+func (e *encoder) writeDHT(tables []huffIndex) {
 	markerlen := 2
-	specs := theHuffmanSpec[:]
-	if nComponent == 1 {
-		// Drop the Chrominance tables.
-		specs = specs[:2]
-	}
-	for _, s := range specs {
-		markerlen += 1 + 16 + len(s.value)
+	for _, h := range tables {
+		markerlen += 1 + 16 + len(e.spec[h].value)
 	}
 	e.writeMarkerHeader(dhtMarker, markerlen)
-	for i, s := range specs {
-		e.writeByte("\x00\x10\x01\x11"[i])
-		e.write(s.count[:])
-		e.write(s.value)
+	for _, h := range tables {
+		e.writeByte("\x00\x10\x01\x11"[h])
+		e.write(e.spec[h].count[:])
+		e.write(e.spec[h].value)
+	}
+}
+
+// optimizeHuffman replaces the Huffman encodings of the tables whose symbols
+// were counted with ones tailored to those counts, stops counting and writes
+// the new tables.
+func (e *encoder) optimizeHuffman() {
+	var tables []huffIndex
+	for h := range e.freq {
+		used := false
+		for _, n := range e.freq[h] {
+			used = used || n > 0
+		}
+		if used {
+			e.spec[h] = optimalHuffmanSpec(&e.freq[h])
+			e.huff[h].init(e.spec[h])
+			tables = append(tables, huffIndex(h))
+		}
 	}
+	e.freq = nil
+	if len(tables) > 0 {
+		e.writeDHT(tables)
+	}
+}
+
+// writeSOS writes the Start Of Scan marker for a scan of the given
+// components, spectral selection and successive approximation.
+func (e *encoder) writeSOS(comps []int, ss, se int, ah, al uint) {
+	e.writeMarkerHeader(sosMarker, 6+2*len(comps))
+	e.writeByte(uint8(len(comps)))
+	for _, c := range comps {
+		// Each component uses the DC and AC tables of its quantization table.
+		tq := e.comp[c].tq
+		e.writeByte(e.comp[c].c)
+		e.writeByte(tq<<4 | tq)
+	}
+	e.writeByte(uint8(ss))
+	e.writeByte(uint8(se))
+	e.writeByte(uint8(ah<<4 | al))
 }
 
-// writeBlock writes a block of pixel data using the given quantization table,
-// returning the post-quantized DC value of the DCT-transformed block. b is in
-// natural (not zig-zag) order.
-func (e *encoder) writeBlock(b *block, q quantIndex, prevDC int32) int32 {
+// restart ends the n'th restart interval, counting from zero, with a
+// restart marker.
+func (e *encoder) restart(n int) {
+	if e.freq != nil {
+		return
+	}
+	e.pad()
+	e.writeByte(0xff)
+	e.writeByte(uint8(rst0Marker + n%8))
+}
+
+// quantize applies the forward DCT to b, which is in natural order, and
+// stores the result quantized with the given table in dst, in zig-zag order.
+func (e *encoder) quantize(dst, b *block, q quantIndex) {
 	fdct(b)
+	for zig := 0; zig < blockSize; zig++ {
+		dst[zig] = div(b[unzig[zig]], 8*int32(e.quant[q][zig]))
+	}
+}
+
+// writeBlock writes a quantized block, in zig-zag order, with the given
+// Huffman DC encoder and the AC encoder that follows it, returning the DC
+// value for the next block to be delta-encoded against.
+func (e *encoder) writeBlock(b *block, h huffIndex, prevDC int32) int32 {
 	// Emit the DC delta.
-	dc := div(b[0], 8*int32(e.quant[q][0]))
-	e.emitHuffRLE(huffIndex(2*q+0), 0, dc-prevDC)
+	e.emitHuffRLE(h, 0, b[0]-prevDC)
 	// Emit the AC components.
-	h, runLength := huffIndex(2*q+1), int32(0)
+	h, runLength := h+1, int32(0)
 	for zig := 1; zig < blockSize; zig++ {
-		ac := div(b[unzig[zig]], 8*int32(e.quant[q][zig]))
+		ac := b[zig]
 		if ac == 0 {
 			runLength++
 		} else {
@@ -382,7 +571,7 @@ func (e *encoder) writeBlock(b *block, q quantIndex, prevDC int32) int32 {
 	if runLength > 0 {
 		e.emitHuff(h, 0x00)
 	}
-	return dc
+	return b[0]
 }
 
 // toYCbCr converts the 8x8 region of m whose top-left corner is p to its
@@ -480,103 +669,419 @@ func scale(dst *block, src *[4]block) {
 	}
 }
 
-// sosHeaderY is the SOS marker "\xff\xda" followed by 8 bytes:
-//   - the marker length "\x00\x08",
-//   - the number of components "\x01",
-//   - component 1 uses DC table 0 and AC table 0 "\x01\x00",
-//   - the bytes "\x00\x3f\x00". Section B.2.3 of the spec says that for
-//     sequential DCTs, those bytes (8-bit Ss, 8-bit Se, 4-bit Ah, 4-bit Al)
-//     should be 0x00, 0x3f, 0x00<<4 | 0x00.
-var sosHeaderY = []byte{
-	0xff, 0xda, 0x00, 0x08, 0x01, 0x01, 0x00, 0x00, 0x3f, 0x00,
-}
-
-// sosHeaderYCbCr is the SOS marker "\xff\xda" followed by 12 bytes:
-//   - the marker length "\x00\x0c",
-//   - the number of components "\x03",
-//   - component 1 uses DC table 0 and AC table 0 "\x01\x00",
-//   - component 2 uses DC table 1 and AC table 1 "\x02\x11",
-//   - component 3 uses DC table 1 and AC table 1 "\x03\x11",
-//   - the bytes "\x00\x3f\x00". Section B.2.3 of the spec says that for
-//     sequential DCTs, those bytes (8-bit Ss, 8-bit Se, 4-bit Ah, 4-bit Al)
-//     should be 0x00, 0x3f, 0x00<<4 | 0x00.
-var sosHeaderYCbCr = []byte{
-	0xff, 0xda, 0x00, 0x0c, 0x03, 0x01, 0x00, 0x02,
-	0x11, 0x03, 0x11, 0x00, 0x3f, 0x00,
+// scaleH scales the 16x8 region represented by the first 2 src blocks to the
+// 8x8 dst block.
+func scaleH(dst *block, src *[4]block) {
+	for i := 0; i < 2; i++ {
+		dstOff := i << 2
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 4; x++ {
+				j := 8*y + 2*x
+				sum := src[i][j] + src[i][j+1]
+				dst[8*y+x+dstOff] = (sum + 1) >> 1
+			}
+		}
+	}
 }
 
-// writeSOS writes the StartOfScan marker.
-func (e *encoder) writeSOS(m image.Image) {
-	switch m.(type) {
-	case *image.Gray:
-		e.write(sosHeaderY)
-	default:
-		e.write(sosHeaderYCbCr)
-	}
+// forEachMCU computes the quantized blocks of each MCU of m into e.mcu and
+// calls f with the MCU's column and row.
+func (e *encoder) forEachMCU(m image.Image, f func(mx, my int)) {
 	var (
 		// Scratch buffers to hold the YCbCr values.
 		// The blocks are in natural (not zig-zag) order.
 		b      block
 		cb, cr [4]block
-		// DC components are delta-encoded.
-		prevDCY, prevDCCb, prevDCCr int32
 	)
+	h, v := e.comp[0].h, e.comp[0].v
 	bounds := m.Bounds()
-	switch m := m.(type) {
 	// TODO(wathiede): switch on m.ColorModel() instead of type.
-	case *image.Gray:
-		for y := bounds.Min.Y; y < bounds.Max.Y; y += 8 {
-			for x := bounds.Min.X; x < bounds.Max.X; x += 8 {
-				p := image.Pt(x, y)
-				grayToY(m, p, &b)
-				prevDCY = e.writeBlock(&b, 0, prevDCY)
+	gray, _ := m.(*image.Gray)
+	rgba, _ := m.(*image.RGBA)
+	ycbcr, _ := m.(*image.YCbCr)
+	for my, y := 0, bounds.Min.Y; y < bounds.Max.Y; my, y = my+1, y+8*v {
+		for mx, x := 0, bounds.Min.X; x < bounds.Max.X; mx, x = mx+1, x+8*h {
+			for i := 0; i < h*v; i++ {
+				p := image.Pt(x+8*(i%h), y+8*(i/h))
+				if gray != nil {
+					grayToY(gray, p, &b)
+				} else if rgba != nil {
+					rgbaToYCbCr(rgba, p, &b, &cb[i], &cr[i])
+				} else if ycbcr != nil {
+					yCbCrToYCbCr(ycbcr, p, &b, &cb[i], &cr[i])
+				} else {
+					toYCbCr(m, p, &b, &cb[i], &cr[i])
+				}
+				e.quantize(&e.mcu[i], &b, quantIndexLuminance)
 			}
+			if len(e.comp) == 3 {
+				for j, c := range [2]*[4]block{&cb, &cr} {
+					switch h<<4 | v {
+					case 0x11:
+						b = c[0]
+					case 0x21:
+						scaleH(&b, c)
+					case 0x22:
+						scale(&b, c)
+					}
+					e.quantize(&e.mcu[h*v+j], &b, quantIndexChrominance)
+				}
+			}
+			f(mx, my)
 		}
-	default:
-		rgba, _ := m.(*image.RGBA)
-		ycbcr, _ := m.(*image.YCbCr)
-		for y := bounds.Min.Y; y < bounds.Max.Y; y += 16 {
-			for x := bounds.Min.X; x < bounds.Max.X; x += 16 {
-				for i := 0; i < 4; i++ {
-					xOff := (i & 1) * 8
-					yOff := (i & 2) * 4
-					p := image.Pt(x+xOff, y+yOff)
-					if rgba != nil {
-						rgbaToYCbCr(rgba, p, &b, &cb[i], &cr[i])
-					} else if ycbcr != nil {
-						yCbCrToYCbCr(ycbcr, p, &b, &cb[i], &cr[i])
-					} else {
-						toYCbCr(m, p, &b, &cb[i], &cr[i])
+	}
+}
+
+// writeBaseline writes the data of the single scan of a baseline image.
+func (e *encoder) writeBaseline(m image.Image) {
+	var (
+		// DC components are delta-encoded.
+		prevDC [3]int32
+		n      int
+	)
+	e.forEachMCU(m, func(mx, my int) {
+		if e.ri > 0 && n > 0 && n%e.ri == 0 {
+			e.restart(n/e.ri - 1)
+			prevDC = [3]int32{}
+		}
+		n++
+		i := 0
+		for c := range e.comp {
+			h := huffIndex(2 * e.comp[c].tq)
+			for j := 0; j < e.comp[c].h*e.comp[c].v; j++ {
+				prevDC[c] = e.writeBlock(&e.mcu[i], h, prevDC[c])
+				i++
+			}
+		}
+	})
+	e.pad()
+}
+
+// progressiveScan is a scan of a progressive image: its components, its
+// spectral selection ss to se and its successive approximation bit
+// positions ah and al.
+type progressiveScan struct {
+	comps  []int
+	ss, se int
+	ah, al uint
+}
+
+// progressiveScans is the sequence of scans of a progressive image. It is
+// the one used by libjpeg's jpeg_simple_progression: the DC coefficients
+// and the low-frequency luma coefficients come first, and the least
+// significant bits of all coefficients come last.
+var progressiveScans = []progressiveScan{
+	{[]int{0, 1, 2}, 0, 0, 0, 1},
+	{[]int{0}, 1, 5, 0, 2},
+	{[]int{2}, 1, 63, 0, 1},
+	{[]int{1}, 1, 63, 0, 1},
+	{[]int{0}, 6, 63, 0, 2},
+	{[]int{0}, 1, 63, 2, 1},
+	{[]int{0, 1, 2}, 0, 0, 1, 0},
+	{[]int{2}, 1, 63, 1, 0},
+	{[]int{1}, 1, 63, 1, 0},
+	{[]int{0}, 1, 63, 1, 0},
+}
+
+// writeProgressive writes the scans of a progressive image. Each scan has
+// its own optimized Huffman tables, since the example tables of section
+// K.3 cannot encode End-of-Band runs.
+func (e *encoder) writeProgressive(m image.Image) {
+	h0, v0 := e.comp[0].h, e.comp[0].v
+	mxx := (e.size.X + 8*h0 - 1) / (8 * h0)
+	myy := (e.size.Y + 8*v0 - 1) / (8 * v0)
+	for c := range e.comp {
+		e.coeffs[c] = make([]block, mxx*e.comp[c].h*myy*e.comp[c].v)
+	}
+	e.forEachMCU(m, func(mx, my int) {
+		i := 0
+		for c := range e.comp {
+			h, v := e.comp[c].h, e.comp[c].v
+			for j := 0; j < h*v; j++ {
+				e.coeffs[c][(v*my+j/h)*mxx*h+h*mx+j%h] = e.mcu[i]
+				i++
+			}
+		}
+	})
+	for _, s := range progressiveScans {
+		comps := s.comps
+		if len(e.comp) == 1 {
+			if comps[0] != 0 {
+				continue
+			}
+			comps = comps[:1]
+		}
+		// DC refinement scans consist of raw bits only.
+		if s.ss != 0 || s.ah == 0 {
+			e.freq = new([nHuffIndex][256]int)
+			e.writeScan(comps, s, mxx, myy)
+			e.optimizeHuffman()
+		}
+		e.writeSOS(comps, s.ss, s.se, s.ah, s.al)
+		e.writeScan(comps, s, mxx, myy)
+	}
+}
+
+// writeScan writes the data of a progressive scan. mxx and myy are the
+// number of MCUs in the image.
+func (e *encoder) writeScan(comps []int, s progressiveScan, mxx, myy int) {
+	var (
+		prevDC [3]int32
+		n      int
+	)
+	// next starts the next MCU, ending a restart interval if needed.
+	next := func(h huffIndex) {
+		if e.ri > 0 && n > 0 && n%e.ri == 0 {
+			e.writeEOBRun(h)
+			e.restart(n/e.ri - 1)
+			prevDC = [3]int32{}
+		}
+		n++
+	}
+	if len(comps) > 1 {
+		// Interleaved scans, which only code DC coefficients, are made of
+		// MCUs.
+		for my := 0; my < myy; my++ {
+			for mx := 0; mx < mxx; mx++ {
+				next(0)
+				for _, c := range comps {
+					h, v := e.comp[c].h, e.comp[c].v
+					for j := 0; j < h*v; j++ {
+						b := &e.coeffs[c][(v*my+j/h)*mxx*h+h*mx+j%h]
+						e.writeDC(b, huffIndex(2*e.comp[c].tq), &prevDC[c], s.ah, s.al)
 					}
-					prevDCY = e.writeBlock(&b, 0, prevDCY)
 				}
-				scale(&b, &cb)
-				prevDCCb = e.writeBlock(&b, 1, prevDCCb)
-				scale(&b, &cr)
-				prevDCCr = e.writeBlock(&b, 1, prevDCCr)
 			}
 		}
+		e.pad()
+		return
+	}
+	// Non-interleaved scans are made of the component's blocks that hold
+	// some of the image, as per section A.2.2.
+	c := comps[0]
+	hmax, vmax := e.comp[0].h, e.comp[0].v
+	h, v := e.comp[c].h, e.comp[c].v
+	bxx := ((e.size.X*h+hmax-1)/hmax + 7) / 8
+	byy := ((e.size.Y*v+vmax-1)/vmax + 7) / 8
+	dc, ac := huffIndex(2*e.comp[c].tq), huffIndex(2*e.comp[c].tq+1)
+	for by := 0; by < byy; by++ {
+		for bx := 0; bx < bxx; bx++ {
+			next(ac)
+			b := &e.coeffs[c][by*mxx*h+bx]
+			switch {
+			case s.ss == 0:
+				e.writeDC(b, dc, &prevDC[c], s.ah, s.al)
+			case s.ah == 0:
+				e.writeACFirst(b, ac, s.ss, s.se, s.al)
+			default:
+				e.writeACRefine(b, ac, s.ss, s.se, s.al)
+			}
+		}
+	}
+	e.writeEOBRun(ac)
+	e.pad()
+}
+
+// writeDC writes the DC coefficient of a block in a progressive scan, as
+// per section G.1.2.1.
+func (e *encoder) writeDC(b *block, h huffIndex, prevDC *int32, ah, al uint) {
+	if ah != 0 {
+		e.emit(uint32(b[0]>>al)&1, 1)
+		return
+	}
+	dc := b[0] >> al
+	e.emitHuffRLE(h, 0, dc-*prevDC)
+	*prevDC = dc
+}
+
+// writeEOBRun writes the pending End-of-Band run, if any, followed by the
+// correction bits of its blocks.
+func (e *encoder) writeEOBRun(h huffIndex) {
+	if e.eobRun == 0 {
+		return
+	}
+	nBits := bitLength(e.eobRun) - 1
+	e.emitHuff(h, int32(nBits<<4))
+	if nBits > 0 {
+		e.emit(uint32(e.eobRun)&(1<<nBits-1), nBits)
+	}
+	e.eobRun = 0
+	for _, bit := range e.corr {
+		e.emit(uint32(bit), 1)
+	}
+	e.corr = e.corr[:0]
+}
+
+// writeACFirst writes the AC coefficients ss to se of a block in the first
+// scan of that band, as per section G.1.2.2.
+func (e *encoder) writeACFirst(b *block, h huffIndex, ss, se int, al uint) {
+	runLength := int32(0)
+	for zig := ss; zig <= se; zig++ {
+		// The point transform of AC coefficients divides rather than
+		// shifts, as per section G.1.2.2.
+		ac := b[zig]
+		if ac < 0 {
+			ac = -(-ac >> al)
+		} else {
+			ac >>= al
+		}
+		if ac == 0 {
+			runLength++
+			continue
+		}
+		e.writeEOBRun(h)
+		for runLength > 15 {
+			e.emitHuff(h, 0xf0)
+			runLength -= 16
+		}
+		e.emitHuffRLE(h, runLength, ac)
+		runLength = 0
+	}
+	if runLength > 0 {
+		e.eobRun++
+		if e.eobRun == 0x7fff {
+			e.writeEOBRun(h)
+		}
+	}
+}
+
+// writeACRefine writes the next bit of the AC coefficients ss to se of a
+// block in a successive approximation scan, as per section G.1.2.3.
+func (e *encoder) writeACRefine(b *block, h huffIndex, ss, se int, al uint) {
+	var (
+		// abs are the magnitudes of the coefficients after the point
+		// transform. Coefficients that are 1 become nonzero in this scan,
+		// and eob is the last of them.
+		abs [blockSize]int32
+		eob int
+		// corr are the correction bits of the coefficients that were
+		// already nonzero, pending until the next Huffman-coded symbol.
+		corr  [blockSize]byte
+		nCorr int
+	)
+	for zig := ss; zig <= se; zig++ {
+		a := b[zig]
+		if a < 0 {
+			a = -a
+		}
+		abs[zig] = a >> al
+		if abs[zig] == 1 {
+			eob = zig
+		}
+	}
+	runLength := int32(0)
+	for zig := ss; zig <= se; zig++ {
+		a := abs[zig]
+		if a == 0 {
+			runLength++
+			continue
+		}
+		// Zero runs that end the block are folded into the End-of-Band.
+		for runLength > 15 && zig <= eob {
+			e.writeEOBRun(h)
+			e.emitHuff(h, 0xf0)
+			runLength -= 16
+			for _, bit := range corr[:nCorr] {
+				e.emit(uint32(bit), 1)
+			}
+			nCorr = 0
+		}
+		if a > 1 {
+			corr[nCorr] = byte(a & 1)
+			nCorr++
+			continue
+		}
+		e.writeEOBRun(h)
+		e.emitHuff(h, runLength<<4|1)
+		if b[zig] < 0 {
+			e.emit(0, 1)
+		} else {
+			e.emit(1, 1)
+		}
+		for _, bit := range corr[:nCorr] {
+			e.emit(uint32(bit), 1)
+		}
+		nCorr = 0
+		runLength = 0
+	}
+	if runLength > 0 || nCorr > 0 {
+		e.eobRun++
+		e.corr = append(e.corr, corr[:nCorr]...)
+		if e.eobRun == 0x7fff {
+			e.writeEOBRun(h)
+		}
 	}
-	// Pad the last byte with 1's.
-	e.emit(0x7f, 7)
 }
 
 // DefaultQuality is the default quality encoding parameter.
 const DefaultQuality = 75
 
+// Subsampling is a chroma subsampling ratio.
+type Subsampling int
+
+const (
+	// Subsampling420 halves the chroma resolution in both directions.
+	Subsampling420 Subsampling = iota
+	// Subsampling422 halves the chroma resolution horizontally.
+	Subsampling422
+	// Subsampling444 keeps the full chroma resolution.
+	Subsampling444
+)
+
 // Options are the encoding parameters.
 // Quality ranges from 1 to 100 inclusive, higher is better.
 type Options struct {
 	Quality int
+
+	// Subsampling is the chroma subsampling of color images. The zero value
+	// is 4:2:0.
+	Subsampling Subsampling
+
+	// Progressive selects progressive rather than baseline encoding.
+	// Progressive images always have optimized Huffman tables.
+	Progressive bool
+
+	// OptimizeHuffman selects Huffman tables computed for the image instead
+	// of the example tables of the JPEG specification, which makes a
+	// smaller file at the cost of an extra pass over the image.
+	OptimizeHuffman bool
+
+	// RestartInterval is the number of MCUs (Minimum Coded Units) between
+	// restart markers, which let decoders resynchronize after corrupt data.
+	// Zero means no restart markers. It must be less than 65536.
+	RestartInterval int
+
+	// Segments are application segments, such as Exif metadata or an ICC
+	// profile, to write after the Start Of Image marker. Encode writes them
+	// as is: it is up to the caller to keep them consistent with the image.
+	Segments []Segment
 }
 
-// Encode writes the Image m to w in JPEG 4:2:0 baseline format with the given
-// options. Default parameters are used if a nil *[Options] is passed.
+// Encode writes the Image m to w in JPEG format with the given options.
+// Default parameters, which produce a 4:2:0 baseline JPEG, are used if a nil
+// *[Options] is passed.
 func Encode(w io.Writer, m image.Image, o *Options) error {
 	b := m.Bounds()
 	if b.Dx() >= 1<<16 || b.Dy() >= 1<<16 {
 		return errors.New("jpeg: image is too large to encode")
 	}
+	if o == nil {
+		o = &Options{Quality: DefaultQuality}
+	}
+	if o.Subsampling < Subsampling420 || o.Subsampling > Subsampling444 {
+		return errors.New("jpeg: invalid subsampling")
+	}
+	if o.RestartInterval < 0 || o.RestartInterval >= 1<<16 {
+		return errors.New("jpeg: invalid restart interval")
+	}
+	for _, s := range o.Segments {
+		if s.Marker < app0Marker || s.Marker > app15Marker {
+			return errors.New("jpeg: invalid segment marker")
+		}
+		if len(s.Data) > 0xffff-2 {
+			return errors.New("jpeg: segment is too large")
+		}
+	}
 	var e encoder
 	if ww, ok := w.(writer); ok {
 		e.w = ww
@@ -584,14 +1089,11 @@ func Encode(w io.Writer, m image.Image, o *Options) error {
 		e.w = bufio.NewWriter(w)
 	}
 	// Clip quality to [1, 100].
-	quality := DefaultQuality
-	if o != nil {
-		quality = o.Quality
-		if quality < 1 {
-			quality = 1
-		} else if quality > 100 {
-			quality = 100
-		}
+	quality := o.Quality
+	if quality < 1 {
+		quality = 1
+	} else if quality > 100 {
+		quality = 100
 	}
 	// Convert from a quality rating to a scaling factor.
 	var scale int
@@ -613,25 +1115,66 @@ func Encode(w io.Writer, m image.Image, o *Options) error {
 			e.quant[i][j] = uint8(x)
 		}
 	}
-	// Compute number of components based on input image type.
-	nComponent := 3
+	// Compute the components based on input image type.
 	switch m.(type) {
 	// TODO(wathiede): switch on m.ColorModel() instead of type.
 	case *image.Gray:
-		nComponent = 1
+		// No subsampling for grayscale image.
+		e.comp = []component{{h: 1, v: 1, c: 1, tq: 0}}
+	default:
+		h, v := 2, 2
+		switch o.Subsampling {
+		case Subsampling422:
+			v = 1
+		case Subsampling444:
+			h, v = 1, 1
+		}
+		e.comp = []component{
+			{h: h, v: v, c: 1, tq: 0},
+			{h: 1, v: 1, c: 2, tq: 1},
+			{h: 1, v: 1, c: 3, tq: 1},
+		}
 	}
+	e.size = b.Size()
+	e.ri = o.RestartInterval
+	e.huff = theHuffmanLUT
+	e.spec = theHuffmanSpec
 	// Write the Start Of Image marker.
 	e.buf[0] = 0xff
 	e.buf[1] = 0xd8
 	e.write(e.buf[:2])
+	// Write the application segments.
+	e.writeSegments(o.Segments)
 	// Write the quantization tables.
 	e.writeDQT()
 	// Write the image dimensions.
-	e.writeSOF0(b.Size(), nComponent)
-	// Write the Huffman tables.
-	e.writeDHT(nComponent)
-	// Write the image data.
-	e.writeSOS(m)
+	if o.Progressive {
+		e.writeSOF(sof2Marker)
+	} else {
+		e.writeSOF(sof0Marker)
+	}
+	if e.ri > 0 {
+		e.writeDRI()
+	}
+	// Write the Huffman tables and the image data.
+	if o.Progressive {
+		e.writeProgressive(m)
+	} else {
+		if o.OptimizeHuffman {
+			e.freq = new([nHuffIndex][256]int)
+			e.writeBaseline(m)
+			e.optimizeHuffman()
+		} else {
+			tables := []huffIndex{0, 1, 2, 3}
+			if len(e.comp) == 1 {
+				// Drop the Chrominance tables.
+				tables = tables[:2]
+			}
+			e.writeDHT(tables)
+		}
+		e.writeSOS([]int{0, 1, 2}[:len(e.comp)], 0, blockSize-1, 0, 0)
+		e.writeBaseline(m)
+	}
 	// Write the End Of Image marker.
 	e.buf[0] = 0xff
 	e.buf[1] = 0xd9
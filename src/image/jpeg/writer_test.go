@@ -287,3 +287,137 @@ func BenchmarkEncodeYCbCr(b *testing.B) {
 		Encode(io.Discard, img, options)
 	}
 }
+
+func TestEncodeOptions(t *testing.T) {
+	m0, err := readPng("../testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray := image.NewGray(image.Rect(0, 0, 45, 29))
+	for y := 0; y < 29; y++ {
+		for x := 0; x < 45; x++ {
+			gray.SetGray(x, y, color.Gray{uint8(5*x + 3*y)})
+		}
+	}
+	for _, m := range []image.Image{m0, gray} {
+		for _, sub := range []Subsampling{Subsampling420, Subsampling422, Subsampling444} {
+			for _, ri := range []int{0, 1, 5} {
+				// Baseline, optimized and progressive encodings quantize the
+				// same coefficients, so they decode to the same image.
+				var want []byte
+				for _, o := range []*Options{
+					{Quality: 80, Subsampling: sub, RestartInterval: ri},
+					{Quality: 80, Subsampling: sub, RestartInterval: ri, OptimizeHuffman: true},
+					{Quality: 80, Subsampling: sub, RestartInterval: ri, Progressive: true},
+				} {
+					var buf bytes.Buffer
+					if err := Encode(&buf, m, o); err != nil {
+						t.Fatalf("%T %+v: %v", m, o, err)
+					}
+					m1, err := Decode(&buf)
+					if err != nil {
+						t.Errorf("%T %+v: %v", m, o, err)
+						continue
+					}
+					if m.Bounds() != m1.Bounds() {
+						t.Errorf("%T %+v: bounds differ: %v and %v", m, o, m.Bounds(), m1.Bounds())
+						continue
+					}
+					var got []byte
+					b := m1.Bounds()
+					switch m1 := m1.(type) {
+					case *image.Gray:
+						for y := b.Min.Y; y < b.Max.Y; y++ {
+							got = append(got, m1.Pix[m1.PixOffset(b.Min.X, y):m1.PixOffset(b.Max.X, y)]...)
+						}
+					case *image.YCbCr:
+						for y := b.Min.Y; y < b.Max.Y; y++ {
+							for x := b.Min.X; x < b.Max.X; x++ {
+								got = append(got, m1.Y[m1.YOffset(x, y)], m1.Cb[m1.COffset(x, y)], m1.Cr[m1.COffset(x, y)])
+							}
+						}
+						if want := [...]image.YCbCrSubsampleRatio{
+							image.YCbCrSubsampleRatio420,
+							image.YCbCrSubsampleRatio422,
+							image.YCbCrSubsampleRatio444,
+						}[sub]; m1.SubsampleRatio != want {
+							t.Errorf("%T %+v: subsample ratio %v, want %v", m, o, m1.SubsampleRatio, want)
+						}
+					}
+					if want == nil {
+						want = got
+						if d := averageDelta(m, m1); d > 6<<8 {
+							t.Errorf("%T %+v: average delta is too high (%d)", m, o, d)
+						}
+					} else if !bytes.Equal(got, want) {
+						t.Errorf("%T %+v: decoded image differs from the baseline one", m, o)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeOptimizeHuffman(t *testing.T) {
+	m, err := readPng("../testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b0, b1 bytes.Buffer
+	if err := Encode(&b0, m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Encode(&b1, m, &Options{Quality: DefaultQuality, OptimizeHuffman: true}); err != nil {
+		t.Fatal(err)
+	}
+	if b1.Len() >= b0.Len() {
+		t.Errorf("optimized encoding is %d bytes, not smaller than %d", b1.Len(), b0.Len())
+	}
+}
+
+func TestOptimalHuffmanSpec(t *testing.T) {
+	var freq [256]int
+	// Fibonacci frequencies make the longest codes of an unconstrained
+	// Huffman code exceed 16 bits.
+	a, b := 1, 1
+	for i := 0; i < 30; i++ {
+		freq[i] = a
+		a, b = b, a+b
+	}
+	s := optimalHuffmanSpec(&freq)
+	if len(s.value) != 30 {
+		t.Fatalf("got %d values, want 30", len(s.value))
+	}
+	// The code must be complete but for the reserved all-ones codeword.
+	var kraft float64
+	for i, n := range s.count {
+		kraft += float64(n) / float64(uint(1)<<(i+1))
+	}
+	if want := 1 - 1.0/(1<<16); kraft != want {
+		t.Errorf("Kraft sum is %v, want %v", kraft, want)
+	}
+	// More frequent symbols have shorter codes, and so come first.
+	if i, j := bytes.IndexByte(s.value, 29), bytes.IndexByte(s.value, 0); i > j {
+		t.Errorf("symbol 29 comes after symbol 0 in %v", s.value)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	m := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	tests := []struct {
+		o    *Options
+		want string
+	}{
+		{&Options{Subsampling: 3}, "invalid subsampling"},
+		{&Options{RestartInterval: -1}, "invalid restart interval"},
+		{&Options{RestartInterval: 1 << 16}, "invalid restart interval"},
+		{&Options{Segments: []Segment{{Marker: comMarker}}}, "invalid segment marker"},
+		{&Options{Segments: []Segment{{Marker: app1Marker, Data: make([]byte, 1<<16)}}}, "segment is too large"},
+	}
+	for _, tt := range tests {
+		err := Encode(io.Discard, m, tt.o)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Encode(%+v) error = %v, want %q", tt.o, err, tt.want)
+		}
+	}
+}
@@ -4,10 +4,13 @@
 
 // Package png implements a PNG image decoder and encoder.
 //
-// The PNG specification is at https://www.w3.org/TR/PNG/.
+// The PNG specification is at https://www.w3.org/TR/PNG/. Animated PNG
+// (APNG) images, read by [DecodeAll] and written by [EncodeAll], are
+// specified at https://wiki.mozilla.org/APNG_Specification.
 package png
 
 import (
+	"bytes"
 	"compress/zlib"
 	"encoding/binary"
 	"fmt"
@@ -15,7 +18,9 @@ import (
 	"hash/crc32"
 	"image"
 	"image/color"
+	"internal/saferio"
 	"io"
+	"time"
 )
 
 // Color type, as per the PNG spec.
@@ -105,6 +110,30 @@ const (
 
 const pngHeader = "\x89PNG\r\n\x1a\n"
 
+// Frame disposal operations, as per the APNG spec. They say how the area
+// of the canvas covered by a frame is treated before the next frame is
+// rendered.
+const (
+	DisposalNone       = 0 // Leave the area as it is.
+	DisposalBackground = 1 // Clear the area to transparent black.
+	DisposalPrevious   = 2 // Restore the area to its previous contents.
+)
+
+// Frame blend operations, as per the APNG spec. They say how a frame is
+// combined with the canvas.
+const (
+	BlendSource = 0 // Replace the canvas pixels.
+	BlendOver   = 1 // Composite the frame over the canvas.
+)
+
+// An apngFrame holds the parameters of a frame, from its fcTL chunk.
+type apngFrame struct {
+	bounds   image.Rectangle
+	delay    time.Duration
+	disposal byte
+	blend    byte
+}
+
 type decoder struct {
 	r             io.Reader
 	img           image.Image
@@ -122,6 +151,21 @@ type decoder struct {
 	// transparency, as opposed to palette transparency.
 	useTransparent bool
 	transparent    [6]byte
+
+	// apng collects the frames of an animated image when decoding with
+	// DecodeAll. The animation chunks are ignored unless apng is non-nil
+	// and an acTL chunk precedes the IDAT chunks.
+	apng      *APNG
+	animated  bool
+	numFrames uint32
+	seq       uint32     // the next expected sequence number
+	frame     *apngFrame // the frame whose image data comes next, if any
+	fdAT      bool       // whether Read reads fdAT rather than IDAT chunks
+
+	// icc is the ICC profile of the iCCP chunk. The chunk is ignored
+	// unless readICC is set.
+	icc     []byte
+	readICC bool
 }
 
 // A FormatError reports that the input is not a valid PNG.
@@ -321,7 +365,8 @@ func (d *decoder) parsetRNS(length uint32) error {
 }
 
 // Read presents one or more IDAT chunks as one continuous stream (minus the
-// intermediate chunk headers and footers). If the PNG data looked like:
+// intermediate chunk headers and footers), or the fdAT chunks of a frame
+// (minus their sequence numbers). If the PNG data looked like:
 //
 //	... len0 IDAT xxx crc0 len1 IDAT yy crc1 len2 IEND crc2
 //
@@ -339,16 +384,29 @@ func (d *decoder) Read(p []byte) (int, error) {
 			return 0, err
 		}
 		// Read the length and chunk type of the next chunk, and check that
-		// it is an IDAT chunk.
+		// it is an IDAT chunk, or an fdAT chunk when reading a frame.
 		if _, err := io.ReadFull(d.r, d.tmp[:8]); err != nil {
 			return 0, err
 		}
 		d.idatLength = binary.BigEndian.Uint32(d.tmp[:4])
-		if string(d.tmp[4:8]) != "IDAT" {
+		name := "IDAT"
+		if d.fdAT {
+			name = "fdAT"
+		}
+		if string(d.tmp[4:8]) != name {
 			return 0, FormatError("not enough pixel data")
 		}
 		d.crc.Reset()
 		d.crc.Write(d.tmp[4:8])
+		if d.fdAT {
+			if d.idatLength < 4 {
+				return 0, FormatError("bad fdAT length")
+			}
+			if err := d.checkSequence(); err != nil {
+				return 0, err
+			}
+			d.idatLength -= 4
+		}
 	}
 	if int(d.idatLength) < 0 {
 		return 0, UnsupportedError("IDAT chunk length overflow")
@@ -857,9 +915,188 @@ func (d *decoder) parseIDAT(length uint32) (err error) {
 	if err != nil {
 		return err
 	}
+	if d.animated {
+		if d.frame != nil {
+			d.appendFrame(d.img)
+		} else {
+			d.apng.Default = d.img
+		}
+	}
+	return d.verifyChecksum()
+}
+
+func (d *decoder) parseacTL(length uint32) error {
+	if length != 8 {
+		return FormatError("bad acTL length")
+	}
+	if _, err := io.ReadFull(d.r, d.tmp[:8]); err != nil {
+		return err
+	}
+	d.crc.Write(d.tmp[:8])
+	d.numFrames = binary.BigEndian.Uint32(d.tmp[:4])
+	plays := binary.BigEndian.Uint32(d.tmp[4:8])
+	if d.numFrames == 0 || d.numFrames > 0x7fffffff || plays > 0x7fffffff {
+		return FormatError("bad acTL values")
+	}
+	d.animated = true
+	d.apng.LoopCount = int(plays)
 	return d.verifyChecksum()
 }
 
+// checkSequence reads the sequence number of an fcTL or fdAT chunk and
+// checks that it is the next one.
+func (d *decoder) checkSequence() error {
+	if _, err := io.ReadFull(d.r, d.tmp[:4]); err != nil {
+		return err
+	}
+	d.crc.Write(d.tmp[:4])
+	if binary.BigEndian.Uint32(d.tmp[:4]) != d.seq {
+		return FormatError("bad sequence number")
+	}
+	d.seq++
+	return nil
+}
+
+func (d *decoder) parsefcTL(length uint32) error {
+	if length != 26 {
+		return FormatError("bad fcTL length")
+	}
+	if err := d.checkSequence(); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(d.r, d.tmp[:22]); err != nil {
+		return err
+	}
+	d.crc.Write(d.tmp[:22])
+	if d.frame != nil {
+		return FormatError("missing frame data")
+	}
+	if uint32(len(d.apng.Image)) >= d.numFrames {
+		return FormatError("too many frames")
+	}
+	w := uint64(binary.BigEndian.Uint32(d.tmp[0:4]))
+	h := uint64(binary.BigEndian.Uint32(d.tmp[4:8]))
+	x := uint64(binary.BigEndian.Uint32(d.tmp[8:12]))
+	y := uint64(binary.BigEndian.Uint32(d.tmp[12:16]))
+	if w == 0 || h == 0 || x+w > uint64(d.width) || y+h > uint64(d.height) {
+		return FormatError("frame out of bounds")
+	}
+	if d.stage < dsSeenIDAT && (x != 0 || y != 0 || w != uint64(d.width) || h != uint64(d.height)) {
+		return FormatError("first frame does not cover the image")
+	}
+	num := time.Duration(binary.BigEndian.Uint16(d.tmp[16:18]))
+	den := time.Duration(binary.BigEndian.Uint16(d.tmp[18:20]))
+	if den == 0 {
+		den = 100
+	}
+	if d.tmp[20] > DisposalPrevious {
+		return FormatError("bad disposal operation")
+	}
+	if d.tmp[21] > BlendOver {
+		return FormatError("bad blend operation")
+	}
+	d.frame = &apngFrame{
+		bounds:   image.Rect(int(x), int(y), int(x+w), int(y+h)),
+		delay:    num * time.Second / den,
+		disposal: d.tmp[20],
+		blend:    d.tmp[21],
+	}
+	return d.verifyChecksum()
+}
+
+func (d *decoder) parsefdAT(length uint32) error {
+	if d.frame == nil {
+		return FormatError("fdAT chunk without fcTL chunk")
+	}
+	if length < 4 {
+		return FormatError("bad fdAT length")
+	}
+	if err := d.checkSequence(); err != nil {
+		return err
+	}
+	// Decode the frame as an image of its own size.
+	width, height := d.width, d.height
+	d.width, d.height = d.frame.bounds.Dx(), d.frame.bounds.Dy()
+	d.idatLength = length - 4
+	d.fdAT = true
+	img, err := d.decode()
+	d.width, d.height = width, height
+	d.fdAT = false
+	if err != nil {
+		return err
+	}
+	d.appendFrame(img)
+	return d.verifyChecksum()
+}
+
+// appendFrame adds the image of the current frame, decoded at the
+// origin, to the animation.
+func (d *decoder) appendFrame(img image.Image) {
+	f := d.frame
+	d.frame = nil
+	p := f.bounds.Min
+	switch m := img.(type) {
+	case *image.Gray:
+		m.Rect = m.Rect.Add(p)
+	case *image.Gray16:
+		m.Rect = m.Rect.Add(p)
+	case *image.RGBA:
+		m.Rect = m.Rect.Add(p)
+	case *image.RGBA64:
+		m.Rect = m.Rect.Add(p)
+	case *image.NRGBA:
+		m.Rect = m.Rect.Add(p)
+	case *image.NRGBA64:
+		m.Rect = m.Rect.Add(p)
+	case *image.Paletted:
+		m.Rect = m.Rect.Add(p)
+	}
+	a := d.apng
+	a.Image = append(a.Image, img)
+	a.Delay = append(a.Delay, f.delay)
+	a.Disposal = append(a.Disposal, f.disposal)
+	a.Blend = append(a.Blend, f.blend)
+}
+
+// maxICCProfile is the largest ICC profile that DecodeICCProfile returns.
+const maxICCProfile = 1 << 24
+
+func (d *decoder) parseiCCP(length uint32) error {
+	data, err := saferio.ReadData(d.r, uint64(length))
+	if err != nil {
+		return err
+	}
+	d.crc.Write(data)
+	if err := d.verifyChecksum(); err != nil {
+		return err
+	}
+	// The profile name, of 1 to 79 bytes, is followed by a null
+	// separator, the compression method and the compressed profile.
+	i := 0
+	for i < len(data) && data[i] != 0 {
+		i++
+	}
+	if i < 1 || i > 79 || len(data) < i+2 {
+		return FormatError("bad iCCP chunk")
+	}
+	if data[i+1] != 0 {
+		return UnsupportedError("iCCP compression method")
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data[i+2:]))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	d.icc, err = io.ReadAll(io.LimitReader(r, maxICCProfile+1))
+	if err != nil {
+		return err
+	}
+	if len(d.icc) > maxICCProfile {
+		return UnsupportedError("ICC profile size")
+	}
+	return nil
+}
+
 func (d *decoder) parseIEND(length uint32) error {
 	if length != 0 {
 		return FormatError("bad IEND length")
@@ -926,6 +1163,38 @@ func (d *decoder) parseChunk(configOnly bool) error {
 		}
 		d.stage = dsSeenIEND
 		return d.parseIEND(length)
+	case "iCCP":
+		if !d.readICC {
+			break
+		}
+		if d.stage != dsSeenIHDR {
+			return chunkOrderError
+		}
+		if d.icc != nil {
+			return FormatError("duplicate iCCP chunk")
+		}
+		return d.parseiCCP(length)
+	case "acTL":
+		if d.apng == nil {
+			break
+		}
+		if d.stage < dsSeenIHDR || d.stage >= dsSeenIDAT || d.animated {
+			return chunkOrderError
+		}
+		return d.parseacTL(length)
+	case "fcTL":
+		if !d.animated {
+			break
+		}
+		return d.parsefcTL(length)
+	case "fdAT":
+		if !d.animated {
+			break
+		}
+		if d.stage != dsSeenIDAT {
+			return chunkOrderError
+		}
+		return d.parsefdAT(length)
 	}
 	if length > 0x7fffffff {
 		return FormatError(fmt.Sprintf("Bad chunk length: %d", length))
@@ -1021,6 +1290,37 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 		}
 	}
 
+	return d.config(), nil
+}
+
+// DecodeICCProfile returns the ICC profile embedded in the iCCP chunk of a
+// PNG image, or nil if there is none, without decoding the image.
+func DecodeICCProfile(r io.Reader) ([]byte, error) {
+	d := &decoder{
+		r:       r,
+		crc:     crc32.NewIEEE(),
+		readICC: true,
+	}
+	if err := d.checkHeader(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	for d.stage < dsSeenPLTE {
+		if err := d.parseChunk(true); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return d.icc, nil
+}
+
+// config returns the color model and dimensions given by the IHDR and
+// PLTE chunks.
+func (d *decoder) config() image.Config {
 	var cm color.Model
 	switch d.cb {
 	case cbG1, cbG2, cbG4, cbG8:
@@ -1046,7 +1346,80 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 		ColorModel: cm,
 		Width:      d.width,
 		Height:     d.height,
-	}, nil
+	}
+}
+
+// APNG represents the frames of an animated PNG image. An image that is
+// not animated has a single frame.
+type APNG struct {
+	// Image holds the successive frames. The bounds of each frame must
+	// be within the rectangle defined by the two points (0, 0) and
+	// (Config.Width, Config.Height). When there is no default image,
+	// the first frame's bounds must be that rectangle.
+	Image []image.Image
+	// Delay is the successive delay times, one per frame.
+	Delay []time.Duration
+	// Disposal is the successive disposal operations, one per frame. A
+	// nil Disposal is valid to pass to EncodeAll, and implies that each
+	// frame's disposal operation is DisposalNone.
+	Disposal []byte
+	// Blend is the successive blend operations, one per frame. A nil
+	// Blend is valid to pass to EncodeAll, and implies that each frame's
+	// blend operation is BlendSource.
+	Blend []byte
+	// LoopCount is the number of times the animation is played. A
+	// LoopCount of 0 means to loop forever.
+	LoopCount int
+	// Config is the color model, width and height of the image. The
+	// ColorModel is ignored by EncodeAll, and a zero-valued Config
+	// implies that the width and height are those of the default image,
+	// or else the first frame's bounds' Rectangle.Max point.
+	Config image.Config
+	// Default, if non-nil, is the image displayed by decoders that do
+	// not support animation, such as Decode, when it is not a frame of
+	// the animation. It has the size of the whole image.
+	Default image.Image
+}
+
+// DecodeAll reads a PNG image from r and returns its frames and timing
+// information. An image that is not animated is returned as a single
+// frame with a zero delay.
+func DecodeAll(r io.Reader) (*APNG, error) {
+	d := &decoder{
+		r:    r,
+		crc:  crc32.NewIEEE(),
+		apng: new(APNG),
+	}
+	if err := d.checkHeader(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	for d.stage != dsSeenIEND {
+		if err := d.parseChunk(false); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	a := d.apng
+	a.Config = d.config()
+	if !d.animated {
+		a.Image = []image.Image{d.img}
+		a.Delay = []time.Duration{0}
+		a.Disposal = []byte{DisposalNone}
+		a.Blend = []byte{BlendSource}
+		return a, nil
+	}
+	if d.frame != nil {
+		return nil, FormatError("missing frame data")
+	}
+	if uint32(len(a.Image)) != d.numFrames {
+		return nil, FormatError("wrong number of frames")
+	}
+	return a, nil
 }
 
 func init() {
@@ -7,7 +7,10 @@ package png
 import (
 	"bufio"
 	"bytes"
+	"compress/zlib"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
 	"io"
@@ -15,6 +18,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 var filenames = []string{
@@ -836,6 +840,180 @@ func TestDecodePalettedWithTransparency(t *testing.T) {
 	}
 }
 
+// pngChunk returns a chunk with the given name and data.
+func pngChunk(name string, data ...[]byte) []byte {
+	d := bytes.Join(data, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(d)))
+	b = append(b, name...)
+	b = append(b, d...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+}
+
+// gray8Data returns the compressed, unfiltered rows of an 8-bit
+// grayscale image.
+func gray8Data(rows ...[]byte) []byte {
+	var b bytes.Buffer
+	z := zlib.NewWriter(&b)
+	for _, row := range rows {
+		z.Write([]byte{ftNone})
+		z.Write(row)
+	}
+	z.Close()
+	return b.Bytes()
+}
+
+func fcTL(seq, w, h, x, y uint32, num, den uint16, disposal, blend byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, seq)
+	for _, v := range []uint32{w, h, x, y} {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	b = binary.BigEndian.AppendUint16(b, num)
+	b = binary.BigEndian.AppendUint16(b, den)
+	return pngChunk("fcTL", append(b, disposal, blend))
+}
+
+func acTL(frames, plays uint32) []byte {
+	return pngChunk("acTL", binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, frames), plays))
+}
+
+func fdAT(seq uint32, data []byte) []byte {
+	return pngChunk("fdAT", binary.BigEndian.AppendUint32(nil, seq), data)
+}
+
+func TestDecodeICCProfile(t *testing.T) {
+	ihdr := pngChunk("IHDR", []byte{0, 0, 0, 1, 0, 0, 0, 1, 8, ctGrayscale, 0, 0, 0})
+	idat := pngChunk("IDAT", gray8Data([]byte{0x80}))
+	iend := pngChunk("IEND")
+	profile := bytes.Repeat([]byte("not really an ICC profile "), 10)
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	w.Write(profile)
+	w.Close()
+	iccp := pngChunk("iCCP", []byte("name\x00\x00"), z.Bytes())
+	build := func(chunks ...[]byte) []byte {
+		return append([]byte(pngHeader), bytes.Join(chunks, nil)...)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want []byte
+		err  string
+	}{
+		{"profile", build(ihdr, iccp, idat, iend), profile, ""},
+		{"no profile", build(ihdr, idat, iend), nil, ""},
+		{"late profile", build(ihdr, idat, iccp, iend), nil, ""},
+		{"unnamed", build(ihdr, pngChunk("iCCP", []byte("\x00\x00"), z.Bytes()), idat, iend), nil, "bad iCCP chunk"},
+		{"compression", build(ihdr, pngChunk("iCCP", []byte("name\x00\x01"), z.Bytes()), idat, iend), nil, "iCCP compression method"},
+		{"duplicate", build(ihdr, iccp, iccp, idat, iend), nil, "duplicate iCCP chunk"},
+	} {
+		got, err := DecodeICCProfile(bytes.NewReader(tt.data))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+		// Decode ignores the iCCP chunk.
+		if _, err := Decode(bytes.NewReader(tt.data)); err != nil {
+			t.Errorf("%s: Decode: %v", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	ihdr := pngChunk("IHDR", []byte{0, 0, 0, 4, 0, 0, 0, 3, 8, ctGrayscale, 0, 0, 0})
+	idat := pngChunk("IDAT", gray8Data([]byte{1, 2, 3, 4}, []byte{5, 6, 7, 8}, []byte{9, 10, 11, 12}))
+	frame := gray8Data([]byte{20, 21}, []byte{22, 23})
+	iend := pngChunk("IEND")
+	build := func(chunks ...[]byte) []byte {
+		return append([]byte(pngHeader), bytes.Join(chunks, nil)...)
+	}
+
+	// The IDAT image is the first frame, and the second frame's data is
+	// split across two fdAT chunks.
+	data := build(ihdr, acTL(2, 5),
+		fcTL(0, 4, 3, 0, 0, 1, 60, DisposalBackground, BlendSource), idat,
+		pngChunk("tEXt", []byte("a\x00b")),
+		fcTL(1, 2, 2, 1, 1, 0, 0, DisposalPrevious, BlendOver), fdAT(2, frame[:5]), fdAT(3, frame[5:]),
+		iend)
+	a, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Image) != 2 || a.LoopCount != 5 || a.Default != nil ||
+		a.Config != (image.Config{ColorModel: color.GrayModel, Width: 4, Height: 3}) {
+		t.Fatalf("DecodeAll: %d frames, %+v", len(a.Image), a)
+	}
+	wantDelay := []time.Duration{time.Second / 60, 0}
+	wantDisposal := []byte{DisposalBackground, DisposalPrevious}
+	wantBlend := []byte{BlendSource, BlendOver}
+	if !reflect.DeepEqual(a.Delay, wantDelay) || !bytes.Equal(a.Disposal, wantDisposal) || !bytes.Equal(a.Blend, wantBlend) {
+		t.Errorf("DecodeAll: Delay %v, Disposal %v, Blend %v", a.Delay, a.Disposal, a.Blend)
+	}
+	if g := a.Image[0].(*image.Gray); g.Rect != image.Rect(0, 0, 4, 3) || g.GrayAt(3, 2).Y != 12 {
+		t.Errorf("frame 0: %v", g)
+	}
+	if g := a.Image[1].(*image.Gray); g.Rect != image.Rect(1, 1, 3, 3) || g.GrayAt(1, 1).Y != 20 || g.GrayAt(2, 2).Y != 23 {
+		t.Errorf("frame 1: %v", g)
+	}
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil || m.Bounds() != image.Rect(0, 0, 4, 3) || m.(*image.Gray).Pix[0] != 1 {
+		t.Errorf("Decode: %v, %v", m, err)
+	}
+
+	// Without an fcTL chunk before it, the IDAT image is a default image.
+	data = build(ihdr, acTL(1, 0), idat, fcTL(0, 2, 2, 2, 1, 3, 0, DisposalNone, BlendOver), fdAT(1, frame), iend)
+	a, err = DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Default == nil || len(a.Image) != 1 || a.Image[0].Bounds() != image.Rect(2, 1, 4, 3) || a.Delay[0] != 30*time.Millisecond {
+		t.Errorf("DecodeAll: default %v, %d frames, %+v", a.Default, len(a.Image), a)
+	}
+
+	// Without an acTL chunk, the animation chunks are ignored.
+	data = build(ihdr, fcTL(0, 4, 3, 0, 0, 1, 60, 0, 0), idat, fcTL(1, 2, 2, 1, 1, 0, 0, 0, 0), fdAT(2, frame), iend)
+	a, err = DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Image) != 1 || a.Default != nil || a.Delay[0] != 0 || a.Image[0].Bounds() != image.Rect(0, 0, 4, 3) {
+		t.Errorf("DecodeAll of a static image: %+v", a)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"bad sequence", build(ihdr, acTL(1, 0), fcTL(1, 4, 3, 0, 0, 0, 0, 0, 0), idat, iend), "bad sequence number"},
+		{"first frame", build(ihdr, acTL(1, 0), fcTL(0, 3, 3, 0, 0, 0, 0, 0, 0), idat, iend), "first frame does not cover"},
+		{"out of bounds", build(ihdr, acTL(1, 0), idat, fcTL(0, 2, 2, 3, 0, 0, 0, 0, 0), fdAT(1, frame), iend), "frame out of bounds"},
+		{"too many frames", build(ihdr, acTL(1, 0), fcTL(0, 4, 3, 0, 0, 0, 0, 0, 0), idat, fcTL(1, 2, 2, 0, 0, 0, 0, 0, 0), fdAT(2, frame), iend), "too many frames"},
+		{"too few frames", build(ihdr, acTL(3, 0), fcTL(0, 4, 3, 0, 0, 0, 0, 0, 0), idat, iend), "wrong number of frames"},
+		{"missing data", build(ihdr, acTL(2, 0), fcTL(0, 4, 3, 0, 0, 0, 0, 0, 0), idat, fcTL(1, 2, 2, 0, 0, 0, 0, 0, 0), iend), "missing frame data"},
+		{"fdAT without fcTL", build(ihdr, acTL(1, 0), idat, fdAT(0, frame), iend), "fdAT chunk without fcTL"},
+		{"fdAT before IDAT", build(ihdr, acTL(1, 0), fcTL(0, 4, 3, 0, 0, 0, 0, 0, 0), fdAT(1, frame), idat, iend), "chunk out of order"},
+		{"acTL after IDAT", build(ihdr, idat, acTL(1, 0), iend), "chunk out of order"},
+		{"disposal", build(ihdr, acTL(1, 0), fcTL(0, 4, 3, 0, 0, 0, 0, 3, 0), idat, iend), "bad disposal operation"},
+		{"short frame data", build(ihdr, acTL(1, 0), idat, fcTL(0, 2, 2, 0, 0, 0, 0, 0, 0), fdAT(1, frame[:5]), iend), "not enough pixel data"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeAll(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: DecodeAll error = %v, want %q", tt.name, err, tt.want)
+		}
+		// Decode ignores the animation chunks.
+		if _, err := Decode(bytes.NewReader(tt.data)); err != nil {
+			t.Errorf("%s: Decode error = %v", tt.name, err)
+		}
+	}
+}
+
 func benchmarkDecode(b *testing.B, filename string, bytesPerPixel int) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
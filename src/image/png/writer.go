@@ -8,11 +8,14 @@ import (
 	"bufio"
 	"compress/zlib"
 	"encoding/binary"
+	"errors"
 	"hash/crc32"
 	"image"
 	"image/color"
 	"io"
+	"slices"
 	"strconv"
+	"time"
 )
 
 // Encoder configures encoding PNG images.
@@ -49,6 +52,12 @@ type encoder struct {
 	zw      *zlib.Writer
 	zwLevel int
 	bw      *bufio.Writer
+
+	// When fdAT is set, Write writes fdAT chunks, numbered from seq,
+	// instead of IDAT chunks.
+	fdAT bool
+	seq  uint32
+	fbuf []byte
 }
 
 // CompressionLevel indicates the compression level.
@@ -195,7 +204,14 @@ func (e *encoder) writePLTEAndTRNS(p color.Palette) {
 // This method should only be called from writeIDATs (via writeImage).
 // No other code should treat an encoder as an io.Writer.
 func (e *encoder) Write(b []byte) (int, error) {
-	e.writeChunk(b, "IDAT")
+	if e.fdAT {
+		e.fbuf = binary.BigEndian.AppendUint32(e.fbuf[:0], e.seq)
+		e.fbuf = append(e.fbuf, b...)
+		e.seq++
+		e.writeChunk(e.fbuf, "fdAT")
+	} else {
+		e.writeChunk(b, "IDAT")
+	}
 	if e.err != nil {
 		return 0, e.err
 	}
@@ -596,6 +612,28 @@ func (enc *Encoder) Encode(w io.Writer, m image.Image) error {
 		return FormatError("invalid image size: " + strconv.FormatInt(mw, 10) + "x" + strconv.FormatInt(mh, 10))
 	}
 
+	e := enc.newEncoder()
+	if enc.BufferPool != nil {
+		defer enc.BufferPool.Put((*EncoderBuffer)(e))
+	}
+	e.w = w
+	e.m = m
+
+	var pal color.Palette
+	e.cb, pal = colorBits(m)
+
+	_, e.err = io.WriteString(w, pngHeader)
+	e.writeIHDR()
+	if pal != nil {
+		e.writePLTEAndTRNS(pal)
+	}
+	e.writeIDATs()
+	e.writeIEND()
+	return e.err
+}
+
+// newEncoder returns an encoder, from the buffer pool if there is one.
+func (enc *Encoder) newEncoder() *encoder {
 	var e *encoder
 	if enc.BufferPool != nil {
 		buffer := enc.BufferPool.Get()
@@ -605,56 +643,222 @@ func (enc *Encoder) Encode(w io.Writer, m image.Image) error {
 	if e == nil {
 		e = &encoder{}
 	}
-	if enc.BufferPool != nil {
-		defer enc.BufferPool.Put((*EncoderBuffer)(e))
-	}
-
 	e.enc = enc
-	e.w = w
-	e.m = m
+	e.err = nil
+	e.fdAT = false
+	e.seq = 0
+	return e
+}
 
-	var pal color.Palette
+// colorBits returns the color type and bit depth in which to encode m,
+// and its palette for a paletted encoding.
+func colorBits(m image.Image) (cb int, pal color.Palette) {
 	// cbP8 encoding needs PalettedImage's ColorIndexAt method.
 	if _, ok := m.(image.PalettedImage); ok {
 		pal, _ = m.ColorModel().(color.Palette)
 	}
 	if pal != nil {
 		if len(pal) <= 2 {
-			e.cb = cbP1
+			cb = cbP1
 		} else if len(pal) <= 4 {
-			e.cb = cbP2
+			cb = cbP2
 		} else if len(pal) <= 16 {
-			e.cb = cbP4
+			cb = cbP4
 		} else {
-			e.cb = cbP8
+			cb = cbP8
 		}
 	} else {
 		switch m.ColorModel() {
 		case color.GrayModel:
-			e.cb = cbG8
+			cb = cbG8
 		case color.Gray16Model:
-			e.cb = cbG16
+			cb = cbG16
 		case color.RGBAModel, color.NRGBAModel, color.AlphaModel:
 			if opaque(m) {
-				e.cb = cbTC8
+				cb = cbTC8
 			} else {
-				e.cb = cbTCA8
+				cb = cbTCA8
 			}
 		default:
 			if opaque(m) {
-				e.cb = cbTC16
+				cb = cbTC16
 			} else {
-				e.cb = cbTCA16
+				cb = cbTCA16
 			}
 		}
 	}
+	return cb, pal
+}
+
+// commonColorBits returns the color type and bit depth in which to
+// encode all the images, and their palette for a paletted encoding.
+// Images that would be encoded differently on their own are encoded as
+// truecolor, with 16 bits per sample if any of them needs it.
+func commonColorBits(images []image.Image) (cb int, pal color.Palette) {
+	cb, pal = colorBits(images[0])
+	same, depth16, alpha := true, false, false
+	for _, m := range images {
+		cb1, pal1 := colorBits(m)
+		if cb1 != cb || !slices.Equal(pal1, pal) {
+			same = false
+		}
+		switch cb1 {
+		case cbG16, cbTC16, cbTCA16:
+			depth16 = true
+		}
+		alpha = alpha || !opaque(m)
+	}
+	switch {
+	case same:
+		return cb, pal
+	case depth16 && alpha:
+		return cbTCA16, nil
+	case depth16:
+		return cbTC16, nil
+	case alpha:
+		return cbTCA8, nil
+	}
+	return cbTC8, nil
+}
+
+// delayFraction returns a frame delay as a fraction of a second. It
+// prefers the smallest denominator that represents the delay exactly as
+// decoded, and otherwise rounds the delay to the nearest millisecond,
+// or to a coarser unit for long delays.
+func delayFraction(d time.Duration) (num, den uint16) {
+	d = min(max(d, 0), 0xffff*time.Second)
+	for den := time.Duration(1); den <= 1000; den++ {
+		n := (d*den + time.Second/2) / time.Second
+		if n <= 0xffff && n*time.Second/den == d {
+			return uint16(n), uint16(den)
+		}
+	}
+	for _, den := range []time.Duration{1000, 100, 10, 1} {
+		if n := (d*den + time.Second/2) / time.Second; n <= 0xffff {
+			return uint16(n), uint16(den)
+		}
+	}
+	return 0xffff, 1
+}
+
+func (e *encoder) writeacTL(numFrames, loopCount int) {
+	binary.BigEndian.PutUint32(e.tmp[0:4], uint32(numFrames))
+	binary.BigEndian.PutUint32(e.tmp[4:8], uint32(loopCount))
+	e.writeChunk(e.tmp[:8], "acTL")
+}
+
+func (e *encoder) writefcTL(b image.Rectangle, delay time.Duration, disposal, blend byte) {
+	num, den := delayFraction(delay)
+	binary.BigEndian.PutUint32(e.tmp[0:4], e.seq)
+	binary.BigEndian.PutUint32(e.tmp[4:8], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(e.tmp[8:12], uint32(b.Dy()))
+	binary.BigEndian.PutUint32(e.tmp[12:16], uint32(b.Min.X))
+	binary.BigEndian.PutUint32(e.tmp[16:20], uint32(b.Min.Y))
+	binary.BigEndian.PutUint16(e.tmp[20:22], num)
+	binary.BigEndian.PutUint16(e.tmp[22:24], den)
+	e.tmp[24] = disposal
+	e.tmp[25] = blend
+	e.seq++
+	e.writeChunk(e.tmp[:26], "fcTL")
+}
+
+// EncodeAll writes the frames in a to w in animated PNG format, using
+// the default [Encoder].
+func EncodeAll(w io.Writer, a *APNG) error {
+	var e Encoder
+	return e.EncodeAll(w, a)
+}
+
+// EncodeAll writes the frames in a to w in animated PNG format. All the
+// frames, and the default image if any, share one color type: that of
+// the images if they agree, and otherwise truecolor, with or without
+// alpha as needed.
+func (enc *Encoder) EncodeAll(w io.Writer, a *APNG) error {
+	if len(a.Image) == 0 {
+		return errors.New("png: must provide at least one image")
+	}
+	if len(a.Image) != len(a.Delay) {
+		return errors.New("png: mismatched image and delay lengths")
+	}
+	if a.Disposal != nil && len(a.Image) != len(a.Disposal) {
+		return errors.New("png: mismatched image and disposal lengths")
+	}
+	if a.Blend != nil && len(a.Image) != len(a.Blend) {
+		return errors.New("png: mismatched image and blend lengths")
+	}
+	if a.LoopCount < 0 || int64(a.LoopCount) > 0x7fffffff {
+		return errors.New("png: invalid loop count")
+	}
+
+	width, height := a.Config.Width, a.Config.Height
+	if a.Config == (image.Config{}) {
+		if a.Default != nil {
+			width, height = a.Default.Bounds().Dx(), a.Default.Bounds().Dy()
+		} else {
+			p := a.Image[0].Bounds().Max
+			width, height = p.X, p.Y
+		}
+	}
+	mw, mh := int64(width), int64(height)
+	if mw <= 0 || mh <= 0 || mw >= 1<<31 || mh >= 1<<31 {
+		return FormatError("invalid image size: " + strconv.FormatInt(mw, 10) + "x" + strconv.FormatInt(mh, 10))
+	}
+	canvas := image.Rect(0, 0, width, height)
+	if a.Default != nil && a.Default.Bounds().Size() != canvas.Size() {
+		return errors.New("png: default image size does not match the image size")
+	}
+	if a.Default == nil && a.Image[0].Bounds() != canvas {
+		return errors.New("png: first frame must cover the image")
+	}
+	for i, m := range a.Image {
+		if b := m.Bounds(); b.Empty() || !b.In(canvas) {
+			return errors.New("png: frame is empty or out of bounds")
+		}
+		if a.Disposal != nil && a.Disposal[i] > DisposalPrevious {
+			return errors.New("png: invalid disposal operation")
+		}
+		if a.Blend != nil && a.Blend[i] > BlendOver {
+			return errors.New("png: invalid blend operation")
+		}
+	}
+
+	e := enc.newEncoder()
+	if enc.BufferPool != nil {
+		defer enc.BufferPool.Put((*EncoderBuffer)(e))
+	}
+	e.w = w
+	images := a.Image
+	e.m = a.Image[0]
+	if a.Default != nil {
+		images = append([]image.Image{a.Default}, images...)
+		e.m = a.Default
+	}
+	var pal color.Palette
+	e.cb, pal = commonColorBits(images)
 
 	_, e.err = io.WriteString(w, pngHeader)
 	e.writeIHDR()
+	e.writeacTL(len(a.Image), a.LoopCount)
 	if pal != nil {
 		e.writePLTEAndTRNS(pal)
 	}
-	e.writeIDATs()
+	if a.Default != nil {
+		e.writeIDATs()
+	}
+	for i, m := range a.Image {
+		var disposal, blend byte
+		if a.Disposal != nil {
+			disposal = a.Disposal[i]
+		}
+		if a.Blend != nil {
+			blend = a.Blend[i]
+		}
+		e.writefcTL(m.Bounds(), a.Delay[i], disposal, blend)
+		e.m = m
+		e.fdAT = i > 0 || a.Default != nil
+		e.writeIDATs()
+	}
+	e.fdAT = false
 	e.writeIEND()
 	return e.err
 }
@@ -11,9 +11,13 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/color/palette"
 	"image/draw"
 	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func diff(m0, m1 image.Image) error {
@@ -278,6 +282,183 @@ func TestWriteRGBA(t *testing.T) {
 	}
 }
 
+func TestEncodeAll(t *testing.T) {
+	full := image.Rect(0, 0, 7, 5)
+	gray := func(r image.Rectangle, v uint8) *image.Gray {
+		m := image.NewGray(r)
+		for i := range m.Pix {
+			m.Pix[i] = v + uint8(i)
+		}
+		return m
+	}
+	paletted := func(r image.Rectangle, v uint8) *image.Paletted {
+		m := image.NewPaletted(r, palette.WebSafe)
+		for i := range m.Pix {
+			m.Pix[i] = v + uint8(i)
+		}
+		return m
+	}
+	nrgba := image.NewNRGBA(image.Rect(2, 1, 5, 4))
+	for i := range nrgba.Pix {
+		nrgba.Pix[i] = uint8(i * 29)
+	}
+	rgba64 := image.NewRGBA64(image.Rect(0, 0, 2, 2))
+	for i := range rgba64.Pix {
+		rgba64.Pix[i] = 0xff
+	}
+	rgba64.Pix[0] = 0x12
+
+	tests := []struct {
+		name string
+		a    *APNG
+		cb   color.Model
+	}{{
+		name: "gray",
+		a: &APNG{
+			Image:     []image.Image{gray(full, 0), gray(image.Rect(1, 2, 4, 5), 100)},
+			Delay:     []time.Duration{time.Second / 60, 40 * time.Millisecond},
+			Disposal:  []byte{DisposalBackground, DisposalPrevious},
+			Blend:     []byte{BlendSource, BlendOver},
+			LoopCount: 3,
+		},
+		cb: color.GrayModel,
+	}, {
+		name: "paletted with default image",
+		a: &APNG{
+			Image:   []image.Image{paletted(image.Rect(3, 0, 7, 2), 10), paletted(full, 20)},
+			Delay:   []time.Duration{time.Second, 0},
+			Config:  image.Config{Width: 7, Height: 5},
+			Default: paletted(image.Rect(10, 10, 17, 15), 30),
+		},
+		cb: color.Palette(palette.WebSafe),
+	}, {
+		name: "mixed",
+		a: &APNG{
+			Image: []image.Image{gray(full, 0), nrgba, paletted(image.Rect(4, 4, 7, 5), 200)},
+			Delay: []time.Duration{100 * time.Millisecond, 2 * time.Minute, 20 * time.Hour},
+		},
+		cb: color.NRGBAModel,
+	}, {
+		name: "mixed 16-bit",
+		a: &APNG{
+			Image: []image.Image{gray(image.Rect(0, 0, 2, 2), 0), rgba64},
+			Delay: []time.Duration{0, 0},
+		},
+		cb: color.RGBA64Model,
+	}}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := EncodeAll(&b, tt.a); err != nil {
+			t.Errorf("%s: EncodeAll: %v", tt.name, err)
+			continue
+		}
+		got, err := DecodeAll(bytes.NewReader(b.Bytes()))
+		if err != nil {
+			t.Errorf("%s: DecodeAll: %v", tt.name, err)
+			continue
+		}
+		if len(got.Image) != len(tt.a.Image) {
+			t.Errorf("%s: got %d frames, want %d", tt.name, len(got.Image), len(tt.a.Image))
+			continue
+		}
+		for i, m := range tt.a.Image {
+			if got.Image[i].Bounds() != m.Bounds() {
+				t.Errorf("%s: frame %d: bounds %v, want %v", tt.name, i, got.Image[i].Bounds(), m.Bounds())
+			}
+			if err := diff(m, got.Image[i]); err != nil {
+				t.Errorf("%s: frame %d: %v", tt.name, i, err)
+			}
+		}
+		wantDelay := tt.a.Delay
+		if tt.name == "mixed" {
+			// Delays are at most 65535 seconds.
+			wantDelay = []time.Duration{100 * time.Millisecond, 2 * time.Minute, 65535 * time.Second}
+		}
+		wantDisposal, wantBlend := tt.a.Disposal, tt.a.Blend
+		if wantDisposal == nil {
+			wantDisposal = make([]byte, len(tt.a.Image))
+		}
+		if wantBlend == nil {
+			wantBlend = make([]byte, len(tt.a.Image))
+		}
+		if !reflect.DeepEqual(got.Delay, wantDelay) || !bytes.Equal(got.Disposal, wantDisposal) || !bytes.Equal(got.Blend, wantBlend) || got.LoopCount != tt.a.LoopCount {
+			t.Errorf("%s: Delay %v, Disposal %v, Blend %v, LoopCount %d", tt.name, got.Delay, got.Disposal, got.Blend, got.LoopCount)
+		}
+		if !reflect.DeepEqual(got.Config.ColorModel, tt.cb) {
+			t.Errorf("%s: color model %v", tt.name, got.Config.ColorModel)
+		}
+
+		// Decode returns the default image, or else the first frame.
+		m, err := Decode(bytes.NewReader(b.Bytes()))
+		if err != nil {
+			t.Errorf("%s: Decode: %v", tt.name, err)
+			continue
+		}
+		want := tt.a.Default
+		if want == nil {
+			want = tt.a.Image[0]
+		}
+		if err := diff(want, m); err != nil {
+			t.Errorf("%s: Decode: %v", tt.name, err)
+		}
+		if tt.a.Default != nil {
+			if err := diff(tt.a.Default, got.Default); err != nil {
+				t.Errorf("%s: default image: %v", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestEncodeAllErrors(t *testing.T) {
+	m := image.NewGray(image.Rect(0, 0, 4, 4))
+	small := image.NewGray(image.Rect(1, 1, 3, 3))
+	d := []time.Duration{0}
+	tests := []struct {
+		a    *APNG
+		want string
+	}{
+		{&APNG{}, "at least one image"},
+		{&APNG{Image: []image.Image{m}}, "mismatched image and delay lengths"},
+		{&APNG{Image: []image.Image{m}, Delay: d, Disposal: []byte{0, 0}}, "mismatched image and disposal lengths"},
+		{&APNG{Image: []image.Image{m}, Delay: d, Blend: []byte{}}, "mismatched image and blend lengths"},
+		{&APNG{Image: []image.Image{m}, Delay: d, LoopCount: -1}, "invalid loop count"},
+		{&APNG{Image: []image.Image{small}, Delay: d}, "first frame must cover the image"},
+		{&APNG{Image: []image.Image{m, small}, Delay: []time.Duration{0, 0}, Config: image.Config{Width: 2, Height: 2}}, "first frame must cover the image"},
+		{&APNG{Image: []image.Image{small}, Delay: d, Default: m, Config: image.Config{Width: 2, Height: 2}}, "default image size"},
+		{&APNG{Image: []image.Image{m, image.NewGray(image.Rect(3, 3, 5, 5))}, Delay: []time.Duration{0, 0}}, "out of bounds"},
+		{&APNG{Image: []image.Image{m}, Delay: d, Disposal: []byte{3}}, "invalid disposal operation"},
+		{&APNG{Image: []image.Image{m}, Delay: d, Blend: []byte{2}}, "invalid blend operation"},
+	}
+	for _, tt := range tests {
+		err := EncodeAll(io.Discard, tt.a)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("EncodeAll(%+v) = %v, want %q", tt.a, err, tt.want)
+		}
+	}
+}
+
+func TestDelayFraction(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		num, den uint16
+	}{
+		{0, 0, 1},
+		{-time.Second, 0, 1},
+		{time.Second, 1, 1},
+		{40 * time.Millisecond, 1, 25},
+		{time.Second / 60, 1, 60},
+		{time.Second / 3, 1, 3},
+		{1234567 * time.Microsecond, 1235, 1000},
+		{20 * time.Minute, 1200, 1},
+		{100 * time.Hour, 0xffff, 1},
+	}
+	for _, tt := range tests {
+		if num, den := delayFraction(tt.d); num != tt.num || den != tt.den {
+			t.Errorf("delayFraction(%v) = %d/%d, want %d/%d", tt.d, num, den, tt.num, tt.den)
+		}
+	}
+}
+
 func BenchmarkEncodeGray(b *testing.B) {
 	img := image.NewGray(image.Rect(0, 0, 640, 480))
 	b.SetBytes(640 * 480 * 1)
@@ -0,0 +1,187 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tiff
+
+// TIFF's variant of LZW differs from the one in package compress/lzw:
+// codes are packed most significant bit first, and the code width grows
+// one code earlier than the table requires ("early change").
+
+const (
+	lzwClear    = 256
+	lzwEOI      = 257
+	lzwFirst    = 258
+	lzwMaxWidth = 12
+	lzwMaxCode  = 1<<lzwMaxWidth - 1
+)
+
+// unLZW decodes src, producing at most limit bytes.
+func unLZW(src []byte, limit int) ([]byte, error) {
+	var (
+		prefix [lzwMaxCode + 1]uint16
+		suffix [lzwMaxCode + 1]byte
+		length [lzwMaxCode + 1]uint16 // length of each code's string
+		first  [lzwMaxCode + 1]byte   // first byte of each code's string
+	)
+	for i := range 256 {
+		suffix[i], first[i], length[i] = byte(i), byte(i), 1
+	}
+	dst := make([]byte, 0, min(limit, 4*len(src)))
+	var bits uint32
+	var nbits uint
+	width := uint(9)
+	next := lzwFirst
+	prev := -1
+	for len(dst) < limit {
+		for nbits < width {
+			if len(src) == 0 {
+				// Tolerate a missing end-of-information code.
+				return dst, nil
+			}
+			bits = bits<<8 | uint32(src[0])
+			src = src[1:]
+			nbits += 8
+		}
+		code := int(bits>>(nbits-width)) & (1<<width - 1)
+		nbits -= width
+
+		switch {
+		case code == lzwClear:
+			width, next, prev = 9, lzwFirst, -1
+			continue
+		case code == lzwEOI:
+			return dst, nil
+		case prev < 0:
+			if code >= 256 {
+				return nil, FormatError("invalid LZW code")
+			}
+			dst = append(dst, byte(code))
+			prev = code
+			continue
+		case code > next || code == next && next > lzwMaxCode:
+			return nil, FormatError("invalid LZW code")
+		}
+
+		// Add the previous string extended by the first byte of this
+		// one, which for code == next is the string being defined.
+		if next <= lzwMaxCode {
+			c := first[code]
+			if code == next {
+				c = first[prev]
+			}
+			prefix[next] = uint16(prev)
+			suffix[next] = c
+			length[next] = length[prev] + 1
+			first[next] = first[prev]
+			next++
+			if next >= 1<<width-1 && width < lzwMaxWidth {
+				width++
+			}
+		}
+
+		// Write the string for code backwards from its last byte.
+		n := int(length[code])
+		if n > limit-len(dst) {
+			n = limit - len(dst)
+		}
+		dst = append(dst, make([]byte, n)...)
+		c := code
+		for i := int(length[code]) - 1; i >= 0; i-- {
+			if i < n {
+				dst[len(dst)-n+i] = suffix[c]
+			}
+			c = int(prefix[c])
+		}
+		prev = code
+	}
+	return dst, nil
+}
+
+// lzw compresses src.
+func lzw(src []byte) []byte {
+	var dst []byte
+	var bits uint32
+	var nbits uint
+	width := uint(9)
+	put := func(code int) {
+		bits = bits<<width | uint32(code)
+		nbits += width
+		for nbits >= 8 {
+			dst = append(dst, byte(bits>>(nbits-8)))
+			nbits -= 8
+		}
+	}
+
+	table := make(map[uint32]int) // (prefix code << 8 | byte) -> code
+	next := lzwFirst
+	put(lzwClear)
+	if len(src) == 0 {
+		put(lzwEOI)
+	}
+	cur := -1
+	emit := func() {
+		put(cur)
+		next++
+		if next == lzwMaxCode-1 {
+			put(lzwClear)
+			clear(table)
+			width, next = 9, lzwFirst
+		} else if next > 1<<width-1 {
+			width++
+		}
+	}
+	for _, c := range src {
+		if cur < 0 {
+			cur = int(c)
+			continue
+		}
+		key := uint32(cur)<<8 | uint32(c)
+		if code, ok := table[key]; ok {
+			cur = code
+			continue
+		}
+		table[key] = next
+		emit()
+		cur = int(c)
+	}
+	if cur >= 0 {
+		emit()
+		put(lzwEOI)
+	}
+	if nbits > 0 {
+		dst = append(dst, byte(bits<<(8-nbits)))
+	}
+	return dst
+}
+
+// unpackBits decodes PackBits run-length encoding, producing at most
+// limit bytes.
+func unpackBits(src []byte, limit int) ([]byte, error) {
+	var dst []byte
+	for len(src) > 0 && len(dst) < limit {
+		n := int(int8(src[0]))
+		src = src[1:]
+		switch {
+		case n >= 0:
+			n++
+			if n > len(src) {
+				return nil, FormatError("short PackBits data")
+			}
+			dst = append(dst, src[:n]...)
+			src = src[n:]
+		case n != -128:
+			if len(src) == 0 {
+				return nil, FormatError("short PackBits data")
+			}
+			for range 1 - n {
+				dst = append(dst, src[0])
+			}
+			src = src[1:]
+		}
+	}
+	if len(dst) > limit {
+		dst = dst[:limit]
+	}
+	return dst, nil
+}
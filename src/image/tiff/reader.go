@@ -0,0 +1,733 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tiff implements a TIFF image decoder and encoder.
+//
+// The decoder reads the first image of a baseline TIFF file: bilevel,
+// grayscale, paletted, RGB and CMYK images with 1 to 16 bits per sample,
+// organized in strips or tiles, uncompressed or compressed with LZW,
+// Deflate or PackBits. The TIFF specification is at
+// https://www.itu.int/itudoc/itu-t/com16/tiff-fx/docs/tiff6.pdf.
+package tiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"internal/saferio"
+	"io"
+	"strconv"
+)
+
+// A FormatError reports that the input is not a valid TIFF image.
+type FormatError string
+
+func (e FormatError) Error() string { return "tiff: invalid format: " + string(e) }
+
+// An UnsupportedError reports that the input uses a valid but
+// unimplemented TIFF feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string { return "tiff: unsupported feature: " + string(e) }
+
+const (
+	leHeader = "II\x2A\x00" // Header for little-endian files.
+	beHeader = "MM\x00\x2A" // Header for big-endian files.
+
+	ifdLen = 12 // Length of an IFD entry in bytes.
+)
+
+// Data types, as stored in IFD entries.
+const (
+	dtByte     = 1
+	dtASCII    = 2
+	dtShort    = 3
+	dtLong     = 4
+	dtRational = 5
+
+	dtUndefined = 7
+)
+
+// lengths of the data types, in bytes.
+var lengths = [...]uint32{0, 1, 1, 2, 4, 8}
+
+// Tags.
+const (
+	tImageWidth                = 256
+	tImageLength               = 257
+	tBitsPerSample             = 258
+	tCompression               = 259
+	tPhotometricInterpretation = 262
+	tFillOrder                 = 266
+	tStripOffsets              = 273
+	tSamplesPerPixel           = 277
+	tRowsPerStrip              = 278
+	tStripByteCounts           = 279
+	tXResolution               = 282
+	tYResolution               = 283
+	tPlanarConfiguration       = 284
+	tResolutionUnit            = 296
+	tPredictor                 = 317
+	tColorMap                  = 320
+	tTileWidth                 = 322
+	tTileLength                = 323
+	tTileOffsets               = 324
+	tTileByteCounts            = 325
+	tExtraSamples              = 338
+	tSampleFormat              = 339
+
+	tICCProfile = 34675
+)
+
+// Compression methods.
+const (
+	cNone       = 1
+	cLZW        = 5
+	cDeflate    = 8
+	cPackBits   = 32773
+	cDeflateOld = 32946
+)
+
+// Photometric interpretations.
+const (
+	pWhiteIsZero = 0
+	pBlackIsZero = 1
+	pRGB         = 2
+	pPaletted    = 3
+	pCMYK        = 5
+)
+
+// Values for the tExtraSamples tag.
+const (
+	esUnspecified  = 0
+	esAssocAlpha   = 1
+	esUnassocAlpha = 2
+)
+
+// imageMode describes how samples are arranged into colors.
+type imageMode int
+
+const (
+	mGray imageMode = iota
+	mGrayInvert
+	mGrayAlpha  // unassociated alpha
+	mGrayAlphaP // associated (premultiplied) alpha
+	mPaletted
+	mRGB
+	mRGBA  // associated (premultiplied) alpha
+	mNRGBA // unassociated alpha
+	mCMYK
+)
+
+// maxPixels bounds the size of an image, guarding against allocating
+// unreasonable amounts of memory for a corrupt header.
+const maxPixels = 1 << 28
+
+type decoder struct {
+	r         io.ReaderAt
+	byteOrder binary.ByteOrder
+	config    image.Config
+	mode      imageMode
+	bps       int // bits per sample
+	spp       int // samples per pixel
+	features  map[int][]uint
+	palette   color.Palette
+	icc       []byte
+}
+
+// firstVal returns the first value of the given tag, or 0.
+func (d *decoder) firstVal(tag int) uint {
+	f := d.features[tag]
+	if len(f) == 0 {
+		return 0
+	}
+	return f[0]
+}
+
+// ifdUint decodes the IFD entry in p, which must be of the dtByte,
+// dtShort or dtLong type, and returns the decoded uint values.
+func (d *decoder) ifdUint(p []byte) ([]uint, error) {
+	datatype := d.byteOrder.Uint16(p[2:4])
+	if dt := int(datatype); dt <= 0 || dt >= len(lengths) {
+		return nil, UnsupportedError("IFD entry datatype")
+	}
+	count := d.byteOrder.Uint32(p[4:8])
+	if count > 1<<30/lengths[datatype] {
+		return nil, FormatError("IFD data too large")
+	}
+	datalen := lengths[datatype] * count
+	var raw []byte
+	if datalen > 4 {
+		var err error
+		raw, err = saferio.ReadDataAt(d.r, uint64(datalen), int64(d.byteOrder.Uint32(p[8:12])))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		raw = p[8 : 8+datalen]
+	}
+
+	u := make([]uint, count)
+	switch datatype {
+	case dtByte:
+		for i := range u {
+			u[i] = uint(raw[i])
+		}
+	case dtShort:
+		for i := range u {
+			u[i] = uint(d.byteOrder.Uint16(raw[2*i:]))
+		}
+	case dtLong:
+		for i := range u {
+			u[i] = uint(d.byteOrder.Uint32(raw[4*i:]))
+		}
+	default:
+		return nil, UnsupportedError("data type")
+	}
+	return u, nil
+}
+
+// ifdBytes returns a copy of the data of the IFD entry in p, which must be
+// of the dtByte or dtUndefined type.
+func (d *decoder) ifdBytes(p []byte) ([]byte, error) {
+	if datatype := d.byteOrder.Uint16(p[2:4]); datatype != dtByte && datatype != dtUndefined {
+		return nil, UnsupportedError("IFD entry datatype")
+	}
+	count := d.byteOrder.Uint32(p[4:8])
+	if count > 1<<30 {
+		return nil, FormatError("IFD data too large")
+	}
+	if count <= 4 {
+		// The data is held in p itself.
+		return bytes.Clone(p[8 : 8+count]), nil
+	}
+	return saferio.ReadDataAt(d.r, uint64(count), int64(d.byteOrder.Uint32(p[8:12])))
+}
+
+// parseIFD decides whether the IFD entry in p is "interesting" and
+// stows away the data in the decoder.
+func (d *decoder) parseIFD(p []byte) error {
+	tag := d.byteOrder.Uint16(p[0:2])
+	switch tag {
+	case tBitsPerSample,
+		tExtraSamples,
+		tPhotometricInterpretation,
+		tCompression,
+		tPredictor,
+		tStripOffsets,
+		tStripByteCounts,
+		tRowsPerStrip,
+		tTileWidth,
+		tTileLength,
+		tTileOffsets,
+		tTileByteCounts,
+		tImageLength,
+		tImageWidth,
+		tFillOrder,
+		tPlanarConfiguration,
+		tSamplesPerPixel,
+		tSampleFormat:
+		val, err := d.ifdUint(p)
+		if err != nil {
+			return err
+		}
+		d.features[int(tag)] = val
+	case tColorMap:
+		val, err := d.ifdUint(p)
+		if err != nil {
+			return err
+		}
+		numcolors := len(val) / 3
+		if len(val)%3 != 0 || numcolors <= 0 || numcolors > 256 {
+			return FormatError("bad ColorMap length")
+		}
+		d.palette = make(color.Palette, numcolors)
+		for i := range numcolors {
+			d.palette[i] = color.RGBA64{
+				uint16(val[i]),
+				uint16(val[i+numcolors]),
+				uint16(val[i+2*numcolors]),
+				0xffff,
+			}
+		}
+	case tICCProfile:
+		var err error
+		if d.icc, err = d.ifdBytes(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newDecoder(r io.Reader) (*decoder, error) {
+	d := &decoder{
+		r:        newReaderAt(r),
+		features: make(map[int][]uint),
+	}
+
+	p := make([]byte, 8)
+	if _, err := d.r.ReadAt(p, 0); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch string(p[0:4]) {
+	case leHeader:
+		d.byteOrder = binary.LittleEndian
+	case beHeader:
+		d.byteOrder = binary.BigEndian
+	default:
+		return nil, FormatError("malformed header")
+	}
+
+	ifdOffset := int64(d.byteOrder.Uint32(p[4:8]))
+
+	// The first two bytes contain the number of entries (12 bytes each).
+	if _, err := d.r.ReadAt(p[0:2], ifdOffset); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	numItems := int(d.byteOrder.Uint16(p[0:2]))
+
+	// All IFD entries are read in one chunk.
+	p, err := saferio.ReadDataAt(d.r, uint64(ifdLen*numItems), ifdOffset+2)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	for i := 0; i < len(p); i += ifdLen {
+		if err := d.parseIFD(p[i : i+ifdLen]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+
+	d.config.Width = int(d.firstVal(tImageWidth))
+	d.config.Height = int(d.firstVal(tImageLength))
+	if d.config.Width <= 0 || d.config.Height <= 0 {
+		return nil, FormatError("non-positive dimension")
+	}
+	if d.config.Width > maxPixels/d.config.Height {
+		return nil, UnsupportedError("dimension overflow")
+	}
+
+	if _, ok := d.features[tSamplesPerPixel]; !ok {
+		d.features[tSamplesPerPixel] = []uint{1}
+	}
+	d.spp = int(d.firstVal(tSamplesPerPixel))
+	if d.spp < 1 || d.spp > 8 {
+		return nil, UnsupportedError("samples per pixel " + strconv.Itoa(d.spp))
+	}
+
+	// Determine the image mode.
+	bps := d.features[tBitsPerSample]
+	if len(bps) == 0 {
+		bps = []uint{1}
+	}
+	for _, b := range bps {
+		if b != bps[0] {
+			return nil, UnsupportedError("differing bits per sample")
+		}
+	}
+	d.bps = int(bps[0])
+	switch d.bps {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, UnsupportedError("bits per sample " + strconv.Itoa(d.bps))
+	}
+	if f := d.firstVal(tSampleFormat); f > 1 {
+		return nil, UnsupportedError("sample format " + strconv.FormatUint(uint64(f), 10))
+	}
+	if d.firstVal(tPlanarConfiguration) > 1 {
+		return nil, UnsupportedError("planar configuration")
+	}
+	if d.firstVal(tFillOrder) > 1 {
+		return nil, UnsupportedError("fill order")
+	}
+
+	extra := uint(esUnspecified)
+	if es := d.features[tExtraSamples]; len(es) > 0 {
+		extra = es[0]
+	}
+	need := func(n int) error {
+		if d.spp < n {
+			return FormatError("too few samples per pixel")
+		}
+		return nil
+	}
+
+	switch d.firstVal(tPhotometricInterpretation) {
+	case pWhiteIsZero, pBlackIsZero:
+		invert := d.firstVal(tPhotometricInterpretation) == pWhiteIsZero
+		switch {
+		case d.spp >= 2 && extra != esUnspecified && !invert:
+			if d.bps < 8 {
+				return nil, UnsupportedError("gray with alpha below 8 bits per sample")
+			}
+			d.mode = mGrayAlpha
+			if extra == esAssocAlpha {
+				d.mode = mGrayAlphaP
+			}
+		case invert:
+			d.mode = mGrayInvert
+		default:
+			d.mode = mGray
+		}
+		switch {
+		case d.mode == mGrayAlpha && d.bps == 16:
+			d.config.ColorModel = color.NRGBA64Model
+		case d.mode == mGrayAlpha:
+			d.config.ColorModel = color.NRGBAModel
+		case d.mode == mGrayAlphaP && d.bps == 16:
+			d.config.ColorModel = color.RGBA64Model
+		case d.mode == mGrayAlphaP:
+			d.config.ColorModel = color.RGBAModel
+		case d.bps == 16:
+			d.config.ColorModel = color.Gray16Model
+		default:
+			d.config.ColorModel = color.GrayModel
+		}
+	case pPaletted:
+		if d.palette == nil {
+			return nil, FormatError("missing ColorMap")
+		}
+		if d.bps > 8 {
+			return nil, UnsupportedError("paletted image with more than 8 bits per sample")
+		}
+		d.mode = mPaletted
+		d.config.ColorModel = d.palette
+	case pRGB:
+		if err := need(3); err != nil {
+			return nil, err
+		}
+		if d.bps < 8 {
+			return nil, UnsupportedError("RGB below 8 bits per sample")
+		}
+		switch {
+		case d.spp >= 4 && extra == esAssocAlpha:
+			d.mode = mRGBA
+		case d.spp >= 4 && extra == esUnassocAlpha:
+			d.mode = mNRGBA
+		default:
+			d.mode = mRGB
+		}
+		switch {
+		case d.mode == mNRGBA && d.bps == 16:
+			d.config.ColorModel = color.NRGBA64Model
+		case d.mode == mNRGBA:
+			d.config.ColorModel = color.NRGBAModel
+		case d.bps == 16:
+			d.config.ColorModel = color.RGBA64Model
+		default:
+			d.config.ColorModel = color.RGBAModel
+		}
+	case pCMYK:
+		if err := need(4); err != nil {
+			return nil, err
+		}
+		if d.bps != 8 {
+			return nil, UnsupportedError("CMYK with other than 8 bits per sample")
+		}
+		d.mode = mCMYK
+		d.config.ColorModel = color.CMYKModel
+	default:
+		return nil, UnsupportedError("color model")
+	}
+
+	return d, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a TIFF image
+// without decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	d, err := newDecoder(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return d.config, nil
+}
+
+// DecodeICCProfile returns the ICC profile embedded in a TIFF image, or nil
+// if there is none, without decoding the image.
+func DecodeICCProfile(r io.Reader) ([]byte, error) {
+	d, err := newDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.icc, nil
+}
+
+// Decode reads a TIFF image from r and returns it as an [image.Image].
+// The type of Image returned depends on the contents of the TIFF.
+func Decode(r io.Reader) (img image.Image, err error) {
+	d, err := newDecoder(r)
+	if err != nil {
+		return
+	}
+
+	blockPadding := false
+	blockWidth := d.config.Width
+	blockHeight := d.config.Height
+	blocksAcross := 1
+	blocksDown := 1
+
+	var blockOffsets, blockCounts []uint
+
+	if int(d.firstVal(tTileWidth)) != 0 {
+		blockPadding = true
+
+		blockWidth = int(d.firstVal(tTileWidth))
+		blockHeight = int(d.firstVal(tTileLength))
+
+		if blockWidth <= 0 || blockHeight <= 0 || blockWidth > maxPixels/blockHeight {
+			return nil, FormatError("invalid tile size")
+		}
+		blocksAcross = (d.config.Width + blockWidth - 1) / blockWidth
+		blocksDown = (d.config.Height + blockHeight - 1) / blockHeight
+
+		blockCounts = d.features[tTileByteCounts]
+		blockOffsets = d.features[tTileOffsets]
+	} else {
+		if rps := int(d.firstVal(tRowsPerStrip)); rps > 0 && rps < blockHeight {
+			blockHeight = rps
+		}
+		blocksDown = (d.config.Height + blockHeight - 1) / blockHeight
+
+		blockOffsets = d.features[tStripOffsets]
+		blockCounts = d.features[tStripByteCounts]
+	}
+
+	// Strips span the width of the image, and only the last strip may be
+	// short; tiles are all the same size, padded at the edges of the image.
+	// Check if we have the right number of strips/tiles, offsets and counts.
+	if n := blocksAcross * blocksDown; len(blockOffsets) < n || len(blockCounts) < n {
+		return nil, FormatError("inconsistent header")
+	}
+
+	rect := image.Rect(0, 0, d.config.Width, d.config.Height)
+	switch d.mode {
+	case mGray, mGrayInvert:
+		if d.bps == 16 {
+			img = image.NewGray16(rect)
+		} else {
+			img = image.NewGray(rect)
+		}
+	case mPaletted:
+		img = image.NewPaletted(rect, d.palette)
+	case mNRGBA, mGrayAlpha:
+		if d.bps == 16 {
+			img = image.NewNRGBA64(rect)
+		} else {
+			img = image.NewNRGBA(rect)
+		}
+	case mRGB, mRGBA, mGrayAlphaP:
+		if d.bps == 16 {
+			img = image.NewRGBA64(rect)
+		} else {
+			img = image.NewRGBA(rect)
+		}
+	case mCMYK:
+		img = image.NewCMYK(rect)
+	}
+
+	predictor := d.firstVal(tPredictor)
+	if predictor > 2 {
+		return nil, UnsupportedError("predictor " + strconv.FormatUint(uint64(predictor), 10))
+	}
+	rowBytes := (blockWidth*d.spp*d.bps + 7) / 8
+	for i := range blocksAcross {
+		for j := range blocksDown {
+			blkH := blockHeight
+			if !blockPadding && j == blocksDown-1 && d.config.Height%blockHeight != 0 {
+				blkH = d.config.Height % blockHeight
+			}
+			k := j*blocksAcross + i
+			buf, err := d.readBlock(blockOffsets[k], blockCounts[k], rowBytes*blkH)
+			if err != nil {
+				return nil, err
+			}
+			if predictor == 2 {
+				if err := d.unpredict(buf, rowBytes, blockWidth); err != nil {
+					return nil, err
+				}
+			}
+			xmin := i * blockWidth
+			ymin := j * blockHeight
+			xmax := min(xmin+blockWidth, d.config.Width)
+			ymax := min(ymin+blkH, d.config.Height)
+			d.decodeBlock(img, buf, rowBytes, xmin, ymin, xmax, ymax)
+		}
+	}
+	return img, nil
+}
+
+// readBlock reads and decompresses the strip or tile at offset, which
+// must hold n bytes of pixel data.
+func (d *decoder) readBlock(offset, count uint, n int) ([]byte, error) {
+	data, err := saferio.ReadDataAt(d.r, uint64(count), int64(offset))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	var buf []byte
+	switch c := d.firstVal(tCompression); c {
+	case 0, cNone:
+		buf = data
+	case cLZW:
+		if buf, err = unLZW(data, n); err != nil {
+			return nil, err
+		}
+	case cDeflate, cDeflateOld:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, FormatError(err.Error())
+		}
+		buf, err = io.ReadAll(io.LimitReader(zr, int64(n)))
+		zr.Close()
+		if err != nil {
+			return nil, FormatError(err.Error())
+		}
+	case cPackBits:
+		if buf, err = unpackBits(data, n); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, UnsupportedError("compression value " + strconv.FormatUint(uint64(c), 10))
+	}
+	if len(buf) < n {
+		return nil, FormatError("not enough pixel data")
+	}
+	return buf, nil
+}
+
+// unpredict undoes horizontal differencing in each row of buf.
+func (d *decoder) unpredict(buf []byte, rowBytes, width int) error {
+	for row := 0; row+rowBytes <= len(buf); row += rowBytes {
+		r := buf[row : row+rowBytes]
+		switch d.bps {
+		case 8:
+			for x := d.spp; x < width*d.spp; x++ {
+				r[x] += r[x-d.spp]
+			}
+		case 16:
+			for x := d.spp; x < width*d.spp; x++ {
+				v := d.byteOrder.Uint16(r[2*x:]) + d.byteOrder.Uint16(r[2*(x-d.spp):])
+				d.byteOrder.PutUint16(r[2*x:], v)
+			}
+		default:
+			return UnsupportedError("horizontal predictor with " + strconv.Itoa(d.bps) + " bits per sample")
+		}
+	}
+	return nil
+}
+
+// decodeBlock stores the pixels of a decompressed strip or tile into
+// the rectangle (xmin, ymin)-(xmax, ymax) of dst.
+func (d *decoder) decodeBlock(dst image.Image, buf []byte, rowBytes, xmin, ymin, xmax, ymax int) {
+	// raw returns the i'th sample in row.
+	raw := func(row []byte, i int) uint16 {
+		switch d.bps {
+		case 16:
+			return d.byteOrder.Uint16(row[2*i:])
+		case 8:
+			return uint16(row[i])
+		}
+		return uint16(row[i*d.bps/8]>>uint(8-d.bps-i*d.bps%8)) & (1<<d.bps - 1)
+	}
+	// sample returns the s'th sample of pixel x in row, scaled to 16 bits.
+	sample := func(row []byte, x, s int) uint16 {
+		v := raw(row, x*d.spp+s)
+		switch d.bps {
+		case 16:
+			return v
+		case 8:
+			return v * 0x101
+		}
+		return uint16(uint32(v) * 0xffff / (1<<d.bps - 1))
+	}
+	for y := ymin; y < ymax; y++ {
+		row := buf[(y-ymin)*rowBytes:]
+		for x := xmin; x < xmax; x++ {
+			bx := x - xmin
+			switch d.mode {
+			case mGray, mGrayInvert:
+				v := sample(row, bx, 0)
+				if d.mode == mGrayInvert {
+					v = 0xffff - v
+				}
+				if img, ok := dst.(*image.Gray16); ok {
+					img.SetGray16(x, y, color.Gray16{v})
+				} else {
+					dst.(*image.Gray).SetGray(x, y, color.Gray{uint8(v >> 8)})
+				}
+			case mPaletted:
+				// Out of range indexes are left as zero.
+				img := dst.(*image.Paletted)
+				if idx := raw(row, bx*d.spp); int(idx) < len(img.Palette) {
+					img.SetColorIndex(x, y, uint8(idx))
+				}
+			case mCMYK:
+				dst.(*image.CMYK).SetCMYK(x, y, color.CMYK{
+					row[bx*d.spp], row[bx*d.spp+1], row[bx*d.spp+2], row[bx*d.spp+3],
+				})
+			default:
+				var r, g, b, a uint16
+				switch d.mode {
+				case mGrayAlpha, mGrayAlphaP:
+					r = sample(row, bx, 0)
+					g, b, a = r, r, sample(row, bx, 1)
+				default:
+					r, g, b, a = sample(row, bx, 0), sample(row, bx, 1), sample(row, bx, 2), 0xffff
+					if d.mode != mRGB {
+						a = sample(row, bx, 3)
+					}
+				}
+				switch img := dst.(type) {
+				case *image.RGBA:
+					img.SetRGBA(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+				case *image.NRGBA:
+					img.SetNRGBA(x, y, color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+				case *image.RGBA64:
+					img.SetRGBA64(x, y, color.RGBA64{r, g, b, a})
+				case *image.NRGBA64:
+					img.SetNRGBA64(x, y, color.NRGBA64{r, g, b, a})
+				}
+			}
+		}
+	}
+}
+
+func init() {
+	image.RegisterFormat("tiff", leHeader, Decode, DecodeConfig)
+	image.RegisterFormat("tiff", beHeader, Decode, DecodeConfig)
+}
+
+// newReaderAt converts an io.Reader into an io.ReaderAt, reading the
+// whole input into memory if r does not support random access.
+func newReaderAt(r io.Reader) io.ReaderAt {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return &errReaderAt{err}
+	}
+	return bytes.NewReader(b)
+}
+
+type errReaderAt struct{ err error }
+
+func (r *errReaderAt) ReadAt(p []byte, off int64) (int, error) { return 0, r.err }
@@ -0,0 +1,250 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+)
+
+// tiffFile assembles a TIFF file from IFD entries with SHORT, LONG, BYTE or
+// UNDEFINED values and a list of blocks of pixel data. The tags tStripOffsets or
+// tTileOffsets, when present with no values, are filled in with the
+// offsets of the blocks.
+func tiffFile(order interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}, entries []ifdEntry, blocks ...[]byte) []byte {
+	var b []byte
+	if order == binary.BigEndian {
+		b = []byte(beHeader)
+	} else {
+		b = []byte(leHeader)
+	}
+	b = order.AppendUint32(b, 0) // IFD offset, set below
+	var offsets []uint32
+	for _, blk := range blocks {
+		offsets = append(offsets, uint32(len(b)))
+		b = append(b, blk...)
+	}
+	order.PutUint32(b[4:], uint32(len(b)))
+	b = order.AppendUint16(b, uint16(len(entries)))
+	extraOff := len(b) + len(entries)*ifdLen + 4
+	var extra []byte
+	for _, e := range entries {
+		if (e.tag == tStripOffsets || e.tag == tTileOffsets) && e.data == nil {
+			e.data = offsets
+		}
+		var data []byte
+		for _, v := range e.data {
+			switch e.datatype {
+			case dtShort:
+				data = order.AppendUint16(data, uint16(v))
+			case dtByte, dtUndefined:
+				data = append(data, byte(v))
+			default:
+				data = order.AppendUint32(data, v)
+			}
+		}
+		b = order.AppendUint16(b, uint16(e.tag))
+		b = order.AppendUint16(b, uint16(e.datatype))
+		b = order.AppendUint32(b, uint32(len(e.data)))
+		if len(data) <= 4 {
+			b = append(b, data...)
+			b = append(b, make([]byte, 4-len(data))...)
+		} else {
+			b = order.AppendUint32(b, uint32(extraOff+len(extra)))
+			extra = append(extra, data...)
+		}
+	}
+	b = order.AppendUint32(b, 0)
+	return append(b, extra...)
+}
+
+func short(tag int, v ...uint32) ifdEntry { return ifdEntry{tag, dtShort, v} }
+func long(tag int, v ...uint32) ifdEntry  { return ifdEntry{tag, dtLong, v} }
+
+func TestDecodeBlocks(t *testing.T) {
+	gray := func(v ...uint8) []color.Color {
+		var c []color.Color
+		for _, x := range v {
+			c = append(c, color.Gray{x})
+		}
+		return c
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want [][]color.Color
+	}{{
+		name: "big-endian 16-bit gray in two strips",
+		data: tiffFile(binary.BigEndian, []ifdEntry{
+			short(tImageWidth, 2), short(tImageLength, 3), short(tBitsPerSample, 16),
+			short(tPhotometricInterpretation, pBlackIsZero), long(tStripOffsets),
+			short(tRowsPerStrip, 2), long(tStripByteCounts, 8, 4),
+		}, []byte{0x12, 0x34, 0, 1, 0xff, 0xff, 0x80, 0}, []byte{0, 0, 0xab, 0xcd}),
+		want: [][]color.Color{
+			{color.Gray16{0x1234}, color.Gray16{1}},
+			{color.Gray16{0xffff}, color.Gray16{0x8000}},
+			{color.Gray16{0}, color.Gray16{0xabcd}},
+		},
+	}, {
+		name: "1-bit WhiteIsZero, PackBits",
+		data: tiffFile(binary.LittleEndian, []ifdEntry{
+			short(tImageWidth, 10), short(tImageLength, 2), short(tCompression, cPackBits),
+			short(tPhotometricInterpretation, pWhiteIsZero), long(tStripOffsets), long(tStripByteCounts, 5),
+		}, []byte{0xfd, 0xa5, 0x80, 0x01}),
+		want: [][]color.Color{
+			gray(0, 255, 0, 255, 255, 0, 255, 0, 0, 255),
+			gray(0, 255, 0, 255, 255, 0, 255, 0, 0, 255),
+		},
+	}, {
+		name: "2-bit gray",
+		data: tiffFile(binary.LittleEndian, []ifdEntry{
+			short(tImageWidth, 5), short(tImageLength, 1), short(tBitsPerSample, 2),
+			short(tPhotometricInterpretation, pBlackIsZero), long(tStripOffsets), long(tStripByteCounts, 2),
+		}, []byte{0x1b, 0xc0}),
+		want: [][]color.Color{gray(0, 0x55, 0xaa, 0xff, 0xff)},
+	}, {
+		name: "4-bit paletted",
+		data: tiffFile(binary.LittleEndian, []ifdEntry{
+			short(tImageWidth, 3), short(tImageLength, 1), short(tBitsPerSample, 4),
+			short(tPhotometricInterpretation, pPaletted), long(tStripOffsets), long(tStripByteCounts, 2),
+			short(tColorMap, append(append(make([]uint32, 16), 0, 0xffff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0), make([]uint32, 16)...)...),
+		}, []byte{0x10, 0x10}),
+		want: [][]color.Color{{color.RGBA{0, 0xff, 0, 0xff}, color.RGBA{0, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff}}},
+	}, {
+		name: "RGB tiles with padding",
+		data: tiffFile(binary.LittleEndian, []ifdEntry{
+			short(tImageWidth, 3), short(tImageLength, 1), short(tBitsPerSample, 8, 8, 8),
+			short(tPhotometricInterpretation, pRGB), short(tSamplesPerPixel, 3),
+			short(tTileWidth, 2), short(tTileLength, 1), long(tTileOffsets), long(tTileByteCounts, 6, 6),
+		}, []byte{1, 2, 3, 4, 5, 6}, []byte{7, 8, 9, 0xee, 0xee, 0xee}),
+		want: [][]color.Color{{color.RGBA{1, 2, 3, 0xff}, color.RGBA{4, 5, 6, 0xff}, color.RGBA{7, 8, 9, 0xff}}},
+	}, {
+		name: "gray with unassociated alpha",
+		data: tiffFile(binary.LittleEndian, []ifdEntry{
+			short(tImageWidth, 1), short(tImageLength, 1), short(tBitsPerSample, 8, 8),
+			short(tPhotometricInterpretation, pBlackIsZero), short(tSamplesPerPixel, 2),
+			long(tStripOffsets), long(tStripByteCounts, 2), short(tExtraSamples, esUnassocAlpha),
+		}, []byte{0x40, 0x80}),
+		want: [][]color.Color{{color.NRGBA{0x40, 0x40, 0x40, 0x80}}},
+	}, {
+		name: "RGB with unspecified extra sample",
+		data: tiffFile(binary.LittleEndian, []ifdEntry{
+			short(tImageWidth, 1), short(tImageLength, 1), short(tBitsPerSample, 8, 8, 8, 8),
+			short(tPhotometricInterpretation, pRGB), short(tSamplesPerPixel, 4),
+			long(tStripOffsets), long(tStripByteCounts, 4), short(tExtraSamples, esUnspecified),
+		}, []byte{1, 2, 3, 4}),
+		want: [][]color.Color{{color.RGBA{1, 2, 3, 0xff}}},
+	}}
+	for _, tt := range tests {
+		m, err := Decode(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if b := m.Bounds(); b != image.Rect(0, 0, len(tt.want[0]), len(tt.want)) {
+			t.Errorf("%s: bounds %v", tt.name, b)
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				r0, g0, b0, a0 := m.At(x, y).RGBA()
+				r1, g1, b1, a1 := want.RGBA()
+				if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+					t.Errorf("%s: At(%d, %d) = %v, want %v", tt.name, x, y, m.At(x, y), want)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeICCProfile(t *testing.T) {
+	base := []ifdEntry{short(tImageWidth, 2), short(tImageLength, 1), short(tBitsPerSample, 8),
+		short(tPhotometricInterpretation, pBlackIsZero), long(tStripOffsets), long(tStripByteCounts, 2)}
+	for _, size := range []int{0, 3, 100} {
+		var profile []byte
+		entries := base
+		if size > 0 {
+			profile = make([]byte, size)
+			var v []uint32
+			for i := range profile {
+				profile[i] = byte(i + 1)
+				v = append(v, uint32(i+1))
+			}
+			entries = append(append([]ifdEntry{}, base...), ifdEntry{tICCProfile, dtUndefined, v})
+		}
+		for _, order := range []interface {
+			binary.ByteOrder
+			binary.AppendByteOrder
+		}{binary.LittleEndian, binary.BigEndian} {
+			data := tiffFile(order, entries, []byte{1, 2})
+			got, err := DecodeICCProfile(bytes.NewReader(data))
+			if err != nil || !bytes.Equal(got, profile) {
+				t.Errorf("size %d, %v: got %v, %v, want %v", size, order, got, err, profile)
+			}
+			if _, err := Decode(bytes.NewReader(data)); err != nil {
+				t.Errorf("size %d, %v: Decode: %v", size, order, err)
+			}
+		}
+	}
+}
+
+func TestIFDBytesCopies(t *testing.T) {
+	d := &decoder{byteOrder: binary.LittleEndian}
+	p := []byte{0x73, 0x87, dtUndefined, 0, 3, 0, 0, 0, 1, 2, 3, 0}
+	got, err := d.ifdBytes(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p[8] = 9
+	if want := []byte{1, 2, 3}; !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	base := []ifdEntry{short(tImageWidth, 2), short(tImageLength, 2), short(tBitsPerSample, 8),
+		short(tPhotometricInterpretation, pBlackIsZero), long(tStripOffsets)}
+	with := func(e ...ifdEntry) []ifdEntry { return append(append([]ifdEntry{}, base...), e...) }
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "unexpected EOF"},
+		{"header", []byte("II*\x01\x08\x00\x00\x00"), "malformed header"},
+		{"short data", tiffFile(binary.LittleEndian, with(long(tStripByteCounts, 3)), []byte{1, 2, 3}), "not enough pixel data"},
+		{"missing strips", tiffFile(binary.LittleEndian, base), "inconsistent header"},
+		{"compression", tiffFile(binary.LittleEndian, with(long(tStripByteCounts, 4), short(tCompression, 7)), []byte{1, 2, 3, 4}), "compression value 7"},
+		{"bits", tiffFile(binary.LittleEndian, []ifdEntry{short(tImageWidth, 1), short(tImageLength, 1), short(tBitsPerSample, 12)}), "bits per sample"},
+		{"palette", tiffFile(binary.LittleEndian, []ifdEntry{short(tImageWidth, 1), short(tImageLength, 1), short(tPhotometricInterpretation, pPaletted)}), "missing ColorMap"},
+		{"dimension", tiffFile(binary.LittleEndian, []ifdEntry{long(tImageWidth, 1<<20), long(tImageLength, 1<<20)}), "dimension overflow"},
+		{"bad LZW", tiffFile(binary.LittleEndian, with(long(tStripByteCounts, 2), short(tCompression, cLZW)), []byte{0xff, 0xff}), "invalid LZW code"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Decode error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	var b bytes.Buffer
+	if err := Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2)), &Options{Compression: LZW}); err != nil {
+		t.Fatal(err)
+	}
+	_, name, err := image.Decode(io.MultiReader(&b))
+	if err != nil || name != "tiff" {
+		t.Errorf("image.Decode = %q, %v", name, err)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// CompressionType describes the type of compression used by [Encode].
+type CompressionType int
+
+const (
+	Uncompressed CompressionType = iota
+	Deflate
+	LZW
+)
+
+// specValue returns the compression type constant from the TIFF spec
+// that is equivalent to c.
+func (c CompressionType) specValue() uint32 {
+	switch c {
+	case LZW:
+		return cLZW
+	case Deflate:
+		return cDeflate
+	}
+	return cNone
+}
+
+// Options are the encoding parameters.
+type Options struct {
+	// Compression is the type of compression used.
+	Compression CompressionType
+	// Predictor determines whether a differencing predictor is used;
+	// if true, instead of each pixel's color, the color difference to the
+	// preceding one is saved. This improves the compression for certain
+	// types of images and compressors. For example, it works well for
+	// photos with Deflate compression.
+	Predictor bool
+}
+
+type ifdEntry struct {
+	tag      int
+	datatype int
+	data     []uint32
+}
+
+// putData appends the encoded values of e to b.
+func (e ifdEntry) putData(b []byte) []byte {
+	for _, d := range e.data {
+		switch e.datatype {
+		case dtByte, dtASCII:
+			b = append(b, byte(d))
+		case dtShort:
+			b = binary.LittleEndian.AppendUint16(b, uint16(d))
+		case dtLong, dtRational:
+			b = binary.LittleEndian.AppendUint32(b, d)
+		}
+	}
+	return b
+}
+
+// count returns the number of values in e, as recorded in the IFD.
+func (e ifdEntry) count() uint32 {
+	if e.datatype == dtRational {
+		return uint32(len(e.data) / 2)
+	}
+	return uint32(len(e.data))
+}
+
+// Encode writes the image m to w. opt determines the options used for
+// encoding, such as the compression type. If opt is nil, an uncompressed
+// image is written.
+//
+// Grayscale and paletted images keep their form, and images with 16-bit
+// color components are written with 16 bits per sample. Other opaque
+// images are written as 8-bit RGB, and images with transparency as 8-bit
+// RGB with an alpha channel.
+func Encode(w io.Writer, m image.Image, opt *Options) error {
+	d := m.Bounds().Size()
+	if d.X <= 0 || d.Y <= 0 || d.X > maxPixels/d.Y {
+		return FormatError("invalid image size: " + strconv.Itoa(d.X) + "x" + strconv.Itoa(d.Y))
+	}
+	var o Options
+	if opt != nil {
+		o = *opt
+	}
+	switch o.Compression {
+	case Uncompressed, Deflate, LZW:
+	default:
+		return UnsupportedError("compression type " + strconv.Itoa(int(o.Compression)))
+	}
+
+	photometric := uint32(pRGB)
+	bitsPerSample := []uint32{8, 8, 8}
+	var extraSamples uint32
+	var colorMap []uint32
+
+	var pix []byte
+	switch m := m.(type) {
+	case *image.Paletted:
+		photometric = pPaletted
+		bitsPerSample = []uint32{8}
+		colorMap = make([]uint32, 256*3)
+		for i := 0; i < 256 && i < len(m.Palette); i++ {
+			r, g, b, _ := m.Palette[i].RGBA()
+			colorMap[i+0*256] = r
+			colorMap[i+1*256] = g
+			colorMap[i+2*256] = b
+		}
+		pix = encodeRows(m, 1, func(x, y int, p []byte) { p[0] = m.ColorIndexAt(x, y) })
+	case *image.Gray:
+		photometric = pBlackIsZero
+		bitsPerSample = []uint32{8}
+		pix = encodeRows(m, 1, func(x, y int, p []byte) { p[0] = m.GrayAt(x, y).Y })
+	case *image.Gray16:
+		photometric = pBlackIsZero
+		bitsPerSample = []uint32{16}
+		pix = encodeRows(m, 2, func(x, y int, p []byte) {
+			binary.LittleEndian.PutUint16(p, m.Gray16At(x, y).Y)
+		})
+	case *image.CMYK:
+		photometric = pCMYK
+		bitsPerSample = []uint32{8, 8, 8, 8}
+		pix = encodeRows(m, 4, func(x, y int, p []byte) {
+			c := m.CMYKAt(x, y)
+			p[0], p[1], p[2], p[3] = c.C, c.M, c.Y, c.K
+		})
+	case *image.RGBA64, *image.NRGBA64:
+		if opaque(m) {
+			bitsPerSample = []uint32{16, 16, 16}
+			pix = encodeRows(m, 6, func(x, y int, p []byte) {
+				c := color.RGBA64Model.Convert(m.At(x, y)).(color.RGBA64)
+				binary.LittleEndian.PutUint16(p[0:], c.R)
+				binary.LittleEndian.PutUint16(p[2:], c.G)
+				binary.LittleEndian.PutUint16(p[4:], c.B)
+			})
+			break
+		}
+		bitsPerSample = []uint32{16, 16, 16, 16}
+		if _, ok := m.(*image.RGBA64); ok {
+			extraSamples = esAssocAlpha
+			pix = encodeRows(m, 8, func(x, y int, p []byte) {
+				c := color.RGBA64Model.Convert(m.At(x, y)).(color.RGBA64)
+				put16(p, c.R, c.G, c.B, c.A)
+			})
+		} else {
+			extraSamples = esUnassocAlpha
+			pix = encodeRows(m, 8, func(x, y int, p []byte) {
+				c := color.NRGBA64Model.Convert(m.At(x, y)).(color.NRGBA64)
+				put16(p, c.R, c.G, c.B, c.A)
+			})
+		}
+	default:
+		if opaque(m) {
+			pix = encodeRows(m, 3, func(x, y int, p []byte) {
+				r, g, b, _ := m.At(x, y).RGBA()
+				p[0], p[1], p[2] = uint8(r>>8), uint8(g>>8), uint8(b>>8)
+			})
+			break
+		}
+		bitsPerSample = []uint32{8, 8, 8, 8}
+		if _, ok := m.(*image.RGBA); ok {
+			extraSamples = esAssocAlpha
+			pix = encodeRows(m, 4, func(x, y int, p []byte) {
+				c := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+				p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+			})
+		} else {
+			extraSamples = esUnassocAlpha
+			pix = encodeRows(m, 4, func(x, y int, p []byte) {
+				c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+				p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+			})
+		}
+	}
+
+	samples := len(bitsPerSample)
+	predictor := uint32(1)
+	if o.Predictor {
+		predictor = 2
+		predict(pix, d.X, samples, int(bitsPerSample[0]))
+	}
+
+	switch o.Compression {
+	case Deflate:
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(pix)
+		zw.Close()
+		pix = buf.Bytes()
+	case LZW:
+		pix = lzw(pix)
+	}
+
+	// The pixel data follows the header, and the IFD follows the data.
+	imageLen := len(pix)
+	ifdOffset := 8 + imageLen + imageLen&1
+	if uint64(ifdOffset) > 1<<32-1<<20 {
+		return UnsupportedError("image too large")
+	}
+
+	ifd := []ifdEntry{
+		{tImageWidth, dtShort, []uint32{uint32(d.X)}},
+		{tImageLength, dtShort, []uint32{uint32(d.Y)}},
+		{tBitsPerSample, dtShort, bitsPerSample},
+		{tCompression, dtShort, []uint32{o.Compression.specValue()}},
+		{tPhotometricInterpretation, dtShort, []uint32{photometric}},
+		{tStripOffsets, dtLong, []uint32{8}},
+		{tSamplesPerPixel, dtShort, []uint32{uint32(samples)}},
+		{tRowsPerStrip, dtShort, []uint32{uint32(d.Y)}},
+		{tStripByteCounts, dtLong, []uint32{uint32(imageLen)}},
+		// There is currently no support for storing the image
+		// resolution, so give a bogus value of 72x72 dpi.
+		{tXResolution, dtRational, []uint32{72, 1}},
+		{tYResolution, dtRational, []uint32{72, 1}},
+		{tResolutionUnit, dtShort, []uint32{2}}, // inch
+	}
+	if d.X > 0xffff || d.Y > 0xffff {
+		ifd[0].datatype, ifd[1].datatype, ifd[7].datatype = dtLong, dtLong, dtLong
+	}
+	if predictor > 1 {
+		ifd = append(ifd, ifdEntry{tPredictor, dtShort, []uint32{predictor}})
+	}
+	if colorMap != nil {
+		ifd = append(ifd, ifdEntry{tColorMap, dtShort, colorMap})
+	}
+	if extraSamples > 0 {
+		ifd = append(ifd, ifdEntry{tExtraSamples, dtShort, []uint32{extraSamples}})
+	}
+	slices.SortFunc(ifd, func(a, b ifdEntry) int { return a.tag - b.tag })
+
+	header := make([]byte, 8)
+	copy(header, leHeader)
+	binary.LittleEndian.PutUint32(header[4:], uint32(ifdOffset))
+
+	// Values that do not fit in an entry are stored after the IFD.
+	dirLen := 2 + len(ifd)*ifdLen + 4
+	var dir, extra []byte
+	dir = binary.LittleEndian.AppendUint16(dir, uint16(len(ifd)))
+	for _, e := range ifd {
+		dir = binary.LittleEndian.AppendUint16(dir, uint16(e.tag))
+		dir = binary.LittleEndian.AppendUint16(dir, uint16(e.datatype))
+		dir = binary.LittleEndian.AppendUint32(dir, e.count())
+		data := e.putData(nil)
+		if len(data) <= 4 {
+			dir = append(dir, data...)
+			dir = append(dir, make([]byte, 4-len(data))...)
+		} else {
+			dir = binary.LittleEndian.AppendUint32(dir, uint32(ifdOffset+dirLen+len(extra)))
+			extra = append(extra, data...)
+		}
+	}
+	dir = binary.LittleEndian.AppendUint32(dir, 0) // no next IFD
+
+	for _, b := range [][]byte{header, pix, make([]byte, imageLen&1), dir, extra} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func put16(p []byte, v ...uint16) {
+	for i, x := range v {
+		binary.LittleEndian.PutUint16(p[2*i:], x)
+	}
+}
+
+// encodeRows returns the pixels of m, each encoded by f into n bytes.
+func encodeRows(m image.Image, n int, f func(x, y int, p []byte)) []byte {
+	b := m.Bounds()
+	pix := make([]byte, b.Dx()*b.Dy()*n)
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			f(x, y, pix[i:i+n])
+			i += n
+		}
+	}
+	return pix
+}
+
+// predict applies horizontal differencing to each row of pix, working
+// backwards so each sample is replaced by its difference from the
+// original value of the sample to its left.
+func predict(pix []byte, width, samples, bps int) {
+	rowBytes := width * samples * bps / 8
+	for row := 0; row < len(pix); row += rowBytes {
+		r := pix[row : row+rowBytes]
+		if bps == 16 {
+			for x := width*samples - 1; x >= samples; x-- {
+				v := binary.LittleEndian.Uint16(r[2*x:]) - binary.LittleEndian.Uint16(r[2*(x-samples):])
+				binary.LittleEndian.PutUint16(r[2*x:], v)
+			}
+			continue
+		}
+		for x := len(r) - 1; x >= samples; x-- {
+			r[x] -= r[x-samples]
+		}
+	}
+}
+
+// opaque reports whether m is fully opaque, as for the png encoder.
+func opaque(m image.Image) bool {
+	if o, ok := m.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			_, _, _, a := m.At(x, y).RGBA()
+			if a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tiff
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"testing"
+)
+
+func diff(m0, m1 image.Image) error {
+	b0, b1 := m0.Bounds(), m1.Bounds()
+	if !b0.Size().Eq(b1.Size()) {
+		return fmt.Errorf("dimensions differ: %v vs %v", b0, b1)
+	}
+	dx := b1.Min.X - b0.Min.X
+	dy := b1.Min.Y - b0.Min.Y
+	for y := b0.Min.Y; y < b0.Max.Y; y++ {
+		for x := b0.Min.X; x < b0.Max.X; x++ {
+			c0 := m0.At(x, y)
+			c1 := m1.At(x+dx, y+dy)
+			r0, g0, b0, a0 := c0.RGBA()
+			r1, g1, b1, a1 := c1.RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				return fmt.Errorf("colors differ at (%d, %d): %T%v vs %T%v", x, y, c0, c0, c1, c1)
+			}
+		}
+	}
+	return nil
+}
+
+// testImages returns images of each type the encoder handles specially.
+func testImages() []image.Image {
+	r := image.Rect(2, 3, 53, 40)
+	rgba := image.NewRGBA(r)
+	nrgba := image.NewNRGBA(r)
+	rgba64 := image.NewRGBA64(r)
+	nrgba64 := image.NewNRGBA64(r)
+	gray := image.NewGray(r)
+	gray16 := image.NewGray16(r)
+	cmyk := image.NewCMYK(r)
+	pal := image.NewPaletted(r, palette.Plan9)
+	opaque := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			a := uint8(x * y)
+			rgba.SetRGBA(x, y, color.RGBA{a / 2, a / 3, a / 4, a})
+			nrgba.SetNRGBA(x, y, color.NRGBA{uint8(x * 5), uint8(y * 7), 0x80, a})
+			rgba64.SetRGBA64(x, y, color.RGBA64{uint16(x * y * 10), 0, uint16(x * y), uint16(x*y*10 + 1)})
+			nrgba64.SetNRGBA64(x, y, color.NRGBA64{uint16(x * 999), uint16(y * 777), 3, uint16(x * y * 40)})
+			gray.SetGray(x, y, color.Gray{uint8(x + y*3)})
+			gray16.SetGray16(x, y, color.Gray16{uint16(x * y * 37)})
+			cmyk.SetCMYK(x, y, color.CMYK{uint8(x), uint8(y), uint8(x * y), 9})
+			pal.SetColorIndex(x, y, uint8(x*y+x))
+			opaque.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y * 4), uint8(x ^ y), 0xff})
+		}
+	}
+	return []image.Image{rgba, nrgba, rgba64, nrgba64, gray, gray16, cmyk, pal, opaque, image.NewUniform(color.White)}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, m := range testImages() {
+		for _, opt := range []*Options{
+			nil,
+			{Compression: Deflate},
+			{Compression: LZW},
+			{Compression: Deflate, Predictor: true},
+			{Compression: LZW, Predictor: true},
+			{Predictor: true},
+		} {
+			var b bytes.Buffer
+			err := Encode(&b, m, opt)
+			if _, ok := m.(*image.Uniform); ok {
+				if err == nil {
+					t.Errorf("Encode(%T) succeeded for unbounded image", m)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("Encode(%T, %+v): %v", m, opt, err)
+				continue
+			}
+			got, err := Decode(bytes.NewReader(b.Bytes()))
+			if err != nil {
+				t.Errorf("Decode(Encode(%T, %+v)): %v", m, opt, err)
+				continue
+			}
+			if err := diff(m, got); err != nil {
+				t.Errorf("Decode(Encode(%T, %+v)): %v", m, opt, err)
+			}
+			cfg, err := DecodeConfig(bytes.NewReader(b.Bytes()))
+			if err != nil || cfg.Width != m.Bounds().Dx() || cfg.Height != m.Bounds().Dy() {
+				t.Errorf("DecodeConfig(Encode(%T, %+v)) = %+v, %v", m, opt, cfg, err)
+			}
+		}
+	}
+}
+
+func TestLZW(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("TOBEORNOTTOBEORTOBEORNOT"),
+		bytes.Repeat([]byte{7}, 100000),
+	}
+	// Incompressible data overflows the code table several times.
+	noise := make([]byte, 50000)
+	x := uint32(1)
+	for i := range noise {
+		x = x*1664525 + 1013904223
+		noise[i] = byte(x >> 24)
+	}
+	inputs = append(inputs, noise)
+	for _, in := range inputs {
+		out, err := unLZW(lzw(in), len(in))
+		if err != nil {
+			t.Errorf("unLZW(lzw(%d bytes)): %v", len(in), err)
+			continue
+		}
+		if !bytes.Equal(out, in) {
+			t.Errorf("unLZW(lzw(%d bytes)) returned %d bytes that differ", len(in), len(out))
+		}
+	}
+	// From the TIFF specification's example: codes 256, 7, 258, 8, 8, 258, 6, 257
+	// packed in 9 bits encode "\a\a\a\b\b\a\a\x06".
+	if got := lzw([]byte("\a\a\a\b\b\a\a\x06")); !bytes.Equal(got, []byte{0x80, 0x01, 0xe0, 0x40, 0x80, 0x44, 0x08, 0x0d, 0x01}) {
+		t.Errorf("lzw = % x", got)
+	}
+}
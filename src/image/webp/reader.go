@@ -0,0 +1,517 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webp implements a WebP image decoder and a lossless encoder.
+//
+// The decoder handles lossy images, including those with an alpha
+// channel, lossless images and animations. Lossy images are decoded as
+// [image.YCbCr] values, or [image.NYCbCrA] values if they have an alpha
+// channel, and lossless images as [image.NRGBA] values.
+//
+// WebP is specified in RFC 9649, and its lossy format uses the key frames
+// of the VP8 video format specified in RFC 6386.
+package webp
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strconv"
+	"time"
+)
+
+// A FormatError reports that the input is not a valid WebP image.
+type FormatError string
+
+func (e FormatError) Error() string { return "webp: invalid format: " + string(e) }
+
+// An UnsupportedError reports that the input uses a valid but unimplemented WebP feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string { return "webp: unsupported feature: " + string(e) }
+
+var errTruncated = FormatError("truncated data")
+
+// maxPixels bounds the size of an image or canvas, guarding against
+// allocating unreasonable amounts of memory for a corrupt header. It is
+// the size of the largest VP8L image.
+const maxPixels = 1 << 28
+
+// flagAnimation is the VP8X flag marking an animated image.
+const flagAnimation = 1 << 1
+
+// An Animation holds the frames of a WebP image, as returned by
+// [DecodeAll]. A still image has a single frame covering the canvas.
+type Animation struct {
+	// Frames holds the successive frames. The bounds of each frame's
+	// image give its position on the canvas.
+	Frames []Frame
+
+	// LoopCount is the number of times the animation is played, with 0
+	// meaning indefinitely.
+	LoopCount int
+
+	// Background is the color suggested for the canvas behind the
+	// frames.
+	Background color.NRGBA
+
+	// Config is the canvas's color model and dimensions. The color
+	// model is the one of the image that [Decode] returns.
+	Config image.Config
+}
+
+// A Frame is one frame of an [Animation].
+type Frame struct {
+	Image    image.Image
+	Duration time.Duration
+
+	// Blend reports whether the frame is alpha-blended onto the canvas,
+	// rather than replacing the canvas pixels it covers.
+	Blend bool
+
+	// DisposeToBackground reports whether the area covered by the frame
+	// is cleared to the background color before the next frame is drawn.
+	DisposeToBackground bool
+}
+
+type decoder struct {
+	r         io.Reader
+	remaining int64 // the unread length of the RIFF payload
+	tmp       [16]byte
+
+	alph      []byte // the ALPH chunk of a still image
+	icc       []byte // the ICCP chunk
+	frameSize int64  // the size of the first ANMF chunk
+}
+
+func (d *decoder) readHeader() error {
+	if _, err := io.ReadFull(d.r, d.tmp[:12]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if string(d.tmp[:4]) != "RIFF" || string(d.tmp[8:12]) != "WEBP" {
+		return FormatError("not a WebP file")
+	}
+	d.remaining = int64(binary.LittleEndian.Uint32(d.tmp[4:])) - 4
+	if d.remaining < 0 {
+		return FormatError("invalid RIFF size")
+	}
+	return nil
+}
+
+// nextChunk reads the header of the next chunk. It returns io.EOF at the
+// end of the RIFF payload.
+func (d *decoder) nextChunk() (id string, size int64, err error) {
+	if d.remaining < 8 {
+		return "", 0, io.EOF
+	}
+	if _, err := io.ReadFull(d.r, d.tmp[:8]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", 0, err
+	}
+	size = int64(binary.LittleEndian.Uint32(d.tmp[4:]))
+	d.remaining -= 8
+	if size+size&1 > d.remaining {
+		return "", 0, FormatError("chunk size exceeds RIFF size")
+	}
+	d.remaining -= size + size&1
+	return string(d.tmp[:4]), size, nil
+}
+
+// chunkData reads the data of a chunk of the given size and its padding.
+func (d *decoder) chunkData(size int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(d.r, size+size&1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) < size+size&1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b[:size], nil
+}
+
+func (d *decoder) skipChunk(size int64) error {
+	n, err := io.CopyN(io.Discard, d.r, size+size&1)
+	if err == io.EOF || err == nil && n < size+size&1 {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func uint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+// readConfig reads the chunks describing the image, stopping before the
+// first frame of an animation or the image data of a still image, which
+// it returns.
+func (d *decoder) readConfig(a *Animation) (id string, data []byte, err error) {
+	id, size, err := d.nextChunk()
+	if err != nil {
+		if err == io.EOF {
+			err = errTruncated
+		}
+		return "", nil, err
+	}
+	switch id {
+	case "VP8 ", "VP8L":
+		data, err := d.chunkData(size)
+		if err != nil {
+			return "", nil, err
+		}
+		a.Config, err = imageConfig(id, data, false)
+		return id, data, err
+	case "VP8X":
+	default:
+		return "", nil, FormatError("unexpected chunk " + strconv.Quote(id))
+	}
+
+	if size < 10 {
+		return "", nil, FormatError("short VP8X chunk")
+	}
+	data, err = d.chunkData(size)
+	if err != nil {
+		return "", nil, err
+	}
+	flags := data[0]
+	w, h := uint24(data[4:])+1, uint24(data[7:])+1
+	if w*h > maxPixels {
+		return "", nil, UnsupportedError("canvas too large")
+	}
+	a.Config.Width, a.Config.Height = w, h
+
+	// Skip the chunks preceding the image data, recording the ICC profile
+	// and the animation parameters.
+	for {
+		id, size, err := d.nextChunk()
+		if err != nil {
+			if err == io.EOF {
+				err = errTruncated
+			}
+			return "", nil, err
+		}
+		switch id {
+		case "ICCP":
+			if d.icc, err = d.chunkData(size); err != nil {
+				return "", nil, err
+			}
+			continue
+		case "ANIM":
+			if flags&flagAnimation == 0 {
+				return "", nil, FormatError("ANIM chunk in still image")
+			}
+			if size < 6 {
+				return "", nil, FormatError("short ANIM chunk")
+			}
+			data, err := d.chunkData(size)
+			if err != nil {
+				return "", nil, err
+			}
+			a.Background = color.NRGBA{R: data[2], G: data[1], B: data[0], A: data[3]}
+			a.LoopCount = int(binary.LittleEndian.Uint16(data[4:]))
+			continue
+		case "ANMF":
+			if flags&flagAnimation == 0 {
+				return "", nil, FormatError("ANMF chunk in still image")
+			}
+			a.Config.ColorModel = color.NRGBAModel
+			d.frameSize = size
+			return id, nil, nil
+		case "ALPH", "VP8 ", "VP8L":
+			if flags&flagAnimation != 0 {
+				return "", nil, FormatError("image data in animation")
+			}
+			data, err := d.chunkData(size)
+			if err != nil {
+				return "", nil, err
+			}
+			if id == "ALPH" {
+				d.alph = data
+				continue
+			}
+			c, err := imageConfig(id, data, d.alph != nil)
+			if err != nil {
+				return "", nil, err
+			}
+			if c.Width != w || c.Height != h {
+				return "", nil, FormatError("image size does not match canvas size")
+			}
+			a.Config.ColorModel = c.ColorModel
+			return id, data, nil
+		}
+		if err := d.skipChunk(size); err != nil {
+			return "", nil, err
+		}
+	}
+}
+
+// imageConfig returns the configuration of the image in a VP8 or VP8L
+// chunk.
+func imageConfig(id string, data []byte, alpha bool) (image.Config, error) {
+	if id == "VP8L" {
+		w, h, _, err := decodeVP8LHeader(data)
+		return image.Config{ColorModel: color.NRGBAModel, Width: w, Height: h}, err
+	}
+	w, h, err := decodeVP8Header(data)
+	model := color.YCbCrModel
+	if alpha {
+		model = color.NYCbCrAModel
+	}
+	return image.Config{ColorModel: model, Width: w, Height: h}, err
+}
+
+// decodeImage decodes the image in a VP8 or VP8L chunk, applying the
+// alpha channel in alph, if any.
+func decodeImage(id string, data, alph []byte) (image.Image, error) {
+	if id == "VP8L" {
+		return decodeVP8L(data)
+	}
+	m, err := decodeVP8(data)
+	if err != nil || alph == nil {
+		return m, err
+	}
+	w, h := m.Rect.Dx(), m.Rect.Dy()
+	a, err := decodeAlpha(alph, w, h)
+	if err != nil {
+		return nil, err
+	}
+	return &image.NYCbCrA{YCbCr: *m, A: a, AStride: w}, nil
+}
+
+// Alpha filtering methods.
+const (
+	filterNone = iota
+	filterHorizontal
+	filterVertical
+	filterGradient
+)
+
+// decodeAlpha decodes the contents of an ALPH chunk for an image of the
+// given size.
+func decodeAlpha(b []byte, w, h int) ([]byte, error) {
+	if len(b) < 1 {
+		return nil, errTruncated
+	}
+	filter := int(b[0] >> 2 & 3)
+	var a []byte
+	switch b[0] & 3 {
+	case 0:
+		if len(b)-1 < w*h {
+			return nil, errTruncated
+		}
+		a = b[1 : 1+w*h]
+	case 1:
+		pix, err := decodeVP8LStream(b[1:], w, h)
+		if err != nil {
+			return nil, err
+		}
+		a = make([]byte, w*h)
+		for i, p := range pix {
+			a[i] = uint8(p >> 8)
+		}
+	default:
+		return nil, FormatError("invalid alpha compression method")
+	}
+	if filter == filterNone {
+		return a, nil
+	}
+	out := make([]byte, w*h)
+	for y := range h {
+		for x := range w {
+			i := y*w + x
+			var pred byte
+			switch {
+			case x == 0 && y == 0:
+			case y == 0 || x > 0 && filter == filterHorizontal:
+				pred = out[i-1]
+			case x == 0 || filter == filterVertical:
+				pred = out[i-w]
+			default:
+				pred = uint8(clamp255(int(out[i-1]) + int(out[i-w]) - int(out[i-w-1])))
+			}
+			out[i] = a[i] + pred
+		}
+	}
+	return out, nil
+}
+
+// readFrame reads the ANMF chunk of the given size and decodes its frame.
+func (d *decoder) readFrame(a *Animation, size int64) (Frame, error) {
+	if size < 16 {
+		return Frame{}, FormatError("short ANMF chunk")
+	}
+	data, err := d.chunkData(size)
+	if err != nil {
+		return Frame{}, err
+	}
+	x, y := 2*uint24(data[0:]), 2*uint24(data[3:])
+	w, h := uint24(data[6:])+1, uint24(data[9:])+1
+	f := Frame{
+		Duration:            time.Duration(uint24(data[12:])) * time.Millisecond,
+		Blend:               data[15]&2 == 0,
+		DisposeToBackground: data[15]&1 != 0,
+	}
+	if x+w > a.Config.Width || y+h > a.Config.Height {
+		return Frame{}, FormatError("frame exceeds canvas")
+	}
+
+	var alph []byte
+	for data = data[16:]; ; {
+		if len(data) < 8 {
+			return Frame{}, FormatError("missing frame image data")
+		}
+		id := string(data[:4])
+		n := int64(binary.LittleEndian.Uint32(data[4:]))
+		if n > int64(len(data)-8) {
+			return Frame{}, errTruncated
+		}
+		chunk := data[8 : 8+n]
+		data = data[min(8+n+n&1, int64(len(data))):]
+		switch id {
+		case "ALPH":
+			alph = chunk
+			continue
+		case "VP8 ", "VP8L":
+		default:
+			continue
+		}
+		c, err := imageConfig(id, chunk, false)
+		if err != nil {
+			return Frame{}, err
+		}
+		if c.Width != w || c.Height != h {
+			return Frame{}, FormatError("frame size does not match image size")
+		}
+		m, err := decodeImage(id, chunk, alph)
+		if err != nil {
+			return Frame{}, err
+		}
+		f.Image = translate(m, image.Pt(x, y))
+		return f, nil
+	}
+}
+
+// translate returns m, decoded at the origin, moved to p.
+func translate(m image.Image, p image.Point) image.Image {
+	switch m := m.(type) {
+	case *image.NRGBA:
+		m.Rect = m.Rect.Add(p)
+	case *image.YCbCr:
+		m.Rect = m.Rect.Add(p)
+	case *image.NYCbCrA:
+		m.Rect = m.Rect.Add(p)
+	}
+	return m
+}
+
+// decode reads a WebP image. If all is false, it stops after the first
+// frame.
+func decode(r io.Reader, all bool) (*Animation, error) {
+	d := &decoder{r: r}
+	if err := d.readHeader(); err != nil {
+		return nil, err
+	}
+	a := new(Animation)
+	id, data, err := d.readConfig(a)
+	if err != nil {
+		return nil, err
+	}
+	if id != "ANMF" {
+		m, err := decodeImage(id, data, d.alph)
+		if err != nil {
+			return nil, err
+		}
+		a.Frames = []Frame{{Image: m}}
+		return a, nil
+	}
+
+	size := d.frameSize
+	for {
+		f, err := d.readFrame(a, size)
+		if err != nil {
+			return nil, err
+		}
+		a.Frames = append(a.Frames, f)
+		if !all {
+			return a, nil
+		}
+		for {
+			id, size, err = d.nextChunk()
+			if err == io.EOF {
+				return a, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if id == "ANMF" {
+				break
+			}
+			if err := d.skipChunk(size); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Decode reads a WebP image from r and returns it as an [image.Image].
+// For an animation, it returns the first frame drawn on a transparent
+// canvas.
+func Decode(r io.Reader) (image.Image, error) {
+	a, err := decode(r, false)
+	if err != nil {
+		return nil, err
+	}
+	f := a.Frames[0]
+	if a.Config.ColorModel != color.NRGBAModel || f.Image.Bounds() == image.Rect(0, 0, a.Config.Width, a.Config.Height) {
+		return f.Image, nil
+	}
+	m := image.NewNRGBA(image.Rect(0, 0, a.Config.Width, a.Config.Height))
+	draw.Draw(m, f.Image.Bounds(), f.Image, f.Image.Bounds().Min, draw.Src)
+	return m, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a WebP image
+// without decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	d := &decoder{r: r}
+	if err := d.readHeader(); err != nil {
+		return image.Config{}, err
+	}
+	var a Animation
+	if _, _, err := d.readConfig(&a); err != nil {
+		return image.Config{}, err
+	}
+	return a.Config, nil
+}
+
+// DecodeICCProfile returns the ICC profile embedded in a WebP image, or nil
+// if there is none, without decoding the image.
+func DecodeICCProfile(r io.Reader) ([]byte, error) {
+	d := &decoder{r: r}
+	if err := d.readHeader(); err != nil {
+		return nil, err
+	}
+	var a Animation
+	if _, _, err := d.readConfig(&a); err != nil {
+		return nil, err
+	}
+	return d.icc, nil
+}
+
+// DecodeAll reads a WebP image from r and returns its frames together
+// with the parameters of the animation. For a still image, it returns a
+// single frame.
+func DecodeAll(r io.Reader) (*Animation, error) {
+	return decode(r, true)
+}
+
+func init() {
+	image.RegisterFormat("webp", "RIFF????WEBPVP8", Decode, DecodeConfig)
+}
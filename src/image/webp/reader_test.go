@@ -0,0 +1,321 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readPNG(t *testing.T, filename string) image.Image {
+	t.Helper()
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// The lossy golden files, produced by libwebp, hold the Y plane above the
+// Cb and Cr planes side by side, followed by the alpha plane if any, as
+// a grayscale image.
+func TestDecodeLossy(t *testing.T) {
+	tests := []struct {
+		filename, golden string
+		alpha            bool
+	}{
+		{"video-001.lossy.webp", "video-001.lossy.ycbcr.png", false},
+		{"basn6a08.lossy.webp", "basn6a08.lossy.ycbcra.png", true},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile("testdata/" + tt.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.filename, err)
+			continue
+		}
+		var yc *image.YCbCr
+		var a []byte
+		switch m := m.(type) {
+		case *image.YCbCr:
+			yc = m
+		case *image.NYCbCrA:
+			yc, a = &m.YCbCr, m.A
+		}
+		if yc == nil || (a != nil) != tt.alpha || yc.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+			t.Errorf("%s: decoded a %T", tt.filename, m)
+			continue
+		}
+		w, h := yc.Rect.Dx(), yc.Rect.Dy()
+		cw, ch := (w+1)/2, (h+1)/2
+		g := readPNG(t, "testdata/"+tt.golden).(*image.Gray)
+		check := func(plane string, got []byte, stride, x0, y0, w, h int) {
+			for y := range h {
+				for x := range w {
+					if want := g.GrayAt(x0+x, y0+y).Y; got[y*stride+x] != want {
+						t.Errorf("%s: %s(%d, %d) = %d, want %d", tt.filename, plane, x, y, got[y*stride+x], want)
+						return
+					}
+				}
+			}
+		}
+		check("Y", yc.Y, yc.YStride, 0, 0, w, h)
+		check("Cb", yc.Cb, yc.CStride, 0, h, cw, ch)
+		check("Cr", yc.Cr, yc.CStride, cw, h, cw, ch)
+		if a != nil {
+			check("A", a, w, 0, h+ch, w, h)
+		}
+	}
+}
+
+func TestDecodeLossless(t *testing.T) {
+	tests := []struct {
+		filename, golden string
+	}{
+		{"video-001.lossless.webp", "../testdata/video-001.png"},
+		{"basn6a08.lossless.webp", "../png/testdata/pngsuite/basn6a08.png"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile("testdata/" + tt.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.filename, err)
+			continue
+		}
+		if _, ok := m.(*image.NRGBA); !ok {
+			t.Errorf("%s: decoded a %T", tt.filename, m)
+		}
+		if err := diff(readPNG(t, tt.golden), m); err != nil {
+			t.Errorf("%s: %v", tt.filename, err)
+		}
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     image.Config
+	}{
+		{"video-001.lossy.webp", image.Config{ColorModel: color.YCbCrModel, Width: 150, Height: 103}},
+		{"basn6a08.lossy.webp", image.Config{ColorModel: color.NYCbCrAModel, Width: 32, Height: 32}},
+		{"video-001.lossless.webp", image.Config{ColorModel: color.NRGBAModel, Width: 150, Height: 103}},
+	}
+	for _, tt := range tests {
+		f, err := os.Open("testdata/" + tt.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := DecodeConfig(f)
+		f.Close()
+		if err != nil || c != tt.want {
+			t.Errorf("%s: DecodeConfig = %+v, %v, want %+v", tt.filename, c, err, tt.want)
+		}
+	}
+}
+
+// chunk returns a RIFF chunk, padded to an even length.
+func chunk(id string, data ...[]byte) []byte {
+	d := bytes.Join(data, nil)
+	b := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(d)))
+	b = append(b, d...)
+	if len(d)&1 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func riff(chunks ...[]byte) []byte {
+	d := bytes.Join(chunks, nil)
+	b := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(4+len(d)))
+	return append(append(b, "WEBP"...), d...)
+}
+
+func uint24le(b []byte, v int) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16))
+}
+
+// stripRIFF returns the chunks of a WebP file.
+func stripRIFF(t *testing.T, b []byte) []byte {
+	t.Helper()
+	if len(b) < 12 {
+		t.Fatal("short WebP file")
+	}
+	return b[12:]
+}
+
+func vp8x(flags byte, w, h int) []byte {
+	b := []byte{flags, 0, 0, 0}
+	return chunk("VP8X", uint24le(uint24le(b, w-1), h-1))
+}
+
+func anmf(x, y, w, h int, ms int, flags byte, image []byte) []byte {
+	b := uint24le(uint24le(uint24le(uint24le(uint24le(nil, x/2), y/2), w-1), h-1), ms)
+	return chunk("ANMF", append(b, flags), image)
+}
+
+func TestDecodeAll(t *testing.T) {
+	small := image.NewNRGBA(image.Rect(0, 0, 5, 3))
+	for i := range small.Pix {
+		small.Pix[i] = uint8(i * 7)
+	}
+	var b bytes.Buffer
+	if err := Encode(&b, small); err != nil {
+		t.Fatal(err)
+	}
+	lossless := stripRIFF(t, b.Bytes())
+	lossyData, err := os.ReadFile("testdata/basn6a08.lossy.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Drop the VP8X chunk, keeping the ALPH and VP8 chunks.
+	lossy := stripRIFF(t, lossyData)[8+10:]
+
+	data := riff(
+		vp8x(flagAnimation|1<<4, 40, 36),
+		chunk("ANIM", []byte{0x30, 0x20, 0x10, 0xff, 3, 0}),
+		anmf(4, 6, 5, 3, 100, 0, lossless),
+		chunk("XYZW", []byte{1, 2, 3}),
+		anmf(8, 2, 32, 32, 250, 3, lossy),
+	)
+	a, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantConfig := image.Config{ColorModel: color.NRGBAModel, Width: 40, Height: 36}
+	if a.Config != wantConfig || a.LoopCount != 3 || a.Background != (color.NRGBA{0x10, 0x20, 0x30, 0xff}) {
+		t.Errorf("DecodeAll: Config %+v, LoopCount %d, Background %v", a.Config, a.LoopCount, a.Background)
+	}
+	if len(a.Frames) != 2 {
+		t.Fatalf("DecodeAll: got %d frames, want 2", len(a.Frames))
+	}
+	f0, f1 := a.Frames[0], a.Frames[1]
+	if f0.Image.Bounds() != image.Rect(4, 6, 9, 9) || f0.Duration != 100*time.Millisecond || !f0.Blend || f0.DisposeToBackground {
+		t.Errorf("frame 0: bounds %v, %+v", f0.Image.Bounds(), f0)
+	}
+	if err := diff(small, f0.Image); err != nil {
+		t.Errorf("frame 0: %v", err)
+	}
+	if _, ok := f1.Image.(*image.NYCbCrA); !ok || f1.Image.Bounds() != image.Rect(8, 2, 40, 34) || f1.Duration != 250*time.Millisecond || f1.Blend || !f1.DisposeToBackground {
+		t.Errorf("frame 1: %T with bounds %v, %+v", f1.Image, f1.Image.Bounds(), f1)
+	}
+
+	// Decode draws the first frame on a transparent canvas.
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Bounds() != image.Rect(0, 0, 40, 36) {
+		t.Fatalf("Decode: bounds %v", m.Bounds())
+	}
+	if c := m.At(3, 6); c != (color.NRGBA{}) {
+		t.Errorf("Decode: At(3, 6) = %v, want transparent", c)
+	}
+	if got, want := m.At(6, 7), small.At(2, 1); got != want {
+		t.Errorf("Decode: At(6, 7) = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeICCProfile(t *testing.T) {
+	var b bytes.Buffer
+	if err := Encode(&b, image.NewGray(image.Rect(0, 0, 3, 3))); err != nil {
+		t.Fatal(err)
+	}
+	vp8l := stripRIFF(t, b.Bytes())
+	profile := []byte("not really an ICC profile")
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"simple", b.Bytes(), nil},
+		{"extended", riff(vp8x(1<<5, 3, 3), chunk("ICCP", profile), vp8l), profile},
+		{"no profile", riff(vp8x(0, 3, 3), vp8l), nil},
+	} {
+		got, err := DecodeICCProfile(bytes.NewReader(tt.data))
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+		if _, err := Decode(bytes.NewReader(tt.data)); err != nil {
+			t.Errorf("%s: Decode: %v", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var b bytes.Buffer
+	if err := Encode(&b, image.NewGray(image.Rect(0, 0, 3, 3))); err != nil {
+		t.Fatal(err)
+	}
+	valid := b.Bytes()
+	vp8l := stripRIFF(t, valid)
+	badSignature := bytes.Clone(vp8l)
+	badSignature[8] = 0x2e
+	shortRIFF := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(shortRIFF[4:], 12)
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "unexpected EOF"},
+		{"not RIFF", append([]byte("RIFX"), valid[4:]...), "not a WebP file"},
+		{"no chunks", riff(), "truncated"},
+		{"truncated", valid[:len(valid)-4], "unexpected EOF"},
+		{"chunk size", shortRIFF, "chunk size exceeds RIFF size"},
+		{"unexpected chunk", riff(chunk("ICCP", nil)), "unexpected chunk"},
+		{"VP8L signature", riff(badSignature), "bad VP8L signature"},
+		{"VP8 inter frame", riff(chunk("VP8 ", []byte{1, 0, 0, 0x9d, 0x01, 0x2a, 1, 0, 1, 0})), "VP8 inter frame"},
+		{"VP8 start code", riff(chunk("VP8 ", []byte{0, 0, 0, 0x9d, 0x01, 0x2b, 1, 0, 1, 0})), "bad VP8 start code"},
+		{"canvas size", riff(vp8x(0, 4, 4), vp8l), "does not match canvas size"},
+		{"ANMF in still image", riff(vp8x(0, 3, 3), anmf(0, 0, 3, 3, 0, 0, vp8l)), "ANMF chunk in still image"},
+		{"frame exceeds canvas", riff(vp8x(flagAnimation, 3, 3), anmf(2, 0, 3, 3, 0, 0, vp8l)), "frame exceeds canvas"},
+		{"frame size", riff(vp8x(flagAnimation, 4, 4), anmf(0, 0, 4, 4, 0, 0, vp8l)), "frame size does not match"},
+		{"missing frame data", riff(vp8x(flagAnimation, 3, 3), anmf(0, 0, 3, 3, 0, 0, nil)), "missing frame image data"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Decode error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	var b bytes.Buffer
+	if err := Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	_, name, err := image.Decode(io.MultiReader(&b))
+	if err != nil || name != "webp" {
+		t.Errorf("image.Decode = %q, %v", name, err)
+	}
+	f, err := os.Open("testdata/video-001.lossy.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, name, err := image.DecodeConfig(f); err != nil || name != "webp" {
+		t.Errorf("image.DecodeConfig = %q, %v", name, err)
+	}
+}
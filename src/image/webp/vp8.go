@@ -0,0 +1,1066 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+import (
+	"image"
+	"math/bits"
+)
+
+// This file implements a decoder for the key frames of the VP8 video
+// format, which WebP uses for lossy images, as specified in RFC 6386.
+
+// A boolDecoder reads the boolean entropy coded data of a partition.
+// Reading past the end yields zero bytes.
+type boolDecoder struct {
+	buf   []byte
+	pos   int
+	value uint32
+	rng   uint32
+	bits  int
+}
+
+func (d *boolDecoder) init(b []byte) {
+	*d = boolDecoder{buf: b, rng: 255}
+	d.value = uint32(d.next())<<8 | uint32(d.next())
+}
+
+func (d *boolDecoder) next() byte {
+	if d.pos >= len(d.buf) {
+		d.pos++
+		return 0
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b
+}
+
+// overrun reports whether the decoder has read well past the end of its
+// input, which a valid partition does not need.
+func (d *boolDecoder) overrun() bool {
+	return d.pos > len(d.buf)+2
+}
+
+// readBool reads a boolean whose probability of being false is prob/256.
+func (d *boolDecoder) readBool(prob uint8) bool {
+	split := 1 + (d.rng-1)*uint32(prob)>>8
+	bigSplit := split << 8
+	var b bool
+	if d.value >= bigSplit {
+		b = true
+		d.rng -= split
+		d.value -= bigSplit
+	} else {
+		d.rng = split
+	}
+	if d.rng < 128 {
+		shift := bits.LeadingZeros8(uint8(d.rng))
+		d.rng <<= shift
+		d.value <<= shift
+		d.bits += shift
+		if d.bits >= 8 {
+			d.bits -= 8
+			d.value |= uint32(d.next()) << d.bits
+		}
+	}
+	return b
+}
+
+func (d *boolDecoder) readBit() bool {
+	return d.readBool(128)
+}
+
+// readLiteral reads an n-bit unsigned value, most significant bit first.
+func (d *boolDecoder) readLiteral(n int) int {
+	v := 0
+	for range n {
+		v <<= 1
+		if d.readBit() {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// readSigned reads an n-bit magnitude followed by a sign bit.
+func (d *boolDecoder) readSigned(n int) int {
+	v := d.readLiteral(n)
+	if d.readBit() {
+		return -v
+	}
+	return v
+}
+
+// readOptionalSigned reads a flag and, if it is set, a signed value.
+func (d *boolDecoder) readOptionalSigned(n int) int {
+	if !d.readBit() {
+		return 0
+	}
+	return d.readSigned(n)
+}
+
+// readTree reads a value coded with the given tree and probabilities.
+func (d *boolDecoder) readTree(tree []int8, probs []uint8) int {
+	i := 0
+	for {
+		if d.readBool(probs[i>>1]) {
+			i = int(tree[i+1])
+		} else {
+			i = int(tree[i])
+		}
+		if i <= 0 {
+			return -i
+		}
+	}
+}
+
+const numSegments = 4
+
+// A quantizer holds the DC and AC step sizes for each kind of block.
+type quantizer struct {
+	y1, y2, uv [2]int32
+}
+
+// A filterParams holds the loop filter parameters of a macroblock.
+type filterParams struct {
+	limit  uint8 // the edge limit of subblock edges; 0 disables filtering
+	ilevel uint8 // the interior limit
+	hev    uint8 // the high edge variance threshold
+	inner  bool  // whether subblock edges are filtered
+}
+
+type vp8Decoder struct {
+	width, height int
+	mbw, mbh      int
+
+	first boolDecoder   // the first partition, holding modes
+	parts []boolDecoder // the partitions holding coefficient tokens
+
+	segmentEnabled bool
+	updateMap      bool
+	absoluteDelta  bool
+	segQuant       [numSegments]int
+	segLevel       [numSegments]int
+	segProbs       [3]uint8
+
+	simple      bool
+	level       int
+	sharpness   int
+	lfDelta     bool
+	refLFDelta  int
+	modeLFDelta int
+
+	quant       [numSegments]quantizer
+	probs       coeffProbs
+	skipEnabled bool
+	skipProb    uint8
+
+	// Planes of the reconstructed frame, whose dimensions are rounded up
+	// to whole macroblocks.
+	y, cb, cr        []byte
+	yStride, cStride int
+
+	// Contexts carried from the macroblocks above and to the left: the
+	// subblock modes along the edge, and whether the edge blocks had
+	// non-zero coefficients, for 4 luma, 2+2 chroma and the Y2 block.
+	upModes   []uint8
+	leftModes [4]uint8
+	upNZ      []uint8
+	leftNZ    [9]uint8
+
+	filter []filterParams
+}
+
+// decodeVP8Header returns the dimensions of a VP8 key frame.
+func decodeVP8Header(b []byte) (width, height int, err error) {
+	if len(b) < 10 {
+		return 0, 0, errTruncated
+	}
+	if b[0]&1 != 0 {
+		return 0, 0, UnsupportedError("VP8 inter frame")
+	}
+	if b[3] != 0x9d || b[4] != 0x01 || b[5] != 0x2a {
+		return 0, 0, FormatError("bad VP8 start code")
+	}
+	width = int(b[6]) | int(b[7]&0x3f)<<8
+	height = int(b[8]) | int(b[9]&0x3f)<<8
+	if width == 0 || height == 0 {
+		return 0, 0, FormatError("zero VP8 dimension")
+	}
+	return width, height, nil
+}
+
+// decodeVP8 decodes a VP8 key frame.
+func decodeVP8(b []byte) (*image.YCbCr, error) {
+	w, h, err := decodeVP8Header(b)
+	if err != nil {
+		return nil, err
+	}
+	tag := int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+	if (tag>>1)&7 > 3 {
+		return nil, UnsupportedError("VP8 profile")
+	}
+	firstSize := tag >> 5
+	b = b[10:]
+	if firstSize > len(b) {
+		return nil, errTruncated
+	}
+	d := &vp8Decoder{
+		width:  w,
+		height: h,
+		mbw:    (w + 15) / 16,
+		mbh:    (h + 15) / 16,
+		probs:  defaultCoeffProbs,
+	}
+	d.first.init(b[:firstSize])
+	if err := d.parseHeader(b[firstSize:]); err != nil {
+		return nil, err
+	}
+
+	d.yStride, d.cStride = 16*d.mbw, 8*d.mbw
+	d.y = make([]byte, d.yStride*16*d.mbh)
+	d.cb = make([]byte, d.cStride*8*d.mbh)
+	d.cr = make([]byte, d.cStride*8*d.mbh)
+	d.upModes = make([]uint8, 4*d.mbw)
+	d.upNZ = make([]uint8, 9*d.mbw)
+	d.filter = make([]filterParams, d.mbw*d.mbh)
+	for mby := range d.mbh {
+		d.leftModes = [4]uint8{}
+		d.leftNZ = [9]uint8{}
+		part := &d.parts[mby%len(d.parts)]
+		for mbx := range d.mbw {
+			d.decodeMacroblock(part, mbx, mby)
+		}
+		if d.first.overrun() || part.overrun() {
+			return nil, errTruncated
+		}
+	}
+	d.loopFilter()
+
+	return &image.YCbCr{
+		Y:              d.y,
+		Cb:             d.cb,
+		Cr:             d.cr,
+		YStride:        d.yStride,
+		CStride:        d.cStride,
+		SubsampleRatio: image.YCbCrSubsampleRatio420,
+		Rect:           image.Rect(0, 0, w, h),
+	}, nil
+}
+
+// parseHeader reads the frame header from the first partition and sets
+// up the token partitions that follow it in b.
+func (d *vp8Decoder) parseHeader(b []byte) error {
+	r := &d.first
+	r.readBit() // color space
+	r.readBit() // clamping type
+
+	d.segmentEnabled = r.readBit()
+	if d.segmentEnabled {
+		d.updateMap = r.readBit()
+		updateData := r.readBit()
+		if updateData {
+			d.absoluteDelta = r.readBit()
+			for i := range d.segQuant {
+				d.segQuant[i] = r.readOptionalSigned(7)
+			}
+			for i := range d.segLevel {
+				d.segLevel[i] = r.readOptionalSigned(6)
+			}
+		}
+		if d.updateMap {
+			for i := range d.segProbs {
+				d.segProbs[i] = 255
+				if r.readBit() {
+					d.segProbs[i] = uint8(r.readLiteral(8))
+				}
+			}
+		}
+	}
+
+	d.simple = r.readBit()
+	d.level = r.readLiteral(6)
+	d.sharpness = r.readLiteral(3)
+	d.lfDelta = r.readBit()
+	if d.lfDelta && r.readBit() {
+		// Only the deltas for intra frames and subblock prediction
+		// matter to key frames.
+		var refDeltas, modeDeltas [4]int
+		for i := range refDeltas {
+			refDeltas[i] = r.readOptionalSigned(6)
+		}
+		for i := range modeDeltas {
+			modeDeltas[i] = r.readOptionalSigned(6)
+		}
+		d.refLFDelta, d.modeLFDelta = refDeltas[0], modeDeltas[0]
+	}
+
+	n := 1 << r.readLiteral(2)
+	if len(b) < 3*(n-1) {
+		return errTruncated
+	}
+	sizes, b := b[:3*(n-1)], b[3*(n-1):]
+	d.parts = make([]boolDecoder, n)
+	for i := range d.parts {
+		size := len(b)
+		if i < n-1 {
+			size = int(sizes[3*i]) | int(sizes[3*i+1])<<8 | int(sizes[3*i+2])<<16
+			if size > len(b) {
+				return errTruncated
+			}
+		}
+		d.parts[i].init(b[:size])
+		b = b[size:]
+	}
+
+	base := r.readLiteral(7)
+	yDC := r.readOptionalSigned(4)
+	y2DC := r.readOptionalSigned(4)
+	y2AC := r.readOptionalSigned(4)
+	uvDC := r.readOptionalSigned(4)
+	uvAC := r.readOptionalSigned(4)
+	for s := range d.quant {
+		q := base
+		if d.segmentEnabled {
+			q = d.segQuant[s]
+			if !d.absoluteDelta {
+				q += base
+			}
+		}
+		dq := &d.quant[s]
+		dq.y1 = [2]int32{int32(dcQuant[qindex(q+yDC)]), int32(acQuant[qindex(q)])}
+		dq.y2 = [2]int32{2 * int32(dcQuant[qindex(q+y2DC)]), max(int32(acQuant[qindex(q+y2AC)])*155/100, 8)}
+		dq.uv = [2]int32{min(int32(dcQuant[qindex(q+uvDC)]), 132), int32(acQuant[qindex(q+uvAC)])}
+	}
+
+	r.readBit() // refresh entropy probabilities
+	for i := range d.probs {
+		for j := range d.probs[i] {
+			for k := range d.probs[i][j] {
+				for l := range d.probs[i][j][k] {
+					if r.readBool(coeffUpdateProbs[i][j][k][l]) {
+						d.probs[i][j][k][l] = uint8(r.readLiteral(8))
+					}
+				}
+			}
+		}
+	}
+	d.skipEnabled = r.readBit()
+	if d.skipEnabled {
+		d.skipProb = uint8(r.readLiteral(8))
+	}
+	if r.overrun() {
+		return errTruncated
+	}
+	return nil
+}
+
+func qindex(q int) int {
+	return min(max(q, 0), 127)
+}
+
+// filterFor returns the loop filter parameters of a macroblock in the
+// given segment.
+func (d *vp8Decoder) filterFor(segment int, subblocks bool) filterParams {
+	level := d.level
+	if d.segmentEnabled {
+		level = d.segLevel[segment]
+		if !d.absoluteDelta {
+			level += d.level
+		}
+	}
+	if d.lfDelta {
+		level += d.refLFDelta
+		if subblocks {
+			level += d.modeLFDelta
+		}
+	}
+	level = min(max(level, 0), 63)
+	if level == 0 {
+		return filterParams{}
+	}
+	ilevel := level
+	if d.sharpness > 0 {
+		if d.sharpness > 4 {
+			ilevel >>= 2
+		} else {
+			ilevel >>= 1
+		}
+		ilevel = min(ilevel, 9-d.sharpness)
+	}
+	ilevel = max(ilevel, 1)
+	var hev uint8
+	switch {
+	case level >= 40:
+		hev = 2
+	case level >= 15:
+		hev = 1
+	}
+	return filterParams{limit: uint8(2*level + ilevel), ilevel: uint8(ilevel), hev: hev, inner: subblocks}
+}
+
+// impliedBModes gives the subblock mode that a macroblock predicted as a
+// whole presents as context to its neighbors.
+var impliedBModes = [4]uint8{predDC: bDCPred, predV: bVEPred, predH: bHEPred, predTM: bTMPred}
+
+func (d *vp8Decoder) decodeMacroblock(part *boolDecoder, mbx, mby int) {
+	r := &d.first
+	segment := 0
+	if d.updateMap {
+		if !r.readBool(d.segProbs[0]) {
+			segment = int(b2u(r.readBool(d.segProbs[1])))
+		} else {
+			segment = 2 + int(b2u(r.readBool(d.segProbs[2])))
+		}
+	}
+	skip := d.skipEnabled && r.readBool(d.skipProb)
+
+	var ymode int
+	switch {
+	case !r.readBool(145):
+		ymode = bPred
+	case !r.readBool(156):
+		ymode = predDC
+		if r.readBool(163) {
+			ymode = predV
+		}
+	default:
+		ymode = predH
+		if r.readBool(128) {
+			ymode = predTM
+		}
+	}
+	var bmodes [16]uint8
+	up := d.upModes[4*mbx : 4*mbx+4]
+	if ymode == bPred {
+		for i := range bmodes {
+			x, y := i&3, i>>2
+			m := uint8(r.readTree(bModeTree[:], kfBModeProbs[up[x]][d.leftModes[y]][:]))
+			bmodes[i] = m
+			up[x], d.leftModes[y] = m, m
+		}
+	} else {
+		m := impliedBModes[ymode]
+		up[0], up[1], up[2], up[3] = m, m, m, m
+		d.leftModes = [4]uint8{m, m, m, m}
+	}
+	var uvmode int
+	switch {
+	case !r.readBool(142):
+		uvmode = predDC
+	case !r.readBool(114):
+		uvmode = predV
+	case !r.readBool(183):
+		uvmode = predH
+	default:
+		uvmode = predTM
+	}
+
+	// coeffs holds 16 luma, 4 Cb and 4 Cr blocks of 16 coefficients.
+	var coeffs [24 * 16]int32
+	nonZero := false
+	if !skip {
+		nonZero = d.parseResiduals(part, mbx, segment, ymode == bPred, &coeffs)
+	} else {
+		upNZ := d.upNZ[9*mbx : 9*mbx+9]
+		if ymode == bPred {
+			clear(upNZ[:8])
+			clear(d.leftNZ[:8])
+		} else {
+			clear(upNZ)
+			clear(d.leftNZ[:])
+		}
+	}
+
+	d.reconstruct(mbx, mby, ymode, &bmodes, uvmode, &coeffs)
+
+	f := d.filterFor(segment, ymode == bPred)
+	f.inner = f.inner || nonZero
+	d.filter[mby*d.mbw+mbx] = f
+}
+
+func b2u(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// parseResiduals reads the coefficients of a macroblock and reports
+// whether any of its blocks has non-zero coefficients.
+func (d *vp8Decoder) parseResiduals(r *boolDecoder, mbx, segment int, subblocks bool, coeffs *[24 * 16]int32) bool {
+	q := &d.quant[segment]
+	upNZ := d.upNZ[9*mbx : 9*mbx+9]
+	nonZero := false
+	first, typ := 0, typeYWithDC
+	if !subblocks {
+		var dc [16]int32
+		nz := d.readCoeffs(r, typeY2, int(upNZ[8]+d.leftNZ[8]), &q.y2, 0, dc[:])
+		upNZ[8] = b2u(nz > 0)
+		d.leftNZ[8] = upNZ[8]
+		if nz > 0 {
+			var out [16]int32
+			inverseWHT(&dc, &out)
+			for i, v := range out {
+				coeffs[16*i] = v
+				nonZero = nonZero || v != 0
+			}
+		}
+		first, typ = 1, typeYAfterY2
+	}
+	for y := range 4 {
+		for x := range 4 {
+			i := 4*y + x
+			nz := d.readCoeffs(r, typ, int(upNZ[x]+d.leftNZ[y]), &q.y1, first, coeffs[16*i:16*i+16])
+			f := b2u(nz > first)
+			upNZ[x], d.leftNZ[y] = f, f
+			nonZero = nonZero || f != 0
+		}
+	}
+	for c := range 2 {
+		for y := range 2 {
+			for x := range 2 {
+				i := 16 + 4*c + 2*y + x
+				u, l := 4+2*c+x, 4+2*c+y
+				nz := d.readCoeffs(r, typeChroma, int(upNZ[u]+d.leftNZ[l]), &q.uv, 0, coeffs[16*i:16*i+16])
+				f := b2u(nz > 0)
+				upNZ[u], d.leftNZ[l] = f, f
+				nonZero = nonZero || f != 0
+			}
+		}
+	}
+	return nonZero
+}
+
+// readCoeffs reads the tokens of a block starting at position first,
+// storing the dequantized coefficients in out. It returns the position
+// following the last coefficient read.
+func (d *vp8Decoder) readCoeffs(r *boolDecoder, typ, ctx int, dq *[2]int32, first int, out []int32) int {
+	probs := &d.probs[typ]
+	p := &probs[coeffBands[first]][ctx]
+	for n := first; n < 16; n++ {
+		if !r.readBool(p[0]) {
+			return n // end of block
+		}
+		for !r.readBool(p[1]) {
+			// A zero coefficient, which cannot be followed by the end
+			// of block.
+			n++
+			if n == 16 {
+				return 16
+			}
+			p = &probs[coeffBands[n]][0]
+		}
+		var v int32
+		if !r.readBool(p[2]) {
+			v = 1
+			p = &probs[coeffBands[n+1]][1]
+		} else {
+			v = readLargeValue(r, p)
+			p = &probs[coeffBands[n+1]][2]
+		}
+		if r.readBit() {
+			v = -v
+		}
+		out[zigzag[n]] = v * dq[min(n, 1)]
+	}
+	return 16
+}
+
+// readLargeValue reads the magnitude of a token greater than 1.
+func readLargeValue(r *boolDecoder, p *[numProbs]uint8) int32 {
+	if !r.readBool(p[3]) {
+		if !r.readBool(p[4]) {
+			return 2
+		}
+		return 3 + int32(b2u(r.readBool(p[5])))
+	}
+	if !r.readBool(p[6]) {
+		if !r.readBool(p[7]) {
+			return 5 + int32(b2u(r.readBool(159)))
+		}
+		v := 7 + 2*int32(b2u(r.readBool(165)))
+		return v + int32(b2u(r.readBool(145)))
+	}
+	bit1 := b2u(r.readBool(p[8]))
+	bit0 := b2u(r.readBool(p[9+bit1]))
+	cat := 2*bit1 + bit0
+	var v int32
+	for _, prob := range catProbs[cat] {
+		v = 2*v + int32(b2u(r.readBool(prob)))
+	}
+	return v + 3 + 8<<cat
+}
+
+// inverseWHT computes the inverse Walsh-Hadamard transform of the Y2
+// block, giving the DC coefficients of the 16 luma blocks.
+func inverseWHT(in, out *[16]int32) {
+	var tmp [16]int32
+	for i := range 4 {
+		a1 := in[i] + in[12+i]
+		b1 := in[4+i] + in[8+i]
+		c1 := in[4+i] - in[8+i]
+		d1 := in[i] - in[12+i]
+		tmp[i] = a1 + b1
+		tmp[4+i] = c1 + d1
+		tmp[8+i] = a1 - b1
+		tmp[12+i] = d1 - c1
+	}
+	for i := range 4 {
+		a1 := tmp[4*i] + tmp[4*i+3]
+		b1 := tmp[4*i+1] + tmp[4*i+2]
+		c1 := tmp[4*i+1] - tmp[4*i+2]
+		d1 := tmp[4*i] - tmp[4*i+3]
+		out[4*i] = (a1 + b1 + 3) >> 3
+		out[4*i+1] = (c1 + d1 + 3) >> 3
+		out[4*i+2] = (a1 - b1 + 3) >> 3
+		out[4*i+3] = (d1 - c1 + 3) >> 3
+	}
+}
+
+const (
+	cospi8sqrt2minus1 = 20091
+	sinpi8sqrt2       = 35468
+)
+
+// inverseDCTAdd adds the inverse DCT of the block c to the 4x4 pixels
+// of p starting at offset off.
+func inverseDCTAdd(p []byte, off, stride int, c []int32) {
+	nonZero := false
+	for _, v := range c[:16] {
+		if v != 0 {
+			nonZero = true
+			break
+		}
+	}
+	if !nonZero {
+		return
+	}
+	var tmp [16]int32
+	for i := range 4 {
+		a1 := c[i] + c[8+i]
+		b1 := c[i] - c[8+i]
+		c1 := c[4+i]*sinpi8sqrt2>>16 - (c[12+i] + c[12+i]*cospi8sqrt2minus1>>16)
+		d1 := c[4+i] + c[4+i]*cospi8sqrt2minus1>>16 + c[12+i]*sinpi8sqrt2>>16
+		tmp[i] = a1 + d1
+		tmp[12+i] = a1 - d1
+		tmp[4+i] = b1 + c1
+		tmp[8+i] = b1 - c1
+	}
+	for i := range 4 {
+		t := tmp[4*i : 4*i+4]
+		a1 := t[0] + t[2]
+		b1 := t[0] - t[2]
+		c1 := t[1]*sinpi8sqrt2>>16 - (t[3] + t[3]*cospi8sqrt2minus1>>16)
+		d1 := t[1] + t[1]*cospi8sqrt2minus1>>16 + t[3]*sinpi8sqrt2>>16
+		row := p[off+i*stride : off+i*stride+4]
+		row[0] = clip8(int32(row[0]) + (a1+d1+4)>>3)
+		row[1] = clip8(int32(row[1]) + (b1+c1+4)>>3)
+		row[2] = clip8(int32(row[2]) + (b1-c1+4)>>3)
+		row[3] = clip8(int32(row[3]) + (a1-d1+4)>>3)
+	}
+}
+
+func clip8(v int32) uint8 {
+	return uint8(min(max(v, 0), 255))
+}
+
+// reconstruct predicts the macroblock and adds its residue.
+func (d *vp8Decoder) reconstruct(mbx, mby, ymode int, bmodes *[16]uint8, uvmode int, coeffs *[24 * 16]int32) {
+	x0, y0 := 16*mbx, 16*mby
+	if ymode != bPred {
+		predictBlock(d.y, d.yStride, x0, y0, 16, ymode)
+		for i := range 16 {
+			off := (y0+4*(i>>2))*d.yStride + x0 + 4*(i&3)
+			inverseDCTAdd(d.y, off, d.yStride, coeffs[16*i:])
+		}
+	} else {
+		// The pixels above and to the right of the macroblock serve as
+		// the above-right pixels of every subblock on its right edge.
+		var ar [4]uint8
+		switch {
+		case mby == 0:
+			ar = [4]uint8{127, 127, 127, 127}
+		case mbx == d.mbw-1:
+			v := d.y[(y0-1)*d.yStride+x0+15]
+			ar = [4]uint8{v, v, v, v}
+		default:
+			copy(ar[:], d.y[(y0-1)*d.yStride+x0+16:])
+		}
+		for i := range 16 {
+			x, y := x0+4*(i&3), y0+4*(i>>2)
+			sar := ar
+			if i&3 != 3 {
+				if y == 0 {
+					sar = [4]uint8{127, 127, 127, 127}
+				} else {
+					copy(sar[:], d.y[(y-1)*d.yStride+x+4:])
+				}
+			}
+			predictSubblock(d.y, d.yStride, x, y, bmodes[i], &sar)
+			inverseDCTAdd(d.y, y*d.yStride+x, d.yStride, coeffs[16*i:])
+		}
+	}
+	for c, p := range [2][]byte{d.cb, d.cr} {
+		x0, y0 := 8*mbx, 8*mby
+		predictBlock(p, d.cStride, x0, y0, 8, uvmode)
+		for i := range 4 {
+			off := (y0+4*(i>>1))*d.cStride + x0 + 4*(i&1)
+			inverseDCTAdd(p, off, d.cStride, coeffs[16*(16+4*c+i):])
+		}
+	}
+}
+
+// predictBlock fills the n×n block at (x0, y0) of a plane with the
+// prediction of the given mode. Pixels above the frame are taken to be
+// 127 and pixels to its left 129.
+func predictBlock(p []byte, stride, x0, y0, n, mode int) {
+	var top, left [16]int32
+	tl := int32(127)
+	for i := range n {
+		top[i], left[i] = 127, 129
+		if y0 > 0 {
+			top[i] = int32(p[(y0-1)*stride+x0+i])
+		}
+		if x0 > 0 {
+			left[i] = int32(p[(y0+i)*stride+x0-1])
+		}
+	}
+	if y0 > 0 {
+		tl = 129
+		if x0 > 0 {
+			tl = int32(p[(y0-1)*stride+x0-1])
+		}
+	}
+	switch mode {
+	case predDC:
+		shift := bits.TrailingZeros(uint(n))
+		var sum int32
+		switch {
+		case x0 > 0 && y0 > 0:
+			for i := range n {
+				sum += top[i] + left[i]
+			}
+			sum = (sum + int32(n)) >> (shift + 1)
+		case y0 > 0:
+			for i := range n {
+				sum += top[i]
+			}
+			sum = (sum + int32(n/2)) >> shift
+		case x0 > 0:
+			for i := range n {
+				sum += left[i]
+			}
+			sum = (sum + int32(n/2)) >> shift
+		default:
+			sum = 128
+		}
+		for y := range n {
+			row := p[(y0+y)*stride+x0 : (y0+y)*stride+x0+n]
+			for x := range row {
+				row[x] = uint8(sum)
+			}
+		}
+	case predV:
+		for y := range n {
+			row := p[(y0+y)*stride+x0 : (y0+y)*stride+x0+n]
+			for x := range row {
+				row[x] = uint8(top[x])
+			}
+		}
+	case predH:
+		for y := range n {
+			row := p[(y0+y)*stride+x0 : (y0+y)*stride+x0+n]
+			for x := range row {
+				row[x] = uint8(left[y])
+			}
+		}
+	case predTM:
+		for y := range n {
+			row := p[(y0+y)*stride+x0 : (y0+y)*stride+x0+n]
+			for x := range row {
+				row[x] = clip8(left[y] + top[x] - tl)
+			}
+		}
+	}
+}
+
+func avg2(a, b int32) uint8 {
+	return uint8((a + b + 1) >> 1)
+}
+
+func avg3(a, b, c int32) uint8 {
+	return uint8((a + 2*b + c + 2) >> 2)
+}
+
+// predictSubblock fills the 4x4 subblock at (x0, y0) of the luma plane
+// with the prediction of the given mode, using ar as the four pixels
+// above and to the right.
+func predictSubblock(p []byte, stride, x0, y0 int, mode uint8, ar *[4]uint8) {
+	// e holds the edge: the top-left pixel, the 8 pixels above, and
+	// the 4 pixels to the left.
+	var top [8]int32
+	var left [4]int32
+	x := int32(127)
+	for i := range 4 {
+		top[i], left[i] = 127, 129
+		if y0 > 0 {
+			top[i] = int32(p[(y0-1)*stride+x0+i])
+		}
+		if x0 > 0 {
+			left[i] = int32(p[(y0+i)*stride+x0-1])
+		}
+		top[4+i] = int32(ar[i])
+	}
+	if y0 > 0 {
+		x = 129
+		if x0 > 0 {
+			x = int32(p[(y0-1)*stride+x0-1])
+		}
+	}
+	a, b, c, dd, e, f, g, h := top[0], top[1], top[2], top[3], top[4], top[5], top[6], top[7]
+	i, j, k, l := left[0], left[1], left[2], left[3]
+
+	var dst [4][4]uint8 // dst[y][x]
+	switch mode {
+	case bDCPred:
+		v := uint8((a + b + c + dd + i + j + k + l + 4) >> 3)
+		for y := range dst {
+			dst[y] = [4]uint8{v, v, v, v}
+		}
+	case bTMPred:
+		for y := range dst {
+			for xx := range dst[y] {
+				dst[y][xx] = clip8(left[y] + top[xx] - x)
+			}
+		}
+	case bVEPred:
+		row := [4]uint8{avg3(x, a, b), avg3(a, b, c), avg3(b, c, dd), avg3(c, dd, e)}
+		for y := range dst {
+			dst[y] = row
+		}
+	case bHEPred:
+		rows := [4]uint8{avg3(x, i, j), avg3(i, j, k), avg3(j, k, l), avg3(k, l, l)}
+		for y, v := range rows {
+			dst[y] = [4]uint8{v, v, v, v}
+		}
+	case bLDPred:
+		dst[0][0] = avg3(a, b, c)
+		dst[0][1], dst[1][0] = avg3(b, c, dd), avg3(b, c, dd)
+		v := avg3(c, dd, e)
+		dst[0][2], dst[1][1], dst[2][0] = v, v, v
+		v = avg3(dd, e, f)
+		dst[0][3], dst[1][2], dst[2][1], dst[3][0] = v, v, v, v
+		v = avg3(e, f, g)
+		dst[1][3], dst[2][2], dst[3][1] = v, v, v
+		v = avg3(f, g, h)
+		dst[2][3], dst[3][2] = v, v
+		dst[3][3] = avg3(g, h, h)
+	case bRDPred:
+		edge := [9]int32{l, k, j, i, x, a, b, c, dd}
+		for y := range dst {
+			for xx := range dst[y] {
+				n := 3 - y + xx
+				dst[y][xx] = avg3(edge[n], edge[n+1], edge[n+2])
+			}
+		}
+	case bVRPred:
+		dst[0][0], dst[2][1] = avg2(x, a), avg2(x, a)
+		dst[0][1], dst[2][2] = avg2(a, b), avg2(a, b)
+		dst[0][2], dst[2][3] = avg2(b, c), avg2(b, c)
+		dst[0][3] = avg2(c, dd)
+		dst[3][0] = avg3(k, j, i)
+		dst[2][0] = avg3(j, i, x)
+		dst[1][0], dst[3][1] = avg3(i, x, a), avg3(i, x, a)
+		dst[1][1], dst[3][2] = avg3(x, a, b), avg3(x, a, b)
+		dst[1][2], dst[3][3] = avg3(a, b, c), avg3(a, b, c)
+		dst[1][3] = avg3(b, c, dd)
+	case bVLPred:
+		dst[0][0] = avg2(a, b)
+		dst[0][1], dst[2][0] = avg2(b, c), avg2(b, c)
+		dst[0][2], dst[2][1] = avg2(c, dd), avg2(c, dd)
+		dst[0][3], dst[2][2] = avg2(dd, e), avg2(dd, e)
+		dst[1][0] = avg3(a, b, c)
+		dst[1][1], dst[3][0] = avg3(b, c, dd), avg3(b, c, dd)
+		dst[1][2], dst[3][1] = avg3(c, dd, e), avg3(c, dd, e)
+		dst[1][3], dst[3][2] = avg3(dd, e, f), avg3(dd, e, f)
+		dst[2][3] = avg3(e, f, g)
+		dst[3][3] = avg3(f, g, h)
+	case bHDPred:
+		dst[0][0], dst[1][2] = avg2(i, x), avg2(i, x)
+		dst[1][0], dst[2][2] = avg2(j, i), avg2(j, i)
+		dst[2][0], dst[3][2] = avg2(k, j), avg2(k, j)
+		dst[3][0] = avg2(l, k)
+		dst[0][3] = avg3(a, b, c)
+		dst[0][2] = avg3(x, a, b)
+		dst[0][1], dst[1][3] = avg3(i, x, a), avg3(i, x, a)
+		dst[1][1], dst[2][3] = avg3(j, i, x), avg3(j, i, x)
+		dst[2][1], dst[3][3] = avg3(k, j, i), avg3(k, j, i)
+		dst[3][1] = avg3(l, k, j)
+	case bHUPred:
+		dst[0][0] = avg2(i, j)
+		dst[0][2], dst[1][0] = avg2(j, k), avg2(j, k)
+		dst[1][2], dst[2][0] = avg2(k, l), avg2(k, l)
+		dst[0][1] = avg3(i, j, k)
+		dst[0][3], dst[1][1] = avg3(j, k, l), avg3(j, k, l)
+		dst[1][3], dst[2][1] = avg3(k, l, l), avg3(k, l, l)
+		v := uint8(l)
+		dst[2][2], dst[2][3], dst[3][0], dst[3][1], dst[3][2], dst[3][3] = v, v, v, v, v, v
+	}
+	for y := range dst {
+		copy(p[(y0+y)*stride+x0:], dst[y][:])
+	}
+}
+
+// loopFilter applies the loop filter to the reconstructed frame, one
+// macroblock at a time in raster order.
+func (d *vp8Decoder) loopFilter() {
+	if d.level == 0 {
+		return
+	}
+	for mby := range d.mbh {
+		for mbx := range d.mbw {
+			f := d.filter[mby*d.mbw+mbx]
+			if f.limit == 0 {
+				continue
+			}
+			limit, ilimit, hev := int32(f.limit), int32(f.ilevel), int32(f.hev)
+			yoff := 16*mby*d.yStride + 16*mbx
+			coff := 8*mby*d.cStride + 8*mbx
+			ys, cs := d.yStride, d.cStride
+			if d.simple {
+				if mbx > 0 {
+					simpleFilter(d.y, yoff, 1, ys, 16, limit+4)
+				}
+				if f.inner {
+					for x := 4; x < 16; x += 4 {
+						simpleFilter(d.y, yoff+x, 1, ys, 16, limit)
+					}
+				}
+				if mby > 0 {
+					simpleFilter(d.y, yoff, ys, 1, 16, limit+4)
+				}
+				if f.inner {
+					for y := 4; y < 16; y += 4 {
+						simpleFilter(d.y, yoff+y*ys, ys, 1, 16, limit)
+					}
+				}
+				continue
+			}
+			if mbx > 0 {
+				normalFilter(d.y, yoff, 1, ys, 16, limit+4, ilimit, hev, true)
+				normalFilter(d.cb, coff, 1, cs, 8, limit+4, ilimit, hev, true)
+				normalFilter(d.cr, coff, 1, cs, 8, limit+4, ilimit, hev, true)
+			}
+			if f.inner {
+				for x := 4; x < 16; x += 4 {
+					normalFilter(d.y, yoff+x, 1, ys, 16, limit, ilimit, hev, false)
+				}
+				normalFilter(d.cb, coff+4, 1, cs, 8, limit, ilimit, hev, false)
+				normalFilter(d.cr, coff+4, 1, cs, 8, limit, ilimit, hev, false)
+			}
+			if mby > 0 {
+				normalFilter(d.y, yoff, ys, 1, 16, limit+4, ilimit, hev, true)
+				normalFilter(d.cb, coff, cs, 1, 8, limit+4, ilimit, hev, true)
+				normalFilter(d.cr, coff, cs, 1, 8, limit+4, ilimit, hev, true)
+			}
+			if f.inner {
+				for y := 4; y < 16; y += 4 {
+					normalFilter(d.y, yoff+y*ys, ys, 1, 16, limit, ilimit, hev, false)
+				}
+				normalFilter(d.cb, coff+4*cs, cs, 1, 8, limit, ilimit, hev, false)
+				normalFilter(d.cr, coff+4*cs, cs, 1, 8, limit, ilimit, hev, false)
+			}
+		}
+	}
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// sclamp clamps v to the range of a signed byte.
+func sclamp(v int32) int32 {
+	return min(max(v, -128), 127)
+}
+
+// The filters below operate on n segments of pixels crossing an edge.
+// off is the offset of the first pixel past the edge, step the distance
+// between pixels across the edge, and next the distance between
+// segments.
+
+// needsFilter reports whether the difference across the edge is small
+// enough to be an artifact, for the edge limit.
+func needsFilter(p []byte, i, step int, limit int32) bool {
+	p1, p0 := int32(p[i-2*step]), int32(p[i-step])
+	q0, q1 := int32(p[i]), int32(p[i+step])
+	return 4*abs32(p0-q0)+abs32(p1-q1) <= 2*limit+1
+}
+
+// filter2 adjusts the two pixels nearest the edge.
+func filter2(p []byte, i, step int) {
+	p1, p0 := int32(p[i-2*step]), int32(p[i-step])
+	q0, q1 := int32(p[i]), int32(p[i+step])
+	a := 3*(q0-p0) + sclamp(p1-q1)
+	a1 := min(max((a+4)>>3, -16), 15)
+	a2 := min(max((a+3)>>3, -16), 15)
+	p[i-step] = clip8(p0 + a2)
+	p[i] = clip8(q0 - a1)
+}
+
+func simpleFilter(p []byte, off, step, next, n int, limit int32) {
+	for range n {
+		if needsFilter(p, off, step, limit) {
+			filter2(p, off, step)
+		}
+		off += next
+	}
+}
+
+// normalFilter applies the normal loop filter to a macroblock edge, when
+// mbEdge is set, or to a subblock edge.
+func normalFilter(p []byte, off, step, next, n int, limit, ilimit, hev int32, mbEdge bool) {
+	for range n {
+		i := off
+		off += next
+		p3, p2 := int32(p[i-4*step]), int32(p[i-3*step])
+		p1, p0 := int32(p[i-2*step]), int32(p[i-step])
+		q0, q1 := int32(p[i]), int32(p[i+step])
+		q2, q3 := int32(p[i+2*step]), int32(p[i+3*step])
+		if !needsFilter(p, i, step, limit) ||
+			abs32(p3-p2) > ilimit || abs32(p2-p1) > ilimit || abs32(p1-p0) > ilimit ||
+			abs32(q3-q2) > ilimit || abs32(q2-q1) > ilimit || abs32(q1-q0) > ilimit {
+			continue
+		}
+		if abs32(p1-p0) > hev || abs32(q1-q0) > hev {
+			filter2(p, i, step)
+			continue
+		}
+		if mbEdge {
+			a := sclamp(3*(q0-p0) + sclamp(p1-q1))
+			a1 := (27*a + 63) >> 7
+			a2 := (18*a + 63) >> 7
+			a3 := (9*a + 63) >> 7
+			p[i-3*step] = clip8(p2 + a3)
+			p[i-2*step] = clip8(p1 + a2)
+			p[i-step] = clip8(p0 + a1)
+			p[i] = clip8(q0 - a1)
+			p[i+step] = clip8(q1 - a2)
+			p[i+2*step] = clip8(q2 - a3)
+			continue
+		}
+		a := 3 * (q0 - p0)
+		a1 := min(max((a+4)>>3, -16), 15)
+		a2 := min(max((a+3)>>3, -16), 15)
+		a3 := (a1 + 1) >> 1
+		p[i-2*step] = clip8(p1 + a3)
+		p[i-step] = clip8(p0 + a2)
+		p[i] = clip8(q0 - a1)
+		p[i+step] = clip8(q1 - a3)
+	}
+}
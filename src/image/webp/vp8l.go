@@ -0,0 +1,720 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+import (
+	"image"
+	"strconv"
+)
+
+// This file implements the decoder for the lossless format, VP8L, as
+// specified in RFC 9649.
+
+const vp8lMagic = 0x2f
+
+// Transform types.
+const (
+	tPredictor     = 0
+	tCrossColor    = 1
+	tSubtractGreen = 2
+	tColorIndexing = 3
+)
+
+// Alphabet sizes of the five prefix codes in a group, apart from the
+// color cache symbols that extend the first.
+const (
+	numLiterals    = 256
+	numLengthCodes = 24
+	numDistCodes   = 40
+)
+
+// maxCacheBits is the largest allowed number of color cache bits.
+const maxCacheBits = 11
+
+// A bitReader reads bits least significant bit first. Reading past the
+// end of the input yields zero bits and sets overrun.
+type bitReader struct {
+	buf     []byte
+	pos     int
+	bits    uint64
+	n       uint
+	overrun bool
+}
+
+func (r *bitReader) fill() {
+	for r.n <= 56 {
+		var b byte
+		if r.pos < len(r.buf) {
+			b = r.buf[r.pos]
+		}
+		r.pos++
+		r.bits |= uint64(b) << r.n
+		r.n += 8
+	}
+}
+
+// peek returns the next n bits, for n <= 32, without consuming them.
+func (r *bitReader) peek(n uint) uint32 {
+	if r.n < n {
+		r.fill()
+	}
+	return uint32(r.bits & (1<<n - 1))
+}
+
+func (r *bitReader) skip(n uint) {
+	r.bits >>= n
+	r.n -= n
+	if uint(r.pos)*8-r.n > uint(len(r.buf))*8 {
+		r.overrun = true
+	}
+}
+
+// read returns the next n bits, for n <= 32.
+func (r *bitReader) read(n uint) uint32 {
+	v := r.peek(n)
+	r.skip(n)
+	return v
+}
+
+// huffLookupBits is the number of bits resolved by a single table lookup.
+const huffLookupBits = 8
+
+// A huffman is a canonical prefix code.
+type huffman struct {
+	// single is set for a code with only one symbol, which is coded
+	// with zero bits.
+	single bool
+	sym    uint16
+
+	// table maps the next huffLookupBits bits of input to the symbol
+	// and code length of codes no longer than that, as sym<<4 | length.
+	// Longer codes have a zero entry and are decoded by walking count
+	// and symbols.
+	table   [1 << huffLookupBits]uint16
+	count   [16]uint16
+	symbols []uint16
+}
+
+// init builds the code described by the code lengths, which are at
+// most 15.
+func (h *huffman) init(lengths []uint8) error {
+	*h = huffman{}
+	n := 0
+	for s, l := range lengths {
+		if l != 0 {
+			h.count[l]++
+			h.sym = uint16(s)
+			n++
+		}
+	}
+	switch n {
+	case 0:
+		return FormatError("empty prefix code")
+	case 1:
+		h.single = true
+		return nil
+	}
+	left := 1
+	for l := 1; l < 16; l++ {
+		left = left<<1 - int(h.count[l])
+		if left < 0 {
+			return FormatError("over-subscribed prefix code")
+		}
+	}
+	if left != 0 {
+		return FormatError("incomplete prefix code")
+	}
+
+	var offs [16]int
+	for l := 1; l < 15; l++ {
+		offs[l+1] = offs[l] + int(h.count[l])
+	}
+	h.symbols = make([]uint16, n)
+	for s, l := range lengths {
+		if l != 0 {
+			h.symbols[offs[l]] = uint16(s)
+			offs[l]++
+		}
+	}
+
+	code, i := 0, 0
+	for l := 1; l <= huffLookupBits; l++ {
+		for range h.count[l] {
+			rev := reverseBits(code, l)
+			for j := rev; j < len(h.table); j += 1 << l {
+				h.table[j] = h.symbols[i]<<4 | uint16(l)
+			}
+			code++
+			i++
+		}
+		code <<= 1
+	}
+	return nil
+}
+
+func reverseBits(code, n int) int {
+	r := 0
+	for range n {
+		r = r<<1 | code&1
+		code >>= 1
+	}
+	return r
+}
+
+// decode reads one symbol.
+func (h *huffman) decode(r *bitReader) int {
+	if h.single {
+		return int(h.sym)
+	}
+	if e := h.table[r.peek(huffLookupBits)]; e != 0 {
+		r.skip(uint(e & 15))
+		return int(e >> 4)
+	}
+	code, first, index := 0, 0, 0
+	for l := 1; l < 16; l++ {
+		code |= int(r.read(1))
+		count := int(h.count[l])
+		if code-count < first {
+			return int(h.symbols[index+code-first])
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	// Unreachable for the complete codes accepted by init.
+	return 0
+}
+
+// A huffGroup holds the prefix codes for green (with length prefixes and
+// color cache indexes), red, blue, alpha and distance prefixes.
+type huffGroup [5]huffman
+
+// codeLengthCodeOrder is the order in which the code lengths of the code
+// length code are stored.
+var codeLengthCodeOrder = [19]uint8{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// readCode reads a prefix code for an alphabet of n symbols.
+func readCode(r *bitReader, h *huffman, n int) error {
+	lengths := make([]uint8, n)
+	if r.read(1) == 1 {
+		// A simple code of one or two symbols.
+		two := r.read(1) == 1
+		s := int(r.read(1 + 7*uint(r.read(1))))
+		if s >= n {
+			return FormatError("invalid prefix code symbol")
+		}
+		lengths[s] = 1
+		if two {
+			s := int(r.read(8))
+			if s >= n {
+				return FormatError("invalid prefix code symbol")
+			}
+			lengths[s] = 1
+		}
+		return h.init(lengths)
+	}
+
+	var clLengths [19]uint8
+	numCodes := 4 + int(r.read(4))
+	for i := range numCodes {
+		clLengths[codeLengthCodeOrder[i]] = uint8(r.read(3))
+	}
+	var cl huffman
+	if err := cl.init(clLengths[:]); err != nil {
+		return err
+	}
+
+	maxSymbol := n
+	if r.read(1) == 1 {
+		nbits := 2 + 2*uint(r.read(3))
+		maxSymbol = 2 + int(r.read(nbits))
+		if maxSymbol > n {
+			return FormatError("invalid prefix code length count")
+		}
+	}
+	prev := uint8(8)
+	for s := 0; s < n && maxSymbol > 0; maxSymbol-- {
+		c := cl.decode(r)
+		if c < 16 {
+			lengths[s] = uint8(c)
+			s++
+			if c != 0 {
+				prev = uint8(c)
+			}
+			continue
+		}
+		var repeat int
+		var l uint8
+		switch c {
+		case 16:
+			repeat, l = 3+int(r.read(2)), prev
+		case 17:
+			repeat = 3 + int(r.read(3))
+		default:
+			repeat = 11 + int(r.read(7))
+		}
+		if s+repeat > n {
+			return FormatError("invalid prefix code lengths")
+		}
+		for range repeat {
+			lengths[s] = l
+			s++
+		}
+	}
+	if r.overrun {
+		return errTruncated
+	}
+	return h.init(lengths)
+}
+
+// prefixValue reads the value, at least 1, of a length or distance coded
+// with the given prefix symbol and extra bits.
+func prefixValue(r *bitReader, prefix int) int {
+	if prefix < 4 {
+		return prefix + 1
+	}
+	extra := uint(prefix-2) >> 1
+	offset := (2 + prefix&1) << extra
+	return offset + int(r.read(extra)) + 1
+}
+
+// distanceMap gives the (x, y) offsets of the 120 shortest distance codes.
+var distanceMap = [120][2]int8{
+	{0, 1}, {1, 0}, {1, 1}, {-1, 1}, {0, 2}, {2, 0}, {1, 2}, {-1, 2},
+	{2, 1}, {-2, 1}, {2, 2}, {-2, 2}, {0, 3}, {3, 0}, {1, 3}, {-1, 3},
+	{3, 1}, {-3, 1}, {2, 3}, {-2, 3}, {3, 2}, {-3, 2}, {0, 4}, {4, 0},
+	{1, 4}, {-1, 4}, {4, 1}, {-4, 1}, {3, 3}, {-3, 3}, {2, 4}, {-2, 4},
+	{4, 2}, {-4, 2}, {0, 5}, {3, 4}, {-3, 4}, {4, 3}, {-4, 3}, {5, 0},
+	{1, 5}, {-1, 5}, {5, 1}, {-5, 1}, {2, 5}, {-2, 5}, {5, 2}, {-5, 2},
+	{4, 4}, {-4, 4}, {3, 5}, {-3, 5}, {5, 3}, {-5, 3}, {0, 6}, {6, 0},
+	{1, 6}, {-1, 6}, {6, 1}, {-6, 1}, {2, 6}, {-2, 6}, {6, 2}, {-6, 2},
+	{4, 5}, {-4, 5}, {5, 4}, {-5, 4}, {3, 6}, {-3, 6}, {6, 3}, {-6, 3},
+	{0, 7}, {7, 0}, {1, 7}, {-1, 7}, {5, 5}, {-5, 5}, {7, 1}, {-7, 1},
+	{4, 6}, {-4, 6}, {6, 4}, {-6, 4}, {2, 7}, {-2, 7}, {7, 2}, {-7, 2},
+	{3, 7}, {-3, 7}, {7, 3}, {-7, 3}, {5, 6}, {-5, 6}, {6, 5}, {-6, 5},
+	{8, 0}, {4, 7}, {-4, 7}, {7, 4}, {-7, 4}, {8, 1}, {8, 2}, {6, 6},
+	{-6, 6}, {8, 3}, {5, 7}, {-5, 7}, {7, 5}, {-7, 5}, {8, 4}, {6, 7},
+	{-6, 7}, {7, 6}, {-7, 6}, {8, 5}, {7, 7}, {-7, 7}, {8, 6}, {8, 7},
+}
+
+// A vp8lTransform is a transform read from the bitstream, with the width
+// of the image it applies to.
+type vp8lTransform struct {
+	kind  int
+	bits  uint
+	xsize int
+	data  []uint32
+}
+
+type vp8lDecoder struct {
+	r          bitReader
+	transforms []vp8lTransform
+}
+
+// subSampleSize returns the size of a dimension of an image whose
+// pixels each cover 1<<bits pixels of an image of the given size.
+func subSampleSize(size int, bits uint) int {
+	return (size + 1<<bits - 1) >> bits
+}
+
+// decodeVP8LHeader returns the dimensions of a VP8L image and whether
+// it uses alpha.
+func decodeVP8LHeader(b []byte) (width, height int, alpha bool, err error) {
+	if len(b) < 5 {
+		return 0, 0, false, errTruncated
+	}
+	if b[0] != vp8lMagic {
+		return 0, 0, false, FormatError("bad VP8L signature")
+	}
+	v := uint32(b[1]) | uint32(b[2])<<8 | uint32(b[3])<<16 | uint32(b[4])<<24
+	if v>>29 != 0 {
+		return 0, 0, false, UnsupportedError("VP8L version " + strconv.Itoa(int(v>>29)))
+	}
+	return int(v&0x3fff) + 1, int(v>>14&0x3fff) + 1, v>>28&1 != 0, nil
+}
+
+// decodeVP8L decodes a VP8L image.
+func decodeVP8L(b []byte) (*image.NRGBA, error) {
+	w, h, _, err := decodeVP8LHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	pix, err := decodeVP8LStream(b[5:], w, h)
+	if err != nil {
+		return nil, err
+	}
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i, p := range pix {
+		m.Pix[4*i+0] = uint8(p >> 16)
+		m.Pix[4*i+1] = uint8(p >> 8)
+		m.Pix[4*i+2] = uint8(p)
+		m.Pix[4*i+3] = uint8(p >> 24)
+	}
+	return m, nil
+}
+
+// decodeVP8LStream decodes the image stream following a VP8L header, or
+// the headerless stream of a losslessly compressed alpha channel, and
+// returns its pixels as ARGB values.
+func decodeVP8LStream(b []byte, w, h int) ([]uint32, error) {
+	d := &vp8lDecoder{r: bitReader{buf: b}}
+	xsize := w
+	var seen [4]bool
+	for d.r.read(1) == 1 {
+		kind := int(d.r.read(2))
+		if seen[kind] {
+			return nil, FormatError("repeated transform")
+		}
+		seen[kind] = true
+		t := vp8lTransform{kind: kind, xsize: xsize}
+		var err error
+		switch kind {
+		case tPredictor, tCrossColor:
+			t.bits = uint(d.r.read(3)) + 2
+			t.data, err = d.decodeImage(subSampleSize(xsize, t.bits), subSampleSize(h, t.bits), false)
+		case tColorIndexing:
+			n := int(d.r.read(8)) + 1
+			switch {
+			case n > 16:
+				t.bits = 0
+			case n > 4:
+				t.bits = 1
+			case n > 2:
+				t.bits = 2
+			default:
+				t.bits = 3
+			}
+			var colors []uint32
+			colors, err = d.decodeImage(n, 1, false)
+			t.data = make([]uint32, 256)
+			for i := range colors {
+				if i > 0 {
+					colors[i] = addPixels(colors[i], colors[i-1])
+				}
+				t.data[i] = colors[i]
+			}
+			xsize = subSampleSize(xsize, t.bits)
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.transforms = append(d.transforms, t)
+	}
+	pix, err := d.decodeImage(xsize, h, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(d.transforms) - 1; i >= 0; i-- {
+		pix = d.transforms[i].inverse(pix, h)
+	}
+	return pix, nil
+}
+
+// decodeImage decodes an entropy-coded image, or for the main image of
+// the stream, a spatially-coded image that may use meta prefix codes.
+func (d *vp8lDecoder) decodeImage(w, h int, main bool) ([]uint32, error) {
+	if w*h > maxPixels {
+		return nil, UnsupportedError("image too large")
+	}
+	r := &d.r
+	var cacheBits uint
+	if r.read(1) == 1 {
+		cacheBits = uint(r.read(4))
+		if cacheBits < 1 || cacheBits > maxCacheBits {
+			return nil, FormatError("invalid color cache size")
+		}
+	}
+
+	// The meta prefix codes, if any, map each block of the image to a
+	// group of prefix codes. Groups that no block uses are read but not
+	// kept, and the others are renumbered densely.
+	var meta []uint32
+	var metaBits uint
+	numGroups, numUsed := 1, 1
+	used := []int{0}
+	if main && r.read(1) == 1 {
+		metaBits = uint(r.read(3)) + 2
+		var err error
+		meta, err = d.decodeImage(subSampleSize(w, metaBits), subSampleSize(h, metaBits), false)
+		if err != nil {
+			return nil, err
+		}
+		index := make(map[uint32]int)
+		for _, p := range meta {
+			numGroups = max(numGroups, int(p>>8&0xffff)+1)
+		}
+		used = make([]int, numGroups)
+		for i := range used {
+			used[i] = -1
+		}
+		for i, p := range meta {
+			g := p >> 8 & 0xffff
+			if _, ok := index[g]; !ok {
+				index[g] = len(index)
+				used[g] = index[g]
+			}
+			meta[i] = uint32(index[g])
+		}
+		numUsed = len(index)
+	}
+	groups := make([]huffGroup, numUsed)
+	var unused huffGroup
+	cacheSize := 0
+	if cacheBits > 0 {
+		cacheSize = 1 << cacheBits
+	}
+	for _, k := range used {
+		g := &unused
+		if k >= 0 {
+			g = &groups[k]
+		}
+		for j := range g {
+			n := numLiterals
+			switch j {
+			case 0:
+				n = numLiterals + numLengthCodes + cacheSize
+			case 4:
+				n = numDistCodes
+			}
+			if err := readCode(r, &g[j], n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var cache []uint32
+	if cacheBits > 0 {
+		cache = make([]uint32, cacheSize)
+	}
+	mw := subSampleSize(w, metaBits)
+	pix := make([]uint32, w*h)
+	g := &groups[0]
+	for pos := 0; pos < len(pix); {
+		if r.overrun {
+			return nil, errTruncated
+		}
+		if meta != nil {
+			x, y := pos%w, pos/w
+			g = &groups[meta[(y>>metaBits)*mw+x>>metaBits]]
+		}
+		s := g[0].decode(r)
+		switch {
+		case s < numLiterals:
+			red := g[1].decode(r)
+			blue := g[2].decode(r)
+			alpha := g[3].decode(r)
+			pix[pos] = uint32(alpha)<<24 | uint32(red)<<16 | uint32(s)<<8 | uint32(blue)
+			if cache != nil {
+				cache[colorHash(pix[pos], cacheBits)] = pix[pos]
+			}
+			pos++
+		case s < numLiterals+numLengthCodes:
+			length := prefixValue(r, s-numLiterals)
+			dist := prefixValue(r, g[4].decode(r))
+			if dist <= len(distanceMap) {
+				o := distanceMap[dist-1]
+				dist = max(int(o[0])+int(o[1])*w, 1)
+			} else {
+				dist -= len(distanceMap)
+			}
+			if dist > pos || length > len(pix)-pos {
+				return nil, FormatError("invalid backward reference")
+			}
+			for i := range length {
+				p := pix[pos+i-dist]
+				pix[pos+i] = p
+				if cache != nil {
+					cache[colorHash(p, cacheBits)] = p
+				}
+			}
+			pos += length
+		default:
+			i := s - numLiterals - numLengthCodes
+			if i >= len(cache) {
+				return nil, FormatError("invalid color cache index")
+			}
+			pix[pos] = cache[i]
+			pos++
+		}
+	}
+	if r.overrun {
+		return nil, errTruncated
+	}
+	return pix, nil
+}
+
+func colorHash(p uint32, bits uint) uint32 {
+	return (0x1e35a7bd * p) >> (32 - bits)
+}
+
+// addPixels adds the components of a and b modulo 256.
+func addPixels(a, b uint32) uint32 {
+	ag := (a & 0xff00ff00) + (b & 0xff00ff00)
+	rb := (a & 0x00ff00ff) + (b & 0x00ff00ff)
+	return ag&0xff00ff00 | rb&0x00ff00ff
+}
+
+// subPixels subtracts the components of b from a modulo 256.
+func subPixels(a, b uint32) uint32 {
+	ag := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	rb := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return ag&0xff00ff00 | rb&0x00ff00ff
+}
+
+// inverse undoes the transform t on the pixels of an image of height h.
+func (t *vp8lTransform) inverse(pix []uint32, h int) []uint32 {
+	w := t.xsize
+	switch t.kind {
+	case tPredictor:
+		tw := subSampleSize(w, t.bits)
+		for y := range h {
+			row := y * w
+			for x := range w {
+				i := row + x
+				var pred uint32
+				switch {
+				case y == 0 && x == 0:
+					pred = 0xff000000
+				case y == 0:
+					pred = pix[i-1]
+				case x == 0:
+					pred = pix[i-w]
+				default:
+					mode := t.data[(y>>t.bits)*tw+x>>t.bits] >> 8 & 15
+					pred = predict(mode, pix[i-1], pix[i-w], pix[i-w+1], pix[i-w-1])
+				}
+				pix[i] = addPixels(pix[i], pred)
+			}
+		}
+	case tCrossColor:
+		tw := subSampleSize(w, t.bits)
+		for y := range h {
+			for x := range w {
+				m := t.data[(y>>t.bits)*tw+x>>t.bits]
+				i := y*w + x
+				pix[i] = inverseCrossColor(pix[i], int8(m), int8(m>>8), int8(m>>16))
+			}
+		}
+	case tSubtractGreen:
+		for i, p := range pix {
+			g := p >> 8 & 0xff
+			pix[i] = addPixels(p, g<<16|g)
+		}
+	case tColorIndexing:
+		if t.bits == 0 {
+			for i, p := range pix {
+				pix[i] = t.data[p>>8&0xff]
+			}
+			break
+		}
+		out := make([]uint32, w*h)
+		pw := subSampleSize(w, t.bits)
+		bpp := uint(8) >> t.bits
+		mask := uint32(1)<<bpp - 1
+		for y := range h {
+			for x := range w {
+				p := pix[y*pw+x>>t.bits] >> 8
+				shift := uint(x&(1<<t.bits-1)) * bpp
+				out[y*w+x] = t.data[p>>shift&mask]
+			}
+		}
+		pix = out
+	}
+	return pix
+}
+
+// inverseCrossColor undoes the color transform with the given
+// multipliers.
+func inverseCrossColor(p uint32, greenToRed, greenToBlue, redToBlue int8) uint32 {
+	g := int8(p >> 8)
+	r := int(p>>16&0xff) + colorDelta(greenToRed, g)
+	b := int(p&0xff) + colorDelta(greenToBlue, g) + colorDelta(redToBlue, int8(r))
+	return p&0xff00ff00 | uint32(r&0xff)<<16 | uint32(b&0xff)
+}
+
+func colorDelta(t, c int8) int {
+	return int(t) * int(c) >> 5
+}
+
+func average2(a, b uint32) uint32 {
+	return ((a^b)&0xfefefefe)>>1 + a&b
+}
+
+// predict returns the prediction of the given mode from the left, top,
+// top-right and top-left pixels.
+func predict(mode, l, t, tr, tl uint32) uint32 {
+	switch mode {
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return average2(average2(l, tr), t)
+	case 6:
+		return average2(l, tl)
+	case 7:
+		return average2(l, t)
+	case 8:
+		return average2(tl, t)
+	case 9:
+		return average2(t, tr)
+	case 10:
+		return average2(average2(l, tl), average2(t, tr))
+	case 11:
+		return selectPixel(l, t, tl)
+	case 12:
+		return clampAddSubtractFull(l, t, tl)
+	case 13:
+		return clampAddSubtractHalf(average2(l, t), tl)
+	}
+	return 0xff000000
+}
+
+func absDiff(a, b int) int {
+	if a < b {
+		return b - a
+	}
+	return a - b
+}
+
+func selectPixel(l, t, tl uint32) uint32 {
+	pl, pt := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		cl, ct, ctl := int(l>>shift&0xff), int(t>>shift&0xff), int(tl>>shift&0xff)
+		p := cl + ct - ctl
+		pl += absDiff(p, cl)
+		pt += absDiff(p, ct)
+	}
+	if pl < pt {
+		return l
+	}
+	return t
+}
+
+func clamp255(v int) uint32 {
+	return uint32(min(max(v, 0), 255))
+}
+
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+	var p uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(a>>shift&0xff) + int(b>>shift&0xff) - int(c>>shift&0xff)
+		p |= clamp255(v) << shift
+	}
+	return p
+}
+
+func clampAddSubtractHalf(a, b uint32) uint32 {
+	var p uint32
+	for shift := 0; shift < 32; shift += 8 {
+		ca, cb := int(a>>shift&0xff), int(b>>shift&0xff)
+		p |= clamp255(ca+(ca-cb)/2) << shift
+	}
+	return p
+}
@@ -0,0 +1,670 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"slices"
+	"strconv"
+)
+
+// This file implements a lossless VP8L encoder. Images with at most 256
+// colors are palette coded; others use the subtract green and predictor
+// transforms. The transformed pixels are compressed with LZ77 backward
+// references and a single group of prefix codes.
+
+// maxDimension is the largest width or height of a VP8L image.
+const maxDimension = 1 << 14
+
+// predictorBits is the log2 of the block size of the predictor transform.
+const predictorBits = 4
+
+// LZ77 parameters. maxWindow is the largest distance that the distance
+// codes can express, and maxLength the longest copy.
+const (
+	minLength = 3
+	maxLength = 4096
+	maxWindow = 1<<20 - 120
+	maxChain  = 64
+	hashBits  = 16
+)
+
+// maxCodeLength is the longest prefix code, and maxCodeLengthCodeLength
+// the longest code in the code length code.
+const (
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+)
+
+// A bitWriter writes bits least significant bit first.
+type bitWriter struct {
+	buf  []byte
+	bits uint64
+	n    uint
+}
+
+// write writes the low n bits of v, with n at most 32.
+func (w *bitWriter) write(v uint32, n uint) {
+	w.bits |= uint64(v) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits >>= 8
+		w.n -= 8
+	}
+}
+
+// flush writes any pending bits, padded with zeros to a byte boundary.
+func (w *bitWriter) flush() {
+	if w.n > 0 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits, w.n = 0, 0
+	}
+}
+
+// A prefixCode holds the code lengths of a canonical prefix code and the
+// bit-reversed codes to write for each symbol.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+	bits    []uint8 // the number of bits written for each symbol
+	used    int     // the number of symbols with a nonzero length
+}
+
+// newPrefixCode builds a prefix code, no longer than limit bits, for
+// symbols with the given frequencies.
+func newPrefixCode(freq []int, limit int) *prefixCode {
+	c := &prefixCode{
+		lengths: codeLengths(freq, limit),
+		codes:   make([]uint16, len(freq)),
+		bits:    make([]uint8, len(freq)),
+	}
+	var count [maxCodeLength + 1]int
+	for _, l := range c.lengths {
+		if l != 0 {
+			count[l]++
+			c.used++
+		}
+	}
+	if c.used <= 1 {
+		// A code with a single symbol is written with zero bits.
+		return c
+	}
+	var next [maxCodeLength + 1]int
+	code := 0
+	for l := 1; l <= maxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	for s, l := range c.lengths {
+		if l != 0 {
+			c.codes[s] = uint16(reverseBits(next[l], int(l)))
+			c.bits[s] = l
+			next[l]++
+		}
+	}
+	return c
+}
+
+func (c *prefixCode) write(w *bitWriter, sym int) {
+	w.write(uint32(c.codes[sym]), uint(c.bits[sym]))
+}
+
+// codeLengths returns the code lengths of a Huffman code, no longer than
+// limit bits, for symbols with the given frequencies. A single symbol
+// gets length 1. When the optimal code is too long, the frequencies are
+// flattened by raising small ones until it fits.
+func codeLengths(freq []int, limit int) []uint8 {
+	lengths := make([]uint8, len(freq))
+	var syms []int
+	for s, f := range freq {
+		if f > 0 {
+			syms = append(syms, s)
+		}
+	}
+	switch len(syms) {
+	case 0:
+		return lengths
+	case 1:
+		lengths[syms[0]] = 1
+		return lengths
+	}
+
+	type node struct {
+		freq        int
+		left, right int // children, or -1 for a leaf
+		sym         int
+	}
+	nodes := make([]node, 0, 2*len(syms))
+	depth := make([]int, 2*len(syms))
+	for minFreq := 1; ; minFreq *= 2 {
+		nodes = nodes[:0]
+		for _, s := range syms {
+			nodes = append(nodes, node{max(freq[s], minFreq), -1, -1, s})
+		}
+		slices.SortStableFunc(nodes, func(a, b node) int { return a.freq - b.freq })
+
+		// Merge the two lightest nodes until one remains, taking them
+		// from the sorted leaves or the internal nodes, which are
+		// created in order of increasing weight.
+		leaf, internal := 0, len(syms)
+		pop := func() int {
+			if leaf < len(syms) && (internal == len(nodes) || nodes[leaf].freq <= nodes[internal].freq) {
+				leaf++
+				return leaf - 1
+			}
+			internal++
+			return internal - 1
+		}
+		for range len(syms) - 1 {
+			a, b := pop(), pop()
+			nodes = append(nodes, node{nodes[a].freq + nodes[b].freq, a, b, -1})
+		}
+
+		// Children follow their parents in reverse order of creation.
+		maxDepth := 0
+		depth[len(nodes)-1] = 0
+		for i := len(nodes) - 1; i >= 0; i-- {
+			n := nodes[i]
+			if n.left < 0 {
+				lengths[n.sym] = uint8(min(depth[i], 255))
+				maxDepth = max(maxDepth, depth[i])
+				continue
+			}
+			depth[n.left] = depth[i] + 1
+			depth[n.right] = depth[i] + 1
+		}
+		if maxDepth <= limit {
+			return lengths
+		}
+	}
+}
+
+// A backRef is a literal pixel, if length is 0, or a backward reference
+// of the given length and distance code.
+type backRef struct {
+	argb   uint32
+	length int32
+	dist   int32
+}
+
+// distanceCodes maps distances of up to 8 rows to the smallest distance
+// code that expresses them in an image of width w. Longer distances d
+// have code d+120.
+func distanceCodes(w int) []int32 {
+	codes := make([]int32, 8*w+9)
+	for i := len(distanceMap) - 1; i >= 0; i-- {
+		o := distanceMap[i]
+		d := max(int(o[0])+int(o[1])*w, 1)
+		codes[d] = int32(i + 1)
+	}
+	return codes
+}
+
+func distanceCode(codes []int32, d int) int {
+	if d < len(codes) && codes[d] != 0 {
+		return int(codes[d])
+	}
+	return d + len(distanceMap)
+}
+
+// matchLength returns the length of the common prefix of a and b.
+func matchLength(a, b []uint32) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// backwardRefs compresses the pixels of an image of width w into
+// literals and backward references, greedily taking the longest match
+// among the pixel to the left, the pixel above and recent positions
+// with the same two pixels.
+func backwardRefs(pix []uint32, w int) []backRef {
+	codes := distanceCodes(w)
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, len(pix))
+	hash := func(i int) uint32 {
+		return (pix[i]*0x1e35a7bd ^ pix[i+1]*0x9e3779b1) >> (32 - hashBits)
+	}
+	insert := func(i int) {
+		if i+1 < len(pix) {
+			h := hash(i)
+			prev[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+
+	var refs []backRef
+	for i := 0; i < len(pix); {
+		bestLen, bestDist := 0, 0
+		limit := min(len(pix)-i, maxLength)
+		try := func(d int) {
+			if n := matchLength(pix[i:i+limit], pix[i-d:]); n > bestLen {
+				bestLen, bestDist = n, d
+			}
+		}
+		if limit >= minLength {
+			if i >= 1 {
+				try(1)
+			}
+			if i >= w && w > 1 {
+				try(w)
+			}
+			if i+1 < len(pix) {
+				j := int(head[hash(i)])
+				for range maxChain {
+					if j < 0 || i-j > maxWindow || bestLen == limit {
+						break
+					}
+					try(i - j)
+					j = int(prev[j])
+				}
+			}
+		}
+		if bestLen < minLength {
+			refs = append(refs, backRef{argb: pix[i]})
+			insert(i)
+			i++
+			continue
+		}
+		refs = append(refs, backRef{length: int32(bestLen), dist: int32(distanceCode(codes, bestDist))})
+		for range bestLen {
+			insert(i)
+			i++
+		}
+	}
+	return refs
+}
+
+// prefixEncode returns the prefix symbol and extra bits coding a length
+// or distance value v, which is at least 1.
+func prefixEncode(v int) (prefix int, extra uint, extraValue uint32) {
+	n := v - 1
+	if n < 4 {
+		return n, 0, 0
+	}
+	h := uint(bits.Len(uint(n))) - 1
+	second := n >> (h - 1) & 1
+	extra = h - 1
+	return int(2*h) + second, extra, uint32(n) & (1<<extra - 1)
+}
+
+// writeImage writes an entropy-coded image of width w, or the main
+// image of the stream, which has a flag for meta prefix codes.
+func writeImage(bw *bitWriter, pix []uint32, w int, main bool) {
+	refs := backwardRefs(pix, w)
+	freq := [5][]int{
+		make([]int, numLiterals+numLengthCodes),
+		make([]int, numLiterals),
+		make([]int, numLiterals),
+		make([]int, numLiterals),
+		make([]int, numDistCodes),
+	}
+	for _, r := range refs {
+		if r.length == 0 {
+			freq[0][r.argb>>8&0xff]++
+			freq[1][r.argb>>16&0xff]++
+			freq[2][r.argb&0xff]++
+			freq[3][r.argb>>24]++
+			continue
+		}
+		l, _, _ := prefixEncode(int(r.length))
+		d, _, _ := prefixEncode(int(r.dist))
+		freq[0][numLiterals+l]++
+		freq[4][d]++
+	}
+
+	bw.write(0, 1) // no color cache
+	if main {
+		bw.write(0, 1) // no meta prefix codes
+	}
+	var codes [5]*prefixCode
+	for i := range codes {
+		codes[i] = newPrefixCode(freq[i], maxCodeLength)
+		writeCode(bw, codes[i])
+	}
+	for _, r := range refs {
+		if r.length == 0 {
+			codes[0].write(bw, int(r.argb>>8&0xff))
+			codes[1].write(bw, int(r.argb>>16&0xff))
+			codes[2].write(bw, int(r.argb&0xff))
+			codes[3].write(bw, int(r.argb>>24))
+			continue
+		}
+		l, n, v := prefixEncode(int(r.length))
+		codes[0].write(bw, numLiterals+l)
+		bw.write(v, n)
+		d, n, v := prefixEncode(int(r.dist))
+		codes[4].write(bw, d)
+		bw.write(v, n)
+	}
+}
+
+// writeCode writes the code lengths of a prefix code.
+func writeCode(bw *bitWriter, c *prefixCode) {
+	var syms []int
+	for s, l := range c.lengths {
+		if l != 0 {
+			syms = append(syms, s)
+		}
+	}
+	if len(syms) <= 2 && (len(syms) == 0 || syms[len(syms)-1] < 256) {
+		// A simple code. An unused alphabet is coded as if it held
+		// only symbol 0.
+		if len(syms) == 0 {
+			syms = []int{0}
+		}
+		bw.write(1, 1)
+		bw.write(uint32(len(syms)-1), 1)
+		if syms[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(syms[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(syms[0]), 8)
+		}
+		if len(syms) == 2 {
+			bw.write(uint32(syms[1]), 8)
+		}
+		return
+	}
+
+	// Run-length code the code lengths with symbols 16 (repeat the
+	// previous length 3 to 6 times), 17 (3 to 10 zeros) and 18 (11 to
+	// 138 zeros), each followed by the given number of extra bits.
+	type token struct {
+		sym   uint8
+		extra uint8
+	}
+	var tokens []token
+	lengths := c.lengths
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		i += run
+		if l == 0 {
+			for run >= 3 {
+				if run >= 11 {
+					n := min(run, 138)
+					tokens = append(tokens, token{18, uint8(n - 11)})
+					run -= n
+				} else {
+					n := min(run, 10)
+					tokens = append(tokens, token{17, uint8(n - 3)})
+					run -= n
+				}
+			}
+		} else {
+			tokens = append(tokens, token{l, 0})
+			run--
+			for run >= 3 {
+				n := min(run, 6)
+				tokens = append(tokens, token{16, uint8(n - 3)})
+				run -= n
+			}
+		}
+		for range run {
+			tokens = append(tokens, token{l, 0})
+		}
+	}
+
+	freq := make([]int, len(codeLengthCodeOrder))
+	for _, t := range tokens {
+		freq[t.sym]++
+	}
+	cl := newPrefixCode(freq, maxCodeLengthCodeLength)
+	numCodes := 4
+	for i, s := range codeLengthCodeOrder {
+		if cl.lengths[s] != 0 {
+			numCodes = max(numCodes, i+1)
+		}
+	}
+	bw.write(0, 1)
+	bw.write(uint32(numCodes-4), 4)
+	for _, s := range codeLengthCodeOrder[:numCodes] {
+		bw.write(uint32(cl.lengths[s]), 3)
+	}
+	bw.write(0, 1) // code all the symbols
+	for _, t := range tokens {
+		cl.write(bw, int(t.sym))
+		switch t.sym {
+		case 16:
+			bw.write(uint32(t.extra), 2)
+		case 17:
+			bw.write(uint32(t.extra), 3)
+		case 18:
+			bw.write(uint32(t.extra), 7)
+		}
+	}
+}
+
+// distinctColors returns the sorted distinct colors of pix, or nil if
+// there are more than 256.
+func distinctColors(pix []uint32) []uint32 {
+	seen := make(map[uint32]bool)
+	for _, p := range pix {
+		if !seen[p] {
+			if len(seen) == 256 {
+				return nil
+			}
+			seen[p] = true
+		}
+	}
+	colors := make([]uint32, 0, len(seen))
+	for p := range seen {
+		colors = append(colors, p)
+	}
+	slices.Sort(colors)
+	return colors
+}
+
+// writeColorIndexing writes a color indexing transform for the palette
+// and returns the packed indexes of pix and their width.
+func writeColorIndexing(bw *bitWriter, pix []uint32, w, h int, colors []uint32) ([]uint32, int) {
+	bw.write(1, 1)
+	bw.write(tColorIndexing, 2)
+	bw.write(uint32(len(colors)-1), 8)
+	deltas := make([]uint32, len(colors))
+	for i, c := range colors {
+		deltas[i] = c
+		if i > 0 {
+			deltas[i] = subPixels(c, colors[i-1])
+		}
+	}
+	writeImage(bw, deltas, len(deltas), false)
+
+	var xbits uint
+	switch {
+	case len(colors) > 16:
+		xbits = 0
+	case len(colors) > 4:
+		xbits = 1
+	case len(colors) > 2:
+		xbits = 2
+	default:
+		xbits = 3
+	}
+	index := make(map[uint32]uint32, len(colors))
+	for i, c := range colors {
+		index[c] = uint32(i)
+	}
+	pw := subSampleSize(w, xbits)
+	bpp := uint(8) >> xbits
+	packed := make([]uint32, pw*h)
+	for y := range h {
+		for x := range w {
+			i := y*pw + x>>xbits
+			packed[i] |= index[pix[y*w+x]] << (8 + uint(x&(1<<xbits-1))*bpp)
+		}
+	}
+	for i := range packed {
+		packed[i] |= 0xff000000
+	}
+	return packed, pw
+}
+
+// writeSubtractGreen writes a subtract green transform and applies it
+// to pix.
+func writeSubtractGreen(bw *bitWriter, pix []uint32) {
+	bw.write(1, 1)
+	bw.write(tSubtractGreen, 2)
+	for i, p := range pix {
+		g := p >> 8 & 0xff
+		pix[i] = subPixels(p, g<<16|g)
+	}
+}
+
+// residualCost estimates the cost of coding a residual as the sum of the
+// magnitudes of its components.
+func residualCost(r uint32) int {
+	c := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(int8(r >> shift))
+		c += max(v, -v)
+	}
+	return c
+}
+
+// writePredictor writes a predictor transform, choosing for each block
+// the mode with the smallest residuals, and returns the residuals.
+func writePredictor(bw *bitWriter, pix []uint32, w, h int) []uint32 {
+	tw, th := subSampleSize(w, predictorBits), subSampleSize(h, predictorBits)
+	modes := make([]uint32, tw*th)
+	res := make([]uint32, len(pix))
+	residual := func(x, y int, mode uint32) uint32 {
+		i := y*w + x
+		var pred uint32
+		switch {
+		case y == 0 && x == 0:
+			pred = 0xff000000
+		case y == 0:
+			pred = pix[i-1]
+		case x == 0:
+			pred = pix[i-w]
+		default:
+			pred = predict(mode, pix[i-1], pix[i-w], pix[i-w+1], pix[i-w-1])
+		}
+		return subPixels(pix[i], pred)
+	}
+	for by := range th {
+		for bx := range tw {
+			x0, y0 := bx<<predictorBits, by<<predictorBits
+			x1, y1 := min(x0+1<<predictorBits, w), min(y0+1<<predictorBits, h)
+			best, bestCost := uint32(0), -1
+			for mode := uint32(0); mode < 14; mode++ {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						cost += residualCost(residual(x, y, mode))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[by*tw+bx] = 0xff000000 | best<<8
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					res[y*w+x] = residual(x, y, best)
+				}
+			}
+		}
+	}
+	bw.write(1, 1)
+	bw.write(tPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	writeImage(bw, modes, tw, false)
+	return res
+}
+
+// argbPixels returns the pixels of m as non-premultiplied ARGB values,
+// and whether any of them is not opaque.
+func argbPixels(m image.Image) ([]uint32, bool) {
+	b := m.Bounds()
+	pix := make([]uint32, 0, b.Dx()*b.Dy())
+	alpha := false
+	add := func(c color.NRGBA) {
+		pix = append(pix, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		alpha = alpha || c.A != 0xff
+	}
+	if n, ok := m.(*image.NRGBA); ok {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := n.Pix[n.PixOffset(b.Min.X, y):]
+			for x := range b.Dx() {
+				add(color.NRGBA{row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]})
+			}
+		}
+		return pix, alpha
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			add(color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA))
+		}
+	}
+	return pix, alpha
+}
+
+// Encode writes the Image m to w in the lossless WebP format. The
+// dimensions of m must be between 1 and 16384.
+func Encode(w io.Writer, m image.Image) error {
+	mw, mh := int64(m.Bounds().Dx()), int64(m.Bounds().Dy())
+	if mw <= 0 || mh <= 0 || mw > maxDimension || mh > maxDimension {
+		return FormatError("invalid image size: " + strconv.FormatInt(mw, 10) + "x" + strconv.FormatInt(mh, 10))
+	}
+	width, height := int(mw), int(mh)
+	pix, alpha := argbPixels(m)
+
+	bw := &bitWriter{buf: make([]byte, 0, 1024)}
+	bw.write(vp8lMagic, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if alpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // version
+
+	xsize := width
+	if colors := distinctColors(pix); colors != nil {
+		pix, xsize = writeColorIndexing(bw, pix, width, height, colors)
+	} else {
+		writeSubtractGreen(bw, pix)
+		pix = writePredictor(bw, pix, width, height)
+	}
+	bw.write(0, 1) // no more transforms
+	writeImage(bw, pix, xsize, true)
+	bw.flush()
+
+	data := bw.buf
+	pad := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if pad != 0 {
+		data = append(data, 0)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// diff reports whether m0 and m1 have the same size and the same
+// non-premultiplied 8-bit colors.
+func diff(m0, m1 image.Image) error {
+	b0, b1 := m0.Bounds(), m1.Bounds()
+	if !b0.Size().Eq(b1.Size()) {
+		return fmt.Errorf("dimensions differ: %v vs %v", b0, b1)
+	}
+	dx := b1.Min.X - b0.Min.X
+	dy := b1.Min.Y - b0.Min.Y
+	for y := b0.Min.Y; y < b0.Max.Y; y++ {
+		for x := b0.Min.X; x < b0.Max.X; x++ {
+			c0 := color.NRGBAModel.Convert(m0.At(x, y))
+			c1 := color.NRGBAModel.Convert(m1.At(x+dx, y+dy))
+			if c0 != c1 {
+				return fmt.Errorf("colors differ at (%d, %d): %v vs %v", x, y, c0, c1)
+			}
+		}
+	}
+	return nil
+}
+
+// testImages returns images of various types and contents, exercising
+// both the palette and the predictor coding of the encoder.
+func testImages() []image.Image {
+	rng := rand.New(rand.NewSource(1))
+	r := image.Rect(2, 3, 53, 40)
+	nrgba := image.NewNRGBA(r)
+	noise := image.NewNRGBA(r)
+	rgba := image.NewRGBA(r)
+	gray := image.NewGray(r)
+	gray16 := image.NewGray16(r)
+	pal := image.NewPaletted(r, palette.Plan9)
+	twoColors := image.NewPaletted(r, color.Palette{color.Black, color.Transparent})
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			a := uint8(x * y)
+			nrgba.SetNRGBA(x, y, color.NRGBA{uint8(x * 5), uint8(y * 7), 0x80, a})
+			noise.SetNRGBA(x, y, color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))})
+			rgba.SetRGBA(x, y, color.RGBA{a / 2, a / 3, a / 4, a})
+			gray.SetGray(x, y, color.Gray{uint8(x + y*3)})
+			gray16.SetGray16(x, y, color.Gray16{uint16(x * y * 37)})
+			pal.SetColorIndex(x, y, uint8(x*y+x))
+			twoColors.SetColorIndex(x, y, uint8(x/3+y)&1)
+		}
+	}
+	// Transparent pixels keep their color.
+	nrgba.SetNRGBA(10, 10, color.NRGBA{1, 2, 3, 0})
+
+	line := image.NewGray(image.Rect(0, 0, 1000, 1))
+	for x := range 1000 {
+		line.Pix[x] = uint8(x % 7)
+	}
+	return []image.Image{
+		nrgba, noise, rgba, gray, gray16, pal, twoColors, line,
+		image.NewNRGBA(image.Rect(0, 0, 1, 1)),
+		image.NewUniform(color.White),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, m := range testImages() {
+		var b bytes.Buffer
+		err := Encode(&b, m)
+		if _, ok := m.(*image.Uniform); ok {
+			if err == nil || !strings.Contains(err.Error(), "invalid image size") {
+				t.Errorf("Encode(%T) = %v, want an image size error", m, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Encode(%T): %v", m, err)
+			continue
+		}
+		got, err := Decode(bytes.NewReader(b.Bytes()))
+		if err != nil {
+			t.Errorf("Decode(Encode(%T)): %v", m, err)
+			continue
+		}
+		if err := diff(m, got); err != nil {
+			t.Errorf("Decode(Encode(%T)): %v", m, err)
+		}
+		cfg, err := DecodeConfig(bytes.NewReader(b.Bytes()))
+		if err != nil || cfg.ColorModel != color.NRGBAModel || cfg.Width != m.Bounds().Dx() || cfg.Height != m.Bounds().Dy() {
+			t.Errorf("DecodeConfig(Encode(%T)) = %+v, %v", m, cfg, err)
+		}
+	}
+}
+
+func TestEncodeCompresses(t *testing.T) {
+	// A smooth gradient is predicted almost perfectly, and repeated rows
+	// become backward references.
+	m := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for y := range 256 {
+		for x := range 256 {
+			m.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(x + y/32), uint8(255 - x), 0xff})
+		}
+	}
+	var b bytes.Buffer
+	if err := Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Len(); n > 2000 {
+		t.Errorf("encoded size is %d bytes, want at most 2000", n)
+	}
+	got, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := diff(m, got); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCodeLengths(t *testing.T) {
+	// Fibonacci frequencies give the deepest possible Huffman code,
+	// which must be flattened to fit the limit.
+	freq := []int{1, 1}
+	for len(freq) < 30 {
+		freq = append(freq, freq[len(freq)-1]+freq[len(freq)-2])
+	}
+	for _, limit := range []int{maxCodeLengthCodeLength, maxCodeLength} {
+		lengths := codeLengths(freq, limit)
+		sum := 0.0
+		for _, l := range lengths {
+			if l == 0 || int(l) > limit {
+				t.Fatalf("limit %d: code lengths %v", limit, lengths)
+			}
+			sum += 1 / float64(uint(1)<<l)
+		}
+		if sum != 1 {
+			t.Errorf("limit %d: code lengths %v are not complete", limit, lengths)
+		}
+	}
+}
@@ -22,17 +22,16 @@
 package profile
 
 import (
+	"encoding/protowire"
 	"errors"
-	"fmt"
 	"slices"
 )
 
 type buffer struct {
-	field int
-	typ   int
+	field protowire.Number
+	typ   protowire.Type
 	u64   uint64
 	data  []byte
-	tmp   [16]byte
 }
 
 type decoder func(*buffer, message) error
@@ -49,37 +48,28 @@ func marshal(m message) []byte {
 }
 
 func encodeVarint(b *buffer, x uint64) {
-	for x >= 128 {
-		b.data = append(b.data, byte(x)|0x80)
-		x >>= 7
-	}
-	b.data = append(b.data, byte(x))
+	b.data = protowire.AppendVarint(b.data, x)
 }
 
 func encodeLength(b *buffer, tag int, len int) {
-	encodeVarint(b, uint64(tag)<<3|2)
+	b.data = protowire.AppendTag(b.data, protowire.Number(tag), protowire.BytesType)
 	encodeVarint(b, uint64(len))
 }
 
 func encodeUint64(b *buffer, tag int, x uint64) {
-	// append varint to b.data
-	encodeVarint(b, uint64(tag)<<3|0)
+	b.data = protowire.AppendTag(b.data, protowire.Number(tag), protowire.VarintType)
 	encodeVarint(b, x)
 }
 
 func encodeUint64s(b *buffer, tag int, x []uint64) {
 	if len(x) > 2 {
 		// Use packed encoding
-		n1 := len(b.data)
-		for _, u := range x {
-			encodeVarint(b, u)
-		}
-		n2 := len(b.data)
-		encodeLength(b, tag, n2-n1)
-		n3 := len(b.data)
-		copy(b.tmp[:], b.data[n2:n3])
-		copy(b.data[n1+(n3-n2):], b.data[n1:n2])
-		copy(b.data[n1:], b.tmp[:n3-n2])
+		b.data = protowire.AppendMessage(b.data, protowire.Number(tag), func(data []byte) []byte {
+			for _, u := range x {
+				data = protowire.AppendVarint(data, u)
+			}
+			return data
+		})
 		return
 	}
 	for _, u := range x {
@@ -109,16 +99,12 @@ func encodeInt64Opt(b *buffer, tag int, x int64) {
 func encodeInt64s(b *buffer, tag int, x []int64) {
 	if len(x) > 2 {
 		// Use packed encoding
-		n1 := len(b.data)
-		for _, u := range x {
-			encodeVarint(b, uint64(u))
-		}
-		n2 := len(b.data)
-		encodeLength(b, tag, n2-n1)
-		n3 := len(b.data)
-		copy(b.tmp[:], b.data[n2:n3])
-		copy(b.data[n1+(n3-n2):], b.data[n1:n2])
-		copy(b.data[n1:], b.tmp[:n3-n2])
+		b.data = protowire.AppendMessage(b.data, protowire.Number(tag), func(data []byte) []byte {
+			for _, u := range x {
+				data = protowire.AppendVarint(data, uint64(u))
+			}
+			return data
+		})
 		return
 	}
 	for _, u := range x {
@@ -138,11 +124,7 @@ func encodeStrings(b *buffer, tag int, x []string) {
 }
 
 func encodeBool(b *buffer, tag int, x bool) {
-	if x {
-		encodeUint64(b, tag, 1)
-	} else {
-		encodeUint64(b, tag, 0)
-	}
+	encodeUint64(b, tag, protowire.EncodeBool(x))
 }
 
 func encodeBoolOpt(b *buffer, tag int, x bool) {
@@ -153,99 +135,64 @@ func encodeBoolOpt(b *buffer, tag int, x bool) {
 }
 
 func encodeMessage(b *buffer, tag int, m message) {
-	n1 := len(b.data)
-	m.encode(b)
-	n2 := len(b.data)
-	encodeLength(b, tag, n2-n1)
-	n3 := len(b.data)
-	copy(b.tmp[:], b.data[n2:n3])
-	copy(b.data[n1+(n3-n2):], b.data[n1:n2])
-	copy(b.data[n1:], b.tmp[:n3-n2])
+	b.data = protowire.AppendMessage(b.data, protowire.Number(tag), func(data []byte) []byte {
+		b.data = data
+		m.encode(b)
+		return b.data
+	})
 }
 
 func unmarshal(data []byte, m message) (err error) {
-	b := buffer{data: data, typ: 2}
+	b := buffer{data: data, typ: protowire.BytesType}
 	return decodeMessage(&b, m)
 }
 
-func le64(p []byte) uint64 {
-	return uint64(p[0]) | uint64(p[1])<<8 | uint64(p[2])<<16 | uint64(p[3])<<24 | uint64(p[4])<<32 | uint64(p[5])<<40 | uint64(p[6])<<48 | uint64(p[7])<<56
-}
-
-func le32(p []byte) uint32 {
-	return uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
-}
-
 func peekNumVarints(data []byte) (numVarints int) {
 	for ; len(data) > 0; numVarints++ {
-		var err error
-		if _, data, err = decodeVarint(data); err != nil {
+		_, n := protowire.ConsumeVarint(data)
+		if n < 0 {
 			break
 		}
+		data = data[n:]
 	}
 	return numVarints
 }
 
-func decodeVarint(data []byte) (uint64, []byte, error) {
-	var i int
-	var u uint64
-	for i = 0; ; i++ {
-		if i >= 10 || i >= len(data) {
-			return 0, nil, errors.New("bad varint")
-		}
-		u |= uint64(data[i]&0x7F) << uint(7*i)
-		if data[i]&0x80 == 0 {
-			return u, data[i+1:], nil
-		}
-	}
-}
-
 func decodeField(b *buffer, data []byte) ([]byte, error) {
-	x, data, err := decodeVarint(data)
-	if err != nil {
-		return nil, err
+	num, typ, n := protowire.ConsumeTag(data)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
 	}
-	b.field = int(x >> 3)
-	b.typ = int(x & 7)
+	data = data[n:]
+	b.field = num
+	b.typ = typ
 	b.data = nil
 	b.u64 = 0
-	switch b.typ {
-	case 0:
-		b.u64, data, err = decodeVarint(data)
-		if err != nil {
-			return nil, err
-		}
-	case 1:
-		if len(data) < 8 {
-			return nil, errors.New("not enough data")
-		}
-		b.u64 = le64(data[:8])
-		data = data[8:]
-	case 2:
-		var n uint64
-		n, data, err = decodeVarint(data)
-		if err != nil {
-			return nil, err
-		}
-		if n > uint64(len(data)) {
-			return nil, errors.New("too much data")
-		}
-		b.data = data[:n]
-		data = data[n:]
-	case 5:
-		if len(data) < 4 {
-			return nil, errors.New("not enough data")
-		}
-		b.u64 = uint64(le32(data[:4]))
-		data = data[4:]
+	switch typ {
+	case protowire.VarintType:
+		b.u64, n = protowire.ConsumeVarint(data)
+	case protowire.Fixed64Type:
+		b.u64, n = protowire.ConsumeFixed64(data)
+	case protowire.BytesType:
+		b.data, n = protowire.ConsumeBytes(data)
+	case protowire.Fixed32Type:
+		var u32 uint32
+		u32, n = protowire.ConsumeFixed32(data)
+		b.u64 = uint64(u32)
 	default:
-		return nil, fmt.Errorf("unknown wire type: %d", b.typ)
+		// Groups are not supported.
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n >= 0 {
+			return nil, errors.New("unsupported wire type")
+		}
 	}
-
-	return data, nil
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	return data[n:], nil
 }
 
-func checkType(b *buffer, typ int) error {
+func checkType(b *buffer, typ protowire.Type) error {
 	if b.typ != typ {
 		return errors.New("type mismatch")
 	}
@@ -253,7 +200,7 @@ func checkType(b *buffer, typ int) error {
 }
 
 func decodeMessage(b *buffer, m message) error {
-	if err := checkType(b, 2); err != nil {
+	if err := checkType(b, protowire.BytesType); err != nil {
 		return err
 	}
 	dec := m.decoder()
@@ -265,7 +212,7 @@ func decodeMessage(b *buffer, m message) error {
 		if err != nil {
 			return err
 		}
-		if b.field >= len(dec) || dec[b.field] == nil {
+		if int(b.field) >= len(dec) || dec[b.field] == nil {
 			continue
 		}
 		if err := dec[b.field](b, m); err != nil {
@@ -276,7 +223,7 @@ func decodeMessage(b *buffer, m message) error {
 }
 
 func decodeInt64(b *buffer, x *int64) error {
-	if err := checkType(b, 0); err != nil {
+	if err := checkType(b, protowire.VarintType); err != nil {
 		return err
 	}
 	*x = int64(b.u64)
@@ -284,19 +231,18 @@ func decodeInt64(b *buffer, x *int64) error {
 }
 
 func decodeInt64s(b *buffer, x *[]int64) error {
-	if b.typ == 2 {
+	if b.typ == protowire.BytesType {
 		// Packed encoding
 		dataLen := peekNumVarints(b.data)
 		*x = slices.Grow(*x, dataLen)
 
 		data := b.data
 		for len(data) > 0 {
-			var u uint64
-			var err error
-
-			if u, data, err = decodeVarint(data); err != nil {
-				return err
+			u, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
 			}
+			data = data[n:]
 			*x = append(*x, int64(u))
 		}
 		return nil
@@ -310,7 +256,7 @@ func decodeInt64s(b *buffer, x *[]int64) error {
 }
 
 func decodeUint64(b *buffer, x *uint64) error {
-	if err := checkType(b, 0); err != nil {
+	if err := checkType(b, protowire.VarintType); err != nil {
 		return err
 	}
 	*x = b.u64
@@ -318,19 +264,18 @@ func decodeUint64(b *buffer, x *uint64) error {
 }
 
 func decodeUint64s(b *buffer, x *[]uint64) error {
-	if b.typ == 2 {
+	if b.typ == protowire.BytesType {
 		// Packed encoding
 		dataLen := peekNumVarints(b.data)
 		*x = slices.Grow(*x, dataLen)
 
 		data := b.data
 		for len(data) > 0 {
-			var u uint64
-			var err error
-
-			if u, data, err = decodeVarint(data); err != nil {
-				return err
+			u, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
 			}
+			data = data[n:]
 			*x = append(*x, u)
 		}
 		return nil
@@ -344,7 +289,7 @@ func decodeUint64s(b *buffer, x *[]uint64) error {
 }
 
 func decodeString(b *buffer, x *string) error {
-	if err := checkType(b, 2); err != nil {
+	if err := checkType(b, protowire.BytesType); err != nil {
 		return err
 	}
 	*x = string(b.data)
@@ -361,13 +306,9 @@ func decodeStrings(b *buffer, x *[]string) error {
 }
 
 func decodeBool(b *buffer, x *bool) error {
-	if err := checkType(b, 0); err != nil {
+	if err := checkType(b, protowire.VarintType); err != nil {
 		return err
 	}
-	if int64(b.u64) == 0 {
-		*x = false
-	} else {
-		*x = true
-	}
+	*x = protowire.DecodeBool(b.u64)
 	return nil
 }
@@ -0,0 +1,29 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rotate_test
+
+import (
+	"log"
+	"log/rotate"
+	"log/slog"
+	"time"
+)
+
+func Example() {
+	w, err := rotate.Open("/var/log/app.log", &rotate.Options{
+		MaxSize:        100 << 20,
+		Interval:       24 * time.Hour,
+		MaxBackups:     7,
+		Compress:       true,
+		ReopenOnHangup: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer w.Close()
+
+	logger := slog.New(slog.NewJSONHandler(w, nil))
+	logger.Info("started")
+}
@@ -0,0 +1,14 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package rotate
+
+import (
+	"os"
+	"syscall"
+)
+
+var hangupSignals = []os.Signal{syscall.SIGHUP}
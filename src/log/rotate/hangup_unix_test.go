@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnHangup(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "log")
+	w, err := Open(name, &Options{ReopenOnHangup: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	write(t, w, "a")
+	if err := os.Rename(name, name+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(name); err == nil {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("file not reopened after SIGHUP")
+		}
+	}
+	write(t, w, "b")
+	check(t, dir, map[string]string{"log": "b", "log.old": "a"})
+}
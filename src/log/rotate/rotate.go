@@ -0,0 +1,397 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rotate implements a file writer that rotates the file it writes
+// to when it grows too large or too old, for use as the output of a
+// [log.Logger] or a [log/slog.Handler].
+//
+// When a [Writer] for the file app.log rotates it, it renames app.log to
+// app.log.1, after renaming app.log.1 to app.log.2 and so on, and creates
+// a new app.log. With [Options.Compress], the backups are compressed with
+// gzip and named app.log.1.gz, app.log.2.gz and so on.
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options are options for a [Writer].
+// A zero Options consists entirely of default values, with which the file
+// is never rotated.
+type Options struct {
+	// MaxSize is the size in bytes beyond which the file is rotated.
+	// If MaxSize is zero, the file is not rotated for its size.
+	MaxSize int64
+
+	// Interval is the time after which the file is rotated, counted from
+	// the time it was opened. If Interval is zero, the file is not rotated
+	// for its age.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files that are kept; older ones
+	// are removed. If MaxBackups is zero, all of them are kept.
+	MaxBackups int
+
+	// Compress causes rotated files to be compressed with gzip.
+	// A rotated file is compressed in the background; an error compressing
+	// it is returned by the next call to [Writer.Rotate] or [Writer.Close].
+	Compress bool
+
+	// Perm is the permission bits of the files that are created.
+	// If Perm is zero, it is 0644.
+	Perm fs.FileMode
+
+	// ReopenOnHangup causes the writer to reopen the file when the process
+	// receives a SIGHUP signal, as tools such as logrotate expect of
+	// processes whose files they rotate. It is ignored on systems without
+	// SIGHUP.
+	ReopenOnHangup bool
+}
+
+// A Writer is an [io.WriteCloser] that writes to a file and rotates it.
+// It is safe for concurrent use by multiple goroutines.
+//
+// Rotation happens in calls to Write: a write that would grow the file
+// beyond [Options.MaxSize], or that comes [Options.Interval] after the file
+// was opened, first rotates it. A single write is never split across
+// files, so a file may exceed MaxSize if a write alone does.
+//
+// If rotating fails, for example because a backup cannot be renamed, the
+// writer keeps writing to the file it has open, and retries the rotation on
+// the next write. Write still writes p, and reports no error if it does;
+// the rotation error is returned by the next call to [Writer.Rotate] or
+// [Writer.Close]. If the file cannot be reopened after a failed rotation,
+// Write writes nothing and returns the error.
+type Writer struct {
+	name     string
+	opts     Options
+	now      func() time.Time                          // time.Now, replaced in tests
+	compress func(name string, perm fs.FileMode) error // compressFile, replaced in tests
+
+	mu     sync.Mutex
+	closed bool
+	f      *os.File // nil if the file could not be reopened
+	size   int64    // the size of f
+	opened time.Time
+
+	compressing *compression // nil if no backup is being compressed
+	pendingErr  error        // the first error compressing a backup or rotating in Write
+
+	sig  chan os.Signal // nil without ReopenOnHangup
+	done chan struct{}
+}
+
+var errClosed = errors.New("rotate: writer is closed")
+
+// Open opens the named file for appending, creating it if needed, and
+// returns a [Writer] that writes to it. If opts is nil, the default options
+// are used.
+func Open(name string, opts *Options) (*Writer, error) {
+	w := &Writer{name: name, now: time.Now, compress: compressFile}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Perm == 0 {
+		w.opts.Perm = 0644
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	if w.opts.ReopenOnHangup && len(hangupSignals) > 0 {
+		w.sig = make(chan os.Signal, 1)
+		w.done = make(chan struct{})
+		signal.Notify(w.sig, hangupSignals...)
+		go w.reopenOnSignal()
+	}
+	return w, nil
+}
+
+// open opens the file.
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.opts.Perm)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f = f
+	w.size = fi.Size()
+	w.opened = w.now()
+	return nil
+}
+
+func (w *Writer) reopenOnSignal() {
+	for {
+		select {
+		case <-w.sig:
+			w.Reopen()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Write writes p to the file, rotating it first if needed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errClosed
+	}
+	var rerr error
+	if w.f == nil {
+		rerr = w.open()
+	} else if w.size > 0 && (w.opts.MaxSize > 0 && w.size+int64(len(p)) > w.opts.MaxSize ||
+		w.opts.Interval > 0 && w.now().Sub(w.opened) >= w.opts.Interval) {
+		rerr = w.rotate()
+	}
+	if w.f == nil {
+		return 0, rerr
+	}
+	if rerr != nil && w.pendingErr == nil {
+		w.pendingErr = rerr
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file, whatever its size and age. It also returns
+// the error compressing a previous backup or rotating the file in a call
+// to Write, if any.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	err := w.rotate()
+	return errors.Join(err, w.takePendingErr())
+}
+
+// Reopen closes the file and opens it again, creating it if it no longer
+// exists, for example because another program renamed it.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	return err
+}
+
+// Close closes the file, after waiting for the compression of a backup, if
+// any. Later calls to the methods of w return errors.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	w.closed = true
+	if w.sig != nil {
+		signal.Stop(w.sig)
+		close(w.done)
+	}
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+	return errors.Join(err, w.takePendingErr())
+}
+
+// rotate closes the file, shifts the backups, and opens a new file. If the
+// backups cannot be shifted, it reopens the file instead. If no file can be
+// opened, w.f is left nil, and the next write tries to open it again.
+func (w *Writer) rotate() error {
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+	if err == nil {
+		// Wait for the compression of the previous backup, which shift
+		// renames.
+		w.waitCompress()
+		err = w.shift()
+		if err == nil && w.opts.Compress {
+			w.startCompress()
+		}
+	}
+	if oerr := w.open(); oerr != nil {
+		return errors.Join(err, oerr)
+	}
+	return err
+}
+
+// A compression is the compression of a backup in the background.
+type compression struct {
+	done chan struct{} // closed when the compression ends
+	err  error
+}
+
+// startCompress starts compressing the first backup in the background.
+func (w *Writer) startCompress() {
+	c := &compression{done: make(chan struct{})}
+	w.compressing = c
+	name, perm := w.backup(1, false), w.opts.Perm
+	go func() {
+		defer close(c.done)
+		c.err = w.compress(name, perm)
+	}()
+}
+
+// waitCompress waits for the compression of the first backup, if any, and
+// records its error.
+func (w *Writer) waitCompress() {
+	if c := w.compressing; c != nil {
+		<-c.done
+		w.compressing = nil
+		if w.pendingErr == nil {
+			w.pendingErr = c.err
+		}
+	}
+}
+
+// takePendingErr waits for the compression of the first backup, if any,
+// and returns and clears the first error compressing a backup or rotating
+// the file in Write.
+func (w *Writer) takePendingErr() error {
+	w.waitCompress()
+	err := w.pendingErr
+	w.pendingErr = nil
+	return err
+}
+
+// backup returns the name of the n-th backup.
+func (w *Writer) backup(n int, compressed bool) string {
+	name := w.name + "." + strconv.Itoa(n)
+	if compressed {
+		name += ".gz"
+	}
+	return name
+}
+
+// exists reports whether the n-th backup exists, and whether it is
+// compressed.
+func (w *Writer) exists(n int) (ok, compressed bool) {
+	for _, compressed := range []bool{true, false} {
+		if _, err := os.Lstat(w.backup(n, compressed)); err == nil {
+			return true, compressed
+		}
+	}
+	return false, false
+}
+
+// shift renames the n-th backup to the (n+1)-th, removing those beyond
+// MaxBackups, and renames the file to the first backup. Only the backups
+// numbered from 1 without a gap are renamed; those past a gap keep their
+// names, and are removed if they are beyond MaxBackups.
+func (w *Writer) shift() error {
+	last := 0
+	for {
+		if ok, _ := w.exists(last + 1); !ok {
+			break
+		}
+		last++
+	}
+	for n := last; n >= 1; n-- {
+		_, compressed := w.exists(n)
+		name := w.backup(n, compressed)
+		if w.opts.MaxBackups > 0 && n >= w.opts.MaxBackups {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(name, w.backup(n+1, compressed)); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(w.name, w.backup(1, false)); err != nil {
+		return err
+	}
+	if w.opts.MaxBackups > 0 {
+		return w.removeBackups()
+	}
+	return nil
+}
+
+// removeBackups removes the backups numbered beyond MaxBackups.
+func (w *Writer) removeBackups() error {
+	dir, prefix := filepath.Dir(w.name), filepath.Base(w.name)+"."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		num, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok {
+			continue
+		}
+		num = strings.TrimSuffix(num, ".gz")
+		n, err := strconv.Atoi(num)
+		if err != nil || num != strconv.Itoa(n) || n <= w.opts.MaxBackups {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressFile compresses the named file with gzip into name+".gz", and
+// removes it.
+func compressFile(name string, perm fs.FileMode) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(name + ".gz")
+		}
+	}()
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,346 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// files returns the names and contents of the files in dir, decompressing
+// the compressed ones.
+func files(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]string{}
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = f
+		if strings.HasSuffix(e.Name(), ".gz") {
+			if r, err = gzip.NewReader(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, err := io.ReadAll(r)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m[e.Name()] = string(b)
+	}
+	return m
+}
+
+func write(t *testing.T, w *Writer, ss ...string) {
+	t.Helper()
+	for _, s := range ss {
+		if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+}
+
+func check(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := files(t, dir)
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("unexpected file %s", name)
+		}
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts Options
+		want map[string]string
+	}{
+		{
+			name: "all backups",
+			opts: Options{MaxSize: 4},
+			want: map[string]string{"log": "ggg", "log.1": "eeff", "log.2": "ccdd", "log.3": "aabb"},
+		},
+		{
+			name: "max backups",
+			opts: Options{MaxSize: 4, MaxBackups: 2},
+			want: map[string]string{"log": "ggg", "log.1": "eeff", "log.2": "ccdd"},
+		},
+		{
+			name: "compress",
+			opts: Options{MaxSize: 4, MaxBackups: 2, Compress: true},
+			want: map[string]string{"log": "ggg", "log.1.gz": "eeff", "log.2.gz": "ccdd"},
+		},
+		{
+			name: "no rotation",
+			want: map[string]string{"log": "aabbccddeeffggg"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Open(filepath.Join(dir, "log"), &tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			write(t, w, "aa", "bb", "cc", "dd", "ee", "ff", "ggg")
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			check(t, dir, tt.want)
+		})
+	}
+}
+
+func TestLargeWrite(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(filepath.Join(dir, "log"), &Options{MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	write(t, w, "a", "bbbbbbbb", "c")
+	check(t, dir, map[string]string{"log": "c", "log.1": "bbbbbbbb", "log.2": "a"})
+}
+
+func TestAppend(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "log")
+	if err := os.WriteFile(name, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Open(name, &Options{MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	write(t, w, "d", "e")
+	check(t, dir, map[string]string{"log": "e", "log.1": "abcd"})
+}
+
+func TestInterval(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w, err := Open(filepath.Join(dir, "log"), &Options{Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.now = func() time.Time { return now }
+	w.opened = now
+
+	write(t, w, "a")
+	now = now.Add(59 * time.Minute)
+	write(t, w, "b")
+	now = now.Add(time.Minute)
+	write(t, w, "c")
+	now = now.Add(30 * time.Minute)
+	write(t, w, "d")
+	check(t, dir, map[string]string{"log": "cd", "log.1": "ab"})
+}
+
+func TestRotateAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "log")
+	w, err := Open(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "a")
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "b")
+
+	// Move the file away, as logrotate does.
+	if err := os.Rename(name, name+".old"); err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "c")
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "d")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, dir, map[string]string{"log": "d", "log.old": "bc", "log.1": "a"})
+
+	if _, err := w.Write([]byte("e")); err != errClosed {
+		t.Errorf("Write after Close: got %v, want %v", err, errClosed)
+	}
+	for _, f := range []func() error{w.Close, w.Rotate, w.Reopen} {
+		if err := f(); err != errClosed {
+			t.Errorf("got %v after Close, want %v", err, errClosed)
+		}
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(filepath.Join(dir, "log"), &Options{MaxSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for j := range 50 {
+				fmt.Fprintf(w, "%d-%02d\n", i, j)
+			}
+		})
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for name, content := range files(t, dir) {
+		if len(content) > 100 {
+			t.Errorf("%s has %d bytes, want at most 100", name, len(content))
+		}
+		lines = append(lines, strings.Fields(content)...)
+	}
+	slices.Sort(lines)
+	var want []string
+	for i := range 8 {
+		for j := range 50 {
+			want = append(want, fmt.Sprintf("%d-%02d", i, j))
+		}
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("got %d lines, want %d", len(lines), len(want))
+	}
+}
+
+func TestRotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "log")
+	// A non-empty directory in the way of the only backup cannot be
+	// removed, so rotating fails.
+	if err := os.MkdirAll(filepath.Join(dir, "log.1", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Open(name, &Options{MaxSize: 4, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "aaa")
+	// The write succeeds, so it reports no error, leaving the rotation
+	// error to Close.
+	write(t, w, "bb")
+
+	if err := os.RemoveAll(filepath.Join(dir, "log.1")); err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "cc")
+	if err := w.Close(); err == nil {
+		t.Error("Close after a failed rotation succeeded")
+	}
+	check(t, dir, map[string]string{"log": "cc", "log.1": "aaabb"})
+}
+
+func TestReopenFailure(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "log")
+	w, err := Open(name, &Options{MaxSize: 4, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	write(t, w, "aaa")
+	// Rotating fails, as in TestRotateFailure, and a directory in place of
+	// the file keeps it from being reopened, so nothing is written.
+	for _, path := range []string{filepath.Join(dir, "log.1", "x"), name} {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := w.Write([]byte("bb")); n != 0 || err == nil {
+		t.Errorf("Write without a file = %d, %v; want 0 and an error", n, err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "log.1"), name} {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(t, w, "cc")
+	check(t, dir, map[string]string{"log": "cc"})
+}
+
+func TestMaxBackupsGap(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "log")
+	for _, backup := range []string{"log.1", "log.3", "log.4.gz", "log.9", "log.03", "log.x"} {
+		if err := os.WriteFile(filepath.Join(dir, backup), []byte(backup), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := Open(name, &Options{MaxSize: 4, MaxBackups: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "aaa", "bb")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, dir, map[string]string{
+		"log":    "bb",
+		"log.1":  "aaa",
+		"log.2":  "log.1",
+		"log.3":  "log.3",
+		"log.03": "log.03",
+		"log.x":  "log.x",
+	})
+}
+
+func TestCompressInBackground(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(filepath.Join(dir, "log"), &Options{MaxSize: 4, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	errCompress := errors.New("compress failed")
+	w.compress = func(name string, perm fs.FileMode) error {
+		<-release
+		return errCompress
+	}
+	write(t, w, "aaa", "bb")
+	// The compression is blocked, but writes go on.
+	write(t, w, "cc")
+	close(release)
+	// The next rotation waits for the compression of the previous backup.
+	write(t, w, "ddd")
+	if err := w.Close(); !errors.Is(err, errCompress) {
+		t.Errorf("Close = %v, want %v", err, errCompress)
+	}
+	check(t, dir, map[string]string{"log": "ddd", "log.1": "bbcc", "log.2": "aaa"})
+}
@@ -0,0 +1,235 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// OverflowPolicy is what an [AsyncHandler] does with a record when its
+// queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest record in the queue.
+	OverflowDropOldest
+	// OverflowDropNewest drops the new record.
+	OverflowDropNewest
+)
+
+var overflowPolicyNames = []string{"Block", "DropOldest", "DropNewest"}
+
+func (p OverflowPolicy) String() string {
+	if p >= 0 && int(p) < len(overflowPolicyNames) {
+		return overflowPolicyNames[p]
+	}
+	return "OverflowPolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// AsyncOptions are options for an [AsyncHandler].
+// A zero AsyncOptions consists entirely of default values.
+type AsyncOptions struct {
+	// Capacity is the number of records that the queue holds.
+	// If Capacity is zero, it is 1024.
+	Capacity int
+
+	// Overflow is what to do with a record when the queue is full.
+	// The default is OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+// An AsyncHandler is a [Handler] that queues records, and passes them to
+// another handler from a background goroutine, so that logging does not
+// wait for slow output.
+//
+// The handler passes each record to the other handler with a context
+// that has the values of the context that the record was logged with, but
+// is never canceled.
+//
+// The handlers returned by the WithAttrs and WithGroup methods of an
+// AsyncHandler share its queue and background goroutine.
+// [AsyncHandler.Close] must be called to stop the goroutine.
+type AsyncHandler struct {
+	h Handler
+	q *asyncQueue
+}
+
+type asyncQueue struct {
+	overflow OverflowPolicy
+
+	mu   sync.Mutex
+	cond sync.Cond // signaled on any change of the state below
+	// buf is a ring buffer holding n records, starting at head.
+	buf     []asyncRecord
+	head, n int
+	busy    bool // whether the goroutine is handling a record
+	closed  bool
+	dropped uint64
+	err     error // the first error since the last Flush
+	done    chan struct{}
+}
+
+type asyncRecord struct {
+	h   Handler
+	ctx context.Context
+	r   Record
+}
+
+var errAsyncClosed = errors.New("slog: AsyncHandler is closed")
+
+// NewAsyncHandler creates an [AsyncHandler] that passes the records it
+// queues to h, using the given options. If opts is nil, the default
+// options are used.
+func NewAsyncHandler(h Handler, opts *AsyncOptions) *AsyncHandler {
+	if opts == nil {
+		opts = &AsyncOptions{}
+	}
+	capacity := opts.Capacity
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	q := &asyncQueue{
+		overflow: opts.Overflow,
+		buf:      make([]asyncRecord, capacity),
+		done:     make(chan struct{}),
+	}
+	q.cond.L = &q.mu
+	go q.run()
+	return &AsyncHandler{h: h, q: q}
+}
+
+// Enabled reports whether the handler that records are passed to handles
+// records at the given level.
+func (h *AsyncHandler) Enabled(ctx context.Context, level Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle queues a clone of r. It returns an error only if the handler is
+// closed. Errors from the handler that records are passed to are reported
+// by [AsyncHandler.Flush] and [AsyncHandler.Close].
+func (h *AsyncHandler) Handle(ctx context.Context, r Record) error {
+	ar := asyncRecord{h.h, context.WithoutCancel(ctx), r.Clone()}
+	q := h.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return errAsyncClosed
+	}
+	if q.n == len(q.buf) {
+		switch q.overflow {
+		case OverflowDropNewest:
+			q.dropped++
+			return nil
+		case OverflowDropOldest:
+			q.buf[q.head] = asyncRecord{}
+			q.head = (q.head + 1) % len(q.buf)
+			q.n--
+			q.dropped++
+		default:
+			for q.n == len(q.buf) && !q.closed {
+				q.cond.Wait()
+			}
+			if q.closed {
+				return errAsyncClosed
+			}
+		}
+	}
+	q.buf[(q.head+q.n)%len(q.buf)] = ar
+	q.n++
+	q.cond.Broadcast()
+	return nil
+}
+
+// run passes the queued records to their handlers until the queue is
+// closed and empty.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for q.n == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.n == 0 {
+			return
+		}
+		ar := q.buf[q.head]
+		q.buf[q.head] = asyncRecord{}
+		q.head = (q.head + 1) % len(q.buf)
+		q.n--
+		q.busy = true
+		q.cond.Broadcast()
+		q.mu.Unlock()
+		err := ar.h.Handle(ar.ctx, ar.r)
+		q.mu.Lock()
+		q.busy = false
+		if err != nil && q.err == nil {
+			q.err = err
+		}
+		q.cond.Broadcast()
+	}
+}
+
+// WithAttrs returns an [AsyncHandler] sharing the queue of h, that passes
+// records to the handler that h passes them to, with the given attributes.
+// If attrs is empty, WithAttrs returns h.
+func (h *AsyncHandler) WithAttrs(attrs []Attr) Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &AsyncHandler{h: h.h.WithAttrs(attrs), q: h.q}
+}
+
+// WithGroup returns an [AsyncHandler] sharing the queue of h, that passes
+// records to the handler that h passes them to, with the given group.
+// If name is empty, WithGroup returns h.
+func (h *AsyncHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &AsyncHandler{h: h.h.WithGroup(name), q: h.q}
+}
+
+// Dropped returns the number of records that the handler, or any handler
+// sharing its queue, dropped because the queue was full.
+func (h *AsyncHandler) Dropped() uint64 {
+	h.q.mu.Lock()
+	defer h.q.mu.Unlock()
+	return h.q.dropped
+}
+
+// Flush waits until the queue is empty and the last record taken from it
+// has been handled, and returns the first error from the handler that
+// records are passed to since the previous call to Flush.
+func (h *AsyncHandler) Flush() error {
+	q := h.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.n > 0 || q.busy {
+		q.cond.Wait()
+	}
+	err := q.err
+	q.err = nil
+	return err
+}
+
+// Close stops accepting records, waits until the queued records have been
+// handled and the background goroutine has stopped, and returns the first
+// error from the handler that records are passed to since the last call to
+// Flush. Close closes all the handlers sharing the queue of h.
+func (h *AsyncHandler) Close() error {
+	q := h.q
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	<-q.done
+	return h.Flush()
+}
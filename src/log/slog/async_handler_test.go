@@ -0,0 +1,218 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateHandler is a handler that records the messages of the records it
+// handles, after signaling started and waiting for gate to be closed.
+type gateHandler struct {
+	started chan string
+	gate    chan struct{}
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{started: make(chan string, 100), gate: make(chan struct{})}
+}
+
+func (h *gateHandler) Enabled(context.Context, Level) bool { return true }
+func (h *gateHandler) WithAttrs([]Attr) Handler            { return h }
+func (h *gateHandler) WithGroup(string) Handler            { return h }
+
+func (h *gateHandler) Handle(ctx context.Context, r Record) error {
+	h.started <- r.Message
+	<-h.gate
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgs = append(h.msgs, r.Message)
+	return nil
+}
+
+func (h *gateHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.msgs)
+}
+
+func TestAsyncHandlerOverflow(t *testing.T) {
+	for _, tt := range []struct {
+		overflow OverflowPolicy
+		want     []string
+		dropped  uint64
+	}{
+		{OverflowBlock, []string{"a", "b", "c", "d"}, 0},
+		{OverflowDropOldest, []string{"a", "c", "d"}, 1},
+		{OverflowDropNewest, []string{"a", "b", "c"}, 1},
+	} {
+		t.Run(tt.overflow.String(), func(t *testing.T) {
+			gh := newGateHandler()
+			h := NewAsyncHandler(gh, &AsyncOptions{Capacity: 2, Overflow: tt.overflow})
+			l := New(h)
+			l.Info("a")
+			<-gh.started // a is being handled, leaving the queue empty
+			l.Info("b")
+			l.Info("c")
+			done := make(chan bool)
+			go func() {
+				l.Info("d") // the queue is full
+				close(done)
+			}()
+			if tt.overflow == OverflowBlock {
+				select {
+				case <-done:
+					t.Fatal("Handle did not block with a full queue")
+				case <-time.After(10 * time.Millisecond):
+				}
+				close(gh.gate)
+				<-done
+			} else {
+				<-done
+				close(gh.gate)
+			}
+			if err := h.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := gh.messages(); !slices.Equal(got, tt.want) {
+				t.Errorf("handled %q, want %q", got, tt.want)
+			}
+			if got := h.Dropped(); got != tt.dropped {
+				t.Errorf("Dropped() = %d, want %d", got, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestAsyncHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewAsyncHandler(NewTextHandler(&buf, &HandlerOptions{ReplaceAttr: removeKeys(TimeKey)}), nil)
+	l := New(h)
+	l.With("a", 1).WithGroup("g").Info("m", "b", 2)
+
+	// The record is cloned, so that its attributes can change after Handle.
+	r := NewRecord(time.Time{}, LevelInfo, "r", 0)
+	for i := range 6 {
+		r.AddAttrs(Int("x", i))
+	}
+	r2 := r
+	h.Handle(context.Background(), r2)
+	r.AddAttrs(Int("y", 0))
+	r2.AddAttrs(Int("z", 0))
+
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "level=INFO msg=m a=1 g.b=2\nlevel=INFO msg=r x=0 x=1 x=2 x=3 x=4 x=5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Handle(context.Background(), r); err == nil {
+		t.Error("Handle after Close succeeded")
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestAsyncHandlerWithEmpty(t *testing.T) {
+	h := NewAsyncHandler(DiscardHandler, nil)
+	defer h.Close()
+	if got := h.WithGroup(""); got != Handler(h) {
+		t.Errorf("WithGroup(\"\") = %p, want the receiver %p", got, h)
+	}
+	if got := h.WithAttrs(nil); got != Handler(h) {
+		t.Errorf("WithAttrs(nil) = %p, want the receiver %p", got, h)
+	}
+	if got := h.WithAttrs([]Attr{}); got != Handler(h) {
+		t.Errorf("WithAttrs([]Attr{}) = %p, want the receiver %p", got, h)
+	}
+	if got := h.WithGroup("g"); got == Handler(h) {
+		t.Error("WithGroup(\"g\") returned the receiver")
+	}
+}
+
+type ctxHandler struct {
+	mu  sync.Mutex
+	err error
+	Handler
+}
+
+func (h *ctxHandler) Handle(ctx context.Context, r Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Value("k") != "v" {
+		h.err = errors.New("context value lost")
+	}
+	if ctx.Err() != nil {
+		h.err = ctx.Err()
+	}
+	return errors.New("handler failed")
+}
+
+func TestAsyncHandlerContextAndErrors(t *testing.T) {
+	ch := &ctxHandler{Handler: NewTextHandler(io.Discard, nil)}
+	h := NewAsyncHandler(ch, nil)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "k", "v"))
+	cancel()
+	New(h).InfoContext(ctx, "m")
+	if err := h.Flush(); err == nil || !strings.Contains(err.Error(), "handler failed") {
+		t.Errorf("Flush = %v, want the handler's error", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("Close = %v, want nil after Flush", err)
+	}
+	if ch.err != nil {
+		t.Errorf("context passed to the handler: %v", ch.err)
+	}
+}
+
+func TestAsyncHandlerCloseUnblocks(t *testing.T) {
+	gh := newGateHandler()
+	h := NewAsyncHandler(gh, &AsyncOptions{Capacity: 1})
+	h.Handle(context.Background(), NewRecord(time.Time{}, LevelInfo, "a", 0))
+	<-gh.started
+	h.Handle(context.Background(), NewRecord(time.Time{}, LevelInfo, "b", 0))
+	errc := make(chan error)
+	go func() {
+		errc <- h.Handle(context.Background(), NewRecord(time.Time{}, LevelInfo, "c", 0))
+	}()
+	closed := make(chan error)
+	go func() { closed <- h.Close() }()
+	if err := <-errc; err == nil {
+		t.Error("Handle blocked on a full queue succeeded after Close")
+	}
+	close(gh.gate)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gh.messages(), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+}
+
+func BenchmarkAsyncHandler(b *testing.B) {
+	h := NewAsyncHandler(NewTextHandler(io.Discard, nil), &AsyncOptions{Overflow: OverflowDropNewest})
+	defer h.Close()
+	l := New(h)
+	b.ReportAllocs()
+	for b.Loop() {
+		l.Info("message", "a", 1, "b", "two")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"slices"
+)
+
+type contextAttrsKey struct{}
+
+// ContextWithAttrs returns a copy of ctx with the given attributes attached,
+// after those already attached to ctx.
+//
+// The built-in handlers add the attributes attached to the context of a log
+// call to its output if [HandlerOptions.AddContextAttrs] is set. Other
+// handlers can retrieve them with [AttrsFromContext].
+func ContextWithAttrs(ctx context.Context, attrs ...Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, contextAttrsKey{}, slices.Concat(AttrsFromContext(ctx), attrs))
+}
+
+// AttrsFromContext returns the attributes attached to ctx with
+// [ContextWithAttrs]. The returned slice must not be modified.
+func AttrsFromContext(ctx context.Context) []Attr {
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]Attr)
+	return slices.Clip(attrs)
+}
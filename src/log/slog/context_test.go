@@ -0,0 +1,97 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextWithAttrs(t *testing.T) {
+	ctx := context.Background()
+	if got := AttrsFromContext(ctx); got != nil {
+		t.Errorf("AttrsFromContext(Background) = %v, want nil", got)
+	}
+	if ContextWithAttrs(ctx) != ctx {
+		t.Error("ContextWithAttrs without attributes returned a new context")
+	}
+	ctx1 := ContextWithAttrs(ctx, String("a", "1"))
+	ctx2 := ContextWithAttrs(ctx1, Int("b", 2))
+	ctx3 := ContextWithAttrs(ctx1, Int("c", 3))
+	for _, tt := range []struct {
+		ctx  context.Context
+		want []Attr
+	}{
+		{ctx1, []Attr{String("a", "1")}},
+		{ctx2, []Attr{String("a", "1"), Int("b", 2)}},
+		{ctx3, []Attr{String("a", "1"), Int("c", 3)}},
+	} {
+		if got := AttrsFromContext(tt.ctx); !slices.EqualFunc(got, tt.want, Attr.Equal) {
+			t.Errorf("got %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestHandlerContextAttrs(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(), String("req", "r1"), Group("trace", String("span", "s1")))
+	for _, tt := range []struct {
+		name string
+		opts HandlerOptions
+		json bool
+		want string
+	}{
+		{
+			name: "text",
+			opts: HandlerOptions{AddContextAttrs: true},
+			want: `level=INFO msg=m req=r1 trace.span=s1 a=1 g.b=2`,
+		},
+		{
+			name: "json",
+			opts: HandlerOptions{AddContextAttrs: true},
+			json: true,
+			want: `{"level":"INFO","msg":"m","req":"r1","trace":{"span":"s1"},"a":1,"g":{"b":2}}`,
+		},
+		{
+			name: "disabled",
+			want: `level=INFO msg=m a=1 g.b=2`,
+		},
+		{
+			name: "replace",
+			opts: HandlerOptions{
+				AddContextAttrs: true,
+				ReplaceAttr: func(groups []string, a Attr) Attr {
+					if a.Key == "req" && len(groups) == 0 {
+						return String("request", a.Value.String())
+					}
+					return a
+				},
+			},
+			want: `level=INFO msg=m request=r1 trace.span=s1 a=1 g.b=2`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var h Handler
+			if tt.json {
+				h = NewJSONHandler(&buf, &tt.opts)
+			} else {
+				h = NewTextHandler(&buf, &tt.opts)
+			}
+			h = h.WithAttrs([]Attr{Int("a", 1)}).WithGroup("g")
+			r := NewRecord(time.Time{}, LevelInfo, "m", 0)
+			r.AddAttrs(Int("b", 2))
+			if err := h.Handle(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -180,6 +180,15 @@ in "Context" do. For example,
 
 It is recommended to pass a context to an output method if one is available.
 
+[ContextWithAttrs] attaches attributes to a context, so that they need not be
+passed down with the loggers of a request, for example. The built-in handlers
+add them to the output of the log calls made with that context if
+[HandlerOptions.AddContextAttrs] is set:
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{AddContextAttrs: true}))
+	ctx = slog.ContextWithAttrs(ctx, slog.String("request_id", id))
+	logger.InfoContext(ctx, "handled") // includes request_id
+
 # Attrs and Values
 
 An [Attr] is a key-value pair. The Logger output methods accept Attrs as well as
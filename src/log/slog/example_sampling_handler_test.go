@@ -0,0 +1,46 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func ExampleSamplingHandler() {
+	removeTime := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+
+	// The console gets a sample of the records, and the audit log all of them.
+	var console, audit bytes.Buffer
+	sampled := slog.NewSamplingHandler(
+		slog.NewTextHandler(&console, &slog.HandlerOptions{ReplaceAttr: removeTime}),
+		&slog.SamplingOptions{First: 2, Thereafter: 3, Period: time.Hour, CountKey: "count"},
+	)
+	logger := slog.New(slog.NewMultiHandler(
+		sampled,
+		slog.NewJSONHandler(&audit, &slog.HandlerOptions{ReplaceAttr: removeTime}),
+	))
+
+	for i := range 6 {
+		logger.Error("connection refused", "attempt", i)
+	}
+
+	os.Stdout.WriteString(console.String())
+	fmt.Println("audit records:", bytes.Count(audit.Bytes(), []byte("\n")))
+
+	// Output:
+	// level=ERROR msg="connection refused" attempt=0
+	// level=ERROR msg="connection refused" attempt=1
+	// level=ERROR msg="connection refused" attempt=4 count=3
+	// audit records: 6
+}
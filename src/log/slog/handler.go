@@ -170,6 +170,11 @@ type HandlerOptions struct {
 	// integer seconds since the Unix epoch), sanitize personal information, or
 	// remove attributes from the output.
 	ReplaceAttr func(groups []string, a Attr) Attr
+
+	// AddContextAttrs causes the handler to add the attributes attached to
+	// the context of the log call with [ContextWithAttrs] to the output,
+	// after the built-in attributes and outside any group.
+	AddContextAttrs bool
 }
 
 // Keys for "built-in" attributes.
@@ -267,7 +272,7 @@ func (h *commonHandler) withGroup(name string) *commonHandler {
 
 // handle is the internal implementation of Handler.Handle
 // used by TextHandler and JSONHandler.
-func (h *commonHandler) handle(r Record) error {
+func (h *commonHandler) handle(ctx context.Context, r Record) error {
 	state := h.newHandleState(buffer.New(), true, "")
 	defer state.free()
 	if h.json {
@@ -313,6 +318,12 @@ func (h *commonHandler) handle(r Record) error {
 	} else {
 		state.appendAttr(String(key, msg))
 	}
+	// Attributes from the context are not in a group either.
+	if h.opts.AddContextAttrs && ctx != nil {
+		for _, a := range AttrsFromContext(ctx) {
+			state.appendAttr(a)
+		}
+	}
 	state.groups = stateGroups // Restore groups passed to ReplaceAttrs.
 	state.appendNonBuiltIns(r)
 	state.buf.WriteByte('\n')
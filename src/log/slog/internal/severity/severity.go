@@ -0,0 +1,24 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package severity maps slog levels to syslog severities, for the handlers
+// of log/slog/syslog and log/slog/journal.
+package severity
+
+import "log/slog"
+
+// Of returns the syslog severity of a level.
+func Of(l slog.Level) int {
+	switch {
+	case l >= slog.LevelError+4:
+		return 2 // LOG_CRIT
+	case l >= slog.LevelError:
+		return 3 // LOG_ERR
+	case l >= slog.LevelWarn:
+		return 4 // LOG_WARNING
+	case l >= slog.LevelInfo:
+		return 6 // LOG_INFO
+	}
+	return 7 // LOG_DEBUG
+}
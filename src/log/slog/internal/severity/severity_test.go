@@ -0,0 +1,27 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package severity
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	for _, tt := range []struct {
+		level slog.Level
+		want  int
+	}{
+		{slog.LevelDebug, 7},
+		{slog.LevelInfo, 6},
+		{slog.LevelWarn, 4},
+		{slog.LevelError, 3},
+		{slog.LevelError + 4, 2},
+	} {
+		if got := Of(tt.level); got != tt.want {
+			t.Errorf("Of(%v) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package journal provides a [slog.Handler] that writes records to the
+// systemd journal, with the native protocol of journald.
+//
+// Each record becomes a journal entry whose MESSAGE field is the message
+// of the record, and whose PRIORITY field is the syslog severity of its
+// level. The attributes of the record become fields too, named after their
+// keys and the groups they are in, joined with underscores, in upper case,
+// with the characters that field names cannot hold replaced with
+// underscores. For example, the attribute "id" in the group "request"
+// becomes the field REQUEST_ID. Names that do not start with a letter,
+// such as those of the trusted fields that journald adds, and the names of
+// the fields that the handler sets itself, such as MESSAGE and PRIORITY,
+// are prefixed with F_, so that attributes cannot override them.
+//
+// The package is only implemented on Unix systems.
+package journal
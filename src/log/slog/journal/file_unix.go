@@ -0,0 +1,55 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package journal
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// tooLarge reports whether err means that an entry is too large to be
+// sent in a datagram.
+func tooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendFile sends entry to journald in an unlinked temporary file, whose
+// descriptor it passes over c.
+func sendFile(c *net.UnixConn, entry []byte) error {
+	dir := "/dev/shm"
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(entry); err != nil {
+		return err
+	}
+	// WriteMsgUnix refuses to write to connected datagram sockets, so send
+	// the descriptor with the socket of c.
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(f.Fd()))
+	werr := rc.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
@@ -0,0 +1,271 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package journal
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"log/slog/internal/severity"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSocket is the path of the socket on which journald listens for
+// entries.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// Options are options for a [Handler].
+// A zero Options consists entirely of default values.
+type Options struct {
+	// Level reports the minimum record level that will be logged.
+	// If Level is nil, the handler assumes slog.LevelInfo.
+	Level slog.Leveler
+
+	// Identifier is the SYSLOG_IDENTIFIER field of the entries.
+	// If Identifier is empty, it is the base name of os.Args[0].
+	Identifier string
+
+	// AddSource causes the handler to add the CODE_FILE, CODE_LINE and
+	// CODE_FUNC fields, with the source code position of the log
+	// statement, to the entries.
+	AddSource bool
+}
+
+// A Handler is a [slog.Handler] that writes records to the journal.
+// Each record is sent in a single datagram, or, if the record is too large
+// for one, in a temporary file whose descriptor is passed to journald.
+type Handler struct {
+	opts   Options
+	fields []byte // the fields of the attributes added with WithAttrs
+	groups []string
+	s      *sender
+}
+
+// A sender sends entries to journald.
+type sender struct {
+	mu     sync.Mutex
+	path   string
+	c      *net.UnixConn // nil if not connected
+	closed bool
+}
+
+var errClosed = errors.New("journal: handler is closed")
+
+// Dial connects to journald on the Unix domain socket at path, or at
+// [DefaultSocket] if path is empty, and returns a [Handler] that writes to
+// it. If opts is nil, the default options are used.
+//
+// If sending an entry fails, the handler reconnects and tries again once.
+// The handler, and the handlers derived from it, must be closed with
+// [Handler.Close].
+func Dial(path string, opts *Options) (*Handler, error) {
+	if path == "" {
+		path = DefaultSocket
+	}
+	s := &sender{path: path}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	h := &Handler{s: s}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Identifier == "" && len(os.Args) > 0 {
+		h.opts.Identifier = filepath.Base(os.Args[0])
+	}
+	return h, nil
+}
+
+// Close closes the connection of the handler to journald. It closes the
+// connection of all the handlers derived from the same call to [Dial].
+func (h *Handler) Close() error {
+	s := h.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.c == nil {
+		return nil
+	}
+	err := s.c.Close()
+	s.c = nil
+	return err
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a new [Handler] whose entries include both the fields
+// of h and those of attrs.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.fields = h.fields[:len(h.fields):len(h.fields)]
+	for _, a := range attrs {
+		h2.fields = appendAttr(h2.fields, h.groups, a)
+	}
+	return &h2
+}
+
+// WithGroup returns a new [Handler] that puts the attributes of the records
+// it handles, and those added with WithAttrs, in the given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+// Handle sends r to journald as an entry.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	buf := make([]byte, 0, 256)
+	buf = appendField(buf, "MESSAGE", r.Message)
+	buf = appendField(buf, "PRIORITY", strconv.Itoa(severity.Of(r.Level)))
+	if h.opts.Identifier != "" {
+		buf = appendField(buf, "SYSLOG_IDENTIFIER", h.opts.Identifier)
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		fs := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := fs.Next()
+		buf = appendField(buf, "CODE_FILE", f.File)
+		buf = appendField(buf, "CODE_LINE", strconv.Itoa(f.Line))
+		buf = appendField(buf, "CODE_FUNC", f.Function)
+	}
+	buf = append(buf, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		buf = appendAttr(buf, h.groups, a)
+		return true
+	})
+	return h.s.send(buf)
+}
+
+// appendAttr appends the fields of the attribute a in the given groups.
+func appendAttr(buf []byte, groups []string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, a := range a.Value.Group() {
+			buf = appendAttr(buf, groups, a)
+		}
+		return buf
+	}
+	name := fieldName(append(groups[:len(groups):len(groups)], a.Key))
+	if name == "" {
+		return buf
+	}
+	var value string
+	if a.Value.Kind() == slog.KindTime {
+		value = a.Value.Time().Format(time.RFC3339Nano)
+	} else {
+		value = a.Value.String()
+	}
+	return appendField(buf, name, value)
+}
+
+// handlerFields are the names of the fields that the handler sets.
+var handlerFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// fieldName returns the name of the field of an attribute with the given
+// path of groups and key: upper-case letters, digits and underscores,
+// starting with a letter, and at most 64 characters long.
+func fieldName(path []string) string {
+	b := []byte(strings.Join(path, "_"))
+	for i, c := range b {
+		switch {
+		case 'a' <= c && c <= 'z':
+			b[i] = c - 'a' + 'A'
+		case 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		default:
+			b[i] = '_'
+		}
+	}
+	name := string(b)
+	if name != "" && (name[0] < 'A' || name[0] > 'Z' || handlerFields[name]) {
+		name = "F_" + name
+	}
+	return name[:min(len(name), 64)]
+}
+
+// appendField appends a field to an entry, in the binary form if the value
+// spans several lines.
+func appendField(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf = append(buf, '=')
+	} else {
+		buf = append(buf, '\n')
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+	}
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+func (s *sender) connect() error {
+	c, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: s.path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	s.c = c
+	return nil
+}
+
+// send sends an entry, reconnecting if needed.
+func (s *sender) send(entry []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errClosed
+	}
+	if s.c != nil {
+		if err := s.write(entry); err == nil {
+			return nil
+		}
+		s.c.Close()
+		s.c = nil
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.write(entry)
+}
+
+func (s *sender) write(entry []byte) error {
+	_, err := s.c.Write(entry)
+	if err != nil && tooLarge(err) {
+		return sendFile(s.c, entry)
+	}
+	return err
+}
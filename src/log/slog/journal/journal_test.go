@@ -0,0 +1,220 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listen listens for entries on a socket in a temporary directory.
+func listen(t *testing.T) (*net.UnixConn, string) {
+	path := filepath.Join(t.TempDir(), "socket")
+	c, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, path
+}
+
+// receive receives an entry, from a datagram or from a passed file, and
+// returns its fields.
+func receive(t *testing.T, c *net.UnixConn) [][2]string {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	b := make([]byte, 1<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := c.ReadMsgUnix(b, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = b[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := os.NewFile(uintptr(fds[0]), "entry")
+		defer f.Close()
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if b, err = io.ReadAll(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return parse(t, b)
+}
+
+// parse parses the fields of an entry.
+func parse(t *testing.T, b []byte) [][2]string {
+	t.Helper()
+	var fields [][2]string
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			t.Fatalf("bad entry %q", b)
+		}
+		name := string(b[:i])
+		var value []byte
+		if b[i] == '=' {
+			b = b[i+1:]
+			j := bytes.IndexByte(b, '\n')
+			if j < 0 {
+				t.Fatalf("bad entry %q", b)
+			}
+			value, b = b[:j], b[j+1:]
+		} else {
+			b = b[i+1:]
+			n := binary.LittleEndian.Uint64(b)
+			b = b[8:]
+			value, b = b[:n], b[n+1:]
+		}
+		fields = append(fields, [2]string{name, string(value)})
+	}
+	return fields
+}
+
+func TestHandler(t *testing.T) {
+	c, path := listen(t)
+	h, err := Dial(path, &Options{Identifier: "test", Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := slog.New(h).With("req", "r1", "message", "spoofed", "priority", 0)
+	l = l.WithGroup("http")
+	l.Warn("hello", "method", "GET", slog.Group("resp", "status", 200), "multi", "a\nb", "9x", 1, "_k", "v")
+	want := [][2]string{
+		{"MESSAGE", "hello"},
+		{"PRIORITY", "4"},
+		{"SYSLOG_IDENTIFIER", "test"},
+		{"REQ", "r1"},
+		{"F_MESSAGE", "spoofed"},
+		{"F_PRIORITY", "0"},
+		{"HTTP_METHOD", "GET"},
+		{"HTTP_RESP_STATUS", "200"},
+		{"HTTP_MULTI", "a\nb"},
+		{"HTTP_9X", "1"},
+		{"HTTP__K", "v"},
+	}
+	if got := receive(t, c); !slices.Equal(got, want) {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestAddSource(t *testing.T) {
+	c, path := listen(t)
+	h, err := Dial(path, &Options{AddSource: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	slog.New(h).Info("m")
+	fields := map[string]string{}
+	for _, f := range receive(t, c) {
+		fields[f[0]] = f[1]
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journal_test.go") || fields["CODE_LINE"] == "" ||
+		!strings.HasSuffix(fields["CODE_FUNC"], ".TestAddSource") {
+		t.Errorf("got %v", fields)
+	}
+}
+
+func TestLargeEntry(t *testing.T) {
+	c, path := listen(t)
+	h, err := Dial(path, &Options{Identifier: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	msg := strings.Repeat("x", 4<<20)
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)); err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, c)
+	if len(got) == 0 || got[0][0] != "MESSAGE" || got[0][1] != msg {
+		t.Errorf("large entry not received")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	c, path := listen(t)
+	h, err := Dial(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := slog.New(h)
+	l.Info("one")
+	receive(t, c)
+
+	// Restart the listener, as when journald restarts.
+	c.Close()
+	os.Remove(path)
+	c, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	l.Info("two")
+	if got := receive(t, c); got[0][1] != "two" {
+		t.Errorf("got %q", got)
+	}
+
+	h.Close()
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "m", 0)); err != errClosed {
+		t.Errorf("Handle after Close: got %v, want %v", err, errClosed)
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for _, tt := range []struct {
+		path []string
+		want string
+	}{
+		{[]string{"key"}, "KEY"},
+		{[]string{"Request", "ID"}, "REQUEST_ID"},
+		{[]string{"a.b-c"}, "A_B_C"},
+		{[]string{"_x"}, "F__X"},
+		{[]string{"_pid"}, "F__PID"},
+		{[]string{"1x"}, "F_1X"},
+		{[]string{"_"}, "F__"},
+		{[]string{"message"}, "F_MESSAGE"},
+		{[]string{"Priority"}, "F_PRIORITY"},
+		{[]string{"code", "file"}, "F_CODE_FILE"},
+		{[]string{"message", "id"}, "MESSAGE_ID"},
+		{[]string{""}, ""},
+		{[]string{strings.Repeat("a", 70)}, strings.Repeat("A", 64)},
+	} {
+		if got := fieldName(tt.path); got != tt.want {
+			t.Errorf("fieldName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDialError(t *testing.T) {
+	if _, err := Dial(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("Dial to a missing socket succeeded")
+	}
+}
@@ -85,8 +85,8 @@ func (h *JSONHandler) WithGroup(name string) Handler {
 // Instead, the error message is formatted as a string.
 //
 // Each call to Handle results in a single serialized call to io.Writer.Write.
-func (h *JSONHandler) Handle(_ context.Context, r Record) error {
-	return h.commonHandler.handle(r)
+func (h *JSONHandler) Handle(ctx context.Context, r Record) error {
+	return h.commonHandler.handle(ctx, r)
 }
 
 // Adapted from time.Time.MarshalJSON to avoid allocation.
@@ -0,0 +1,172 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SamplingOptions are options for a [SamplingHandler].
+// A zero SamplingOptions consists entirely of default values, which
+// handle one record per second for each level and message.
+type SamplingOptions struct {
+	// First is the number of records with the same level and message that
+	// are handled in each period. If First is zero, it is 1.
+	First int
+
+	// Thereafter is the sampling rate of the records after the first ones
+	// in each period: every Thereafter-th record is handled. If Thereafter
+	// is zero, the records after the first ones are dropped.
+	Thereafter int
+
+	// Period is the length of the periods over which records are counted.
+	// If Period is zero, it is one second.
+	Period time.Duration
+
+	// CountKey, if not empty, is the key of an attribute that the handler
+	// adds to a record standing for records that were dropped: its value
+	// is the number of records with the same level and message that the
+	// record stands for, itself included, since the last one handled in
+	// the same period. Records dropped at the end of a period are not
+	// reported by a later record; [SamplingHandler.Dropped] counts them.
+	CountKey string
+}
+
+// A SamplingHandler is a [Handler] that passes only a sample of the
+// records with the same level and message to another handler, to limit
+// the output of logging that repeats itself.
+//
+// In each period, the handler passes the first records with a given level
+// and message, and then a fraction of them. Periods are measured with the
+// times of the records, or the current time for records without one. The
+// handler forgets the counts of each period when the next one starts, so
+// that it only keeps the levels and messages of the current period.
+//
+// The handlers returned by the WithAttrs and WithGroup methods of a
+// SamplingHandler share its counts, so that records are sampled together
+// whatever the attributes of the logger that they are logged with.
+// To sample records for some outputs and not others, combine handlers with
+// [NewMultiHandler].
+type SamplingHandler struct {
+	h Handler
+	s *sampler
+}
+
+type sampler struct {
+	first, thereafter int
+	period            time.Duration
+	countKey          string
+
+	mu      sync.Mutex
+	start   time.Time // the start of the current period
+	counts  map[sampleKey]*sampleCount
+	dropped uint64
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCount struct {
+	n       int // records in the current period
+	dropped int // records dropped in the current period since the last one handled
+}
+
+// NewSamplingHandler creates a [SamplingHandler] that passes a sample of
+// the records it handles to h, using the given options. If opts is nil,
+// the default options are used.
+func NewSamplingHandler(h Handler, opts *SamplingOptions) *SamplingHandler {
+	if opts == nil {
+		opts = &SamplingOptions{}
+	}
+	s := &sampler{
+		first:      max(opts.First, 1),
+		thereafter: max(opts.Thereafter, 0),
+		period:     opts.Period,
+		countKey:   opts.CountKey,
+		counts:     make(map[sampleKey]*sampleCount),
+	}
+	if s.period <= 0 {
+		s.period = time.Second
+	}
+	return &SamplingHandler{h: h, s: s}
+}
+
+// Enabled reports whether the handler that records are passed to handles
+// records at the given level.
+func (h *SamplingHandler) Enabled(ctx context.Context, level Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle passes r to the other handler if r is part of the sample, and
+// drops it otherwise.
+func (h *SamplingHandler) Handle(ctx context.Context, r Record) error {
+	n, ok := h.s.sample(r)
+	if !ok {
+		return nil
+	}
+	if h.s.countKey != "" && n > 1 {
+		r = r.Clone()
+		r.AddAttrs(Int(h.s.countKey, n))
+	}
+	return h.h.Handle(ctx, r)
+}
+
+// sample reports whether r is part of the sample and, if so, the number of
+// records that r stands for.
+func (s *sampler) sample(r Record) (int, bool) {
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !now.Before(s.start.Add(s.period)) {
+		s.start = now
+		// Allocate a new map, rather than clearing the old one, so that a
+		// burst of distinct messages does not keep its memory.
+		s.counts = make(map[sampleKey]*sampleCount)
+	}
+	k := sampleKey{r.Level, r.Message}
+	c := s.counts[k]
+	if c == nil {
+		c = &sampleCount{}
+		s.counts[k] = c
+	}
+	c.n++
+	if c.n <= s.first || s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0 {
+		n := c.dropped + 1
+		c.dropped = 0
+		return n, true
+	}
+	c.dropped++
+	s.dropped++
+	return 0, false
+}
+
+// WithAttrs returns a [SamplingHandler] sharing the counts of h, that
+// passes records to the handler that h passes them to, with the given
+// attributes.
+func (h *SamplingHandler) WithAttrs(attrs []Attr) Handler {
+	return &SamplingHandler{h: h.h.WithAttrs(attrs), s: h.s}
+}
+
+// WithGroup returns a [SamplingHandler] sharing the counts of h, that
+// passes records to the handler that h passes them to, with the given
+// group.
+func (h *SamplingHandler) WithGroup(name string) Handler {
+	return &SamplingHandler{h: h.h.WithGroup(name), s: h.s}
+}
+
+// Dropped returns the number of records that the handler, or any handler
+// sharing its counts, dropped.
+func (h *SamplingHandler) Dropped() uint64 {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	return h.s.dropped
+}
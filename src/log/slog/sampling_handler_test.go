@@ -0,0 +1,131 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	type rec struct {
+		at    time.Duration
+		level Level
+		msg   string
+	}
+	repeat := func(n int, r rec) []rec {
+		rs := make([]rec, n)
+		for i := range rs {
+			rs[i] = r
+			rs[i].at += time.Duration(i) * time.Millisecond
+		}
+		return rs
+	}
+
+	for _, tt := range []struct {
+		name    string
+		opts    *SamplingOptions
+		recs    []rec
+		want    string
+		dropped uint64
+	}{
+		{
+			name:    "default",
+			recs:    repeat(5, rec{0, LevelInfo, "a"}),
+			want:    "level=INFO msg=a\n",
+			dropped: 4,
+		},
+		{
+			name:    "first and thereafter",
+			opts:    &SamplingOptions{First: 2, Thereafter: 3},
+			recs:    repeat(9, rec{0, LevelInfo, "a"}),
+			want:    "level=INFO msg=a\nlevel=INFO msg=a\nlevel=INFO msg=a\nlevel=INFO msg=a\n",
+			dropped: 5,
+		},
+		{
+			name: "keys",
+			recs: []rec{{0, LevelInfo, "a"}, {0, LevelInfo, "b"}, {0, LevelWarn, "a"}, {0, LevelInfo, "a"}},
+			want: "level=INFO msg=a\nlevel=INFO msg=b\nlevel=WARN msg=a\n",
+			// The last record repeats the first.
+			dropped: 1,
+		},
+		{
+			name:    "periods",
+			opts:    &SamplingOptions{Period: time.Minute},
+			recs:    []rec{{0, LevelInfo, "a"}, {59 * time.Second, LevelInfo, "a"}, {61 * time.Second, LevelInfo, "a"}},
+			want:    "level=INFO msg=a\nlevel=INFO msg=a\n",
+			dropped: 1,
+		},
+		{
+			name: "count",
+			opts: &SamplingOptions{First: 1, Thereafter: 2, Period: time.Minute, CountKey: "count"},
+			recs: append(repeat(4, rec{0, LevelInfo, "a"}), repeat(3, rec{2 * time.Minute, LevelInfo, "a"})...),
+			// The record dropped at the end of the first period is not
+			// reported in the second one.
+			want: "level=INFO msg=a\nlevel=INFO msg=a count=2\n" +
+				"level=INFO msg=a\nlevel=INFO msg=a count=2\n",
+			dropped: 3,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewSamplingHandler(NewTextHandler(&buf, &HandlerOptions{ReplaceAttr: removeKeys(TimeKey)}), tt.opts)
+			for _, r := range tt.recs {
+				if err := h.Handle(context.Background(), NewRecord(t0.Add(r.at), r.level, r.msg, 0)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			if got := h.Dropped(); got != tt.dropped {
+				t.Errorf("Dropped() = %d, want %d", got, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestSamplingHandlerShared(t *testing.T) {
+	var sampled, all bytes.Buffer
+	opts := &HandlerOptions{ReplaceAttr: removeKeys(TimeKey)}
+	sh := NewSamplingHandler(NewTextHandler(&sampled, opts), &SamplingOptions{Period: time.Hour})
+	l := New(NewMultiHandler(sh, NewTextHandler(&all, opts)))
+	for i := range 3 {
+		// Loggers with different attributes share the counts.
+		l.With("req", i).WithGroup("g").Error("failed", "n", i)
+	}
+	if got, want := sampled.String(), "level=ERROR msg=failed req=0 g.n=0\n"; got != want {
+		t.Errorf("sampled output:\n%s\nwant\n%s", got, want)
+	}
+	if got := strings.Count(all.String(), "\n"); got != 3 {
+		t.Errorf("unsampled output has %d lines, want 3", got)
+	}
+	if sh.Dropped() != 2 {
+		t.Errorf("Dropped() = %d, want 2", sh.Dropped())
+	}
+}
+
+func TestSamplingHandlerForgetsPeriods(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewSamplingHandler(DiscardHandler, &SamplingOptions{Period: time.Minute})
+	for i := range 100 {
+		// Each message is dropped once.
+		for range 2 {
+			h.Handle(context.Background(), NewRecord(t0, LevelInfo, fmt.Sprint("m", i), 0))
+		}
+	}
+	h.Handle(context.Background(), NewRecord(t0.Add(time.Minute), LevelInfo, "m", 0))
+	if got := len(h.s.counts); got != 1 {
+		t.Errorf("%d counts after a new period, want 1", got)
+	}
+	if got := h.Dropped(); got != 100 {
+		t.Errorf("Dropped() = %d, want 100", got)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"errors"
+	"net"
+)
+
+// Dial establishes a connection to a syslog server at the address raddr on
+// the named network, such as "udp", "tcp" or "unixgram", and returns a
+// [Handler] that writes to it. If network is empty, Dial connects to the
+// local syslog server through a Unix domain socket. If opts is nil, the
+// default options are used.
+//
+// Messages on stream connections, such as "tcp" and "unix" ones, are
+// framed by octet counting. If writing a message fails, the handler
+// reconnects and tries again once. The handler, and the handlers derived
+// from it, must be closed with [Handler.Close].
+func Dial(network, raddr string, opts *Options) (*Handler, error) {
+	if opts != nil && (opts.Facility < Kern || opts.Facility > Local7) {
+		return nil, errFacility
+	}
+	c := &conn{network: network, raddr: raddr}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return newHandler(&writer{c: c}, opts), nil
+}
+
+// Close closes the connection of the handler, if it was created by [Dial].
+// It closes the connection of all the handlers derived from the same call
+// to Dial.
+func (h *Handler) Close() error {
+	w := h.w
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.c == nil {
+		return nil
+	}
+	w.c.closed = true
+	if w.c.c == nil {
+		return nil
+	}
+	err := w.c.c.Close()
+	w.c.c = nil
+	return err
+}
+
+// A conn is a connection to a syslog server.
+type conn struct {
+	network, raddr string
+	c              net.Conn // nil if not connected
+	stream         bool
+	closed         bool
+}
+
+var errClosed = errors.New("syslog: handler is closed")
+
+func (c *conn) connect() error {
+	if c.network == "" {
+		return c.connectLocal()
+	}
+	nc, err := net.Dial(c.network, c.raddr)
+	if err != nil {
+		return err
+	}
+	c.c = nc
+	c.stream = isStream(c.network)
+	return nil
+}
+
+// connectLocal connects to the local syslog server.
+func (c *conn) connectLocal() error {
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if nc, err := net.Dial(network, path); err == nil {
+				c.c = nc
+				c.stream = network == "unix"
+				return nil
+			}
+		}
+	}
+	return errors.New("syslog: no local syslog server")
+}
+
+func isStream(network string) bool {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+// write writes msg, reconnecting if needed.
+func (c *conn) write(msg []byte, octetCounting bool) error {
+	if c.closed {
+		return errClosed
+	}
+	if c.c != nil {
+		if err := c.writeConn(msg, octetCounting); err == nil {
+			return nil
+		}
+		c.c.Close()
+		c.c = nil
+	}
+	if err := c.connect(); err != nil {
+		return err
+	}
+	return c.writeConn(msg, octetCounting)
+}
+
+func (c *conn) writeConn(msg []byte, octetCounting bool) error {
+	if octetCounting || c.stream {
+		msg = frame(msg)
+	}
+	_, err := c.c.Write(msg)
+	return err
+}
@@ -0,0 +1,400 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package syslog provides a [slog.Handler] that writes records as syslog
+// messages in the format of RFC 5424.
+//
+// Unlike the messages of package [log/syslog], which are unstructured, the
+// messages carry the attributes of records as structured data: each group
+// of attributes becomes an SD-ELEMENT whose ID is the name of the group,
+// and the attributes outside groups form an SD-ELEMENT whose ID is
+// [Options.DefaultID]. Attributes in nested groups become parameters whose
+// names are the keys of the nested groups and the attribute, separated by
+// dots. For example,
+//
+//	logger.Info("request", "user", "gopher", slog.Group("http", "method", "GET", slog.Group("req", "size", 10)))
+//
+// yields the structured data
+//
+//	[attrs user="gopher"][http method="GET" req.size="10"]
+//
+// The MSG part of a message is the message of the record. If it holds
+// non-ASCII characters and is valid UTF-8, it starts with a byte order mark,
+// as RFC 5424 requires of UTF-8 messages; otherwise, it is sent as is.
+//
+// A [Handler] writes to an [io.Writer], or to a connection to a syslog
+// server opened by [Dial].
+package syslog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"log/slog/internal/severity"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// The Facility is the part of the system that messages come from.
+type Facility int
+
+// Facilities, with the values of the LOG_ constants of
+// /usr/include/sys/syslog.h.
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+	_ // unused
+	_ // unused
+	_ // unused
+	_ // unused
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// Options are options for a [Handler].
+// A zero Options consists entirely of default values.
+type Options struct {
+	// Level reports the minimum record level that will be logged.
+	// If Level is nil, the handler assumes slog.LevelInfo.
+	Level slog.Leveler
+
+	// Facility is the facility of the messages, at most Local7. The zero
+	// value, Kern, selects User, as only the kernel logs kernel messages.
+	Facility Facility
+
+	// Hostname is the HOSTNAME of the messages.
+	// If Hostname is empty, the handler uses [os.Hostname].
+	Hostname string
+
+	// AppName is the APP-NAME of the messages.
+	// If AppName is empty, it is the base name of os.Args[0].
+	AppName string
+
+	// MsgID is the MSGID of the messages, if not empty.
+	MsgID string
+
+	// EnterpriseID, if not empty, is the private enterprise number
+	// appended to the IDs of SD-ELEMENTs after an "@", as RFC 5424
+	// requires for the IDs that are not registered with IANA.
+	EnterpriseID string
+
+	// DefaultID is the ID of the SD-ELEMENT holding the attributes outside
+	// groups. If DefaultID is empty, it is "attrs".
+	DefaultID string
+
+	// OctetCounting causes the handler to prefix each message with its
+	// length and a space, as RFC 6587 specifies for stream transports.
+	// The handlers created by [Dial] do so on stream connections whether
+	// it is set or not.
+	OctetCounting bool
+}
+
+// A Handler is a [slog.Handler] that writes records as RFC 5424 syslog
+// messages. Each message is written with a single call to Write.
+type Handler struct {
+	opts   Options
+	fields string // the header fields after the TIMESTAMP
+	attrs  []groupedAttr
+	groups []string
+	w      *writer
+}
+
+// A groupedAttr is an attribute added with WithAttrs, with the groups that
+// were open.
+type groupedAttr struct {
+	groups []string
+	a      slog.Attr
+}
+
+// A writer writes messages, serialized.
+type writer struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  *conn // non-nil if the handler was created by Dial
+}
+
+// NewHandler creates a [Handler] that writes to w, using the given
+// options. If opts is nil, the default options are used.
+func NewHandler(w io.Writer, opts *Options) *Handler {
+	return newHandler(&writer{w: w}, opts)
+}
+
+func newHandler(w *writer, opts *Options) *Handler {
+	h := &Handler{w: w}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Facility == Kern {
+		h.opts.Facility = User
+	}
+	hostname := h.opts.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	appName := h.opts.AppName
+	if appName == "" && len(os.Args) > 0 {
+		appName = filepath.Base(os.Args[0])
+	}
+	if h.opts.DefaultID == "" {
+		h.opts.DefaultID = "attrs"
+	}
+	h.fields = headerField(hostname, 255) + " " +
+		headerField(appName, 48) + " " +
+		headerField(strconv.Itoa(os.Getpid()), 128) + " " +
+		headerField(h.opts.MsgID, 32) + " "
+	return h
+}
+
+// headerField returns s as a header field of at most n characters,
+// replacing the characters that are not printable US-ASCII, or "-" if s is
+// empty.
+func headerField(s string, n int) string {
+	if s == "" {
+		return "-"
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	return string(b[:min(len(b), n)])
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a new [Handler] whose messages include both the
+// attributes of h and attrs.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = make([]groupedAttr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(h2.attrs, h.attrs)
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, groupedAttr{h.groups, a})
+	}
+	return &h2
+}
+
+// WithGroup returns a new [Handler] that puts the attributes of the records
+// it handles, and those added with WithAttrs, in the given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+var errFacility = errors.New("syslog: invalid facility")
+
+// Handle writes r as a syslog message.
+// It returns an error if the facility of h is invalid.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	if h.opts.Facility < Kern || h.opts.Facility > Local7 {
+		return errFacility
+	}
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(h.opts.Facility)<<3|int64(severity.Of(r.Level)), 10)
+	buf = append(buf, ">1 "...) // VERSION 1
+	if r.Time.IsZero() {
+		buf = append(buf, '-')
+	} else {
+		buf = r.Time.Round(0).AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	buf = append(buf, ' ')
+	buf = append(buf, h.fields...)
+
+	var sd structuredData
+	for _, ga := range h.attrs {
+		sd.add(ga.groups, ga.a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		sd.add(h.groups, a)
+		return true
+	})
+	buf = sd.append(buf, h.opts.DefaultID, h.opts.EnterpriseID)
+	if r.Message != "" {
+		buf = append(buf, ' ')
+		if !isASCII(r.Message) && utf8.ValidString(r.Message) {
+			buf = append(buf, "\ufeff"...) // BOM
+		}
+		buf = append(buf, r.Message...)
+	}
+	return h.w.write(buf, h.opts.OctetCounting)
+}
+
+func (w *writer) write(msg []byte, octetCounting bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.c != nil {
+		return w.c.write(msg, octetCounting)
+	}
+	if octetCounting {
+		msg = frame(msg)
+	}
+	_, err := w.w.Write(msg)
+	return err
+}
+
+func isASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// frame prefixes msg with its length, for octet-counting framing.
+func frame(msg []byte) []byte {
+	return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+}
+
+// structuredData collects the SD-ELEMENTs of a message.
+type structuredData struct {
+	elems []sdElement
+}
+
+type sdElement struct {
+	id     string
+	params []sdParam
+}
+
+type sdParam struct {
+	name, value string
+}
+
+// add adds the attribute a in the given groups.
+func (sd *structuredData) add(groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, a := range attrs {
+			sd.add(groups, a)
+		}
+		return
+	}
+	id, name := "", a.Key
+	if len(groups) > 0 {
+		id = groups[0]
+		name = strings.Join(append(groups[1:len(groups):len(groups)], a.Key), ".")
+	}
+	var value string
+	if a.Value.Kind() == slog.KindTime {
+		value = a.Value.Time().Format(time.RFC3339Nano)
+	} else {
+		value = a.Value.String()
+	}
+	for i := range sd.elems {
+		if sd.elems[i].id == id {
+			sd.elems[i].params = append(sd.elems[i].params, sdParam{name, value})
+			return
+		}
+	}
+	sd.elems = append(sd.elems, sdElement{id, []sdParam{{name, value}}})
+}
+
+// append appends the STRUCTURED-DATA to buf, naming the element of the
+// attributes outside groups defaultID.
+func (sd *structuredData) append(buf []byte, defaultID, enterpriseID string) []byte {
+	if len(sd.elems) == 0 {
+		return append(buf, '-')
+	}
+	for _, e := range sd.elems {
+		id := e.id
+		if id == "" {
+			id = defaultID
+		}
+		suffix := ""
+		if enterpriseID != "" {
+			suffix = "@" + enterpriseID
+		}
+		buf = append(buf, '[')
+		buf = appendSDName(buf, id, 32-len(suffix))
+		buf = append(buf, suffix...)
+		for _, p := range e.params {
+			buf = append(buf, ' ')
+			buf = appendSDName(buf, p.name, 32)
+			buf = append(buf, '=', '"')
+			buf = appendParamValue(buf, p.value)
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ']')
+	}
+	return buf
+}
+
+// appendSDName appends s as an SD-NAME of at most n characters: printable
+// US-ASCII characters other than '=', ' ', ']' and '"', which are replaced
+// with underscores.
+func appendSDName(buf []byte, s string, n int) []byte {
+	if s == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(s) && i < n; i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendParamValue appends s as a PARAM-VALUE, escaping '"', '\' and ']'
+// and replacing invalid UTF-8.
+func appendParamValue(buf []byte, s string) []byte {
+	for _, r := range s {
+		switch r {
+		case '"', '\\', ']':
+			buf = append(buf, '\\', byte(r))
+		default:
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,298 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+func TestHandler(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	for _, tt := range []struct {
+		name  string
+		opts  Options
+		level slog.Level
+		msg   string
+		attrs []slog.Attr
+		with  func(slog.Handler) slog.Handler
+		want  string
+	}{
+		{
+			name: "no attrs",
+			msg:  "hello",
+			want: "<14>1 2026-01-02T03:04:05.000006Z host app PID - - hello",
+		},
+		{
+			name:  "levels and facility",
+			opts:  Options{Facility: Local0, MsgID: "ID1"},
+			level: slog.LevelError,
+			msg:   "m",
+			want:  "<131>1 2026-01-02T03:04:05.000006Z host app PID ID1 - m",
+		},
+		{
+			name:  "groups",
+			msg:   "request",
+			attrs: []slog.Attr{slog.String("user", "gopher"), slog.Group("http", "method", "GET", slog.Group("req", "size", 10))},
+			want:  `<14>1 2026-01-02T03:04:05.000006Z host app PID - [attrs user="gopher"][http method="GET" req.size="10"] request`,
+		},
+		{
+			name:  "escaping",
+			msg:   "m",
+			attrs: []slog.Attr{slog.String("a b", `x"y\z]`)},
+			want:  `<14>1 2026-01-02T03:04:05.000006Z host app PID - [attrs a_b="x\"y\\z\]"] m`,
+		},
+		{
+			name:  "enterprise ID",
+			opts:  Options{EnterpriseID: "32473", DefaultID: "meta"},
+			msg:   "m",
+			attrs: []slog.Attr{slog.Int("a", 1), slog.Group("g", "b", 2)},
+			want:  `<14>1 2026-01-02T03:04:05.000006Z host app PID - [meta@32473 a="1"][g@32473 b="2"] m`,
+		},
+		{
+			name:  "with",
+			msg:   "m",
+			attrs: []slog.Attr{slog.Int("c", 3)},
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g").WithAttrs([]slog.Attr{slog.Int("b", 2)}).WithGroup("h")
+			},
+			want: `<14>1 2026-01-02T03:04:05.000006Z host app PID - [attrs a="1"][g b="2" h.c="3"] m`,
+		},
+		{
+			name:  "empty",
+			msg:   "",
+			attrs: []slog.Attr{{}, slog.Group("g")},
+			want:  "<14>1 2026-01-02T03:04:05.000006Z host app PID - -",
+		},
+		{
+			name: "UTF-8 message",
+			msg:  "héllo",
+			want: "<14>1 2026-01-02T03:04:05.000006Z host app PID - - \ufeffhéllo",
+		},
+		{
+			name: "non-UTF-8 message",
+			msg:  "h\xe9llo",
+			want: "<14>1 2026-01-02T03:04:05.000006Z host app PID - - h\xe9llo",
+		},
+		{
+			name: "octet counting",
+			opts: Options{OctetCounting: true},
+			msg:  "m",
+			want: fmt.Sprintf("%d <14>1 2026-01-02T03:04:05.000006Z host app %s - - m", 49+len(pid), pid),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.opts.Hostname = "host"
+			tt.opts.AppName = "app"
+			var h slog.Handler = NewHandler(&buf, &tt.opts)
+			if tt.with != nil {
+				h = tt.with(h)
+			}
+			r := slog.NewRecord(testTime, tt.level, tt.msg, 0)
+			r.AddAttrs(tt.attrs...)
+			if err := h.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Replace(tt.want, "PID", pid, 1)
+			if got := buf.String(); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestFacility(t *testing.T) {
+	r := slog.NewRecord(testTime, slog.LevelInfo, "m", 0)
+	var buf bytes.Buffer
+	if err := NewHandler(&buf, &Options{Facility: Local7}).Handle(context.Background(), r); err != nil {
+		t.Errorf("Handle with Local7: %v", err)
+	}
+	for _, f := range []Facility{Local7 + 1, -1} {
+		buf.Reset()
+		if err := NewHandler(&buf, &Options{Facility: f}).Handle(context.Background(), r); err == nil {
+			t.Errorf("Handle with facility %d succeeded, writing %q", f, buf.String())
+		}
+		if _, err := Dial("udp", "127.0.0.1:0", &Options{Facility: f}); err == nil {
+			t.Errorf("Dial with facility %d succeeded", f)
+		}
+	}
+}
+
+func TestHeaderField(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"", 10, "-"},
+		{"my host", 10, "my_host"},
+		{"héllo", 10, "h__llo"},
+		{"abcdef", 3, "abc"},
+	} {
+		if got := headerField(tt.s, tt.n); got != tt.want {
+			t.Errorf("headerField(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	h := NewHandler(&bytes.Buffer{}, &Options{Level: slog.LevelWarn})
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("Info enabled with Level Warn")
+	}
+	if !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("Warn not enabled with Level Warn")
+	}
+}
+
+func TestDialUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	h, err := Dial("udp", pc.LocalAddr().String(), &Options{Hostname: "host", AppName: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	slog.New(h).Info("hello", "a", 1)
+
+	pc.SetReadDeadline(time.Now().Add(10 * time.Second))
+	b := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b[:n])
+	if !strings.HasPrefix(got, "<14>1 ") || !strings.HasSuffix(got, ` [attrs a="1"] hello`) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDialTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	msgs := make(chan string)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			close(msgs)
+			return
+		}
+		defer c.Close()
+		r := bufio.NewReader(c)
+		for {
+			msg, err := readFramed(r)
+			if err != nil {
+				close(msgs)
+				return
+			}
+			msgs <- msg
+		}
+	}()
+
+	h, err := Dial("tcp", ln.Addr().String(), &Options{Hostname: "host", AppName: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(h)
+	l.Info("one")
+	l.Warn("two\nlines")
+	for _, want := range []string{" - - one", " - - two\nlines"} {
+		got, ok := <-msgs
+		if !ok {
+			t.Fatal("connection closed")
+		}
+		if !strings.HasSuffix(got, want) {
+			t.Errorf("got %q, want suffix %q", got, want)
+		}
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-msgs; ok {
+		t.Error("message after Close")
+	}
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "m", 0)); err != errClosed {
+		t.Errorf("Handle after Close: got %v, want %v", err, errClosed)
+	}
+}
+
+// readFramed reads a message framed by octet counting.
+func readFramed(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(s, " "))
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func TestDialUnixgram(t *testing.T) {
+	switch runtime.GOOS {
+	case "windows", "plan9", "js", "wasip1", "android", "ios":
+		t.Skipf("unixgram not supported on %s", runtime.GOOS)
+	}
+	path := filepath.Join(t.TempDir(), "log")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	h, err := Dial("unixgram", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	slog.New(h).WithGroup("g").Error("failed", "err", "boom")
+
+	pc.SetReadDeadline(time.Now().Add(10 * time.Second))
+	b := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b[:n]); !strings.HasPrefix(got, "<11>1 ") || !strings.HasSuffix(got, ` [g err="boom"] failed`) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDialError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if _, err := Dial("tcp", addr, nil); err == nil {
+		t.Error("Dial to a closed port succeeded")
+	}
+}
@@ -89,8 +89,8 @@ func (h *TextHandler) WithGroup(name string) Handler {
 //
 // Each call to Handle results in a single serialized call to
 // io.Writer.Write.
-func (h *TextHandler) Handle(_ context.Context, r Record) error {
-	return h.commonHandler.handle(r)
+func (h *TextHandler) Handle(ctx context.Context, r Record) error {
+	return h.commonHandler.handle(ctx, r)
 }
 
 func appendTextValue(s *handleState, v Value) error {
@@ -4,6 +4,8 @@
 
 package pprof
 
+import "encoding/protowire"
+
 // A protobuf is a simple protocol buffer encoder.
 type protobuf struct {
 	data []byte
@@ -12,21 +14,16 @@ type protobuf struct {
 }
 
 func (b *protobuf) varint(x uint64) {
-	for x >= 128 {
-		b.data = append(b.data, byte(x)|0x80)
-		x >>= 7
-	}
-	b.data = append(b.data, byte(x))
+	b.data = protowire.AppendVarint(b.data, x)
 }
 
 func (b *protobuf) length(tag int, len int) {
-	b.varint(uint64(tag)<<3 | 2)
+	b.data = protowire.AppendTag(b.data, protowire.Number(tag), protowire.BytesType)
 	b.varint(uint64(len))
 }
 
 func (b *protobuf) uint64(tag int, x uint64) {
-	// append varint to b.data
-	b.varint(uint64(tag)<<3 | 0)
+	b.data = protowire.AppendTag(b.data, protowire.Number(tag), protowire.VarintType)
 	b.varint(x)
 }
 
@@ -108,11 +105,7 @@ func (b *protobuf) stringOpt(tag int, x string) {
 }
 
 func (b *protobuf) bool(tag int, x bool) {
-	if x {
-		b.uint64(tag, 1)
-	} else {
-		b.uint64(tag, 0)
-	}
+	b.uint64(tag, protowire.EncodeBool(x))
 }
 
 func (b *protobuf) boolOpt(tag int, x bool) {